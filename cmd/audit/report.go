@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/audit"
+	"github.com/ory/keto/ketoctx"
+)
+
+const (
+	FlagNamespace  = "namespace"
+	FlagPermission = "permission"
+	FlagObject     = "object"
+	FlagMaxObjects = "max-objects"
+	FlagMaxDepth   = "max-depth"
+	FlagBatchSize  = "batch-size"
+	FlagOutput     = "output"
+
+	OutputCSV   = "csv"
+	OutputJSONL = "jsonl"
+)
+
+func newReportCmd(opts []ketoctx.Option) *cobra.Command {
+	var (
+		o      audit.Options
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report all subjects with a permission",
+		Long: `Report all pairs of object and subject ID with the permission, e.g. for periodic access reviews.
+
+The command reads the relation tuples from the configured database in batches. Every object that has a relation tuple
+with the permission is reported, unless the objects are given with --object. Subject sets are followed up to the max
+depth, the via column holds the subject set through which the subject has the permission.`,
+		Example: "keto audit report --namespace document --permission view --output csv > view-access.csv",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var (
+				emit  func(*audit.Entry) error
+				flush func() error
+			)
+			switch output {
+			case OutputCSV:
+				emit, flush = csvWriter(cmd.OutOrStdout())
+			case OutputJSONL:
+				emit, flush = jsonLinesWriter(cmd.OutOrStdout())
+			default:
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Unknown output format %q, expected one of [%s %s].\n", output, OutputCSV, OutputJSONL)
+				return cmdx.FailSilently(cmd)
+			}
+
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			err = audit.Report(cmd.Context(), reg, o, emit)
+			if err == nil {
+				err = flush()
+			}
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not create the report: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&o.Namespace, FlagNamespace, "n", "", "The namespace of the objects.")
+	flags.StringVar(&o.Permission, FlagPermission, "", "The relation to report.")
+	flags.StringSliceVar(&o.Objects, FlagObject, nil, "Only report these objects.")
+	flags.IntVar(&o.MaxObjects, FlagMaxObjects, 0, "The maximum number of reported objects. Zero means no limit.")
+	flags.IntVarP(&o.MaxDepth, FlagMaxDepth, "d", 0, "The maximum depth of followed subject sets. Defaults to and is capped by the configured max read depth.")
+	flags.IntVar(&o.BatchSize, FlagBatchSize, audit.DefaultBatchSize, "The number of relation tuples read per query.")
+	flags.StringVarP(&output, FlagOutput, "o", OutputCSV, fmt.Sprintf("The output format, one of [%s %s].", OutputCSV, OutputJSONL))
+	_ = cmd.MarkFlagRequired(FlagNamespace)
+	_ = cmd.MarkFlagRequired(FlagPermission)
+
+	return cmd
+}
+
+func csvWriter(w io.Writer) (emit func(*audit.Entry) error, flush func() error) {
+	cw := csv.NewWriter(w)
+	// The writer is buffered, write errors are returned by Error after flushing.
+	_ = cw.Write([]string{"object", "subject", "via"})
+
+	emit = func(e *audit.Entry) error {
+		return cw.Write([]string{e.Object, e.Subject, e.Via})
+	}
+	flush = func() error {
+		cw.Flush()
+		return cw.Error()
+	}
+	return emit, flush
+}
+
+func jsonLinesWriter(w io.Writer) (emit func(*audit.Entry) error, flush func() error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	return func(e *audit.Entry) error {
+		return enc.Encode(e)
+	}, bw.Flush
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/audit"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestReportCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "document"}}))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "document", Object: "design", Relation: "view", Subject: &relationtuple.SubjectID{ID: "alice"}},
+		&relationtuple.InternalRelationTuple{Namespace: "document", Object: "roadmap, 2023", Relation: "view", Subject: &relationtuple.SubjectID{ID: "bob"}},
+	))
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newReportCmd(nil)
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+	args := []string{"--" + FlagNamespace, "document", "--" + FlagPermission, "view"}
+
+	t.Run("case=csv", func(t *testing.T) {
+		stdOut := c.ExecNoErr(t, args...)
+		assert.Equal(t, "object,subject,via\ndesign,alice,\n\"roadmap, 2023\",bob,\n", stdOut)
+	})
+
+	t.Run("case=json lines", func(t *testing.T) {
+		stdOut := c.ExecNoErr(t, append(args, "--"+FlagOutput, OutputJSONL)...)
+		lines := strings.Split(strings.TrimSpace(stdOut), "\n")
+		require.Len(t, lines, 2)
+		var e audit.Entry
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+		assert.Equal(t, audit.Entry{Object: "roadmap, 2023", Subject: "bob"}, e)
+	})
+
+	t.Run("case=rejects unknown formats", func(t *testing.T) {
+		stdErr := c.ExecExpectedErr(t, append(args, "--"+FlagOutput, "xml")...)
+		assert.Contains(t, stdErr, `Unknown output format "xml"`)
+	})
+}
@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/ketoctx"
+)
+
+func newAuditCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Commands to review who has access",
+	}
+	cmd.AddCommand(newReportCmd(opts))
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	parent.AddCommand(newAuditCmd(opts))
+}
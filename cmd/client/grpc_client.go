@@ -13,6 +13,10 @@ import (
 	"github.com/spf13/pflag"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	// enables client-side health checking
+	_ "google.golang.org/grpc/health"
+	// registers the xds resolver, so that remotes like xds:///keto-read can be used
+	_ "google.golang.org/grpc/xds"
 )
 
 type contextKeys string
@@ -25,6 +29,14 @@ const (
 	EnvWriteRemote = "KETO_WRITE_REMOTE"
 
 	ContextKeyTimeout contextKeys = "timeout"
+	// ContextKeyWithoutHealthCheck disables the client-side health checking,
+	// e.g. to connect to a replica that is not serving to check its status.
+	ContextKeyWithoutHealthCheck contextKeys = "without_health_check"
+
+	// DefaultServiceConfig balances requests over all addresses the remote
+	// resolves to and only uses replicas that report to be serving. Resolvers
+	// that provide their own service config, like xDS, take precedence.
+	DefaultServiceConfig = `{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":""}}`
 )
 
 func getRemote(cmd *cobra.Command, flagRemote, envRemote string) (remote string) {
@@ -60,12 +72,21 @@ func Conn(ctx context.Context, remote string) (*grpc.ClientConn, error) {
 		timeout = d
 	}
 
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock(),
+		grpc.WithDefaultServiceConfig(DefaultServiceConfig),
+	}
+	if disabled, _ := ctx.Value(ContextKeyWithoutHealthCheck).(bool); disabled {
+		opts = append(opts, grpc.WithDisableHealthCheck())
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	return grpc.DialContext(ctx, remote, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock(), grpc.WithDisableHealthCheck())
+	return grpc.DialContext(ctx, remote, opts...)
 }
 
 func RegisterRemoteURLFlags(flags *pflag.FlagSet) {
-	flags.String(FlagReadRemote, "127.0.0.1:4466", "Remote address of the read API endpoint.")
-	flags.String(FlagWriteRemote, "127.0.0.1:4467", "Remote address of the write API endpoint.")
+	flags.String(FlagReadRemote, "127.0.0.1:4466", "Remote address of the read API endpoint. Use the dns:/// or xds:/// scheme to load-balance over all replicas, or unix:<path> for a unix socket.")
+	flags.String(FlagWriteRemote, "127.0.0.1:4467", "Remote address of the write API endpoint. Use the dns:/// or xds:/// scheme to load-balance over all replicas, or unix:<path> for a unix socket.")
 }
@@ -2,12 +2,21 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"net"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	grpcHealthV1 "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/ory/keto/internal/namespace"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 )
 
 func TestGetRemote(t *testing.T) {
@@ -60,3 +69,51 @@ func TestGetRemote(t *testing.T) {
 		assert.Contains(t, stdErr.String(), "falling back")
 	})
 }
+
+func TestConn(t *testing.T) {
+	ts := NewTestServer(t, ReadServer, []*namespace.Namespace{{Name: "n", ID: 1}}, func() *cobra.Command { return &cobra.Command{} })
+	t.Cleanup(func() { ts.Shutdown(t) })
+
+	for _, remote := range []string{ts.Addr, "dns:///" + ts.Addr} {
+		t.Run("remote="+remote, func(t *testing.T) {
+			conn, err := Conn(context.Background(), remote)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = conn.Close() })
+
+			resp, err := grpcHealthV1.NewHealthClient(conn).Check(context.Background(), &grpcHealthV1.HealthCheckRequest{
+				Service: rts.ReadService_ServiceDesc.ServiceName,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, grpcHealthV1.HealthCheckResponse_SERVING, resp.Status)
+		})
+	}
+}
+
+func TestConnHealthCheck(t *testing.T) {
+	hs := health.NewServer()
+	hs.SetServingStatus("", grpcHealthV1.HealthCheckResponse_NOT_SERVING)
+	s := grpc.NewServer()
+	grpcHealthV1.RegisterHealthServer(s, hs)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	ctx := context.WithValue(context.Background(), ContextKeyTimeout, 500*time.Millisecond)
+
+	t.Run("case=does not connect to a replica that is not serving", func(t *testing.T) {
+		_, err := Conn(ctx, l.Addr().String())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("case=connects without health checking", func(t *testing.T) {
+		conn, err := Conn(context.WithValue(ctx, ContextKeyWithoutHealthCheck, true), l.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		resp, err := grpcHealthV1.NewHealthClient(conn).Check(context.Background(), &grpcHealthV1.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, grpcHealthV1.HealthCheckResponse_NOT_SERVING, resp.Status)
+	})
+}
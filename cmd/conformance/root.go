@@ -0,0 +1,103 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/client"
+	"github.com/ory/keto/internal/conformance"
+)
+
+const (
+	FlagNamespace = "namespace"
+	FlagCategory  = "category"
+)
+
+type reportTable struct {
+	*conformance.Report
+}
+
+func (t *reportTable) Header() []string {
+	return []string{"CASE", "RESULT", "DURATION", "ERROR"}
+}
+
+func (t *reportTable) Table() [][]string {
+	rows := make([][]string, len(t.Results))
+	for i, res := range t.Results {
+		result := "FAIL"
+		if res.Passed {
+			result = "PASS"
+		}
+		rows[i] = []string{res.Case, result, res.Duration.String(), res.Error}
+	}
+	return rows
+}
+
+func (t *reportTable) Interface() interface{} {
+	return t.Report
+}
+
+func (t *reportTable) Len() int {
+	return len(t.Results)
+}
+
+func newConformanceCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "conformance",
+		Short: "Validate the API behavior of a running deployment",
+	}
+}
+
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the conformance suite against the remotes",
+		Long: fmt.Sprintf(`Run the conformance suite (version %s) against the read and write APIs. It covers the semantics of check and expand,
+pagination of list requests, and the error codes of malformed requests. Use it to validate custom builds, proxies
+in front of Keto, and upgrades.
+
+The cases write relation tuples to the namespace given by --%s, which has to be configured on the deployment.
+Every run uses objects and subjects unique to it, and deletes its relation tuples afterwards.`, conformance.SuiteVersion, FlagNamespace),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			read, err := client.GetReadConn(cmd)
+			if err != nil {
+				return err
+			}
+			defer read.Close()
+			write, err := client.GetWriteConn(cmd)
+			if err != nil {
+				return err
+			}
+			defer write.Close()
+
+			report := conformance.Run(cmd.Context(), read, write, &conformance.Options{
+				Namespace:  flagx.MustGetString(cmd, FlagNamespace),
+				Categories: flagx.MustGetStringSlice(cmd, FlagCategory),
+			})
+			cmdx.PrintTable(cmd, &reportTable{report})
+			if failed := report.Failed(); failed > 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%d of %d cases of the conformance suite %s failed.\n", failed, len(report.Results), report.SuiteVersion)
+				return cmdx.FailSilently(cmd)
+			}
+			return nil
+		},
+	}
+
+	client.RegisterRemoteURLFlags(cmd.Flags())
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	cmd.Flags().String(FlagNamespace, "conformance", "the namespace the cases write to")
+	cmd.Flags().StringSlice(FlagCategory, nil, fmt.Sprintf("only run the cases of these categories; any of {%s, %s, %s, %s}",
+		conformance.CategoryCheck, conformance.CategoryExpand, conformance.CategoryPagination, conformance.CategoryErrors))
+
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command) {
+	c := newConformanceCmd()
+	c.AddCommand(newRunCmd())
+	parent.AddCommand(c)
+}
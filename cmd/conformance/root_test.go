@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/cmd/client"
+	"github.com/ory/keto/internal/conformance"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+)
+
+func listen(t *testing.T, s *grpc.Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(l) //nolint:errcheck
+	t.Cleanup(s.Stop)
+	return l.Addr().String()
+}
+
+func TestRunCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "conformance"}}))
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newRunCmd()
+		},
+		PersistentArgs: []string{
+			"--" + client.FlagReadRemote, listen(t, reg.ReadGRPCServer(ctx)),
+			"--" + client.FlagWriteRemote, listen(t, reg.WriteGRPCServer(ctx)),
+			"--" + cmdx.FlagFormat, string(cmdx.FormatJSON),
+		},
+		Ctx: ctx,
+	}
+
+	t.Run("case=passes", func(t *testing.T) {
+		var report conformance.Report
+		require.NoError(t, json.Unmarshal([]byte(c.ExecNoErr(t)), &report))
+		assert.Equal(t, conformance.SuiteVersion, report.SuiteVersion)
+		assert.NotEmpty(t, report.Results)
+		assert.Zero(t, report.Failed())
+	})
+
+	t.Run("case=fails if the namespace is not configured", func(t *testing.T) {
+		stdOut, stdErr, err := c.Exec(nil, "--"+FlagNamespace, "unknown", "--"+FlagCategory, conformance.CategoryCheck)
+		require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+
+		var report conformance.Report
+		require.NoError(t, json.Unmarshal([]byte(stdOut), &report))
+		assert.Equal(t, len(report.Results), report.Failed())
+		assert.Contains(t, stdErr, "cases of the conformance suite")
+	})
+}
@@ -0,0 +1,110 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/ketoctx"
+)
+
+type (
+	indexAdvisor interface {
+		AdvisedIndexes(ctx context.Context) (*sql.IndexReport, error)
+	}
+	indexTable struct {
+		*sql.IndexReport
+	}
+)
+
+func (t *indexTable) Header() []string {
+	return []string{"INDEX", "QUERY SHAPE", "STATUS", "SCANS"}
+}
+
+func (t *indexTable) Table() [][]string {
+	rows := make([][]string, len(t.Indexes))
+	for i, idx := range t.Indexes {
+		status, scans := "missing", "n/a"
+		if idx.Present {
+			status = "present"
+		}
+		if idx.Scans != nil {
+			scans = strconv.FormatInt(*idx.Scans, 10)
+		}
+		rows[i] = []string{idx.Name, idx.QueryShape, status, scans}
+	}
+	return rows
+}
+
+func (t *indexTable) Interface() interface{} {
+	return t.IndexReport
+}
+
+func (t *indexTable) Len() int {
+	return len(t.Indexes)
+}
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose the deployment",
+	}
+}
+
+func newIndexesCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "indexes",
+		Short: "Report which advised indexes are missing",
+		Long: `Report which of the advised indexes for common list queries exist in the configured database.
+
+The advised indexes are created by optional migrations that are applied with ` + "`keto migrate up`" + ` if ` + "`" + config.KeyMigrationAdvisedIndexes + "`" + `
+is enabled. On PostgreSQL, the report also shows how often each index and, sequentially, the relation tuple table were
+scanned since the statistics were last reset. Many sequential scans point to list queries without a matching index.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			advisor, ok := reg.Persister().(indexAdvisor)
+			if !ok {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "The storage backend does not use SQL indexes.")
+				return cmdx.FailSilently(cmd)
+			}
+			report, err := advisor.AdvisedIndexes(cmd.Context())
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not inspect the indexes: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			cmdx.PrintTable(cmd, &indexTable{report})
+			if report.SequentialScans != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The relation tuple table was scanned sequentially %d times.\n", *report.SequentialScans)
+			}
+			for _, idx := range report.Indexes {
+				if !idx.Present {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Enable %s and run `keto migrate up` to create the missing indexes.\n", config.KeyMigrationAdvisedIndexes)
+					break
+				}
+			}
+			return nil
+		},
+	}
+
+	cmdx.RegisterFormatFlags(cmd.Flags())
+
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	doctor := newDoctorCmd()
+	doctor.AddCommand(newIndexesCmd(opts))
+	parent.AddCommand(doctor)
+}
@@ -0,0 +1,57 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/persistence/sql"
+)
+
+func TestIndexesCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newIndexesCmd(nil)
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+
+	report := func(t *testing.T) (*sql.IndexReport, string) {
+		stdOut, stdErr, err := c.Exec(nil, "--"+cmdx.FlagFormat, string(cmdx.FormatJSON))
+		require.NoError(t, err)
+		var r sql.IndexReport
+		require.NoError(t, json.Unmarshal([]byte(stdOut), &r))
+		return &r, stdErr
+	}
+
+	t.Run("case=reports missing indexes", func(t *testing.T) {
+		r, stdErr := report(t)
+		require.Len(t, r.Indexes, 3)
+		for _, idx := range r.Indexes {
+			assert.False(t, idx.Present, idx.Name)
+		}
+		assert.Contains(t, stdErr, "migrations.advised_indexes")
+	})
+
+	t.Run("case=reports present indexes", func(t *testing.T) {
+		up, err := sql.AdvisedIndexMigrations.ReadFile("migrations/advised/20221224100000000000_advised_list_indexes.up.sql")
+		require.NoError(t, err)
+		require.NoError(t, reg.Persister().(*sql.Persister).Connection(ctx).RawQuery(string(up)).Exec())
+
+		r, stdErr := report(t)
+		for _, idx := range r.Indexes {
+			assert.True(t, idx.Present, idx.Name)
+			assert.Nil(t, idx.Scans)
+		}
+		assert.Empty(t, stdErr)
+	})
+}
@@ -0,0 +1,61 @@
+package graphstats
+
+import (
+	"fmt"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/graphstats"
+	"github.com/ory/keto/ketoctx"
+)
+
+const (
+	FlagSampleSize = "sample-size"
+	FlagTop        = "top"
+)
+
+func newGraphStatsCmd(opts []ketoctx.Option) *cobra.Command {
+	var o graphstats.Options
+
+	cmd := &cobra.Command{
+		Use:   "graph-stats",
+		Short: "Report statistics of the relation tuple graph",
+		Long: `Report statistics of the relation tuple graph, e.g. to choose cache and materialization strategies.
+
+The command samples the oldest relation tuples of every namespace from the configured database. For the sampled
+subject sets, it reports the depth distribution, the fan-out percentiles, and the most-referenced subject sets.
+Subject sets are only followed through sampled tuples, so increase the sample size for deep graphs.
+
+The same report is served at ` + "`" + graphstats.RouteBase + "`" + ` on the write API.`,
+		Example: "keto graph-stats --sample-size 100000 --format json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			report, err := graphstats.Collect(cmd.Context(), reg, o)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not collect the graph statistics: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			cmdx.PrintJSONAble(cmd, report)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&o.SampleSize, FlagSampleSize, graphstats.DefaultSampleSize, "The maximum number of relation tuples sampled per namespace.")
+	flags.IntVar(&o.Top, FlagTop, graphstats.DefaultTop, "The number of most-referenced subject sets reported per namespace.")
+	cmdx.RegisterJSONFormatFlags(flags)
+
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	parent.AddCommand(newGraphStatsCmd(opts))
+}
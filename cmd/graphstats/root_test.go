@@ -0,0 +1,49 @@
+package graphstats
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/graphstats"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestGraphStatsCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "doc"}}))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "doc", Object: "d", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice"}},
+		&relationtuple.InternalRelationTuple{Namespace: "doc", Object: "d", Relation: "view", Subject: &relationtuple.SubjectSet{Namespace: "doc", Object: "d", Relation: "owner"}},
+	))
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newGraphStatsCmd(nil)
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+
+	t.Run("format=default", func(t *testing.T) {
+		stdOut := c.ExecNoErr(t)
+		assert.Contains(t, stdOut, "doc: sampled 2 relation tuples\n  depth: 1=1 2=1\n")
+		assert.Contains(t, stdOut, "most referenced:\n    doc:d#owner 1\n")
+	})
+
+	t.Run("format=json", func(t *testing.T) {
+		var report graphstats.Report
+		require.NoError(t, json.Unmarshal([]byte(c.ExecNoErr(t, "--"+FlagSampleSize, "1", "--"+cmdx.FlagFormat, string(cmdx.FormatJSON))), &report))
+		require.Len(t, report.Namespaces, 1)
+		assert.Equal(t, 1, report.Namespaces[0].SampledTuples)
+		assert.True(t, report.Namespaces[0].Truncated)
+	})
+}
@@ -14,7 +14,27 @@ import (
 )
 
 func NewRegistry(cmd *cobra.Command, opts []ketoctx.Option) (driver.Registry, error) {
-	reg, err := driver.NewDefaultRegistry(cmd.Context(), cmd.Flags(), false, opts...)
+	return newRegistry(cmd, false, opts)
+}
+
+// NewMigratedRegistry applies all pending migrations before it initializes the
+// registry.
+func NewMigratedRegistry(cmd *cobra.Command, opts []ketoctx.Option) (driver.Registry, error) {
+	reg, err := newRegistry(cmd, true, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reg.MigrateUp(cmd.Context()); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply migrations: %+v\n", err)
+		return nil, cmdx.FailSilently(cmd)
+	}
+
+	return reg, nil
+}
+
+func newRegistry(cmd *cobra.Command, withoutNetwork bool, opts []ketoctx.Option) (driver.Registry, error) {
+	reg, err := driver.NewDefaultRegistry(cmd.Context(), cmd.Flags(), withoutNetwork, opts...)
 	if errors.Is(err, persistence.ErrNetworkMigrationsMissing) {
 		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Migrations were not applied yet, please apply them first.")
 		return nil, cmdx.FailSilently(cmd)
@@ -0,0 +1,197 @@
+package loadgen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/loadgen"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/ketoctx"
+)
+
+const (
+	FlagDepth              = "depth"
+	FlagFanout             = "fanout"
+	FlagNamespaces         = "namespaces"
+	FlagUsers              = "users"
+	FlagFanoutDistribution = "fanout-distribution"
+	FlagPopularity         = "popularity"
+	FlagMaxTuples          = "max-tuples"
+	FlagSeed               = "seed"
+	FlagNamespacePrefix    = "namespace-prefix"
+	FlagOutput             = "output"
+	FlagProfile            = "profile"
+
+	writeBatchSize = 1000
+)
+
+func newGraphCmd(opts []ketoctx.Option) *cobra.Command {
+	var (
+		o                              loadgen.GraphOptions
+		fanoutDistribution, popularity string
+		output, profile                string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Generate nested group and document hierarchies",
+		Long: `Generate nested group and document hierarchies.
+
+The first namespace holds nested groups with users as members. All other namespaces hold folders with documents as leaves,
+which inherit their viewers and editors from the parent folder and are shared with groups and users.
+The number of children is drawn from the fanout distribution, and users and groups are picked by the popularity distribution.
+The "zipf" distribution is heavy-tailed: most nodes have few children and few users and groups are very popular.
+
+The same flags and seed always generate the same relation tuples. Without a seed, a random one is used and printed.
+Use --profile to generate one of the named data sets, which always have the same options and seed. They are meant
+to compare the performance of different Keto versions on identical data.
+
+The relation tuples are written directly to the configured database, unless --output is set. The output file can be imported
+with "keto relation-tuple create". In both cases, the server has to be configured with the printed namespaces.`,
+		Example: "keto loadgen graph --depth 8 --fanout 20 --namespaces 5 --max-tuples 1000000\n" +
+			"keto loadgen graph --profile gdrive-like --output gdrive-like.json",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			o.FanoutDistribution, o.Popularity = loadgen.Distribution(fanoutDistribution), loadgen.Distribution(popularity)
+			if profile != "" {
+				for _, f := range []string{FlagDepth, FlagFanout, FlagNamespaces, FlagUsers, FlagFanoutDistribution, FlagPopularity, FlagMaxTuples, FlagSeed, FlagNamespacePrefix} {
+					if cmd.Flags().Changed(f) {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The flag --%s cannot be combined with --%s, profiles always generate the same data.\n", f, FlagProfile)
+						return cmdx.FailSilently(cmd)
+					}
+				}
+				p, err := loadgen.GetProfile(profile)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+				o = p.Options
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Using the profile %s: %s\n", p.Name, p.Description)
+			} else if !cmd.Flags().Changed(FlagSeed) {
+				o.Seed = time.Now().UnixNano()
+			}
+			if err := o.Validate(); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Invalid options: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Using the seed %d\n", o.Seed)
+
+			var (
+				emit  func(*relationtuple.InternalRelationTuple) error
+				flush func() error
+			)
+			if output != "" {
+				out := cmd.OutOrStdout()
+				if output != "-" {
+					f, err := os.Create(output)
+					if err != nil {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not create the output file: %s\n", err)
+						return cmdx.FailSilently(cmd)
+					}
+					defer f.Close()
+					out = f
+				}
+				emit, flush = jsonArrayWriter(out)
+			} else {
+				reg, err := helpers.NewRegistry(cmd, opts)
+				if err != nil {
+					return err
+				}
+				if err := reg.Config(ctx).Set(config.KeyNamespaces, o.NamespaceDefinitions()); err != nil {
+					return err
+				}
+
+				batch := make([]*relationtuple.InternalRelationTuple, 0, writeBatchSize)
+				flush = func() error {
+					if len(batch) == 0 {
+						return nil
+					}
+					err := reg.RelationTupleManager().WriteRelationTuples(ctx, batch...)
+					batch = batch[:0]
+					return err
+				}
+				emit = func(r *relationtuple.InternalRelationTuple) error {
+					batch = append(batch, r)
+					if len(batch) == writeBatchSize {
+						return flush()
+					}
+					return nil
+				}
+			}
+
+			n, err := loadgen.GenerateGraph(ctx, o, emit)
+			if err == nil {
+				err = flush()
+			}
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not generate the relation tuples: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			nn, err := json.MarshalIndent(o.NamespaceDefinitions(), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Generated %d relation tuples. Configure the following namespaces to use them:\n%s\n", n, nn)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&o.Depth, FlagDepth, 4, "The number of levels of the group and folder hierarchies.")
+	flags.IntVar(&o.Fanout, FlagFanout, 5, "The mean number of children of a folder or group, and of members of a group.")
+	flags.IntVar(&o.Namespaces, FlagNamespaces, 2, "The number of namespaces. The first one holds groups, all others documents.")
+	flags.IntVar(&o.Users, FlagUsers, 1000, "The number of distinct users.")
+	flags.StringVar(&fanoutDistribution, FlagFanoutDistribution, string(loadgen.DistributionUniform), fmt.Sprintf("The distribution of the number of children, one of %v.", loadgen.Distributions))
+	flags.StringVar(&popularity, FlagPopularity, string(loadgen.DistributionZipf), fmt.Sprintf("The distribution by which users and groups are picked, one of %v.", loadgen.Distributions))
+	flags.IntVar(&o.MaxTuples, FlagMaxTuples, 100000, "The maximum number of relation tuples, split evenly among the namespaces.")
+	flags.Int64Var(&o.Seed, FlagSeed, 0, "The seed of the random generator. Defaults to a random seed.")
+	flags.StringVar(&o.NamespacePrefix, FlagNamespacePrefix, "loadgen-", "The prefix of the generated namespace names.")
+	flags.StringVar(&profile, FlagProfile, "", fmt.Sprintf("Generate a named data set with fixed options and seed, one of %v.", loadgen.ProfileNames()))
+	flags.StringVarP(&output, FlagOutput, "o", "", "Write the relation tuples as JSON to this file instead of the database. Use `-` for STD_OUT.")
+
+	return cmd
+}
+
+// jsonArrayWriter streams the relation tuples as a JSON array, so that large
+// data sets do not have to be kept in memory.
+func jsonArrayWriter(w io.Writer) (emit func(*relationtuple.InternalRelationTuple) error, flush func() error) {
+	bw := bufio.NewWriter(w)
+	first := true
+
+	emit = func(r *relationtuple.InternalRelationTuple) error {
+		sep := ",\n"
+		if first {
+			sep, first = "[\n", false
+		}
+		enc, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, _ = bw.WriteString(sep)
+		_, err = bw.Write(enc)
+		return err
+	}
+	flush = func() error {
+		end := "\n]\n"
+		if first {
+			end = "[]\n"
+		}
+		if _, err := bw.WriteString(end); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+	return emit, flush
+}
@@ -0,0 +1,82 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestGraphCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newGraphCmd(nil)
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+	args := []string{"--" + FlagSeed, "7", "--" + FlagMaxTuples, "150", "--" + FlagNamespaces, "3"}
+
+	t.Run("case=writes to a file", func(t *testing.T) {
+		stdOut, stdErr, err := c.Exec(nil, append(args, "--"+FlagOutput, "-")...)
+		require.NoError(t, err, stdErr)
+		assert.Contains(t, stdErr, "Using the seed 7")
+		assert.Contains(t, stdErr, "Generated 150 relation tuples")
+		assert.Contains(t, stdErr, `"name": "loadgen-documents-2"`)
+
+		var tuples []*relationtuple.InternalRelationTuple
+		require.NoError(t, json.Unmarshal([]byte(stdOut), &tuples))
+		assert.Len(t, tuples, 150)
+
+		again, _, err := c.Exec(nil, append(args, "--"+FlagOutput, "-")...)
+		require.NoError(t, err)
+		assert.Equal(t, stdOut, again)
+	})
+
+	t.Run("case=writes to the database", func(t *testing.T) {
+		_, stdErr, err := c.Exec(nil, args...)
+		require.NoError(t, err, stdErr)
+
+		total := 0
+		for _, ns := range []string{"loadgen-groups", "loadgen-documents-1", "loadgen-documents-2"} {
+			tuples, next, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: ns}, x.WithSize(1000))
+			require.NoError(t, err)
+			assert.Empty(t, next)
+			total += len(tuples)
+		}
+		assert.Equal(t, 150, total)
+	})
+
+	t.Run("case=rejects invalid options", func(t *testing.T) {
+		stdErr := c.ExecExpectedErr(t, "--"+FlagPopularity, "normal")
+		assert.Contains(t, stdErr, `unknown distribution "normal"`)
+	})
+}
+
+func TestGraphCmdProfile(t *testing.T) {
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newGraphCmd(nil)
+		},
+		Ctx: context.Background(),
+	}
+
+	t.Run("case=rejects generator flags", func(t *testing.T) {
+		stdErr := c.ExecExpectedErr(t, "--"+FlagProfile, "iot-fleet", "--"+FlagSeed, "1")
+		assert.Contains(t, stdErr, "--seed cannot be combined with --profile")
+	})
+
+	t.Run("case=rejects unknown profiles", func(t *testing.T) {
+		stdErr := c.ExecExpectedErr(t, "--"+FlagProfile, "unknown")
+		assert.Contains(t, stdErr, `unknown profile "unknown"`)
+	})
+}
@@ -0,0 +1,20 @@
+package loadgen
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/ketoctx"
+)
+
+func newLoadgenCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "loadgen",
+		Short: "Generate synthetic data sets for load testing",
+	}
+	cmd.AddCommand(newGraphCmd(opts))
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	parent.AddCommand(newLoadgenCmd(opts))
+}
@@ -1,15 +1,19 @@
 package migrate
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
+	"github.com/gobuffalo/pop/v6"
 	"github.com/ory/x/cmdx"
 	"github.com/ory/x/flagx"
 	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/persistence/sql"
 	"github.com/ory/keto/ketoctx"
 )
 
@@ -18,7 +22,9 @@ func newDownCmd(opts []ketoctx.Option) *cobra.Command {
 		Use:   "down <steps>",
 		Short: "Migrate the database down",
 		Long: "Migrate the database down a specific amount of steps.\n" +
-			"Pass 0 steps to fully migrate down.",
+			"Pass 0 steps to fully migrate down.\n" +
+			"The migrations that would run are analyzed beforehand. If any of them deletes data, e.g. by dropping a table or column, " +
+			"the command refuses to run unless the `--" + FlagAllowDataLoss + "` flag is set.",
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			steps, err := strconv.ParseInt(args[0], 0, 0)
@@ -36,18 +42,25 @@ func newDownCmd(opts []ketoctx.Option) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			c, err := reg.PopConnection(cmd.Context())
+			if err != nil {
+				return err
+			}
 
-			return BoxDown(cmd, mb, int(steps))
+			return BoxDown(cmd, mb, c, int(steps))
 		},
 	}
 
 	RegisterYesFlag(cmd.Flags())
+	cmd.Flags().Bool(FlagAllowDataLoss, false, "Run down migrations even if they delete data")
 	cmdx.RegisterFormatFlags(cmd.Flags())
 
 	return cmd
 }
 
-func BoxDown(cmd *cobra.Command, mb *popx.MigrationBox, steps int) error {
+// BoxDown rolls back the given number of migrations of the box. It fails if
+// another process is migrating the database at the same time.
+func BoxDown(cmd *cobra.Command, mb *popx.MigrationBox, c *pop.Connection, steps int) error {
 	s, err := mb.Status(cmd.Context())
 	if err != nil {
 		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not get migration status: %+v\n", err)
@@ -55,12 +68,34 @@ func BoxDown(cmd *cobra.Command, mb *popx.MigrationBox, steps int) error {
 	}
 	cmdx.PrintTable(cmd, s)
 
+	plan, err := sql.AnalyzeDown(cmd.Context(), mb, steps)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not analyze the down migrations: %+v\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	if plan.Len() == 0 {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No migrations are applied, there is nothing to do.")
+		return nil
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), "The following migrations would be rolled back:")
+	cmdx.PrintTable(cmd, plan)
+
+	if plan.Destructive() && !flagx.MustGetBool(cmd, FlagAllowDataLoss) {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Some of the migrations delete data, see above. Create a back up and pass --%s to migrate down anyway.\n", FlagAllowDataLoss)
+		return cmdx.FailSilently(cmd)
+	}
+
 	if !flagx.MustGetBool(cmd, FlagYes) && !cmdx.AskForConfirmation("Do you really want to migrate down? This will delete data.", cmd.InOrStdin(), cmd.OutOrStdout()) {
 		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Migration aborted.")
 		return nil
 	}
 
-	if err := mb.Down(cmd.Context(), steps); err != nil {
+	if err := sql.TryWithMigrationLock(cmd.Context(), c, func(ctx context.Context) error {
+		return mb.Down(ctx, steps)
+	}); errors.Is(err, sql.ErrMigrationLocked) {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could apply down migrations: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	} else if err != nil {
 		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could apply down migrations: %+v\n", err)
 		return cmdx.FailSilently(cmd)
 	}
@@ -112,7 +112,7 @@ func TestMigrate(t *testing.T) {
 
 					t.Cleanup(func() {
 						// migrate all down
-						t.Logf("cleanup:\n%s\n", cmd.ExecNoErr(t, "down", "0", "--"+FlagYes))
+						t.Logf("cleanup:\n%s\n", cmd.ExecNoErr(t, "down", "0", "--"+FlagYes, "--"+FlagAllowDataLoss))
 					})
 
 					parts := strings.Split(stdOut, "Are you sure that you want to apply this migration?")
@@ -127,7 +127,7 @@ func TestMigrate(t *testing.T) {
 
 					t.Cleanup(func() {
 						// migrate all down
-						t.Logf("cleanup:\n%s\n", cmd.ExecNoErr(t, "down", "0", "--"+FlagYes))
+						t.Logf("cleanup:\n%s\n", cmd.ExecNoErr(t, "down", "0", "--"+FlagYes, "--"+FlagAllowDataLoss))
 					})
 
 					parts := strings.Split(out, "Applying migrations...")
@@ -163,7 +163,100 @@ func TestUpAndDown(t *testing.T) {
 			cf := dbx.ConfigFile(t, map[string]interface{}{config.KeyDSN: dsn.Conn})
 
 			t.Log(cmd.ExecNoErr(t, "up", "-c", cf, "--"+FlagYes))
-			t.Log(cmd.ExecNoErr(t, "down", "0", "-c", cf, "--"+FlagYes))
+			t.Log(cmd.ExecNoErr(t, "down", "0", "-c", cf, "--"+FlagYes, "--"+FlagAllowDataLoss))
 		})
 	}
 }
+
+func TestDownDataLoss(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			cmd := newMigrateCmd(nil)
+			configx.RegisterFlags(cmd.PersistentFlags())
+			return cmd
+		},
+		Ctx:            ctx,
+		PersistentArgs: []string{"-c", dbx.ConfigFile(t, map[string]interface{}{config.KeyDSN: dbx.GetSqlite(t, dbx.SQLiteFile).Conn})},
+	}
+	cmd.ExecNoErr(t, "up", "--"+FlagYes)
+
+	// The indices of the commit sequences only hold derived data.
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+
+	// The commit sequences are dropped.
+	stdOut, stdErr, err := cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_commit_sequences")
+	assert.Contains(t, stdOut, "drops the column deleted_seq of the table keto_relation_tuple_deletions")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The commit times of the deleted relation tuples are dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the column commit_time of the table keto_relation_tuple_deletions")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The record of the applied seed is dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_seeds")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The deletion log of the deltas is dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_relation_tuple_deletions")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The stored namespace configs are dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_namespace_configs")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The search indices only exist on PostgreSQL.
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+
+	// The indices for sorting only hold derived data.
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+
+	// The labels of the relation tuples are dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_relation_tuple_labels")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// The change notifications only exist on PostgreSQL.
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+
+	// The query cache only holds derived data, but its tables are dropped.
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "1", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_query_cache")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	cmd.ExecNoErr(t, "down", "1", "--"+FlagYes, "--"+FlagAllowDataLoss)
+
+	// Dropping indices keeps all data.
+	out := cmd.ExecNoErr(t, "down", "1", "--"+FlagYes)
+	assert.Contains(t, out, "The following migrations would be rolled back")
+
+	status := cmd.ExecNoErr(t, "status")
+	stdOut, stdErr, err = cmd.Exec(nil, "down", "0", "--"+FlagYes)
+	require.ErrorIs(t, err, cmdx.ErrNoPrintButFail)
+	assert.Contains(t, stdOut, "drops the table keto_relation_tuple_tombstones")
+	assert.Contains(t, stdErr, "--"+FlagAllowDataLoss)
+	assert.Equal(t, status, cmd.ExecNoErr(t, "status"))
+
+	cmd.ExecNoErr(t, "down", "0", "--"+FlagYes, "--"+FlagAllowDataLoss)
+	assertNoneApplied(t, cmd.ExecNoErr(t, "status"))
+}
@@ -2,19 +2,25 @@ package migrate
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/gobuffalo/pop/v6"
 	"github.com/ory/x/cmdx"
 	"github.com/ory/x/flagx"
 	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/persistence/sql"
 	"github.com/ory/keto/ketoctx"
+	"github.com/ory/keto/ketodriver"
 )
 
 const (
-	FlagYes = "yes"
+	FlagYes           = "yes"
+	FlagAllowDataLoss = "allow-data-loss"
 )
 
 func newUpCmd(opts []ketoctx.Option) *cobra.Command {
@@ -39,15 +45,49 @@ Before running this command on an existing database, create a back up!
 				return err
 			}
 
+			if _, ok := ketodriver.Lookup(reg.Config(ctx).DSN()); ok {
+				if err := reg.MigrateUp(ctx); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply migrations of the storage driver: %+v\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Successfully applied migrations of the storage driver!")
+				return nil
+			}
+
 			mb, err := reg.MigrationBox(ctx)
 			if err != nil {
 				return err
 			}
+			c, err := reg.PopConnection(ctx)
+			if err != nil {
+				return err
+			}
 
-			if err := BoxUp(cmd, mb); err != nil {
+			if err := BoxUp(cmd, mb, c); err != nil {
 				return err
 			}
 
+			// The region-local databases are migrated in a stable order.
+			mbs, err := reg.RegionMigrationBoxes(ctx)
+			if err != nil {
+				return err
+			}
+			conns, err := reg.RegionPopConnections(ctx)
+			if err != nil {
+				return err
+			}
+			regions := make([]string, 0, len(mbs))
+			for region := range mbs {
+				regions = append(regions, region)
+			}
+			sort.Strings(regions)
+			for _, region := range regions {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\nMigrating the database of region %q.\n", region)
+				if err := BoxUp(cmd, mbs[region], conns[region]); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		},
 	}
@@ -63,7 +103,9 @@ func RegisterYesFlag(flags *pflag.FlagSet) {
 	flags.BoolP(FlagYes, "y", false, "yes to all questions, no user input required")
 }
 
-func BoxUp(cmd *cobra.Command, mb *popx.MigrationBox) error {
+// BoxUp applies all pending migrations of the box. It fails if another process
+// is migrating the database at the same time.
+func BoxUp(cmd *cobra.Command, mb *popx.MigrationBox, c *pop.Connection) error {
 	_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Current status:")
 
 	s, err := mb.Status(cmd.Context())
@@ -85,7 +127,10 @@ func BoxUp(cmd *cobra.Command, mb *popx.MigrationBox) error {
 
 	_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Applying migrations...")
 
-	if err := mb.Up(cmd.Context()); err != nil {
+	if err := sql.TryWithMigrationLock(cmd.Context(), c, mb.Up); errors.Is(err, sql.ErrMigrationLocked) {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply migrations: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	} else if err != nil {
 		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply migrations: %+v\n", err)
 		return cmdx.FailSilently(cmd)
 	}
@@ -0,0 +1,123 @@
+package namespace
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/ketoctx"
+)
+
+func NewMigrateToDBCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-to-db",
+		Short: "Store the configured namespaces in the database",
+		Long: `Reads the namespaces from the configured files or the inline configuration,
+validates them, and stores them in the database as the first, active version
+of the namespace configs. Afterwards, point the namespaces of all instances to
+the database to serve and change them through the admin API.
+
+The command refuses to run if the database already holds namespace configs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+
+			s := reg.NamespaceConfigStore()
+			if s == nil {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "The storage backend does not support storing namespace configs.")
+				return cmdx.FailSilently(cmd)
+			}
+
+			nm, err := reg.Config(ctx).NamespaceManager()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the configured namespaces: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			nn, err := configuredNamespaces(cmd, nm)
+			if err != nil {
+				return err
+			}
+
+			history, err := s.NamespaceConfigHistory(ctx)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the stored namespace configs: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			if len(history) > 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The database already holds %d version(s) of the namespace configs. Use the admin API to add further versions.\n", len(history))
+				return cmdx.FailSilently(cmd)
+			}
+
+			v, err := s.AddNamespaceConfig(ctx, 0, nn)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not store the namespaces: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			if _, err := s.ActivateNamespaceConfig(ctx, v.Version); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not activate version %d of the namespace configs: %+v\n", v.Version, err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), `Stored %d namespace(s) as version %d of the namespace configs and activated it.
+
+To serve them from the database, change the namespaces in the config of all instances to
+
+  namespaces: %s
+
+or set the environment variable NAMESPACES=%s. Afterwards, the namespace files
+are not read anymore; change the namespaces through the admin API instead.
+`, len(v.Namespaces), v.Version, config.NamespacesDatabase, config.NamespacesDatabase)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// configuredNamespaces returns the namespaces of the manager. Namespace files
+// are validated again, because the watcher skips files it could not parse.
+func configuredNamespaces(cmd *cobra.Command, nm namespace.Manager) ([]*namespace.Namespace, error) {
+	var nn []*namespace.Namespace
+	switch m := nm.(type) {
+	case *config.DatabaseNamespaceManager:
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "The namespaces are already stored in the database.")
+		return nil, cmdx.FailSilently(cmd)
+	case *config.NamespaceWatcher:
+		for _, file := range m.NamespaceFiles() {
+			n, err := validateNamespaceBytes(cmd, file.Name, file.Contents, file.Parser)
+			if err != nil {
+				return nil, err
+			}
+			nn = append(nn, n)
+		}
+	default:
+		var err error
+		nn, err = nm.Namespaces(cmd.Context())
+		if err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the configured namespaces: %+v\n", err)
+			return nil, cmdx.FailSilently(cmd)
+		}
+	}
+
+	if len(nn) == 0 {
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "There are no namespaces configured.")
+		return nil, cmdx.FailSilently(cmd)
+	}
+	if err := namespace.Validate(nn); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The configured namespaces are invalid: %+v\n", err)
+		return nil, cmdx.FailSilently(cmd)
+	}
+	sort.Slice(nn, func(i, j int) bool {
+		return nn[i].ID < nn[j].ID
+	})
+	return nn, nil
+}
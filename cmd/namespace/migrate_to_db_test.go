@@ -0,0 +1,86 @@
+package namespace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+)
+
+func TestMigrateToDB(t *testing.T) {
+	setup := func(t *testing.T) (*cmdx.CommandExecuter, *driver.RegistryDefault) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		c := &cmdx.CommandExecuter{
+			New: func() *cobra.Command {
+				return NewMigrateToDBCmd(nil)
+			},
+			Ctx: context.WithValue(context.Background(), driver.RegistryContextKey, reg),
+		}
+		return c, reg
+	}
+
+	t.Run("case=stores namespace files", func(t *testing.T) {
+		ctx := context.Background()
+		c, reg := setup(t)
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"), []byte("id: 2\nname: b\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yml"), []byte("id: 1\nname: a\n"), 0600))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, "file://"+dir))
+
+		stdOut := c.ExecNoErr(t)
+		assert.Contains(t, stdOut, "namespaces: "+config.NamespacesDatabase)
+
+		v, err := reg.NamespaceConfigStore().GetNamespaceConfig(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, v.Active)
+		assert.Equal(t, []*namespace.Namespace{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, v.Namespaces)
+
+		assert.Contains(t, c.ExecExpectedErr(t), "already holds 1 version(s)")
+	})
+
+	t.Run("case=stores inline namespaces", func(t *testing.T) {
+		ctx := context.Background()
+		c, reg := setup(t)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 3, Name: "c"}}))
+
+		c.ExecNoErr(t)
+
+		version, err := reg.NamespaceConfigStore().ActiveNamespaceConfigVersion(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), version)
+	})
+
+	t.Run("case=rejects invalid namespaces", func(t *testing.T) {
+		ctx := context.Background()
+		c, reg := setup(t)
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yml"), []byte("id: 1\nname: a\n"), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"), []byte("id: not a number\n"), 0600))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, "file://"+dir))
+
+		assert.Contains(t, c.ExecExpectedErr(t), "b.yml")
+
+		vv, err := reg.NamespaceConfigStore().NamespaceConfigHistory(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, vv)
+	})
+
+	t.Run("case=namespaces already in the database", func(t *testing.T) {
+		ctx := context.Background()
+		c, reg := setup(t)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, config.NamespacesDatabase))
+
+		assert.Contains(t, c.ExecExpectedErr(t), "already stored in the database")
+	})
+}
@@ -29,7 +29,7 @@ func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
 	migrateCmd := NewMigrateCmd()
 	migrateCmd.AddCommand(NewMigrateUpCmd(), NewMigrateDownCmd(), NewMigrateStatusCmd(), NewMigrateLegacyCmd(opts))
 
-	rootCmd.AddCommand(migrateCmd, NewValidateCmd())
+	rootCmd.AddCommand(migrateCmd, NewValidateCmd(), NewMigrateToDBCmd(opts), NewTemplateCmd())
 
 	parent.AddCommand(rootCmd)
 }
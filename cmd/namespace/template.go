@@ -0,0 +1,43 @@
+package namespace
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/internal/orgs"
+)
+
+const (
+	FlagTemplateName = "name"
+	FlagTemplateID   = "id"
+
+	templateOrgHierarchy = "org-hierarchy"
+)
+
+func NewTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template " + templateOrgHierarchy,
+		Short: "Print the namespace definition of a common permission model",
+		Long: "Print the namespace definition of a common permission model, to be added to the namespaces of the config.\n" +
+			"The " + templateOrgHierarchy + " model structures organizations, teams, and projects as paths, with the roles viewer, editor, and admin that apply to the object they are bound on and everything below. " +
+			"Set orgs.namespace to its name to manage it through the organization API.",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{templateOrgHierarchy},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out, err := json.MarshalIndent(orgs.Template(int32(flagx.MustGetInt(cmd, FlagTemplateID)), flagx.MustGetString(cmd, FlagTemplateName)), "", "  ")
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not encode the namespace: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+			return nil
+		},
+	}
+	cmd.Flags().String(FlagTemplateName, "orgs", "The name of the namespace")
+	cmd.Flags().Int(FlagTemplateID, 0, "The ID of the namespace")
+	return cmd
+}
@@ -0,0 +1,30 @@
+package namespace
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateCmd(t *testing.T) {
+	cmd := cmdx.CommandExecuter{New: func() *cobra.Command {
+		cmd := validateCommand()
+		cmd.AddCommand(NewTemplateCmd())
+		return cmd
+	}}
+
+	t.Run("case=prints a valid namespace", func(t *testing.T) {
+		fn := filepath.Join(t.TempDir(), "orgs.json")
+		require.NoError(t, ioutil.WriteFile(fn, []byte(cmd.ExecNoErr(t, "template", "org-hierarchy", "--name", "tenants", "--id", "3")), fileMode))
+		cmd.ExecNoErr(t, "validate", fn)
+	})
+
+	t.Run("case=rejects unknown templates", func(t *testing.T) {
+		_, _, err := cmd.Exec(nil, "template", "unknown")
+		require.Error(t, err)
+	})
+}
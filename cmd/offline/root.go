@@ -0,0 +1,449 @@
+package offline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/objectstore"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/snapshot"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/ketoctx"
+	"github.com/ory/keto/ketooffline"
+)
+
+const (
+	FlagFile      = "file"
+	FlagSnapshot  = "snapshot"
+	FlagMaxDepth  = "max-depth"
+	FlagBatchSize = "batch-size"
+	FlagSince     = "since"
+	FlagDelta     = "delta"
+	FlagAt        = "at"
+)
+
+// writeOutput writes to the file of the --file flag, or to standard output.
+func writeOutput(cmd *cobra.Command, write func(io.Writer) error) error {
+	var out io.Writer = cmd.OutOrStdout()
+	if file := flagx.MustGetString(cmd, FlagFile); file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not create the file: %+v\n", err)
+			return cmdx.FailSilently(cmd)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(out); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not write the file: %+v\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	return nil
+}
+
+// export writes to object storage or a local directory if the --file flag is
+// such a location, or to writeOutput otherwise. Objects in locations ending
+// with a slash are named after their kind and time.
+func export(cmd *cobra.Command, c *config.Config, kind, contentType string, write func(io.Writer) error) error {
+	file := flagx.MustGetString(cmd, FlagFile)
+	if !objectstore.IsURL(file) && !strings.HasSuffix(file, "/") {
+		return writeOutput(cmd, write)
+	}
+
+	location, name := objectstore.Split(file)
+	if name == "" {
+		name = objectstore.Name(kind, time.Now(), ".pb.gz")
+	}
+	b, err := objectstore.Open(c, location)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not open the destination: %+v\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not write the file: %+v\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	if err := b.Put(cmd.Context(), name, buf.Bytes(), contentType); err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not upload the file: %+v\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s.\n", b.Location(name))
+	return nil
+}
+
+func newOfflineCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "offline",
+		Short: "Export snapshots and evaluate checks offline",
+	}
+}
+
+func newExportCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a snapshot for offline checks",
+		Long: `Export all relation tuples and the compiled namespaces from the configured database into a
+gzip-compressed protobuf snapshot. The snapshot can be checked with "keto offline check", or in Go
+programs with the github.com/ory/keto/ketooffline package, without access to Ory Keto, and
+restored into another database with "keto offline restore".
+
+If the database supports snapshot tokens, all relation tuples are read at one snapshot, which is
+recorded in the snapshot. Otherwise, writes during the export may be partially included.
+
+With --since, only the relation tuples deleted and written since the snapshot token of a snapshot
+or a previous delta are exported. Apply deltas with "keto offline apply".
+
+The file can also be an object storage location like s3://<bucket>/<prefix>, gs://<bucket>/<prefix>,
+or azblob://<container>/<prefix>, configured in "object_storage". If the file ends with a slash,
+the snapshot is written to snapshots/keto-snapshot-<time>.pb.gz below it, and deltas to
+deltas/keto-delta-<time>.pb.gz, so that lifecycle rules can expire them separately.`,
+		Example: `keto offline export --file snapshot.pb.gz
+keto offline export --since 1666000000000000000 --file delta.pb.gz
+keto offline export --file s3://my-bucket/keto/`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+
+			if since := flagx.MustGetString(cmd, FlagSince); since != "" {
+				d, err := snapshot.ExportDelta(ctx, reg, since)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not export the delta: %+v\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+				if err := export(cmd, reg.Config(ctx), "delta", snapshot.DeltaContentType, func(w io.Writer) error { return ketooffline.WriteDelta(w, d) }); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d deletion(s) and %d write(s) since the snapshot token %s.\n", len(d.Deleted), len(d.Written), since)
+				return nil
+			}
+
+			s, err := snapshot.Export(ctx, reg)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not export the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			if err := export(cmd, reg.Config(ctx), "snapshot", snapshot.ContentType, func(w io.Writer) error { return ketooffline.Write(w, s) }); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d relation tuple(s) of %d namespace(s).\n", len(s.RelationTuples), len(s.Namespaces))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(FlagFile, "f", "-", "The file or object storage location to write the snapshot to, - for standard output")
+	cmd.Flags().String(FlagSince, "", "Export a delta since this snapshot token instead of a full snapshot")
+
+	return cmd
+}
+
+func newRestoreCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the relation tuples of a snapshot",
+		Long: `Write the relation tuples of a snapshot exported with "keto offline export" into the configured
+database, e.g. to seed a staging environment or to recover from a backup.
+
+All namespaces of the snapshot have to be configured. The namespace configs of the snapshot are
+not restored. The command refuses to run if the database already holds relation tuples.`,
+		Example: "keto offline restore --file snapshot.pb.gz",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			batchSize := flagx.MustGetInt(cmd, FlagBatchSize)
+			if batchSize < 1 {
+				return fmt.Errorf("--%s has to be at least 1", FlagBatchSize)
+			}
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+
+			var in io.Reader = cmd.InOrStdin()
+			if file := flagx.MustGetString(cmd, FlagFile); file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not open the snapshot: %s\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+				defer f.Close()
+				in = f
+			}
+			s, err := ketooffline.Read(in)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			nm, err := reg.Config(ctx).NamespaceManager()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the configured namespaces: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			for _, n := range s.Namespaces {
+				if _, err := nm.GetNamespaceByName(ctx, n.Name); errorcode.Is(err, errorcode.NamespaceUnknown) {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The namespace %q of the snapshot is not configured.\n", n.Name)
+					return cmdx.FailSilently(cmd)
+				} else if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the configured namespaces: %+v\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+			}
+
+			for _, n := range s.Namespaces {
+				existing, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: n.Name}, x.WithSize(1))
+				if err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the relation tuples: %+v\n", err)
+					return cmdx.FailSilently(cmd)
+				}
+				if len(existing) > 0 {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The database already holds relation tuples in the namespace %q. Restore into an empty database instead.\n", n.Name)
+					return cmdx.FailSilently(cmd)
+				}
+			}
+
+			for start := 0; start < len(s.RelationTuples); start += batchSize {
+				end := start + batchSize
+				if end > len(s.RelationTuples) {
+					end = len(s.RelationTuples)
+				}
+				if err := reg.RelationTupleManager().TransactRelationTuples(ctx, s.RelationTuples[start:end], nil); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not write the relation tuples, %d of %d were restored: %+v\n", start, len(s.RelationTuples), err)
+					return cmdx.FailSilently(cmd)
+				}
+			}
+
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Restored %d relation tuple(s) of %d namespace(s).\n", len(s.RelationTuples), len(s.Namespaces))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(FlagFile, "f", "-", "The file to read the snapshot from, - for standard input")
+	cmd.Flags().Int(FlagBatchSize, 500, "The maximum number of relation tuples written in one transaction")
+
+	return cmd
+}
+
+func newApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <snapshot file> <delta file>...",
+		Short: "Apply deltas to a snapshot",
+		Long: `Apply deltas exported with "keto offline export --since" to a snapshot, in the given order. Each
+delta has to start at the snapshot token the snapshot is at after the previous one.
+
+The snapshot file is replaced with the updated snapshot, unless --file is set.`,
+		Example: "keto offline apply snapshot.pb.gz delta.pb.gz",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var s *ketooffline.Snapshot
+			if err := readFile(args[0], func(r io.Reader) (err error) {
+				s, err = ketooffline.Read(r)
+				return err
+			}); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			for _, file := range args[1:] {
+				var d *ketooffline.Delta
+				if err := readFile(file, func(r io.Reader) (err error) {
+					d, err = ketooffline.ReadDelta(r)
+					return err
+				}); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the delta %s: %+v\n", file, err)
+					return cmdx.FailSilently(cmd)
+				}
+				if err := s.Apply(d); err != nil {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply the delta %s: %s\n", file, err)
+					return cmdx.FailSilently(cmd)
+				}
+			}
+
+			if flagx.MustGetString(cmd, FlagFile) != "" {
+				if err := writeOutput(cmd, func(w io.Writer) error { return ketooffline.Write(w, s) }); err != nil {
+					return err
+				}
+			} else if err := replaceFile(args[0], s); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not write the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Applied %d delta(s), the snapshot is at the snapshot token %s.\n", len(args)-1, s.SnapshotToken)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(FlagFile, "f", "", "The file to write the updated snapshot to, - for standard output")
+
+	return cmd
+}
+
+func readFile(name string, read func(io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// replaceFile writes the snapshot next to the file first, so that the file
+// is not left half-written.
+func replaceFile(name string, s *ketooffline.Snapshot) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := ketooffline.Write(f, s); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+type checkOutput struct {
+	Allowed bool `json:"allowed"`
+}
+
+func (o *checkOutput) String() string {
+	if o.Allowed {
+		return "Allowed\n"
+	}
+	return "Denied\n"
+}
+
+// replay applies the deltas of the --delta flags to the snapshot, up to the
+// time of the --at flag if it is set.
+func replay(cmd *cobra.Command, s *ketooffline.Snapshot) error {
+	files := flagx.MustGetStringSlice(cmd, FlagDelta)
+	deltas := make([]*ketooffline.Delta, len(files))
+	for i, file := range files {
+		if err := readFile(file, func(r io.Reader) (err error) {
+			deltas[i], err = ketooffline.ReadDelta(r)
+			return err
+		}); err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not read the delta %s: %+v\n", file, err)
+			return cmdx.FailSilently(cmd)
+		}
+	}
+
+	at := flagx.MustGetString(cmd, FlagAt)
+	if at == "" {
+		for i, d := range deltas {
+			if err := s.Apply(d); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply the delta %s: %s\n", files[i], err)
+				return cmdx.FailSilently(cmd)
+			}
+		}
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not parse --%s, expected an RFC 3339 time like 2024-01-01T00:00:00Z: %s\n", FlagAt, err)
+		return cmdx.FailSilently(cmd)
+	}
+	n, err := s.Replay(t, deltas...)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not replay the deltas up to %s: %s\n", at, err)
+		return cmdx.FailSilently(cmd)
+	}
+	state, err := ketooffline.TokenTime(s.SnapshotToken)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not replay the deltas up to %s: %s\n", at, err)
+		return cmdx.FailSilently(cmd)
+	}
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Replayed %d of %d delta(s), checking the state at %s (snapshot token %s).\n", n, len(deltas), state.Format(time.RFC3339Nano), s.SnapshotToken)
+	return nil
+}
+
+func newCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check <subject> <relation> <namespace> <object>",
+		Short: "Check whether a subject has a relation on an object in a snapshot",
+		Long: `Check whether a subject has a relation on an object in a snapshot exported with "keto offline export".
+The subject is a subject ID, or a subject set in the form <namespace>:<object>#<relation>.
+
+Deltas exported with "keto offline export --since" are applied to the snapshot in the given order
+first. With --at, only the deltas up to that time are applied, to reconstruct who had access at a
+point in the past. Deltas are applied as a whole, so the check sees the state at the end of the last
+delta before that time; the more often deltas are exported, the closer it is to the requested time.`,
+		Example: `keto offline check alice view files report --snapshot snapshot.pb.gz
+keto offline check alice view files report --snapshot snapshot.pb.gz --delta delta-1.pb.gz --delta delta-2.pb.gz --at 2024-01-01T00:00:00Z`,
+		Args: cobra.ExactArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sub, err := relationtuple.SubjectFromString(args[0])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not parse the subject: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			f, err := os.Open(flagx.MustGetString(cmd, FlagSnapshot))
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not open the snapshot: %s\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			defer f.Close()
+			s, err := ketooffline.Read(f)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not load the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+			if err := replay(cmd, s); err != nil {
+				return err
+			}
+			e, err := ketooffline.New(cmd.Context(), s)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not load the snapshot: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			allowed, err := e.Check(cmd.Context(), &ketooffline.RelationTuple{
+				Namespace: args[2],
+				Object:    args[3],
+				Relation:  args[1],
+				Subject:   sub,
+			}, flagx.MustGetInt(cmd, FlagMaxDepth))
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not evaluate the check: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			cmdx.PrintJSONAble(cmd, &checkOutput{Allowed: allowed})
+			return nil
+		},
+	}
+
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	cmd.Flags().StringP(FlagSnapshot, "s", "", "The snapshot file")
+	cmd.Flags().StringSlice(FlagDelta, nil, "Delta files to apply to the snapshot first, in order")
+	cmd.Flags().String(FlagAt, "", "Only apply the deltas up to this RFC 3339 time, e.g. 2024-01-01T00:00:00Z")
+	cmd.Flags().IntP(FlagMaxDepth, "d", 0, "Maximum depth of the search tree. If the value is less than 1 or greater than the default max-depth then the default max-depth will be used instead.")
+	_ = cmd.MarkFlagRequired(FlagSnapshot)
+
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	root := newOfflineCmd()
+	root.AddCommand(newExportCmd(opts), newRestoreCmd(opts), newApplyCmd(), newCheckCmd())
+	parent.AddCommand(root)
+}
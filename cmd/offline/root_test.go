@@ -0,0 +1,187 @@
+package offline
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/ketooffline"
+)
+
+var nn = []*namespace.Namespace{{ID: 0, Name: "files"}, {ID: 1, Name: "groups"}}
+
+func setup(t *testing.T, nn []*namespace.Namespace) (*cmdx.CommandExecuter, *driver.RegistryDefault) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, nn))
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			root := &cobra.Command{}
+			RegisterCommandsRecursive(root, nil)
+			return root
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+	return c, reg
+}
+
+func writeTuples(t *testing.T, reg *driver.RegistryDefault, tuples ...string) {
+	for _, s := range tuples {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), rt))
+	}
+}
+
+func TestExportAndCheck(t *testing.T) {
+	c, reg := setup(t, nn)
+	writeTuples(t, reg, "files:report#view@groups:staff#member", "groups:staff#member@alice")
+
+	file := filepath.Join(t.TempDir(), "snapshot.pb.gz")
+
+	_, stdErr, err := c.Exec(nil, "offline", "export", "--file", file)
+	require.NoError(t, err, stdErr)
+	assert.Contains(t, stdErr, "Exported 2 relation tuple(s) of 2 namespace(s).")
+
+	assert.Equal(t, "Allowed\n", c.ExecNoErr(t, "offline", "check", "alice", "view", "files", "report", "--snapshot", file))
+	assert.Equal(t, "Denied\n", c.ExecNoErr(t, "offline", "check", "groups:staff#member", "view", "files", "other", "--snapshot", file))
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "check", "alice", "view", "files", "report", "--snapshot", file+".missing"), "Could not open the snapshot")
+}
+
+func TestExportToDirectory(t *testing.T) {
+	c, reg := setup(t, nn)
+	writeTuples(t, reg, "groups:staff#member@alice")
+	dir := t.TempDir()
+
+	_, stdErr, err := c.Exec(nil, "offline", "export", "--file", dir+"/")
+	require.NoError(t, err, stdErr)
+
+	files, err := filepath.Glob(filepath.Join(dir, "snapshots", "keto-snapshot-*.pb.gz"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Contains(t, stdErr, "Wrote "+files[0]+".")
+	assert.Equal(t, "Allowed\n", c.ExecNoErr(t, "offline", "check", "alice", "member", "groups", "staff", "--snapshot", files[0]))
+
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "export", "--file", filepath.Join(dir, "missing")+"/"), "Could not open the destination")
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	source, reg := setup(t, nn)
+	writeTuples(t, reg, "files:report#view@groups:staff#member", "groups:staff#member@alice", "groups:staff#member@bob")
+	file := filepath.Join(t.TempDir(), "snapshot.pb.gz")
+	_, stdErr, err := source.Exec(nil, "offline", "export", "--file", file)
+	require.NoError(t, err, stdErr)
+
+	t.Run("case=restores into an empty database", func(t *testing.T) {
+		c, target := setup(t, nn)
+		_, stdErr, err := c.Exec(nil, "offline", "restore", "--file", file, "--batch-size", "2")
+		require.NoError(t, err, stdErr)
+		assert.Contains(t, stdErr, "Restored 3 relation tuple(s) of 2 namespace(s).")
+
+		allowed, err := target.PermissionEngine().SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "files",
+			Object:    "report",
+			Relation:  "view",
+			Subject:   &relationtuple.SubjectID{ID: "bob"},
+		}, 0)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		assert.Contains(t, c.ExecExpectedErr(t, "offline", "restore", "--file", file), "already holds relation tuples")
+	})
+
+	t.Run("case=requires the namespaces", func(t *testing.T) {
+		c, target := setup(t, nn[:1])
+		assert.Contains(t, c.ExecExpectedErr(t, "offline", "restore", "--file", file), `The namespace "groups" of the snapshot is not configured.`)
+
+		rts, _, err := target.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "files"})
+		require.NoError(t, err)
+		assert.Empty(t, rts)
+	})
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	c, reg := setup(t, nn)
+	writeTuples(t, reg, "groups:staff#member@alice")
+	dir := t.TempDir()
+	snapshot, delta := filepath.Join(dir, "snapshot.pb.gz"), filepath.Join(dir, "delta.pb.gz")
+	_, stdErr, err := c.Exec(nil, "offline", "export", "--file", snapshot)
+	require.NoError(t, err, stdErr)
+	check := func(t *testing.T, subject string) string {
+		return c.ExecNoErr(t, "offline", "check", subject, "member", "groups", "staff", "--snapshot", snapshot)
+	}
+
+	token, err := reg.Persister().(persistence.SnapshotReader).SnapshotToken(ctx)
+	require.NoError(t, err)
+	require.NoError(t, reg.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "groups"}))
+	writeTuples(t, reg, "groups:staff#member@bob")
+	_, stdErr, err = c.Exec(nil, "offline", "export", "--since", token, "--file", delta)
+	require.NoError(t, err, stdErr)
+	assert.Contains(t, stdErr, "Exported 1 deletion(s) and 1 write(s)")
+
+	_, stdErr, err = c.Exec(nil, "offline", "apply", snapshot, delta)
+	require.NoError(t, err, stdErr)
+	assert.Equal(t, "Denied\n", check(t, "alice"))
+	assert.Equal(t, "Allowed\n", check(t, "bob"))
+
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "apply", snapshot, delta), "Could not apply the delta")
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "export", "--since", "not a token"), "Could not export the delta")
+}
+
+func TestCheckAt(t *testing.T) {
+	ctx := context.Background()
+	c, reg := setup(t, nn)
+	writeTuples(t, reg, "groups:staff#member@alice")
+	dir := t.TempDir()
+	snapshot, first, second := filepath.Join(dir, "snapshot.pb.gz"), filepath.Join(dir, "delta-1.pb.gz"), filepath.Join(dir, "delta-2.pb.gz")
+	export := func(t *testing.T, args ...string) string {
+		_, stdErr, err := c.Exec(nil, append([]string{"offline", "export"}, args...)...)
+		require.NoError(t, err, stdErr)
+		token, err := reg.Persister().(persistence.SnapshotReader).SnapshotToken(ctx)
+		require.NoError(t, err)
+		return token
+	}
+
+	token := export(t, "--file", snapshot)
+	writeTuples(t, reg, "groups:staff#member@bob")
+	token = export(t, "--since", token, "--file", first)
+	before, err := ketooffline.TokenTime(token)
+	require.NoError(t, err)
+	require.NoError(t, reg.RelationTupleManager().DeleteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+		Namespace: "groups", Object: "staff", Relation: "member", Subject: &relationtuple.SubjectID{ID: "alice"},
+	}))
+	export(t, "--since", token, "--file", second)
+
+	check := func(t *testing.T, subject string, args ...string) string {
+		stdOut, stdErr, err := c.Exec(nil, append([]string{"offline", "check", subject, "member", "groups", "staff", "--snapshot", snapshot, "--delta", first, "--delta", second}, args...)...)
+		require.NoError(t, err, stdErr)
+		return stdOut
+	}
+	assert.Equal(t, "Denied\n", check(t, "alice"))
+	assert.Equal(t, "Allowed\n", check(t, "bob"))
+
+	at := before.Format(time.RFC3339Nano)
+	assert.Equal(t, "Allowed\n", check(t, "alice", "--at", at))
+	assert.Equal(t, "Allowed\n", check(t, "bob", "--at", at))
+
+	_, stdErr, err := c.Exec(nil, "offline", "check", "alice", "member", "groups", "staff", "--snapshot", snapshot, "--delta", first, "--delta", second, "--at", at)
+	require.NoError(t, err, stdErr)
+	assert.Contains(t, stdErr, "Replayed 1 of 2 delta(s)")
+
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "check", "alice", "member", "groups", "staff", "--snapshot", snapshot, "--at", "2000-01-01T00:00:00Z"), "Could not replay the deltas")
+	assert.Contains(t, c.ExecExpectedErr(t, "offline", "check", "alice", "member", "groups", "staff", "--snapshot", snapshot, "--at", "yesterday"), "Could not parse --at")
+}
@@ -0,0 +1,63 @@
+package privacy
+
+import (
+	"fmt"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/ketoctx"
+)
+
+const FlagForce = "force"
+
+func newEraseSubjectCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "erase-subject <subject-id>",
+		Short: "Erase all relation tuples of a subject",
+		Long: `Erase all relation tuples of a subject, e.g. to comply with a GDPR erasure request.
+
+This deletes all relation tuples with the subject ID. If the subject ID has the form <namespace>:<object> and the namespace
+is configured, all relation tuples with a subject set of that object are deleted as well, regardless of the relation.
+In multi-primary mode, the deleted tuples leave tombstones so that replicas do not restore them. Tombstones only
+hold a hash of the tuple. Earlier deletions of the subject are purged from the deletion log as well, so that reads at
+earlier snapshots and deltas do not restore the tuples.
+
+The command prints an erasure report listing the deleted tuples. It is recommended to first run the command
+without the ` + "`--force`" + ` flag, which only reports the tuples that would be deleted.`,
+		Example: "keto privacy erase-subject user:alice --force --format json > erasure-report.json",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reg, err := helpers.NewRegistry(cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			eraser, ok := reg.Persister().(persistence.SubjectEraser)
+			if !ok {
+				_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "The storage driver does not support erasing subjects.")
+				return cmdx.FailSilently(cmd)
+			}
+
+			force := flagx.MustGetBool(cmd, FlagForce)
+			if !force {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "WARNING: This operation is not reversible. Please use the `--%s` flag to proceed.\n", FlagForce)
+			}
+			report, err := eraser.EraseSubject(cmd.Context(), args[0], !force)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not erase the subject: %+v\n", err)
+				return cmdx.FailSilently(cmd)
+			}
+
+			cmdx.PrintJSONAble(cmd, report)
+			return nil
+		},
+	}
+	cmd.Flags().Bool(FlagForce, false, "Erase the subject instead of only reporting what would be erased")
+	cmdx.RegisterJSONFormatFlags(cmd.Flags())
+
+	return cmd
+}
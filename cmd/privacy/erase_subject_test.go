@@ -0,0 +1,55 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestEraseSubjectCmd(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "doc"}}))
+	tuple := &relationtuple.InternalRelationTuple{Namespace: "doc", Object: "d", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "user:alice"}}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newEraseSubjectCmd(nil)
+		},
+		Ctx: context.WithValue(ctx, driver.RegistryContextKey, reg),
+	}
+	count := func(t *testing.T) int {
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "doc"})
+		require.NoError(t, err)
+		return len(res)
+	}
+
+	t.Run("case=reports without force", func(t *testing.T) {
+		stdOut, stdErr, err := c.Exec(nil, "user:alice")
+		require.NoError(t, err)
+		assert.Contains(t, stdErr, "--force")
+		assert.Contains(t, stdOut, "Would erase 1 relation tuples of the subject user:alice.\n  "+tuple.String())
+		assert.Equal(t, 1, count(t))
+	})
+
+	t.Run("case=erases with force", func(t *testing.T) {
+		var report persistence.ErasureReport
+		require.NoError(t, json.Unmarshal([]byte(c.ExecNoErr(t, "user:alice", "--"+FlagForce, "--"+cmdx.FlagFormat, string(cmdx.FormatJSON))), &report))
+		assert.Equal(t, "user:alice", report.Subject)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, []*relationtuple.InternalRelationTuple{tuple}, report.RelationTuples)
+		assert.Equal(t, 0, count(t))
+	})
+}
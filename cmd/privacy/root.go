@@ -0,0 +1,20 @@
+package privacy
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/ketoctx"
+)
+
+func newPrivacyCmd(opts []ketoctx.Option) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "privacy",
+		Short: "Commands to handle data privacy requests",
+	}
+	cmd.AddCommand(newEraseSubjectCmd(opts))
+	return cmd
+}
+
+func RegisterCommandsRecursive(parent *cobra.Command, opts []ketoctx.Option) {
+	parent.AddCommand(newPrivacyCmd(opts))
+}
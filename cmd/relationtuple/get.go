@@ -26,6 +26,7 @@ const (
 	FlagSubjectSet = "subject-set"
 	FlagRelation   = "relation"
 	FlagObject     = "object"
+	FlagLabel      = "label"
 	FlagPageSize   = "page-size"
 	FlagPageToken  = "page-token"
 )
@@ -36,6 +37,7 @@ func registerRelationTupleFlags(flags *pflag.FlagSet) {
 	flags.String(FlagSubjectSet, "", `Set the requested subject set; format: "namespace:object#relation"`)
 	flags.String(FlagRelation, "", "Set the requested relation")
 	flags.String(FlagObject, "", "Set the requested object")
+	flags.StringArray(FlagLabel, nil, `Set a label the requested relation tuples must have; format: "key=value"; can be repeated`)
 
 	flags.String(FlagSubject, "", "")
 	if err := flags.MarkHidden(FlagSubject); err != nil {
@@ -63,6 +65,15 @@ func readQueryFromFlags(cmd *cobra.Command) (*rts.ListRelationTuplesRequest_Quer
 		query.Subject = s.ToProto()
 	}
 
+	labels, err := cmd.Flags().GetStringArray(FlagLabel)
+	if err != nil {
+		return nil, err
+	}
+	query.Labels, err = relationtuple.ParseLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+
 	return query, nil
 }
 
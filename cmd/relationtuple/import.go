@@ -0,0 +1,160 @@
+package relationtuple
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ory/keto/cmd/client"
+	"github.com/ory/keto/internal/importer"
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+const (
+	FlagToken                 = "token"
+	FlagAPIURL                = "api-url"
+	FlagOrganizationNamespace = "organization-namespace"
+	FlagTeamNamespace         = "team-namespace"
+	FlagRepositoryNamespace   = "repository-namespace"
+	FlagDryRun                = "dry-run"
+
+	importBatchSize = 500
+)
+
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import relation tuples from source code hosting platforms",
+	}
+	cmd.AddCommand(newImportGitHubCmd(), newImportGitLabCmd())
+	return cmd
+}
+
+func newImportGitHubCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "github <organization>",
+		Short: "Import the members, teams, and repository collaborators of a GitHub organization",
+		Long: "Import the members, teams, and repository collaborators of a GitHub organization.\n" +
+			"The token is read from the `--token` flag or the GITHUB_TOKEN environment variable and needs read access to the organization's members, teams, and repositories.\n" +
+			"Imported relation tuples replace existing identical ones, so the import can be repeated. Permissions that were revoked on GitHub are not deleted.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, args[0], importer.NewGitHub(
+				&http.Client{Timeout: 30 * time.Second},
+				flagx.MustGetString(cmd, FlagAPIURL),
+				tokenFromFlagOrEnv(cmd, "GITHUB_TOKEN"),
+				namespacesFromFlags(cmd),
+			))
+		},
+	}
+	registerImportFlags(cmd.Flags(), importer.GitHubDefaultURL)
+
+	return cmd
+}
+
+func newImportGitLabCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gitlab <group>",
+		Short: "Import the members of a GitLab group, its subgroups, and projects",
+		Long: "Import the members of a GitLab group, its subgroups, and projects.\n" +
+			"The group is identified by its full path. The token is read from the `--token` flag or the GITLAB_TOKEN environment variable and needs the read_api scope.\n" +
+			"Imported relation tuples replace existing identical ones, so the import can be repeated. Permissions that were revoked on GitLab are not deleted.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, args[0], importer.NewGitLab(
+				&http.Client{Timeout: 30 * time.Second},
+				flagx.MustGetString(cmd, FlagAPIURL),
+				tokenFromFlagOrEnv(cmd, "GITLAB_TOKEN"),
+				namespacesFromFlags(cmd),
+			))
+		},
+	}
+	registerImportFlags(cmd.Flags(), importer.GitLabDefaultURL)
+
+	return cmd
+}
+
+func registerImportFlags(flags *pflag.FlagSet, defaultURL string) {
+	registerPackageFlags(flags)
+	flags.String(FlagToken, "", "The API token")
+	flags.String(FlagAPIURL, defaultURL, "The API URL, set it for self-hosted installations")
+	flags.String(FlagOrganizationNamespace, importer.DefaultNamespaces.Organizations, "The namespace of organizations and groups")
+	flags.String(FlagTeamNamespace, importer.DefaultNamespaces.Teams, "The namespace of teams")
+	flags.String(FlagRepositoryNamespace, importer.DefaultNamespaces.Repositories, "The namespace of repositories and projects")
+	flags.Bool(FlagDryRun, false, "Only print the relation tuples instead of writing them")
+}
+
+func tokenFromFlagOrEnv(cmd *cobra.Command, env string) string {
+	if t := flagx.MustGetString(cmd, FlagToken); t != "" {
+		return t
+	}
+	return os.Getenv(env)
+}
+
+func namespacesFromFlags(cmd *cobra.Command) importer.Namespaces {
+	return importer.Namespaces{
+		Organizations: flagx.MustGetString(cmd, FlagOrganizationNamespace),
+		Teams:         flagx.MustGetString(cmd, FlagTeamNamespace),
+		Repositories:  flagx.MustGetString(cmd, FlagRepositoryNamespace),
+	}
+}
+
+func runImport(cmd *cobra.Command, org string, i importer.Importer) error {
+	tuples, err := i.Import(cmd.Context(), org)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not import %s: %+v\n", org, err)
+		return cmdx.FailSilently(cmd)
+	}
+
+	if !flagx.MustGetBool(cmd, FlagDryRun) {
+		if err := writeImportedTuples(cmd, tuples); err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Error doing the request: %s\n", err)
+			return cmdx.FailSilently(cmd)
+		}
+	}
+
+	cmdx.PrintTable(cmd, relationtuple.NewRelationCollection(tuples))
+	return nil
+}
+
+// writeImportedTuples deletes and then inserts the tuples in batches, so that
+// repeated imports do not create duplicates.
+func writeImportedTuples(cmd *cobra.Command, tuples []*relationtuple.InternalRelationTuple) error {
+	conn, err := client.GetWriteConn(cmd)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	cl := rts.NewWriteServiceClient(conn)
+
+	transact := func(ctx context.Context, action rts.RelationTupleDelta_Action, batch []*relationtuple.InternalRelationTuple) error {
+		deltas := make([]*rts.RelationTupleDelta, len(batch))
+		for i, t := range batch {
+			deltas[i] = &rts.RelationTupleDelta{Action: action, RelationTuple: t.ToProto()}
+		}
+		_, err := cl.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{RelationTupleDeltas: deltas})
+		return err
+	}
+
+	for start := 0; start < len(tuples); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(tuples) {
+			end = len(tuples)
+		}
+		if err := transact(cmd.Context(), rts.RelationTupleDelta_ACTION_DELETE, tuples[start:end]); err != nil {
+			return err
+		}
+		if err := transact(cmd.Context(), rts.RelationTupleDelta_ACTION_INSERT, tuples[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,7 +21,8 @@ func RegisterCommandsRecursive(parent *cobra.Command) {
 
 	parent.AddCommand(relationCmd)
 
-	relationCmd.AddCommand(newGetCmd(), newCreateCmd(), newDeleteCmd(), newDeleteAllCmd(), newParseCmd())
+	relationCmd.AddCommand(newGetCmd(), newCreateCmd(), newDeleteCmd(), newDeleteAllCmd(), newParseCmd(), newImportCmd())
+	parent.AddCommand(newSyncCmd())
 }
 
 func registerPackageFlags(flags *pflag.FlagSet) {
@@ -0,0 +1,216 @@
+package relationtuple
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/flagx"
+	"github.com/spf13/cobra"
+
+	"github.com/ory/keto/cmd/client"
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+const (
+	FlagFrom      = "from"
+	FlagTo        = "to"
+	FlagToWrite   = "to-write"
+	FlagBatchSize = "batch-size"
+
+	syncActionInsert = "insert"
+	syncActionDelete = "delete"
+)
+
+func newSyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync --from <source read remote> --to <target read remote> --to-write <target write remote>",
+		Short: "Sync relation tuples from one Ory Keto instance to another",
+		Long: "Sync the relation tuples matching the query from one Ory Keto instance to another, e.g. to promote permission data from staging to production.\n" +
+			"Both instances are listed through their read APIs. Relation tuples that are missing on the target are inserted, and relation tuples that only exist on the target are deleted, so that the target matches the source afterwards. Labels are not compared.\n" +
+			"It is recommended to first run the command with the `--dry-run` flag to verify the changes.",
+		Args: cobra.NoArgs,
+		RunE: syncRelationTuples,
+	}
+
+	cmdx.RegisterFormatFlags(cmd.Flags())
+	registerRelationTupleFlags(cmd.Flags())
+	cmd.Flags().String(FlagFrom, "", "Remote address of the read API endpoint of the source")
+	cmd.Flags().String(FlagTo, "", "Remote address of the read API endpoint of the target")
+	cmd.Flags().String(FlagToWrite, "", "Remote address of the write API endpoint of the target")
+	cmd.Flags().Bool(FlagDryRun, false, "Only print the changes instead of applying them")
+	cmd.Flags().Int32(FlagPageSize, 1000, "The page size to list the relation tuples with")
+	cmd.Flags().Int(FlagBatchSize, 500, "The maximum number of changes applied in one transaction")
+
+	return cmd
+}
+
+func syncRelationTuples(cmd *cobra.Command, _ []string) error {
+	from, to, toWrite := flagx.MustGetString(cmd, FlagFrom), flagx.MustGetString(cmd, FlagTo), flagx.MustGetString(cmd, FlagToWrite)
+	dryRun := flagx.MustGetBool(cmd, FlagDryRun)
+	if from == "" || to == "" || (toWrite == "" && !dryRun) {
+		return fmt.Errorf("the flags --%s, --%s, and --%s are required, --%s only if not running with --%s", FlagFrom, FlagTo, FlagToWrite, FlagToWrite, FlagDryRun)
+	}
+	batchSize := flagx.MustGetInt(cmd, FlagBatchSize)
+	if batchSize < 1 {
+		return fmt.Errorf("--%s has to be at least 1", FlagBatchSize)
+	}
+
+	query, err := readQueryFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	pageSize, err := cmd.Flags().GetInt32(FlagPageSize)
+	if err != nil {
+		return err
+	}
+
+	source, err := listAllTuples(cmd, from, query, pageSize)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not list the relation tuples of the source: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	target, err := listAllTuples(cmd, to, query, pageSize)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not list the relation tuples of the target: %s\n", err)
+		return cmdx.FailSilently(cmd)
+	}
+	deltas := diffTuples(source, target)
+
+	if !dryRun && len(deltas) > 0 {
+		if err := applyDeltas(cmd.Context(), toWrite, deltas, batchSize); err != nil {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Could not apply the changes to the target: %s\n", err)
+			return cmdx.FailSilently(cmd)
+		}
+	}
+
+	cmdx.PrintTable(cmd, &syncOutput{Deltas: deltas})
+	return nil
+}
+
+// listAllTuples lists the relation tuples matching the query on all pages,
+// keyed by their string representation.
+func listAllTuples(cmd *cobra.Command, remote string, query *rts.ListRelationTuplesRequest_Query, pageSize int32) (map[string]*relationtuple.InternalRelationTuple, error) {
+	conn, err := client.Conn(cmd.Context(), remote)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	cl := rts.NewReadServiceClient(conn)
+
+	tuples := make(map[string]*relationtuple.InternalRelationTuple)
+	var page string
+	for {
+		resp, err := cl.ListRelationTuples(cmd.Context(), &rts.ListRelationTuplesRequest{
+			Query:     query,
+			PageSize:  pageSize,
+			PageToken: page,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, rt := range resp.RelationTuples {
+			it, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(rt)
+			if err != nil {
+				return nil, err
+			}
+			tuples[it.String()] = it
+		}
+		if resp.NextPageToken == "" {
+			return tuples, nil
+		}
+		page = resp.NextPageToken
+	}
+}
+
+// diffTuples returns the changes that make the target match the source,
+// sorted by relation tuple.
+func diffTuples(source, target map[string]*relationtuple.InternalRelationTuple) []*syncDelta {
+	deltas := []*syncDelta{}
+	for k, rt := range source {
+		if _, ok := target[k]; !ok {
+			deltas = append(deltas, &syncDelta{Action: syncActionInsert, key: k, RelationTuple: rt})
+		}
+	}
+	for k, rt := range target {
+		if _, ok := source[k]; !ok {
+			deltas = append(deltas, &syncDelta{Action: syncActionDelete, key: k, RelationTuple: rt})
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].key < deltas[j].key
+	})
+	return deltas
+}
+
+func applyDeltas(ctx context.Context, remote string, deltas []*syncDelta, batchSize int) error {
+	conn, err := client.Conn(ctx, remote)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	cl := rts.NewWriteServiceClient(conn)
+
+	for start := 0; start < len(deltas); start += batchSize {
+		end := start + batchSize
+		if end > len(deltas) {
+			end = len(deltas)
+		}
+		batch := make([]*rts.RelationTupleDelta, end-start)
+		for i, d := range deltas[start:end] {
+			action := rts.RelationTupleDelta_ACTION_INSERT
+			if d.Action == syncActionDelete {
+				action = rts.RelationTupleDelta_ACTION_DELETE
+			}
+			batch[i] = &rts.RelationTupleDelta{Action: action, RelationTuple: d.RelationTuple.ToProto()}
+		}
+		if _, err := cl.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{RelationTupleDeltas: batch}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type (
+	syncDelta struct {
+		Action        string                               `json:"action"`
+		RelationTuple *relationtuple.InternalRelationTuple `json:"relation_tuple"`
+
+		key string
+	}
+	syncOutput struct {
+		Deltas []*syncDelta `json:"deltas"`
+	}
+)
+
+func (o *syncOutput) Header() []string {
+	return append([]string{"ACTION"}, (&relationtuple.RelationCollection{}).Header()...)
+}
+
+func (o *syncOutput) Table() [][]string {
+	rows := make([][]string, len(o.Deltas))
+	for i, d := range o.Deltas {
+		rows[i] = append([]string{d.Action}, d.RelationTuple.Columns()...)
+	}
+	return rows
+}
+
+func (o *syncOutput) Interface() interface{} {
+	return o
+}
+
+func (o *syncOutput) Len() int {
+	return len(o.Deltas)
+}
+
+func (o *syncOutput) IDs() []string {
+	ids := make([]string, len(o.Deltas))
+	for i, d := range o.Deltas {
+		ids[i] = d.key
+	}
+	return ids
+}
+
+var _ cmdx.Table = (*syncOutput)(nil)
@@ -0,0 +1,113 @@
+package relationtuple
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/ory/x/cmdx"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestSyncCmd(t *testing.T) {
+	ctx := context.Background()
+
+	serve := func(t *testing.T, s *grpc.Server) string {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go func() { _ = s.Serve(l) }()
+		t.Cleanup(s.Stop)
+		return l.Addr().String()
+	}
+	instance := func(t *testing.T, tuples ...string) (driver.Registry, string, string) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "docs"}, {ID: 1, Name: "groups"}}))
+		for _, s := range tuples {
+			rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+			require.NoError(t, err)
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, rt))
+		}
+		return reg, serve(t, reg.ReadGRPCServer(ctx)), serve(t, reg.WriteGRPCServer(ctx))
+	}
+	tuples := func(t *testing.T, reg driver.Registry) []string {
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{})
+		require.NoError(t, err)
+		ss := make([]string, len(res))
+		for i, rt := range res {
+			ss[i] = rt.String()
+		}
+		return ss
+	}
+
+	_, from, _ := instance(t,
+		"docs:a#viewer@alice",
+		"docs:b#viewer@groups:staff#member",
+		"groups:staff#member@bob",
+	)
+
+	c := &cmdx.CommandExecuter{
+		New: func() *cobra.Command {
+			return newSyncCmd()
+		},
+		Ctx: ctx,
+	}
+	deltas := func(t *testing.T, stdOut string) []*syncDelta {
+		var out syncOutput
+		require.NoError(t, json.Unmarshal([]byte(stdOut), &out), stdOut)
+		return out.Deltas
+	}
+
+	t.Run("case=dry run does not apply changes", func(t *testing.T) {
+		target, to, _ := instance(t, "docs:a#viewer@alice", "docs:c#viewer@mallory")
+
+		dd := deltas(t, c.ExecNoErr(t, "--from", from, "--to", to, "--dry-run", "--format", "json"))
+		require.Len(t, dd, 3)
+		assert.Equal(t, syncActionInsert, dd[0].Action)
+		assert.Equal(t, "docs:b#viewer@groups:staff#member", dd[0].RelationTuple.String())
+		assert.Equal(t, syncActionDelete, dd[1].Action)
+		assert.Equal(t, "docs:c#viewer@mallory", dd[1].RelationTuple.String())
+		assert.Equal(t, syncActionInsert, dd[2].Action)
+
+		assert.ElementsMatch(t, []string{"docs:a#viewer@alice", "docs:c#viewer@mallory"}, tuples(t, target))
+	})
+
+	t.Run("case=applies the minimal changes", func(t *testing.T) {
+		target, to, toWrite := instance(t, "docs:a#viewer@alice", "docs:c#viewer@mallory")
+
+		c.ExecNoErr(t, "--from", from, "--to", to, "--to-write", toWrite, "--batch-size", "1")
+		assert.ElementsMatch(t, []string{
+			"docs:a#viewer@alice",
+			"docs:b#viewer@groups:staff#member",
+			"groups:staff#member@bob",
+		}, tuples(t, target))
+
+		assert.Empty(t, deltas(t, c.ExecNoErr(t, "--from", from, "--to", to, "--dry-run", "--format", "json")))
+	})
+
+	t.Run("case=scoped by the query", func(t *testing.T) {
+		target, to, toWrite := instance(t, "groups:admins#member@carol")
+
+		c.ExecNoErr(t, "--from", from, "--to", to, "--to-write", toWrite, "--namespace", "docs")
+		assert.ElementsMatch(t, []string{
+			"docs:a#viewer@alice",
+			"docs:b#viewer@groups:staff#member",
+			"groups:admins#member@carol",
+		}, tuples(t, target))
+	})
+
+	t.Run("case=requires the target write remote", func(t *testing.T) {
+		_, to, _ := instance(t)
+		_, stdErr, err := c.Exec(nil, "--from", from, "--to", to)
+		require.Error(t, err)
+		assert.Contains(t, stdErr, "--to-write")
+	})
+}
@@ -28,6 +28,9 @@ import (
 
 	"github.com/ory/keto/cmd/expand"
 
+	"github.com/ory/keto/cmd/loadgen"
+
+	"github.com/ory/keto/cmd/audit"
 	"github.com/ory/keto/cmd/check"
 
 	"github.com/ory/keto/cmd/server"
@@ -36,8 +39,13 @@ import (
 	"github.com/ory/x/cmdx"
 	"github.com/ory/x/configx"
 
+	"github.com/ory/keto/cmd/conformance"
+	"github.com/ory/keto/cmd/doctor"
+	"github.com/ory/keto/cmd/graphstats"
 	"github.com/ory/keto/cmd/migrate"
 	"github.com/ory/keto/cmd/namespace"
+	"github.com/ory/keto/cmd/offline"
+	"github.com/ory/keto/cmd/privacy"
 	"github.com/ory/keto/cmd/relationtuple"
 
 	"github.com/spf13/cobra"
@@ -58,6 +66,13 @@ func NewRootCmd(opts ...ketoctx.Option) *cobra.Command {
 	server.RegisterCommandsRecursive(cmd, opts)
 	check.RegisterCommandsRecursive(cmd)
 	expand.RegisterCommandsRecursive(cmd)
+	loadgen.RegisterCommandsRecursive(cmd, opts)
+	privacy.RegisterCommandsRecursive(cmd, opts)
+	graphstats.RegisterCommandsRecursive(cmd, opts)
+	audit.RegisterCommandsRecursive(cmd, opts)
+	doctor.RegisterCommandsRecursive(cmd, opts)
+	offline.RegisterCommandsRecursive(cmd, opts)
+	conformance.RegisterCommandsRecursive(cmd)
 	status.RegisterCommandRecursive(cmd)
 
 	cmd.AddCommand(cmdx.Version(&config.Version, &config.Commit, &config.Date))
@@ -15,12 +15,19 @@
 package server
 
 import (
+	"github.com/ory/x/flagx"
 	"github.com/spf13/cobra"
 
 	"github.com/ory/keto/cmd/helpers"
+	"github.com/ory/keto/internal/driver/config"
 	"github.com/ory/keto/ketoctx"
 )
 
+const (
+	FlagAutoMigrate = "auto-migrate"
+	FlagReadOnly    = "read-only"
+)
+
 // serveCmd represents the serve command
 func newServe(opts []ketoctx.Option) *cobra.Command {
 	cmd := &cobra.Command{
@@ -33,17 +40,40 @@ func newServe(opts []ketoctx.Option) *cobra.Command {
 ORY Keto can be configured using environment variables as well as a configuration file. For more information
 on configuration options, open the configuration documentation:
 
->> https://www.ory.sh/keto/docs/reference/configuration <<`,
+>> https://www.ory.sh/keto/docs/reference/configuration <<
+
+## Migrations
+
+With the --auto-migrate flag, pending migrations are applied before the server starts. While migrating, a lock is held
+in the database, so replicas that start at the same time wait for each other instead of applying the same migrations.
+Create a back up before upgrading Ory Keto with this flag!
+
+## Read-Only Mode
+
+With the --read-only flag, all writes are rejected while reads stay available, e.g. during migrations or when the DSN
+points at a replica. The mode can be toggled at runtime through the /admin/read-only endpoint of the write API.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			reg, err := helpers.NewRegistry(cmd, opts)
+			newRegistry := helpers.NewRegistry
+			if flagx.MustGetBool(cmd, FlagAutoMigrate) {
+				newRegistry = helpers.NewMigratedRegistry
+			}
+
+			reg, err := newRegistry(cmd, opts)
 			if err != nil {
 				return err
 			}
+			if flagx.MustGetBool(cmd, FlagReadOnly) {
+				if err := reg.Config(cmd.Context()).Set(config.KeyReadOnly, true); err != nil {
+					return err
+				}
+			}
 
 			return reg.ServeAllSQA(cmd)
 		},
 	}
 
+	cmd.Flags().Bool(FlagAutoMigrate, false, "Apply pending migrations before serving, see the migrations section above")
+	cmd.Flags().Bool(FlagReadOnly, false, "Reject all writes, see the read-only mode section above")
 	cmd.Flags().Bool("sqa-opt-out", false, "Disable anonymized telemetry reports - for more information please visit https://www.ory.sh/docs/ecosystem/sqa")
 
 	return cmd
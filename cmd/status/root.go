@@ -28,8 +28,9 @@ func newStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Get the status of the upstream Keto instance",
-		Long:  "Get a status report about the upstream Keto instance. Can also block until the service is healthy.",
-		Args:  cobra.ExactArgs(0),
+		Long: "Get a status report about the upstream Keto instance. Can also block until the service is healthy.\n\n" +
+			"Unlike the other commands, which only connect to instances that report to be serving, this command also connects to instances that are not serving (yet), in order to report their status.",
+		Args: cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var connect func(*cobra.Command) (*grpc.ClientConn, error)
 
@@ -44,6 +45,14 @@ func newStatusCmd() *cobra.Command {
 
 			loudPrinter := cmdx.NewLoudOutPrinter(cmd)
 
+			// All other commands dial with client-side health checking, so
+			// that requests are only balanced to replicas that are serving.
+			// This command reports the status itself, so it has to connect
+			// to a replica that is not serving as well. Otherwise, the dial
+			// would only succeed once the replica is serving, and the status
+			// could never be reported as NOT_SERVING.
+			cmd.SetContext(context.WithValue(cmd.Context(), cliclient.ContextKeyWithoutHealthCheck, true))
+
 			conn, err := connect(cmd)
 			for block && err != nil {
 				if !errors.Is(err, context.DeadlineExceeded) {
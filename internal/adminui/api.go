@@ -0,0 +1,125 @@
+package adminui
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	namespacesResponse struct {
+		Namespaces []*namespace.Namespace `json:"namespaces"`
+	}
+	checkResponse struct {
+		Allowed bool `json:"allowed"`
+		// The expand tree of the checked object and relation, explaining how the
+		// decision was reached.
+		Explanation *expand.Tree `json:"explanation"`
+	}
+)
+
+func (h *Handler) getNamespaces(w http.ResponseWriter, r *http.Request) {
+	nm, err := h.d.Config(r.Context()).NamespaceManager()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	nn, err := nm.Namespaces(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, &namespacesResponse{Namespaces: nn})
+}
+
+func (h *Handler) getRelationTuples(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query, err := (&relationtuple.RelationQuery{}).FromURLQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, herodot.ErrBadRequest.WithError(err.Error()))
+		return
+	}
+
+	paginationOpts := []x.PaginationOptionSetter{x.WithToken(q.Get("page_token"))}
+	if pageSize := q.Get("page_size"); pageSize != "" {
+		s, err := strconv.ParseInt(pageSize, 0, 0)
+		if err != nil {
+			h.d.Writer().WriteError(w, r, herodot.ErrBadRequest.WithError(err.Error()))
+			return
+		}
+		paginationOpts = append(paginationOpts, x.WithSize(int(s)))
+	}
+	order, err := x.ParseOrder(q.Get("order_by"), q.Get("order"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	paginationOpts = append(paginationOpts, order)
+
+	rels, nextPage, err := h.d.RelationTupleManager().GetRelationTuples(r.Context(), query, paginationOpts...)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, &relationtuple.GetResponse{
+		RelationTuples: rels,
+		NextPageToken:  nextPage,
+	})
+}
+
+func (h *Handler) getCheck(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	maxDepth, err := x.GetMaxDepthFromQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	tuple, err := (&relationtuple.InternalRelationTuple{}).FromURLQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	allowed, err := h.d.PermissionEngine().SubjectIsAllowed(r.Context(), tuple, maxDepth)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	explanation, err := h.d.ExpandEngine().BuildTree(r.Context(), tuple.DeriveSubject(), maxDepth)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, &checkResponse{
+		Allowed:     allowed,
+		Explanation: explanation,
+	})
+}
+
+func (h *Handler) getExpand(w http.ResponseWriter, r *http.Request) {
+	maxDepth, err := x.GetMaxDepthFromQuery(r.URL.Query())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	tree, err := h.d.ExpandEngine().BuildTree(r.Context(), (&relationtuple.SubjectSet{}).FromURLQuery(r.URL.Query()), maxDepth)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, tree)
+}
@@ -0,0 +1,119 @@
+package adminui
+
+import (
+	"crypto/subtle"
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		relationtuple.ManagerProvider
+		check.EngineProvider
+		expand.EngineProvider
+		config.Provider
+		x.LoggerProvider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase    = "/admin/ui"
+	APIRouteBase = RouteBase + "/api"
+)
+
+var (
+	//go:embed static/*
+	static embed.FS
+
+	ErrCredentialsMissing = herodot.ErrInternalServerError.WithError("the admin endpoints are enabled but no basic auth credentials are configured")
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		// can only happen if the embed directive is broken
+		panic(err)
+	}
+	files := http.StripPrefix(RouteBase, http.FileServer(http.FS(assets)))
+
+	r.GET(RouteBase, h.protect(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		http.Redirect(w, r, RouteBase+"/", http.StatusMovedPermanently)
+	}))
+	r.GET(RouteBase+"/*path", h.protect(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		switch p := ps.ByName("path"); {
+		case p == "/api/namespaces":
+			h.getNamespaces(w, r)
+		case p == "/api/relation-tuples":
+			h.getRelationTuples(w, r)
+		case p == "/api/check":
+			h.getCheck(w, r)
+		case p == "/api/expand":
+			h.getExpand(w, r)
+		default:
+			files.ServeHTTP(w, r)
+		}
+	}))
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// protect hides the UI when it is disabled and enforces basic auth otherwise.
+// Both checks are done per request because the config is contextualized.
+func (h *Handler) protect(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		c := h.d.Config(r.Context())
+		if !c.AdminUIEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !Authenticate(w, r, c, h.d.Writer(), "Ory Keto Admin UI") {
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+// Authenticate enforces the basic auth credentials of the admin UI, which
+// also protect other admin endpoints. It writes the error and returns false if
+// the request does not carry them.
+func Authenticate(w http.ResponseWriter, r *http.Request, c *config.Config, hw herodot.Writer, realm string) bool {
+	username, password := c.AdminUIBasicAuth()
+	if username == "" || password == "" {
+		hw.WriteError(w, r, ErrCredentialsMissing)
+		return false
+	}
+
+	u, p, ok := r.BasicAuth()
+	if !ok ||
+		subtle.ConstantTimeCompare([]byte(u), []byte(username)) != 1 ||
+		subtle.ConstantTimeCompare([]byte(p), []byte(password)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+		hw.WriteError(w, r, herodot.ErrUnauthorized.WithReason("Invalid admin credentials."))
+		return false
+	}
+	return true
+}
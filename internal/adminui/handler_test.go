@@ -0,0 +1,114 @@
+package adminui_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/adminui"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	nspace := &namespace.Namespace{Name: "admin ui", ID: 1}
+
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{nspace}))
+
+	r := httprouter.New()
+	adminui.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T, path string, withAuth bool) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		if withAuth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	t.Run("case=not found when disabled", func(t *testing.T) {
+		resp, _ := get(t, adminui.RouteBase+"/", true)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyAdminUIEnabled, true))
+
+	t.Run("case=refuses to serve without configured credentials", func(t *testing.T) {
+		resp, _ := get(t, adminui.RouteBase+"/", true)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set("serve.write.admin_ui.basic_auth", map[string]interface{}{
+		"username": "admin",
+		"password": "secret",
+	}))
+
+	t.Run("case=requires credentials", func(t *testing.T) {
+		resp, _ := get(t, adminui.RouteBase+"/", false)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+	})
+
+	t.Run("case=serves the UI", func(t *testing.T) {
+		resp, body := get(t, adminui.RouteBase+"/", true)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "Ory Keto Admin")
+	})
+
+	t.Run("case=lists namespaces", func(t *testing.T) {
+		resp, body := get(t, adminui.APIRouteBase+"/namespaces", true)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s", body)
+		assert.Equal(t, nspace.Name, gjson.GetBytes(body, "namespaces.0.name").String())
+	})
+
+	tuple := &relationtuple.InternalRelationTuple{
+		Namespace: nspace.Name,
+		Object:    "document",
+		Relation:  "view",
+		Subject:   &relationtuple.SubjectID{ID: "user"},
+	}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+
+	t.Run("case=searches relation tuples", func(t *testing.T) {
+		resp, body := get(t, adminui.APIRouteBase+"/relation-tuples?"+url.Values{"namespace": {nspace.Name}}.Encode(), true)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s", body)
+		assert.Equal(t, "user", gjson.GetBytes(body, "relation_tuples.0.subject_id").String())
+	})
+
+	t.Run("case=checks with explanation", func(t *testing.T) {
+		q, err := tuple.ToURLQuery()
+		require.NoError(t, err)
+
+		resp, body := get(t, adminui.APIRouteBase+"/check?"+q.Encode(), true)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s", body)
+		assert.True(t, gjson.GetBytes(body, "allowed").Bool())
+		assert.Equal(t, "user", gjson.GetBytes(body, "explanation.children.0.subject_id").String())
+	})
+
+	t.Run("case=expands", func(t *testing.T) {
+		resp, body := get(t, adminui.APIRouteBase+"/expand?"+tuple.DeriveSubject().ToURLQuery().Encode(), true)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "%s", body)
+		assert.Equal(t, "union", gjson.GetBytes(body, "type").String())
+	})
+}
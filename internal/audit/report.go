@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		relationtuple.ManagerProvider
+		config.Provider
+	}
+	Options struct {
+		Namespace string
+		// Permission is the relation to report.
+		Permission string
+		// Objects restricts the report to these objects. All objects with
+		// the permission are reported if it is empty.
+		Objects []string
+		// MaxObjects is the maximum number of reported objects, 0 means no
+		// limit.
+		MaxObjects int
+		// MaxDepth is the maximum depth of followed subject sets. It defaults
+		// to the configured max read depth, which also caps it.
+		MaxDepth int
+		// BatchSize is the number of relation tuples read per query.
+		BatchSize int
+	}
+	// Entry is a subject that has the permission on the object.
+	Entry struct {
+		Object  string `json:"object"`
+		Subject string `json:"subject"`
+		// Via is the subject set of the object's relation tuple through which
+		// the subject has the permission. It is empty if the subject was
+		// granted the permission directly.
+		Via string `json:"via"`
+	}
+)
+
+const DefaultBatchSize = 100
+
+func (o *Options) defaults(maxReadDepth int) error {
+	if o.Namespace == "" || o.Permission == "" {
+		return errors.New("the namespace and permission are required")
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.MaxDepth <= 0 || o.MaxDepth > maxReadDepth {
+		o.MaxDepth = maxReadDepth
+	}
+	return nil
+}
+
+// Report emits every pair of object and subject ID with the permission. The
+// objects are reported in the order of their first relation tuple with the
+// permission, every subject once per object.
+func Report(ctx context.Context, d dependencies, o Options, emit func(*Entry) error) error {
+	if err := o.defaults(d.Config(ctx).MaxReadDepth()); err != nil {
+		return err
+	}
+
+	objects := 0
+	reportObject := func(object string) (bool, error) {
+		if o.MaxObjects > 0 && objects >= o.MaxObjects {
+			return false, nil
+		}
+		objects++
+		return true, reportSubjects(ctx, d, o, object, emit)
+	}
+
+	if len(o.Objects) > 0 {
+		for _, object := range o.Objects {
+			if more, err := reportObject(object); err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	for page := ""; ; {
+		rels, next, err := d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: o.Namespace, Relation: o.Permission}, x.WithSize(o.BatchSize), x.WithToken(page))
+		if err != nil {
+			return err
+		}
+		for _, r := range rels {
+			if _, ok := seen[r.Object]; ok {
+				continue
+			}
+			seen[r.Object] = struct{}{}
+			if more, err := reportObject(r.Object); err != nil || !more {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		page = next
+	}
+}
+
+// reportSubjects walks the subject sets of the object breadth-first, so that
+// every subject is reported with the shortest path.
+func reportSubjects(ctx context.Context, d dependencies, o Options, object string, emit func(*Entry) error) error {
+	type set struct {
+		*relationtuple.SubjectSet
+		depth int
+		via   string
+	}
+
+	root := &relationtuple.SubjectSet{Namespace: o.Namespace, Object: object, Relation: o.Permission}
+	queue := []set{{SubjectSet: root, depth: 1}}
+	visited := map[string]struct{}{root.String(): {}}
+	reported := map[string]struct{}{}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for page := ""; ; {
+			rels, next, err := d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{
+				Namespace: current.Namespace,
+				Object:    current.Object,
+				Relation:  current.Relation,
+			}, x.WithSize(o.BatchSize), x.WithToken(page))
+			if errorcode.Is(err, errorcode.NamespaceUnknown) && current.SubjectSet != root {
+				// The subject set is in an unknown namespace.
+				break
+			} else if err != nil {
+				return err
+			}
+
+			for _, r := range rels {
+				switch s := r.Subject.(type) {
+				case *relationtuple.SubjectID:
+					if _, ok := reported[s.ID]; ok {
+						continue
+					}
+					reported[s.ID] = struct{}{}
+					if err := emit(&Entry{Object: object, Subject: s.ID, Via: current.via}); err != nil {
+						return err
+					}
+				case *relationtuple.SubjectSet:
+					if _, ok := visited[s.String()]; ok || current.depth >= o.MaxDepth {
+						continue
+					}
+					visited[s.String()] = struct{}{}
+					via := current.via
+					if via == "" {
+						via = s.String()
+					}
+					queue = append(queue, set{SubjectSet: s, depth: current.depth + 1, via: via})
+				}
+			}
+
+			if next == "" {
+				break
+			}
+			page = next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/audit"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "document", ID: 1}, {Name: "group", ID: 2}}))
+
+	engineers := &relationtuple.SubjectSet{Namespace: "group", Object: "engineers", Relation: "member"}
+	backend := &relationtuple.SubjectSet{Namespace: "group", Object: "backend", Relation: "member"}
+	tuple := func(namespace, object, relation string, s relationtuple.Subject) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{Namespace: namespace, Object: object, Relation: relation, Subject: s}
+	}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		tuple("document", "design", "view", &relationtuple.SubjectID{ID: "alice"}),
+		tuple("document", "design", "view", engineers),
+		tuple("document", "design", "edit", &relationtuple.SubjectID{ID: "mallory"}),
+		tuple("document", "roadmap", "view", backend),
+		tuple("group", "engineers", "member", &relationtuple.SubjectID{ID: "alice"}),
+		tuple("group", "engineers", "member", backend),
+		tuple("group", "backend", "member", &relationtuple.SubjectID{ID: "bob"}),
+	))
+
+	report := func(t *testing.T, o audit.Options) []audit.Entry {
+		var entries []audit.Entry
+		require.NoError(t, audit.Report(ctx, reg, o, func(e *audit.Entry) error {
+			entries = append(entries, *e)
+			return nil
+		}))
+		return entries
+	}
+
+	t.Run("case=reports all objects", func(t *testing.T) {
+		assert.Equal(t, []audit.Entry{
+			{Object: "design", Subject: "alice"},
+			{Object: "design", Subject: "bob", Via: engineers.String()},
+			{Object: "roadmap", Subject: "bob", Via: backend.String()},
+		}, report(t, audit.Options{Namespace: "document", Permission: "view", BatchSize: 1}))
+	})
+
+	t.Run("case=filters objects", func(t *testing.T) {
+		assert.Equal(t, []audit.Entry{
+			{Object: "roadmap", Subject: "bob", Via: backend.String()},
+		}, report(t, audit.Options{Namespace: "document", Permission: "view", Objects: []string{"roadmap"}}))
+
+		assert.Len(t, report(t, audit.Options{Namespace: "document", Permission: "view", MaxObjects: 1}), 2)
+	})
+
+	t.Run("case=respects the max depth", func(t *testing.T) {
+		assert.Equal(t, []audit.Entry{
+			{Object: "design", Subject: "alice"},
+		}, report(t, audit.Options{Namespace: "document", Permission: "view", Objects: []string{"design"}, MaxDepth: 1}))
+	})
+
+	t.Run("case=requires namespace and permission", func(t *testing.T) {
+		assert.Error(t, audit.Report(ctx, reg, audit.Options{Namespace: "document"}, nil))
+	})
+}
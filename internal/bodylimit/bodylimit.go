@@ -0,0 +1,82 @@
+package bodylimit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	// Limits rejects REST requests with bodies larger than the configured
+	// maximum of the API, before any handler decodes them. The gRPC servers
+	// enforce the same maximum on received messages.
+	Limits struct {
+		d dependencies
+	}
+)
+
+var ErrBodyTooLarge = herodot.DefaultError{
+	IDField:       errorcode.BodyTooLarge,
+	StatusField:   http.StatusText(http.StatusRequestEntityTooLarge),
+	ErrorField:    "The request body is too large.",
+	CodeField:     http.StatusRequestEntityTooLarge,
+	GRPCCodeField: codes.ResourceExhausted,
+}
+
+func New(d dependencies) *Limits {
+	return &Limits{d: d}
+}
+
+func tooLarge(iface string, limit int) error {
+	return errors.WithStack(ErrBodyTooLarge.
+		WithReasonf("The request body exceeds the limit of %d bytes of the %s API.", limit, iface).
+		WithDetail(errorcode.DetailLimit, config.KeyLimitMaxBodySize+"."+iface))
+}
+
+// HTTPMiddleware returns a middleware for the given API interface ("read" or
+// "write") that rejects requests with larger bodies than allowed. Bodies
+// without a content length are read up to the limit, so that chunked requests
+// can't exceed it either.
+func (l *Limits) HTTPMiddleware(iface string) func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		limit := l.d.Config(r.Context()).MaxBodySize(iface)
+		if r.Body == nil || r.Body == http.NoBody {
+			next(rw, r)
+			return
+		}
+		if r.ContentLength > int64(limit) {
+			l.d.Writer().WriteError(rw, r, tooLarge(iface, limit))
+			return
+		}
+		if r.ContentLength >= 0 {
+			// The server never reads beyond the content length.
+			next(rw, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+		_ = r.Body.Close()
+		if err != nil {
+			l.d.Writer().WriteError(rw, r, herodot.ErrBadRequest.WithError(err.Error()))
+			return
+		}
+		if len(body) > limit {
+			l.d.Writer().WriteError(rw, r, tooLarge(iface, limit))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(rw, r)
+	}
+}
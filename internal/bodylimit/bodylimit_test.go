@@ -0,0 +1,103 @@
+package bodylimit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "docs"}}))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxBodySize+".write", "1KB"))
+
+	ts := httptest.NewServer(reg.WriteRouter(ctx))
+	t.Cleanup(ts.Close)
+
+	put := func(t *testing.T, body io.Reader) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodPut, ts.URL+relationtuple.WriteRouteBase, body)
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, raw
+	}
+	large := `{"namespace": "docs", "object": "` + strings.Repeat("a", 2000) + `", "relation": "view", "subject_id": "alice"}`
+
+	t.Run("case=accepts small bodies", func(t *testing.T) {
+		resp, raw := put(t, strings.NewReader(`{"namespace": "docs", "object": "a", "relation": "view", "subject_id": "alice"}`))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, "%s", raw)
+	})
+
+	t.Run("case=rejects large bodies with the limit", func(t *testing.T) {
+		resp, raw := put(t, strings.NewReader(large))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		var body struct {
+			Error struct {
+				ID      string                 `json:"id"`
+				Reason  string                 `json:"reason"`
+				Details map[string]interface{} `json:"details"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &body))
+		assert.Equal(t, errorcode.BodyTooLarge, body.Error.ID)
+		assert.Contains(t, body.Error.Reason, "1024 bytes")
+		assert.Equal(t, config.KeyLimitMaxBodySize+".write", body.Error.Details[errorcode.DetailLimit])
+	})
+
+	t.Run("case=rejects large bodies without content length", func(t *testing.T) {
+		// Wrapping the reader hides its length, so the request is chunked.
+		resp, _ := put(t, io.MultiReader(bytes.NewBufferString(large)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	t.Run("case=rejects large gRPC messages", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		srv := reg.WriteGRPCServer(ctx)
+		go func() { _ = srv.Serve(l) }()
+		t.Cleanup(srv.Stop)
+
+		conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, err = rts.NewWriteServiceClient(conn).TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+			RelationTupleDeltas: []*rts.RelationTupleDelta{{
+				Action: rts.RelationTupleDelta_ACTION_INSERT,
+				RelationTuple: &rts.RelationTuple{
+					Namespace: "docs",
+					Object:    strings.Repeat("a", 2000),
+					Relation:  "view",
+					Subject:   rts.NewSubjectID("alice"),
+				},
+			}},
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err), "%+v", err)
+		assert.Contains(t, err.Error(), "1024")
+	})
+}
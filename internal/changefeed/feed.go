@@ -0,0 +1,104 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	Provider interface {
+		ChangeFeed() *Feed
+	}
+	dependencies interface {
+		persistence.Provider
+		config.Provider
+		x.LoggerProvider
+	}
+	// Feed distributes the changes to relation tuples streamed by the
+	// database to its subscribers. This keeps in-memory state up to date with
+	// the writes of all servers, without a changelog table that every write
+	// would have to insert into.
+	Feed struct {
+		d dependencies
+
+		mu          sync.RWMutex
+		subscribers []func(*persistence.Change)
+	}
+)
+
+const maxRetryInterval = 30 * time.Second
+
+var changesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "changefeed_changes_total",
+	Help:      "The number of relation tuple changes received from the database changefeed.",
+})
+
+func init() {
+	prometheus.MustRegister(changesTotal)
+}
+
+func NewFeed(d dependencies) *Feed {
+	return &Feed{d: d}
+}
+
+// Subscribe calls f for every change. The subscribers are called one after
+// the other and must not block.
+func (f *Feed) Subscribe(s func(*persistence.Change)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subscribers = append(f.subscribers, s)
+}
+
+func (f *Feed) publish(c *persistence.Change) {
+	changesTotal.Inc()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, s := range f.subscribers {
+		s(c)
+	}
+}
+
+// Run watches the changes until the context is canceled. It returns
+// immediately if the changefeed is disabled or not supported by the
+// database. Interrupted feeds are resubscribed, changes in between are lost.
+func (f *Feed) Run(ctx context.Context) error {
+	if !f.d.Config(ctx).ChangeFeedEnabled() {
+		return nil
+	}
+	cf, ok := f.d.Persister().(persistence.ChangeFeed)
+	if !ok {
+		f.d.Logger().Warn("The changefeed is enabled, but the persister does not support it.")
+		return nil
+	}
+
+	for wait := time.Second; ; wait *= 2 {
+		err := cf.WatchChanges(ctx, f.publish)
+		if errors.Is(err, persistence.ErrChangeFeedUnsupported) {
+			f.d.Logger().Warn("The changefeed is enabled, but the database does not support it.")
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if wait > maxRetryInterval {
+			wait = maxRetryInterval
+		}
+		f.d.Logger().WithError(err).Warnf("The changefeed was interrupted, resubscribing in %s.", wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package changefeed_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/changefeed"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	configProvider = config.Provider
+	loggerProvider = x.LoggerProvider
+	deps           struct {
+		configProvider
+		loggerProvider
+		p persistence.Persister
+	}
+	// streamingPersister streams the changes and blocks until the context is
+	// canceled.
+	streamingPersister struct {
+		persistence.Persister
+		changes []*persistence.Change
+	}
+)
+
+func (d *deps) Persister() persistence.Persister {
+	return d.p
+}
+
+func (p *streamingPersister) WatchChanges(ctx context.Context, f func(*persistence.Change)) error {
+	for _, c := range p.changes {
+		f(c)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, p persistence.Persister) (*deps, *changefeed.Feed) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+		d := &deps{configProvider: reg, loggerProvider: reg, p: p}
+		return d, changefeed.NewFeed(d)
+	}
+
+	t.Run("case=publishes the changes to all subscribers", func(t *testing.T) {
+		change := &persistence.Change{RelationTuple: &relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}}
+		_, f := setup(t, &streamingPersister{changes: []*persistence.Change{change}})
+
+		received := make(chan *persistence.Change, 2)
+		f.Subscribe(func(c *persistence.Change) { received <- c })
+		f.Subscribe(func(c *persistence.Change) { received <- c })
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- f.Run(ctx) }()
+
+		assert.Equal(t, change, <-received)
+		assert.Equal(t, change, <-received)
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("case=returns if the persister is not supported", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		// Only the methods of the interface are promoted.
+		_, f := setup(t, struct{ persistence.Persister }{reg.Persister()})
+		assert.NoError(t, f.Run(ctx))
+	})
+
+	t.Run("case=returns if disabled", func(t *testing.T) {
+		d, f := setup(t, &streamingPersister{})
+		require.NoError(t, d.Config(ctx).Set(config.KeyChangeFeedEnabled, false))
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		assert.NoError(t, f.Run(ctx))
+		assert.NoError(t, ctx.Err())
+	})
+}
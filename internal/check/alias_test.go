@@ -0,0 +1,105 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestRelationAliases(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := &relationtuple.SubjectID{ID: "alice"}, &relationtuple.SubjectID{ID: "bob"}
+
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: json.RawMessage(`{"aliases": {"reader": "viewer"}}`)},
+		{ID: 1, Name: "groups", Config: json.RawMessage(`{"aliases": {"participant": "member"}}`)},
+	})
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+		// written before the rename and not migrated yet
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "groups", Object: "staff", Relation: "participant"}},
+		&relationtuple.InternalRelationTuple{Namespace: "groups", Object: "staff", Relation: "member", Subject: bob},
+	))
+	e := check.NewEngine(reg)
+
+	aliasChecks := func(t *testing.T, ns, alias string) float64 {
+		mfs, err := prometheus.DefaultGatherer.Gather()
+		require.NoError(t, err)
+		for _, mf := range mfs {
+			if mf.GetName() != "keto_relation_alias_checks_total" {
+				continue
+			}
+			for _, m := range mf.Metric {
+				labels := map[string]string{}
+				for _, l := range m.Label {
+					labels[l.GetName()] = l.GetValue()
+				}
+				if labels["namespace"] == ns && labels["alias"] == alias {
+					return m.Counter.GetValue()
+				}
+			}
+		}
+		return 0
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		rt       *relationtuple.InternalRelationTuple
+		expected bool
+	}{
+		{
+			desc:     "alias evaluates the renamed relation",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "reader", Subject: alice},
+			expected: true,
+		},
+		{
+			desc:     "renamed relation is evaluated as before",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+			expected: true,
+		},
+		{
+			desc:     "alias in a subject set evaluates the renamed relation",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "reader", Subject: bob},
+			expected: true,
+		},
+		{
+			desc: "alias does not grant other subjects",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "reader", Subject: &relationtuple.SubjectID{ID: "mallory"}},
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.SubjectIsAllowed(ctx, tc.rt, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+
+	t.Run("case=counts alias usage", func(t *testing.T) {
+		before := aliasChecks(t, "docs", "reader")
+		_, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "reader", Subject: alice}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, aliasChecks(t, "docs", "reader"))
+
+		before = aliasChecks(t, "docs", "viewer")
+		_, err = e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, before, aliasChecks(t, "docs", "viewer"))
+	})
+
+	t.Run("case=rejects chained aliases", func(t *testing.T) {
+		assert.Error(t, namespace.Validate([]*namespace.Namespace{
+			{Name: "docs", Config: json.RawMessage(`{"aliases": {"reader": "viewer", "viewer": "watcher"}}`)},
+		}))
+		assert.Error(t, namespace.Validate([]*namespace.Namespace{
+			{Name: "docs", Config: json.RawMessage(`{"aliases": {"reader": "reader"}}`)},
+		}))
+	})
+}
@@ -0,0 +1,101 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestDenyRelations(t *testing.T) {
+	ctx := context.Background()
+	alice, bob, carol := &relationtuple.SubjectID{ID: "alice"}, &relationtuple.SubjectID{ID: "bob"}, &relationtuple.SubjectID{ID: "carol"}
+	staff := &relationtuple.SubjectSet{Namespace: "groups", Object: "staff", Relation: "member"}
+
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: json.RawMessage(`{"deny": {"viewer": ["legal_hold"]}}`)},
+		{ID: 1, Name: "groups"},
+	})
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: staff},
+		&relationtuple.InternalRelationTuple{Namespace: "groups", Object: "staff", Relation: "member", Subject: bob},
+		&relationtuple.InternalRelationTuple{Namespace: "groups", Object: "staff", Relation: "member", Subject: carol},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "legal_hold", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "legal_hold", Subject: &relationtuple.SubjectSet{Namespace: "groups", Object: "held", Relation: "member"}},
+		&relationtuple.InternalRelationTuple{Namespace: "groups", Object: "held", Relation: "member", Subject: bob},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "folder", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "docs", Object: "doc", Relation: "viewer"}},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "folder", Relation: "viewer", Subject: staff},
+	))
+	e := check.NewEngine(reg)
+
+	for _, tc := range []struct {
+		desc     string
+		rt       *relationtuple.InternalRelationTuple
+		expected bool
+	}{
+		{
+			desc: "direct deny overrides direct grant",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+		},
+		{
+			desc: "deny through a subject set overrides grant through a subject set",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: bob},
+		},
+		{
+			desc:     "grant without deny",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: carol},
+			expected: true,
+		},
+		{
+			desc:     "deny only applies to the denied relation on its object",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "folder", Relation: "viewer", Subject: bob},
+			expected: true,
+		},
+		{
+			desc: "deny applies when reached through a subject set",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "folder", Relation: "viewer", Subject: alice},
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.SubjectIsAllowed(ctx, tc.rt, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+
+	t.Run("case=direct deny at max depth 1", func(t *testing.T) {
+		allowed, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice}, 1)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
+
+func TestDenyRelationsDenyingEachOther(t *testing.T) {
+	ctx := context.Background()
+	alice := &relationtuple.SubjectID{ID: "alice"}
+
+	// Namespaces of the config file are not validated, so the engine has to
+	// terminate on such a cycle nonetheless.
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: json.RawMessage(`{"deny": {"viewer": ["blocked"], "blocked": ["viewer"]}}`)},
+	})
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "blocked", Subject: alice},
+	))
+	e := check.NewEngine(reg)
+
+	for _, relation := range []string{"viewer", "blocked"} {
+		t.Run("relation="+relation, func(t *testing.T) {
+			_, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: relation, Subject: alice}, 0)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestRelationMaxDepth(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := &relationtuple.SubjectID{ID: "alice"}, &relationtuple.SubjectID{ID: "bob"}
+	group := func(name string) *relationtuple.SubjectSet {
+		return &relationtuple.SubjectSet{Namespace: "groups", Object: name, Relation: "member"}
+	}
+
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "docs"},
+		{ID: 1, Name: "groups", Config: json.RawMessage(`{"max_depth": {"member": 2}}`)},
+	})
+	// Written top-down, so that the nesting is not detected on write.
+	for _, rt := range []*relationtuple.InternalRelationTuple{
+		{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: group("a")},
+		{Namespace: "groups", Object: "a", Relation: "member", Subject: group("b")},
+		{Namespace: "groups", Object: "b", Relation: "member", Subject: group("c")},
+		{Namespace: "groups", Object: "b", Relation: "member", Subject: bob},
+		{Namespace: "groups", Object: "c", Relation: "member", Subject: alice},
+	} {
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, rt))
+	}
+	e := check.NewEngine(reg)
+
+	for _, tc := range []struct {
+		desc     string
+		rt       *relationtuple.InternalRelationTuple
+		expected bool
+	}{
+		{
+			desc:     "within the max depth",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "a", Relation: "member", Subject: bob},
+			expected: true,
+		},
+		{
+			desc: "beyond the max depth",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "a", Relation: "member", Subject: alice},
+		},
+		{
+			desc:     "passing through the relation",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: bob},
+			expected: true,
+		},
+		{
+			desc: "beyond the max depth when passing through the relation",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "docs", Object: "doc", Relation: "viewer", Subject: alice},
+		},
+		{
+			desc:     "starting deeper",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "b", Relation: "member", Subject: alice},
+			expected: true,
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.SubjectIsAllowed(ctx, tc.rt, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+}
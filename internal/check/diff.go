@@ -0,0 +1,169 @@
+package check
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	// AccessDiffRequest compares the access of two subjects to an object, or
+	// of one subject at two snapshots. Unset other fields default to the
+	// subject and the current snapshot.
+	AccessDiffRequest struct {
+		Namespace      string
+		Object         string
+		Subject        relationtuple.Subject
+		OtherSubject   relationtuple.Subject
+		Snaptoken      string
+		OtherSnaptoken string
+		MaxDepth       int
+	}
+	// AccessDiff lists the relations to the object that are granted on only
+	// one side of the comparison.
+	//
+	// swagger:model accessDiff
+	AccessDiff struct {
+		Namespace      string `json:"namespace"`
+		Object         string `json:"object"`
+		Subject        string `json:"subject"`
+		OtherSubject   string `json:"other_subject"`
+		Snaptoken      string `json:"snaptoken"`
+		OtherSnaptoken string `json:"other_snaptoken"`
+		// The differing permits, sorted by relation.
+		Differences []*PermitDifference `json:"differences"`
+	}
+	// PermitDifference is a relation that is granted to only one side.
+	PermitDifference struct {
+		Relation     string `json:"relation"`
+		Allowed      bool   `json:"allowed"`
+		OtherAllowed bool   `json:"other_allowed"`
+		// The chain of relation tuples from the object to the subject that
+		// grants the relation on the allowed side.
+		RelationTuples []*relationtuple.InternalRelationTuple `json:"relation_tuples"`
+	}
+)
+
+// relations returns all relations that relation tuples of the object have in
+// any of the contexts.
+func (e *Engine) relations(namespace, object string, ctxs ...context.Context) ([]string, error) {
+	seen := map[string]struct{}{}
+	for _, ctx := range ctxs {
+		for page := ""; ; {
+			rels, next, err := e.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: namespace, Object: object}, x.WithToken(page))
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rels {
+				seen[r.Relation] = struct{}{}
+			}
+			if next == "" {
+				break
+			}
+			page = next
+		}
+	}
+
+	relations := make([]string, 0, len(seen))
+	for r := range seen {
+		relations = append(relations, r)
+	}
+	sort.Strings(relations)
+	return relations, nil
+}
+
+// DiffAccess compares the effective access to the object, which are all
+// relations that relation tuples of the object have on either side.
+// Snapshots older than the retention of the deletion log are refused.
+func (e *Engine) DiffAccess(ctx context.Context, req *AccessDiffRequest) (*AccessDiff, error) {
+	if req.Subject == nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithReason("A subject is required."))
+	}
+	if req.OtherSubject == nil {
+		req.OtherSubject = req.Subject
+	}
+
+	sr, ok := e.d.Persister().(persistence.SnapshotReader)
+	current := ""
+	if ok {
+		var err error
+		if current, err = sr.SnapshotToken(ctx); err != nil {
+			return nil, err
+		}
+	} else if req.Snaptoken != "" || req.OtherSnaptoken != "" {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithReason("The persister does not support snapshots."))
+	}
+	if req.Snaptoken == "" {
+		req.Snaptoken = current
+	}
+	if req.OtherSnaptoken == "" {
+		req.OtherSnaptoken = current
+	}
+
+	sideCtx := func(token string) (context.Context, error) {
+		if token == current {
+			return ctx, nil
+		}
+		return sr.AtSnapshot(ctx, token)
+	}
+	ctxA, err := sideCtx(req.Snaptoken)
+	if err != nil {
+		return nil, err
+	}
+	ctxB, err := sideCtx(req.OtherSnaptoken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Global max-depth takes precedence when it is the lesser or if the
+	// request max-depth is less than or equal to 0.
+	restDepth := req.MaxDepth
+	if globalMaxDepth := e.d.Config(ctx).MaxReadDepth(); restDepth <= 0 || globalMaxDepth < restDepth {
+		restDepth = globalMaxDepth
+	}
+
+	relations, err := e.relations(req.Namespace, req.Object, ctxA, ctxB)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &AccessDiff{
+		Namespace:      req.Namespace,
+		Object:         req.Object,
+		Subject:        req.Subject.String(),
+		OtherSubject:   req.OtherSubject.String(),
+		Snaptoken:      req.Snaptoken,
+		OtherSnaptoken: req.OtherSnaptoken,
+		Differences:    []*PermitDifference{},
+	}
+	for _, relation := range relations {
+		allowed, tuples, err := e.ev.Explain(ctxA, &relationtuple.InternalRelationTuple{Namespace: req.Namespace, Object: req.Object, Relation: relation, Subject: req.Subject}, restDepth)
+		if err != nil {
+			return nil, err
+		}
+		otherAllowed, otherTuples, err := e.ev.Explain(ctxB, &relationtuple.InternalRelationTuple{Namespace: req.Namespace, Object: req.Object, Relation: relation, Subject: req.OtherSubject}, restDepth)
+		if err != nil {
+			return nil, err
+		}
+		if allowed == otherAllowed {
+			continue
+		}
+		if otherAllowed {
+			tuples = otherTuples
+		}
+		diff.Differences = append(diff.Differences, &PermitDifference{
+			Relation:       relation,
+			Allowed:        allowed,
+			OtherAllowed:   otherAllowed,
+			RelationTuples: tuples,
+		})
+	}
+	return diff, nil
+}
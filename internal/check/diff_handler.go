@@ -0,0 +1,111 @@
+package check
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// swagger:parameters getAccessDiff
+// nolint:deadcode,unused
+type getAccessDiffParameters struct {
+	// The namespace of the object.
+	//
+	// in: query
+	// required: true
+	Namespace string `json:"namespace"`
+
+	// The object to compare the access to.
+	//
+	// in: query
+	// required: true
+	Object string `json:"object"`
+
+	// The subject, either a subject ID or a subject set of the form
+	// `namespace:object#relation`.
+	//
+	// in: query
+	// required: true
+	Subject string `json:"subject"`
+
+	// The subject to compare with. Defaults to the subject.
+	//
+	// in: query
+	OtherSubject string `json:"other_subject"`
+
+	// The snapshot to evaluate the subject at. Defaults to the current one.
+	//
+	// in: query
+	Snaptoken string `json:"snaptoken"`
+
+	// The snapshot to evaluate the other subject at. Defaults to the current
+	// one.
+	//
+	// in: query
+	OtherSnaptoken string `json:"other_snaptoken"`
+
+	// in: query
+	MaxDepth int `json:"max-depth"`
+}
+
+// swagger:route GET /admin/access-diff write getAccessDiff
+//
+// Compare the access to an object
+//
+// Compares the effective access of two subjects to the object, or of one
+// subject at two snapshots, and returns the relations that are granted on
+// only one side together with the relation tuples granting them. The
+// effective access consists of all relations that relation tuples of the
+// object have on either side. The response contains the current snapshot
+// token, which can be used to compare with the access at this point later on.
+// Snapshots older than the retention of deleted relation tuples are refused.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: accessDiff
+//       400: genericError
+//       500: genericError
+func (h *Handler) getAccessDiff(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+
+	maxDepth, err := x.GetMaxDepthFromQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	req := &AccessDiffRequest{
+		Namespace:      q.Get("namespace"),
+		Object:         q.Get("object"),
+		Snaptoken:      q.Get("snaptoken"),
+		OtherSnaptoken: q.Get("other_snaptoken"),
+		MaxDepth:       maxDepth,
+	}
+	if req.Namespace == "" || req.Object == "" || q.Get("subject") == "" {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReason("The namespace, object, and subject are required.")))
+		return
+	}
+	for key, s := range map[string]*relationtuple.Subject{"subject": &req.Subject, "other_subject": &req.OtherSubject} {
+		if raw := q.Get(key); raw != "" {
+			if *s, err = relationtuple.SubjectFromString(raw); err != nil {
+				h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithReasonf("Malformed %s %q.", key, raw)))
+				return
+			}
+		}
+	}
+
+	diff, err := h.d.PermissionEngine().DiffAccess(r.Context(), req)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, diff)
+}
@@ -0,0 +1,123 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestDiffAccess(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}, {Name: "groups", ID: 2}}))
+
+	alice, bob := &relationtuple.SubjectID{ID: "alice"}, &relationtuple.SubjectID{ID: "bob"}
+	admins := &relationtuple.SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}
+	grantedToAdmins := &relationtuple.InternalRelationTuple{Namespace: "files", Object: "doc", Relation: "edit", Subject: admins}
+	bobIsAdmin := &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "admins", Relation: "member", Subject: bob}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "files", Object: "doc", Relation: "view", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "files", Object: "doc", Relation: "view", Subject: bob},
+		grantedToAdmins,
+	))
+	before, err := reg.Persister().(persistence.SnapshotReader).SnapshotToken(ctx)
+	require.NoError(t, err)
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, bobIsAdmin))
+
+	e := reg.PermissionEngine()
+
+	t.Run("case=compares two subjects", func(t *testing.T) {
+		diff, err := e.DiffAccess(ctx, &check.AccessDiffRequest{Namespace: "files", Object: "doc", Subject: alice, OtherSubject: bob})
+		require.NoError(t, err)
+		assert.Equal(t, []*check.PermitDifference{{
+			Relation:       "edit",
+			Allowed:        false,
+			OtherAllowed:   true,
+			RelationTuples: []*relationtuple.InternalRelationTuple{grantedToAdmins, bobIsAdmin},
+		}}, diff.Differences)
+	})
+
+	t.Run("case=compares two snapshots", func(t *testing.T) {
+		diff, err := e.DiffAccess(ctx, &check.AccessDiffRequest{Namespace: "files", Object: "doc", Subject: bob, Snaptoken: before})
+		require.NoError(t, err)
+		assert.Equal(t, before, diff.Snaptoken)
+		assert.NotEqual(t, before, diff.OtherSnaptoken)
+		require.Len(t, diff.Differences, 1)
+		assert.Equal(t, "edit", diff.Differences[0].Relation)
+		assert.False(t, diff.Differences[0].Allowed)
+		assert.True(t, diff.Differences[0].OtherAllowed)
+	})
+
+	t.Run("case=same access has no differences", func(t *testing.T) {
+		diff, err := e.DiffAccess(ctx, &check.AccessDiffRequest{Namespace: "files", Object: "doc", Subject: alice})
+		require.NoError(t, err)
+		assert.Empty(t, diff.Differences)
+	})
+
+	t.Run("case=rest handler", func(t *testing.T) {
+		r := httprouter.New()
+		check.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+		ts := httptest.NewServer(r)
+		defer ts.Close()
+
+		get := func(q url.Values) *http.Response {
+			resp, err := ts.Client().Get(ts.URL + check.RouteAccessDiff + "?" + q.Encode())
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := get(url.Values{"namespace": {"files"}, "object": {"doc"}, "subject": {"alice"}, "other_subject": {"groups:admins#member"}})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var diff check.AccessDiff
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&diff))
+		assert.Equal(t, "groups:admins#member", diff.OtherSubject)
+		// The admin group is granted edit, but not view.
+		require.Len(t, diff.Differences, 2)
+		assert.Equal(t, "edit", diff.Differences[0].Relation)
+		assert.Equal(t, []*relationtuple.InternalRelationTuple{grantedToAdmins}, diff.Differences[0].RelationTuples)
+		assert.Equal(t, "view", diff.Differences[1].Relation)
+		assert.True(t, diff.Differences[1].Allowed)
+
+		resp = get(url.Values{"namespace": {"files"}, "object": {"doc"}})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = get(url.Values{"namespace": {"files"}, "object": {"doc"}, "subject": {"alice"}, "snaptoken": {"invalid"}})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("case=compares snapshots across a revocation", func(t *testing.T) {
+		comment := &relationtuple.InternalRelationTuple{Namespace: "files", Object: "doc", Relation: "comment", Subject: alice}
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, comment))
+		granted, err := reg.Persister().(persistence.SnapshotReader).SnapshotToken(ctx)
+		require.NoError(t, err)
+		require.NoError(t, reg.RelationTupleManager().DeleteRelationTuples(ctx, comment))
+
+		// No relation tuple of the object has the revoked relation anymore.
+		diff, err := e.DiffAccess(ctx, &check.AccessDiffRequest{Namespace: "files", Object: "doc", Subject: alice, Snaptoken: granted})
+		require.NoError(t, err)
+		assert.Equal(t, []*check.PermitDifference{{
+			Relation:       "comment",
+			Allowed:        true,
+			OtherAllowed:   false,
+			RelationTuples: []*relationtuple.InternalRelationTuple{comment},
+		}}, diff.Differences)
+	})
+}
@@ -8,12 +8,20 @@ import (
 
 	"github.com/ory/keto/internal/x"
 
+	"github.com/ory/keto/internal/metering"
+	"github.com/ory/keto/internal/objectfilter"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/proxy"
+
 	"github.com/ory/keto/internal/namespace"
 
 	"github.com/ory/keto/internal/relationtuple"
 
 	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/featureflag"
 
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -22,12 +30,20 @@ import (
 
 type configProvider = config.Provider
 type loggerProvider = x.LoggerProvider
+type meterProvider = metering.Provider
+type objectFilterProvider = objectfilter.Provider
+type persisterProvider = persistence.Provider
+type proxyProvider = proxy.Provider
 
 // deps is defined to capture engine dependencies in a single struct
 type deps struct {
 	*relationtuple.ManagerWrapper // managerProvider
 	configProvider
 	loggerProvider
+	meterProvider
+	objectFilterProvider
+	persisterProvider
+	proxyProvider
 }
 
 func newDepsProvider(t *testing.T, namespaces []*namespace.Namespace, pageOpts ...x.PaginationOptionSetter) *deps {
@@ -36,9 +52,13 @@ func newDepsProvider(t *testing.T, namespaces []*namespace.Namespace, pageOpts .
 	mr := relationtuple.NewManagerWrapper(t, reg, pageOpts...)
 
 	return &deps{
-		ManagerWrapper: mr,
-		configProvider: reg,
-		loggerProvider: reg,
+		ManagerWrapper:       mr,
+		configProvider:       reg,
+		loggerProvider:       reg,
+		meterProvider:        reg,
+		objectFilterProvider: reg,
+		persisterProvider:    reg,
+		proxyProvider:        reg,
 	}
 }
 
@@ -567,6 +587,7 @@ func TestEngine(t *testing.T) {
 		}...))
 
 		e := check.NewEngine(reg)
+		hook := test.NewLocal(reg.Logger().Logger)
 
 		stations := []string{sendlingerTor, odeonsplatz, centralStation}
 		res, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
@@ -579,5 +600,166 @@ func TestEngine(t *testing.T) {
 		}, 0)
 		require.NoError(t, err)
 		assert.False(t, res)
+
+		var cycles [][]string
+		for _, e := range hook.AllEntries() {
+			if c, ok := e.Data["cycle"].([]string); ok {
+				cycles = append(cycles, c)
+			}
+		}
+		require.Len(t, cycles, 1)
+		assert.Equal(t, []string{
+			namesp + ":" + odeonsplatz + "#" + connected,
+			namesp + ":" + centralStation + "#" + connected,
+			namesp + ":" + sendlingerTor + "#" + connected,
+			namesp + ":" + odeonsplatz + "#" + connected,
+		}, cycles[0])
+	})
+
+	t.Run("case=shadow evaluation against canary namespaces", func(t *testing.T) {
+		user := &relationtuple.SubjectID{ID: "user"}
+		reg := newDepsProvider(t, []*namespace.Namespace{{Name: "files", ID: 1}, {Name: "folders", ID: 2}})
+		// The candidate moves the files to the ID of the folders.
+		require.NoError(t, reg.Config(ctx).Set(config.KeyCanaryNamespaces, []*namespace.Namespace{{Name: "files", ID: 2}}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyCanarySampleRate, 1))
+
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "files",
+			Object:    "file",
+			Relation:  "view",
+			Subject:   user,
+		}))
+
+		e := check.NewEngine(reg)
+		hook := test.NewLocal(reg.Logger().Logger)
+
+		for _, object := range []string{"file", "other file"} {
+			res, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+				Namespace: "files",
+				Object:    object,
+				Relation:  "view",
+				Subject:   user,
+			}, 0)
+			require.NoError(t, err)
+			assert.Equal(t, object == "file", res)
+		}
+		e.WaitShadowChecks()
+
+		var divergent []string
+		for _, e := range hook.AllEntries() {
+			if _, ok := e.Data["canary_allowed"]; ok {
+				divergent = append(divergent, e.Data["object"].(string))
+				assert.Equal(t, true, e.Data["allowed"])
+				assert.Equal(t, false, e.Data["canary_allowed"])
+			}
+		}
+		assert.Equal(t, []string{"file"}, divergent)
+	})
+
+	t.Run("case=direct match first", func(t *testing.T) {
+		user := &relationtuple.SubjectID{ID: "user"}
+		reg := newDepsProvider(t, []*namespace.Namespace{{Name: "files", ID: 1}, {Name: "groups", ID: 2}})
+		tuples := []*relationtuple.InternalRelationTuple{{
+			Namespace: "files",
+			Object:    "file",
+			Relation:  "view",
+			Subject:   user,
+		}}
+		for _, group := range []string{"a", "b", "c", "d"} {
+			tuples = append(tuples, &relationtuple.InternalRelationTuple{
+				Namespace: "files",
+				Object:    "file",
+				Relation:  "view",
+				Subject:   &relationtuple.SubjectSet{Namespace: "groups", Object: group, Relation: "member"},
+			}, &relationtuple.InternalRelationTuple{
+				Namespace: "groups",
+				Object:    group,
+				Relation:  "member",
+				Subject:   &relationtuple.SubjectID{ID: "someone else"},
+			})
+		}
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuples...))
+
+		e := check.NewEngine(reg)
+		assertAllowed := func(t *testing.T, ctx context.Context) {
+			reg.RequestedPages = nil
+			res, err := e.SubjectIsAllowed(ctx, tuples[0], 0)
+			require.NoError(t, err)
+			assert.True(t, res)
+		}
+
+		t.Run("disabled", func(t *testing.T) {
+			assertAllowed(t, ctx)
+		})
+
+		t.Run("overridden per request", func(t *testing.T) {
+			assertAllowed(t, featureflag.WithOverrides(ctx, map[featureflag.Flag]bool{featureflag.DirectMatchFirst: true}))
+			// no subject set was expanded
+			assert.Len(t, reg.RequestedPages, 1)
+		})
+
+		t.Run("enabled for the namespace", func(t *testing.T) {
+			require.NoError(t, reg.Config(ctx).Set(config.KeyFeatureFlagNamespaces+".files."+string(featureflag.DirectMatchFirst), true))
+			assertAllowed(t, ctx)
+			assert.Len(t, reg.RequestedPages, 1)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyFeatureFlagNamespaces+".files."+string(featureflag.DirectMatchFirst), false))
+		})
+
+		t.Run("shadow evaluated", func(t *testing.T) {
+			assertAllowed(t, ctx)
+			current := len(reg.RequestedPages)
+
+			require.NoError(t, reg.Config(ctx).Set(config.KeyShadowFeatureFlags, map[string]interface{}{string(featureflag.DirectMatchFirst): true}))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyShadowSampleRate, 1))
+			hook := test.NewLocal(reg.Logger().Logger)
+			// The candidate strategy is evaluated after the request ended.
+			rctx, cancel := context.WithCancel(ctx)
+			assertAllowed(t, rctx)
+			cancel()
+			e.WaitShadowChecks()
+			require.NoError(t, reg.Config(ctx).Set(config.KeyShadowSampleRate, 0))
+
+			// the candidate strategy expanded no subject set
+			assert.Len(t, reg.RequestedPages, current+1)
+			for _, e := range hook.AllEntries() {
+				assert.NotContains(t, e.Data, "candidate_allowed")
+				assert.NotContains(t, e.Data, logrus.ErrorKey)
+			}
+		})
+
+		t.Run("enabled globally", func(t *testing.T) {
+			require.NoError(t, reg.Config(ctx).Set(config.KeyFeatureFlagNamespaces+".files", map[string]interface{}{}))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyFeatureFlags+"."+string(featureflag.DirectMatchFirst), true))
+			assertAllowed(t, ctx)
+			assert.Len(t, reg.RequestedPages, 1)
+		})
+	})
+
+	t.Run("case=object filter denies unknown objects without querying", func(t *testing.T) {
+		user := &relationtuple.SubjectID{ID: "user"}
+		reg := newDepsProvider(t, []*namespace.Namespace{{Name: "files", ID: 1}})
+		require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyObjectFilterEnabled, true))
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "files",
+			Object:    "shared file",
+			Relation:  "view",
+			Subject:   user,
+		}))
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+		e := check.NewEngine(reg)
+		for _, object := range []string{"shared file", "private file"} {
+			reg.RequestedPages = nil
+			res, err := e.SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+				Namespace: "files",
+				Object:    object,
+				Relation:  "view",
+				Subject:   user,
+			}, 0)
+			require.NoError(t, err)
+			assert.Equal(t, object == "shared file", res)
+			assert.Equal(t, object == "shared file", len(reg.RequestedPages) > 0)
+		}
 	})
 }
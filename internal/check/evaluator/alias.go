@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+)
+
+var aliasChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "relation_alias_checks_total",
+	Help:      "The number of checks that queried a relation by an alias, by namespace and alias.",
+}, []string{"namespace", "alias"})
+
+func init() {
+	prometheus.MustRegister(aliasChecksTotal)
+}
+
+// resolveAlias returns the query with the relation it is an alias for, or the
+// query itself if its relation is not an alias.
+func (e *Evaluator) resolveAlias(ctx context.Context, query *relationtuple.RelationQuery) (*relationtuple.RelationQuery, error) {
+	opts, err := e.d.NamespaceOptions(ctx, query.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	relation, isAlias := opts.Resolve(query.Relation)
+	if !isAlias {
+		return query, nil
+	}
+
+	aliasChecksTotal.WithLabelValues(query.Namespace, query.Relation).Inc()
+	requestid.Logger(ctx, e.d.Logger()).
+		WithField("namespace", query.Namespace).
+		WithField("alias", query.Relation).
+		WithField("relation", relation).
+		Debug("resolved relation alias")
+	resolved := *query
+	resolved.Relation = relation
+	return &resolved, nil
+}
@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/graph"
+)
+
+type (
+	denyingKey struct{}
+	// denying is a relation whose deny relations are being evaluated, and
+	// the one it was reached from.
+	denying struct {
+		parent                      *denying
+		namespace, object, relation string
+	}
+)
+
+// denied returns true if the subject has one of the relations that deny the
+// relation of the query on its object. Deny relations are evaluated before
+// any grant and at the same depth, so that they override it.
+func (e *Evaluator) denied(ctx context.Context, subject relationtuple.Subject, query *relationtuple.RelationQuery, restDepth int) (bool, error) {
+	opts, err := e.d.NamespaceOptions(ctx, query.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if len(opts.Deny[query.Relation]) == 0 {
+		return false, nil
+	}
+
+	// Deny relations that deny each other are rejected by the validation of
+	// the namespaces, but those of the config file are not validated. A
+	// relation whose deny relations are already being evaluated is not
+	// denied again, so that such cycles terminate.
+	parent, _ := ctx.Value(denyingKey{}).(*denying)
+	for d := parent; d != nil; d = d.parent {
+		if d.namespace == query.Namespace && d.object == query.Object && d.relation == query.Relation {
+			return false, nil
+		}
+	}
+	ctx = context.WithValue(ctx, denyingKey{}, &denying{parent: parent, namespace: query.Namespace, object: query.Object, relation: query.Relation})
+
+	for _, relation := range opts.Deny[query.Relation] {
+		// The deny relation is a traversal of its own, subjects visited
+		// while looking for a grant must not hide it.
+		denied, err := e.Evaluate(graph.NewTraversal(ctx), &relationtuple.InternalRelationTuple{
+			Namespace: query.Namespace,
+			Object:    query.Object,
+			Relation:  relation,
+			Subject:   subject,
+		}, restDepth)
+		if denied || err != nil {
+			return denied, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,15 @@
+package evaluator
+
+import (
+	"context"
+)
+
+// capDepth returns the rest depth capped at the max depth of the relation, if
+// its namespace configures one.
+func (e *Evaluator) capDepth(ctx context.Context, namespace, relation string, restDepth int) (int, error) {
+	opts, err := e.d.NamespaceOptions(ctx, namespace)
+	if err != nil {
+		return 0, err
+	}
+	return opts.CapDepth(relation, restDepth), nil
+}
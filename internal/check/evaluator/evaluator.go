@@ -0,0 +1,263 @@
+// Package evaluator traverses the relation tuples to evaluate checks. It reads
+// the namespaces and relation tuples through Dependencies only, so that the
+// check engine and the offline evaluation of snapshots share it without the
+// latter depending on the config.
+package evaluator
+
+import (
+	"context"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/graph"
+)
+
+type (
+	Dependencies interface {
+		x.LoggerProvider
+		// NamespaceOptions returns the resolved options of the namespace,
+		// or the defaults if the namespace is unknown.
+		NamespaceOptions(ctx context.Context, namespace string) (*namespace.Options, error)
+		// Manager returns the relation tuple manager to query.
+		Manager(ctx context.Context) relationtuple.Manager
+		// ObjectsGetter returns the getter to query the relation tuples of
+		// an object and its ancestors at once, if the storage backend has
+		// one.
+		ObjectsGetter(ctx context.Context) (relationtuple.ObjectsGetter, bool)
+		// MayHaveTuples returns false if the object certainly has no
+		// relation tuples.
+		MayHaveTuples(ctx context.Context, namespace, object string) bool
+		// DirectMatchFirst returns whether a page of relation tuples is
+		// searched for the subject before its subject sets are expanded.
+		DirectMatchFirst(ctx context.Context, namespace string) bool
+	}
+	Evaluator struct {
+		d Dependencies
+	}
+)
+
+func New(d Dependencies) *Evaluator {
+	return &Evaluator{d: d}
+}
+
+// Evaluate returns whether the subject of the relation tuple has the relation
+// on the object, expanding at most restDepth indirections.
+func (e *Evaluator) Evaluate(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int) (bool, error) {
+	query, err := e.resolveAlias(ctx, &relationtuple.RelationQuery{Object: r.Object, Relation: r.Relation, Namespace: r.Namespace})
+	if err != nil {
+		return false, err
+	}
+	r = &relationtuple.InternalRelationTuple{Namespace: query.Namespace, Object: query.Object, Relation: query.Relation, Subject: r.Subject}
+
+	// Objects without any relation tuples don't grant anything, unless they
+	// inherit the relation from an ancestor.
+	if !e.d.MayHaveTuples(ctx, r.Namespace, r.Object) {
+		ancestors, err := e.ancestors(ctx, r.Namespace, r.Object, r.Relation)
+		if err != nil {
+			return false, err
+		}
+		if !e.anyMayHaveTuples(ctx, r.Namespace, ancestors) {
+			return false, nil
+		}
+	}
+
+	// The root is not visited, but cycles leading back to it are reported.
+	ctx = graph.ExtendPath(ctx, &relationtuple.SubjectSet{Namespace: r.Namespace, Object: r.Object, Relation: r.Relation})
+	return e.checkOneIndirectionFurther(ctx, r, query, restDepth)
+}
+
+// Explain evaluates the relation tuple like Evaluate and returns the chain of
+// relation tuples granting it.
+func (e *Evaluator) Explain(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int) (bool, []*relationtuple.InternalRelationTuple, error) {
+	var path []relationtuple.Subject
+	allowed, err := e.Evaluate(context.WithValue(ctx, grantKey{}, &path), r, restDepth)
+	if err != nil || !allowed {
+		return false, nil, err
+	}
+
+	// The path starts with the checked subject set and ends with the subject,
+	// every step is one relation tuple.
+	tuples := make([]*relationtuple.InternalRelationTuple, 0, len(path))
+	for i := 1; i < len(path); i++ {
+		set, ok := path[i-1].(*relationtuple.SubjectSet)
+		if !ok {
+			break
+		}
+		tuples = append(tuples, &relationtuple.InternalRelationTuple{
+			Namespace: set.Namespace,
+			Object:    set.Object,
+			Relation:  set.Relation,
+			Subject:   path[i],
+		})
+	}
+	return true, tuples, nil
+}
+
+type grantKey struct{}
+
+// normalizeSubject returns the subject as it is stored in the relation tuples
+// of the namespace, so that it equals the subjects read from them. Subject
+// IDs are normalized by the options of the namespace of the tuples, and the
+// objects of subject sets by the options of their own namespace.
+func (e *Evaluator) normalizeSubject(ctx context.Context, namespace string, subject relationtuple.Subject) (relationtuple.Subject, error) {
+	switch s := subject.(type) {
+	case *relationtuple.SubjectID:
+		opts, err := e.d.NamespaceOptions(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &relationtuple.SubjectID{ID: opts.Normalize(s.ID)}, nil
+	case *relationtuple.SubjectSet:
+		opts, err := e.d.NamespaceOptions(ctx, s.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &relationtuple.SubjectSet{Namespace: s.Namespace, Object: opts.Normalize(s.Object), Relation: s.Relation}, nil
+	}
+	return subject, nil
+}
+
+// recordGrant stores the path of the traversal as the granting one, if the
+// caller asked for it.
+func recordGrant(ctx context.Context) {
+	if grant, ok := ctx.Value(grantKey{}).(*[]relationtuple.Subject); ok {
+		*grant = graph.Path(ctx)
+	}
+}
+
+func (e *Evaluator) subjectIsAllowed(
+	ctx context.Context,
+	requested *relationtuple.InternalRelationTuple,
+	rels []*relationtuple.InternalRelationTuple,
+	restDepth int,
+) (bool, error) {
+	// This is the same as the graph problem "can requested.Subject be reached from requested.Object through the first outgoing edge requested.Relation"
+	//
+	// We implement recursive depth-first search here.
+	// TODO replace by more performant algorithm: https://github.com/ory/keto/issues/483
+
+	if len(rels) == 0 {
+		return false, nil
+	}
+	// The subjects of the page are stored normalized by the options of its
+	// namespace.
+	subject, err := e.normalizeSubject(ctx, rels[0].Namespace, requested.Subject)
+	if err != nil {
+		return false, err
+	}
+
+	if e.d.DirectMatchFirst(ctx, rels[0].Namespace) {
+		// a direct match on this page saves expanding the subject sets before it
+		for _, sr := range rels {
+			if subject.Equals(sr.Subject) {
+				recordGrant(graph.ExtendPath(ctx, sr.Subject))
+				return true, nil
+			}
+		}
+	}
+
+	for _, sr := range rels {
+		ctx, wasAlreadyVisited := graph.CheckAndAddVisited(ctx, sr.Subject)
+		if wasAlreadyVisited {
+			if cycle, ok := graph.FindCycle(ctx, sr.Subject); ok {
+				cycle.Report(e.d.Logger(), "check")
+			}
+			continue
+		}
+
+		// we only have to check Subject here as we know that sr was reached from requested.ObjectID, requested.Relation through 0...n indirections
+		if subject.Equals(sr.Subject) {
+			// found the requested relation
+			recordGrant(ctx)
+			return true, nil
+		}
+
+		sub, isSubjectSet := sr.Subject.(*relationtuple.SubjectSet)
+		if !isSubjectSet {
+			continue
+		}
+		if crossed, err := e.crossesTenantBoundary(ctx, sr, sub); err != nil {
+			return false, err
+		} else if crossed {
+			continue
+		}
+
+		// expand the set by one indirection; paginated
+		allowed, err := e.checkOneIndirectionFurther(
+			ctx,
+			requested,
+			&relationtuple.RelationQuery{Object: sub.Object, Relation: sub.Relation, Namespace: sub.Namespace},
+			restDepth-1,
+		)
+		if err != nil {
+			return false, err
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (e *Evaluator) checkOneIndirectionFurther(
+	ctx context.Context,
+	requested *relationtuple.InternalRelationTuple,
+	expandQuery *relationtuple.RelationQuery,
+	restDepth int,
+) (bool, error) {
+	expandQuery, err := e.resolveAlias(ctx, expandQuery)
+	if err != nil {
+		return false, err
+	}
+	restDepth, err = e.capDepth(ctx, expandQuery.Namespace, expandQuery.Relation, restDepth)
+	if err != nil {
+		return false, err
+	}
+	if restDepth <= 0 {
+		requestid.Logger(ctx, e.d.Logger()).WithFields(requested.ToLoggerFields()).Debug("reached max-depth, therefore this query will not be further expanded")
+		return false, nil
+	}
+	if denied, err := e.denied(ctx, requested.Subject, expandQuery, restDepth); denied || err != nil {
+		return false, err
+	}
+
+	get := e.d.Manager(ctx).GetRelationTuples
+	ancestors, err := e.ancestors(ctx, expandQuery.Namespace, expandQuery.Object, expandQuery.Relation)
+	if err != nil {
+		return false, err
+	}
+	if len(ancestors) > 0 {
+		// The relation tuples of the object and its ancestors are queried
+		// together.
+		getter, _ := e.d.ObjectsGetter(ctx)
+		objects := append([]string{expandQuery.Object}, ancestors...)
+		get = func(ctx context.Context, query *relationtuple.RelationQuery, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+			return getter.GetRelationTuplesOfObjects(ctx, &relationtuple.RelationQuery{Namespace: query.Namespace, Relation: query.Relation}, objects, options...)
+		}
+	}
+
+	// an empty page token denotes the first page (as tokens are opaque)
+	var prevPage string
+
+	for {
+		nextRels, nextPage, err := get(ctx, expandQuery, x.WithToken(prevPage))
+		if errorcode.Is(err, errorcode.NamespaceUnknown) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		allowed, err := e.subjectIsAllowed(ctx, requested, nextRels, restDepth)
+
+		// loop through pages until either allowed, end of pages, or an error occurred
+		if allowed || nextPage == "" || err != nil {
+			return allowed, err
+		}
+
+		prevPage = nextPage
+	}
+}
@@ -0,0 +1,31 @@
+package evaluator
+
+import (
+	"context"
+)
+
+// ancestors returns the ancestors of the object that it inherits the relation
+// from. It returns none if the namespace is not hierarchical, or if the
+// storage backend can't query several objects at once.
+func (e *Evaluator) ancestors(ctx context.Context, ns, object, relation string) ([]string, error) {
+	if _, ok := e.d.ObjectsGetter(ctx); !ok {
+		return nil, nil
+	}
+	opts, err := e.d.NamespaceOptions(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Hierarchy.Inherits(relation) {
+		return nil, nil
+	}
+	return opts.Hierarchy.Ancestors(object), nil
+}
+
+func (e *Evaluator) anyMayHaveTuples(ctx context.Context, namespace string, objects []string) bool {
+	for _, o := range objects {
+		if e.d.MayHaveTuples(ctx, namespace, o) {
+			return true
+		}
+	}
+	return false
+}
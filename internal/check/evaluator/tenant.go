@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+var tenantCrossingsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "tenant_boundary_crossings_total",
+	Help:      "The number of subject sets that checks reached across a tenant boundary, by namespace of the relation tuple and the mode of its boundary.",
+}, []string{"namespace", "mode"})
+
+func init() {
+	prometheus.MustRegister(tenantCrossingsTotal)
+}
+
+// crossesTenantBoundary returns true if the subject set of the relation tuple
+// belongs to another tenant than the object, and the boundary of the
+// namespace is enforced. Crossings of boundaries that are only flagged are
+// logged.
+func (e *Evaluator) crossesTenantBoundary(ctx context.Context, rt *relationtuple.InternalRelationTuple, set *relationtuple.SubjectSet) (bool, error) {
+	opts, err := e.d.NamespaceOptions(ctx, rt.Namespace)
+	if err != nil || opts.TenantBoundary == nil || opts.TenantBoundary.Relation == rt.Relation {
+		return false, err
+	}
+	setOpts, err := e.d.NamespaceOptions(ctx, set.Namespace)
+	if err != nil || setOpts.TenantBoundary == nil {
+		return false, err
+	}
+
+	tenants, err := e.tenants(ctx, rt.Namespace, rt.Object, opts.TenantBoundary)
+	if err != nil || len(tenants) == 0 {
+		return false, err
+	}
+	setTenants, err := e.tenants(ctx, set.Namespace, set.Object, setOpts.TenantBoundary)
+	if err != nil || len(setTenants) == 0 {
+		return false, err
+	}
+	for t := range setTenants {
+		if tenants[t] {
+			return false, nil
+		}
+	}
+
+	mode := namespace.TenantBoundaryFlag
+	if opts.TenantBoundary.Enforced() {
+		mode = namespace.TenantBoundaryEnforce
+	}
+	tenantCrossingsTotal.WithLabelValues(rt.Namespace, mode).Inc()
+	requestid.Logger(ctx, e.d.Logger()).
+		WithFields(rt.ToLoggerFields()).
+		WithField("mode", mode).
+		Warn("A check reached a subject set of another tenant.")
+	return opts.TenantBoundary.Enforced(), nil
+}
+
+// tenants returns the tenants of the object, i.e. the subjects of its
+// boundary relation.
+func (e *Evaluator) tenants(ctx context.Context, ns, object string, b *namespace.TenantBoundary) (map[string]bool, error) {
+	tenants := map[string]bool{}
+	query := &relationtuple.RelationQuery{Namespace: ns, Object: object, Relation: b.Relation}
+	for page := ""; ; {
+		res, next, err := e.d.Manager(ctx).GetRelationTuples(ctx, query, x.WithToken(page))
+		if err != nil {
+			return nil, err
+		}
+		for _, rt := range res {
+			tenants[rt.Subject.String()] = true
+		}
+		if next == "" {
+			return tenants, nil
+		}
+		page = next
+	}
+}
@@ -0,0 +1,136 @@
+package check
+
+import (
+	"context"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// Expression is a boolean expression over checks. Exactly one of its fields
+// must be set.
+//
+// swagger:model checkExpression
+type Expression struct {
+	// Check is allowed if the subject has the relation on the object.
+	Check *relationtuple.InternalRelationTuple `json:"check,omitempty"`
+	// And is allowed if all of the expressions are allowed.
+	And []*Expression `json:"and,omitempty"`
+	// Or is allowed if any of the expressions is allowed.
+	Or []*Expression `json:"or,omitempty"`
+	// Not is allowed if the expression is denied.
+	Not *Expression `json:"not,omitempty"`
+}
+
+// validate returns the number of checks in the expression.
+func (ex *Expression) validate() (int, error) {
+	if ex == nil {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.ExpressionInvalid).WithReason("The expression must not be empty."))
+	}
+
+	set := 0
+	for _, ok := range []bool{ex.Check != nil, ex.And != nil, ex.Or != nil, ex.Not != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.ExpressionInvalid).WithReason("Exactly one of check, and, or, and not must be set in each expression."))
+	}
+
+	switch {
+	case ex.Check != nil:
+		if ex.Check.Subject == nil {
+			return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithReason("Each check requires a subject."))
+		}
+		return 1, nil
+	case ex.Not != nil:
+		return ex.Not.validate()
+	}
+
+	operands := ex.And
+	if ex.Or != nil {
+		operands = ex.Or
+	}
+	if len(operands) == 0 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.ExpressionInvalid).WithReason("The operands of and and or must not be empty."))
+	}
+	n := 0
+	for _, o := range operands {
+		m, err := o.validate()
+		if err != nil {
+			return 0, err
+		}
+		n += m
+	}
+	return n, nil
+}
+
+// CheckExpression evaluates the expression. All checks are evaluated at the
+// same snapshot, if the persister supports snapshots, so that concurrent
+// writes can't change the outcome halfway through. Each distinct check is
+// evaluated at most once, and evaluation stops as soon as the outcome is
+// known.
+func (e *Engine) CheckExpression(ctx context.Context, ex *Expression, restDepth int) (bool, error) {
+	n, err := ex.validate()
+	if err != nil {
+		return false, err
+	}
+	if limit := e.d.Config(ctx).MaxExpressionChecks(); n > limit {
+		return false, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.ExpressionTooLarge).WithReasonf("The expression contains %d checks, but at most %d are allowed.", n, limit).WithDetail(errorcode.DetailLimit, config.KeyLimitMaxExpressionChecks))
+	}
+
+	if sr, ok := e.d.Persister().(persistence.SnapshotReader); ok {
+		token, err := sr.SnapshotToken(ctx)
+		if err != nil {
+			return false, err
+		}
+		if ctx, err = sr.AtSnapshot(ctx, token); err != nil {
+			return false, err
+		}
+	}
+
+	results := make(map[string]bool, n)
+	var eval func(*Expression) (bool, error)
+	eval = func(ex *Expression) (bool, error) {
+		switch {
+		case ex.Check != nil:
+			key := ex.Check.String()
+			if allowed, ok := results[key]; ok {
+				return allowed, nil
+			}
+			allowed, err := e.SubjectIsAllowed(ctx, ex.Check, restDepth)
+			if err != nil {
+				return false, err
+			}
+			results[key] = allowed
+			return allowed, nil
+		case ex.Not != nil:
+			allowed, err := eval(ex.Not)
+			return !allowed, err
+		}
+
+		// And stops at the first denied operand, Or at the first allowed one.
+		isOr := ex.Or != nil
+		operands := ex.And
+		if isOr {
+			operands = ex.Or
+		}
+		for _, o := range operands {
+			allowed, err := eval(o)
+			if err != nil {
+				return false, err
+			}
+			if allowed == isOr {
+				return isOr, nil
+			}
+		}
+		return !isOr, nil
+	}
+	return eval(ex)
+}
@@ -0,0 +1,163 @@
+package check_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestCheckExpression(t *testing.T) {
+	ctx := context.Background()
+	user := &relationtuple.SubjectID{ID: "user"}
+	view := &relationtuple.InternalRelationTuple{Namespace: "folders", Object: "reports", Relation: "view", Subject: user}
+	banned := &relationtuple.InternalRelationTuple{Namespace: "orgs", Object: "acme", Relation: "banned", Subject: user}
+	edit := &relationtuple.InternalRelationTuple{Namespace: "folders", Object: "reports", Relation: "edit", Subject: user}
+
+	reg := newDepsProvider(t, []*namespace.Namespace{{Name: "folders", ID: 0}, {Name: "orgs", ID: 1}})
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, view))
+	e := check.NewEngine(reg)
+
+	c := func(r *relationtuple.InternalRelationTuple) *check.Expression {
+		return &check.Expression{Check: r}
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		ex       *check.Expression
+		expected bool
+	}{
+		{desc: "check", ex: c(view), expected: true},
+		{desc: "and not", ex: &check.Expression{And: []*check.Expression{c(view), {Not: c(banned)}}}, expected: true},
+		{desc: "and", ex: &check.Expression{And: []*check.Expression{c(view), c(edit)}}},
+		{desc: "or", ex: &check.Expression{Or: []*check.Expression{c(edit), c(view)}}, expected: true},
+		{desc: "not", ex: &check.Expression{Not: c(view)}},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.CheckExpression(ctx, tc.ex, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+
+	t.Run("case=banned", func(t *testing.T) {
+		reg := newDepsProvider(t, []*namespace.Namespace{{Name: "folders", ID: 0}, {Name: "orgs", ID: 1}})
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, view, banned))
+
+		allowed, err := check.NewEngine(reg).CheckExpression(ctx, &check.Expression{And: []*check.Expression{c(view), {Not: c(banned)}}}, 0)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("case=evaluates each check once", func(t *testing.T) {
+		reg.RequestedPages = nil
+		_, err := e.CheckExpression(ctx, c(edit), 0)
+		require.NoError(t, err)
+		single := len(reg.RequestedPages)
+		require.NotZero(t, single)
+
+		reg.RequestedPages = nil
+		allowed, err := e.CheckExpression(ctx, &check.Expression{Or: []*check.Expression{c(edit), {Not: c(edit)}}}, 0)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, single, len(reg.RequestedPages))
+	})
+
+	t.Run("case=stops once the outcome is known", func(t *testing.T) {
+		reg.RequestedPages = nil
+		_, err := e.CheckExpression(ctx, &check.Expression{And: []*check.Expression{c(edit), c(view)}}, 0)
+		require.NoError(t, err)
+		short := len(reg.RequestedPages)
+
+		reg.RequestedPages = nil
+		_, err = e.CheckExpression(ctx, c(edit), 0)
+		require.NoError(t, err)
+		assert.Equal(t, len(reg.RequestedPages), short)
+		assert.NotZero(t, short)
+	})
+
+	t.Run("case=rejects invalid expressions", func(t *testing.T) {
+		for _, ex := range []*check.Expression{
+			nil,
+			{},
+			{Check: view, Not: c(banned)},
+			{And: []*check.Expression{}},
+			{Or: []*check.Expression{c(view), {}}},
+			{Check: &relationtuple.InternalRelationTuple{Namespace: "folders", Object: "reports", Relation: "view"}},
+		} {
+			_, err := e.CheckExpression(ctx, ex, 0)
+			assert.Contains(t, []string{errorcode.ExpressionInvalid, errorcode.SubjectInvalid}, errorcode.Of(err), "%+v", err)
+		}
+	})
+
+	t.Run("case=limits the number of checks", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxExpressionChecks, 2))
+		t.Cleanup(func() {
+			require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxExpressionChecks, 16))
+		})
+
+		_, err := e.CheckExpression(ctx, &check.Expression{Or: []*check.Expression{c(view), c(edit)}}, 0)
+		require.NoError(t, err)
+
+		_, err = e.CheckExpression(ctx, &check.Expression{Or: []*check.Expression{c(view), c(edit), {Not: c(banned)}}}, 0)
+		assert.True(t, errorcode.Is(err, errorcode.ExpressionTooLarge), "%+v", err)
+	})
+}
+
+func TestCheckExpressionHandler(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "folders", ID: 0}, {Name: "orgs", ID: 1}}))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+		Namespace: "folders",
+		Object:    "reports",
+		Relation:  "view",
+		Subject:   &relationtuple.SubjectID{ID: "user"},
+	}))
+
+	r := httprouter.New()
+	check.NewHandler(reg).RegisterReadRoutes(&x.ReadRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	post := func(t *testing.T, body string) (int, []byte) {
+		resp, err := ts.Client().Post(ts.URL+check.ExpressionRoute, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, raw
+	}
+
+	status, body := post(t, `{"and": [
+		{"check": {"namespace": "folders", "object": "reports", "relation": "view", "subject_id": "user"}},
+		{"not": {"check": {"namespace": "orgs", "object": "acme", "relation": "banned", "subject_id": "user"}}}
+	]}`)
+	require.Equal(t, http.StatusOK, status, "%s", body)
+	assert.True(t, gjson.GetBytes(body, "allowed").Bool())
+
+	status, body = post(t, `{"not": {"check": {"namespace": "folders", "object": "reports", "relation": "view", "subject_id": "user"}}}`)
+	require.Equal(t, http.StatusOK, status, "%s", body)
+	assert.False(t, gjson.GetBytes(body, "allowed").Bool())
+
+	status, body = post(t, `{"and": []}`)
+	assert.Equal(t, http.StatusBadRequest, status, "%s", body)
+
+	status, body = post(t, `{"and": `)
+	assert.Equal(t, http.StatusBadRequest, status, "%s", body)
+}
@@ -3,16 +3,21 @@ package check
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/ory/herodot"
 	"github.com/pkg/errors"
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 
 	"google.golang.org/grpc"
 
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/oidc"
 	"github.com/ory/keto/internal/relationtuple"
 
 	"github.com/julienschmidt/httprouter"
@@ -23,6 +28,8 @@ import (
 type (
 	handlerDependencies interface {
 		EngineProvider
+		config.Provider
+		oidc.VerifierProvider
 		x.LoggerProvider
 		x.WriterProvider
 	}
@@ -40,6 +47,8 @@ func NewHandler(d handlerDependencies) *Handler {
 const (
 	RouteBase        = "/relation-tuples/check"
 	OpenAPIRouteBase = RouteBase + "/openapi"
+	ExpressionRoute  = RouteBase + "/expression"
+	RouteAccessDiff  = "/admin/access-diff"
 )
 
 func (h *Handler) RegisterReadRoutes(r *x.ReadRouter) {
@@ -47,9 +56,12 @@ func (h *Handler) RegisterReadRoutes(r *x.ReadRouter) {
 	r.GET(OpenAPIRouteBase, h.getCheckNoStatus)
 	r.POST(RouteBase, h.postCheckMirrorStatus)
 	r.POST(OpenAPIRouteBase, h.postCheckNoStatus)
+	r.POST(ExpressionRoute, h.postCheckExpression)
 }
 
-func (h *Handler) RegisterWriteRoutes(_ *x.WriteRouter) {}
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteAccessDiff, h.getAccessDiff)
+}
 
 func (h *Handler) RegisterReadGRPC(s *grpc.Server) {
 	rts.RegisterCheckServiceServer(s, h)
@@ -95,11 +107,12 @@ type getCheckRequest struct {
 //       400: genericError
 //       500: genericError
 func (h *Handler) getCheckNoStatus(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	allowed, err := h.getCheck(r.Context(), r.URL.Query())
+	allowed, err := h.getCheck(r.Context(), r)
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
+	h.setCacheHeaders(w, r)
 	h.d.Writer().Write(w, r, &RESTResponse{Allowed: allowed})
 }
 
@@ -123,11 +136,12 @@ func (h *Handler) getCheckNoStatus(w http.ResponseWriter, r *http.Request, _ htt
 //       403: getCheckResponse
 //       500: genericError
 func (h *Handler) getCheckMirrorStatus(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	allowed, err := h.getCheck(r.Context(), r.URL.Query())
+	allowed, err := h.getCheck(r.Context(), r)
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
+	h.setCacheHeaders(w, r)
 
 	if allowed {
 		h.d.Writer().Write(w, r, &RESTResponse{Allowed: allowed})
@@ -137,12 +151,23 @@ func (h *Handler) getCheckMirrorStatus(w http.ResponseWriter, r *http.Request, _
 	h.d.Writer().WriteCode(w, r, http.StatusForbidden, &RESTResponse{Allowed: allowed})
 }
 
-func (h *Handler) getCheck(ctx context.Context, q url.Values) (bool, error) {
+func (h *Handler) getCheck(ctx context.Context, r *http.Request) (bool, error) {
+	q := r.URL.Query()
 	maxDepth, err := x.GetMaxDepthFromQuery(q)
 	if err != nil {
 		return false, err
 	}
 
+	if token, ok := h.bearerToken(ctx, r); ok {
+		query, err := (&relationtuple.RelationQuery{}).FromURLQuery(q)
+		if err != nil {
+			return false, err
+		}
+		if query.Subject() == nil {
+			return h.checkWithToken(ctx, token, query, maxDepth)
+		}
+	}
+
 	tuple, err := (&relationtuple.InternalRelationTuple{}).FromURLQuery(q)
 	if err != nil {
 		return false, err
@@ -151,6 +176,67 @@ func (h *Handler) getCheck(ctx context.Context, q url.Values) (bool, error) {
 	return h.d.PermissionEngine().SubjectIsAllowed(ctx, tuple, maxDepth)
 }
 
+// setCacheHeaders allows caches to serve the check result for the configured
+// staleness window. The result is treated as of the start of the current
+// snapshot quantization interval, which the Age header reflects, so that it
+// expires in all caches at the same time regardless of the instance that
+// evaluated it. Results of bearer token checks are only cached by the client.
+func (h *Handler) setCacheHeaders(w http.ResponseWriter, r *http.Request) {
+	window, quantization := h.d.Config(r.Context()).Staleness()
+	if window <= 0 {
+		return
+	}
+
+	var age time.Duration
+	if quantization > 0 {
+		now := time.Now()
+		age = now.Sub(now.Truncate(quantization))
+	}
+	if age >= window {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+
+	scope := "public"
+	if _, ok := h.bearerToken(r.Context(), r); ok {
+		scope = "private"
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(window/time.Second)))
+	w.Header().Set("Age", strconv.Itoa(int(age/time.Second)))
+}
+
+// bearerToken returns the bearer token of the request if OIDC is enabled. The
+// token is only used for checks without a subject, as gateways might forward
+// the authorization header anyway.
+func (h *Handler) bearerToken(ctx context.Context, r *http.Request) (string, bool) {
+	if !h.d.Config(ctx).OIDCEnabled() {
+		return "", false
+	}
+	return oidc.BearerToken(r)
+}
+
+// checkWithToken checks the query for the subjects derived from the bearer
+// token. The subject of the query is ignored.
+func (h *Handler) checkWithToken(ctx context.Context, token string, query *relationtuple.RelationQuery, maxDepth int) (bool, error) {
+	subjects, err := h.d.OIDCVerifier().Subjects(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range subjects {
+		allowed, err := h.d.PermissionEngine().SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: query.Namespace,
+			Object:    query.Object,
+			Relation:  query.Relation,
+			Subject:   s,
+		}, maxDepth)
+		if err != nil || allowed {
+			return allowed, err
+		}
+	}
+	return false, nil
+}
+
 // swagger:route POST /relation-tuples/check/openapi read postCheck
 //
 // Check a relation tuple
@@ -170,7 +256,7 @@ func (h *Handler) getCheck(ctx context.Context, q url.Values) (bool, error) {
 //       400: genericError
 //       500: genericError
 func (h *Handler) postCheckNoStatus(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	allowed, err := h.postCheck(r.Context(), r.Body, r.URL.Query())
+	allowed, err := h.postCheck(r.Context(), r)
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
@@ -198,7 +284,7 @@ func (h *Handler) postCheckNoStatus(w http.ResponseWriter, r *http.Request, _ ht
 //       403: getCheckResponse
 //       500: genericError
 func (h *Handler) postCheckMirrorStatus(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	allowed, err := h.postCheck(r.Context(), r.Body, r.URL.Query())
+	allowed, err := h.postCheck(r.Context(), r)
 	if err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
@@ -212,21 +298,102 @@ func (h *Handler) postCheckMirrorStatus(w http.ResponseWriter, r *http.Request,
 	h.d.Writer().WriteCode(w, r, http.StatusForbidden, &RESTResponse{Allowed: allowed})
 }
 
-func (h *Handler) postCheck(ctx context.Context, body io.Reader, query url.Values) (bool, error) {
-	maxDepth, err := x.GetMaxDepthFromQuery(query)
+func (h *Handler) postCheck(ctx context.Context, r *http.Request) (bool, error) {
+	maxDepth, err := x.GetMaxDepthFromQuery(r.URL.Query())
 	if err != nil {
 		return false, err
 	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	if token, ok := h.bearerToken(ctx, r); ok {
+		var query relationtuple.RelationQuery
+		if err := json.Unmarshal(body, &query); err != nil {
+			return false, errors.WithStack(err)
+		}
+		if query.Subject() == nil {
+			return h.checkWithToken(ctx, token, &query, maxDepth)
+		}
+	}
+
 	var tuple relationtuple.InternalRelationTuple
-	if err := json.NewDecoder(body).Decode(&tuple); err != nil {
+	if err := json.Unmarshal(body, &tuple); err != nil {
 		return false, errors.WithStack(err)
 	}
 
 	return h.d.PermissionEngine().SubjectIsAllowed(ctx, &tuple, maxDepth)
 }
 
+// swagger:parameters postCheckExpression
+// nolint:deadcode,unused
+type postCheckExpressionRequest struct {
+	// in: query
+	MaxDepth int `json:"max-depth"`
+
+	// in: body
+	// required: true
+	Body Expression
+}
+
+// swagger:route POST /relation-tuples/check/expression read postCheckExpression
+//
+// Check a permission expression
+//
+// Evaluates a boolean expression over several checks in one request, e.g.
+// whether the subject may view the folder and is not banned from the
+// organization. All checks are evaluated at the same snapshot. The maximum
+// depth applies to each check.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getCheckResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) postCheckExpression(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	maxDepth, err := x.GetMaxDepthFromQuery(r.URL.Query())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var ex Expression
+	if err := json.NewDecoder(r.Body).Decode(&ex); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	allowed, err := h.d.PermissionEngine().CheckExpression(r.Context(), &ex, maxDepth)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, &RESTResponse{Allowed: allowed})
+}
+
 func (h *Handler) Check(ctx context.Context, req *rts.CheckRequest) (*rts.CheckResponse, error) {
+	// As on the REST API, the token is only used for requests without a
+	// subject, as gateways might forward the authorization metadata anyway.
+	if token, ok := oidc.BearerTokenFromContext(ctx); ok && req.Subject == nil && h.d.Config(ctx).OIDCEnabled() {
+		allowed, err := h.checkWithToken(ctx, token, &relationtuple.RelationQuery{
+			Namespace: req.Namespace,
+			Object:    req.Object,
+			Relation:  req.Relation,
+		}, int(req.MaxDepth))
+		if err != nil {
+			return nil, err
+		}
+		return &rts.CheckResponse{Allowed: allowed, Snaptoken: "not yet implemented"}, nil
+	}
+
 	tuple, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,92 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestHierarchy(t *testing.T) {
+	ctx := context.Background()
+	alice, bob := &relationtuple.SubjectID{ID: "alice"}, &relationtuple.SubjectID{ID: "bob"}
+
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "files", Config: json.RawMessage(`{"hierarchy": {"relations": ["viewer"]}}`)},
+		{ID: 1, Name: "groups"},
+	})
+	require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyObjectFilterEnabled, true))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder", Relation: "viewer", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder", Relation: "owner", Subject: alice},
+		&relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "groups", Object: "staff", Relation: "member"}},
+		&relationtuple.InternalRelationTuple{Namespace: "groups", Object: "staff", Relation: "member", Subject: bob},
+	))
+	e := check.NewEngine(reg)
+
+	// The ancestors are queried through the wrappers of the manager.
+	_, ok := reg.RelationTupleManager().(relationtuple.ObjectsGetter)
+	require.True(t, ok)
+
+	for _, tc := range []struct {
+		desc     string
+		rt       *relationtuple.InternalRelationTuple
+		expected bool
+	}{
+		{
+			desc:     "inherits from the parent",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder/file", Relation: "viewer", Subject: alice},
+			expected: true,
+		},
+		{
+			desc:     "inherits through subject sets of the root",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder/sub/file", Relation: "viewer", Subject: bob},
+			expected: true,
+		},
+		{
+			desc: "does not inherit from descendants",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive", Relation: "viewer", Subject: alice},
+		},
+		{
+			desc: "does not inherit from siblings",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder2/file", Relation: "viewer", Subject: alice},
+		},
+		{
+			desc: "does not inherit other relations",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "files", Object: "drive/folder/file", Relation: "owner", Subject: alice},
+		},
+		{
+			desc: "does not inherit in other namespaces",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "staff/team", Relation: "member", Subject: bob},
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.SubjectIsAllowed(ctx, tc.rt, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+}
+
+func TestHierarchyAncestors(t *testing.T) {
+	for _, tc := range []struct {
+		h         *namespace.Hierarchy
+		object    string
+		ancestors []string
+	}{
+		{h: &namespace.Hierarchy{}, object: "a/b/c", ancestors: []string{"a/b", "a"}},
+		{h: &namespace.Hierarchy{}, object: "a"},
+		{h: &namespace.Hierarchy{}, object: "/a//b/", ancestors: []string{"/a//b", "/a"}},
+		{h: &namespace.Hierarchy{Separator: "::"}, object: "a::b::c", ancestors: []string{"a::b", "a"}},
+	} {
+		assert.Equal(t, tc.ancestors, tc.h.Ancestors(tc.object), tc.object)
+	}
+}
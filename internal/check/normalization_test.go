@@ -0,0 +1,91 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestCheckNormalizedIdentifiers(t *testing.T) {
+	const composed, decomposed = "caf\u00e9", "cafe\u0301"
+
+	ctx := context.Background()
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "normalized", Config: json.RawMessage(`{"unicode_normalization": "nfc"}`)},
+		{ID: 1, Name: "verbatim"},
+		{ID: 2, Name: "case-insensitive", Config: json.RawMessage(`{"case_insensitive": true}`)},
+	})
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		&relationtuple.InternalRelationTuple{Namespace: "normalized", Object: "doc", Relation: "owner", Subject: &relationtuple.SubjectID{ID: decomposed}},
+		&relationtuple.InternalRelationTuple{Namespace: "verbatim", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "normalized", Object: decomposed, Relation: "owner"}},
+		&relationtuple.InternalRelationTuple{Namespace: "normalized", Object: decomposed, Relation: "owner", Subject: &relationtuple.SubjectID{ID: "user"}},
+		&relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "Alice@Example.com"}},
+		&relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "verbatim", Object: "Staff", Relation: "member"}},
+		&relationtuple.InternalRelationTuple{Namespace: "verbatim", Object: "Staff", Relation: "member", Subject: &relationtuple.SubjectID{ID: "Bob"}},
+	))
+	e := check.NewEngine(reg)
+
+	for _, tc := range []struct {
+		desc     string
+		rt       *relationtuple.InternalRelationTuple
+		expected bool
+	}{
+		{
+			desc:     "decomposed subject ID",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "normalized", Object: "doc", Relation: "owner", Subject: &relationtuple.SubjectID{ID: decomposed}},
+			expected: true,
+		},
+		{
+			desc:     "composed subject ID",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "normalized", Object: "doc", Relation: "owner", Subject: &relationtuple.SubjectID{ID: composed}},
+			expected: true,
+		},
+		{
+			desc:     "decomposed subject set object normalized by its namespace",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "verbatim", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "normalized", Object: decomposed, Relation: "owner"}},
+			expected: true,
+		},
+		{
+			desc:     "subject through a decomposed subject set",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "verbatim", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "user"}},
+			expected: true,
+		},
+		{
+			desc:     "subject ID as written to a case-insensitive namespace",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "Alice@Example.com"}},
+			expected: true,
+		},
+		{
+			desc:     "subject ID in another case",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "DOC", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice@example.COM"}},
+			expected: true,
+		},
+		{
+			desc:     "subject set as written to a case-insensitive namespace",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "verbatim", Object: "Staff", Relation: "member"}},
+			expected: true,
+		},
+		{
+			desc:     "subject ID of a case-sensitive subject set keeps its case",
+			rt:       &relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "Bob"}},
+			expected: true,
+		},
+		{
+			desc: "subject ID of a case-sensitive subject set in another case",
+			rt:   &relationtuple.InternalRelationTuple{Namespace: "case-insensitive", Object: "doc", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "bob"}},
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			allowed, err := e.SubjectIsAllowed(ctx, tc.rt, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+}
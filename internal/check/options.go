@@ -0,0 +1,61 @@
+package check
+
+import (
+	"context"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/keto/internal/check/evaluator"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// evaluatorDependencies provides the evaluator with the namespaces of the
+// config and the relation tuples of the registry.
+type evaluatorDependencies struct {
+	e *Engine
+}
+
+var _ evaluator.Dependencies = (*evaluatorDependencies)(nil)
+
+func (d *evaluatorDependencies) Logger() *logrusx.Logger {
+	return d.e.d.Logger()
+}
+
+// NamespaceOptions returns the options of the namespace, or the defaults if
+// the namespace is unknown.
+func (d *evaluatorDependencies) NamespaceOptions(ctx context.Context, name string) (*namespace.Options, error) {
+	nm, err := d.e.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	n, err := nm.GetNamespaceByName(ctx, name)
+	if errorcode.Is(err, errorcode.NamespaceUnknown) {
+		return &namespace.Options{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return namespace.ResolvedOptions(ctx, nm, n)
+}
+
+func (d *evaluatorDependencies) Manager(ctx context.Context) relationtuple.Manager {
+	return d.e.manager(ctx)
+}
+
+// ObjectsGetter returns the manager of the checks if it can query several
+// objects at once, so that the queries of ancestors pass the same wrappers as
+// the ones of single objects.
+func (d *evaluatorDependencies) ObjectsGetter(ctx context.Context) (relationtuple.ObjectsGetter, bool) {
+	getter, ok := d.e.manager(ctx).(relationtuple.ObjectsGetter)
+	return getter, ok
+}
+
+func (d *evaluatorDependencies) MayHaveTuples(ctx context.Context, namespace, object string) bool {
+	return d.e.d.ObjectFilter().MayHaveTuples(ctx, namespace, object)
+}
+
+func (d *evaluatorDependencies) DirectMatchFirst(ctx context.Context, namespace string) bool {
+	return featureflag.EnabledIn(ctx, d.e.d.Config(ctx), featureflag.DirectMatchFirst, namespace)
+}
@@ -0,0 +1,165 @@
+package check
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+const (
+	shadowResultMatch     = "match"
+	shadowResultDivergent = "divergent"
+	shadowResultError     = "error"
+	shadowResultSkipped   = "skipped"
+
+	// shadowCheckTimeout bounds a shadow evaluation, which is detached from
+	// the deadline of its request.
+	shadowCheckTimeout = 10 * time.Second
+	// maxShadowChecks bounds the shadow evaluations running at the same
+	// time. Checks sampled while all are running are skipped.
+	maxShadowChecks = 16
+
+	strategyCurrent   = "current"
+	strategyCandidate = "candidate"
+)
+
+var canaryChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "canary_checks_total",
+	Help:      "The number of checks that were also evaluated against the canary namespace config, by the namespace of the check and whether the result matched the active config.",
+}, []string{"namespace", "result"})
+
+var (
+	shadowChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "keto",
+		Name:      "shadow_checks_total",
+		Help:      "The number of checks that were also evaluated with the candidate strategy, by the namespace of the check and whether the result matched the current strategy.",
+	}, []string{"namespace", "result"})
+	shadowCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "keto",
+		Name:      "shadow_check_duration_seconds",
+		Help:      "The duration of the shadow evaluated checks, by the namespace of the check and the strategy.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"namespace", "strategy"})
+)
+
+func init() {
+	prometheus.MustRegister(canaryChecksTotal, shadowChecksTotal, shadowCheckDuration)
+}
+
+func (e *Engine) sampleShadowCheck(ctx context.Context) bool {
+	if config.IsCanary(ctx) {
+		return false
+	}
+	rate := e.d.Config(ctx).CanarySampleRate()
+	return rate > 0 && rand.Float64() < rate // #nosec G404 -- sampling does not need a secure random source
+}
+
+// goShadow runs the shadow evaluation in the background, so that the live
+// result is returned without waiting for it. The evaluation keeps the values
+// of the request context, e.g. the network, but not its cancellation, and has
+// its own timeout. If too many shadow evaluations are running, the check is
+// counted as skipped in total instead.
+func (e *Engine) goShadow(ctx context.Context, r *relationtuple.InternalRelationTuple, total *prometheus.CounterVec, evaluate func(ctx context.Context)) {
+	select {
+	case e.shadowSlots <- struct{}{}:
+	default:
+		total.WithLabelValues(r.Namespace, shadowResultSkipped).Inc()
+		requestid.Logger(ctx, e.d.Logger()).WithFields(r.ToLoggerFields()).Debug("Too many shadow evaluations are running, the check is not shadow evaluated.")
+		return
+	}
+
+	e.shadows.Add(1)
+	go func() {
+		defer func() {
+			<-e.shadowSlots
+			e.shadows.Done()
+		}()
+		ctx, cancel := context.WithTimeout(x.DetachedContext(ctx), shadowCheckTimeout)
+		defer cancel()
+		evaluate(ctx)
+	}()
+}
+
+// WaitShadowChecks waits until the shadow evaluations running in the
+// background are done.
+func (e *Engine) WaitShadowChecks() {
+	e.shadows.Wait()
+}
+
+// shadowCheck evaluates the check against the canary namespace config and
+// reports whether the result diverges from the live one.
+func (e *Engine) shadowCheck(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int, live bool) {
+	l := requestid.Logger(ctx, e.d.Logger()).WithFields(r.ToLoggerFields())
+
+	canary, err := e.ev.Evaluate(config.WithCanary(ctx), r, restDepth)
+	if err != nil {
+		canaryChecksTotal.WithLabelValues(r.Namespace, shadowResultError).Inc()
+		l.WithError(err).Warn("Could not evaluate the check against the canary namespace config.")
+		return
+	}
+	if canary != live {
+		canaryChecksTotal.WithLabelValues(r.Namespace, shadowResultDivergent).Inc()
+		l.WithField("allowed", live).
+			WithField("canary_allowed", canary).
+			Info("The result of the check diverges with the canary namespace config.")
+		return
+	}
+	canaryChecksTotal.WithLabelValues(r.Namespace, shadowResultMatch).Inc()
+}
+
+// sampleCandidateCheck returns the feature flags of the candidate strategy if
+// the check is to be evaluated with it.
+func (e *Engine) sampleCandidateCheck(ctx context.Context) map[featureflag.Flag]bool {
+	if config.IsCanary(ctx) {
+		return nil
+	}
+	c := e.d.Config(ctx)
+	rate := c.ShadowSampleRate()
+	if rate <= 0 || rand.Float64() >= rate { // #nosec G404 -- sampling does not need a secure random source
+		return nil
+	}
+	flags := make(map[featureflag.Flag]bool)
+	for name, enabled := range c.ShadowFeatureFlags() {
+		flags[featureflag.Flag(name)] = enabled
+	}
+	return flags
+}
+
+// candidateCheck evaluates the check with the candidate strategy, and reports
+// whether the result diverges from the current one and how long both took.
+func (e *Engine) candidateCheck(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int, flags map[featureflag.Flag]bool, current bool, currentDuration time.Duration) {
+	l := requestid.Logger(ctx, e.d.Logger()).WithFields(r.ToLoggerFields())
+
+	start := time.Now()
+	candidate, err := e.ev.Evaluate(featureflag.WithOverrides(ctx, flags), r, restDepth)
+	candidateDuration := time.Since(start)
+	if err != nil {
+		shadowChecksTotal.WithLabelValues(r.Namespace, shadowResultError).Inc()
+		l.WithError(err).Warn("Could not evaluate the check with the candidate strategy.")
+		return
+	}
+	shadowCheckDuration.WithLabelValues(r.Namespace, strategyCurrent).Observe(currentDuration.Seconds())
+	shadowCheckDuration.WithLabelValues(r.Namespace, strategyCandidate).Observe(candidateDuration.Seconds())
+
+	l = l.WithField("duration", currentDuration).
+		WithField("candidate_duration", candidateDuration).
+		WithField("duration_delta", candidateDuration-currentDuration)
+	if candidate != current {
+		shadowChecksTotal.WithLabelValues(r.Namespace, shadowResultDivergent).Inc()
+		l.WithField("allowed", current).
+			WithField("candidate_allowed", candidate).
+			Info("The result of the check diverges with the candidate strategy.")
+		return
+	}
+	shadowChecksTotal.WithLabelValues(r.Namespace, shadowResultMatch).Inc()
+	l.Trace("The result of the check matches with the candidate strategy.")
+}
@@ -0,0 +1,89 @@
+package check_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestTenantBoundary(t *testing.T) {
+	ctx := context.Background()
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+
+	for _, tc := range []struct {
+		mode     string
+		expected bool
+	}{
+		{mode: namespace.TenantBoundaryEnforce, expected: false},
+		{mode: namespace.TenantBoundaryFlag, expected: true},
+	} {
+		t.Run("mode="+tc.mode, func(t *testing.T) {
+			boundary := json.RawMessage(`{"tenant_boundary": {"relation": "tenant", "mode": "` + tc.mode + `"}}`)
+			reg := newDepsProvider(t, []*namespace.Namespace{
+				{ID: 0, Name: "docs", Config: boundary},
+				{ID: 1, Name: "groups", Config: boundary},
+			})
+			// The subject sets are written before the groups are assigned
+			// to tenants, as crossing subject sets are rejected afterwards.
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+				tuple("docs:report#viewer@groups:acme-staff#member"),
+				tuple("docs:report#viewer@groups:evil-staff#member"),
+				tuple("groups:acme-staff#member@alice"),
+				tuple("groups:evil-staff#member@mallory"),
+				tuple("docs:report#tenant@acme"),
+				tuple("groups:acme-staff#tenant@acme"),
+				tuple("groups:evil-staff#tenant@evil"),
+			))
+			e := check.NewEngine(reg)
+
+			allowed, err := e.SubjectIsAllowed(ctx, tuple("docs:report#viewer@alice"), 0)
+			require.NoError(t, err)
+			assert.True(t, allowed, "same tenant")
+
+			allowed, err = e.SubjectIsAllowed(ctx, tuple("docs:report#viewer@mallory"), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed, "other tenant")
+		})
+	}
+}
+
+func TestTenantBoundaryPagination(t *testing.T) {
+	ctx := context.Background()
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+
+	boundary := json.RawMessage(`{"tenant_boundary": {"relation": "tenant", "mode": "` + namespace.TenantBoundaryEnforce + `"}}`)
+	// Every page holds one relation tuple, so the shared tenant is only on
+	// the last page of the tenants of the document.
+	reg := newDepsProvider(t, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: boundary},
+		{ID: 1, Name: "groups", Config: boundary},
+	}, x.WithSize(1))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		tuple("docs:report#viewer@groups:acme-staff#member"),
+		tuple("groups:acme-staff#member@alice"),
+		tuple("docs:report#tenant@globex"),
+		tuple("docs:report#tenant@initech"),
+		tuple("docs:report#tenant@acme"),
+		tuple("groups:acme-staff#tenant@acme"),
+	))
+
+	allowed, err := check.NewEngine(reg).SubjectIsAllowed(ctx, tuple("docs:report#viewer@alice"), 0)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,194 @@
+package check
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/objectfilter"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/graph"
+)
+
+type (
+	VerifierProvider interface {
+		ConsistencyVerifier() *Verifier
+	}
+	VerifierDependencies interface {
+		EngineProvider
+		config.Provider
+		x.LoggerProvider
+	}
+	// Verifier evaluates a sample of the checks again without the object
+	// filter, and reports the checks whose results diverge. It evaluates them
+	// with the engine directly, so they are neither proxied nor metered, and
+	// reads the relation tuples from the persister instead of the relation
+	// tuple manager, so that e.g. injected faults don't show up as
+	// divergences. The query cache is bypassed as well.
+	Verifier struct {
+		d VerifierDependencies
+	}
+	// Divergence is a sampled check whose result differs from the result
+	// without the object filter.
+	Divergence struct {
+		RelationTuple *relationtuple.InternalRelationTuple
+		MaxDepth      int
+		CheckedAt     time.Time
+		// Allowed is the result of the sampled check.
+		Allowed bool
+		// VerifiedAllowed is the result without the object filter.
+		VerifiedAllowed bool
+	}
+	// sample is a check kept for the verifier. It keeps the values of the
+	// request context, e.g. the network and the request ID, so that it is
+	// verified like the request, but not its cancellation.
+	sample struct {
+		ctx       context.Context
+		r         *relationtuple.InternalRelationTuple
+		restDepth int
+		allowed   bool
+		checkedAt time.Time
+	}
+	verificationContextKey struct{}
+)
+
+const (
+	verifiedMatch     = "match"
+	verifiedDivergent = "divergent"
+	verifiedChanged   = "changed"
+	verifiedError     = "error"
+)
+
+var verifiedChecksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "consistency_verifier_checks_total",
+	Help:      "The number of sampled checks that were evaluated again by the consistency verifier, by the namespace of the check and whether the result matched.",
+}, []string{"namespace", "result"})
+
+func init() {
+	prometheus.MustRegister(verifiedChecksTotal)
+}
+
+func NewVerifier(d VerifierDependencies) *Verifier {
+	return &Verifier{d: d}
+}
+
+// manager returns the relation tuple manager to evaluate checks with, which
+// is the persister during verifications.
+func (e *Engine) manager(ctx context.Context) relationtuple.Manager {
+	if ctx.Value(verificationContextKey{}) != nil {
+		return e.d.Persister()
+	}
+	return e.d.RelationTupleManager()
+}
+
+// recordSample keeps the check for the consistency verifier if it is sampled.
+func (e *Engine) recordSample(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int, allowed bool) {
+	c := e.d.Config(ctx)
+	if !c.ConsistencyVerifierEnabled() || config.IsCanary(ctx) {
+		return
+	}
+	rate, _, maxSamples := c.ConsistencyVerifier()
+	if rate <= 0 || rand.Float64() >= rate { // #nosec G404 -- sampling does not need a secure random source
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.samples = append(e.samples, &sample{
+		ctx:       x.DetachedContext(ctx),
+		r:         r,
+		restDepth: restDepth,
+		allowed:   allowed,
+		checkedAt: time.Now(),
+	})
+	if over := len(e.samples) - maxSamples; over > 0 {
+		e.samples = append([]*sample(nil), e.samples[over:]...)
+	}
+}
+
+func (e *Engine) takeSamples() []*sample {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	samples := e.samples
+	e.samples = nil
+	return samples
+}
+
+// Verify evaluates the checks sampled since the last verification again, and
+// returns those whose results diverge. A check whose result differs only
+// because the relation tuples changed since is not returned, as the regular
+// evaluation also returns the new result. The checks are evaluated in the
+// network of their request, and the verification stops when the context is
+// canceled.
+func (v *Verifier) Verify(ctx context.Context) []*Divergence {
+	e := v.d.PermissionEngine()
+
+	var divergences []*Divergence
+	for _, s := range e.takeSamples() {
+		if ctx.Err() != nil {
+			return divergences
+		}
+		sctx := persistence.WithoutCache(context.WithValue(s.ctx, verificationContextKey{}, true))
+		l := requestid.Logger(sctx, v.d.Logger()).WithFields(s.r.ToLoggerFields())
+
+		verified, err := e.ev.Evaluate(objectfilter.WithoutFilter(graph.NewTraversal(sctx)), s.r, s.restDepth)
+		if err != nil {
+			verifiedChecksTotal.WithLabelValues(s.r.Namespace, verifiedError).Inc()
+			l.WithError(err).Warn("Could not verify the check.")
+			continue
+		}
+		if verified == s.allowed {
+			verifiedChecksTotal.WithLabelValues(s.r.Namespace, verifiedMatch).Inc()
+			continue
+		}
+
+		current, err := e.ev.Evaluate(graph.NewTraversal(sctx), s.r, s.restDepth)
+		if err != nil {
+			verifiedChecksTotal.WithLabelValues(s.r.Namespace, verifiedError).Inc()
+			l.WithError(err).Warn("Could not verify the check.")
+			continue
+		}
+		if current == verified {
+			verifiedChecksTotal.WithLabelValues(s.r.Namespace, verifiedChanged).Inc()
+			l.Debug("The result of the check changed since it was sampled.")
+			continue
+		}
+
+		verifiedChecksTotal.WithLabelValues(s.r.Namespace, verifiedDivergent).Inc()
+		l.WithField("max_depth", s.restDepth).
+			WithField("checked_at", s.checkedAt).
+			WithField("allowed", s.allowed).
+			WithField("verified_allowed", verified).
+			Error("The result of the check diverges when evaluated without the object filter.")
+		divergences = append(divergences, &Divergence{
+			RelationTuple:   s.r,
+			MaxDepth:        s.restDepth,
+			CheckedAt:       s.checkedAt,
+			Allowed:         s.allowed,
+			VerifiedAllowed: verified,
+		})
+	}
+	return divergences
+}
+
+// Run verifies the sampled checks periodically until the context is canceled.
+func (v *Verifier) Run(ctx context.Context) error {
+	for {
+		_, interval, _ := v.d.Config(ctx).ConsistencyVerifier()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		if v.d.Config(ctx).ConsistencyVerifierEnabled() {
+			v.Verify(ctx)
+		}
+	}
+}
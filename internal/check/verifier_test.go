@@ -0,0 +1,143 @@
+package check_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/networkx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/ketoctx"
+)
+
+type (
+	// networkContextualizer serves the network of the context, if it has one.
+	networkContextualizer struct {
+		ketoctx.DefaultContextualizer
+	}
+	networkContextKey struct{}
+)
+
+func (*networkContextualizer) Network(ctx context.Context, network uuid.UUID) uuid.UUID {
+	if nid, ok := ctx.Value(networkContextKey{}).(uuid.UUID); ok {
+		return nid
+	}
+	return network
+}
+
+func TestVerifier(t *testing.T) {
+	ctx := context.Background()
+	tuple := func(object string) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{Namespace: "files", Object: object, Relation: "view", Subject: &relationtuple.SubjectID{ID: "user"}}
+	}
+
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}}))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyObjectFilterEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyConsistencyVerifierEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyConsistencyVerifierSampleRate, 1))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("shared file")))
+	require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+	check := func(t *testing.T, object string, expected bool) {
+		allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple(object), 0)
+		require.NoError(t, err)
+		require.Equal(t, expected, allowed)
+	}
+
+	t.Run("case=matching results", func(t *testing.T) {
+		check(t, "shared file", true)
+		check(t, "private file", false)
+		assert.Empty(t, reg.ConsistencyVerifier().Verify(ctx))
+	})
+
+	t.Run("case=changed relation tuples", func(t *testing.T) {
+		check(t, "new file", false)
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("new file")))
+		assert.Empty(t, reg.ConsistencyVerifier().Verify(ctx))
+	})
+
+	t.Run("case=diverging object filter", func(t *testing.T) {
+		// writing to the persister bypasses the object filter updates
+		require.NoError(t, reg.Persister().WriteRelationTuples(ctx, tuple("unfiltered file")))
+		check(t, "unfiltered file", false)
+
+		divergences := reg.ConsistencyVerifier().Verify(ctx)
+		require.Len(t, divergences, 1)
+		assert.Equal(t, tuple("unfiltered file"), divergences[0].RelationTuple)
+		assert.False(t, divergences[0].Allowed)
+		assert.True(t, divergences[0].VerifiedAllowed)
+
+		assert.Empty(t, reg.ConsistencyVerifier().Verify(ctx), "samples are verified once")
+	})
+
+	t.Run("case=verifications are not metered", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyMeteringEnabled, true))
+		t.Cleanup(func() { require.NoError(t, reg.Config(ctx).Set(config.KeyMeteringEnabled, false)) })
+		checks := func() int64 {
+			usage, err := reg.Meter().Usage(ctx)
+			require.NoError(t, err)
+			var n int64
+			for _, u := range usage {
+				n += u.Checks
+			}
+			return n
+		}
+
+		check(t, "shared file", true)
+		before := checks()
+		assert.Empty(t, reg.ConsistencyVerifier().Verify(ctx))
+		assert.Equal(t, before, checks())
+	})
+
+	t.Run("case=outlives the request", func(t *testing.T) {
+		rctx, cancel := context.WithCancel(ctx)
+		allowed, err := reg.PermissionEngine().SubjectIsAllowed(rctx, tuple("shared file"), 0)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		cancel()
+
+		// The sampled check is verified with the values of the request
+		// context, but it is not canceled with the request.
+		require.NoError(t, reg.Persister().WriteRelationTuples(ctx, tuple("late file")))
+		check(t, "late file", false)
+		divergences := reg.ConsistencyVerifier().Verify(ctx)
+		require.Len(t, divergences, 1)
+		assert.Equal(t, tuple("late file"), divergences[0].RelationTuple)
+	})
+
+	t.Run("case=keeps the latest samples", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyConsistencyVerifierMaxSamples, 1))
+		check(t, "unfiltered file", false)
+		check(t, "shared file", true)
+		assert.Empty(t, reg.ConsistencyVerifier().Verify(ctx))
+	})
+
+	t.Run("case=verifies checks in their network", func(t *testing.T) {
+		p, ok := reg.Persister().(*sql.Persister)
+		require.True(t, ok)
+		other := networkx.NewNetwork()
+		require.NoError(t, p.Connection(ctx).Create(other))
+		reg.WithContextualizer(&networkContextualizer{})
+		t.Cleanup(func() { reg.WithContextualizer(&ketoctx.DefaultContextualizer{}) })
+
+		octx := context.WithValue(ctx, networkContextKey{}, other.ID)
+		require.NoError(t, reg.ObjectFilter().Rebuild(octx))
+		require.NoError(t, reg.Persister().WriteRelationTuples(octx, tuple("other file")))
+		allowed, err := reg.PermissionEngine().SubjectIsAllowed(octx, tuple("other file"), 0)
+		require.NoError(t, err)
+		require.False(t, allowed)
+
+		divergences := reg.ConsistencyVerifier().Verify(ctx)
+		require.Len(t, divergences, 1)
+		assert.Equal(t, tuple("other file"), divergences[0].RelationTuple)
+	})
+}
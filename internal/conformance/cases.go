@@ -0,0 +1,228 @@
+package conformance
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// cases is the suite of SuiteVersion. Names are stable, so reports can be
+// compared across builds.
+var cases = []*testCase{
+	{
+		name:     "check/direct relation tuple",
+		category: CategoryCheck,
+		run: func(ctx context.Context, e *env) error {
+			t := e.tuple("doc", "viewer", e.subjectID("alice"))
+			if err := e.insert(ctx, t); err != nil {
+				return err
+			}
+			return e.expectAllowed(ctx, t, true)
+		},
+	},
+	{
+		name:     "check/no relation tuple",
+		category: CategoryCheck,
+		run: func(ctx context.Context, e *env) error {
+			if err := e.insert(ctx, e.tuple("doc", "viewer", e.subjectID("alice"))); err != nil {
+				return err
+			}
+			if err := e.expectAllowed(ctx, e.tuple("doc", "viewer", e.subjectID("bob")), false); err != nil {
+				return err
+			}
+			return e.expectAllowed(ctx, e.tuple("doc", "owner", e.subjectID("alice")), false)
+		},
+	},
+	{
+		name:     "check/subject set indirection",
+		category: CategoryCheck,
+		run: func(ctx context.Context, e *env) error {
+			if err := e.insert(ctx,
+				e.tuple("doc", "viewer", e.subjectSet("group", "member")),
+				e.tuple("group", "member", e.subjectSet("team", "member")),
+				e.tuple("team", "member", e.subjectID("alice")),
+			); err != nil {
+				return err
+			}
+			if err := e.expectAllowed(ctx, e.tuple("doc", "viewer", e.subjectID("alice")), true); err != nil {
+				return err
+			}
+			return e.expectAllowed(ctx, e.tuple("doc", "viewer", e.subjectID("bob")), false)
+		},
+	},
+	{
+		name:     "check/deleted relation tuple",
+		category: CategoryCheck,
+		run: func(ctx context.Context, e *env) error {
+			t := e.tuple("doc", "viewer", e.subjectID("alice"))
+			if err := e.insert(ctx, t); err != nil {
+				return err
+			}
+			if err := e.transact(ctx, rts.RelationTupleDelta_ACTION_DELETE, t); err != nil {
+				return errors.WithMessage(err, "could not delete the relation tuple")
+			}
+			return e.expectAllowed(ctx, t, false)
+		},
+	},
+	{
+		name:     "expand/subject set",
+		category: CategoryExpand,
+		run: func(ctx context.Context, e *env) error {
+			if err := e.insert(ctx,
+				e.tuple("group", "member", e.subjectID("alice")),
+				e.tuple("group", "member", e.subjectSet("team", "member")),
+				e.tuple("team", "member", e.subjectID("bob")),
+			); err != nil {
+				return err
+			}
+			res, err := e.expand.Expand(ctx, &rts.ExpandRequest{
+				Subject:  e.subjectSet("group", "member").ToProto(),
+				MaxDepth: 3,
+			})
+			if err != nil {
+				return errors.WithMessage(err, "could not expand")
+			}
+			if res.Tree.GetNodeType() != rts.NodeType_NODE_TYPE_UNION {
+				return errors.Errorf("expected the root to be a union node, got %s", res.Tree.GetNodeType())
+			}
+
+			leaves := map[string]bool{}
+			var walk func(*rts.SubjectTree)
+			walk = func(n *rts.SubjectTree) {
+				if id := n.GetSubject().GetId(); n.NodeType == rts.NodeType_NODE_TYPE_LEAF && id != "" {
+					leaves[id] = true
+				}
+				for _, c := range n.Children {
+					walk(c)
+				}
+			}
+			walk(res.Tree)
+			for _, id := range []string{e.id("alice"), e.id("bob")} {
+				if !leaves[id] {
+					return errors.Errorf("expected the tree to contain the subject %s", id)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name:     "pagination/pages cover all relation tuples once",
+		category: CategoryPagination,
+		run: func(ctx context.Context, e *env) error {
+			const total, pageSize = 5, 2
+			tuples := make([]*relationtuple.InternalRelationTuple, total)
+			for i := range tuples {
+				tuples[i] = e.tuple("doc", "viewer", e.subjectID(string(rune('a'+i))))
+			}
+			if err := e.insert(ctx, tuples...); err != nil {
+				return err
+			}
+
+			seen := map[string]bool{}
+			pages := 0
+			for token := ""; ; {
+				res, err := e.read.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+					Query:     &rts.ListRelationTuplesRequest_Query{Namespace: e.ns, Object: e.id("doc")},
+					PageSize:  pageSize,
+					PageToken: token,
+				})
+				if err != nil {
+					return errors.WithMessage(err, "could not list the relation tuples")
+				}
+				pages++
+				if len(res.RelationTuples) > pageSize {
+					return errors.Errorf("expected at most %d relation tuples per page, got %d", pageSize, len(res.RelationTuples))
+				}
+				for _, pt := range res.RelationTuples {
+					t, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(pt)
+					if err != nil {
+						return err
+					}
+					if seen[t.String()] {
+						return errors.Errorf("the relation tuple %s was listed twice", t)
+					}
+					seen[t.String()] = true
+				}
+				if res.NextPageToken == "" {
+					break
+				}
+				if pages > total {
+					return errors.New("the pagination does not end")
+				}
+				token = res.NextPageToken
+			}
+
+			if len(seen) != total {
+				return errors.Errorf("expected %d relation tuples, got %d", total, len(seen))
+			}
+			for _, t := range tuples {
+				if !seen[t.String()] {
+					return errors.Errorf("the relation tuple %s was not listed", t)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name:     "pagination/query filters relation tuples",
+		category: CategoryPagination,
+		run: func(ctx context.Context, e *env) error {
+			if err := e.insert(ctx,
+				e.tuple("doc", "viewer", e.subjectID("alice")),
+				e.tuple("doc", "owner", e.subjectID("alice")),
+				e.tuple("other", "viewer", e.subjectID("alice")),
+			); err != nil {
+				return err
+			}
+			res, err := e.read.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+				Query: &rts.ListRelationTuplesRequest_Query{Namespace: e.ns, Object: e.id("doc"), Relation: "viewer"},
+			})
+			if err != nil {
+				return errors.WithMessage(err, "could not list the relation tuples")
+			}
+			if len(res.RelationTuples) != 1 || res.RelationTuples[0].Relation != "viewer" || res.RelationTuples[0].Object != e.id("doc") {
+				return errors.Errorf("expected only the viewer of %s, got %v", e.id("doc"), res.RelationTuples)
+			}
+			if res.NextPageToken != "" {
+				return errors.New("expected no next page")
+			}
+			return nil
+		},
+	},
+	{
+		name:     "errors/write to unknown namespace",
+		category: CategoryErrors,
+		run: func(ctx context.Context, e *env) error {
+			t := e.tuple("doc", "viewer", e.subjectID("alice"))
+			t.Namespace = e.id("unknown")
+			return expectCode(e.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, t), codes.NotFound)
+		},
+	},
+	// Malformed requests are reported as bad requests, which map to
+	// FailedPrecondition over gRPC.
+	{
+		name:     "errors/check without subject",
+		category: CategoryErrors,
+		run: func(ctx context.Context, e *env) error {
+			_, err := e.check.Check(ctx, &rts.CheckRequest{Namespace: e.ns, Object: e.id("doc"), Relation: "viewer"})
+			return expectCode(err, codes.FailedPrecondition)
+		},
+	},
+	{
+		name:     "errors/write without subject",
+		category: CategoryErrors,
+		run: func(ctx context.Context, e *env) error {
+			_, err := e.write.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+				RelationTupleDeltas: []*rts.RelationTupleDelta{{
+					Action:        rts.RelationTupleDelta_ACTION_INSERT,
+					RelationTuple: &rts.RelationTuple{Namespace: e.ns, Object: e.id("doc"), Relation: "viewer"},
+				}},
+			})
+			return expectCode(err, codes.FailedPrecondition)
+		},
+	},
+}
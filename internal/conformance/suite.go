@@ -0,0 +1,205 @@
+// Package conformance validates the API behavior of a running deployment.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// SuiteVersion is the version of the suite. It changes whenever a case is
+// added or changes what it expects, so that reports of different builds are
+// only compared if they ran the same suite.
+const SuiteVersion = "1"
+
+const (
+	CategoryCheck      = "check"
+	CategoryExpand     = "expand"
+	CategoryPagination = "pagination"
+	CategoryErrors     = "errors"
+)
+
+type (
+	// Options configure a run of the suite.
+	Options struct {
+		// Namespace is the namespace the cases write to. It has to be
+		// configured on the deployment. All relation tuples are written to
+		// objects unique to the run and deleted afterwards.
+		Namespace string
+		// Categories restricts the run to cases of these categories. All
+		// cases run if it is empty.
+		Categories []string
+	}
+
+	// Report is the outcome of a run of the suite.
+	Report struct {
+		SuiteVersion string    `json:"suite_version"`
+		Results      []*Result `json:"results"`
+	}
+	Result struct {
+		Case     string        `json:"case"`
+		Category string        `json:"category"`
+		Passed   bool          `json:"passed"`
+		Error    string        `json:"error,omitempty"`
+		Duration time.Duration `json:"duration"`
+	}
+
+	testCase struct {
+		name, category string
+		run            func(ctx context.Context, e *env) error
+	}
+
+	// env is the environment of a single case.
+	env struct {
+		check   rts.CheckServiceClient
+		expand  rts.ExpandServiceClient
+		read    rts.ReadServiceClient
+		write   rts.WriteServiceClient
+		ns      string
+		prefix  string
+		written []*relationtuple.InternalRelationTuple
+	}
+)
+
+// Failed returns the number of failed cases.
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// Run runs the suite against the read and write APIs of a deployment.
+func Run(ctx context.Context, read, write grpc.ClientConnInterface, o *Options) *Report {
+	include := make(map[string]bool, len(o.Categories))
+	for _, c := range o.Categories {
+		include[c] = true
+	}
+	runID := uuid.Must(uuid.NewV4()).String()[:8]
+
+	report := &Report{SuiteVersion: SuiteVersion}
+	for i, tc := range cases {
+		if len(include) > 0 && !include[tc.category] {
+			continue
+		}
+		e := &env{
+			check:  rts.NewCheckServiceClient(read),
+			expand: rts.NewExpandServiceClient(read),
+			read:   rts.NewReadServiceClient(read),
+			write:  rts.NewWriteServiceClient(write),
+			ns:     o.Namespace,
+			prefix: fmt.Sprintf("conformance-%s-%d-", runID, i),
+		}
+
+		start := time.Now()
+		err := tc.run(ctx, e)
+		if cerr := e.cleanup(ctx); err == nil && cerr != nil {
+			err = errors.WithMessage(cerr, "could not delete the relation tuples of the case")
+		}
+		res := &Result{
+			Case:     tc.name,
+			Category: tc.category,
+			Passed:   err == nil,
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
+
+// id returns a name that is unique to the case and run.
+func (e *env) id(name string) string {
+	return e.prefix + name
+}
+
+func (e *env) tuple(object, relation string, subject relationtuple.Subject) *relationtuple.InternalRelationTuple {
+	return &relationtuple.InternalRelationTuple{
+		Namespace: e.ns,
+		Object:    e.id(object),
+		Relation:  relation,
+		Subject:   subject,
+	}
+}
+
+func (e *env) subjectID(id string) relationtuple.Subject {
+	return &relationtuple.SubjectID{ID: e.id(id)}
+}
+
+func (e *env) subjectSet(object, relation string) relationtuple.Subject {
+	return &relationtuple.SubjectSet{Namespace: e.ns, Object: e.id(object), Relation: relation}
+}
+
+func (e *env) transact(ctx context.Context, action rts.RelationTupleDelta_Action, tuples ...*relationtuple.InternalRelationTuple) error {
+	deltas := make([]*rts.RelationTupleDelta, len(tuples))
+	for i, t := range tuples {
+		deltas[i] = &rts.RelationTupleDelta{Action: action, RelationTuple: t.ToProto()}
+	}
+	_, err := e.write.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{RelationTupleDeltas: deltas})
+	return err
+}
+
+func (e *env) insert(ctx context.Context, tuples ...*relationtuple.InternalRelationTuple) error {
+	if err := e.transact(ctx, rts.RelationTupleDelta_ACTION_INSERT, tuples...); err != nil {
+		return errors.WithMessage(err, "could not insert the relation tuples")
+	}
+	e.written = append(e.written, tuples...)
+	return nil
+}
+
+func (e *env) cleanup(ctx context.Context) error {
+	if len(e.written) == 0 {
+		return nil
+	}
+	return e.transact(ctx, rts.RelationTupleDelta_ACTION_DELETE, e.written...)
+}
+
+func (e *env) isAllowed(ctx context.Context, t *relationtuple.InternalRelationTuple) (bool, error) {
+	res, err := e.check.Check(ctx, &rts.CheckRequest{
+		Namespace: t.Namespace,
+		Object:    t.Object,
+		Relation:  t.Relation,
+		Subject:   t.Subject.ToProto(),
+	})
+	if err != nil {
+		return false, errors.WithMessage(err, "could not check")
+	}
+	return res.Allowed, nil
+}
+
+// expectAllowed checks the relation tuple and compares the result.
+func (e *env) expectAllowed(ctx context.Context, t *relationtuple.InternalRelationTuple, expected bool) error {
+	allowed, err := e.isAllowed(ctx, t)
+	if err != nil {
+		return err
+	}
+	if allowed != expected {
+		return errors.Errorf("expected check of %s to return allowed=%t, got allowed=%t", t, expected, allowed)
+	}
+	return nil
+}
+
+// expectCode compares the gRPC status code of the error.
+func expectCode(err error, expected codes.Code) error {
+	if err == nil {
+		return errors.Errorf("expected an error with code %s, got none", expected)
+	}
+	if actual := status.Code(err); actual != expected {
+		return errors.Errorf("expected an error with code %s, got %s: %s", expected, actual, err)
+	}
+	return nil
+}
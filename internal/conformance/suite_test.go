@@ -0,0 +1,70 @@
+package conformance_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/conformance"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func serve(t *testing.T, s *grpc.Server) *grpc.ClientConn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Serve(l) //nolint:errcheck
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "conformance"}}))
+
+	read := serve(t, reg.ReadGRPCServer(ctx))
+	write := serve(t, reg.WriteGRPCServer(ctx))
+
+	t.Run("case=passes against the server", func(t *testing.T) {
+		report := conformance.Run(ctx, read, write, &conformance.Options{Namespace: "conformance"})
+		assert.Equal(t, conformance.SuiteVersion, report.SuiteVersion)
+		require.NotEmpty(t, report.Results)
+		for _, res := range report.Results {
+			assert.True(t, res.Passed, "%s: %s", res.Case, res.Error)
+		}
+
+		all, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "conformance"})
+		require.NoError(t, err)
+		assert.Empty(t, all, "the relation tuples of the run are deleted")
+	})
+
+	t.Run("case=runs only the selected categories", func(t *testing.T) {
+		report := conformance.Run(ctx, read, write, &conformance.Options{
+			Namespace:  "conformance",
+			Categories: []string{conformance.CategoryErrors},
+		})
+		require.NotEmpty(t, report.Results)
+		for _, res := range report.Results {
+			assert.Equal(t, conformance.CategoryErrors, res.Category)
+		}
+	})
+
+	t.Run("case=fails without a configured namespace", func(t *testing.T) {
+		report := conformance.Run(ctx, read, write, &conformance.Options{
+			Namespace:  "not-configured",
+			Categories: []string{conformance.CategoryCheck},
+		})
+		assert.Equal(t, len(report.Results), report.Failed())
+	})
+}
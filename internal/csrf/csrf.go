@@ -0,0 +1,106 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	// Protection implements double-submit CSRF protection: safe requests
+	// receive a random token in a cookie that scripts of the site can read,
+	// and unsafe requests carrying cookies have to repeat the token in a
+	// header. Other sites can make browsers send the cookie, but can't read
+	// it to set the header.
+	Protection struct {
+		d dependencies
+	}
+)
+
+var ErrTokenInvalid = herodot.DefaultError{
+	IDField:       errorcode.CSRFTokenInvalid,
+	StatusField:   http.StatusText(http.StatusForbidden),
+	ErrorField:    "The CSRF token of the request is missing or does not match the CSRF cookie.",
+	CodeField:     http.StatusForbidden,
+	GRPCCodeField: codes.PermissionDenied,
+}
+
+func New(d dependencies) *Protection {
+	return &Protection{d: d}
+}
+
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HTTPMiddleware returns a middleware for the given API interface ("read" or
+// "write") that enforces the CSRF protection if it is enabled. Unsafe
+// requests without any cookie are not cookie-authenticated and pass, as
+// forged requests rely on the browser sending the cookies of the user.
+func (p *Protection) HTTPMiddleware(iface string) func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	return func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c, enabled := p.d.Config(r.Context()).CSRF(iface)
+		if !enabled {
+			next(rw, r)
+			return
+		}
+
+		cookie, err := r.Cookie(c.CookieName)
+		if safe(r.Method) {
+			if err != nil || cookie.Value == "" {
+				token, err := newToken()
+				if err != nil {
+					p.d.Writer().WriteError(rw, r, err)
+					return
+				}
+				http.SetCookie(rw, &http.Cookie{
+					Name:     c.CookieName,
+					Value:    token,
+					Path:     "/",
+					Domain:   c.CookieDomain,
+					Secure:   r.TLS != nil,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			next(rw, r)
+			return
+		}
+
+		if len(r.Cookies()) == 0 {
+			next(rw, r)
+			return
+		}
+		header := r.Header.Get(c.HeaderName)
+		if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			p.d.Writer().WriteError(rw, r, errors.WithStack(ErrTokenInvalid.
+				WithReasonf("Requests with cookies have to repeat the value of the %q cookie in the %q header.", c.CookieName, c.HeaderName)))
+			return
+		}
+		next(rw, r)
+	}
+}
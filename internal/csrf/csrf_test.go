@@ -0,0 +1,102 @@
+package csrf_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/keto/internal/csrf"
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/errorcode"
+)
+
+func TestProtection(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+
+	n := negroni.New()
+	n.UseFunc(csrf.New(reg).HTTPMiddleware("read"))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	ts := httptest.NewServer(n)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method string, cookie *http.Cookie, header string) *http.Response {
+		req, err := http.NewRequest(method, ts.URL+"/relation-tuples/check", nil)
+		require.NoError(t, err)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		if header != "" {
+			req.Header.Set("X-CSRF-Token", header)
+		}
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("case=is disabled by default", func(t *testing.T) {
+		resp := do(t, http.MethodPost, &http.Cookie{Name: "session", Value: "s"}, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Cookies())
+	})
+
+	require.NoError(t, reg.Config(ctx).Set("serve.read.csrf.enabled", true))
+
+	var token *http.Cookie
+	t.Run("case=issues tokens on safe requests", func(t *testing.T) {
+		resp := do(t, http.MethodGet, nil, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, resp.Cookies(), 1)
+		token = resp.Cookies()[0]
+		assert.Equal(t, "keto_csrf_token", token.Name)
+		assert.NotEmpty(t, token.Value)
+
+		resp = do(t, http.MethodGet, token, "")
+		assert.Empty(t, resp.Cookies(), "existing tokens are kept")
+	})
+
+	t.Run("case=requires the token on unsafe requests with cookies", func(t *testing.T) {
+		for _, tc := range []struct {
+			desc     string
+			header   string
+			expected int
+		}{
+			{desc: "matching header", header: token.Value, expected: http.StatusOK},
+			{desc: "missing header", expected: http.StatusForbidden},
+			{desc: "wrong header", header: "forged", expected: http.StatusForbidden},
+		} {
+			t.Run("case="+tc.desc, func(t *testing.T) {
+				resp := do(t, http.MethodPost, token, tc.header)
+				assert.Equal(t, tc.expected, resp.StatusCode)
+				if tc.expected == http.StatusForbidden {
+					body, err := io.ReadAll(resp.Body)
+					require.NoError(t, err)
+					assert.Equal(t, errorcode.CSRFTokenInvalid, gjson.GetBytes(body, "error.id").String(), "%s", body)
+				}
+			})
+		}
+
+		resp := do(t, http.MethodPost, &http.Cookie{Name: "session", Value: "s"}, "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "requests with other cookies need the token as well")
+	})
+
+	t.Run("case=passes unsafe requests without cookies", func(t *testing.T) {
+		resp := do(t, http.MethodPost, nil, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("case=allows the header in CORS requests", func(t *testing.T) {
+		options, _ := reg.Config(ctx).CORS("read")
+		assert.Contains(t, options.AllowedHeaders, "X-CSRF-Token")
+	})
+}
@@ -0,0 +1,129 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+	}
+	// Deadlines sets the configured default deadlines on check, expand, and
+	// list requests that do not have one, and reports requests that failed
+	// because their deadline passed with a structured timeout error.
+	Deadlines struct {
+		d dependencies
+	}
+	writer struct {
+		herodot.Writer
+	}
+)
+
+const (
+	RPCCheck  = "check"
+	RPCExpand = "expand"
+	RPCList   = "list"
+)
+
+var ErrDeadlineExceeded = herodot.DefaultError{
+	IDField:       errorcode.DeadlineExceeded,
+	StatusField:   http.StatusText(http.StatusGatewayTimeout),
+	ErrorField:    "The request did not finish before its deadline.",
+	CodeField:     http.StatusGatewayTimeout,
+	GRPCCodeField: codes.DeadlineExceeded,
+}
+
+var grpcRPCs = map[string]string{
+	"/" + rts.CheckService_ServiceDesc.ServiceName + "/Check":             RPCCheck,
+	"/" + rts.ExpandService_ServiceDesc.ServiceName + "/Expand":           RPCExpand,
+	"/" + rts.ReadService_ServiceDesc.ServiceName + "/ListRelationTuples": RPCList,
+}
+
+func New(d dependencies) *Deadlines {
+	return &Deadlines{d: d}
+}
+
+func httpRPC(r *http.Request) string {
+	switch p := r.URL.Path; {
+	case p == check.RouteBase || strings.HasPrefix(p, check.RouteBase+"/"):
+		return RPCCheck
+	case p == expand.RouteBase:
+		return RPCExpand
+	case p == relationtuple.ReadRouteBase && r.Method == http.MethodGet:
+		return RPCList
+	}
+	return ""
+}
+
+// withDefault returns the context with the default deadline of the RPC, if
+// it has none yet.
+func (d *Deadlines) withDefault(ctx context.Context, rpc string) (context.Context, context.CancelFunc) {
+	if rpc == "" {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	timeout := d.d.Config(ctx).DefaultDeadline(rpc)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// exceeded returns whether the request failed because its deadline passed.
+func exceeded(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+func (d *Deadlines) HTTPMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx, cancel := d.withDefault(r.Context(), httpRPC(r))
+	defer cancel()
+	next(rw, r.WithContext(ctx))
+}
+
+func (d *Deadlines) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, cancel := d.withDefault(ctx, grpcRPCs[info.FullMethod])
+	defer cancel()
+	resp, err := handler(ctx, req)
+	if exceeded(ctx, err) {
+		return nil, errors.WithStack(ErrDeadlineExceeded)
+	}
+	return resp, err
+}
+
+// Writer wraps the writer so that it reports errors of requests whose
+// deadline passed as ErrDeadlineExceeded. Handlers often wrap the cause, e.g.
+// a canceled database query, in an internal server error.
+func Writer(w herodot.Writer) herodot.Writer {
+	return &writer{Writer: w}
+}
+
+func (w *writer) WriteError(rw http.ResponseWriter, r *http.Request, err error, opts ...herodot.Option) {
+	if exceeded(r.Context(), err) {
+		err = errors.WithStack(ErrDeadlineExceeded)
+	}
+	w.Writer.WriteError(rw, r, err, opts...)
+}
+
+func (w *writer) WriteErrorCode(rw http.ResponseWriter, r *http.Request, code int, err error, opts ...herodot.Option) {
+	if exceeded(r.Context(), err) {
+		w.Writer.WriteError(rw, r, errors.WithStack(ErrDeadlineExceeded), opts...)
+		return
+	}
+	w.Writer.WriteErrorCode(rw, r, code, err, opts...)
+}
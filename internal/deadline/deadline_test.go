@@ -0,0 +1,118 @@
+package deadline_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/deadline"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/expand"
+)
+
+type deps struct {
+	c *config.Config
+}
+
+func (d *deps) Config(context.Context) *config.Config { return d.c }
+
+func newDeadlines(t *testing.T) *deadline.Deadlines {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	c := config.New(ctx, logrusx.New("test", "today"), cp)
+	require.NoError(t, c.Set(config.KeyLimitDefaultDeadline+"."+deadline.RPCCheck, "10ms"))
+	require.NoError(t, c.Set(config.KeyLimitDefaultDeadline+"."+deadline.RPCList, "1h"))
+	return deadline.New(&deps{c: c})
+}
+
+// waitForDeadline blocks until the context is done, like a slow database
+// query, and returns how the handler would typically report that.
+func waitForDeadline(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil
+	}
+	<-ctx.Done()
+	return herodot.ErrInternalServerError.WithError(ctx.Err().Error())
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	d := newDeadlines(t)
+	w := deadline.Writer(herodot.NewJSONWriter(nil))
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		d.HTTPMiddleware(rw, r, func(rw http.ResponseWriter, r *http.Request) {
+			if err := waitForDeadline(r.Context()); err != nil {
+				w.WriteError(rw, r, err)
+				return
+			}
+			rw.WriteHeader(http.StatusNoContent)
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	t.Run("case=check exceeds its deadline", func(t *testing.T) {
+		resp, err := ts.Client().Get(ts.URL + check.OpenAPIRouteBase)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+
+		var body herodot.ErrorContainer
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, deadline.ErrDeadlineExceeded.ErrorField, body.Error.ErrorField)
+	})
+
+	t.Run("case=expand has no deadline", func(t *testing.T) {
+		resp, err := ts.Client().Get(ts.URL + expand.RouteBase)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	d := newDeadlines(t)
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return "done", waitForDeadline(ctx)
+	}
+
+	t.Run("case=check exceeds its deadline", func(t *testing.T) {
+		_, err := d.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/ory.keto.relation_tuples.v1alpha2.CheckService/Check"}, handler)
+		var herr herodot.DefaultError
+		require.True(t, errors.As(err, &herr), "%+v", err)
+		assert.Equal(t, codes.DeadlineExceeded, herr.GRPCStatus().Code())
+	})
+
+	t.Run("case=keeps the deadline of the client", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		clientDeadline, _ := ctx.Deadline()
+
+		_, err := d.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/ory.keto.relation_tuples.v1alpha2.ReadService/ListRelationTuples"}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			actual, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Equal(t, clientDeadline, actual)
+			return nil, nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("case=other methods have no deadline", func(t *testing.T) {
+		resp, err := d.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/ory.keto.relation_tuples.v1alpha2.WriteService/TransactRelationTuples"}, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "done", resp)
+	})
+}
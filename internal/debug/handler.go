@@ -0,0 +1,88 @@
+// Package debug serves runtime debug endpoints on the metrics API, so that
+// production issues can be investigated without a debug build.
+package debug
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/ory/keto/internal/adminui"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase       = "/debug"
+	PprofRoute      = RouteBase + "/pprof"
+	VarsRoute       = RouteBase + "/vars"
+	GoroutinesRoute = RouteBase + "/goroutines"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+// SetRoutes registers the debug endpoints on the metrics router.
+func (h *Handler) SetRoutes(r *httprouter.Router) {
+	r.GET(PprofRoute, h.protect(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, PprofRoute+"/", http.StatusMovedPermanently)
+	}))
+	r.GET(PprofRoute+"/*profile", h.protect(servePprof))
+	r.POST(PprofRoute+"/symbol", h.protect(pprof.Symbol))
+	r.GET(VarsRoute, h.protect(expvar.Handler().ServeHTTP))
+	r.GET(GoroutinesRoute, h.protect(dumpGoroutines))
+}
+
+func servePprof(w http.ResponseWriter, r *http.Request) {
+	switch httprouter.ParamsFromContext(r.Context()).ByName("profile") {
+	case "/cmdline":
+		pprof.Cmdline(w, r)
+	case "/profile":
+		pprof.Profile(w, r)
+	case "/symbol":
+		pprof.Symbol(w, r)
+	case "/trace":
+		pprof.Trace(w, r)
+	default:
+		// the index and the named profiles, e.g. /debug/pprof/heap
+		pprof.Index(w, r)
+	}
+}
+
+// dumpGoroutines writes the stack traces of all goroutines in the format of
+// an unrecovered panic.
+func dumpGoroutines(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_ = runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+}
+
+// protect hides the endpoints when they are disabled and enforces the admin
+// credentials otherwise.
+func (h *Handler) protect(next http.HandlerFunc) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		c := h.d.Config(r.Context())
+		if !c.MetricsDebugEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		if !adminui.Authenticate(w, r, c, h.d.Writer(), "Ory Keto Debug") {
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, ps)))
+	}
+}
@@ -0,0 +1,91 @@
+package debug_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/debug"
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type deps struct {
+	c *config.Config
+}
+
+func (d *deps) Config(context.Context) *config.Config { return d.c }
+func (d *deps) Writer() herodot.Writer                { return herodot.NewJSONWriter(nil) }
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	c := config.New(ctx, logrusx.New("test", "today"), cp)
+
+	router := httprouter.New()
+	debug.NewHandler(&deps{c: c}).SetRoutes(router)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T, path string, auth bool) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("case=not served by default", func(t *testing.T) {
+		code, _ := get(t, debug.GoroutinesRoute, true)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	require.NoError(t, c.Set(config.KeyMetricsDebugEnabled, true))
+
+	t.Run("case=requires credentials", func(t *testing.T) {
+		code, _ := get(t, debug.VarsRoute, false)
+		assert.Equal(t, http.StatusInternalServerError, code)
+
+		require.NoError(t, c.Set(config.KeyAdminUIBasicAuthUsername, "admin"))
+		require.NoError(t, c.Set(config.KeyAdminUIBasicAuthPassword, "secret"))
+		code, _ = get(t, debug.VarsRoute, false)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("case=serves the endpoints", func(t *testing.T) {
+		code, body := get(t, debug.VarsRoute, true)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, `"memstats"`)
+
+		code, body = get(t, debug.GoroutinesRoute, true)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "goroutine ")
+
+		code, body = get(t, debug.PprofRoute+"/", true)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "heap")
+
+		code, _ = get(t, debug.PprofRoute+"/heap?debug=1", true)
+		assert.Equal(t, http.StatusOK, code)
+
+		code, body = get(t, debug.PprofRoute+"/cmdline", true)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotEmpty(t, body)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ory/herodot"
 	"github.com/pkg/errors"
 
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/namespace"
 )
 
@@ -34,7 +35,7 @@ func (s *memoryNamespaceManager) GetNamespaceByName(_ context.Context, name stri
 		}
 	}
 
-	return nil, errors.WithStack(herodot.ErrNotFound.WithReasonf("Unknown namespace with name %q.", name))
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithReasonf("Unknown namespace with name %q.", name))
 }
 
 func (s *memoryNamespaceManager) GetNamespaceByConfigID(_ context.Context, id int32) (*namespace.Namespace, error) {
@@ -44,7 +45,7 @@ func (s *memoryNamespaceManager) GetNamespaceByConfigID(_ context.Context, id in
 		}
 	}
 
-	return nil, errors.WithStack(herodot.ErrNotFound.WithReasonf("Unknown namespace with id %d.", id))
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithReasonf("Unknown namespace with id %d.", id))
 }
 
 func (s *memoryNamespaceManager) Namespaces(_ context.Context) ([]*namespace.Namespace, error) {
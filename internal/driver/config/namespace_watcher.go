@@ -19,6 +19,7 @@ import (
 	"github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
 
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/namespace"
 )
 
@@ -175,7 +176,7 @@ func (n *NamespaceWatcher) GetNamespaceByName(_ context.Context, name string) (*
 		}
 	}
 
-	return nil, errors.WithStack(herodot.ErrNotFound.WithErrorf("Unknown namespace with name %s", name))
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithErrorf("Unknown namespace with name %s", name))
 }
 
 func (n *NamespaceWatcher) GetNamespaceByConfigID(_ context.Context, id int32) (*namespace.Namespace, error) {
@@ -188,7 +189,7 @@ func (n *NamespaceWatcher) GetNamespaceByConfigID(_ context.Context, id int32) (
 		}
 	}
 
-	return nil, errors.WithStack(herodot.ErrNotFound.WithErrorf("Unknown namespace with ID %d", id))
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithErrorf("Unknown namespace with ID %d", id))
 }
 
 func (n *NamespaceWatcher) Namespaces(_ context.Context) ([]*namespace.Namespace, error) {
@@ -2,13 +2,19 @@ package config
 
 import (
 	"context"
+	"database/sql"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ory/keto/embedx"
 
+	"github.com/gofrs/uuid"
+	"github.com/inhies/go-bytesize"
 	"github.com/ory/herodot"
 	_ "github.com/ory/jsonschema/v3/httploader"
 	"github.com/ory/x/configx"
@@ -25,19 +31,155 @@ import (
 const (
 	KeyDSN = "dsn"
 
-	KeyLimitMaxReadDepth = "limit.max_read_depth"
-	KeyReadAPIHost       = "serve.read.host"
-	KeyReadAPIPort       = "serve.read.port"
+	KeyLimitMaxReadDepth        = "limit.max_read_depth"
+	KeyLimitMaxExpandMemory     = "limit.max_expand_memory"
+	KeyLimitMaxExpandChildren   = "limit.max_expand_children"
+	KeyLimitDefaultPageSize     = "limit.default_page_size"
+	KeyLimitMaxPageSize         = "limit.max_page_size"
+	KeyLimitMaxExpressionChecks = "limit.max_expression_checks"
+	KeyLimitMaxLength           = "limit.max_length"
+	KeyLimitDefaultDeadline     = "limit.default_deadline"
+	KeyLimitMaxBodySize         = "limit.max_body_size"
+	KeyReadAPIHost              = "serve.read.host"
+	KeyReadAPIPort              = "serve.read.port"
 
 	KeyWriteAPIHost = "serve.write.host"
 	KeyWriteAPIPort = "serve.write.port"
 
+	KeyAdminUIEnabled           = "serve.write.admin_ui.enabled"
+	KeyAdminUIBasicAuthUsername = "serve.write.admin_ui.basic_auth.username"
+	KeyAdminUIBasicAuthPassword = "serve.write.admin_ui.basic_auth.password"
+
 	KeyMetricsHost = "serve.metrics.host"
 	KeyMetricsPort = "serve.metrics.port"
 
+	KeyMetricsDebugEnabled = "serve.metrics.debug.enabled"
+
 	KeyNamespaces = "namespaces"
+	EnvNamespaces = "NAMESPACES"
+
+	KeyNamespaceStorePollInterval = "namespace_store.poll_interval"
+
+	KeyTupleTemplates = "templates"
+
+	KeyQueryCacheEnabled = "query_cache.enabled"
+
+	KeyObjectFilterEnabled           = "object_filter.enabled"
+	KeyObjectFilterRebuildInterval   = "object_filter.rebuild_interval"
+	KeyObjectFilterFalsePositiveRate = "object_filter.false_positive_rate"
+
+	KeyChangeFeedEnabled      = "changefeed.enabled"
+	KeyChangeFeedPollInterval = "changefeed.poll_interval"
+
+	KeySlowQueryLogEnabled   = "slow_query_log.enabled"
+	KeySlowQueryLogThreshold = "slow_query_log.threshold"
+
+	KeyTransactionIsolationLevel = "transactions.isolation_level"
+	KeyTransactionMaxAttempts    = "transactions.retry.max_attempts"
+	KeyTransactionInitialBackoff = "transactions.retry.initial_backoff"
+	KeyTransactionMaxBackoff     = "transactions.retry.max_backoff"
+
+	KeyRegionName              = "region.name"
+	KeyRegionNetworks          = "region.networks"
+	KeyRegionMisroutedRequests = "region.misrouted_requests"
+	KeyRegionDatabases         = "region.databases"
+
+	KeyReplicationMode               = "replication.mode"
+	KeyReplicationTombstoneRetention = "replication.tombstone_retention"
+
+	KeyReadOnly = "read_only"
+
+	KeyMigrationDirectories    = "migrations.directories"
+	KeyMigrationAdvisedIndexes = "migrations.advised_indexes"
+
+	KeySeedNamespaces     = "seed.namespaces"
+	KeySeedRelationTuples = "seed.relation_tuples"
+
+	KeyOIDCEnabled         = "oidc.enabled"
+	KeyOIDCIssuer          = "oidc.issuer"
+	KeyOIDCJWKSURL         = "oidc.jwks_url"
+	KeyOIDCAudiences       = "oidc.audiences"
+	KeyOIDCSubjectClaim    = "oidc.subject_claim"
+	KeyOIDCSubjectPrefix   = "oidc.subject_prefix"
+	KeyOIDCSubjectSet      = "oidc.subject_set"
+	KeyOIDCGroupsClaim     = "oidc.groups.claim"
+	KeyOIDCGroupsNamespace = "oidc.groups.namespace"
+	KeyOIDCGroupsRelation  = "oidc.groups.relation"
+
+	KeyGroupsNamespace = "groups.namespace"
+	KeyGroupsRelation  = "groups.relation"
+
+	KeyOrgsNamespace = "orgs.namespace"
+
+	KeyLDAPSyncEnabled = "ldap_sync.enabled"
+
+	KeyFaultInjectionEnabled = "fault_injection.enabled"
+
+	KeySCIMEnabled     = "scim.enabled"
+	KeySCIMBearerToken = "scim.bearer_token"
+	KeySCIMNamespace   = "scim.namespace"
+
+	KeyMeteringEnabled         = "metering.enabled"
+	KeyMeteringExportDirectory = "metering.export.directory"
+	KeyMeteringExportInterval  = "metering.export.interval"
+
+	KeyMirrorReadRemote  = "mirror.read_remote"
+	KeyMirrorWriteRemote = "mirror.write_remote"
+	KeyMirrorQueueSize   = "mirror.queue_size"
+
+	KeyWatchPollInterval = "watch.poll_interval"
+
+	KeyProxyReadRemote       = "proxy.read_remote"
+	KeyProxyReadURL          = "proxy.read_url"
+	KeyProxyMaxCachedResults = "proxy.max_cached_results"
+	KeySidecarNamespaces     = "proxy.sidecar.namespaces"
+	KeySidecarMaxStaleness   = "proxy.sidecar.max_staleness"
+
+	KeyCanaryNamespaces = "canary.namespaces"
+	KeyCanarySampleRate = "canary.sample_rate"
+
+	KeyShadowFeatureFlags = "shadow.feature_flags"
+	KeyShadowSampleRate   = "shadow.sample_rate"
+
+	KeySnapshotDeltaRetention = "snapshots.delta_retention"
+
+	KeyConsistencyMaxStaleness = "consistency.max_staleness"
+	KeyConsistencyQuantization = "consistency.quantization"
+
+	KeyConsistencyVerifierEnabled    = "consistency.verifier.enabled"
+	KeyConsistencyVerifierSampleRate = "consistency.verifier.sample_rate"
+	KeyConsistencyVerifierInterval   = "consistency.verifier.interval"
+	KeyConsistencyVerifierMaxSamples = "consistency.verifier.max_samples"
+
+	KeyObjectStorageS3Region               = "object_storage.s3.region"
+	KeyObjectStorageS3Endpoint             = "object_storage.s3.endpoint"
+	KeyObjectStorageS3ServerSideEncryption = "object_storage.s3.server_side_encryption"
+	KeyObjectStorageS3KMSKeyID             = "object_storage.s3.kms_key_id"
+	KeyObjectStorageGCSEndpoint            = "object_storage.gcs.endpoint"
+	KeyObjectStorageGCSCredentialsFile     = "object_storage.gcs.credentials_file"
+	KeyObjectStorageGCSKMSKeyName          = "object_storage.gcs.kms_key_name"
+	KeyObjectStorageAzureAccount           = "object_storage.azure.account"
+	KeyObjectStorageAzureEndpoint          = "object_storage.azure.endpoint"
+	KeyObjectStorageAzureEncryptionScope   = "object_storage.azure.encryption_scope"
+
+	KeyDev                   = "dev"
+	KeyFeatureFlags          = "feature_flags"
+	KeyFeatureFlagNamespaces = "feature_flags.namespaces"
 
-	DSNMemory = "sqlite://file::memory:?_fk=true&cache=shared"
+	// DSNMemory is the DSN format of the "memory" database. Every Config gets
+	// its own database name, so that several in-memory instances in one process
+	// do not share their data.
+	DSNMemory = "sqlite://file:keto-memory-%s?_fk=true&mode=memory&cache=shared"
+
+	// NamespacesDatabase is the namespaces location that serves the active
+	// version of the namespace configs stored in the database.
+	NamespacesDatabase = "db://"
+
+	MisroutedRequestsReject = "reject"
+	MisroutedRequestsProxy  = "proxy"
+
+	ReplicationModeSinglePrimary = "single_primary"
+	ReplicationModeMultiPrimary  = "multi_primary"
 )
 
 type (
@@ -49,10 +191,70 @@ type (
 		nm                     namespace.Manager
 		cancelNamespaceManager context.CancelFunc
 		nmLock                 sync.Mutex
+		nsStore                namespace.ConfigStore
+
+		// namespacesKey is KeyCanaryNamespaces for the canary config and
+		// empty otherwise.
+		namespacesKey string
+		canary        *Config
+
+		memoryDSN     string
+		memoryDSNOnce sync.Once
 	}
 	Provider interface {
 		Config(ctx context.Context) *Config
 	}
+	// MaxLengths are the maximum numbers of characters of relation tuple
+	// identifiers. The limits for namespaces, objects, and relations also
+	// apply to subject sets. Zero means not limited.
+	MaxLengths struct {
+		Namespace, Object, Relation, SubjectID int
+	}
+	// S3Storage are the settings of exports to S3 and S3-compatible storage.
+	// An empty endpoint means AWS. The credentials are read from the
+	// environment.
+	S3Storage struct {
+		Region, Endpoint string
+		// ServerSideEncryption is "AES256", "aws:kms", or empty for the
+		// default encryption of the bucket.
+		ServerSideEncryption, KMSKeyID string
+	}
+	// GCSStorage are the settings of exports to Google Cloud Storage. An
+	// empty credentials file means the application default credentials.
+	GCSStorage struct {
+		Endpoint, CredentialsFile, KMSKeyName string
+	}
+	// AzureStorage are the settings of exports to Azure Blob Storage. The
+	// credentials come from the default credential chain of the Azure SDK.
+	AzureStorage struct {
+		Account, Endpoint, EncryptionScope string
+	}
+	// CSRF are the settings of the double-submit CSRF protection of a REST
+	// API. An empty cookie domain means the host of the request.
+	CSRF struct {
+		CookieName, CookieDomain, HeaderName string
+	}
+	// LDAPSync are the settings of the synchronization of LDAP groups into
+	// the groups namespace. Without a user filter, the member attribute holds
+	// the user IDs instead of the DNs of the users.
+	LDAPSync struct {
+		URL, BindDN, BindPassword, BaseDN string
+		GroupFilter, UserFilter           string
+		GroupNameAttribute                string
+		MemberAttribute                   string
+		UserIDAttribute                   string
+		Namespace                         string
+		Interval, Timeout                 time.Duration
+		PageSize                          int
+	}
+	// FaultInjection are the faults injected into the calls of the relation
+	// tuple manager. The rates are the fractions of the calls that are
+	// affected. Empty operations mean all operations.
+	FaultInjection struct {
+		Latency                                    time.Duration
+		LatencyRate, ErrorRate, PartialFailureRate float64
+		Operations                                 []string
+	}
 )
 
 func New(ctx context.Context, l *logrusx.Logger, p *configx.Provider) *Config {
@@ -75,6 +277,15 @@ func NewDefault(ctx context.Context, flags *pflag.FlagSet, l *logrusx.Logger, op
 }
 
 func NewProvider(ctx context.Context, flags *pflag.FlagSet, config *Config, opts ...configx.OptionModifier) (*configx.Provider, error) {
+	nn, err := namespacesFromEnv()
+	if err != nil {
+		return nil, err
+	} else if nn != nil {
+		// Forced values take precedence over the string loaded from the
+		// environment, and are validated like all other values.
+		opts = append(opts, configx.WithValue(KeyNamespaces, nn))
+	}
+
 	p, err := configx.New(
 		ctx,
 		embedx.ConfigSchema,
@@ -82,7 +293,7 @@ func NewProvider(ctx context.Context, flags *pflag.FlagSet, config *Config, opts
 			configx.WithFlags(flags),
 			configx.WithStderrValidationReporter(),
 			configx.WithImmutables(KeyDSN, "serve"),
-			configx.OmitKeysFromTracing(KeyDSN),
+			configx.OmitKeysFromTracing(KeyDSN, KeyAdminUIBasicAuthPassword),
 			configx.WithLogrusWatcher(config.l),
 			configx.WithContext(ctx),
 			configx.AttachWatcher(config.watcher),
@@ -95,6 +306,22 @@ func NewProvider(ctx context.Context, flags *pflag.FlagSet, config *Config, opts
 	return p, nil
 }
 
+// namespacesFromEnv decodes the namespaces if they are defined inline as a
+// JSON array in the environment variable, which otherwise is loaded as a
+// string and taken for a URI. It returns nil if the variable holds a URI.
+func namespacesFromEnv() ([]interface{}, error) {
+	raw := strings.TrimSpace(os.Getenv(EnvNamespaces))
+	if !strings.HasPrefix(raw, "[") {
+		return nil, nil
+	}
+
+	var nn []interface{}
+	if err := json.Unmarshal([]byte(raw), &nn); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the namespaces in the environment variable %s", EnvNamespaces)
+	}
+	return nn, nil
+}
+
 func (k *Config) Source() *configx.Provider {
 	return k.p
 }
@@ -108,6 +335,13 @@ func (k *Config) watcher(_ watcherx.Event, err error) {
 	if err != nil {
 		return
 	}
+	k.reloadNamespaces()
+	if k.CanaryEnabled() {
+		k.Canary().reloadNamespaces()
+	}
+}
+
+func (k *Config) reloadNamespaces() {
 	nm, err := k.NamespaceManager()
 	if err != nil {
 		k.l.WithError(err).Error("got internal error in config watcher: could not get namespace manager")
@@ -143,15 +377,17 @@ func (k *Config) Set(key string, v interface{}) error {
 		return err
 	}
 
-	if key == KeyNamespaces {
+	switch key {
+	case KeyNamespaces:
 		k.resetNamespaceManager()
+	case KeyCanaryNamespaces:
+		k.Canary().resetNamespaceManager()
 	}
 	return nil
 }
 
 func (k *Config) ReadAPIListenOn() string {
-	return fmt.Sprintf(
-		"%s:%d",
+	return configx.GetAddress(
 		k.p.StringF(KeyReadAPIHost, ""),
 		k.p.IntF(KeyReadAPIPort, 4466),
 	)
@@ -161,14 +397,146 @@ func (k *Config) MaxReadDepth() int {
 	return k.p.Int(KeyLimitMaxReadDepth)
 }
 
+// MaxExpandMemory returns the approximate number of bytes an expand tree may
+// use. Zero means it is not limited.
+func (k *Config) MaxExpandMemory() int {
+	return int(k.p.ByteSizeF(KeyLimitMaxExpandMemory, 0))
+}
+
+// MaxExpandChildren returns the maximum number of children of an expand tree
+// node. Zero means it is not limited.
+func (k *Config) MaxExpandChildren() int {
+	return k.p.IntF(KeyLimitMaxExpandChildren, 0)
+}
+
+// DefaultPageSize returns the page size of list requests that do not ask for
+// one. It is never larger than MaxPageSize.
+func (k *Config) DefaultPageSize() int {
+	d := k.p.IntF(KeyLimitDefaultPageSize, 100)
+	if m := k.MaxPageSize(); d > m {
+		return m
+	}
+	return d
+}
+
+// MaxPageSize returns the largest page size list requests may ask for.
+func (k *Config) MaxPageSize() int {
+	return k.p.IntF(KeyLimitMaxPageSize, 1000)
+}
+
+// MaxExpressionChecks returns the maximum number of checks in one permission
+// expression.
+func (k *Config) MaxExpressionChecks() int {
+	return k.p.IntF(KeyLimitMaxExpressionChecks, 16)
+}
+
+// MaxLengths returns the maximum number of characters of the identifiers in
+// relation tuples. Namespace names are not limited by default, because only
+// their IDs are stored.
+func (k *Config) MaxLengths() MaxLengths {
+	return MaxLengths{
+		Namespace: k.p.IntF(KeyLimitMaxLength+".namespace", 0),
+		Object:    k.p.IntF(KeyLimitMaxLength+".object", 64),
+		Relation:  k.p.IntF(KeyLimitMaxLength+".relation", 64),
+		SubjectID: k.p.IntF(KeyLimitMaxLength+".subject_id", 64),
+	}
+}
+
+// DefaultDeadline returns how long the server works on a "check", "expand",
+// or "list" request without a deadline. It is zero if such requests are not
+// limited.
+func (k *Config) DefaultDeadline(rpc string) time.Duration {
+	return k.p.DurationF(KeyLimitDefaultDeadline+"."+rpc, 0)
+}
+
+// MaxBodySize returns the maximum number of bytes of REST request bodies and
+// received gRPC messages of the "read" or "write" API.
+func (k *Config) MaxBodySize(iface string) int {
+	return int(k.p.ByteSizeF(KeyLimitMaxBodySize+"."+iface, 4*bytesize.MB))
+}
+
+// TupleTemplate returns the parameters and relation tuples of the named
+// template. ok is false if no template of that name is configured.
+func (k *Config) TupleTemplate(name string) (parameters, tuples []string, ok bool) {
+	key := KeyTupleTemplates + "." + name
+	if name == "" || strings.Contains(name, ".") || k.p.Get(key) == nil {
+		return nil, nil, false
+	}
+	return k.p.Strings(key + ".parameters"), k.p.Strings(key + ".tuples"), true
+}
+
+func (k *Config) QueryCacheEnabled() bool {
+	return k.p.Bool(KeyQueryCacheEnabled)
+}
+
+func (k *Config) ObjectFilterEnabled() bool {
+	return k.p.Bool(KeyObjectFilterEnabled)
+}
+
+// ObjectFilter returns how often the object filters are rebuilt and their
+// target false positive rate.
+func (k *Config) ObjectFilter() (rebuildInterval time.Duration, falsePositiveRate float64) {
+	return k.p.DurationF(KeyObjectFilterRebuildInterval, 10*time.Minute), k.p.Float64F(KeyObjectFilterFalsePositiveRate, 0.01)
+}
+
+func (k *Config) ChangeFeedEnabled() bool {
+	return k.p.Bool(KeyChangeFeedEnabled)
+}
+
+func (k *Config) ChangeFeedPollInterval() time.Duration {
+	return k.p.DurationF(KeyChangeFeedPollInterval, time.Second)
+}
+
+// SlowQueryLog returns whether SQL statements that take longer than the
+// threshold are logged.
+func (k *Config) SlowQueryLog() (enabled bool, threshold time.Duration) {
+	return k.p.Bool(KeySlowQueryLogEnabled), k.p.DurationF(KeySlowQueryLogThreshold, 100*time.Millisecond)
+}
+
+// IsolationLevel returns the isolation level of transactions on the given
+// database dialect, as named by pop.
+func (k *Config) IsolationLevel(dialect string) sql.IsolationLevel {
+	switch k.p.String(KeyTransactionIsolationLevel + "." + dialect) {
+	case "read_committed":
+		return sql.LevelReadCommitted
+	case "repeatable_read":
+		return sql.LevelRepeatableRead
+	case "serializable":
+		return sql.LevelSerializable
+	}
+	return sql.LevelDefault
+}
+
+// TransactionRetry returns how often transactions are attempted if they fail
+// with a serialization failure or deadlock, and the bounds of the backoff
+// between attempts.
+func (k *Config) TransactionRetry() (maxAttempts int, initialBackoff, maxBackoff time.Duration) {
+	return k.p.IntF(KeyTransactionMaxAttempts, 5),
+		k.p.DurationF(KeyTransactionInitialBackoff, 20*time.Millisecond),
+		k.p.DurationF(KeyTransactionMaxBackoff, time.Second)
+}
+
 func (k *Config) WriteAPIListenOn() string {
-	return fmt.Sprintf(
-		"%s:%d",
+	return configx.GetAddress(
 		k.p.StringF(KeyWriteAPIHost, ""),
 		k.p.IntF(KeyWriteAPIPort, 4467),
 	)
 }
 
+// MetricsDebugEnabled returns whether the pprof, expvar, and goroutine dump
+// endpoints are served on the metrics API.
+func (k *Config) MetricsDebugEnabled() bool {
+	return k.p.Bool(KeyMetricsDebugEnabled)
+}
+
+func (k *Config) AdminUIEnabled() bool {
+	return k.p.Bool(KeyAdminUIEnabled)
+}
+
+func (k *Config) AdminUIBasicAuth() (username, password string) {
+	return k.p.String(KeyAdminUIBasicAuthUsername), k.p.String(KeyAdminUIBasicAuthPassword)
+}
+
 func (k *Config) CORS(iface string) (cors.Options, bool) {
 	switch iface {
 	case "read", "write", "metrics":
@@ -176,18 +544,61 @@ func (k *Config) CORS(iface string) (cors.Options, bool) {
 		panic("expected interface 'read' or 'write', but got unknown interface " + iface)
 	}
 
-	return k.p.CORS("serve."+iface, cors.Options{
+	options, enabled := k.p.CORS("serve."+iface, cors.Options{
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
 		AllowedHeaders:   []string{"Authorization", "Content-Type"},
 		ExposedHeaders:   []string{"Content-Type"},
 		AllowCredentials: true,
 	})
+	// Browsers have to be allowed to send the CSRF token.
+	if c, ok := k.CSRF(iface); ok {
+		options.AllowedHeaders = append(options.AllowedHeaders, c.HeaderName)
+	}
+	return options, enabled
+}
+
+// CSRF returns the settings of the CSRF protection of the REST API of the
+// "read" or "write" API, and whether it is enabled.
+func (k *Config) CSRF(iface string) (CSRF, bool) {
+	key := "serve." + iface + ".csrf"
+	return CSRF{
+		CookieName:   k.p.StringF(key+".cookie_name", "keto_csrf_token"),
+		CookieDomain: k.p.String(key + ".cookie_domain"),
+		HeaderName:   k.p.StringF(key+".header_name", "X-CSRF-Token"),
+	}, k.p.Bool(key + ".enabled")
+}
+
+// GRPCMaxConnectionAge returns the maximum age of gRPC connections of the
+// "read" or "write" API and the grace period for pending RPCs. Zero means
+// connections are not closed because of their age.
+func (k *Config) GRPCMaxConnectionAge(iface string) (age, grace time.Duration) {
+	return k.p.DurationF("serve."+iface+".grpc.max_connection_age", 0),
+		k.p.DurationF("serve."+iface+".grpc.max_connection_age_grace", 0)
+}
+
+// H2C returns whether the REST API of the "read" or "write" API is served over
+// HTTP/2 without TLS as well.
+func (k *Config) H2C(iface string) bool {
+	return k.p.Bool("serve." + iface + ".http.h2c")
+}
+
+// SocketPermission returns the permissions of the unix socket of the "read" or
+// "write" API. They are only applied if the host is a unix socket path.
+func (k *Config) SocketPermission(iface string) *configx.UnixPermission {
+	return &configx.UnixPermission{
+		Owner: k.p.String("serve." + iface + ".socket.owner"),
+		Group: k.p.String("serve." + iface + ".socket.group"),
+		Mode:  os.FileMode(k.p.IntF("serve."+iface+".socket.mode", 0660)),
+	}
 }
 
 func (k *Config) DSN() string {
 	dsn := k.p.String(KeyDSN)
 	if dsn == "memory" {
-		return DSNMemory
+		k.memoryDSNOnce.Do(func() {
+			k.memoryDSN = fmt.Sprintf(DSNMemory, uuid.Must(uuid.NewV4()))
+		})
+		return k.memoryDSN
 	}
 	return dsn
 }
@@ -220,6 +631,16 @@ func (k *Config) NamespaceManager() (namespace.Manager, error) {
 		switch nTyped := nn.(type) {
 		case string:
 			var err error
+			if nTyped == NamespacesDatabase {
+				if k.nsStore == nil {
+					return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("The namespaces are configured to be stored in the database, but the storage backend does not support it."))
+				}
+				k.nm, err = NewDatabaseNamespaceManager(ctx, k.l, k.nsStore, k.NamespaceStorePollInterval())
+				if err != nil {
+					return nil, err
+				}
+				break
+			}
 			k.nm, err = NewNamespaceWatcher(ctx, k.l, nTyped)
 			if err != nil {
 				return nil, err
@@ -234,9 +655,33 @@ func (k *Config) NamespaceManager() (namespace.Manager, error) {
 	return k.nm, nil
 }
 
+// UseNamespaceConfigStore sets the store of the namespace configs that is
+// used if the namespaces are located in the database.
+func (k *Config) UseNamespaceConfigStore(s namespace.ConfigStore) {
+	k.nmLock.Lock()
+	defer k.nmLock.Unlock()
+	k.nsStore = s
+	if k.canary != nil {
+		k.canary.nsStore = s
+	}
+}
+
+// NamespaceStorePollInterval returns how often the activation pointer of the
+// namespace configs in the database is polled.
+func (k *Config) NamespaceStorePollInterval() time.Duration {
+	return k.p.DurationF(KeyNamespaceStorePollInterval, 5*time.Second)
+}
+
 // getNamespaces returns string or []*namespace.Namespace
 func (k *Config) getNamespaces() (interface{}, error) {
-	switch nTyped := k.p.GetF(KeyNamespaces, "file://./keto_namespaces").(type) {
+	var nn interface{}
+	if k.namespacesKey == KeyCanaryNamespaces {
+		nn = k.p.Get(KeyCanaryNamespaces)
+	} else {
+		nn = k.p.GetF(KeyNamespaces, "file://./keto_namespaces")
+	}
+
+	switch nTyped := nn.(type) {
 	case string:
 		return nTyped, nil
 	case []*namespace.Namespace:
@@ -259,6 +704,364 @@ func (k *Config) getNamespaces() (interface{}, error) {
 	}
 }
 
+// Region returns the region this instance is located in. It is empty if
+// network pinning is disabled.
+func (k *Config) Region() string {
+	return k.p.String(KeyRegionName)
+}
+
+// NetworkRegion returns the home region of the network, or the empty string
+// if the network is not pinned to a region.
+func (k *Config) NetworkRegion(nid uuid.UUID) string {
+	return k.p.StringMap(KeyRegionNetworks)[nid.String()]
+}
+
+// RegionNetworks returns the networks that are homed in the region.
+func (k *Config) RegionNetworks(region string) []uuid.UUID {
+	var nids []uuid.UUID
+	for id, home := range k.p.StringMap(KeyRegionNetworks) {
+		if nid, err := uuid.FromString(id); err == nil && home == region {
+			nids = append(nids, nid)
+		}
+	}
+	return nids
+}
+
+func (k *Config) MisroutedRequests() string {
+	return k.p.StringF(KeyRegionMisroutedRequests, MisroutedRequestsReject)
+}
+
+// RegionDSNs returns the DSNs of the databases of the regions that networks
+// are homed in, keyed by region.
+func (k *Config) RegionDSNs() map[string]string {
+	return k.p.StringMap(KeyRegionDatabases)
+}
+
+// MultiPrimary returns whether more than one region writes to the replicated
+// database.
+func (k *Config) MultiPrimary() bool {
+	return k.p.StringF(KeyReplicationMode, ReplicationModeSinglePrimary) == ReplicationModeMultiPrimary
+}
+
+// TombstoneRetention returns how long the tombstones of deleted relation tuples
+// are kept in multi-primary mode.
+func (k *Config) TombstoneRetention() time.Duration {
+	return k.p.DurationF(KeyReplicationTombstoneRetention, 7*24*time.Hour)
+}
+
+func (k *Config) MeteringEnabled() bool {
+	return k.p.Bool(KeyMeteringEnabled)
+}
+
+// MeteringExport returns the directory or object storage URL to export the
+// usage to and the length of a period. The directory is empty if usage is not
+// exported.
+func (k *Config) MeteringExport() (directory string, interval time.Duration) {
+	return k.p.String(KeyMeteringExportDirectory), k.p.DurationF(KeyMeteringExportInterval, time.Hour)
+}
+
+func (k *Config) S3Storage() S3Storage {
+	return S3Storage{
+		Region:               k.p.String(KeyObjectStorageS3Region),
+		Endpoint:             k.p.String(KeyObjectStorageS3Endpoint),
+		ServerSideEncryption: k.p.String(KeyObjectStorageS3ServerSideEncryption),
+		KMSKeyID:             k.p.String(KeyObjectStorageS3KMSKeyID),
+	}
+}
+
+func (k *Config) GCSStorage() GCSStorage {
+	return GCSStorage{
+		Endpoint:        k.p.String(KeyObjectStorageGCSEndpoint),
+		CredentialsFile: k.p.String(KeyObjectStorageGCSCredentialsFile),
+		KMSKeyName:      k.p.String(KeyObjectStorageGCSKMSKeyName),
+	}
+}
+
+func (k *Config) AzureStorage() AzureStorage {
+	return AzureStorage{
+		Account:         k.p.String(KeyObjectStorageAzureAccount),
+		Endpoint:        k.p.String(KeyObjectStorageAzureEndpoint),
+		EncryptionScope: k.p.String(KeyObjectStorageAzureEncryptionScope),
+	}
+}
+
+// MirrorRemotes returns the gRPC addresses of the read and write API of the
+// secondary Keto that writes are mirrored to. The write remote is empty if
+// writes are not mirrored.
+func (k *Config) MirrorRemotes() (read, write string) {
+	return k.p.String(KeyMirrorReadRemote), k.p.String(KeyMirrorWriteRemote)
+}
+
+func (k *Config) MirrorQueueSize() int {
+	return k.p.IntF(KeyMirrorQueueSize, 10000)
+}
+
+// WatchPollInterval returns how often watch requests look for a new
+// snapshot.
+func (k *Config) WatchPollInterval() time.Duration {
+	return k.p.DurationF(KeyWatchPollInterval, 250*time.Millisecond)
+}
+
+// ProxyUpstream returns the gRPC address and the REST URL of the read API of
+// the upstream Keto. Checks and expands are only proxied if the address is
+// set.
+func (k *Config) ProxyUpstream() (remote, url string) {
+	return k.p.String(KeyProxyReadRemote), k.p.String(KeyProxyReadURL)
+}
+
+func (k *Config) ProxyMaxCachedResults() int {
+	return k.p.IntF(KeyProxyMaxCachedResults, 10000)
+}
+
+// SidecarNamespaces returns the namespaces whose relation tuples are copied
+// from the upstream to check them locally.
+func (k *Config) SidecarNamespaces() []string {
+	return k.p.Strings(KeySidecarNamespaces)
+}
+
+// SidecarMaxStaleness returns how long the copied relation tuples are used
+// since they were last known to match the upstream.
+func (k *Config) SidecarMaxStaleness() time.Duration {
+	return k.p.DurationF(KeySidecarMaxStaleness, 10*time.Second)
+}
+
+// CanaryEnabled returns whether a canary namespace config is loaded alongside
+// the active one.
+func (k *Config) CanaryEnabled() bool {
+	return k.p.Get(KeyCanaryNamespaces) != nil
+}
+
+// CanarySampleRate returns the fraction of checks that are also evaluated
+// against the canary namespace config.
+func (k *Config) CanarySampleRate() float64 {
+	if !k.CanaryEnabled() {
+		return 0
+	}
+	return k.p.Float64F(KeyCanarySampleRate, 0)
+}
+
+// ShadowFeatureFlags returns the feature flags of the candidate strategy that
+// sampled checks are also evaluated with.
+func (k *Config) ShadowFeatureFlags() map[string]bool {
+	raw, _ := k.p.Get(KeyShadowFeatureFlags).(map[string]interface{})
+	flags := make(map[string]bool, len(raw))
+	for name := range raw {
+		flags[name] = k.p.Bool(KeyShadowFeatureFlags + "." + name)
+	}
+	return flags
+}
+
+// ShadowSampleRate returns the fraction of checks that are also evaluated with
+// the candidate strategy.
+func (k *Config) ShadowSampleRate() float64 {
+	if len(k.ShadowFeatureFlags()) == 0 {
+		return 0
+	}
+	return k.p.Float64F(KeyShadowSampleRate, 0)
+}
+
+// SnapshotDeltaRetention returns how long deleted relation tuples are kept for
+// deltas.
+func (k *Config) SnapshotDeltaRetention() time.Duration {
+	return k.p.DurationF(KeySnapshotDeltaRetention, 7*24*time.Hour)
+}
+
+// Staleness returns how long check results may be served from caches, and the
+// interval that the snapshots they are valid for are quantized to. Caching is
+// disabled if the staleness window is zero.
+func (k *Config) Staleness() (window, quantization time.Duration) {
+	return k.p.DurationF(KeyConsistencyMaxStaleness, 0), k.p.DurationF(KeyConsistencyQuantization, 0)
+}
+
+// ConsistencyVerifierEnabled returns whether a sample of the checks is
+// evaluated again in the background without the object filter.
+func (k *Config) ConsistencyVerifierEnabled() bool {
+	return k.p.Bool(KeyConsistencyVerifierEnabled)
+}
+
+// ConsistencyVerifier returns the fraction of checks that are verified, how
+// often the sampled checks are verified, and how many are kept until then.
+func (k *Config) ConsistencyVerifier() (sampleRate float64, interval time.Duration, maxSamples int) {
+	return k.p.Float64F(KeyConsistencyVerifierSampleRate, 0.01),
+		k.p.DurationF(KeyConsistencyVerifierInterval, time.Minute),
+		k.p.IntF(KeyConsistencyVerifierMaxSamples, 1000)
+}
+
+// Dev returns whether the development mode is enabled, in which requests can
+// override the feature flags.
+func (k *Config) Dev() bool {
+	return k.p.Bool(KeyDev)
+}
+
+// FeatureFlag returns whether the experimental behavior is enabled globally.
+func (k *Config) FeatureFlag(name string) bool {
+	return k.p.Bool(KeyFeatureFlags + "." + name)
+}
+
+// NamespaceFeatureFlag returns whether the experimental behavior is enabled
+// for the namespace. The namespace config takes precedence over the global
+// one if it sets the flag.
+func (k *Config) NamespaceFeatureFlag(name, namespace string) bool {
+	key := KeyFeatureFlagNamespaces + "." + namespace + "." + name
+	if namespace != "" && !strings.Contains(namespace, ".") && k.p.Get(key) != nil {
+		return k.p.Bool(key)
+	}
+	return k.FeatureFlag(name)
+}
+
+// Canary returns the config that resolves namespaces using the canary
+// namespace config. All other values are the same as the ones of k.
+func (k *Config) Canary() *Config {
+	if k.namespacesKey == KeyCanaryNamespaces {
+		return k
+	}
+
+	k.nmLock.Lock()
+	defer k.nmLock.Unlock()
+
+	if k.canary == nil {
+		k.canary = &Config{
+			p:             k.p,
+			l:             k.l,
+			ctx:           k.ctx,
+			nsStore:       k.nsStore,
+			namespacesKey: KeyCanaryNamespaces,
+		}
+	}
+	return k.canary
+}
+
+type canaryContextKey struct{}
+
+// WithCanary returns a context in which config providers return the canary
+// config, so that namespaces are resolved using the canary namespace config.
+func WithCanary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, canaryContextKey{}, true)
+}
+
+func IsCanary(ctx context.Context) bool {
+	canary, _ := ctx.Value(canaryContextKey{}).(bool)
+	return canary
+}
+
+// ReadOnly returns whether mutations are rejected.
+func (k *Config) ReadOnly() bool {
+	return k.p.Bool(KeyReadOnly)
+}
+
+func (k *Config) MigrationDirectories() []string {
+	return k.p.Strings(KeyMigrationDirectories)
+}
+
+// Seed returns the namespace files and relation tuple files that an empty
+// database is seeded with on first boot.
+func (k *Config) Seed() (namespaceFiles, tupleFiles []string) {
+	return k.p.Strings(KeySeedNamespaces), k.p.Strings(KeySeedRelationTuples)
+}
+
+// MigrationAdvisedIndexes returns whether the migrations of the advised
+// indexes are applied together with Keto's migrations.
+func (k *Config) MigrationAdvisedIndexes() bool {
+	return k.p.Bool(KeyMigrationAdvisedIndexes)
+}
+
+func (k *Config) OIDCEnabled() bool {
+	return k.p.Bool(KeyOIDCEnabled)
+}
+
+func (k *Config) OIDCIssuer() string {
+	return k.p.String(KeyOIDCIssuer)
+}
+
+func (k *Config) OIDCJWKSURL() string {
+	return k.p.String(KeyOIDCJWKSURL)
+}
+
+func (k *Config) OIDCAudiences() []string {
+	return k.p.Strings(KeyOIDCAudiences)
+}
+
+func (k *Config) OIDCSubjectClaim() string {
+	return k.p.StringF(KeyOIDCSubjectClaim, "sub")
+}
+
+// OIDCSubject returns how the subject claim is mapped to the subject: the
+// prefix is prepended to the claim value, and if a namespace is returned, the
+// subject is the subject set "<namespace>:<prefix><claim>#<relation>" instead
+// of a subject ID.
+func (k *Config) OIDCSubject() (prefix, namespace, relation string) {
+	return k.p.String(KeyOIDCSubjectPrefix), k.p.String(KeyOIDCSubjectSet + ".namespace"), k.p.String(KeyOIDCSubjectSet + ".relation")
+}
+
+// OIDCGroups returns the claim that groups are read from and the namespace
+// and relation of the subject sets they are mapped to. The claim is empty if
+// groups are not mapped.
+func (k *Config) OIDCGroups() (claim, namespace, relation string) {
+	return k.p.String(KeyOIDCGroupsClaim), k.p.String(KeyOIDCGroupsNamespace), k.p.String(KeyOIDCGroupsRelation)
+}
+
+// Groups returns the namespace of the groups managed through the group API and
+// the relation of their members.
+func (k *Config) Groups() (namespace, relation string) {
+	return k.p.StringF(KeyGroupsNamespace, "groups"), k.p.StringF(KeyGroupsRelation, "member")
+}
+
+// OrgsNamespace returns the namespace of the organizations, teams, and
+// projects managed through the organization API.
+func (k *Config) OrgsNamespace() string {
+	return k.p.StringF(KeyOrgsNamespace, "orgs")
+}
+
+// LDAPSync returns the settings of the LDAP group synchronization and whether
+// it is enabled.
+func (k *Config) LDAPSync() (LDAPSync, bool) {
+	return LDAPSync{
+		URL:                k.p.String("ldap_sync.url"),
+		BindDN:             k.p.String("ldap_sync.bind_dn"),
+		BindPassword:       k.p.String("ldap_sync.bind_password"),
+		BaseDN:             k.p.String("ldap_sync.base_dn"),
+		GroupFilter:        k.p.StringF("ldap_sync.group_filter", "(objectClass=group)"),
+		UserFilter:         k.p.StringF("ldap_sync.user_filter", "(objectClass=person)"),
+		GroupNameAttribute: k.p.StringF("ldap_sync.attributes.group_name", "cn"),
+		MemberAttribute:    k.p.StringF("ldap_sync.attributes.member", "member"),
+		UserIDAttribute:    k.p.StringF("ldap_sync.attributes.user_id", "sAMAccountName"),
+		Namespace:          k.p.StringF("ldap_sync.namespace", "ldap"),
+		Interval:           k.p.DurationF("ldap_sync.interval", time.Hour),
+		Timeout:            k.p.DurationF("ldap_sync.timeout", 30*time.Second),
+		PageSize:           k.p.IntF("ldap_sync.page_size", 500),
+	}, k.p.Bool(KeyLDAPSyncEnabled)
+}
+
+// FaultInjection returns the faults to inject into persister calls, and
+// whether fault injection is enabled. It only has an effect in binaries built
+// with the faultinjection tag.
+func (k *Config) FaultInjection() (FaultInjection, bool) {
+	return FaultInjection{
+		Latency:            k.p.DurationF("fault_injection.latency", 0),
+		LatencyRate:        k.p.Float64F("fault_injection.latency_rate", 0),
+		ErrorRate:          k.p.Float64F("fault_injection.error_rate", 0),
+		PartialFailureRate: k.p.Float64F("fault_injection.partial_failure_rate", 0),
+		Operations:         k.p.Strings("fault_injection.operations"),
+	}, k.p.Bool(KeyFaultInjectionEnabled)
+}
+
+// SCIMEnabled returns whether the SCIM endpoints are served on the write API.
+func (k *Config) SCIMEnabled() bool {
+	return k.p.Bool(KeySCIMEnabled)
+}
+
+// SCIMBearerToken returns the token that identity providers authenticate SCIM
+// requests with. SCIM requests are rejected while it is empty.
+func (k *Config) SCIMBearerToken() string {
+	return k.p.String(KeySCIMBearerToken)
+}
+
+// SCIMNamespace returns the namespace recording the users and groups
+// provisioned through SCIM.
+func (k *Config) SCIMNamespace() string {
+	return k.p.StringF(KeySCIMNamespace, "scim")
+}
+
 func (k *Config) MetricsListenOn() string {
 	return fmt.Sprintf(
 		"%s:%d",
@@ -8,7 +8,7 @@ import (
 	"github.com/ory/keto/embedx"
 
 	"github.com/ory/x/configx"
-
+	"github.com/ory/x/dbal"
 	"github.com/ory/x/logrusx"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/spf13/pflag"
@@ -122,6 +122,28 @@ func TestKoanfNamespaceManager(t *testing.T) {
 		assert.True(t, ok)
 	})
 
+	t.Run("case=loads the canary namespaces alongside the active ones", func(t *testing.T) {
+		_, p := setup(t)
+
+		active, candidate := &namespace.Namespace{ID: 0, Name: "files"}, &namespace.Namespace{ID: 1, Name: "files"}
+		require.NoError(t, p.Set(KeyNamespaces, []*namespace.Namespace{active}))
+		assert.False(t, p.CanaryEnabled())
+		assert.Zero(t, p.CanarySampleRate())
+
+		require.NoError(t, p.Set(KeyCanaryNamespaces, []*namespace.Namespace{candidate}))
+		require.NoError(t, p.Set(KeyCanarySampleRate, 0.5))
+		assert.True(t, p.CanaryEnabled())
+		assert.Equal(t, 0.5, p.CanarySampleRate())
+
+		assertNamespaces(t, p, active)
+		assertNamespaces(t, p.Canary(), candidate)
+		assert.Same(t, p.Canary(), p.Canary().Canary())
+
+		candidate = &namespace.Namespace{ID: 2, Name: "files"}
+		require.NoError(t, p.Set(KeyCanaryNamespaces, []*namespace.Namespace{candidate}))
+		assertNamespaces(t, p.Canary(), candidate)
+	})
+
 	t.Run("case=uses passed configx provider", func(t *testing.T) {
 		ctx := context.Background()
 		cp, err := configx.New(ctx, embedx.ConfigSchema, configx.WithValue(KeyDSN, "foobar"))
@@ -131,4 +153,98 @@ func TestKoanfNamespaceManager(t *testing.T) {
 		assert.Equal(t, "foobar", p.DSN())
 		assert.Same(t, cp, p.p)
 	})
+
+	t.Run("case=memory DSN is unique per config", func(t *testing.T) {
+		ctx := context.Background()
+		newConfig := func() *Config {
+			cp, err := configx.New(ctx, embedx.ConfigSchema, configx.WithValue(KeyDSN, "memory"))
+			require.NoError(t, err)
+			return New(ctx, logrusx.New("test", "today"), cp)
+		}
+
+		a, b := newConfig(), newConfig()
+		assert.True(t, dbal.IsMemorySQLite(a.DSN()), a.DSN())
+		assert.Equal(t, a.DSN(), a.DSN())
+		assert.NotEqual(t, a.DSN(), b.DSN())
+	})
+}
+
+func TestNamespacesFromEnv(t *testing.T) {
+	newConfig := func(t *testing.T) (*Config, error) {
+		t.Setenv(KeyDSN, "memory")
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		return NewDefault(ctx, pflag.NewFlagSet("test", pflag.ContinueOnError), logrusx.New("test", "today"))
+	}
+
+	t.Run("case=inline JSON array", func(t *testing.T) {
+		t.Setenv(EnvNamespaces, ` [{"name": "files", "id": 0}, {"name": "groups", "id": 1}]`)
+
+		p, err := newConfig(t)
+		require.NoError(t, err)
+
+		nm, err := p.NamespaceManager()
+		require.NoError(t, err)
+		_, ok := nm.(*memoryNamespaceManager)
+		assert.True(t, ok)
+
+		n, err := nm.GetNamespaceByConfigID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "groups", n.Name)
+	})
+
+	t.Run("case=URI", func(t *testing.T) {
+		t.Setenv(EnvNamespaces, "file://"+t.TempDir())
+
+		p, err := newConfig(t)
+		require.NoError(t, err)
+
+		nm, err := p.NamespaceManager()
+		require.NoError(t, err)
+		_, ok := nm.(*NamespaceWatcher)
+		assert.True(t, ok)
+	})
+
+	t.Run("case=validates against the schema", func(t *testing.T) {
+		t.Setenv(EnvNamespaces, `[{"name": "files"}]`)
+
+		_, err := newConfig(t)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `missing properties: "id"`)
+	})
+
+	t.Run("case=malformed JSON", func(t *testing.T) {
+		t.Setenv(EnvNamespaces, `[{"name": "files", `)
+
+		_, err := newConfig(t)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), EnvNamespaces)
+	})
+}
+
+func TestObjectFilterRequiresChangeFeed(t *testing.T) {
+	newConfig := func(t *testing.T, values map[string]interface{}) (*Config, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		values[KeyDSN] = "memory"
+		return NewDefault(ctx, pflag.NewFlagSet("test", pflag.ContinueOnError), logrusx.New("test", "today"), configx.WithValues(values))
+	}
+
+	t.Run("case=rejects the object filter without changefeed", func(t *testing.T) {
+		_, err := newConfig(t, map[string]interface{}{KeyObjectFilterEnabled: true})
+		require.Error(t, err)
+	})
+
+	t.Run("case=accepts the object filter with changefeed", func(t *testing.T) {
+		c, err := newConfig(t, map[string]interface{}{KeyObjectFilterEnabled: true, KeyChangeFeedEnabled: true})
+		require.NoError(t, err)
+		assert.True(t, c.ObjectFilterEnabled())
+
+		assert.Error(t, c.Set(KeyChangeFeedEnabled, false))
+	})
+
+	t.Run("case=accepts the changefeed without object filter", func(t *testing.T) {
+		_, err := newConfig(t, map[string]interface{}{KeyChangeFeedEnabled: true})
+		require.NoError(t, err)
+	})
 }
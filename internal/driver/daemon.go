@@ -9,13 +9,13 @@ import (
 	"strings"
 	"syscall"
 
-	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
-
 	prometheus "github.com/ory/x/prometheusx"
 	grpcOtel "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 
+	"github.com/ory/x/configx"
 	"github.com/ory/x/logrusx"
+	"github.com/ory/x/networkx"
 
 	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
@@ -25,11 +25,30 @@ import (
 	"github.com/rs/cors"
 	"github.com/urfave/negroni"
 	grpcHealthV1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/ory/keto/internal/adminui"
 	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/debug"
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/graphstats"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/ldapsync"
+	"github.com/ory/keto/internal/metering"
+	"github.com/ory/keto/internal/mirror"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/opa"
+	"github.com/ory/keto/internal/orgs"
+	"github.com/ory/keto/internal/readonly"
 	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/roles"
+	"github.com/ory/keto/internal/scim"
+	"github.com/ory/keto/internal/servermetadata"
+	"github.com/ory/keto/internal/snapshot"
 	"github.com/ory/keto/internal/x"
 
 	"github.com/ory/analytics-go/v4"
@@ -43,6 +62,8 @@ import (
 	"github.com/ory/graceful"
 	"github.com/pkg/errors"
 	"github.com/soheilhy/cmux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 )
@@ -85,6 +106,12 @@ func (r *RegistryDefault) ServeAllSQA(cmd *cobra.Command) error {
 }
 
 func (r *RegistryDefault) ServeAll(ctx context.Context) error {
+	// Seed before serving, so that no client sees a partially seeded
+	// database.
+	if err := r.Seeder().Seed(ctx); err != nil {
+		return err
+	}
+
 	innerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -100,6 +127,10 @@ func (r *RegistryDefault) ServeAll(ctx context.Context) error {
 		case <-innerCtx.Done():
 		}
 
+		// Let clients stop sending new requests to this instance while the
+		// servers drain.
+		r.HealthServer().Shutdown()
+
 		ctx, cancel := context.WithTimeout(context.Background(), graceful.DefaultShutdownTimeout)
 		defer cancel()
 
@@ -121,6 +152,27 @@ func (r *RegistryDefault) ServeAll(ctx context.Context) error {
 	eg.Go(r.serveRead(innerCtx, doneShutdown))
 	eg.Go(r.serveWrite(innerCtx, doneShutdown))
 	eg.Go(r.serveMetrics(innerCtx, doneShutdown))
+	eg.Go(func() error {
+		return r.Meter().ExportPeriodically(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.Mirror().Run(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.Proxy().Run(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.ObjectFilter().Run(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.ChangeFeed().Run(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.LDAPSyncer().Run(innerCtx)
+	})
+	eg.Go(func() error {
+		return r.ConsistencyVerifier().Run(innerCtx)
+	})
 
 	return eg.Wait()
 }
@@ -133,7 +185,7 @@ func (r *RegistryDefault) serveRead(ctx context.Context, done chan<- struct{}) f
 	}
 
 	return func() error {
-		return multiplexPort(ctx, r.Logger().WithField("endpoint", "read"), r.Config(ctx).ReadAPIListenOn(), rt, s, done)
+		return multiplexPort(ctx, r.Logger().WithField("endpoint", "read"), r.Config(ctx).ReadAPIListenOn(), r.Config(ctx).SocketPermission("read"), r.Config(ctx).H2C("read"), rt, s, done)
 	}
 }
 
@@ -145,7 +197,7 @@ func (r *RegistryDefault) serveWrite(ctx context.Context, done chan<- struct{})
 	}
 
 	return func() error {
-		return multiplexPort(ctx, r.Logger().WithField("endpoint", "write"), r.Config(ctx).WriteAPIListenOn(), rt, s, done)
+		return multiplexPort(ctx, r.Logger().WithField("endpoint", "write"), r.Config(ctx).WriteAPIListenOn(), r.Config(ctx).SocketPermission("write"), r.Config(ctx).H2C("write"), rt, s, done)
 	}
 }
 
@@ -187,8 +239,38 @@ func (r *RegistryDefault) serveMetrics(ctx context.Context, done chan<- struct{}
 	}
 }
 
-func multiplexPort(ctx context.Context, log *logrusx.Logger, addr string, router http.Handler, grpcS *grpc.Server, done chan<- struct{}) error {
-	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+// listen listens on the TCP address, or on the unix socket if the address has
+// the "unix:" prefix. A socket file left behind by a previous process is
+// replaced, but not one that is still in use.
+func listen(ctx context.Context, addr string, perm *configx.UnixPermission) (net.Listener, error) {
+	if !networkx.AddressIsUnixSocket(addr) {
+		return (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	}
+
+	path := strings.TrimPrefix(addr, "unix:")
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if c, err := (&net.Dialer{}).DialContext(ctx, "unix", path); err == nil {
+			_ = c.Close()
+			return nil, errors.Errorf("the unix socket %s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	l, err := (&net.ListenConfig{}).Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := perm.SetPermission(path); err != nil {
+		_ = l.Close()
+		return nil, errors.Wrapf(err, "could not set the permissions of the unix socket %s", path)
+	}
+	return l, nil
+}
+
+func multiplexPort(ctx context.Context, log *logrusx.Logger, addr string, perm *configx.UnixPermission, h2cEnabled bool, router http.Handler, grpcS *grpc.Server, done chan<- struct{}) error {
+	l, err := listen(ctx, addr, perm)
 	if err != nil {
 		return err
 	}
@@ -197,11 +279,25 @@ func multiplexPort(ctx context.Context, log *logrusx.Logger, addr string, router
 	m.SetReadTimeout(graceful.DefaultReadTimeout)
 
 	grpcL := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
-	httpL := m.Match(cmux.HTTP1())
+	httpLs := []net.Listener{m.Match(cmux.HTTP1())}
 
 	restS := graceful.WithDefaults(&http.Server{
 		Handler: router,
 	})
+	if h2cEnabled {
+		// Clients with prior knowledge skip the HTTP/1.1 upgrade, their
+		// connections start with the HTTP/2 preface.
+		httpLs = append(httpLs, m.Match(cmux.HTTP2()))
+
+		h2s := &http2.Server{}
+		// Registers the HTTP/2 server for the shutdown of restS, which then
+		// sends GOAWAY frames to the h2c connections. They are hijacked and
+		// therefore not closed by restS itself.
+		if err := http2.ConfigureServer(restS, h2s); err != nil {
+			return errors.WithStack(err)
+		}
+		restS.Handler = h2c.NewHandler(router, h2s)
+	}
 
 	eg := &errgroup.Group{}
 
@@ -212,12 +308,15 @@ func multiplexPort(ctx context.Context, log *logrusx.Logger, addr string, router
 		return nil
 	})
 
-	eg.Go(func() error {
-		if err := restS.Serve(httpL); !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, cmux.ErrServerClosed) {
-			return errors.WithStack(err)
-		}
-		return nil
-	})
+	for _, httpL := range httpLs {
+		httpL := httpL
+		eg.Go(func() error {
+			if err := restS.Serve(httpL); !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, cmux.ErrServerClosed) {
+				return errors.WithStack(err)
+			}
+			return nil
+		})
+	}
 
 	eg.Go(func() error {
 		err := m.Serve()
@@ -279,22 +378,50 @@ func (r *RegistryDefault) allHandlers() []Handler {
 			relationtuple.NewHandler(r),
 			check.NewHandler(r),
 			expand.NewHandler(r),
+			adminui.NewHandler(r),
+			opa.NewHandler(r),
+			metering.NewHandler(r),
+			graphstats.NewHandler(r),
+			snapshot.NewHandler(r),
+			mirror.NewHandler(r),
+			servermetadata.NewHandler(r),
+			namespace.NewHandler(r),
+			roles.NewHandler(r),
+			groups.NewHandler(r),
+			orgs.NewHandler(r),
+			scim.NewHandler(r),
+			ldapsync.NewHandler(r),
+			featureflag.NewHandler(r),
+			readonly.NewHandler(r),
 		}
 	}
 	return r.handlers
 }
 
+// requestLog logs the requests with their request ID.
+func (r *RegistryDefault) requestLog(name string) *reqlog.Middleware {
+	m := reqlog.NewMiddlewareFromLogger(r.l, name).ExcludePaths(healthx.AliveCheckPath, healthx.ReadyCheckPath)
+	m.Before = requestid.ReqlogBefore
+	return m
+}
+
 func (r *RegistryDefault) ReadRouter(ctx context.Context) http.Handler {
 	n := negroni.New()
 	for _, f := range r.defaultHttpMiddlewares {
 		n.UseFunc(f)
 	}
-	n.Use(reqlog.NewMiddlewareFromLogger(r.l, "read#Ory Keto").ExcludePaths(healthx.AliveCheckPath, healthx.ReadyCheckPath))
+	n.UseFunc(requestid.HTTPMiddleware)
+	n.UseFunc(r.LoadReporter().HTTPMiddleware)
+	n.Use(r.requestLog("read#Ory Keto"))
+	n.UseFunc(r.BodyLimits().HTTPMiddleware("read"))
+	n.UseFunc(r.CSRFProtection().HTTPMiddleware("read"))
+	n.UseFunc(r.RegionPinning().HTTPMiddleware)
+	n.UseFunc(r.Deadlines().HTTPMiddleware)
+	n.UseFunc(r.FeatureFlagOverrides().HTTPMiddleware)
 
 	br := &x.ReadRouter{Router: httprouter.New()}
 
 	r.HealthHandler().SetHealthRoutes(br.Router, false)
-	r.HealthHandler().SetVersionRoutes(br.Router)
 
 	for _, h := range r.allHandlers() {
 		h.RegisterReadRoutes(br)
@@ -320,12 +447,16 @@ func (r *RegistryDefault) WriteRouter(ctx context.Context) http.Handler {
 	for _, f := range r.defaultHttpMiddlewares {
 		n.UseFunc(f)
 	}
-	n.Use(reqlog.NewMiddlewareFromLogger(r.l, "write#Ory Keto").ExcludePaths(healthx.AliveCheckPath, healthx.ReadyCheckPath))
+	n.UseFunc(requestid.HTTPMiddleware)
+	n.UseFunc(r.LoadReporter().HTTPMiddleware)
+	n.Use(r.requestLog("write#Ory Keto"))
+	n.UseFunc(r.BodyLimits().HTTPMiddleware("write"))
+	n.UseFunc(r.RegionPinning().HTTPMiddleware)
+	n.UseFunc(r.ReadOnlyMode().HTTPMiddleware)
 
 	pr := &x.WriteRouter{Router: httprouter.New()}
 
 	r.HealthHandler().SetHealthRoutes(pr.Router, false)
-	r.HealthHandler().SetVersionRoutes(pr.Router)
 
 	for _, h := range r.allHandlers() {
 		h.RegisterWriteRoutes(pr)
@@ -347,13 +478,20 @@ func (r *RegistryDefault) WriteRouter(ctx context.Context) http.Handler {
 }
 
 func (r *RegistryDefault) unaryInterceptors(ctx context.Context) []grpc.UnaryServerInterceptor {
-	is := make([]grpc.UnaryServerInterceptor, len(r.defaultUnaryInterceptors), len(r.defaultUnaryInterceptors)+2)
+	is := make([]grpc.UnaryServerInterceptor, len(r.defaultUnaryInterceptors), len(r.defaultUnaryInterceptors)+5)
 	copy(is, r.defaultUnaryInterceptors)
 	is = append(is,
+		requestid.UnaryInterceptor,
+		r.LoadReporter().UnaryInterceptor,
 		herodot.UnaryErrorUnwrapInterceptor,
+		errorcode.UnaryInterceptor,
 		grpcMiddleware.ChainUnaryServer(
 			grpc_logrus.UnaryServerInterceptor(r.l.Entry),
 		),
+		r.RegionPinning().UnaryInterceptor,
+		r.ReadOnlyMode().UnaryInterceptor,
+		r.Deadlines().UnaryInterceptor,
+		r.FeatureFlagOverrides().UnaryInterceptor,
 	)
 	if r.Tracer(ctx).IsLoaded() {
 		is = append(is, grpcOtel.UnaryServerInterceptor(grpcOtel.WithTracerProvider(otel.GetTracerProvider())))
@@ -365,13 +503,19 @@ func (r *RegistryDefault) unaryInterceptors(ctx context.Context) []grpc.UnarySer
 }
 
 func (r *RegistryDefault) streamInterceptors(ctx context.Context) []grpc.StreamServerInterceptor {
-	is := make([]grpc.StreamServerInterceptor, len(r.defaultStreamInterceptors), len(r.defaultStreamInterceptors)+2)
+	is := make([]grpc.StreamServerInterceptor, len(r.defaultStreamInterceptors), len(r.defaultStreamInterceptors)+5)
 	copy(is, r.defaultStreamInterceptors)
 	is = append(is,
+		requestid.StreamInterceptor,
+		r.LoadReporter().StreamInterceptor,
 		herodot.StreamErrorUnwrapInterceptor,
+		errorcode.StreamInterceptor,
 		grpcMiddleware.ChainStreamServer(
 			grpc_logrus.StreamServerInterceptor(r.l.Entry),
 		),
+		r.RegionPinning().StreamInterceptor,
+		r.ReadOnlyMode().StreamInterceptor,
+		r.FeatureFlagOverrides().StreamInterceptor,
 	)
 	if r.Tracer(ctx).IsLoaded() {
 		is = append(is, grpcOtel.StreamServerInterceptor(grpcOtel.WithTracerProvider(otel.GetTracerProvider())))
@@ -382,37 +526,59 @@ func (r *RegistryDefault) streamInterceptors(ctx context.Context) []grpc.StreamS
 	return is
 }
 
-func (r *RegistryDefault) ReadGRPCServer(ctx context.Context) *grpc.Server {
-	s := grpc.NewServer(
+func (r *RegistryDefault) grpcServerOptions(ctx context.Context, iface string) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
 		grpc.ChainStreamInterceptor(r.streamInterceptors(ctx)...),
 		grpc.ChainUnaryInterceptor(r.unaryInterceptors(ctx)...),
-	)
+		grpc.MaxRecvMsgSize(r.Config(ctx).MaxBodySize(iface)),
+	}
+	if age, grace := r.Config(ctx).GRPCMaxConnectionAge(iface); age > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionAge:      age,
+			MaxConnectionAgeGrace: grace,
+		}))
+	}
+	return opts
+}
+
+// setServingStatus reports every service of the server as serving, so that
+// clients and proxies checking the health of a specific service (e.g. gRPC
+// client-side health checking or xDS health checks) can route requests to this
+// instance. All services are reported as not serving once the server shuts
+// down.
+func (r *RegistryDefault) setServingStatus(s *grpc.Server) {
+	for name := range s.GetServiceInfo() {
+		r.HealthServer().SetServingStatus(name, grpcHealthV1.HealthCheckResponse_SERVING)
+	}
+}
+
+func (r *RegistryDefault) ReadGRPCServer(ctx context.Context) *grpc.Server {
+	s := grpc.NewServer(r.grpcServerOptions(ctx, "read")...)
 
 	grpcHealthV1.RegisterHealthServer(s, r.HealthServer())
-	rts.RegisterVersionServiceServer(s, r)
+	r.LoadReporter().Register(s)
 	reflection.Register(s)
 
 	for _, h := range r.allHandlers() {
 		h.RegisterReadGRPC(s)
 	}
 
+	r.setServingStatus(s)
 	return s
 }
 
 func (r *RegistryDefault) WriteGRPCServer(ctx context.Context) *grpc.Server {
-	s := grpc.NewServer(
-		grpc.ChainStreamInterceptor(r.streamInterceptors(ctx)...),
-		grpc.ChainUnaryInterceptor(r.unaryInterceptors(ctx)...),
-	)
+	s := grpc.NewServer(r.grpcServerOptions(ctx, "write")...)
 
 	grpcHealthV1.RegisterHealthServer(s, r.HealthServer())
-	rts.RegisterVersionServiceServer(s, r)
+	r.LoadReporter().Register(s)
 	reflection.Register(s)
 
 	for _, h := range r.allHandlers() {
 		h.RegisterWriteGRPC(s)
 	}
 
+	r.setServingStatus(s)
 	return s
 }
 
@@ -422,6 +588,7 @@ func (r *RegistryDefault) metricsRouter(ctx context.Context) http.Handler {
 
 	r.PrometheusManager().RegisterRouter(router)
 	r.MetricsHandler().SetRoutes(router)
+	debug.NewHandler(r).SetRoutes(router)
 	n.UseHandler(router)
 	n.Use(r.PrometheusManager())
 
@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+)
+
+func TestMultiplexPort(t *testing.T) {
+	for _, h2cEnabled := range []bool{false, true} {
+		h2cEnabled := h2cEnabled
+		t.Run("h2c="+map[bool]string{false: "disabled", true: "enabled"}[h2cEnabled], func(t *testing.T) {
+			dir, err := os.MkdirTemp("", "keto-mux")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = os.RemoveAll(dir) })
+			path := filepath.Join(dir, "api.sock")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{}, 1)
+			served := make(chan error, 1)
+			go func() {
+				served <- multiplexPort(ctx, logrusx.New("", ""), "unix:"+path, &configx.UnixPermission{Mode: 0660}, h2cEnabled, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, _ = io.WriteString(w, r.Proto)
+				}), grpc.NewServer(), done)
+			}()
+
+			dial := func(context.Context, string, string) (net.Conn, error) {
+				return net.Dial("unix", path)
+			}
+			require.Eventually(t, func() bool {
+				c, err := dial(ctx, "", "")
+				if err != nil {
+					return false
+				}
+				_ = c.Close()
+				return true
+			}, 5*time.Second, 10*time.Millisecond)
+
+			get := func(c *http.Client) (string, error) {
+				resp, err := c.Get("http://keto/")
+				if err != nil {
+					return "", err
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				return string(body), err
+			}
+
+			proto, err := get(&http.Client{Transport: &http.Transport{DialContext: dial}})
+			require.NoError(t, err)
+			assert.Equal(t, "HTTP/1.1", proto)
+
+			proto, err = get(&http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+					return dial(ctx, network, addr)
+				},
+			}})
+			if h2cEnabled {
+				require.NoError(t, err)
+				assert.Equal(t, "HTTP/2.0", proto)
+			} else {
+				assert.Error(t, err)
+			}
+
+			cancel()
+			require.NoError(t, <-served)
+			<-done
+		})
+	}
+}
@@ -10,9 +10,15 @@ import (
 	otelsql "github.com/ory/x/otelx/sql"
 	"github.com/ory/x/sqlcon"
 	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/persistence/sql"
 )
 
 func (r *RegistryDefault) PopConnectionWithOpts(ctx context.Context, popOpts ...func(*pop.ConnectionDetails)) (*pop.Connection, error) {
+	return r.popConnection(ctx, r.Config(ctx).DSN(), popOpts...)
+}
+
+func (r *RegistryDefault) popConnection(ctx context.Context, dsn string, popOpts ...func(*pop.ConnectionDetails)) (*pop.Connection, error) {
 	tracer := r.Tracer(ctx)
 
 	var opts []instrumentedsql.Opt
@@ -22,14 +28,21 @@ func (r *RegistryDefault) PopConnectionWithOpts(ctx context.Context, popOpts ...
 			instrumentedsql.WithOmitArgs(),
 		}
 	}
-	pool, idlePool, connMaxLifetime, connMaxIdleTime, cleanedDSN := sqlcon.ParseConnectionOptions(r.Logger(), r.Config(ctx).DSN())
+	slowQueryLog, _ := r.Config(ctx).SlowQueryLog()
+	if slowQueryLog {
+		opts = append(opts,
+			instrumentedsql.WithLogger(sql.NewSlowQueryLogger(r)),
+			instrumentedsql.WithOmitArgs(),
+		)
+	}
+	pool, idlePool, connMaxLifetime, connMaxIdleTime, cleanedDSN := sqlcon.ParseConnectionOptions(r.Logger(), dsn)
 	connDetails := &pop.ConnectionDetails{
 		URL:                       sqlcon.FinalizeDSN(r.Logger(), cleanedDSN),
 		IdlePool:                  idlePool,
 		ConnMaxLifetime:           connMaxLifetime,
 		ConnMaxIdleTime:           connMaxIdleTime,
 		Pool:                      pool,
-		UseInstrumentedDriver:     tracer != nil && tracer.IsLoaded(),
+		UseInstrumentedDriver:     tracer != nil && tracer.IsLoaded() || slowQueryLog,
 		InstrumentedDriverOptions: opts,
 	}
 	for _, o := range popOpts {
@@ -81,3 +94,20 @@ func (r *RegistryDefault) PopConnection(ctx context.Context) (*pop.Connection, e
 	}
 	return r.conn, nil
 }
+
+// RegionPopConnections returns the connections to the region-local databases,
+// keyed by region.
+func (r *RegistryDefault) RegionPopConnections(ctx context.Context) (map[string]*pop.Connection, error) {
+	if r.rcs == nil {
+		conns := make(map[string]*pop.Connection)
+		for region, dsn := range r.Config(ctx).RegionDSNs() {
+			c, err := r.popConnection(ctx, dsn)
+			if err != nil {
+				return nil, err
+			}
+			conns[region] = c
+		}
+		r.rcs = conns
+	}
+	return r.rcs, nil
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/ory/x/healthx"
 	"github.com/ory/x/otelx"
+	"github.com/ory/x/popx"
 	prometheus "github.com/ory/x/prometheusx"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
@@ -14,6 +15,7 @@ import (
 	"github.com/ory/keto/internal/check"
 	"github.com/ory/keto/internal/driver/config"
 	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/namespace"
 	"github.com/ory/keto/internal/persistence"
 	"github.com/ory/keto/internal/relationtuple"
 	"github.com/ory/keto/internal/x"
@@ -28,6 +30,10 @@ type (
 		x.WriterProvider
 
 		relationtuple.ManagerProvider
+		relationtuple.CounterProvider
+		relationtuple.SearcherProvider
+		relationtuple.SnapshotterProvider
+		namespace.ConfigStoreProvider
 		expand.EngineProvider
 		check.EngineProvider
 		persistence.Migrator
@@ -35,6 +41,8 @@ type (
 
 		PopConnection(ctx context.Context) (*pop.Connection, error)
 		PopConnectionWithOpts(ctx context.Context, f ...func(*pop.ConnectionDetails)) (*pop.Connection, error)
+		RegionPopConnections(ctx context.Context) (map[string]*pop.Connection, error)
+		RegionMigrationBoxes(ctx context.Context) (map[string]*popx.MigrationBox, error)
 
 		HealthHandler() *healthx.Handler
 		Tracer(ctx context.Context) *otelx.Tracer
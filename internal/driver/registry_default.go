@@ -2,13 +2,15 @@ package driver
 
 import (
 	"context"
+	"io/fs"
 	"net/http"
+	"os"
 	"sync"
 
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 	"github.com/ory/herodot"
 	"github.com/ory/x/dbal"
-	"github.com/ory/x/fsx"
 	"github.com/ory/x/healthx"
 	"github.com/ory/x/logrusx"
 	"github.com/ory/x/metricsx"
@@ -16,19 +18,42 @@ import (
 	"github.com/ory/x/otelx"
 	"github.com/ory/x/popx"
 	prometheus "github.com/ory/x/prometheusx"
+	"github.com/ory/x/sqlcon"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 
+	"github.com/ory/keto/internal/bodylimit"
+	"github.com/ory/keto/internal/changefeed"
 	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/csrf"
+	"github.com/ory/keto/internal/deadline"
 	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/faultinject"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/ldapsync"
+	"github.com/ory/keto/internal/loadreport"
+	"github.com/ory/keto/internal/metering"
+	"github.com/ory/keto/internal/mirror"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/objectfilter"
+	"github.com/ory/keto/internal/oidc"
+	"github.com/ory/keto/internal/orgs"
 	"github.com/ory/keto/internal/persistence"
 	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/proxy"
+	"github.com/ory/keto/internal/readonly"
+	"github.com/ory/keto/internal/region"
 	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/roles"
+	"github.com/ory/keto/internal/scim"
+	"github.com/ory/keto/internal/seed"
 	"github.com/ory/keto/internal/x"
 	"github.com/ory/keto/ketoctx"
-	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+	"github.com/ory/keto/ketodriver"
 )
 
 var (
@@ -36,7 +61,6 @@ var (
 	_ x.WriterProvider               = (*RegistryDefault)(nil)
 	_ x.LoggerProvider               = (*RegistryDefault)(nil)
 	_ Registry                       = (*RegistryDefault)(nil)
-	_ rts.VersionServiceServer       = (*RegistryDefault)(nil)
 	_ ketoctx.ContextualizerProvider = (*RegistryDefault)(nil)
 )
 
@@ -44,13 +68,37 @@ type (
 	RegistryDefault struct {
 		p     persistence.Persister
 		mb    *popx.MigrationBox
+		rmbs  map[string]*popx.MigrationBox
 		l     *logrusx.Logger
 		w     herodot.Writer
 		ce    *check.Engine
 		ee    *expand.Engine
 		c     *config.Config
 		conn  *pop.Connection
+		rcs   map[string]*pop.Connection
 		ctxer ketoctx.Contextualizer
+		rp    *region.Pinning
+		ro    *readonly.Mode
+		dl    *deadline.Deadlines
+		ff    *featureflag.Overrides
+		lr    *loadreport.Reporter
+		ov    *oidc.Verifier
+		m     *metering.Meter
+		mi    *mirror.Mirror
+		px    *proxy.Proxy
+		of    *objectfilter.Filter
+		cf    *changefeed.Feed
+		rm    *roles.Manager
+		bl    *bodylimit.Limits
+		gm    *groups.Manager
+		om    *orgs.Manager
+		sm    *scim.Manager
+		ls    *ldapsync.Syncer
+		sd    *seed.Seeder
+		fi    *faultinject.Injector
+		rtm   relationtuple.Manager
+		cv    *check.Verifier
+		csrf  *csrf.Protection
 
 		initialized    sync.Once
 		healthH        *healthx.Handler
@@ -64,6 +112,7 @@ type (
 		defaultUnaryInterceptors  []grpc.UnaryServerInterceptor
 		defaultStreamInterceptors []grpc.StreamServerInterceptor
 		defaultHttpMiddlewares    []func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc)
+		extraMigrations           []fs.FS
 	}
 	Handler interface {
 		RegisterReadRoutes(r *x.ReadRouter)
@@ -78,10 +127,20 @@ func (r *RegistryDefault) Contextualizer() ketoctx.Contextualizer {
 }
 
 func (r *RegistryDefault) Config(ctx context.Context) *config.Config {
+	c := r.c
 	if provider := r.ctxer.Config(ctx, r.c.Source()); provider != r.c.Source() {
-		return config.New(ctx, r.Logger(), provider)
+		c = config.New(ctx, r.Logger(), provider)
+		c.UseNamespaceConfigStore(r.NamespaceConfigStore())
 	}
-	return r.c
+	if config.IsCanary(ctx) {
+		return c.Canary()
+	}
+	return c
+}
+
+// RelationTupleConfig returns the config of the relation tuple handlers.
+func (r *RegistryDefault) RelationTupleConfig(ctx context.Context) relationtuple.Config {
+	return r.Config(ctx)
 }
 
 func (r *RegistryDefault) HealthHandler() *healthx.Handler {
@@ -100,10 +159,6 @@ func (r *RegistryDefault) HealthServer() *health.Server {
 	return r.healthServer
 }
 
-func (r *RegistryDefault) GetVersion(_ context.Context, _ *rts.GetVersionRequest) (*rts.GetVersionResponse, error) {
-	return &rts.GetVersionResponse{Version: config.Version}, nil
-}
-
 func (r *RegistryDefault) Tracer(ctx context.Context) *otelx.Tracer {
 	if r.tracer == nil {
 		// Tracing is initialized only once so it can not be hot reloaded or context-aware.
@@ -140,16 +195,48 @@ func (r *RegistryDefault) Logger() *logrusx.Logger {
 
 func (r *RegistryDefault) Writer() herodot.Writer {
 	if r.w == nil {
-		r.w = herodot.NewJSONWriter(r.Logger())
+		w := herodot.NewJSONWriter(r.Logger())
+		w.ErrorEnhancer = errorcode.JSONErrorEnhancer
+		r.w = deadline.Writer(w)
 	}
 	return r.w
 }
 
 func (r *RegistryDefault) RelationTupleManager() relationtuple.Manager {
-	if r.p == nil {
+	if r.rtm == nil {
 		panic("no relation tuple manager, but expected to have one")
 	}
-	return r.p
+	return r.rtm
+}
+
+func (r *RegistryDefault) RelationTupleCounter() relationtuple.Counter {
+	if c, ok := r.Persister().(relationtuple.Counter); ok {
+		return c
+	}
+	return nil
+}
+
+func (r *RegistryDefault) RelationTupleSnapshotter() relationtuple.Snapshotter {
+	if s, ok := r.Persister().(relationtuple.Snapshotter); ok {
+		return s
+	}
+	return nil
+}
+
+func (r *RegistryDefault) RelationTupleSearcher() relationtuple.Searcher {
+	if s, ok := r.Persister().(relationtuple.Searcher); ok {
+		return s
+	}
+	return nil
+}
+
+// NamespaceConfigStore returns the store of the namespace configs, or nil if
+// the storage backend does not support storing them.
+func (r *RegistryDefault) NamespaceConfigStore() namespace.ConfigStore {
+	if s, ok := r.p.(namespace.ConfigStore); ok {
+		return s
+	}
+	return nil
 }
 
 func (r *RegistryDefault) Persister() persistence.Persister {
@@ -166,6 +253,13 @@ func (r *RegistryDefault) PermissionEngine() *check.Engine {
 	return r.ce
 }
 
+func (r *RegistryDefault) ConsistencyVerifier() *check.Verifier {
+	if r.cv == nil {
+		r.cv = check.NewVerifier(r)
+	}
+	return r.cv
+}
+
 func (r *RegistryDefault) ExpandEngine() *expand.Engine {
 	if r.ee == nil {
 		r.ee = expand.NewEngine(r)
@@ -173,17 +267,144 @@ func (r *RegistryDefault) ExpandEngine() *expand.Engine {
 	return r.ee
 }
 
+func (r *RegistryDefault) CSRFProtection() *csrf.Protection {
+	if r.csrf == nil {
+		r.csrf = csrf.New(r)
+	}
+	return r.csrf
+}
+
+func (r *RegistryDefault) BodyLimits() *bodylimit.Limits {
+	if r.bl == nil {
+		r.bl = bodylimit.New(r)
+	}
+	return r.bl
+}
+
+func (r *RegistryDefault) RegionPinning() *region.Pinning {
+	if r.rp == nil {
+		r.rp = region.NewPinning(r)
+	}
+	return r.rp
+}
+
+func (r *RegistryDefault) ReadOnlyMode() *readonly.Mode {
+	if r.ro == nil {
+		r.ro = readonly.New(r)
+	}
+	return r.ro
+}
+
+func (r *RegistryDefault) Deadlines() *deadline.Deadlines {
+	if r.dl == nil {
+		r.dl = deadline.New(r)
+	}
+	return r.dl
+}
+
+func (r *RegistryDefault) FeatureFlagOverrides() *featureflag.Overrides {
+	if r.ff == nil {
+		r.ff = featureflag.New(r)
+	}
+	return r.ff
+}
+
+func (r *RegistryDefault) LoadReporter() *loadreport.Reporter {
+	if r.lr == nil {
+		r.lr = loadreport.New()
+	}
+	return r.lr
+}
+
+func (r *RegistryDefault) Meter() *metering.Meter {
+	return r.m
+}
+
+func (r *RegistryDefault) FaultInjector() *faultinject.Injector {
+	return r.fi
+}
+
+func (r *RegistryDefault) Mirror() *mirror.Mirror {
+	return r.mi
+}
+
+func (r *RegistryDefault) GroupManager() *groups.Manager {
+	if r.gm == nil {
+		r.gm = groups.NewManager(r)
+	}
+	return r.gm
+}
+
+func (r *RegistryDefault) OrgManager() *orgs.Manager {
+	if r.om == nil {
+		r.om = orgs.NewManager(r)
+	}
+	return r.om
+}
+
+func (r *RegistryDefault) LDAPSyncer() *ldapsync.Syncer {
+	if r.ls == nil {
+		r.ls = ldapsync.NewSyncer(r)
+	}
+	return r.ls
+}
+
+func (r *RegistryDefault) Seeder() *seed.Seeder {
+	if r.sd == nil {
+		r.sd = seed.NewSeeder(r)
+	}
+	return r.sd
+}
+
+func (r *RegistryDefault) SCIMManager() *scim.Manager {
+	if r.sm == nil {
+		r.sm = scim.NewManager(r)
+	}
+	return r.sm
+}
+
+func (r *RegistryDefault) RoleManager() *roles.Manager {
+	if r.rm == nil {
+		r.rm = roles.NewManager(r)
+	}
+	return r.rm
+}
+
+func (r *RegistryDefault) Proxy() *proxy.Proxy {
+	return r.px
+}
+
+func (r *RegistryDefault) ObjectFilter() *objectfilter.Filter {
+	return r.of
+}
+
+func (r *RegistryDefault) ChangeFeed() *changefeed.Feed {
+	return r.cf
+}
+
+func (r *RegistryDefault) OIDCVerifier() *oidc.Verifier {
+	if r.ov == nil {
+		r.ov = oidc.NewVerifier(r)
+	}
+	return r.ov
+}
+
+// storageDriver returns the out-of-tree storage driver registered for the
+// scheme of the DSN, if any.
+func (r *RegistryDefault) storageDriver(ctx context.Context) (ketodriver.Driver, bool) {
+	return ketodriver.Lookup(r.Config(ctx).DSN())
+}
+
 func (r *RegistryDefault) MigrationBox(ctx context.Context) (*popx.MigrationBox, error) {
+	if _, ok := r.storageDriver(ctx); ok {
+		return nil, errors.Errorf("the storage driver for the DSN scheme %q does not use SQL migrations", ketodriver.Scheme(r.Config(ctx).DSN()))
+	}
 	if r.mb == nil {
 		c, err := r.PopConnection(ctx)
 		if err != nil {
 			return nil, err
 		}
-
-		mb, err := popx.NewMigrationBox(
-			fsx.Merge(sql.Migrations, networkx.Migrations),
-			popx.NewMigrator(c, r.Logger(), r.Tracer(ctx), 0),
-		)
+		mb, err := r.newMigrationBox(ctx, c)
 		if err != nil {
 			return nil, err
 		}
@@ -192,23 +413,121 @@ func (r *RegistryDefault) MigrationBox(ctx context.Context) (*popx.MigrationBox,
 	return r.mb, nil
 }
 
+// RegionMigrationBoxes returns the migration boxes of the region-local
+// databases, keyed by region.
+func (r *RegistryDefault) RegionMigrationBoxes(ctx context.Context) (map[string]*popx.MigrationBox, error) {
+	if r.rmbs == nil {
+		conns, err := r.RegionPopConnections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mbs := make(map[string]*popx.MigrationBox, len(conns))
+		for region, c := range conns {
+			mb, err := r.newMigrationBox(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			mbs[region] = mb
+		}
+		r.rmbs = mbs
+	}
+	return r.rmbs, nil
+}
+
+func (r *RegistryDefault) newMigrationBox(ctx context.Context, c *pop.Connection) (*popx.MigrationBox, error) {
+	extra := append([]fs.FS{}, r.extraMigrations...)
+	for _, dir := range r.Config(ctx).MigrationDirectories() {
+		extra = append(extra, os.DirFS(dir))
+	}
+	if r.Config(ctx).MigrationAdvisedIndexes() {
+		extra = append(extra, sql.AdvisedIndexMigrations)
+	}
+	return sql.NewMigrationBox(c, r.Logger(), r.Tracer(ctx), extra...)
+}
+
 func (r *RegistryDefault) MigrateUp(ctx context.Context) error {
+	if _, ok := r.storageDriver(ctx); ok {
+		if err := r.Init(ctx); err != nil {
+			return err
+		}
+		if m, ok := r.p.(ketodriver.Migrator); ok {
+			return m.MigrateUp(ctx)
+		}
+		return nil
+	}
+
 	mb, err := r.MigrationBox(ctx)
 	if err != nil {
 		return err
 	}
-	if err := mb.Up(ctx); err != nil {
+	c, err := r.PopConnection(ctx)
+	if err != nil {
+		return err
+	}
+	// Replicas that start at the same time must not apply the same
+	// migrations concurrently.
+	if err := sql.WithMigrationLock(ctx, c, mb.Up); err != nil {
+		return err
+	}
+
+	mbs, err := r.RegionMigrationBoxes(ctx)
+	if err != nil {
 		return err
 	}
+	conns, err := r.RegionPopConnections(ctx)
+	if err != nil {
+		return err
+	}
+	for region, mb := range mbs {
+		if err := sql.WithMigrationLock(ctx, conns[region], mb.Up); err != nil {
+			return errors.WithMessagef(err, "could not migrate the database of region %q", region)
+		}
+	}
 	return r.Init(ctx)
 }
 
 func (r *RegistryDefault) MigrateDown(ctx context.Context) error {
+	if _, ok := r.storageDriver(ctx); ok {
+		if err := r.Init(ctx); err != nil {
+			return err
+		}
+		if m, ok := r.p.(ketodriver.Migrator); ok {
+			return m.MigrateDown(ctx)
+		}
+		return nil
+	}
+
 	mb, err := r.MigrationBox(ctx)
 	if err != nil {
 		return err
 	}
-	return mb.Down(ctx, -1)
+	c, err := r.PopConnection(ctx)
+	if err != nil {
+		return err
+	}
+	if err := sql.TryWithMigrationLock(ctx, c, func(ctx context.Context) error {
+		return mb.Down(ctx, -1)
+	}); err != nil {
+		return err
+	}
+
+	mbs, err := r.RegionMigrationBoxes(ctx)
+	if err != nil {
+		return err
+	}
+	conns, err := r.RegionPopConnections(ctx)
+	if err != nil {
+		return err
+	}
+	for region, mb := range mbs {
+		mb := mb
+		if err := sql.TryWithMigrationLock(ctx, conns[region], func(ctx context.Context) error {
+			return mb.Down(ctx, -1)
+		}); err != nil {
+			return errors.WithMessagef(err, "could not migrate the database of region %q", region)
+		}
+	}
+	return nil
 }
 
 func (r *RegistryDefault) DetermineNetwork(ctx context.Context) (*networkx.Network, error) {
@@ -242,12 +561,61 @@ func (r *RegistryDefault) InitWithoutNetworkID(ctx context.Context) error {
 			return err
 		}
 	}
+
+	mbs, err := r.RegionMigrationBoxes(ctx)
+	if err != nil {
+		return err
+	}
+	for region, dsn := range r.Config(ctx).RegionDSNs() {
+		if !dbal.IsMemorySQLite(dsn) {
+			continue
+		}
+		if err := mbs[region].Up(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createRegionNetworks creates the networks that are homed in a region, and
+// the one of this instance, in the database of the region, as the relation
+// tuples reference them.
+func (r *RegistryDefault) createRegionNetworks(ctx context.Context, nid uuid.UUID) error {
+	conns, err := r.RegionPopConnections(ctx)
+	if err != nil {
+		return err
+	}
+	for region, c := range conns {
+		c := c.WithContext(ctx)
+		for _, id := range append([]uuid.UUID{nid}, r.Config(ctx).RegionNetworks(region)...) {
+			exists, err := c.Where("id = ?", id).Exists(&networkx.Network{})
+			if err != nil {
+				return sqlcon.HandleError(err)
+			}
+			if exists {
+				continue
+			}
+			if err := c.Create(&networkx.Network{ID: id}); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+	}
 	return nil
 }
 
 func (r *RegistryDefault) Init(ctx context.Context) (err error) {
 	r.initialized.Do(func() {
 		err = func() error {
+			if drv, ok := r.storageDriver(ctx); ok {
+				p, err := drv.Open(ctx, r, r.Config(ctx).DSN())
+				if err != nil {
+					return err
+				}
+				r.p = p
+				r.c.UseNamespaceConfigStore(r.NamespaceConfigStore())
+				return nil
+			}
+
 			if err := r.InitWithoutNetworkID(ctx); err != nil {
 				return err
 			}
@@ -257,13 +625,41 @@ func (r *RegistryDefault) Init(ctx context.Context) (err error) {
 				return err
 			}
 
-			r.p, err = sql.NewPersister(ctx, r, network.ID)
+			if err := r.createRegionNetworks(ctx, network.ID); err != nil {
+				return err
+			}
+			conns, err := r.RegionPopConnections(ctx)
+			if err != nil {
+				return err
+			}
+
+			r.p, err = sql.NewPersister(ctx, r, network.ID, sql.WithRegionConnections(conns))
 			if err != nil {
 				return err
 			}
+			r.c.UseNamespaceConfigStore(r.NamespaceConfigStore())
 
 			return nil
 		}()
+		if err == nil {
+			r.initComponents()
+		}
 	})
 	return
 }
+
+// initComponents creates the components that the servers and the request
+// handlers share. They are created once before any server starts, as they are
+// used concurrently.
+func (r *RegistryDefault) initComponents() {
+	r.m = metering.NewMeter(r)
+	r.mi = mirror.NewMirror(r)
+	r.px = proxy.NewProxy(r)
+	// Writes and checks must use the same object filter, and the changefeed
+	// must update it.
+	r.of = objectfilter.NewFilter(r)
+	r.cf = changefeed.NewFeed(r)
+	r.cf.Subscribe(r.of.ApplyChange)
+	r.fi = faultinject.New(r)
+	r.rtm = r.of.Manager(r.mi.Manager(r.m.Manager(r.fi.Manager(r.p))))
+}
@@ -48,6 +48,7 @@ func NewDefaultRegistry(ctx context.Context, flags *pflag.FlagSet, withoutNetwor
 		defaultUnaryInterceptors:  options.GRPCUnaryInterceptors(),
 		defaultStreamInterceptors: options.GRPCStreamInterceptors(),
 		defaultHttpMiddlewares:    options.HTTPMiddlewares(),
+		extraMigrations:           options.Migrations(),
 	}
 
 	init := r.Init
@@ -61,23 +62,29 @@ func NewDefaultRegistry(ctx context.Context, flags *pflag.FlagSet, withoutNetwor
 	return r, nil
 }
 
-func NewSqliteTestRegistry(t *testing.T, debugOnDisk bool) *RegistryDefault {
+func NewSqliteTestRegistry(t *testing.T, debugOnDisk bool, values ...map[string]interface{}) *RegistryDefault {
 	mode := dbx.SQLiteMemory
 	if debugOnDisk {
 		mode = dbx.SQLiteDebug
 	}
-	return NewTestRegistry(t, dbx.GetSqlite(t, mode))
+	return NewTestRegistry(t, dbx.GetSqlite(t, mode), values...)
 }
 
-func NewTestRegistry(t *testing.T, dsn *dbx.DsnT) *RegistryDefault {
+// NewTestRegistry returns an initialized registry for the DSN. The values are
+// set in the config before the registry is initialized.
+func NewTestRegistry(t *testing.T, dsn *dbx.DsnT, values ...map[string]interface{}) *RegistryDefault {
 	l := logrusx.New("Ory Keto", "testing")
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
 
-	ctx = configx.ContextWithConfigOptions(ctx, configx.WithValues(map[string]interface{}{
+	opts := []configx.OptionModifier{configx.WithValues(map[string]interface{}{
 		config.KeyDSN: dsn.Conn,
 		"log.level":   "debug",
-	}))
+	})}
+	for _, v := range values {
+		opts = append(opts, configx.WithValues(v))
+	}
+	ctx = configx.ContextWithConfigOptions(ctx, opts...)
 	c, err := config.NewDefault(ctx, nil, l)
 	require.NoError(t, err)
 
@@ -116,3 +123,10 @@ func newLogger(ctx context.Context) *logrusx.Logger {
 
 	return logrusx.New("Ory Keto", config.Version, opts...)
 }
+
+// WithContextualizer replaces the contextualizer of the registry, e.g. to
+// serve several networks in tests.
+func (r *RegistryDefault) WithContextualizer(ctxer ketoctx.Contextualizer) *RegistryDefault {
+	r.ctxer = ctxer
+	return r
+}
@@ -1,9 +1,14 @@
 package e2e
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -16,7 +21,9 @@ import (
 
 	"github.com/ory/keto/cmd"
 	cliclient "github.com/ory/keto/cmd/client"
+	"github.com/ory/keto/internal/driver/config"
 	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/namespace"
 	"github.com/ory/keto/internal/relationtuple"
 	"github.com/ory/keto/internal/x"
 	"github.com/ory/keto/internal/x/dbx"
@@ -141,3 +148,55 @@ func TestServeConfig(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, "https://ory.sh", resp.Header.Get("Access-Control-Allow-Origin"), "%+v", resp.Header)
 }
+
+func TestServeUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	readSocket, writeSocket := filepath.Join(dir, "read.sock"), filepath.Join(dir, "write.sock")
+
+	ctx, reg, namespaceTestMgr := newInitializedReg(t, dbx.GetSqlite(t, dbx.SQLiteMemory), map[string]interface{}{
+		config.KeyReadAPIHost:  "unix:" + readSocket,
+		config.KeyWriteAPIHost: "unix:" + writeSocket,
+		"serve.read.socket":    map[string]interface{}{"mode": 0600},
+	})
+
+	closeServer := startServer(ctx, t, reg)
+	t.Cleanup(closeServer)
+
+	gc := &grpcClient{
+		readRemote:  reg.Config(ctx).ReadAPIListenOn(),
+		writeRemote: reg.Config(ctx).WriteAPIListenOn(),
+		ctx:         ctx,
+	}
+	gc.waitUntilLive(t)
+
+	for socket, mode := range map[string]os.FileMode{readSocket: 0600, writeSocket: 0660} {
+		fi, err := os.Stat(socket)
+		require.NoError(t, err)
+		assert.Equal(t, mode, fi.Mode().Perm(), socket)
+	}
+
+	n := &namespace.Namespace{Name: t.Name()}
+	namespaceTestMgr.add(t, n)
+	rt := &relationtuple.InternalRelationTuple{
+		Namespace: n.Name,
+		Object:    "o",
+		Relation:  "r",
+		Subject:   &relationtuple.SubjectID{ID: "s"},
+	}
+	gc.createTuple(t, rt)
+
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", readSocket)
+		},
+	}}
+	resp, err := hc.Get("http://keto" + relationtuple.ReadRouteBase + "?namespace=" + url.QueryEscape(n.Name))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "%s", body)
+	assert.Contains(t, string(body), `"subject_id":"s"`)
+}
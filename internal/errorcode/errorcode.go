@@ -0,0 +1,237 @@
+// Package errorcode defines the machine-readable codes of errors. REST error
+// bodies carry the code in the "id" field, gRPC errors in a
+// google.rpc.ErrorInfo detail with Domain as the domain. Clients should match
+// on the code instead of the message, the codes are stable across versions.
+//
+// gRPC errors also carry google.rpc.BadRequest and google.rpc.QuotaFailure
+// details for the DetailField and DetailLimit details of herodot errors.
+package errorcode
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+
+	"github.com/ory/keto/internal/requestid"
+)
+
+// Domain is the domain of the google.rpc.ErrorInfo details.
+const Domain = "keto.ory.sh"
+
+// The codes of specific errors. Errors are created with herodot, e.g.
+// herodot.ErrNotFound.WithID(NamespaceUnknown).
+const (
+	NamespaceUnknown        = "KETO_NAMESPACE_UNKNOWN"
+	NamespaceConfigInvalid  = "KETO_NAMESPACE_CONFIG_INVALID"
+	NamespaceConfigUnknown  = "KETO_NAMESPACE_CONFIG_UNKNOWN"
+	NamespaceConfigConflict = "KETO_NAMESPACE_CONFIG_CONFLICT"
+	PreconditionRequired    = "KETO_PRECONDITION_REQUIRED"
+	SubjectInvalid          = "KETO_SUBJECT_INVALID"
+	TupleMalformed          = "KETO_TUPLE_MALFORMED"
+	TupleFieldTooLong       = "KETO_TUPLE_FIELD_TOO_LONG"
+	LabelMalformed          = "KETO_LABEL_MALFORMED"
+	TemplateInvalid         = "KETO_TEMPLATE_INVALID"
+	MaxDepthInvalid         = "KETO_MAX_DEPTH_INVALID"
+	MaxDepthExceeded        = "KETO_MAX_DEPTH_EXCEEDED"
+	TenantBoundaryCrossed   = "KETO_TENANT_BOUNDARY_CROSSED"
+	PageSizeInvalid         = "KETO_PAGE_SIZE_INVALID"
+	OrderInvalid            = "KETO_ORDER_INVALID"
+	SnapshotTokenInvalid    = "KETO_SNAPSHOT_TOKEN_INVALID"
+	SnapshotTokenExpired    = "KETO_SNAPSHOT_TOKEN_EXPIRED"
+	ExpressionInvalid       = "KETO_EXPRESSION_INVALID"
+	ExpressionTooLarge      = "KETO_EXPRESSION_TOO_LARGE"
+	BatchTooLarge           = "KETO_BATCH_TOO_LARGE"
+	MemoryBudgetExceeded    = "KETO_MEMORY_BUDGET_EXCEEDED"
+	RegionMisdirected       = "KETO_REGION_MISDIRECTED"
+	ReadOnly                = "KETO_READ_ONLY"
+	FaultInjected           = "KETO_FAULT_INJECTED"
+	TokenInvalid            = "KETO_TOKEN_INVALID"
+	UnsupportedByStorage    = "KETO_UNSUPPORTED_BY_STORAGE"
+	DeadlineExceeded        = "KETO_DEADLINE_EXCEEDED"
+	FeatureFlagUnknown      = "KETO_FEATURE_FLAG_UNKNOWN"
+	RoleUnknown             = "KETO_ROLE_UNKNOWN"
+	BodyTooLarge            = "KETO_BODY_TOO_LARGE"
+	GroupExists             = "KETO_GROUP_EXISTS"
+	GroupCycle              = "KETO_GROUP_CYCLE"
+	SubjectPatchConflict    = "KETO_SUBJECT_PATCH_CONFLICT"
+	CSRFTokenInvalid        = "KETO_CSRF_TOKEN_INVALID"
+	RequestInvalid          = "KETO_REQUEST_INVALID"
+	Unauthenticated         = "KETO_UNAUTHENTICATED"
+	Forbidden               = "KETO_FORBIDDEN"
+	NotFound                = "KETO_NOT_FOUND"
+	Conflict                = "KETO_CONFLICT"
+	ResourceExhausted       = "KETO_RESOURCE_EXHAUSTED"
+	Unimplemented           = "KETO_UNIMPLEMENTED"
+	Unavailable             = "KETO_UNAVAILABLE"
+	Internal                = "KETO_INTERNAL"
+)
+
+// The herodot error details that are returned as typed gRPC details as well.
+const (
+	// DetailField is the request field an error refers to. It is returned as
+	// a google.rpc.BadRequest field violation.
+	DetailField = "field"
+	// DetailLimit is the configuration key of the limit an error exceeds. It
+	// is returned as a google.rpc.QuotaFailure violation.
+	DetailLimit = "limit"
+)
+
+// The generic codes of errors without a specific code, by status.
+var (
+	httpCodes = map[int]string{
+		http.StatusBadRequest:          RequestInvalid,
+		http.StatusUnauthorized:        Unauthenticated,
+		http.StatusForbidden:           Forbidden,
+		http.StatusNotFound:            NotFound,
+		http.StatusConflict:            Conflict,
+		http.StatusTooManyRequests:     ResourceExhausted,
+		http.StatusNotImplemented:      Unimplemented,
+		http.StatusServiceUnavailable:  Unavailable,
+		http.StatusGatewayTimeout:      DeadlineExceeded,
+		http.StatusInternalServerError: Internal,
+	}
+	grpcCodes = map[codes.Code]string{
+		codes.InvalidArgument:    RequestInvalid,
+		codes.FailedPrecondition: RequestInvalid,
+		codes.OutOfRange:         RequestInvalid,
+		codes.Unauthenticated:    Unauthenticated,
+		codes.PermissionDenied:   Forbidden,
+		codes.NotFound:           NotFound,
+		codes.AlreadyExists:      Conflict,
+		codes.Aborted:            Conflict,
+		codes.ResourceExhausted:  ResourceExhausted,
+		codes.Unimplemented:      Unimplemented,
+		codes.Unavailable:        Unavailable,
+		codes.DeadlineExceeded:   DeadlineExceeded,
+	}
+)
+
+type grpcStatusCarrier interface {
+	GRPCStatus() *status.Status
+}
+
+// fromErrorInfo returns the code of the ErrorInfo detail, if the status has
+// one.
+func fromErrorInfo(st *status.Status) string {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Domain == Domain {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// Of returns the code of the error. It works on errors returned by the
+// handlers as well as on errors received by gRPC clients. Errors without a
+// specific code get the generic code of their status.
+func Of(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var sc grpcStatusCarrier
+	isStatus := errors.As(err, &sc)
+	if isStatus {
+		if c := fromErrorInfo(sc.GRPCStatus()); c != "" {
+			return c
+		}
+	}
+	if c := herodot.IDCarrier(nil); errors.As(err, &c) && c.ID() != "" {
+		return c.ID()
+	}
+	if c := herodot.StatusCodeCarrier(nil); errors.As(err, &c) {
+		if code, ok := httpCodes[c.StatusCode()]; ok {
+			return code
+		}
+		return Internal
+	}
+	if isStatus {
+		if code, ok := grpcCodes[sc.GRPCStatus().Code()]; ok {
+			return code
+		}
+	}
+	return Internal
+}
+
+// Is returns whether the error has the code.
+func Is(err error, code string) bool {
+	return err != nil && Of(err) == code
+}
+
+// JSONErrorEnhancer is the herodot.JSONWriter error enhancer that sets the
+// "id" of error bodies to the code of the error.
+func JSONErrorEnhancer(r *http.Request, err error) interface{} {
+	de := herodot.ToDefaultError(err, r.Header.Get(requestid.Header))
+	if de.IDField == "" {
+		de.IDField = Of(err)
+	}
+	return &herodot.ErrorContainer{Error: de}
+}
+
+// typedDetails returns the typed gRPC details of the herodot error details.
+func typedDetails(err error) []protoiface.MessageV1 {
+	de := herodot.ToDefaultError(err, "")
+	description := de.ReasonField
+	if description == "" {
+		description = de.ErrorField
+	}
+
+	var details []protoiface.MessageV1
+	if field, ok := de.DetailsField[DetailField].(string); ok {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}},
+		})
+	}
+	if limit, ok := de.DetailsField[DetailLimit].(string); ok {
+		details = append(details, &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: limit, Description: description}},
+		})
+	}
+	return details
+}
+
+// withDetails adds the ErrorInfo detail with the code of the error, and the
+// typed details of its herodot details.
+func withDetails(err error) error {
+	if err == nil {
+		return nil
+	}
+	var st *status.Status
+	if sc := grpcStatusCarrier(nil); errors.As(err, &sc) {
+		st = sc.GRPCStatus()
+	} else {
+		st = status.New(codes.Unknown, err.Error())
+	}
+	if st.Code() == codes.OK || fromErrorInfo(st) != "" {
+		return err
+	}
+	details := append([]protoiface.MessageV1{&errdetails.ErrorInfo{Reason: Of(err), Domain: Domain}}, typedDetails(err)...)
+	withInfo, derr := st.WithDetails(details...)
+	if derr != nil {
+		return err
+	}
+	return withInfo.Err()
+}
+
+// UnaryInterceptor adds the ErrorInfo detail to errors. It has to come after
+// the herodot error unwrap interceptor in the chain, so that it still sees the
+// wrapped errors of the handlers.
+func UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, withDetails(err)
+	}
+	return resp, nil
+}
+
+// StreamInterceptor is the UnaryInterceptor for streaming RPCs.
+func StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return withDetails(handler(srv, ss))
+}
@@ -0,0 +1,131 @@
+package errorcode_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/errorcode"
+)
+
+func TestOf(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "specific code",
+			err:      errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithReason("unknown")),
+			expected: errorcode.NamespaceUnknown,
+		},
+		{
+			name:     "generic herodot error",
+			err:      errors.WithStack(herodot.ErrBadRequest.WithError("invalid")),
+			expected: errorcode.RequestInvalid,
+		},
+		{
+			name:     "gRPC status",
+			err:      status.Error(codes.Unavailable, "unavailable"),
+			expected: errorcode.Unavailable,
+		},
+		{
+			name:     "other error",
+			err:      errors.New("boom"),
+			expected: errorcode.Internal,
+		},
+	} {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, errorcode.Of(tc.err))
+			assert.True(t, errorcode.Is(tc.err, tc.expected))
+		})
+	}
+}
+
+func TestJSONErrorEnhancer(t *testing.T) {
+	w := herodot.NewJSONWriter(nil)
+	w.ErrorEnhancer = errorcode.JSONErrorEnhancer
+
+	for _, tc := range []struct {
+		err      error
+		expected string
+	}{
+		{err: herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid), expected: errorcode.SubjectInvalid},
+		{err: herodot.ErrNotFound, expected: errorcode.NotFound},
+	} {
+		rec := httptest.NewRecorder()
+		w.WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), tc.err)
+
+		var body herodot.ErrorContainer
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, tc.expected, body.Error.ID())
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	call := func(err error) error {
+		_, err = errorcode.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		})
+		return err
+	}
+
+	t.Run("case=adds the code to errors", func(t *testing.T) {
+		err := call(errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid)))
+		st := status.Convert(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+
+		var info *errdetails.ErrorInfo
+		for _, d := range st.Details() {
+			if i, ok := d.(*errdetails.ErrorInfo); ok {
+				info = i
+			}
+		}
+		require.NotNil(t, info, "%+v", st.Details())
+		assert.Equal(t, errorcode.SubjectInvalid, info.Reason)
+		assert.Equal(t, errorcode.Domain, info.Domain)
+		assert.Equal(t, errorcode.SubjectInvalid, errorcode.Of(err))
+	})
+
+	t.Run("case=adds typed details", func(t *testing.T) {
+		st := status.Convert(call(errors.WithStack(herodot.ErrBadRequest.
+			WithReason("too many").
+			WithDetail(errorcode.DetailField, "page_size").
+			WithDetail(errorcode.DetailLimit, "limit.max_page_size"))))
+
+		var (
+			badRequest *errdetails.BadRequest
+			quota      *errdetails.QuotaFailure
+		)
+		for _, d := range st.Details() {
+			switch d := d.(type) {
+			case *errdetails.BadRequest:
+				badRequest = d
+			case *errdetails.QuotaFailure:
+				quota = d
+			}
+		}
+		require.NotNil(t, badRequest, "%+v", st.Details())
+		require.Len(t, badRequest.FieldViolations, 1)
+		assert.Equal(t, "page_size", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "too many", badRequest.FieldViolations[0].Description)
+		require.NotNil(t, quota, "%+v", st.Details())
+		require.Len(t, quota.Violations, 1)
+		assert.Equal(t, "limit.max_page_size", quota.Violations[0].Subject)
+	})
+
+	t.Run("case=passes successful calls", func(t *testing.T) {
+		assert.NoError(t, call(nil))
+	})
+}
@@ -0,0 +1,58 @@
+package expand
+
+import (
+	"net/http"
+	"unsafe"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// memoryBudget approximates the memory held by an expand tree while it is
+// built. Only the tree nodes are accounted for, the pages of relation tuples are
+// short-lived.
+type memoryBudget struct {
+	limit, used int
+}
+
+var ErrMemoryBudgetExceeded = &herodot.DefaultError{
+	IDField:       errorcode.MemoryBudgetExceeded,
+	CodeField:     http.StatusUnprocessableEntity,
+	StatusField:   http.StatusText(http.StatusUnprocessableEntity),
+	GRPCCodeField: codes.ResourceExhausted,
+	ErrorField:    "the expand tree exceeds the memory budget",
+	DetailsField:  map[string]interface{}{errorcode.DetailLimit: config.KeyLimitMaxExpandMemory},
+}
+
+const (
+	// treeNodeSize includes the pointer in the children of the parent.
+	treeNodeSize   = int(unsafe.Sizeof(Tree{}) + unsafe.Sizeof(&Tree{}))
+	subjectIDSize  = int(unsafe.Sizeof(relationtuple.SubjectID{}))
+	subjectSetSize = int(unsafe.Sizeof(relationtuple.SubjectSet{}))
+)
+
+func (b *memoryBudget) add(t *Tree) error {
+	if b.limit <= 0 {
+		return nil
+	}
+
+	b.used += treeNodeSize
+	switch s := t.Subject.(type) {
+	case *relationtuple.SubjectID:
+		b.used += subjectIDSize + len(s.ID)
+	case *relationtuple.SubjectSet:
+		// Expanded subject sets are also kept in the set of visited nodes.
+		b.used += subjectSetSize + 2*(len(s.Namespace)+len(s.Object)+len(s.Relation))
+	}
+
+	if b.used > b.limit {
+		return errors.WithStack(ErrMemoryBudgetExceeded.WithReasonf(
+			"Expanding the subject set would use more than the configured maximum of %d bytes. Lower the max depth or expand a more specific subject set.", b.limit))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package expand
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+)
+
+var cacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "query_cache_requests_total",
+	Help:      "The number of expand requests looked up in the query cache, by whether the tree was cached for the current snapshot.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(cacheRequestsTotal)
+}
+
+// cachedTree returns the tree cached for the current snapshot, or builds and
+// caches it. Cache errors are logged, the request is served regardless.
+func (e *Engine) cachedTree(ctx context.Context, cache persistence.SnapshotCache, subject relationtuple.Subject, restDepth, maxMemory, maxChildren int, build func() (*Tree, error)) (*Tree, error) {
+	l := requestid.Logger(ctx, e.d.Logger()).WithField("subject", subject.String())
+
+	snapshot, err := cache.SnapshotToken(ctx)
+	if err != nil {
+		l.WithError(err).Warn("Could not get the snapshot, the expand tree is not cached.")
+		return build()
+	}
+
+	// All parameters that change the tree are part of the key.
+	key := fmt.Sprintf("expand\x00%s\x00%d\x00%d\x00%d", subject, restDepth, maxMemory, maxChildren)
+	if v, ok, err := cache.GetCachedValue(ctx, key, snapshot); err != nil {
+		l.WithError(err).Warn("Could not read the cached expand tree.")
+	} else if ok {
+		var t *Tree
+		if err := json.Unmarshal(v, &t); err == nil {
+			cacheRequestsTotal.WithLabelValues("hit").Inc()
+			return t, nil
+		}
+		l.WithError(err).Warn("Could not decode the cached expand tree.")
+	}
+	cacheRequestsTotal.WithLabelValues("miss").Inc()
+
+	t, err := build()
+	if err != nil {
+		return nil, err
+	}
+	// The tree is built from the latest tuples, so it is only cached if no
+	// change was committed since the snapshot was taken.
+	if after, err := cache.SnapshotToken(ctx); err != nil {
+		l.WithError(err).Warn("Could not get the snapshot, the expand tree is not cached.")
+		return t, nil
+	} else if after != snapshot {
+		return t, nil
+	}
+	v, err := json.Marshal(t)
+	if err == nil {
+		err = cache.SetCachedValue(ctx, key, snapshot, v)
+	}
+	if err != nil {
+		l.WithError(err).Warn("Could not cache the expand tree.")
+	}
+	return t, nil
+}
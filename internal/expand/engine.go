@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/proxy"
 	"github.com/ory/keto/internal/x"
 	"github.com/ory/keto/internal/x/graph"
 
@@ -15,6 +17,8 @@ type (
 		relationtuple.ManagerProvider
 		config.Provider
 		x.LoggerProvider
+		persistence.Provider
+		proxy.Provider
 	}
 	Engine struct {
 		d EngineDependencies
@@ -36,9 +40,33 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 		restDepth = globalMaxDepth
 	}
 
+	if p := e.d.Proxy(); p.Enabled(ctx) {
+		t, err := p.Expand(ctx, subject, restDepth)
+		if err != nil || t == nil {
+			return nil, err
+		}
+		return TreeFromProto(t)
+	}
+
+	c := e.d.Config(ctx)
+	maxMemory, maxChildren := c.MaxExpandMemory(), c.MaxExpandChildren()
+	build := func() (*Tree, error) {
+		return e.buildTree(ctx, subject, restDepth, &memoryBudget{limit: maxMemory}, maxChildren)
+	}
+
+	if cache, ok := e.d.Persister().(persistence.SnapshotCache); ok && c.QueryCacheEnabled() && !persistence.CacheBypassed(ctx) {
+		return e.cachedTree(ctx, cache, subject, restDepth, maxMemory, maxChildren, build)
+	}
+	return build()
+}
+
+func (e *Engine) buildTree(ctx context.Context, subject relationtuple.Subject, restDepth int, budget *memoryBudget, maxChildren int) (*Tree, error) {
 	if us, isUserSet := subject.(*relationtuple.SubjectSet); isUserSet {
 		ctx, wasAlreadyVisited := graph.CheckAndAddVisited(ctx, subject)
 		if wasAlreadyVisited {
+			if cycle, ok := graph.FindCycle(ctx, subject); ok {
+				cycle.Report(e.d.Logger(), "expand")
+			}
 			return nil, nil
 		}
 
@@ -46,6 +74,9 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 			Type:    Union,
 			Subject: subject,
 		}
+		if err := budget.add(subTree); err != nil {
+			return nil, err
+		}
 
 		var (
 			rels     []*relationtuple.InternalRelationTuple
@@ -53,6 +84,12 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 		)
 		// do ... while nextPage != ""
 		for ok := true; ok; ok = nextPage != "" {
+			pagination := []x.PaginationOptionSetter{x.WithToken(nextPage)}
+			if maxChildren > 0 {
+				// never fetch more children than will fit into the node
+				pagination = append(pagination, x.WithSize(maxChildren-len(subTree.Children)))
+			}
+
 			var err error
 			rels, nextPage, err = e.d.RelationTupleManager().GetRelationTuples(
 				ctx,
@@ -61,7 +98,7 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 					Object:    us.Object,
 					Namespace: us.Namespace,
 				},
-				x.WithToken(nextPage),
+				pagination...,
 			)
 			if err != nil {
 				return nil, err
@@ -76,7 +113,7 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 
 			children := make([]*Tree, len(rels))
 			for ri, r := range rels {
-				child, err := e.BuildTree(ctx, r.Subject, restDepth-1)
+				child, err := e.buildTree(ctx, r.Subject, restDepth-1, budget, maxChildren)
 				if err != nil {
 					return nil, err
 				}
@@ -85,18 +122,32 @@ func (e *Engine) BuildTree(ctx context.Context, subject relationtuple.Subject, r
 						Type:    Leaf,
 						Subject: r.Subject,
 					}
+					if err := budget.add(child); err != nil {
+						return nil, err
+					}
 				}
 				children[ri] = child
 			}
 			subTree.Children = append(subTree.Children, children...)
+
+			if maxChildren > 0 && len(subTree.Children) >= maxChildren && nextPage != "" {
+				// the client can continue listing the children with the token
+				subTree.Truncated = true
+				subTree.NextPageToken = nextPage
+				break
+			}
 		}
 
 		return subTree, nil
 	}
 
 	// is SubjectID
-	return &Tree{
+	leaf := &Tree{
 		Type:    Leaf,
 		Subject: subject,
-	}, nil
+	}
+	if err := budget.add(leaf); err != nil {
+		return nil, err
+	}
+	return leaf, nil
 }
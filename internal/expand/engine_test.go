@@ -2,6 +2,8 @@ package expand_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/ory/keto/internal/driver/config"
@@ -9,11 +11,14 @@ import (
 	"github.com/ory/keto/internal/x"
 
 	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/proxy"
 
 	"github.com/ory/keto/internal/relationtuple"
 
 	"github.com/ory/keto/internal/expand"
 
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -22,12 +27,16 @@ import (
 
 type configProvider = config.Provider
 type loggerProvider = x.LoggerProvider
+type persisterProvider = persistence.Provider
+type proxyProvider = proxy.Provider
 
 // deps is defined to capture engine dependencies in a single struct
 type deps struct {
 	*relationtuple.ManagerWrapper // managerProvider
 	configProvider
 	loggerProvider
+	persisterProvider
+	proxyProvider
 }
 
 func newTestEngine(t *testing.T, namespaces []*namespace.Namespace, paginationOpts ...x.PaginationOptionSetter) (*relationtuple.ManagerWrapper, *expand.Engine) {
@@ -35,9 +44,11 @@ func newTestEngine(t *testing.T, namespaces []*namespace.Namespace, paginationOp
 	require.NoError(t, innerReg.Config(context.Background()).Set(config.KeyNamespaces, namespaces))
 	reg := relationtuple.NewManagerWrapper(t, innerReg, paginationOpts...)
 	e := expand.NewEngine(&deps{
-		ManagerWrapper: reg,
-		configProvider: innerReg,
-		loggerProvider: innerReg,
+		ManagerWrapper:    reg,
+		configProvider:    innerReg,
+		loggerProvider:    innerReg,
+		persisterProvider: innerReg,
+		proxyProvider:     innerReg,
 	})
 	return reg, e
 }
@@ -87,11 +98,11 @@ func TestEngine(t *testing.T) {
 			Children: []*expand.Tree{
 				{
 					Type:    expand.Leaf,
-					Subject: paul,
+					Subject: tommy,
 				},
 				{
 					Type:    expand.Leaf,
-					Subject: tommy,
+					Subject: paul,
 				},
 			},
 		}, tree)
@@ -360,6 +371,7 @@ func TestEngine(t *testing.T) {
 			},
 		}...))
 
+		hook := test.NewLocal(reg.Reg.(x.LoggerProvider).Logger().Logger)
 		tree, err := e.BuildTree(context.Background(), &relationtuple.SubjectSet{
 			Namespace: namesp,
 			Object:    sendlingerTor,
@@ -367,5 +379,139 @@ func TestEngine(t *testing.T) {
 		}, 100)
 		require.NoError(t, err)
 		assert.Equal(t, expectedTree, tree)
+
+		require.NotNil(t, hook.LastEntry())
+		assert.Equal(t, []string{
+			sendlingerTorSS.String(), odeonsplatzSS.String(), centralStationSS.String(), sendlingerTorSS.String(),
+		}, hook.LastEntry().Data["cycle"])
+	})
+
+	t.Run("case=enforces the memory budget", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyNamespaces, []*namespace.Namespace{{}}))
+		e := reg.ExpandEngine()
+		root := &relationtuple.SubjectSet{Object: "org", Relation: "member"}
+		for i := 0; i < 100; i++ {
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), &relationtuple.InternalRelationTuple{
+				Object:   root.Object,
+				Relation: root.Relation,
+				Subject:  &relationtuple.SubjectID{ID: fmt.Sprintf("user-%d", i)},
+			}))
+		}
+
+		// unlimited by default
+		tree, err := e.BuildTree(context.Background(), root, 10)
+		require.NoError(t, err)
+		assert.Len(t, tree.Children, 100)
+
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyLimitMaxExpandMemory, "1KB"))
+		_, err = e.BuildTree(context.Background(), root, 10)
+		assert.ErrorIs(t, err, expand.ErrMemoryBudgetExceeded)
+
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyLimitMaxExpandMemory, "1MB"))
+		tree, err = e.BuildTree(context.Background(), root, 10)
+		require.NoError(t, err)
+		assert.Len(t, tree.Children, 100)
+	})
+
+	t.Run("case=truncates nodes with too many children", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyNamespaces, []*namespace.Namespace{{}}))
+		e := reg.ExpandEngine()
+		root := &relationtuple.SubjectSet{Object: "org", Relation: "member"}
+		for i := 0; i < 5; i++ {
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), &relationtuple.InternalRelationTuple{
+				Object:   root.Object,
+				Relation: root.Relation,
+				Subject:  &relationtuple.SubjectID{ID: fmt.Sprintf("user-%d", i)},
+			}))
+		}
+
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyLimitMaxExpandChildren, 5))
+		tree, err := e.BuildTree(context.Background(), root, 10)
+		require.NoError(t, err)
+		assert.Len(t, tree.Children, 5)
+		assert.False(t, tree.Truncated)
+		assert.Empty(t, tree.NextPageToken)
+
+		require.NoError(t, reg.Config(context.Background()).Set(config.KeyLimitMaxExpandChildren, 3))
+		tree, err = e.BuildTree(context.Background(), root, 10)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 3)
+		assert.True(t, tree.Truncated)
+		require.NotEmpty(t, tree.NextPageToken)
+		assert.Contains(t, tree.String(), "…")
+
+		rest, next, err := reg.RelationTupleManager().GetRelationTuples(context.Background(), &relationtuple.RelationQuery{
+			Namespace: root.Namespace,
+			Object:    root.Object,
+			Relation:  root.Relation,
+		}, x.WithToken(tree.NextPageToken))
+		require.NoError(t, err)
+		assert.Empty(t, next)
+		require.Len(t, rest, 2)
+		assert.Equal(t, "user-3", rest[0].Subject.String())
+		assert.Equal(t, "user-4", rest[1].Subject.String())
+
+		for _, c := range []func(*expand.Tree) (*expand.Tree, error){
+			func(tree *expand.Tree) (*expand.Tree, error) {
+				return expand.TreeFromProto(tree.ToProto())
+			},
+			func(tree *expand.Tree) (*expand.Tree, error) {
+				enc, err := json.Marshal(tree)
+				if err != nil {
+					return nil, err
+				}
+				actual := &expand.Tree{}
+				return actual, json.Unmarshal(enc, actual)
+			},
+		} {
+			actual, err := c(tree)
+			require.NoError(t, err)
+			assert.True(t, actual.Truncated)
+			assert.Equal(t, tree.NextPageToken, actual.NextPageToken)
+		}
+	})
+
+	t.Run("case=caches trees until the snapshot advances", func(t *testing.T) {
+		ctx := context.Background()
+		innerReg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, innerReg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "groups"}}))
+		require.NoError(t, innerReg.Config(ctx).Set(config.KeyQueryCacheEnabled, true))
+		reg := relationtuple.NewManagerWrapper(t, innerReg)
+		e := expand.NewEngine(&deps{
+			ManagerWrapper:    reg,
+			configProvider:    innerReg,
+			loggerProvider:    innerReg,
+			persisterProvider: innerReg,
+			proxyProvider:     innerReg,
+		})
+
+		root := &relationtuple.SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}
+		member := func(id string) *relationtuple.InternalRelationTuple {
+			return &relationtuple.InternalRelationTuple{Namespace: root.Namespace, Object: root.Object, Relation: root.Relation, Subject: &relationtuple.SubjectID{ID: id}}
+		}
+		require.NoError(t, reg.WriteRelationTuples(ctx, member("alice")))
+
+		first, err := e.BuildTree(ctx, root, 10)
+		require.NoError(t, err)
+		requested := len(reg.RequestedPages)
+
+		cached, err := e.BuildTree(ctx, root, 10)
+		require.NoError(t, err)
+		assert.Len(t, reg.RequestedPages, requested, "the cached tree must not query the relation tuples")
+		assert.Equal(t, first.String(), cached.String())
+
+		require.NoError(t, reg.WriteRelationTuples(ctx, member("bob")))
+		updated, err := e.BuildTree(ctx, root, 10)
+		require.NoError(t, err)
+		assert.Greater(t, len(reg.RequestedPages), requested)
+		assert.Len(t, updated.Children, 2)
+
+		requested = len(reg.RequestedPages)
+		bypassed, err := e.BuildTree(persistence.WithoutCache(ctx), root, 10)
+		require.NoError(t, err)
+		assert.Greater(t, len(reg.RequestedPages), requested, "the cache must be bypassed")
+		assert.Equal(t, updated.String(), bypassed.String())
 	})
 }
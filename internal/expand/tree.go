@@ -27,6 +27,10 @@ type Tree struct {
 	Type     NodeType              `json:"type"`
 	Subject  relationtuple.Subject `json:"subject"`
 	Children []*Tree               `json:"children,omitempty"`
+	// Truncated is set if the node has more children than the configured
+	// maximum. NextPageToken lists the remaining ones.
+	Truncated     bool   `json:"truncated,omitempty"`
+	NextPageToken string `json:"next_page_token,omitempty"`
 }
 
 var (
@@ -88,6 +92,11 @@ type node struct {
 	Children   []*node                   `json:"children,omitempty"`
 	SubjectID  *string                   `json:"subject_id,omitempty"`
 	SubjectSet *relationtuple.SubjectSet `json:"subject_set,omitempty"`
+	// Whether the node has more children than `limit.max_expand_children`.
+	Truncated bool `json:"truncated,omitempty"`
+	// The token to list the remaining children of a truncated node, using the
+	// node's subject set as query.
+	NextPageToken string `json:"next_page_token,omitempty"`
 }
 
 func (n *node) toTree() (*Tree, error) {
@@ -105,6 +114,8 @@ func (n *node) toTree() (*Tree, error) {
 	}
 
 	t.Type = n.Type
+	t.Truncated = n.Truncated
+	t.NextPageToken = n.NextPageToken
 
 	if n.Children != nil {
 		t.Children = make([]*Tree, len(n.Children))
@@ -124,6 +135,8 @@ func (n *node) fromTree(t *Tree) error {
 	n.Type = t.Type
 	n.SubjectID = t.Subject.SubjectID()
 	n.SubjectSet = t.Subject.SubjectSet()
+	n.Truncated = t.Truncated
+	n.NextPageToken = t.NextPageToken
 
 	if t.Children != nil {
 		n.Children = make([]*node, len(t.Children))
@@ -180,9 +193,11 @@ func (t *Tree) ToProto() *rts.SubjectTree {
 	}
 
 	return &rts.SubjectTree{
-		NodeType: t.Type.ToProto(),
-		Subject:  t.Subject.ToProto(),
-		Children: children,
+		NodeType:      t.Type.ToProto(),
+		Subject:       t.Subject.ToProto(),
+		Children:      children,
+		Truncated:     t.Truncated,
+		NextPageToken: t.NextPageToken,
 	}
 }
 
@@ -197,8 +212,10 @@ func TreeFromProto(t *rts.SubjectTree) (*Tree, error) {
 		return nil, err
 	}
 	self := &Tree{
-		Type:    NodeTypeFromProto(t.NodeType),
-		Subject: sub,
+		Type:          NodeTypeFromProto(t.NodeType),
+		Subject:       sub,
+		Truncated:     t.Truncated,
+		NextPageToken: t.NextPageToken,
 	}
 
 	if t.NodeType != rts.NodeType_NODE_TYPE_LEAF {
@@ -231,5 +248,9 @@ func (t *Tree) String() string {
 		children[i] = strings.Join(strings.Split(c.String(), "\n"), "\n│  ")
 	}
 
+	if t.Truncated {
+		children = append(children, "…")
+	}
+
 	return fmt.Sprintf("∪ %s\n├─ %s", sub, strings.Join(children, "\n├─ "))
 }
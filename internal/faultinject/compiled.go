@@ -0,0 +1,6 @@
+//go:build faultinjection
+
+package faultinject
+
+// compiled is true in binaries built with the faultinjection tag.
+const compiled = true
@@ -0,0 +1,5 @@
+//go:build !faultinjection
+
+package faultinject
+
+const compiled = false
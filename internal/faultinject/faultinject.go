@@ -0,0 +1,193 @@
+// Package faultinject injects latency, errors, and partial failures into the
+// calls of the relation tuple manager, so that the resilience of clients can be
+// tested in integration environments. Faults are only injected by binaries
+// built with the faultinjection tag, and only if enabled in the config.
+package faultinject
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/stringslice"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	Provider interface {
+		FaultInjector() *Injector
+	}
+	dependencies interface {
+		config.Provider
+		x.LoggerProvider
+	}
+	Injector struct {
+		d dependencies
+
+		warnOnce sync.Once
+	}
+	manager struct {
+		relationtuple.Manager
+		i *Injector
+	}
+	// objectsManager also injects the faults into the queries of several
+	// objects, if the wrapped manager supports them.
+	objectsManager struct {
+		*manager
+		getter relationtuple.ObjectsGetter
+	}
+)
+
+const (
+	OperationGet       = "get"
+	OperationWrite     = "write"
+	OperationDelete    = "delete"
+	OperationDeleteAll = "delete_all"
+	OperationTransact  = "transact"
+
+	faultLatency        = "latency"
+	faultError          = "error"
+	faultPartialFailure = "partial_failure"
+)
+
+var ErrInjected = herodot.DefaultError{
+	IDField:       errorcode.FaultInjected,
+	StatusField:   http.StatusText(http.StatusServiceUnavailable),
+	ErrorField:    "The call to the database failed because of an injected fault.",
+	CodeField:     http.StatusServiceUnavailable,
+	GRPCCodeField: codes.Unavailable,
+}
+
+var injectedFaultsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "injected_faults_total",
+	Help:      "The number of faults injected into the calls of the relation tuple manager, by operation and fault.",
+}, []string{"operation", "fault"})
+
+func init() {
+	prometheus.MustRegister(injectedFaultsTotal)
+}
+
+func New(d dependencies) *Injector {
+	return &Injector{d: d}
+}
+
+// Manager wraps the relation tuple manager to inject the configured faults
+// into its calls. Without the faultinjection build tag, the manager is
+// returned as is.
+func (i *Injector) Manager(inner relationtuple.Manager) relationtuple.Manager {
+	if !compiled {
+		if _, enabled := i.d.Config(context.Background()).FaultInjection(); enabled {
+			i.warnOnce.Do(func() {
+				i.d.Logger().Warn("Fault injection is enabled in the config, but this binary was built without the faultinjection tag. No faults are injected.")
+			})
+		}
+		return inner
+	}
+	m := &manager{Manager: inner, i: i}
+	if getter, ok := inner.(relationtuple.ObjectsGetter); ok {
+		return &objectsManager{manager: m, getter: getter}
+	}
+	return m
+}
+
+func sampled(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate // #nosec G404 -- fault injection does not need a secure random source
+}
+
+func (i *Injector) faultError(ctx context.Context, operation, fault string) error {
+	injectedFaultsTotal.WithLabelValues(operation, fault).Inc()
+	requestid.Logger(ctx, i.d.Logger()).
+		WithField("operation", operation).
+		WithField("fault", fault).
+		Debug("Injected a fault into the relation tuple manager.")
+	return errors.WithStack(ErrInjected.WithReasonf("Injected a %s into the %s operation.", fault, operation))
+}
+
+// inject calls f unless an error is injected before, and may add latency or
+// replace the result of f by an error afterwards.
+func (i *Injector) inject(ctx context.Context, operation string, f func() error) error {
+	c, enabled := i.d.Config(ctx).FaultInjection()
+	if !enabled {
+		return f()
+	}
+	if len(c.Operations) > 0 && !stringslice.Has(c.Operations, operation) {
+		return f()
+	}
+
+	if c.Latency > 0 && sampled(c.LatencyRate) {
+		injectedFaultsTotal.WithLabelValues(operation, faultLatency).Inc()
+		select {
+		case <-time.After(c.Latency):
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+	}
+	if sampled(c.ErrorRate) {
+		return i.faultError(ctx, operation, faultError)
+	}
+	if err := f(); err != nil {
+		return err
+	}
+	if sampled(c.PartialFailureRate) {
+		return i.faultError(ctx, operation, faultPartialFailure)
+	}
+	return nil
+}
+
+func (m *manager) GetRelationTuples(ctx context.Context, query *relationtuple.RelationQuery, options ...x.PaginationOptionSetter) (res []*relationtuple.InternalRelationTuple, nextPage string, err error) {
+	err = m.i.inject(ctx, OperationGet, func() (err error) {
+		res, nextPage, err = m.Manager.GetRelationTuples(ctx, query, options...)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return res, nextPage, nil
+}
+
+func (m *objectsManager) GetRelationTuplesOfObjects(ctx context.Context, query *relationtuple.RelationQuery, objects []string, options ...x.PaginationOptionSetter) (res []*relationtuple.InternalRelationTuple, nextPage string, err error) {
+	err = m.i.inject(ctx, OperationGet, func() (err error) {
+		res, nextPage, err = m.getter.GetRelationTuplesOfObjects(ctx, query, objects, options...)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return res, nextPage, nil
+}
+
+func (m *manager) WriteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return m.i.inject(ctx, OperationWrite, func() error {
+		return m.Manager.WriteRelationTuples(ctx, rs...)
+	})
+}
+
+func (m *manager) DeleteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return m.i.inject(ctx, OperationDelete, func() error {
+		return m.Manager.DeleteRelationTuples(ctx, rs...)
+	})
+}
+
+func (m *manager) DeleteAllRelationTuples(ctx context.Context, query *relationtuple.RelationQuery) error {
+	return m.i.inject(ctx, OperationDeleteAll, func() error {
+		return m.Manager.DeleteAllRelationTuples(ctx, query)
+	})
+}
+
+func (m *manager) TransactRelationTuples(ctx context.Context, insert []*relationtuple.InternalRelationTuple, delete []*relationtuple.InternalRelationTuple) error {
+	return m.i.inject(ctx, OperationTransact, func() error {
+		return m.Manager.TransactRelationTuples(ctx, insert, delete)
+	})
+}
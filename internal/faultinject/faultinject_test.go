@@ -0,0 +1,127 @@
+package faultinject
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	deps struct {
+		c *config.Config
+		l *logrusx.Logger
+	}
+	countingManager struct {
+		relationtuple.Manager
+		calls int
+	}
+)
+
+func (d *deps) Config(context.Context) *config.Config { return d.c }
+func (d *deps) Logger() *logrusx.Logger               { return d.l }
+
+func (m *countingManager) WriteRelationTuples(context.Context, ...*relationtuple.InternalRelationTuple) error {
+	m.calls++
+	return nil
+}
+
+func (m *countingManager) GetRelationTuples(context.Context, *relationtuple.RelationQuery, ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	m.calls++
+	return []*relationtuple.InternalRelationTuple{{Namespace: "n"}}, "next", nil
+}
+
+func (m *countingManager) GetRelationTuplesOfObjects(context.Context, *relationtuple.RelationQuery, []string, ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	m.calls++
+	return []*relationtuple.InternalRelationTuple{{Namespace: "n"}}, "", nil
+}
+
+func TestInjector(t *testing.T) {
+	ctx := context.Background()
+	newInjector := func(t *testing.T, c map[string]interface{}) *Injector {
+		cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+		require.NoError(t, err)
+		d := &deps{c: config.New(ctx, logrusx.New("test", "today"), cp), l: logrusx.New("test", "today")}
+		if c != nil {
+			c["enabled"] = true
+			require.NoError(t, d.c.Set("fault_injection", c))
+		}
+		return New(d)
+	}
+	setup := func(t *testing.T, c map[string]interface{}) (*countingManager, relationtuple.Manager) {
+		inner := &countingManager{}
+		return inner, &manager{Manager: inner, i: newInjector(t, c)}
+	}
+	assertInjected := func(t *testing.T, err error) {
+		var herr *herodot.DefaultError
+		require.True(t, errors.As(err, &herr), "%+v", err)
+		assert.Equal(t, errorcode.FaultInjected, herr.ID())
+	}
+
+	t.Run("case=passes calls through if disabled", func(t *testing.T) {
+		inner, m := setup(t, nil)
+		res, next, err := m.GetRelationTuples(ctx, &relationtuple.RelationQuery{})
+		require.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "next", next)
+		assert.Equal(t, 1, inner.calls)
+
+		if !compiled {
+			assert.Same(t, inner, newInjector(t, map[string]interface{}{}).Manager(inner))
+		}
+	})
+
+	t.Run("case=fails before the call", func(t *testing.T) {
+		inner, m := setup(t, map[string]interface{}{"error_rate": 1})
+		res, next, err := m.GetRelationTuples(ctx, &relationtuple.RelationQuery{})
+		assertInjected(t, err)
+		assert.Empty(t, res)
+		assert.Empty(t, next)
+		assert.Zero(t, inner.calls)
+	})
+
+	t.Run("case=fails after the call", func(t *testing.T) {
+		inner, m := setup(t, map[string]interface{}{"partial_failure_rate": 1})
+		assertInjected(t, m.WriteRelationTuples(ctx))
+		assert.Equal(t, 1, inner.calls, "the write went through")
+	})
+
+	t.Run("case=only affects the configured operations", func(t *testing.T) {
+		inner, m := setup(t, map[string]interface{}{"error_rate": 1, "operations": []string{OperationGet}})
+		assert.NoError(t, m.WriteRelationTuples(ctx))
+		assert.Equal(t, 1, inner.calls)
+	})
+
+	t.Run("case=fails the queries of several objects", func(t *testing.T) {
+		inner := &countingManager{}
+		m := &objectsManager{manager: &manager{Manager: inner, i: newInjector(t, map[string]interface{}{"error_rate": 1})}, getter: inner}
+		res, _, err := m.GetRelationTuplesOfObjects(ctx, &relationtuple.RelationQuery{Namespace: "n"}, []string{"a", "b"})
+		assertInjected(t, err)
+		assert.Empty(t, res)
+		assert.Zero(t, inner.calls)
+	})
+
+	t.Run("case=adds latency", func(t *testing.T) {
+		inner, m := setup(t, map[string]interface{}{"latency": "50ms", "latency_rate": 1})
+		start := time.Now()
+		require.NoError(t, m.WriteRelationTuples(ctx))
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, m.WriteRelationTuples(canceled), context.Canceled)
+		assert.Equal(t, 1, inner.calls)
+	})
+}
@@ -0,0 +1,259 @@
+// Package featureflag gates experimental engine behavior. Flags are enabled
+// globally or per namespace in the config, can be overridden per namespace at
+// runtime, and in development mode single requests can override them, so that
+// a behavior can be rolled out incrementally.
+package featureflag
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	Flag string
+
+	Provider interface {
+		FeatureFlagOverrides() *Overrides
+	}
+	dependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	// Overrides holds the runtime overrides of the feature flags per
+	// namespace, and reads the per-request overrides from the
+	// X-Keto-Feature-Flags header or gRPC metadata. Per-request overrides are
+	// ignored unless the development mode is enabled.
+	Overrides struct {
+		d dependencies
+
+		mu sync.RWMutex
+		// namespaces is replaced instead of modified, so that requests can
+		// keep using the one they started with.
+		namespaces map[string]map[Flag]bool
+	}
+	stream struct {
+		grpc.ServerStream
+		ctx context.Context
+	}
+	overridesContextKey          struct{}
+	namespaceOverridesContextKey struct{}
+)
+
+const (
+	// DirectMatchFirst makes checks look for the requested subject among
+	// all tuples of a page before expanding the subject sets of the page.
+	DirectMatchFirst Flag = "direct_match_first"
+
+	// MetadataKey is the gRPC metadata key and HTTP header of the overrides,
+	// a comma separated list of flag=on or flag=off.
+	MetadataKey = "x-keto-feature-flags"
+)
+
+// All are the known flags.
+var All = []Flag{DirectMatchFirst}
+
+var overridesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "feature_flag_overrides_total",
+	Help:      "The number of requests that overrode a feature flag, by flag and value.",
+}, []string{"flag", "value"})
+
+func init() {
+	prometheus.MustRegister(overridesTotal)
+}
+
+func New(d dependencies) *Overrides {
+	return &Overrides{d: d}
+}
+
+// Enabled returns whether the flag is enabled for the request, either by an
+// override of the request or globally.
+func Enabled(ctx context.Context, c *config.Config, f Flag) bool {
+	if enabled, ok := overrides(ctx)[f]; ok {
+		return enabled
+	}
+	return c.FeatureFlag(string(f))
+}
+
+// EnabledIn returns whether the flag is enabled for the namespace. An override
+// of the request takes precedence over a runtime override of the namespace,
+// then the config of the namespace, and then the global config.
+func EnabledIn(ctx context.Context, c *config.Config, f Flag, namespace string) bool {
+	if enabled, ok := overrides(ctx)[f]; ok {
+		return enabled
+	}
+	if enabled, ok := namespaceOverrides(ctx)[namespace][f]; ok {
+		return enabled
+	}
+	return c.NamespaceFeatureFlag(string(f), namespace)
+}
+
+// WithOverrides returns a context in which the flags are enabled or disabled
+// regardless of the config.
+func WithOverrides(ctx context.Context, flags map[Flag]bool) context.Context {
+	merged := make(map[Flag]bool, len(flags))
+	for f, enabled := range overrides(ctx) {
+		merged[f] = enabled
+	}
+	for f, enabled := range flags {
+		merged[f] = enabled
+	}
+	return context.WithValue(ctx, overridesContextKey{}, merged)
+}
+
+func overrides(ctx context.Context) map[Flag]bool {
+	flags, _ := ctx.Value(overridesContextKey{}).(map[Flag]bool)
+	return flags
+}
+
+func namespaceOverrides(ctx context.Context) map[string]map[Flag]bool {
+	flags, _ := ctx.Value(namespaceOverridesContextKey{}).(map[string]map[Flag]bool)
+	return flags
+}
+
+// NamespaceOverrides returns the runtime overrides of the namespace.
+func (o *Overrides) NamespaceOverrides(namespace string) map[Flag]bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	flags := make(map[Flag]bool, len(o.namespaces[namespace]))
+	for f, enabled := range o.namespaces[namespace] {
+		flags[f] = enabled
+	}
+	return flags
+}
+
+// SetNamespaceOverrides replaces the runtime overrides of the namespace; no
+// flags remove them. They apply to the requests this instance serves from
+// then on and are not persisted, so they are lost on restart and have to be
+// set on every instance.
+func (o *Overrides) SetNamespaceOverrides(namespace string, flags map[Flag]bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	namespaces := make(map[string]map[Flag]bool, len(o.namespaces)+1)
+	for n, f := range o.namespaces {
+		namespaces[n] = f
+	}
+	delete(namespaces, namespace)
+	if len(flags) > 0 {
+		copied := make(map[Flag]bool, len(flags))
+		for f, enabled := range flags {
+			copied[f] = enabled
+		}
+		namespaces[namespace] = copied
+	}
+	o.namespaces = namespaces
+}
+
+// Parse parses overrides in the format of the MetadataKey values.
+func Parse(values ...string) (map[Flag]bool, error) {
+	flags := make(map[Flag]bool)
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, value := part, "on"
+			if i := strings.Index(part, "="); i >= 0 {
+				name, value = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+			}
+			if !known(Flag(name)) {
+				return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.FeatureFlagUnknown).
+					WithReasonf("The feature flag %q is unknown.", name).
+					WithDetail(errorcode.DetailField, MetadataKey))
+			}
+			switch value {
+			case "on", "true":
+				flags[Flag(name)] = true
+			case "off", "false":
+				flags[Flag(name)] = false
+			default:
+				return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+					WithReasonf("The feature flag %q has to be set to on or off, but got %q.", name, value).
+					WithDetail(errorcode.DetailField, MetadataKey))
+			}
+		}
+	}
+	return flags, nil
+}
+
+func known(f Flag) bool {
+	for _, k := range All {
+		if k == f {
+			return true
+		}
+	}
+	return false
+}
+
+// withOverrides returns the context with the runtime overrides and the
+// overrides of the request, if the development mode is enabled.
+func (o *Overrides) withOverrides(ctx context.Context, values []string) (context.Context, error) {
+	o.mu.RLock()
+	namespaces := o.namespaces
+	o.mu.RUnlock()
+	if len(namespaces) > 0 {
+		ctx = context.WithValue(ctx, namespaceOverridesContextKey{}, namespaces)
+	}
+
+	if len(values) == 0 || !o.d.Config(ctx).Dev() {
+		return ctx, nil
+	}
+	flags, err := Parse(values...)
+	if err != nil {
+		return nil, err
+	}
+	for f, enabled := range flags {
+		value := "off"
+		if enabled {
+			value = "on"
+		}
+		overridesTotal.WithLabelValues(string(f), value).Inc()
+	}
+	return WithOverrides(ctx, flags), nil
+}
+
+func (o *Overrides) HTTPMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx, err := o.withOverrides(r.Context(), r.Header.Values(MetadataKey))
+	if err != nil {
+		o.d.Writer().WriteError(rw, r, err)
+		return
+	}
+	next(rw, r.WithContext(ctx))
+}
+
+func (o *Overrides) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, err := o.withOverrides(ctx, md.Get(MetadataKey))
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func (o *Overrides) StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	ctx, err := o.withOverrides(ss.Context(), md.Get(MetadataKey))
+	if err != nil {
+		return err
+	}
+	return handler(srv, &stream{ServerStream: ss, ctx: ctx})
+}
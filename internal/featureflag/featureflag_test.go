@@ -0,0 +1,218 @@
+package featureflag_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/x"
+)
+
+type deps struct {
+	c *config.Config
+	o *featureflag.Overrides
+}
+
+func (d *deps) Config(context.Context) *config.Config        { return d.c }
+func (d *deps) Writer() herodot.Writer                       { return herodot.NewJSONWriter(nil) }
+func (d *deps) FeatureFlagOverrides() *featureflag.Overrides { return d.o }
+
+func newConfig(t *testing.T) *config.Config {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	return config.New(ctx, logrusx.New("test", "today"), cp)
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		values   []string
+		expected map[featureflag.Flag]bool
+	}{
+		{values: nil, expected: map[featureflag.Flag]bool{}},
+		{values: []string{"direct_match_first"}, expected: map[featureflag.Flag]bool{featureflag.DirectMatchFirst: true}},
+		{values: []string{" direct_match_first = off ,"}, expected: map[featureflag.Flag]bool{featureflag.DirectMatchFirst: false}},
+		{values: []string{"direct_match_first=off", "direct_match_first=true"}, expected: map[featureflag.Flag]bool{featureflag.DirectMatchFirst: true}},
+	} {
+		flags, err := featureflag.Parse(tc.values...)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, flags, "%v", tc.values)
+	}
+
+	_, err := featureflag.Parse("unknown=on")
+	assert.True(t, errorcode.Is(err, errorcode.FeatureFlagUnknown), "%+v", err)
+	_, err = featureflag.Parse("direct_match_first=maybe")
+	assert.True(t, errorcode.Is(err, errorcode.RequestInvalid), "%+v", err)
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := context.Background()
+	c := newConfig(t)
+
+	assert.False(t, featureflag.Enabled(ctx, c, featureflag.DirectMatchFirst))
+	require.NoError(t, c.Set(config.KeyFeatureFlags+"."+string(featureflag.DirectMatchFirst), true))
+	assert.True(t, featureflag.Enabled(ctx, c, featureflag.DirectMatchFirst))
+
+	ctx = featureflag.WithOverrides(ctx, map[featureflag.Flag]bool{featureflag.DirectMatchFirst: false})
+	assert.False(t, featureflag.Enabled(ctx, c, featureflag.DirectMatchFirst))
+}
+
+func TestOverrides(t *testing.T) {
+	c := newConfig(t)
+	o := featureflag.New(&deps{c: c})
+
+	unary := func(t *testing.T, value string) (bool, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(featureflag.MetadataKey, value))
+		var enabled bool
+		_, err := o.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			enabled = featureflag.Enabled(ctx, c, featureflag.DirectMatchFirst)
+			return nil, nil
+		})
+		return enabled, err
+	}
+	serve := func(t *testing.T, value string) (bool, int) {
+		var enabled bool
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/relation-tuples/check", nil)
+		req.Header.Set(featureflag.MetadataKey, value)
+		o.HTTPMiddleware(rec, req, func(_ http.ResponseWriter, r *http.Request) {
+			enabled = featureflag.Enabled(r.Context(), c, featureflag.DirectMatchFirst)
+		})
+		return enabled, rec.Code
+	}
+
+	t.Run("case=ignored outside of development mode", func(t *testing.T) {
+		enabled, err := unary(t, "direct_match_first=on,unknown")
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		enabled, code := serve(t, "direct_match_first=on")
+		assert.Equal(t, http.StatusOK, code)
+		assert.False(t, enabled)
+	})
+
+	require.NoError(t, c.Set(config.KeyDev, true))
+
+	t.Run("case=applied in development mode", func(t *testing.T) {
+		enabled, err := unary(t, "direct_match_first=on")
+		require.NoError(t, err)
+		assert.True(t, enabled)
+
+		enabled, code := serve(t, "direct_match_first")
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, enabled)
+	})
+
+	t.Run("case=rejects unknown flags", func(t *testing.T) {
+		_, err := unary(t, "unknown=on")
+		assert.True(t, errorcode.Is(err, errorcode.FeatureFlagUnknown), "%+v", err)
+
+		_, code := serve(t, "unknown=on")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestEnabledIn(t *testing.T) {
+	ctx := context.Background()
+	c := newConfig(t)
+	o := featureflag.New(&deps{c: c})
+	flag := featureflag.DirectMatchFirst
+
+	// enabledIn evaluates the flag like a request passing the middleware
+	enabledIn := func(namespace string) (enabled bool) {
+		o.HTTPMiddleware(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/relation-tuples/check", nil), func(_ http.ResponseWriter, r *http.Request) {
+			enabled = featureflag.EnabledIn(r.Context(), c, flag, namespace)
+		})
+		return
+	}
+
+	require.NoError(t, c.Set(config.KeyFeatureFlagNamespaces+".docs."+string(flag), true))
+	assert.True(t, enabledIn("docs"))
+	assert.False(t, enabledIn("files"))
+
+	require.NoError(t, c.Set(config.KeyFeatureFlags+"."+string(flag), true))
+	require.NoError(t, c.Set(config.KeyFeatureFlagNamespaces+".files."+string(flag), false))
+	assert.False(t, enabledIn("files"), "the namespace config takes precedence")
+	assert.True(t, enabledIn("other"))
+
+	o.SetNamespaceOverrides("files", map[featureflag.Flag]bool{flag: true})
+	assert.True(t, enabledIn("files"), "runtime overrides take precedence over the config")
+	assert.False(t, featureflag.EnabledIn(ctx, c, flag, "files"), "outside of requests only the config applies")
+
+	assert.False(t, featureflag.EnabledIn(featureflag.WithOverrides(ctx, map[featureflag.Flag]bool{flag: false}), c, flag, "docs"),
+		"request overrides take precedence over everything")
+
+	o.SetNamespaceOverrides("files", nil)
+	assert.Empty(t, o.NamespaceOverrides("files"))
+	assert.False(t, enabledIn("files"))
+}
+
+func TestHandler(t *testing.T) {
+	c := newConfig(t)
+	require.NoError(t, c.Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "docs"}}))
+	d := &deps{c: c}
+	d.o = featureflag.New(d)
+
+	r := httprouter.New()
+	featureflag.NewHandler(d).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, namespace, body string) (*http.Response, *featureflag.NamespaceFlags) {
+		req, err := http.NewRequest(method, ts.URL+strings.Replace(featureflag.RouteNamespace, ":namespace", namespace, 1), strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var flags featureflag.NamespaceFlags
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&flags))
+		}
+		return resp, &flags
+	}
+
+	resp, flags := do(t, http.MethodGet, "docs", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, &featureflag.NamespaceFlags{
+		Namespace: "docs",
+		Flags:     map[featureflag.Flag]bool{featureflag.DirectMatchFirst: false},
+		Overrides: map[featureflag.Flag]bool{},
+	}, flags)
+
+	resp, flags = do(t, http.MethodPut, "docs", `{"direct_match_first":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, flags.Flags[featureflag.DirectMatchFirst])
+	assert.Equal(t, map[featureflag.Flag]bool{featureflag.DirectMatchFirst: true}, d.o.NamespaceOverrides("docs"))
+
+	resp, _ = do(t, http.MethodPut, "docs", `{"unknown":true}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = do(t, http.MethodPut, "docs", `[]`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = do(t, http.MethodGet, "unknown", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = do(t, http.MethodDelete, "docs", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, d.o.NamespaceOverrides("docs"))
+
+	require.NoError(t, c.Set(config.KeyFeatureFlagNamespaces+".docs."+string(featureflag.DirectMatchFirst), true))
+	_, flags = do(t, http.MethodGet, "docs", "")
+	assert.True(t, flags.Flags[featureflag.DirectMatchFirst])
+}
@@ -0,0 +1,205 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+	// The feature flags of a namespace
+	//
+	// swagger:model namespaceFeatureFlags
+	NamespaceFlags struct {
+		// required: true
+		Namespace string `json:"namespace"`
+		// Whether each flag is enabled for the namespace, taking the
+		// overrides and the config into account
+		//
+		// required: true
+		Flags map[Flag]bool `json:"flags"`
+		// The runtime overrides of this instance for the namespace
+		//
+		// required: true
+		Overrides map[Flag]bool `json:"overrides"`
+	}
+)
+
+const (
+	RouteBase      = "/admin/feature-flags"
+	RouteNamespace = RouteBase + "/namespaces/:namespace"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteNamespace, h.getNamespaceFeatureFlags)
+	r.PUT(RouteNamespace, h.overrideNamespaceFeatureFlags)
+	r.DELETE(RouteNamespace, h.resetNamespaceFeatureFlags)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:parameters getNamespaceFeatureFlags resetNamespaceFeatureFlags
+// nolint:deadcode,unused
+type namespaceFeatureFlagsParameters struct {
+	// in: path
+	// required: true
+	Namespace string `json:"namespace"`
+}
+
+// swagger:parameters overrideNamespaceFeatureFlags
+// nolint:deadcode,unused
+type overrideNamespaceFeatureFlagsParameters struct {
+	// in: path
+	// required: true
+	Namespace string `json:"namespace"`
+
+	// The flags to enable or disable, replacing the previous overrides
+	//
+	// in: body
+	// required: true
+	Body map[Flag]bool
+}
+
+func (h *Handler) namespaceFlags(r *http.Request, namespace string) *NamespaceFlags {
+	res := &NamespaceFlags{
+		Namespace: namespace,
+		Flags:     make(map[Flag]bool, len(All)),
+		Overrides: h.d.FeatureFlagOverrides().NamespaceOverrides(namespace),
+	}
+	c := h.d.Config(r.Context())
+	for _, f := range All {
+		if enabled, ok := res.Overrides[f]; ok {
+			res.Flags[f] = enabled
+		} else {
+			res.Flags[f] = c.NamespaceFeatureFlag(string(f), namespace)
+		}
+	}
+	return res
+}
+
+func (h *Handler) requireNamespace(r *http.Request, ps httprouter.Params) (string, error) {
+	nm, err := h.d.Config(r.Context()).NamespaceManager()
+	if err != nil {
+		return "", err
+	}
+	n, err := nm.GetNamespaceByName(r.Context(), ps.ByName("namespace"))
+	if err != nil {
+		return "", err
+	}
+	return n.Name, nil
+}
+
+// swagger:route GET /admin/feature-flags/namespaces/{namespace} write getNamespaceFeatureFlags
+//
+// Get the feature flags of a namespace
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: namespaceFeatureFlags
+//       404: genericError
+//       500: genericError
+func (h *Handler) getNamespaceFeatureFlags(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	namespace, err := h.requireNamespace(r, ps)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, h.namespaceFlags(r, namespace))
+}
+
+// swagger:route PUT /admin/feature-flags/namespaces/{namespace} write overrideNamespaceFeatureFlags
+//
+// Override the feature flags of a namespace
+//
+// Enables or disables experimental engine behavior for the namespace
+// regardless of the config. The overrides only apply to the instance that
+// serves this request and are lost on restart.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: namespaceFeatureFlags
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) overrideNamespaceFeatureFlags(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	namespace, err := h.requireNamespace(r, ps)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var flags map[Flag]bool
+	if err := json.NewDecoder(r.Body).Decode(&flags); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReasonf("The body has to be a JSON object of feature flags and whether they are enabled: %s", err)))
+		return
+	}
+	for f := range flags {
+		if !known(f) {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.FeatureFlagUnknown).
+				WithReasonf("The feature flag %q is unknown.", f).
+				WithDetail(errorcode.DetailField, string(f))))
+			return
+		}
+	}
+
+	h.d.FeatureFlagOverrides().SetNamespaceOverrides(namespace, flags)
+	h.d.Writer().Write(w, r, h.namespaceFlags(r, namespace))
+}
+
+// swagger:route DELETE /admin/feature-flags/namespaces/{namespace} write resetNamespaceFeatureFlags
+//
+// Remove the feature flag overrides of a namespace
+//
+// The flags of the namespace are read from the config again.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) resetNamespaceFeatureFlags(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	namespace, err := h.requireNamespace(r, ps)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.FeatureFlagOverrides().SetNamespaceOverrides(namespace, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
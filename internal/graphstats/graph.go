@@ -0,0 +1,86 @@
+package graphstats
+
+import (
+	"sort"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type (
+	node struct {
+		set        *relationtuple.SubjectSet
+		subjects   []relationtuple.Subject
+		references int
+	}
+	graph struct {
+		nodes map[string]*node
+		// depths caches the depth of the nodes. Nodes that are being
+		// computed have a depth of zero, which breaks cycles.
+		depths map[string]int
+	}
+)
+
+func newGraph() *graph {
+	return &graph{nodes: map[string]*node{}, depths: map[string]int{}}
+}
+
+func (g *graph) node(set *relationtuple.SubjectSet) *node {
+	key := set.String()
+	n, ok := g.nodes[key]
+	if !ok {
+		n = &node{set: set}
+		g.nodes[key] = n
+	}
+	return n
+}
+
+func (g *graph) add(r *relationtuple.InternalRelationTuple) {
+	n := g.node(&relationtuple.SubjectSet{Namespace: r.Namespace, Object: r.Object, Relation: r.Relation})
+	n.subjects = append(n.subjects, r.Subject)
+	if s, ok := r.Subject.(*relationtuple.SubjectSet); ok {
+		g.node(s).references++
+	}
+}
+
+func (g *graph) depth(key string) int {
+	if d, ok := g.depths[key]; ok {
+		return d
+	}
+	n, ok := g.nodes[key]
+	if !ok || len(n.subjects) == 0 {
+		return 0
+	}
+
+	g.depths[key] = 0
+	deepest := 0
+	for _, s := range n.subjects {
+		if s, ok := s.(*relationtuple.SubjectSet); ok {
+			if d := g.depth(s.String()); d > deepest {
+				deepest = d
+			}
+		}
+	}
+	g.depths[key] = deepest + 1
+	return deepest + 1
+}
+
+// mostReferenced returns the top subject sets of the namespace with the
+// most references, breaking ties by name.
+func (g *graph) mostReferenced(namespace string, top int) []*Reference {
+	refs := []*Reference{}
+	for _, n := range g.nodes {
+		if n.set.Namespace == namespace && n.references > 0 {
+			refs = append(refs, &Reference{SubjectSet: n.set, References: n.references})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].References != refs[j].References {
+			return refs[i].References > refs[j].References
+		}
+		return refs[i].SubjectSet.String() < refs[j].SubjectSet.String()
+	})
+	if len(refs) > top {
+		refs = refs[:top]
+	}
+	return refs
+}
@@ -0,0 +1,95 @@
+package graphstats
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		dependencies
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const RouteBase = "/admin/graph-stats"
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.getGraphStats)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:parameters getGraphStats
+// nolint:deadcode,unused
+type getGraphStatsParameters struct {
+	// The maximum number of relation tuples sampled per namespace. Defaults
+	// to 10000.
+	//
+	// in: query
+	SampleSize int `json:"sample_size"`
+
+	// The number of most-referenced subject sets reported per namespace.
+	// Defaults to 10.
+	//
+	// in: query
+	Top int `json:"top"`
+}
+
+// swagger:route GET /admin/graph-stats write getGraphStats
+//
+// Get statistics of the relation tuple graph
+//
+// Samples the oldest relation tuples of every namespace and reports the depth
+// distribution and fan-out percentiles of the sampled subject sets, and the
+// most-referenced subject sets. Sampling reads from the database, so keep the
+// sample size moderate on busy deployments.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: graphStatsReport
+//       400: genericError
+//       500: genericError
+func (h *Handler) getGraphStats(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+
+	var o Options
+	for key, v := range map[string]*int{"sample_size": &o.SampleSize, "top": &o.Top} {
+		if raw := q.Get(key); raw != "" {
+			i, err := strconv.ParseInt(raw, 0, 0)
+			if err != nil {
+				h.d.Writer().WriteError(w, r, herodot.ErrBadRequest.WithError(err.Error()))
+				return
+			}
+			*v = int(i)
+		}
+	}
+
+	report, err := Collect(r.Context(), h.d, o)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, report)
+}
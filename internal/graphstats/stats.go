@@ -0,0 +1,201 @@
+package graphstats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		relationtuple.ManagerProvider
+		config.Provider
+	}
+	Options struct {
+		// SampleSize is the maximum number of relation tuples read per
+		// namespace.
+		SampleSize int
+		// Top is the number of most-referenced subject sets reported per
+		// namespace.
+		Top int
+	}
+	// The statistics of the sampled relation tuple graph
+	//
+	// swagger:model graphStatsReport
+	Report struct {
+		SampleSize int               `json:"sample_size"`
+		Namespaces []*NamespaceStats `json:"namespaces"`
+	}
+	NamespaceStats struct {
+		Namespace     string `json:"namespace"`
+		SampledTuples int    `json:"sampled_tuples"`
+		// Truncated is true if the namespace holds more tuples than were
+		// sampled.
+		Truncated bool `json:"truncated"`
+		// Depth maps the depth of the sampled subject sets to their number.
+		// Subject sets with only subject IDs have a depth of one, every
+		// indirection through another subject set adds one.
+		Depth map[int]int `json:"depth"`
+		// FanOut are the percentiles of the number of direct subjects of the
+		// sampled subject sets.
+		FanOut         Percentiles  `json:"fan_out"`
+		MostReferenced []*Reference `json:"most_referenced"`
+	}
+	Percentiles struct {
+		P50 int `json:"p50"`
+		P90 int `json:"p90"`
+		P99 int `json:"p99"`
+		Max int `json:"max"`
+	}
+	Reference struct {
+		SubjectSet *relationtuple.SubjectSet `json:"subject_set"`
+		// References is the number of sampled tuples that have the subject
+		// set as subject.
+		References int `json:"references"`
+	}
+)
+
+const (
+	DefaultSampleSize = 10000
+	DefaultTop        = 10
+
+	pageSize = 1000
+)
+
+func (o *Options) defaults() {
+	if o.SampleSize <= 0 {
+		o.SampleSize = DefaultSampleSize
+	}
+	if o.Top <= 0 {
+		o.Top = DefaultTop
+	}
+}
+
+// Collect samples the oldest relation tuples of every namespace and reports
+// the shape of the sampled graph. Subject sets are followed across
+// namespaces, but only through sampled tuples, so the depth of large graphs
+// is underestimated.
+func Collect(ctx context.Context, d dependencies, o Options) (*Report, error) {
+	o.defaults()
+
+	nm, err := d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	nn, err := nm.Namespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nn, func(i, j int) bool { return nn[i].Name < nn[j].Name })
+
+	g := newGraph()
+	report := &Report{SampleSize: o.SampleSize, Namespaces: make([]*NamespaceStats, len(nn))}
+	for i, n := range nn {
+		stats := &NamespaceStats{Namespace: n.Name, Depth: map[int]int{}, MostReferenced: []*Reference{}}
+		report.Namespaces[i] = stats
+
+		for page := ""; ; {
+			size := pageSize
+			if rest := o.SampleSize - stats.SampledTuples; rest < size {
+				size = rest
+			}
+			rels, next, err := d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: n.Name}, x.WithSize(size), x.WithToken(page))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for _, r := range rels {
+				g.add(r)
+			}
+			stats.SampledTuples += len(rels)
+			if next == "" {
+				break
+			}
+			if stats.SampledTuples >= o.SampleSize {
+				stats.Truncated = true
+				break
+			}
+			page = next
+		}
+	}
+
+	byNamespace := make(map[string]*NamespaceStats, len(report.Namespaces))
+	for _, stats := range report.Namespaces {
+		byNamespace[stats.Namespace] = stats
+	}
+	fanOut := map[string][]int{}
+	for key, node := range g.nodes {
+		stats, ok := byNamespace[node.set.Namespace]
+		if !ok || len(node.subjects) == 0 {
+			// Only referenced, not sampled.
+			continue
+		}
+		stats.Depth[g.depth(key)]++
+		fanOut[node.set.Namespace] = append(fanOut[node.set.Namespace], len(node.subjects))
+	}
+	for _, stats := range report.Namespaces {
+		stats.FanOut = percentiles(fanOut[stats.Namespace])
+		stats.MostReferenced = g.mostReferenced(stats.Namespace, o.Top)
+	}
+
+	return report, nil
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, n := range r.Namespaces {
+		_, _ = fmt.Fprintf(&b, "%s: sampled %d relation tuples", n.Namespace, n.SampledTuples)
+		if n.Truncated {
+			b.WriteString(" (truncated)")
+		}
+		b.WriteString("\n")
+
+		depths := make([]int, 0, len(n.Depth))
+		for depth := range n.Depth {
+			depths = append(depths, depth)
+		}
+		sort.Ints(depths)
+		b.WriteString("  depth:")
+		for _, depth := range depths {
+			_, _ = fmt.Fprintf(&b, " %d=%d", depth, n.Depth[depth])
+		}
+		b.WriteString("\n")
+
+		_, _ = fmt.Fprintf(&b, "  fan-out: p50=%d p90=%d p99=%d max=%d\n", n.FanOut.P50, n.FanOut.P90, n.FanOut.P99, n.FanOut.Max)
+		if len(n.MostReferenced) > 0 {
+			b.WriteString("  most referenced:\n")
+		}
+		for _, ref := range n.MostReferenced {
+			_, _ = fmt.Fprintf(&b, "    %s %d\n", ref.SubjectSet, ref.References)
+		}
+	}
+	return b.String()
+}
+
+func (r *Report) Interface() interface{} {
+	return r
+}
+
+// percentiles returns the nearest-rank percentiles of the values.
+func percentiles(values []int) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Ints(values)
+	rank := func(p int) int {
+		i := (p*len(values)+99)/100 - 1
+		return values[i]
+	}
+	return Percentiles{
+		P50: rank(50),
+		P90: rank(90),
+		P99: rank(99),
+		Max: values[len(values)-1],
+	}
+}
@@ -0,0 +1,74 @@
+package graphstats_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/graphstats"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "groups"}, {ID: 1, Name: "docs"}}))
+
+	admins := &relationtuple.SubjectSet{Namespace: "groups", Object: "admins", Relation: "member"}
+	devs := &relationtuple.SubjectSet{Namespace: "groups", Object: "devs", Relation: "member"}
+	for _, r := range []string{
+		"groups:admins#member@alice",
+		"groups:admins#member@bob",
+		"groups:devs#member@groups:admins#member",
+		"groups:devs#member@carol",
+		"docs:readme#view@groups:devs#member",
+		"docs:readme#view@groups:admins#member",
+		"docs:a#view@groups:devs#member",
+		"docs:b#view@dave",
+	} {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(r)
+		require.NoError(t, err)
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, rt))
+	}
+
+	t.Run("case=reports the sampled graph", func(t *testing.T) {
+		report, err := graphstats.Collect(ctx, reg, graphstats.Options{})
+		require.NoError(t, err)
+		assert.Equal(t, graphstats.DefaultSampleSize, report.SampleSize)
+		require.Len(t, report.Namespaces, 2)
+
+		docs, groups := report.Namespaces[0], report.Namespaces[1]
+		assert.Equal(t, &graphstats.NamespaceStats{
+			Namespace:      "docs",
+			SampledTuples:  4,
+			Depth:          map[int]int{1: 1, 3: 2},
+			FanOut:         graphstats.Percentiles{P50: 1, P90: 2, P99: 2, Max: 2},
+			MostReferenced: []*graphstats.Reference{},
+		}, docs)
+		assert.Equal(t, &graphstats.NamespaceStats{
+			Namespace:      "groups",
+			SampledTuples:  4,
+			Depth:          map[int]int{1: 1, 2: 1},
+			FanOut:         graphstats.Percentiles{P50: 2, P90: 2, P99: 2, Max: 2},
+			MostReferenced: []*graphstats.Reference{{SubjectSet: admins, References: 2}, {SubjectSet: devs, References: 2}},
+		}, groups)
+
+		assert.Contains(t, report.String(), "docs: sampled 4 relation tuples\n  depth: 1=1 3=2\n  fan-out: p50=1 p90=2 p99=2 max=2\n")
+	})
+
+	t.Run("case=limits the sample and the most-referenced subject sets", func(t *testing.T) {
+		report, err := graphstats.Collect(ctx, reg, graphstats.Options{SampleSize: 3, Top: 1})
+		require.NoError(t, err)
+
+		for _, n := range report.Namespaces {
+			assert.Equal(t, 3, n.SampledTuples, n.Namespace)
+			assert.True(t, n.Truncated, n.Namespace)
+		}
+		assert.Len(t, report.Namespaces[1].MostReferenced, 1)
+	})
+}
@@ -0,0 +1,288 @@
+package groups
+
+import (
+	"context"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// Groups are stored as the relation tuples of their members in the configured
+// groups namespace, e.g. "groups:devs#member@alice". Nested groups are
+// members by their subject set, e.g. "groups:devs#member@groups:admins#member",
+// which makes the admins members of the devs. A group exists as long as it
+// has members.
+
+type (
+	Provider interface {
+		GroupManager() *Manager
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+	}
+	Manager struct {
+		d dependencies
+	}
+	// A member of a group
+	//
+	// swagger:model groupMember
+	Member struct {
+		// SubjectID of the member
+		//
+		// Exactly one of SubjectID, Group, and SubjectSet has to be provided.
+		SubjectID *string `json:"subject_id,omitempty"`
+		// Group whose members are members of the group as well
+		//
+		// Exactly one of SubjectID, Group, and SubjectSet has to be provided.
+		Group *string `json:"group,omitempty"`
+		// SubjectSet of other members, e.g. of a role in another namespace
+		//
+		// Exactly one of SubjectID, Group, and SubjectSet has to be provided.
+		//
+		// swagger:allOf
+		SubjectSet *relationtuple.SubjectSet `json:"subject_set,omitempty"`
+	}
+)
+
+var ErrGroupExists = herodot.ErrConflict.WithID(errorcode.GroupExists)
+
+func NewManager(d dependencies) *Manager {
+	return &Manager{d: d}
+}
+
+func (m *Manager) config(ctx context.Context) (ns, relation string) {
+	return m.d.Config(ctx).Groups()
+}
+
+// subject returns the subject of the member. It is a subject set for nested
+// groups.
+func (m *Manager) subject(ctx context.Context, member *Member) (relationtuple.Subject, error) {
+	set := 0
+	for _, ok := range []bool{member.SubjectID != nil, member.Group != nil, member.SubjectSet != nil} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return nil, errors.WithStack(relationtuple.ErrDuplicateSubject.WithError("exactly one of subject_id, group, or subject_set has to be provided"))
+	case member.SubjectID != nil:
+		return &relationtuple.SubjectID{ID: *member.SubjectID}, nil
+	case member.Group != nil:
+		ns, relation := m.config(ctx)
+		return &relationtuple.SubjectSet{Namespace: ns, Object: *member.Group, Relation: relation}, nil
+	case member.SubjectSet != nil:
+		return member.SubjectSet, nil
+	}
+	return nil, errors.WithStack(relationtuple.ErrNilSubject)
+}
+
+// nestedGroup returns the group that the subject is the member subject set
+// of, if any.
+func (m *Manager) nestedGroup(ctx context.Context, s relationtuple.Subject) (string, bool) {
+	ns, relation := m.config(ctx)
+	if set, ok := s.(*relationtuple.SubjectSet); ok && set.Namespace == ns && set.Relation == relation {
+		return set.Object, true
+	}
+	return "", false
+}
+
+func (m *Manager) member(ctx context.Context, s relationtuple.Subject) *Member {
+	if group, ok := m.nestedGroup(ctx, s); ok {
+		return &Member{Group: &group}
+	}
+	switch s := s.(type) {
+	case *relationtuple.SubjectID:
+		return &Member{SubjectID: &s.ID}
+	case *relationtuple.SubjectSet:
+		return &Member{SubjectSet: s}
+	}
+	return &Member{}
+}
+
+func requireGroup(group string) error {
+	if group == "" {
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).WithReason("The group is required.").WithDetail(errorcode.DetailField, "group"))
+	}
+	return nil
+}
+
+func (m *Manager) tuple(ctx context.Context, group string, member *Member) (*relationtuple.InternalRelationTuple, error) {
+	if err := requireGroup(group); err != nil {
+		return nil, err
+	}
+	s, err := m.subject(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	ns, relation := m.config(ctx)
+	return &relationtuple.InternalRelationTuple{Namespace: ns, Object: group, Relation: relation, Subject: s}, nil
+}
+
+func (m *Manager) membersQuery(ctx context.Context, group string) *relationtuple.RelationQuery {
+	ns, relation := m.config(ctx)
+	return &relationtuple.RelationQuery{Namespace: ns, Object: group, Relation: relation}
+}
+
+// exists returns whether the group has any member.
+func (m *Manager) exists(ctx context.Context, group string) (bool, error) {
+	res, _, err := m.d.RelationTupleManager().GetRelationTuples(ctx, m.membersQuery(ctx, group), x.WithSize(1))
+	if err != nil {
+		return false, err
+	}
+	return len(res) > 0, nil
+}
+
+// checkCycle returns an error if adding the nested group to the group would
+// make the group a member of itself. Concurrent writes can still create
+// cycles, which the check and expand engines stop at their maximum depth.
+func (m *Manager) checkCycle(ctx context.Context, group, nested string) error {
+	cycle := func() error {
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.GroupCycle).
+			WithReasonf("The group %q can't be a member of the group %q, because %q is a member of %q already.", nested, group, group, nested))
+	}
+	if nested == group {
+		return cycle()
+	}
+
+	visited := map[string]bool{nested: true}
+	queue := []string{nested}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		var page string
+		for {
+			res, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, m.membersQuery(ctx, current),
+				x.WithToken(page), x.WithSize(m.d.Config(ctx).MaxPageSize()))
+			if err != nil {
+				return err
+			}
+			for _, rt := range res {
+				g, ok := m.nestedGroup(ctx, rt.Subject)
+				if !ok || visited[g] {
+					continue
+				}
+				if g == group {
+					return cycle()
+				}
+				visited[g] = true
+				queue = append(queue, g)
+			}
+			if next == "" {
+				break
+			}
+			page = next
+		}
+	}
+	return nil
+}
+
+// Create creates the group with its initial members. Groups without members
+// can't be stored, so at least one is required.
+func (m *Manager) Create(ctx context.Context, group string, members []*Member) error {
+	if err := requireGroup(group); err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReason("A group needs at least one member, as it is stored as the relation tuples of its members.").
+			WithDetail(errorcode.DetailField, "members"))
+	}
+	exists, err := m.exists(ctx, group)
+	if err != nil {
+		return err
+	} else if exists {
+		return errors.WithStack(ErrGroupExists.WithReasonf("The group %q exists already.", group))
+	}
+
+	seen := map[string]bool{}
+	var insert []*relationtuple.InternalRelationTuple
+	for _, member := range members {
+		rt, err := m.tuple(ctx, group, member)
+		if err != nil {
+			return err
+		}
+		if seen[rt.String()] {
+			continue
+		}
+		seen[rt.String()] = true
+		if nested, ok := m.nestedGroup(ctx, rt.Subject); ok {
+			if err := m.checkCycle(ctx, group, nested); err != nil {
+				return err
+			}
+		}
+		insert = append(insert, rt)
+	}
+	return m.d.RelationTupleManager().TransactRelationTuples(ctx, insert, nil)
+}
+
+// Delete deletes all members of the group, and removes it from the groups
+// it is a member of.
+func (m *Manager) Delete(ctx context.Context, group string) error {
+	if err := requireGroup(group); err != nil {
+		return err
+	}
+	ns, relation := m.config(ctx)
+	if err := m.d.RelationTupleManager().DeleteAllRelationTuples(ctx, m.membersQuery(ctx, group)); err != nil {
+		return err
+	}
+	return m.d.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{
+		Namespace:  ns,
+		Relation:   relation,
+		SubjectSet: &relationtuple.SubjectSet{Namespace: ns, Object: group, Relation: relation},
+	})
+}
+
+// AddMember adds the member to the group, creating the group if it has no
+// members yet. Adding a member again is a no-op.
+func (m *Manager) AddMember(ctx context.Context, group string, member *Member) error {
+	rt, err := m.tuple(ctx, group, member)
+	if err != nil {
+		return err
+	}
+	res, _, err := m.d.RelationTupleManager().GetRelationTuples(ctx, rt.ToQuery(), x.WithSize(1))
+	if err != nil {
+		return err
+	} else if len(res) > 0 {
+		return nil
+	}
+	if nested, ok := m.nestedGroup(ctx, rt.Subject); ok {
+		if err := m.checkCycle(ctx, group, nested); err != nil {
+			return err
+		}
+	}
+	return m.d.RelationTupleManager().WriteRelationTuples(ctx, rt)
+}
+
+// RemoveMember removes the member from the group. The group is deleted with
+// its last member.
+func (m *Manager) RemoveMember(ctx context.Context, group string, member *Member) error {
+	rt, err := m.tuple(ctx, group, member)
+	if err != nil {
+		return err
+	}
+	return m.d.RelationTupleManager().DeleteRelationTuples(ctx, rt)
+}
+
+// Members returns a page of the direct members of the group.
+func (m *Manager) Members(ctx context.Context, group string, options ...x.PaginationOptionSetter) ([]*Member, string, error) {
+	if err := requireGroup(group); err != nil {
+		return nil, "", err
+	}
+	res, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, m.membersQuery(ctx, group), options...)
+	if err != nil {
+		return nil, "", err
+	}
+	members := make([]*Member, len(res))
+	for i, rt := range res {
+		members[i] = m.member(ctx, rt.Subject)
+	}
+	return members, next, nil
+}
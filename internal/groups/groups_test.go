@@ -0,0 +1,125 @@
+package groups_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/groups/groupstest"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/x"
+)
+
+func newRegistry(t *testing.T) *driver.RegistryDefault {
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(context.Background()).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "groups"}, {ID: 1, Name: "docs"}}))
+	return reg
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=manages members", func(t *testing.T) {
+		reg := newRegistry(t)
+		m := reg.GroupManager()
+
+		require.NoError(t, m.Create(ctx, "admins", []*groups.Member{{SubjectID: pointerx.String("alice")}}))
+		require.NoError(t, m.Create(ctx, "devs", []*groups.Member{{SubjectID: pointerx.String("bob")}, {Group: pointerx.String("admins")}}))
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+		err := m.Create(ctx, "devs", []*groups.Member{{SubjectID: pointerx.String("carol")}})
+		assert.True(t, errorcode.Is(err, errorcode.GroupExists), "%+v", err)
+
+		require.NoError(t, m.AddMember(ctx, "admins", &groups.Member{SubjectID: pointerx.String("carol")}))
+		require.NoError(t, m.AddMember(ctx, "admins", &groups.Member{SubjectID: pointerx.String("carol")}))
+		members, next, err := m.Members(ctx, "admins")
+		require.NoError(t, err)
+		assert.Empty(t, next)
+		assert.ElementsMatch(t, []*groups.Member{{SubjectID: pointerx.String("alice")}, {SubjectID: pointerx.String("carol")}}, members)
+
+		require.NoError(t, m.RemoveMember(ctx, "admins", &groups.Member{SubjectID: pointerx.String("alice")}))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"))
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "carol"))
+
+		require.NoError(t, m.Delete(ctx, "admins"))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "carol"))
+		members, _, err = m.Members(ctx, "devs")
+		require.NoError(t, err)
+		assert.Equal(t, []*groups.Member{{SubjectID: pointerx.String("bob")}}, members, "the deleted group is no member anymore")
+	})
+
+	t.Run("case=prevents cycles", func(t *testing.T) {
+		reg := newRegistry(t)
+		m := reg.GroupManager()
+
+		require.NoError(t, m.AddMember(ctx, "a", &groups.Member{Group: pointerx.String("b")}))
+		require.NoError(t, m.AddMember(ctx, "b", &groups.Member{Group: pointerx.String("c")}))
+
+		for _, tc := range []struct{ group, nested string }{{"c", "a"}, {"c", "b"}, {"a", "a"}} {
+			err := m.AddMember(ctx, tc.group, &groups.Member{Group: pointerx.String(tc.nested)})
+			assert.True(t, errorcode.Is(err, errorcode.GroupCycle), "%s in %s: %+v", tc.nested, tc.group, err)
+		}
+		err := m.Create(ctx, "c", []*groups.Member{{Group: pointerx.String("a")}})
+		assert.True(t, errorcode.Is(err, errorcode.GroupCycle), "%+v", err)
+
+		require.NoError(t, m.AddMember(ctx, "a", &groups.Member{Group: pointerx.String("c")}), "diamonds are no cycles")
+	})
+
+	t.Run("case=rejects ambiguous members", func(t *testing.T) {
+		reg := newRegistry(t)
+		err := reg.GroupManager().AddMember(ctx, "a", &groups.Member{SubjectID: pointerx.String("alice"), Group: pointerx.String("b")})
+		assert.True(t, errorcode.Is(err, errorcode.SubjectInvalid), "%+v", err)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	reg := newRegistry(t)
+	r := httprouter.New()
+	groups.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string, expectedStatus int) []byte {
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		require.Equal(t, expectedStatus, resp.StatusCode, buf.String())
+		return buf.Bytes()
+	}
+
+	do(t, http.MethodPost, groups.RouteBase, `{"group": "admins", "members": [{"subject_id": "alice"}]}`, http.StatusCreated)
+	do(t, http.MethodPost, groups.RouteBase, `{"group": "admins", "members": [{"subject_id": "alice"}]}`, http.StatusConflict)
+	do(t, http.MethodPost, groups.RouteBase, `{"group": "empty", "members": []}`, http.StatusBadRequest)
+	do(t, http.MethodPut, "/admin/groups/devs/members", `{"group": "admins"}`, http.StatusCreated)
+	do(t, http.MethodPut, "/admin/groups/admins/members", `{"group": "devs"}`, http.StatusBadRequest)
+	assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+	var body struct {
+		Members []*groups.Member `json:"members"`
+	}
+	require.NoError(t, json.Unmarshal(do(t, http.MethodGet, "/admin/groups/devs/members?page_size=10", "", http.StatusOK), &body))
+	assert.Equal(t, []*groups.Member{{Group: pointerx.String("admins")}}, body.Members)
+
+	do(t, http.MethodDelete, "/admin/groups/devs/members?member_group=admins", "", http.StatusNoContent)
+	assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+	do(t, http.MethodDelete, "/admin/groups/admins", "", http.StatusNoContent)
+	assert.False(t, groupstest.IsMember(t, reg, "admins", "alice"))
+}
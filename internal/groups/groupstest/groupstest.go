@@ -0,0 +1,35 @@
+// Package groupstest provides helpers for tests of the group memberships.
+package groupstest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type Registry interface {
+	config.Provider
+	check.EngineProvider
+}
+
+// IsMember checks whether the subject is a member of the group, directly or
+// through nested groups, in the configured groups namespace.
+func IsMember(t *testing.T, reg Registry, group, subject string) bool {
+	t.Helper()
+
+	ctx := context.Background()
+	ns, relation := reg.Config(ctx).Groups()
+	allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+		Namespace: ns,
+		Object:    group,
+		Relation:  relation,
+		Subject:   &relationtuple.SubjectID{ID: subject},
+	}, 0)
+	require.NoError(t, err)
+	return allowed
+}
@@ -0,0 +1,291 @@
+package groups
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/x/pointerx"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase    = "/admin/groups"
+	GroupRoute   = RouteBase + "/:group"
+	MembersRoute = GroupRoute + "/members"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.POST(RouteBase, h.createGroup)
+	r.DELETE(GroupRoute, h.deleteGroup)
+	r.GET(MembersRoute, h.getGroupMembers)
+	r.PUT(MembersRoute, h.addGroupMember)
+	r.DELETE(MembersRoute, h.removeGroupMember)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// membersURL returns the path of the members of the group.
+func membersURL(group string) string {
+	return RouteBase + "/" + url.PathEscape(group) + "/members"
+}
+
+// The group to create
+//
+// swagger:model createGroupBody
+type createGroupBody struct {
+	// required: true
+	Group string `json:"group"`
+	// The initial members, at least one is required.
+	//
+	// required: true
+	Members []*Member `json:"members"`
+}
+
+// swagger:parameters createGroup
+// nolint:deadcode,unused
+type createGroupParameters struct {
+	// in: body
+	Body createGroupBody
+}
+
+// swagger:route POST /admin/groups write createGroup
+//
+// Create a group
+//
+// Creates the group with its initial members. Nested groups that would make
+// the group a member of itself are rejected.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: createGroupBody
+//       400: genericError
+//       409: genericError
+//       500: genericError
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body createGroupBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	if err := h.d.GroupManager().Create(r.Context(), body.Group, body.Members); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().WriteCreated(w, r, membersURL(body.Group), &body)
+}
+
+// swagger:parameters deleteGroup getGroupMembers
+// nolint:deadcode,unused
+type groupParameters struct {
+	// required: true
+	// in: path
+	Group string `json:"group"`
+}
+
+// swagger:route DELETE /admin/groups/{group} write deleteGroup
+//
+// Delete a group
+//
+// Removes all members of the group, and the group from all groups it is a
+// member of.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) deleteGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := h.d.GroupManager().Delete(r.Context(), ps.ByName("group")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// The direct members of a group
+//
+// swagger:model getGroupMembersResponse
+type getGroupMembersResponse struct {
+	Members []*Member `json:"members"`
+	// The opaque token to request the next page, empty on the last page.
+	NextPageToken string `json:"next_page_token"`
+}
+
+// swagger:route GET /admin/groups/{group}/members write getGroupMembers
+//
+// List the members of a group
+//
+// Lists the direct members of the group. Members of nested groups are listed
+// through the nested groups, or with the expand API.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getGroupMembersResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) getGroupMembers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q := r.URL.Query()
+	c := h.d.Config(r.Context())
+	size := c.DefaultPageSize()
+	if raw := q.Get("page_size"); raw != "" {
+		requested, err := strconv.ParseInt(raw, 0, 0)
+		if err != nil || requested < 1 || int(requested) > c.MaxPageSize() {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.PageSizeInvalid).
+				WithReasonf("The page size has to be a number between 1 and %d, got %q.", c.MaxPageSize(), raw).
+				WithDetail(errorcode.DetailLimit, config.KeyLimitMaxPageSize)))
+			return
+		}
+		size = int(requested)
+	}
+
+	members, next, err := h.d.GroupManager().Members(r.Context(), ps.ByName("group"), x.WithToken(q.Get("page_token")), x.WithSize(size))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, &getGroupMembersResponse{Members: members, NextPageToken: next})
+}
+
+// swagger:parameters addGroupMember
+// nolint:deadcode,unused
+type addGroupMemberParameters struct {
+	// required: true
+	// in: path
+	Group string `json:"group"`
+
+	// in: body
+	Body Member
+}
+
+// swagger:route PUT /admin/groups/{group}/members write addGroupMember
+//
+// Add a member to a group
+//
+// Adding a member again is a no-op. Nested groups that would make the group a
+// member of itself are rejected.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: groupMember
+//       400: genericError
+//       500: genericError
+func (h *Handler) addGroupMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var m Member
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	group := ps.ByName("group")
+	if err := h.d.GroupManager().AddMember(r.Context(), group, &m); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().WriteCreated(w, r, membersURL(group), &m)
+}
+
+// swagger:parameters removeGroupMember
+// nolint:deadcode,unused
+type removeGroupMemberParameters struct {
+	// required: true
+	// in: path
+	Group string `json:"group"`
+
+	// in: query
+	SubjectID string `json:"subject_id"`
+
+	// The nested group to remove.
+	//
+	// in: query
+	MemberGroup string `json:"member_group"`
+
+	// in: query
+	SubjectSetNamespace string `json:"subject_set.namespace"`
+
+	// in: query
+	SubjectSetObject string `json:"subject_set.object"`
+
+	// in: query
+	SubjectSetRelation string `json:"subject_set.relation"`
+}
+
+// swagger:route DELETE /admin/groups/{group}/members write removeGroupMember
+//
+// Remove a member from a group
+//
+// The member is identified by exactly one of the subject_id, member_group, or
+// subject_set query parameters. The group is deleted with its last member.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) removeGroupMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q := r.URL.Query()
+	query, err := (&relationtuple.RelationQuery{}).FromURLQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+	m := &Member{SubjectID: query.SubjectID, SubjectSet: query.SubjectSet}
+	if q.Has("member_group") {
+		m.Group = pointerx.String(q.Get("member_group"))
+	}
+
+	if err := h.d.GroupManager().RemoveMember(r.Context(), ps.ByName("group"), m); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 )
 
 // Linger please
@@ -607,6 +608,10 @@ type ReadApiApiGetRelationTuplesRequest struct {
 	subjectSetNamespace *string
 	subjectSetObject    *string
 	subjectSetRelation  *string
+	label               *[]string
+	count               *bool
+	orderBy             *string
+	order               *string
 }
 
 func (r ReadApiApiGetRelationTuplesRequest) PageToken(pageToken string) ReadApiApiGetRelationTuplesRequest {
@@ -645,6 +650,22 @@ func (r ReadApiApiGetRelationTuplesRequest) SubjectSetRelation(subjectSetRelatio
 	r.subjectSetRelation = &subjectSetRelation
 	return r
 }
+func (r ReadApiApiGetRelationTuplesRequest) Label(label []string) ReadApiApiGetRelationTuplesRequest {
+	r.label = &label
+	return r
+}
+func (r ReadApiApiGetRelationTuplesRequest) Count(count bool) ReadApiApiGetRelationTuplesRequest {
+	r.count = &count
+	return r
+}
+func (r ReadApiApiGetRelationTuplesRequest) OrderBy(orderBy string) ReadApiApiGetRelationTuplesRequest {
+	r.orderBy = &orderBy
+	return r
+}
+func (r ReadApiApiGetRelationTuplesRequest) Order(order string) ReadApiApiGetRelationTuplesRequest {
+	r.order = &order
+	return r
+}
 
 func (r ReadApiApiGetRelationTuplesRequest) Execute() (*GetRelationTuplesResponse, *http.Response, error) {
 	return r.ApiService.GetRelationTuplesExecute(r)
@@ -715,6 +736,26 @@ func (a *ReadApiService) GetRelationTuplesExecute(r ReadApiApiGetRelationTuplesR
 	if r.subjectSetRelation != nil {
 		localVarQueryParams.Add("subject_set.relation", parameterToString(*r.subjectSetRelation, ""))
 	}
+	if r.label != nil {
+		t := *r.label
+		if reflect.TypeOf(t).Kind() == reflect.Slice {
+			s := reflect.ValueOf(t)
+			for i := 0; i < s.Len(); i++ {
+				localVarQueryParams.Add("label", parameterToString(s.Index(i), "multi"))
+			}
+		} else {
+			localVarQueryParams.Add("label", parameterToString(t, "multi"))
+		}
+	}
+	if r.count != nil {
+		localVarQueryParams.Add("count", parameterToString(*r.count, ""))
+	}
+	if r.orderBy != nil {
+		localVarQueryParams.Add("order_by", parameterToString(*r.orderBy, ""))
+	}
+	if r.order != nil {
+		localVarQueryParams.Add("order", parameterToString(*r.order, ""))
+	}
 	// to determine the Content-Type header
 	localVarHTTPContentTypes := []string{}
 
@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 )
 
 // Linger please
@@ -55,7 +56,9 @@ type WriteApi interface {
 
 	/*
 	 * PatchRelationTuples Patch Multiple Relation Tuples
-	 * Use this endpoint to patch one or more relation tuples.
+	 * Use this endpoint to patch one or more relation tuples. Instead of a
+	relation tuple, a delta can reference a relation tuple template of the
+	configuration, which is expanded into all of its relation tuples.
 	 * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
 	 * @return WriteApiApiPatchRelationTuplesRequest
 	 */
@@ -208,6 +211,7 @@ type WriteApiApiDeleteRelationTuplesRequest struct {
 	subjectSetNamespace *string
 	subjectSetObject    *string
 	subjectSetRelation  *string
+	label               *[]string
 }
 
 func (r WriteApiApiDeleteRelationTuplesRequest) Namespace(namespace string) WriteApiApiDeleteRelationTuplesRequest {
@@ -238,6 +242,10 @@ func (r WriteApiApiDeleteRelationTuplesRequest) SubjectSetRelation(subjectSetRel
 	r.subjectSetRelation = &subjectSetRelation
 	return r
 }
+func (r WriteApiApiDeleteRelationTuplesRequest) Label(label []string) WriteApiApiDeleteRelationTuplesRequest {
+	r.label = &label
+	return r
+}
 
 func (r WriteApiApiDeleteRelationTuplesRequest) Execute() (*http.Response, error) {
 	return r.ApiService.DeleteRelationTuplesExecute(r)
@@ -300,6 +308,17 @@ func (a *WriteApiService) DeleteRelationTuplesExecute(r WriteApiApiDeleteRelatio
 	if r.subjectSetRelation != nil {
 		localVarQueryParams.Add("subject_set.relation", parameterToString(*r.subjectSetRelation, ""))
 	}
+	if r.label != nil {
+		t := *r.label
+		if reflect.TypeOf(t).Kind() == reflect.Slice {
+			s := reflect.ValueOf(t)
+			for i := 0; i < s.Len(); i++ {
+				localVarQueryParams.Add("label", parameterToString(s.Index(i), "multi"))
+			}
+		} else {
+			localVarQueryParams.Add("label", parameterToString(t, "multi"))
+		}
+	}
 	// to determine the Content-Type header
 	localVarHTTPContentTypes := []string{}
 
@@ -381,7 +400,9 @@ func (r WriteApiApiPatchRelationTuplesRequest) Execute() (*http.Response, error)
 
 /*
  * PatchRelationTuples Patch Multiple Relation Tuples
- * Use this endpoint to patch one or more relation tuples.
+ * Use this endpoint to patch one or more relation tuples. Instead of a
+relation tuple, a delta can reference a relation tuple template of the
+configuration, which is expanded into all of its relation tuples.
  * @param ctx context.Context - for authentication, logging, cancellation, deadlines, tracing, etc. Passed from http.Request or context.Background().
  * @return WriteApiApiPatchRelationTuplesRequest
  */
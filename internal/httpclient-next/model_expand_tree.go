@@ -17,10 +17,14 @@ import (
 
 // ExpandTree struct for ExpandTree
 type ExpandTree struct {
-	Children   []ExpandTree `json:"children,omitempty"`
-	SubjectId  *string      `json:"subject_id,omitempty"`
-	SubjectSet *SubjectSet  `json:"subject_set,omitempty"`
-	Type       string       `json:"type"`
+	Children []ExpandTree `json:"children,omitempty"`
+	// The token to list the remaining children of a truncated node, using the node's subject set as query.
+	NextPageToken *string     `json:"next_page_token,omitempty"`
+	SubjectId     *string     `json:"subject_id,omitempty"`
+	SubjectSet    *SubjectSet `json:"subject_set,omitempty"`
+	// Whether the node has more children than `limit.max_expand_children`.
+	Truncated *bool  `json:"truncated,omitempty"`
+	Type      string `json:"type"`
 }
 
 // NewExpandTree instantiates a new ExpandTree object
@@ -73,6 +77,38 @@ func (o *ExpandTree) SetChildren(v []ExpandTree) {
 	o.Children = v
 }
 
+// GetNextPageToken returns the NextPageToken field value if set, zero value otherwise.
+func (o *ExpandTree) GetNextPageToken() string {
+	if o == nil || o.NextPageToken == nil {
+		var ret string
+		return ret
+	}
+	return *o.NextPageToken
+}
+
+// GetNextPageTokenOk returns a tuple with the NextPageToken field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ExpandTree) GetNextPageTokenOk() (*string, bool) {
+	if o == nil || o.NextPageToken == nil {
+		return nil, false
+	}
+	return o.NextPageToken, true
+}
+
+// HasNextPageToken returns a boolean if a field has been set.
+func (o *ExpandTree) HasNextPageToken() bool {
+	if o != nil && o.NextPageToken != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetNextPageToken gets a reference to the given string and assigns it to the NextPageToken field.
+func (o *ExpandTree) SetNextPageToken(v string) {
+	o.NextPageToken = &v
+}
+
 // GetSubjectId returns the SubjectId field value if set, zero value otherwise.
 func (o *ExpandTree) GetSubjectId() string {
 	if o == nil || o.SubjectId == nil {
@@ -137,6 +173,38 @@ func (o *ExpandTree) SetSubjectSet(v SubjectSet) {
 	o.SubjectSet = &v
 }
 
+// GetTruncated returns the Truncated field value if set, zero value otherwise.
+func (o *ExpandTree) GetTruncated() bool {
+	if o == nil || o.Truncated == nil {
+		var ret bool
+		return ret
+	}
+	return *o.Truncated
+}
+
+// GetTruncatedOk returns a tuple with the Truncated field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ExpandTree) GetTruncatedOk() (*bool, bool) {
+	if o == nil || o.Truncated == nil {
+		return nil, false
+	}
+	return o.Truncated, true
+}
+
+// HasTruncated returns a boolean if a field has been set.
+func (o *ExpandTree) HasTruncated() bool {
+	if o != nil && o.Truncated != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetTruncated gets a reference to the given bool and assigns it to the Truncated field.
+func (o *ExpandTree) SetTruncated(v bool) {
+	o.Truncated = &v
+}
+
 // GetType returns the Type field value
 func (o *ExpandTree) GetType() string {
 	if o == nil {
@@ -166,12 +234,18 @@ func (o ExpandTree) MarshalJSON() ([]byte, error) {
 	if o.Children != nil {
 		toSerialize["children"] = o.Children
 	}
+	if o.NextPageToken != nil {
+		toSerialize["next_page_token"] = o.NextPageToken
+	}
 	if o.SubjectId != nil {
 		toSerialize["subject_id"] = o.SubjectId
 	}
 	if o.SubjectSet != nil {
 		toSerialize["subject_set"] = o.SubjectSet
 	}
+	if o.Truncated != nil {
+		toSerialize["truncated"] = o.Truncated
+	}
 	if true {
 		toSerialize["type"] = o.Type
 	}
@@ -20,6 +20,8 @@ type GetRelationTuplesResponse struct {
 	// The opaque token to provide in a subsequent request to get the next page. It is the empty string iff this is the last page.
 	NextPageToken  *string                 `json:"next_page_token,omitempty"`
 	RelationTuples []InternalRelationTuple `json:"relation_tuples,omitempty"`
+	// The total number of relation tuples matching the query. It is only returned if the request set `count=true`.
+	TotalCount *int64 `json:"total_count,omitempty"`
 }
 
 // NewGetRelationTuplesResponse instantiates a new GetRelationTuplesResponse object
@@ -103,6 +105,38 @@ func (o *GetRelationTuplesResponse) SetRelationTuples(v []InternalRelationTuple)
 	o.RelationTuples = v
 }
 
+// GetTotalCount returns the TotalCount field value if set, zero value otherwise.
+func (o *GetRelationTuplesResponse) GetTotalCount() int64 {
+	if o == nil || o.TotalCount == nil {
+		var ret int64
+		return ret
+	}
+	return *o.TotalCount
+}
+
+// GetTotalCountOk returns a tuple with the TotalCount field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *GetRelationTuplesResponse) GetTotalCountOk() (*int64, bool) {
+	if o == nil || o.TotalCount == nil {
+		return nil, false
+	}
+	return o.TotalCount, true
+}
+
+// HasTotalCount returns a boolean if a field has been set.
+func (o *GetRelationTuplesResponse) HasTotalCount() bool {
+	if o != nil && o.TotalCount != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetTotalCount gets a reference to the given int64 and assigns it to the TotalCount field.
+func (o *GetRelationTuplesResponse) SetTotalCount(v int64) {
+	o.TotalCount = &v
+}
+
 func (o GetRelationTuplesResponse) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
 	if o.NextPageToken != nil {
@@ -111,6 +145,9 @@ func (o GetRelationTuplesResponse) MarshalJSON() ([]byte, error) {
 	if o.RelationTuples != nil {
 		toSerialize["relation_tuples"] = o.RelationTuples
 	}
+	if o.TotalCount != nil {
+		toSerialize["total_count"] = o.TotalCount
+	}
 	return json.Marshal(toSerialize)
 }
 
@@ -17,6 +17,14 @@ import (
 
 // InlineResponse2001 struct for InlineResponse2001
 type InlineResponse2001 struct {
+	// The version of the relation tuple APIs.
+	ApiVersion *string `json:"api_version,omitempty"`
+	// The features the instance knows of, and whether it supports them. Features missing from the map are unknown to the instance.
+	Features *map[string]bool `json:"features,omitempty"`
+	// The version of the latest applied schema migration. It is empty if the storage backend does not use SQL migrations.
+	MigrationVersion *string `json:"migration_version,omitempty"`
+	// The number of schema migrations that are not applied yet.
+	PendingMigrations *int64 `json:"pending_migrations,omitempty"`
 	// The version of Ory Keto.
 	Version string `json:"version"`
 }
@@ -39,6 +47,134 @@ func NewInlineResponse2001WithDefaults() *InlineResponse2001 {
 	return &this
 }
 
+// GetApiVersion returns the ApiVersion field value if set, zero value otherwise.
+func (o *InlineResponse2001) GetApiVersion() string {
+	if o == nil || o.ApiVersion == nil {
+		var ret string
+		return ret
+	}
+	return *o.ApiVersion
+}
+
+// GetApiVersionOk returns a tuple with the ApiVersion field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *InlineResponse2001) GetApiVersionOk() (*string, bool) {
+	if o == nil || o.ApiVersion == nil {
+		return nil, false
+	}
+	return o.ApiVersion, true
+}
+
+// HasApiVersion returns a boolean if a field has been set.
+func (o *InlineResponse2001) HasApiVersion() bool {
+	if o != nil && o.ApiVersion != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetApiVersion gets a reference to the given string and assigns it to the ApiVersion field.
+func (o *InlineResponse2001) SetApiVersion(v string) {
+	o.ApiVersion = &v
+}
+
+// GetFeatures returns the Features field value if set, zero value otherwise.
+func (o *InlineResponse2001) GetFeatures() map[string]bool {
+	if o == nil || o.Features == nil {
+		var ret map[string]bool
+		return ret
+	}
+	return *o.Features
+}
+
+// GetFeaturesOk returns a tuple with the Features field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *InlineResponse2001) GetFeaturesOk() (*map[string]bool, bool) {
+	if o == nil || o.Features == nil {
+		return nil, false
+	}
+	return o.Features, true
+}
+
+// HasFeatures returns a boolean if a field has been set.
+func (o *InlineResponse2001) HasFeatures() bool {
+	if o != nil && o.Features != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetFeatures gets a reference to the given map[string]bool and assigns it to the Features field.
+func (o *InlineResponse2001) SetFeatures(v map[string]bool) {
+	o.Features = &v
+}
+
+// GetMigrationVersion returns the MigrationVersion field value if set, zero value otherwise.
+func (o *InlineResponse2001) GetMigrationVersion() string {
+	if o == nil || o.MigrationVersion == nil {
+		var ret string
+		return ret
+	}
+	return *o.MigrationVersion
+}
+
+// GetMigrationVersionOk returns a tuple with the MigrationVersion field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *InlineResponse2001) GetMigrationVersionOk() (*string, bool) {
+	if o == nil || o.MigrationVersion == nil {
+		return nil, false
+	}
+	return o.MigrationVersion, true
+}
+
+// HasMigrationVersion returns a boolean if a field has been set.
+func (o *InlineResponse2001) HasMigrationVersion() bool {
+	if o != nil && o.MigrationVersion != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetMigrationVersion gets a reference to the given string and assigns it to the MigrationVersion field.
+func (o *InlineResponse2001) SetMigrationVersion(v string) {
+	o.MigrationVersion = &v
+}
+
+// GetPendingMigrations returns the PendingMigrations field value if set, zero value otherwise.
+func (o *InlineResponse2001) GetPendingMigrations() int64 {
+	if o == nil || o.PendingMigrations == nil {
+		var ret int64
+		return ret
+	}
+	return *o.PendingMigrations
+}
+
+// GetPendingMigrationsOk returns a tuple with the PendingMigrations field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *InlineResponse2001) GetPendingMigrationsOk() (*int64, bool) {
+	if o == nil || o.PendingMigrations == nil {
+		return nil, false
+	}
+	return o.PendingMigrations, true
+}
+
+// HasPendingMigrations returns a boolean if a field has been set.
+func (o *InlineResponse2001) HasPendingMigrations() bool {
+	if o != nil && o.PendingMigrations != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetPendingMigrations gets a reference to the given int64 and assigns it to the PendingMigrations field.
+func (o *InlineResponse2001) SetPendingMigrations(v int64) {
+	o.PendingMigrations = &v
+}
+
 // GetVersion returns the Version field value
 func (o *InlineResponse2001) GetVersion() string {
 	if o == nil {
@@ -65,6 +201,18 @@ func (o *InlineResponse2001) SetVersion(v string) {
 
 func (o InlineResponse2001) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.ApiVersion != nil {
+		toSerialize["api_version"] = o.ApiVersion
+	}
+	if o.Features != nil {
+		toSerialize["features"] = o.Features
+	}
+	if o.MigrationVersion != nil {
+		toSerialize["migration_version"] = o.MigrationVersion
+	}
+	if o.PendingMigrations != nil {
+		toSerialize["pending_migrations"] = o.PendingMigrations
+	}
 	if true {
 		toSerialize["version"] = o.Version
 	}
@@ -17,6 +17,8 @@ import (
 
 // InternalRelationTuple struct for InternalRelationTuple
 type InternalRelationTuple struct {
+	// Labels of the Relation Tuple
+	Labels *map[string]string `json:"labels,omitempty"`
 	// Namespace of the Relation Tuple
 	Namespace string `json:"namespace"`
 	// Object of the Relation Tuple
@@ -48,6 +50,38 @@ func NewInternalRelationTupleWithDefaults() *InternalRelationTuple {
 	return &this
 }
 
+// GetLabels returns the Labels field value if set, zero value otherwise.
+func (o *InternalRelationTuple) GetLabels() map[string]string {
+	if o == nil || o.Labels == nil {
+		var ret map[string]string
+		return ret
+	}
+	return *o.Labels
+}
+
+// GetLabelsOk returns a tuple with the Labels field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *InternalRelationTuple) GetLabelsOk() (*map[string]string, bool) {
+	if o == nil || o.Labels == nil {
+		return nil, false
+	}
+	return o.Labels, true
+}
+
+// HasLabels returns a boolean if a field has been set.
+func (o *InternalRelationTuple) HasLabels() bool {
+	if o != nil && o.Labels != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetLabels gets a reference to the given map[string]string and assigns it to the Labels field.
+func (o *InternalRelationTuple) SetLabels(v map[string]string) {
+	o.Labels = &v
+}
+
 // GetNamespace returns the Namespace field value
 func (o *InternalRelationTuple) GetNamespace() string {
 	if o == nil {
@@ -186,6 +220,9 @@ func (o *InternalRelationTuple) SetSubjectSet(v SubjectSet) {
 
 func (o InternalRelationTuple) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.Labels != nil {
+		toSerialize["labels"] = o.Labels
+	}
 	if true {
 		toSerialize["namespace"] = o.Namespace
 	}
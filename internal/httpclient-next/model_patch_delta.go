@@ -19,6 +19,7 @@ import (
 type PatchDelta struct {
 	Action        *string                `json:"action,omitempty"`
 	RelationTuple *InternalRelationTuple `json:"relation_tuple,omitempty"`
+	Template      *TupleTemplate         `json:"template,omitempty"`
 }
 
 // NewPatchDelta instantiates a new PatchDelta object
@@ -102,6 +103,38 @@ func (o *PatchDelta) SetRelationTuple(v InternalRelationTuple) {
 	o.RelationTuple = &v
 }
 
+// GetTemplate returns the Template field value if set, zero value otherwise.
+func (o *PatchDelta) GetTemplate() TupleTemplate {
+	if o == nil || o.Template == nil {
+		var ret TupleTemplate
+		return ret
+	}
+	return *o.Template
+}
+
+// GetTemplateOk returns a tuple with the Template field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *PatchDelta) GetTemplateOk() (*TupleTemplate, bool) {
+	if o == nil || o.Template == nil {
+		return nil, false
+	}
+	return o.Template, true
+}
+
+// HasTemplate returns a boolean if a field has been set.
+func (o *PatchDelta) HasTemplate() bool {
+	if o != nil && o.Template != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetTemplate gets a reference to the given TupleTemplate and assigns it to the Template field.
+func (o *PatchDelta) SetTemplate(v TupleTemplate) {
+	o.Template = &v
+}
+
 func (o PatchDelta) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
 	if o.Action != nil {
@@ -110,6 +143,9 @@ func (o PatchDelta) MarshalJSON() ([]byte, error) {
 	if o.RelationTuple != nil {
 		toSerialize["relation_tuple"] = o.RelationTuple
 	}
+	if o.Template != nil {
+		toSerialize["template"] = o.Template
+	}
 	return json.Marshal(toSerialize)
 }
 
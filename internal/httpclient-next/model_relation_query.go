@@ -17,6 +17,8 @@ import (
 
 // RelationQuery struct for RelationQuery
 type RelationQuery struct {
+	// Labels of the Relation Tuple  When querying, the relation tuples must have all of the labels.
+	Labels *map[string]string `json:"labels,omitempty"`
 	// Namespace of the Relation Tuple
 	Namespace *string `json:"namespace,omitempty"`
 	// Object of the Relation Tuple
@@ -45,6 +47,38 @@ func NewRelationQueryWithDefaults() *RelationQuery {
 	return &this
 }
 
+// GetLabels returns the Labels field value if set, zero value otherwise.
+func (o *RelationQuery) GetLabels() map[string]string {
+	if o == nil || o.Labels == nil {
+		var ret map[string]string
+		return ret
+	}
+	return *o.Labels
+}
+
+// GetLabelsOk returns a tuple with the Labels field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *RelationQuery) GetLabelsOk() (*map[string]string, bool) {
+	if o == nil || o.Labels == nil {
+		return nil, false
+	}
+	return o.Labels, true
+}
+
+// HasLabels returns a boolean if a field has been set.
+func (o *RelationQuery) HasLabels() bool {
+	if o != nil && o.Labels != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetLabels gets a reference to the given map[string]string and assigns it to the Labels field.
+func (o *RelationQuery) SetLabels(v map[string]string) {
+	o.Labels = &v
+}
+
 // GetNamespace returns the Namespace field value if set, zero value otherwise.
 func (o *RelationQuery) GetNamespace() string {
 	if o == nil || o.Namespace == nil {
@@ -207,6 +241,9 @@ func (o *RelationQuery) SetSubjectSet(v SubjectSet) {
 
 func (o RelationQuery) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
+	if o.Labels != nil {
+		toSerialize["labels"] = o.Labels
+	}
 	if o.Namespace != nil {
 		toSerialize["namespace"] = o.Namespace
 	}
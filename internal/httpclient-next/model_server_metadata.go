@@ -0,0 +1,256 @@
+/*
+ * Ory Keto API
+ *
+ * Documentation for all of Ory Keto's REST APIs. gRPC is documented separately.
+ *
+ * API version: 1.0.0
+ * Contact: hi@ory.sh
+ */
+
+// Code generated by OpenAPI Generator (https://openapi-generator.tech); DO NOT EDIT.
+
+package client
+
+import (
+	"encoding/json"
+)
+
+// ServerMetadata The metadata of the instance
+type ServerMetadata struct {
+	// The version of the relation tuple APIs.
+	ApiVersion *string `json:"api_version,omitempty"`
+	// The features the instance knows of, and whether it supports them. Features missing from the map are unknown to the instance.
+	Features *map[string]bool `json:"features,omitempty"`
+	// The version of the latest applied schema migration. It is empty if the storage backend does not use SQL migrations.
+	MigrationVersion *string `json:"migration_version,omitempty"`
+	// The number of schema migrations that are not applied yet.
+	PendingMigrations *int64 `json:"pending_migrations,omitempty"`
+	// The version of Ory Keto.
+	Version string `json:"version"`
+}
+
+// NewServerMetadata instantiates a new ServerMetadata object
+// This constructor will assign default values to properties that have it defined,
+// and makes sure properties required by API are set, but the set of arguments
+// will change when the set of required properties is changed
+func NewServerMetadata(version string) *ServerMetadata {
+	this := ServerMetadata{}
+	this.Version = version
+	return &this
+}
+
+// NewServerMetadataWithDefaults instantiates a new ServerMetadata object
+// This constructor will only assign default values to properties that have it defined,
+// but it doesn't guarantee that properties required by API are set
+func NewServerMetadataWithDefaults() *ServerMetadata {
+	this := ServerMetadata{}
+	return &this
+}
+
+// GetApiVersion returns the ApiVersion field value if set, zero value otherwise.
+func (o *ServerMetadata) GetApiVersion() string {
+	if o == nil || o.ApiVersion == nil {
+		var ret string
+		return ret
+	}
+	return *o.ApiVersion
+}
+
+// GetApiVersionOk returns a tuple with the ApiVersion field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ServerMetadata) GetApiVersionOk() (*string, bool) {
+	if o == nil || o.ApiVersion == nil {
+		return nil, false
+	}
+	return o.ApiVersion, true
+}
+
+// HasApiVersion returns a boolean if a field has been set.
+func (o *ServerMetadata) HasApiVersion() bool {
+	if o != nil && o.ApiVersion != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetApiVersion gets a reference to the given string and assigns it to the ApiVersion field.
+func (o *ServerMetadata) SetApiVersion(v string) {
+	o.ApiVersion = &v
+}
+
+// GetFeatures returns the Features field value if set, zero value otherwise.
+func (o *ServerMetadata) GetFeatures() map[string]bool {
+	if o == nil || o.Features == nil {
+		var ret map[string]bool
+		return ret
+	}
+	return *o.Features
+}
+
+// GetFeaturesOk returns a tuple with the Features field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ServerMetadata) GetFeaturesOk() (*map[string]bool, bool) {
+	if o == nil || o.Features == nil {
+		return nil, false
+	}
+	return o.Features, true
+}
+
+// HasFeatures returns a boolean if a field has been set.
+func (o *ServerMetadata) HasFeatures() bool {
+	if o != nil && o.Features != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetFeatures gets a reference to the given map[string]bool and assigns it to the Features field.
+func (o *ServerMetadata) SetFeatures(v map[string]bool) {
+	o.Features = &v
+}
+
+// GetMigrationVersion returns the MigrationVersion field value if set, zero value otherwise.
+func (o *ServerMetadata) GetMigrationVersion() string {
+	if o == nil || o.MigrationVersion == nil {
+		var ret string
+		return ret
+	}
+	return *o.MigrationVersion
+}
+
+// GetMigrationVersionOk returns a tuple with the MigrationVersion field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ServerMetadata) GetMigrationVersionOk() (*string, bool) {
+	if o == nil || o.MigrationVersion == nil {
+		return nil, false
+	}
+	return o.MigrationVersion, true
+}
+
+// HasMigrationVersion returns a boolean if a field has been set.
+func (o *ServerMetadata) HasMigrationVersion() bool {
+	if o != nil && o.MigrationVersion != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetMigrationVersion gets a reference to the given string and assigns it to the MigrationVersion field.
+func (o *ServerMetadata) SetMigrationVersion(v string) {
+	o.MigrationVersion = &v
+}
+
+// GetPendingMigrations returns the PendingMigrations field value if set, zero value otherwise.
+func (o *ServerMetadata) GetPendingMigrations() int64 {
+	if o == nil || o.PendingMigrations == nil {
+		var ret int64
+		return ret
+	}
+	return *o.PendingMigrations
+}
+
+// GetPendingMigrationsOk returns a tuple with the PendingMigrations field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *ServerMetadata) GetPendingMigrationsOk() (*int64, bool) {
+	if o == nil || o.PendingMigrations == nil {
+		return nil, false
+	}
+	return o.PendingMigrations, true
+}
+
+// HasPendingMigrations returns a boolean if a field has been set.
+func (o *ServerMetadata) HasPendingMigrations() bool {
+	if o != nil && o.PendingMigrations != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetPendingMigrations gets a reference to the given int64 and assigns it to the PendingMigrations field.
+func (o *ServerMetadata) SetPendingMigrations(v int64) {
+	o.PendingMigrations = &v
+}
+
+// GetVersion returns the Version field value
+func (o *ServerMetadata) GetVersion() string {
+	if o == nil {
+		var ret string
+		return ret
+	}
+
+	return o.Version
+}
+
+// GetVersionOk returns a tuple with the Version field value
+// and a boolean to check if the value has been set.
+func (o *ServerMetadata) GetVersionOk() (*string, bool) {
+	if o == nil {
+		return nil, false
+	}
+	return &o.Version, true
+}
+
+// SetVersion sets field value
+func (o *ServerMetadata) SetVersion(v string) {
+	o.Version = v
+}
+
+func (o ServerMetadata) MarshalJSON() ([]byte, error) {
+	toSerialize := map[string]interface{}{}
+	if o.ApiVersion != nil {
+		toSerialize["api_version"] = o.ApiVersion
+	}
+	if o.Features != nil {
+		toSerialize["features"] = o.Features
+	}
+	if o.MigrationVersion != nil {
+		toSerialize["migration_version"] = o.MigrationVersion
+	}
+	if o.PendingMigrations != nil {
+		toSerialize["pending_migrations"] = o.PendingMigrations
+	}
+	if true {
+		toSerialize["version"] = o.Version
+	}
+	return json.Marshal(toSerialize)
+}
+
+type NullableServerMetadata struct {
+	value *ServerMetadata
+	isSet bool
+}
+
+func (v NullableServerMetadata) Get() *ServerMetadata {
+	return v.value
+}
+
+func (v *NullableServerMetadata) Set(val *ServerMetadata) {
+	v.value = val
+	v.isSet = true
+}
+
+func (v NullableServerMetadata) IsSet() bool {
+	return v.isSet
+}
+
+func (v *NullableServerMetadata) Unset() {
+	v.value = nil
+	v.isSet = false
+}
+
+func NewNullableServerMetadata(val *ServerMetadata) *NullableServerMetadata {
+	return &NullableServerMetadata{value: val, isSet: true}
+}
+
+func (v NullableServerMetadata) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.value)
+}
+
+func (v *NullableServerMetadata) UnmarshalJSON(src []byte) error {
+	v.isSet = true
+	return json.Unmarshal(src, &v.value)
+}
@@ -0,0 +1,145 @@
+/*
+ * Ory Keto API
+ *
+ * Documentation for all of Ory Keto's REST APIs. gRPC is documented separately.
+ *
+ * API version: 1.0.0
+ * Contact: hi@ory.sh
+ */
+
+// Code generated by OpenAPI Generator (https://openapi-generator.tech); DO NOT EDIT.
+
+package client
+
+import (
+	"encoding/json"
+)
+
+// TupleTemplate TupleTemplate references a relation tuple template of the configuration.
+type TupleTemplate struct {
+	// Name of the template
+	Name string `json:"name"`
+	// Parameters of the template  The values of all parameters of the template.
+	Parameters *map[string]string `json:"parameters,omitempty"`
+}
+
+// NewTupleTemplate instantiates a new TupleTemplate object
+// This constructor will assign default values to properties that have it defined,
+// and makes sure properties required by API are set, but the set of arguments
+// will change when the set of required properties is changed
+func NewTupleTemplate(name string) *TupleTemplate {
+	this := TupleTemplate{}
+	this.Name = name
+	return &this
+}
+
+// NewTupleTemplateWithDefaults instantiates a new TupleTemplate object
+// This constructor will only assign default values to properties that have it defined,
+// but it doesn't guarantee that properties required by API are set
+func NewTupleTemplateWithDefaults() *TupleTemplate {
+	this := TupleTemplate{}
+	return &this
+}
+
+// GetName returns the Name field value
+func (o *TupleTemplate) GetName() string {
+	if o == nil {
+		var ret string
+		return ret
+	}
+
+	return o.Name
+}
+
+// GetNameOk returns a tuple with the Name field value
+// and a boolean to check if the value has been set.
+func (o *TupleTemplate) GetNameOk() (*string, bool) {
+	if o == nil {
+		return nil, false
+	}
+	return &o.Name, true
+}
+
+// SetName sets field value
+func (o *TupleTemplate) SetName(v string) {
+	o.Name = v
+}
+
+// GetParameters returns the Parameters field value if set, zero value otherwise.
+func (o *TupleTemplate) GetParameters() map[string]string {
+	if o == nil || o.Parameters == nil {
+		var ret map[string]string
+		return ret
+	}
+	return *o.Parameters
+}
+
+// GetParametersOk returns a tuple with the Parameters field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *TupleTemplate) GetParametersOk() (*map[string]string, bool) {
+	if o == nil || o.Parameters == nil {
+		return nil, false
+	}
+	return o.Parameters, true
+}
+
+// HasParameters returns a boolean if a field has been set.
+func (o *TupleTemplate) HasParameters() bool {
+	if o != nil && o.Parameters != nil {
+		return true
+	}
+
+	return false
+}
+
+// SetParameters gets a reference to the given map[string]string and assigns it to the Parameters field.
+func (o *TupleTemplate) SetParameters(v map[string]string) {
+	o.Parameters = &v
+}
+
+func (o TupleTemplate) MarshalJSON() ([]byte, error) {
+	toSerialize := map[string]interface{}{}
+	if true {
+		toSerialize["name"] = o.Name
+	}
+	if o.Parameters != nil {
+		toSerialize["parameters"] = o.Parameters
+	}
+	return json.Marshal(toSerialize)
+}
+
+type NullableTupleTemplate struct {
+	value *TupleTemplate
+	isSet bool
+}
+
+func (v NullableTupleTemplate) Get() *TupleTemplate {
+	return v.value
+}
+
+func (v *NullableTupleTemplate) Set(val *TupleTemplate) {
+	v.value = val
+	v.isSet = true
+}
+
+func (v NullableTupleTemplate) IsSet() bool {
+	return v.isSet
+}
+
+func (v *NullableTupleTemplate) Unset() {
+	v.value = nil
+	v.isSet = false
+}
+
+func NewNullableTupleTemplate(val *TupleTemplate) *NullableTupleTemplate {
+	return &NullableTupleTemplate{value: val, isSet: true}
+}
+
+func (v NullableTupleTemplate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.value)
+}
+
+func (v *NullableTupleTemplate) UnmarshalJSON(src []byte) error {
+	v.isSet = true
+	return json.Unmarshal(src, &v.value)
+}
@@ -60,6 +60,18 @@ func NewGetRelationTuplesParamsWithHTTPClient(client *http.Client) *GetRelationT
 */
 type GetRelationTuplesParams struct {
 
+	/* Count.
+
+	   Return the total number of matching relation tuples
+	*/
+	Count *bool
+
+	/* Label.
+
+	   Labels of the Relation Tuple, formatted as "key=value"
+	*/
+	Label []string
+
 	/* Namespace.
 
 	   Namespace of the Relation Tuple
@@ -72,6 +84,22 @@ type GetRelationTuplesParams struct {
 	*/
 	Object *string
 
+	/* Order.
+
+	   Sort direction
+
+	   Default: "asc"
+	*/
+	Order *string
+
+	/* OrderBy.
+
+	   Sort the relation tuples by this field
+
+	   Default: "commit_time"
+	*/
+	OrderBy *string
+
 	// PageSize.
 	//
 	// Format: int64
@@ -127,7 +155,21 @@ func (o *GetRelationTuplesParams) WithDefaults() *GetRelationTuplesParams {
 //
 // All values with no default are reset to their zero value.
 func (o *GetRelationTuplesParams) SetDefaults() {
-	// no default values defined for this parameter
+	var (
+		orderDefault = string("asc")
+
+		orderByDefault = string("commit_time")
+	)
+
+	val := GetRelationTuplesParams{
+		Order:   &orderDefault,
+		OrderBy: &orderByDefault,
+	}
+
+	val.timeout = o.timeout
+	val.Context = o.Context
+	val.HTTPClient = o.HTTPClient
+	*o = val
 }
 
 // WithTimeout adds the timeout to the get relation tuples params
@@ -163,6 +205,28 @@ func (o *GetRelationTuplesParams) SetHTTPClient(client *http.Client) {
 	o.HTTPClient = client
 }
 
+// WithCount adds the count to the get relation tuples params
+func (o *GetRelationTuplesParams) WithCount(count *bool) *GetRelationTuplesParams {
+	o.SetCount(count)
+	return o
+}
+
+// SetCount adds the count to the get relation tuples params
+func (o *GetRelationTuplesParams) SetCount(count *bool) {
+	o.Count = count
+}
+
+// WithLabel adds the label to the get relation tuples params
+func (o *GetRelationTuplesParams) WithLabel(label []string) *GetRelationTuplesParams {
+	o.SetLabel(label)
+	return o
+}
+
+// SetLabel adds the label to the get relation tuples params
+func (o *GetRelationTuplesParams) SetLabel(label []string) {
+	o.Label = label
+}
+
 // WithNamespace adds the namespace to the get relation tuples params
 func (o *GetRelationTuplesParams) WithNamespace(namespace *string) *GetRelationTuplesParams {
 	o.SetNamespace(namespace)
@@ -185,6 +249,28 @@ func (o *GetRelationTuplesParams) SetObject(object *string) {
 	o.Object = object
 }
 
+// WithOrder adds the order to the get relation tuples params
+func (o *GetRelationTuplesParams) WithOrder(order *string) *GetRelationTuplesParams {
+	o.SetOrder(order)
+	return o
+}
+
+// SetOrder adds the order to the get relation tuples params
+func (o *GetRelationTuplesParams) SetOrder(order *string) {
+	o.Order = order
+}
+
+// WithOrderBy adds the orderBy to the get relation tuples params
+func (o *GetRelationTuplesParams) WithOrderBy(orderBy *string) *GetRelationTuplesParams {
+	o.SetOrderBy(orderBy)
+	return o
+}
+
+// SetOrderBy adds the orderBy to the get relation tuples params
+func (o *GetRelationTuplesParams) SetOrderBy(orderBy *string) {
+	o.OrderBy = orderBy
+}
+
 // WithPageSize adds the pageSize to the get relation tuples params
 func (o *GetRelationTuplesParams) WithPageSize(pageSize *int64) *GetRelationTuplesParams {
 	o.SetPageSize(pageSize)
@@ -270,6 +356,34 @@ func (o *GetRelationTuplesParams) WriteToRequest(r runtime.ClientRequest, reg st
 	}
 	var res []error
 
+	if o.Count != nil {
+
+		// query param count
+		var qrCount bool
+
+		if o.Count != nil {
+			qrCount = *o.Count
+		}
+		qCount := swag.FormatBool(qrCount)
+		if qCount != "" {
+
+			if err := r.SetQueryParam("count", qCount); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.Label != nil {
+
+		// binding items for label
+		joinedLabel := o.bindParamLabel(reg)
+
+		// query array param label
+		if err := r.SetQueryParam("label", joinedLabel...); err != nil {
+			return err
+		}
+	}
+
 	if o.Namespace != nil {
 
 		// query param namespace
@@ -304,6 +418,40 @@ func (o *GetRelationTuplesParams) WriteToRequest(r runtime.ClientRequest, reg st
 		}
 	}
 
+	if o.Order != nil {
+
+		// query param order
+		var qrOrder string
+
+		if o.Order != nil {
+			qrOrder = *o.Order
+		}
+		qOrder := qrOrder
+		if qOrder != "" {
+
+			if err := r.SetQueryParam("order", qOrder); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.OrderBy != nil {
+
+		// query param order_by
+		var qrOrderBy string
+
+		if o.OrderBy != nil {
+			qrOrderBy = *o.OrderBy
+		}
+		qOrderBy := qrOrderBy
+		if qOrderBy != "" {
+
+			if err := r.SetQueryParam("order_by", qOrderBy); err != nil {
+				return err
+			}
+		}
+	}
+
 	if o.PageSize != nil {
 
 		// query param page_size
@@ -428,3 +576,20 @@ func (o *GetRelationTuplesParams) WriteToRequest(r runtime.ClientRequest, reg st
 	}
 	return nil
 }
+
+// bindParamGetRelationTuples binds the parameter label
+func (o *GetRelationTuplesParams) bindParamLabel(formats strfmt.Registry) []string {
+	labelIR := o.Label
+
+	var labelIC []string
+	for _, labelIIR := range labelIR { // explode []string
+
+		labelIIV := labelIIR // string as string
+		labelIC = append(labelIC, labelIIV)
+	}
+
+	// items.CollectionFormat: "multi"
+	labelIS := swag.JoinByFormat(labelIC, "multi")
+
+	return labelIS
+}
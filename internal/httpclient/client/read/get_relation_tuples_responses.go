@@ -29,6 +29,12 @@ func (o *GetRelationTuplesReader) ReadResponse(response runtime.ClientResponse,
 			return nil, err
 		}
 		return result, nil
+	case 304:
+		result := NewGetRelationTuplesNotModified()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
 	case 404:
 		result := NewGetRelationTuplesNotFound()
 		if err := result.readResponse(response, consumer, o.formats); err != nil {
@@ -78,6 +84,27 @@ func (o *GetRelationTuplesOK) readResponse(response runtime.ClientResponse, cons
 	return nil
 }
 
+// NewGetRelationTuplesNotModified creates a GetRelationTuplesNotModified with default headers values
+func NewGetRelationTuplesNotModified() *GetRelationTuplesNotModified {
+	return &GetRelationTuplesNotModified{}
+}
+
+/* GetRelationTuplesNotModified describes a response with status code 304, with default header values.
+
+Empty responses are sent when, for example, resources are deleted. The HTTP status code for empty responses is typically 201.
+*/
+type GetRelationTuplesNotModified struct {
+}
+
+func (o *GetRelationTuplesNotModified) Error() string {
+	return fmt.Sprintf("[GET /relation-tuples][%d] getRelationTuplesNotModified ", 304)
+}
+
+func (o *GetRelationTuplesNotModified) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	return nil
+}
+
 // NewGetRelationTuplesNotFound creates a GetRelationTuplesNotFound with default headers values
 func NewGetRelationTuplesNotFound() *GetRelationTuplesNotFound {
 	return &GetRelationTuplesNotFound{}
@@ -29,6 +29,12 @@ func (o *GetVersionReader) ReadResponse(response runtime.ClientResponse, consume
 			return nil, err
 		}
 		return result, nil
+	case 500:
+		result := NewGetVersionInternalServerError()
+		if err := result.readResponse(response, consumer, o.formats); err != nil {
+			return nil, err
+		}
+		return nil, result
 	default:
 		return nil, runtime.NewAPIError("response status code does not match any response statuses defined for this endpoint in the swagger spec", response, response.Code())
 	}
@@ -41,22 +47,54 @@ func NewGetVersionOK() *GetVersionOK {
 
 /* GetVersionOK describes a response with status code 200, with default header values.
 
-version
+serverMetadata
 */
 type GetVersionOK struct {
-	Payload *models.Version
+	Payload *models.ServerMetadata
 }
 
 func (o *GetVersionOK) Error() string {
 	return fmt.Sprintf("[GET /version][%d] getVersionOK  %+v", 200, o.Payload)
 }
-func (o *GetVersionOK) GetPayload() *models.Version {
+func (o *GetVersionOK) GetPayload() *models.ServerMetadata {
 	return o.Payload
 }
 
 func (o *GetVersionOK) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
 
-	o.Payload = new(models.Version)
+	o.Payload = new(models.ServerMetadata)
+
+	// response payload
+	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// NewGetVersionInternalServerError creates a GetVersionInternalServerError with default headers values
+func NewGetVersionInternalServerError() *GetVersionInternalServerError {
+	return &GetVersionInternalServerError{}
+}
+
+/* GetVersionInternalServerError describes a response with status code 500, with default header values.
+
+genericError
+*/
+type GetVersionInternalServerError struct {
+	Payload *models.GenericError
+}
+
+func (o *GetVersionInternalServerError) Error() string {
+	return fmt.Sprintf("[GET /version][%d] getVersionInternalServerError  %+v", 500, o.Payload)
+}
+func (o *GetVersionInternalServerError) GetPayload() *models.GenericError {
+	return o.Payload
+}
+
+func (o *GetVersionInternalServerError) readResponse(response runtime.ClientResponse, consumer runtime.Consumer, formats strfmt.Registry) error {
+
+	o.Payload = new(models.GenericError)
 
 	// response payload
 	if err := consumer.Consume(response.Body(), o.Payload); err != nil && err != io.EOF {
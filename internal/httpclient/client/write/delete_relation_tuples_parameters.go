@@ -14,6 +14,7 @@ import (
 	"github.com/go-openapi/runtime"
 	cr "github.com/go-openapi/runtime/client"
 	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
 )
 
 // NewDeleteRelationTuplesParams creates a new DeleteRelationTuplesParams object,
@@ -59,6 +60,12 @@ func NewDeleteRelationTuplesParamsWithHTTPClient(client *http.Client) *DeleteRel
 */
 type DeleteRelationTuplesParams struct {
 
+	/* Label.
+
+	   Labels of the Relation Tuple, formatted as "key=value"
+	*/
+	Label []string
+
 	/* Namespace.
 
 	   Namespace of the Relation Tuple
@@ -154,6 +161,17 @@ func (o *DeleteRelationTuplesParams) SetHTTPClient(client *http.Client) {
 	o.HTTPClient = client
 }
 
+// WithLabel adds the label to the delete relation tuples params
+func (o *DeleteRelationTuplesParams) WithLabel(label []string) *DeleteRelationTuplesParams {
+	o.SetLabel(label)
+	return o
+}
+
+// SetLabel adds the label to the delete relation tuples params
+func (o *DeleteRelationTuplesParams) SetLabel(label []string) {
+	o.Label = label
+}
+
 // WithNamespace adds the namespace to the delete relation tuples params
 func (o *DeleteRelationTuplesParams) WithNamespace(namespace *string) *DeleteRelationTuplesParams {
 	o.SetNamespace(namespace)
@@ -239,6 +257,17 @@ func (o *DeleteRelationTuplesParams) WriteToRequest(r runtime.ClientRequest, reg
 	}
 	var res []error
 
+	if o.Label != nil {
+
+		// binding items for label
+		joinedLabel := o.bindParamLabel(reg)
+
+		// query array param label
+		if err := r.SetQueryParam("label", joinedLabel...); err != nil {
+			return err
+		}
+	}
+
 	if o.Namespace != nil {
 
 		// query param namespace
@@ -363,3 +392,20 @@ func (o *DeleteRelationTuplesParams) WriteToRequest(r runtime.ClientRequest, reg
 	}
 	return nil
 }
+
+// bindParamDeleteRelationTuples binds the parameter label
+func (o *DeleteRelationTuplesParams) bindParamLabel(formats strfmt.Registry) []string {
+	labelIR := o.Label
+
+	var labelIC []string
+	for _, labelIIR := range labelIR { // explode []string
+
+		labelIIV := labelIIR // string as string
+		labelIC = append(labelIC, labelIIV)
+	}
+
+	// items.CollectionFormat: "multi"
+	labelIS := swag.JoinByFormat(labelIC, "multi")
+
+	return labelIS
+}
@@ -122,7 +122,9 @@ func (a *Client) DeleteRelationTuples(params *DeleteRelationTuplesParams, opts .
 /*
   PatchRelationTuples patches multiple relation tuples
 
-  Use this endpoint to patch one or more relation tuples.
+  Use this endpoint to patch one or more relation tuples. Instead of a
+relation tuple, a delta can reference a relation tuple template of the
+configuration, which is expanded into all of its relation tuples.
 */
 func (a *Client) PatchRelationTuples(params *PatchRelationTuplesParams, opts ...ClientOption) (*PatchRelationTuplesNoContent, error) {
 	// TODO: Validate the params before sending
@@ -24,12 +24,19 @@ type ExpandTree struct {
 	// children
 	Children []*ExpandTree `json:"children"`
 
+	// The token to list the remaining children of a truncated node, using the
+	// node's subject set as query.
+	NextPageToken string `json:"next_page_token,omitempty"`
+
 	// subject id
 	SubjectID string `json:"subject_id,omitempty"`
 
 	// subject set
 	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
 
+	// Whether the node has more children than `limit.max_expand_children`.
+	Truncated bool `json:"truncated,omitempty"`
+
 	// type
 	// Required: true
 	// Enum: [union exclusion intersection leaf]
@@ -26,6 +26,10 @@ type GetRelationTuplesResponse struct {
 
 	// relation tuples
 	RelationTuples []*InternalRelationTuple `json:"relation_tuples"`
+
+	// The total number of relation tuples matching the query. It is only
+	// returned if the request set `count=true`.
+	TotalCount int64 `json:"total_count,omitempty"`
 }
 
 // Validate validates this get relation tuples response
@@ -19,6 +19,9 @@ import (
 // swagger:model InternalRelationTuple
 type InternalRelationTuple struct {
 
+	// Labels of the Relation Tuple
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Namespace of the Relation Tuple
 	// Required: true
 	Namespace *string `json:"namespace"`
@@ -26,6 +26,9 @@ type PatchDelta struct {
 
 	// relation tuple
 	RelationTuple *InternalRelationTuple `json:"relation_tuple,omitempty"`
+
+	// template
+	Template *TupleTemplate `json:"template,omitempty"`
 }
 
 // Validate validates this patch delta
@@ -40,6 +43,10 @@ func (m *PatchDelta) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateTemplate(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -105,6 +112,23 @@ func (m *PatchDelta) validateRelationTuple(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *PatchDelta) validateTemplate(formats strfmt.Registry) error {
+	if swag.IsZero(m.Template) { // not required
+		return nil
+	}
+
+	if m.Template != nil {
+		if err := m.Template.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("template")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // ContextValidate validate this patch delta based on the context it is used
 func (m *PatchDelta) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
@@ -113,6 +137,10 @@ func (m *PatchDelta) ContextValidate(ctx context.Context, formats strfmt.Registr
 		res = append(res, err)
 	}
 
+	if err := m.contextValidateTemplate(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -133,6 +161,20 @@ func (m *PatchDelta) contextValidateRelationTuple(ctx context.Context, formats s
 	return nil
 }
 
+func (m *PatchDelta) contextValidateTemplate(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Template != nil {
+		if err := m.Template.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("template")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *PatchDelta) MarshalBinary() ([]byte, error) {
 	if m == nil {
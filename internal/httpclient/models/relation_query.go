@@ -18,6 +18,11 @@ import (
 // swagger:model RelationQuery
 type RelationQuery struct {
 
+	// Labels of the Relation Tuple
+	//
+	// When querying, the relation tuples must have all of the labels.
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Namespace of the Relation Tuple
 	Namespace string `json:"namespace,omitempty"`
 
@@ -0,0 +1,85 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// ServerMetadata The metadata of the instance
+//
+// swagger:model serverMetadata
+type ServerMetadata struct {
+
+	// The version of the relation tuple APIs.
+	APIVersion string `json:"api_version,omitempty"`
+
+	// The features the instance knows of, and whether it supports them.
+	// Features missing from the map are unknown to the instance.
+	Features map[string]bool `json:"features,omitempty"`
+
+	// The version of the latest applied schema migration. It is empty if
+	// the storage backend does not use SQL migrations.
+	MigrationVersion string `json:"migration_version,omitempty"`
+
+	// The number of schema migrations that are not applied yet.
+	PendingMigrations int64 `json:"pending_migrations,omitempty"`
+
+	// The version of Ory Keto.
+	// Required: true
+	Version *string `json:"version"`
+}
+
+// Validate validates this server metadata
+func (m *ServerMetadata) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateVersion(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *ServerMetadata) validateVersion(formats strfmt.Registry) error {
+
+	if err := validate.Required("version", "body", m.Version); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this server metadata based on context it is used
+func (m *ServerMetadata) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *ServerMetadata) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *ServerMetadata) UnmarshalBinary(b []byte) error {
+	var res ServerMetadata
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
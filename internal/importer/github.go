@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type (
+	// GitHub imports the members, teams, and repository collaborators of a
+	// GitHub organization:
+	//
+	//	organizations:<org>#<admin|member>@<login>
+	//	teams:<org>/<team>#<maintainer|member>@<login>
+	//	teams:<org>/<parent>#member@teams:<org>/<team>#member
+	//	repositories:<org>/<repo>#<role>@<login>
+	//	repositories:<org>/<repo>#<role>@teams:<org>/<team>#member
+	//
+	// The role is one of read, triage, write, maintain, admin, or the name of a
+	// custom repository role. Only direct collaborators are imported, access
+	// through teams is expressed by the subject sets.
+	GitHub struct {
+		Namespaces Namespaces
+		c          *client
+	}
+	githubUser struct {
+		Login string `json:"login"`
+	}
+	githubTeam struct {
+		Slug   string      `json:"slug"`
+		Parent *githubTeam `json:"parent"`
+	}
+	githubPermissions struct {
+		Admin    bool `json:"admin"`
+		Maintain bool `json:"maintain"`
+		Push     bool `json:"push"`
+		Triage   bool `json:"triage"`
+		Pull     bool `json:"pull"`
+	}
+	githubRepository struct {
+		Name        string             `json:"name"`
+		RoleName    string             `json:"role_name"`
+		Permissions *githubPermissions `json:"permissions"`
+	}
+	githubCollaborator struct {
+		Login       string             `json:"login"`
+		RoleName    string             `json:"role_name"`
+		Permissions *githubPermissions `json:"permissions"`
+	}
+)
+
+const GitHubDefaultURL = "https://api.github.com"
+
+var _ Importer = (*GitHub)(nil)
+
+// NewGitHub creates a GitHub importer. The token needs read access to the
+// members, teams, and repositories of the organization. Pass the API URL of
+// your GitHub Enterprise Server installation as baseURL, or leave it empty for
+// github.com.
+func NewGitHub(hc *http.Client, baseURL, token string, n Namespaces) *GitHub {
+	if baseURL == "" {
+		baseURL = GitHubDefaultURL
+	}
+	h := http.Header{}
+	h.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+	return &GitHub{
+		Namespaces: n,
+		c:          &client{http: hc, baseURL: baseURL, header: h},
+	}
+}
+
+func (g *GitHub) Import(ctx context.Context, org string) ([]*relationtuple.InternalRelationTuple, error) {
+	var tuples []*relationtuple.InternalRelationTuple
+
+	for _, role := range []string{"admin", "member"} {
+		var members []githubUser
+		if err := g.c.getAll(ctx, pathEscape("orgs", org, "members"), url.Values{"role": {role}}, &members); err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			tuples = append(tuples, newTuple(g.Namespaces.Organizations, org, role, &relationtuple.SubjectID{ID: m.Login}))
+		}
+	}
+
+	var teams []githubTeam
+	if err := g.c.getAll(ctx, pathEscape("orgs", org, "teams"), nil, &teams); err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		tt, err := g.importTeam(ctx, org, t)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tt...)
+	}
+
+	var repos []githubRepository
+	if err := g.c.getAll(ctx, pathEscape("orgs", org, "repos"), url.Values{"type": {"all"}}, &repos); err != nil {
+		return nil, err
+	}
+	for _, r := range repos {
+		var collaborators []githubCollaborator
+		if err := g.c.getAll(ctx, pathEscape("repos", org, r.Name, "collaborators"), url.Values{"affiliation": {"direct"}}, &collaborators); err != nil {
+			return nil, err
+		}
+		for _, c := range collaborators {
+			if role := githubRole(c.RoleName, c.Permissions); role != "" {
+				tuples = append(tuples, newTuple(g.Namespaces.Repositories, org+"/"+r.Name, role, &relationtuple.SubjectID{ID: c.Login}))
+			}
+		}
+	}
+
+	return tuples, nil
+}
+
+func (g *GitHub) importTeam(ctx context.Context, org string, t githubTeam) ([]*relationtuple.InternalRelationTuple, error) {
+	team := org + "/" + t.Slug
+	members := &relationtuple.SubjectSet{Namespace: g.Namespaces.Teams, Object: team, Relation: "member"}
+
+	var tuples []*relationtuple.InternalRelationTuple
+	if t.Parent != nil {
+		tuples = append(tuples, newTuple(g.Namespaces.Teams, org+"/"+t.Parent.Slug, "member", members))
+	}
+
+	for _, role := range []string{"maintainer", "member"} {
+		var users []githubUser
+		if err := g.c.getAll(ctx, pathEscape("orgs", org, "teams", t.Slug, "members"), url.Values{"role": {role}}, &users); err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			tuples = append(tuples, newTuple(g.Namespaces.Teams, team, role, &relationtuple.SubjectID{ID: u.Login}))
+		}
+	}
+
+	var repos []githubRepository
+	if err := g.c.getAll(ctx, pathEscape("orgs", org, "teams", t.Slug, "repos"), nil, &repos); err != nil {
+		return nil, err
+	}
+	for _, r := range repos {
+		if role := githubRole(r.RoleName, r.Permissions); role != "" {
+			tuples = append(tuples, newTuple(g.Namespaces.Repositories, org+"/"+r.Name, role, members))
+		}
+	}
+	return tuples, nil
+}
+
+// githubRole returns the role name, falling back to the highest permission for
+// older GitHub Enterprise Server versions that do not return role names.
+func githubRole(roleName string, p *githubPermissions) string {
+	switch {
+	case roleName != "":
+		return roleName
+	case p == nil:
+		return ""
+	case p.Admin:
+		return "admin"
+	case p.Maintain:
+		return "maintain"
+	case p.Push:
+		return "write"
+	case p.Triage:
+		return "triage"
+	case p.Pull:
+		return "read"
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type (
+	// GitLab imports the members of a GitLab group, its subgroups, and their
+	// projects:
+	//
+	//	organizations:<group>#<role>@<username>
+	//	organizations:<subgroup>#<role>@organizations:<group>#<role>
+	//	repositories:<project>#<role>@<username>
+	//
+	// Groups and projects are identified by their full path. The role is one of
+	// minimal_access, guest, reporter, developer, maintainer, or owner. Project
+	// members include inherited members and members of groups the project is
+	// shared with, as GitLab reports them.
+	GitLab struct {
+		Namespaces Namespaces
+		c          *client
+	}
+	gitlabGroup struct {
+		ID       int    `json:"id"`
+		FullPath string `json:"full_path"`
+		ParentID *int   `json:"parent_id"`
+	}
+	gitlabProject struct {
+		ID                int    `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	}
+	gitlabMember struct {
+		Username    string `json:"username"`
+		AccessLevel int    `json:"access_level"`
+	}
+)
+
+const GitLabDefaultURL = "https://gitlab.com/api/v4"
+
+var (
+	_ Importer = (*GitLab)(nil)
+
+	// gitlabRoles maps GitLab's access levels to relations.
+	gitlabRoles = map[int]string{
+		5:  "minimal_access",
+		10: "guest",
+		20: "reporter",
+		30: "developer",
+		40: "maintainer",
+		50: "owner",
+	}
+	gitlabAccessLevels = []int{5, 10, 20, 30, 40, 50}
+)
+
+// NewGitLab creates a GitLab importer. The token needs the read_api scope.
+// Pass the API URL of your self-managed installation as baseURL, or leave it
+// empty for gitlab.com.
+func NewGitLab(hc *http.Client, baseURL, token string, n Namespaces) *GitLab {
+	if baseURL == "" {
+		baseURL = GitLabDefaultURL
+	}
+	h := http.Header{}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+	return &GitLab{
+		Namespaces: n,
+		c:          &client{http: hc, baseURL: baseURL, header: h},
+	}
+}
+
+func (g *GitLab) Import(ctx context.Context, group string) ([]*relationtuple.InternalRelationTuple, error) {
+	var root gitlabGroup
+	if err := g.c.get(ctx, pathEscape("groups", group), &root); err != nil {
+		return nil, err
+	}
+	var descendants []gitlabGroup
+	if err := g.c.getAll(ctx, pathEscape("groups", group, "descendant_groups"), nil, &descendants); err != nil {
+		return nil, err
+	}
+
+	groups := append([]gitlabGroup{root}, descendants...)
+	paths := make(map[int]string, len(groups))
+	for _, gr := range groups {
+		paths[gr.ID] = gr.FullPath
+	}
+
+	var tuples []*relationtuple.InternalRelationTuple
+	for _, gr := range groups {
+		if gr.ParentID != nil {
+			if parent, ok := paths[*gr.ParentID]; ok {
+				for _, level := range gitlabAccessLevels {
+					role := gitlabRoles[level]
+					tuples = append(tuples, newTuple(g.Namespaces.Organizations, gr.FullPath, role, &relationtuple.SubjectSet{
+						Namespace: g.Namespaces.Organizations,
+						Object:    parent,
+						Relation:  role,
+					}))
+				}
+			}
+		}
+
+		var members []gitlabMember
+		if err := g.c.getAll(ctx, pathEscape("groups", strconv.Itoa(gr.ID), "members"), nil, &members); err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, g.memberTuples(gr.FullPath, g.Namespaces.Organizations, members)...)
+	}
+
+	var projects []gitlabProject
+	if err := g.c.getAll(ctx, pathEscape("groups", group, "projects"), url.Values{"include_subgroups": {"true"}}, &projects); err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		var members []gitlabMember
+		if err := g.c.getAll(ctx, pathEscape("projects", strconv.Itoa(p.ID), "members", "all"), nil, &members); err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, g.memberTuples(p.PathWithNamespace, g.Namespaces.Repositories, members)...)
+	}
+
+	return tuples, nil
+}
+
+func (g *GitLab) memberTuples(object, namespace string, members []gitlabMember) []*relationtuple.InternalRelationTuple {
+	tuples := make([]*relationtuple.InternalRelationTuple, 0, len(members))
+	for _, m := range members {
+		if role, ok := gitlabRoles[m.AccessLevel]; ok {
+			tuples = append(tuples, newTuple(namespace, object, role, &relationtuple.SubjectID{ID: m.Username}))
+		}
+	}
+	return tuples
+}
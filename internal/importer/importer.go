@@ -0,0 +1,132 @@
+// Package importer reads the permissions of source code hosting platforms
+// through their APIs and maps them to relation tuples.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type (
+	// Namespaces are the namespaces the imported relation tuples are written
+	// to.
+	Namespaces struct {
+		// Organizations contains GitHub organizations and GitLab groups.
+		Organizations string
+		// Teams contains GitHub teams.
+		Teams string
+		// Repositories contains GitHub repositories and GitLab projects.
+		Repositories string
+	}
+	// Importer lists the permissions of an organization.
+	Importer interface {
+		Import(ctx context.Context, organization string) ([]*relationtuple.InternalRelationTuple, error)
+	}
+	// client is a minimal REST client for paginated JSON APIs.
+	client struct {
+		http    *http.Client
+		baseURL string
+		header  http.Header
+	}
+)
+
+var DefaultNamespaces = Namespaces{
+	Organizations: "organizations",
+	Teams:         "teams",
+	Repositories:  "repositories",
+}
+
+var nextLinkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// getAll fetches all pages of the resource and appends the items of every page
+// to the slice pointed to by res. Both GitHub and GitLab announce the next page
+// in the Link header.
+func (c *client) getAll(ctx context.Context, path string, query url.Values, res interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", "100")
+	next := c.url(path) + "?" + query.Encode()
+
+	var all []json.RawMessage
+	for next != "" {
+		var page []json.RawMessage
+		link, err := c.do(ctx, next, &page)
+		if err != nil {
+			return err
+		}
+		all = append(all, page...)
+
+		next = ""
+		if m := nextLinkRegex.FindStringSubmatch(link); m != nil {
+			next = m[1]
+		}
+	}
+
+	raw, err := json.Marshal(all)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(raw, res))
+}
+
+// get fetches a single resource.
+func (c *client) get(ctx context.Context, path string, res interface{}) error {
+	_, err := c.do(ctx, c.url(path), res)
+	return err
+}
+
+func (c *client) url(path string) string {
+	return strings.TrimSuffix(c.baseURL, "/") + path
+}
+
+func (c *client) do(ctx context.Context, u string, res interface{}) (link string, _ error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", errors.Errorf("GET %s: unexpected status code %d: %s", req.URL.Redacted(), resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return "", errors.Wrapf(err, "GET %s: could not decode the response", req.URL.Redacted())
+	}
+	return resp.Header.Get("Link"), nil
+}
+
+func newTuple(namespace, object, relation string, subject relationtuple.Subject) *relationtuple.InternalRelationTuple {
+	return &relationtuple.InternalRelationTuple{
+		Namespace: namespace,
+		Object:    object,
+		Relation:  relation,
+		Subject:   subject,
+	}
+}
+
+func pathEscape(parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = url.PathEscape(p)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
@@ -0,0 +1,141 @@
+package importer_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/importer"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// fakeAPI serves the JSON responses by request URI. Responses with several
+// pages are split at the page boundary announced in the Link header.
+func fakeAPI(t *testing.T, token string, responses map[string][]interface{}) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer "+token, r.Header.Get("Authorization"))
+
+		pages, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(pages) > 1 {
+			w.Header().Set("Link", `<`+ts.URL+r.URL.RequestURI()+`&page=2>; rel="next"`)
+			responses[r.URL.RequestURI()+"&page=2"] = pages[1:]
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(pages[0]))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func user(name string) interface{} {
+	return map[string]interface{}{"login": name, "username": name}
+}
+
+func TestGitHub(t *testing.T) {
+	ts := fakeAPI(t, "gh-token", map[string][]interface{}{
+		"/orgs/acme/members?per_page=100&role=admin":  {[]interface{}{user("alice")}},
+		"/orgs/acme/members?per_page=100&role=member": {[]interface{}{user("bob")}, []interface{}{user("carol")}},
+		"/orgs/acme/teams?per_page=100": {[]interface{}{
+			map[string]interface{}{"slug": "eng"},
+			map[string]interface{}{"slug": "backend", "parent": map[string]interface{}{"slug": "eng"}},
+		}},
+		"/orgs/acme/teams/eng/members?per_page=100&role=maintainer":     {[]interface{}{user("alice")}},
+		"/orgs/acme/teams/eng/members?per_page=100&role=member":         {[]interface{}{}},
+		"/orgs/acme/teams/eng/repos?per_page=100":                       {[]interface{}{map[string]interface{}{"name": "api", "role_name": "write"}}},
+		"/orgs/acme/teams/backend/members?per_page=100&role=maintainer": {[]interface{}{}},
+		"/orgs/acme/teams/backend/members?per_page=100&role=member":     {[]interface{}{user("bob")}},
+		"/orgs/acme/teams/backend/repos?per_page=100": {[]interface{}{
+			map[string]interface{}{"name": "api", "permissions": map[string]bool{"admin": false, "maintain": true, "push": true, "pull": true}},
+		}},
+		"/orgs/acme/repos?per_page=100&type=all": {[]interface{}{map[string]interface{}{"name": "api"}}},
+		"/repos/acme/api/collaborators?affiliation=direct&per_page=100": {[]interface{}{
+			map[string]interface{}{"login": "dave", "role_name": "read"},
+		}},
+	})
+
+	tuples, err := importer.NewGitHub(ts.Client(), ts.URL, "gh-token", importer.DefaultNamespaces).Import(context.Background(), "acme")
+	require.NoError(t, err)
+
+	expected := []string{
+		"organizations:acme#admin@alice",
+		"organizations:acme#member@bob",
+		"organizations:acme#member@carol",
+		"teams:acme/eng#maintainer@alice",
+		"repositories:acme/api#write@teams:acme/eng#member",
+		"teams:acme/eng#member@teams:acme/backend#member",
+		"teams:acme/backend#member@bob",
+		"repositories:acme/api#maintain@teams:acme/backend#member",
+		"repositories:acme/api#read@dave",
+	}
+	assert.Equal(t, expected, tupleStrings(tuples))
+}
+
+func TestGitLab(t *testing.T) {
+	member := func(name string, level int) interface{} {
+		return map[string]interface{}{"username": name, "access_level": level}
+	}
+
+	ts := fakeAPI(t, "gl-token", map[string][]interface{}{
+		"/groups/acme": {map[string]interface{}{"id": 1, "full_path": "acme"}},
+		"/groups/acme/descendant_groups?per_page=100": {[]interface{}{
+			map[string]interface{}{"id": 2, "full_path": "acme/infra", "parent_id": 1},
+		}},
+		"/groups/1/members?per_page=100": {[]interface{}{member("alice", 50)}},
+		"/groups/2/members?per_page=100": {[]interface{}{member("bob", 30), member("eve", 0)}},
+		"/groups/acme/projects?include_subgroups=true&per_page=100": {[]interface{}{
+			map[string]interface{}{"id": 7, "path_with_namespace": "acme/infra/terraform"},
+		}},
+		"/projects/7/members/all?per_page=100": {[]interface{}{member("alice", 50)}, []interface{}{member("bob", 30)}},
+	})
+
+	tuples, err := importer.NewGitLab(ts.Client(), ts.URL, "gl-token", importer.Namespaces{
+		Organizations: "groups",
+		Repositories:  "projects",
+	}).Import(context.Background(), "acme")
+	require.NoError(t, err)
+
+	expected := []string{
+		"groups:acme#owner@alice",
+		"groups:acme/infra#minimal_access@groups:acme#minimal_access",
+		"groups:acme/infra#guest@groups:acme#guest",
+		"groups:acme/infra#reporter@groups:acme#reporter",
+		"groups:acme/infra#developer@groups:acme#developer",
+		"groups:acme/infra#maintainer@groups:acme#maintainer",
+		"groups:acme/infra#owner@groups:acme#owner",
+		"groups:acme/infra#developer@bob",
+		"projects:acme/infra/terraform#owner@alice",
+		"projects:acme/infra/terraform#developer@bob",
+	}
+	assert.Equal(t, expected, tupleStrings(tuples))
+}
+
+func TestUnexpectedStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	_, err := importer.NewGitHub(ts.Client(), ts.URL, "", importer.DefaultNamespaces).Import(context.Background(), "acme")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+	assert.Contains(t, err.Error(), "Resource not accessible")
+}
+
+func tupleStrings(tuples []*relationtuple.InternalRelationTuple) []string {
+	s := make([]string, len(tuples))
+	for i, t := range tuples {
+		s[i] = t.String()
+	}
+	return s
+}
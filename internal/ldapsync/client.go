@@ -0,0 +1,156 @@
+package ldapsync
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+// The directory is read with a password bind and searches with the paged
+// results control (RFC 2696), which Active Directory requires for more than
+// 1000 results.
+
+type (
+	conn struct {
+		c *ldap.Conn
+	}
+	entry struct {
+		dn string
+		// attributes by their lower-case name, as attribute names are case
+		// insensitive
+		attributes map[string][]string
+	}
+)
+
+// dial connects to an "ldap://" or "ldaps://" URL.
+func dial(ctx context.Context, rawURL string, timeout time.Duration) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	host := u.Host
+	d := &net.Dialer{Timeout: timeout}
+
+	var c net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		c, err = d.DialContext(ctx, "tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		c, err = (&tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}).DialContext(ctx, "tcp", host)
+	default:
+		return nil, errors.Errorf("ldap: unsupported scheme %q, expected ldap or ldaps", u.Scheme)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	lc := ldap.NewConn(c, u.Scheme == "ldaps")
+	lc.Start()
+	lc.SetTimeout(timeout)
+	return &conn{c: lc}, nil
+}
+
+func (c *conn) bind(dn, password string) error {
+	return errors.WithStack(c.c.Bind(dn, password))
+}
+
+func newEntry(e *ldap.Entry) *entry {
+	res := &entry{dn: e.DN, attributes: make(map[string][]string, len(e.Attributes))}
+	for _, attr := range e.Attributes {
+		name := strings.ToLower(attr.Name)
+		res.attributes[name] = append(res.attributes[name], attr.Values...)
+	}
+	return res
+}
+
+// search calls f for every entry matching the filter, requesting pages of the
+// given size. A page size of zero disables paging.
+func (c *conn) search(base string, scope int, filter string, attributes []string, pageSize int, f func(*entry) error) error {
+	req := ldap.NewSearchRequest(base, scope, ldap.NeverDerefAliases, 0, 0, false, filter, attributes, nil)
+
+	var (
+		res *ldap.SearchResult
+		err error
+	)
+	if pageSize > 0 {
+		res, err = c.c.SearchWithPaging(req, uint32(pageSize))
+	} else {
+		res, err = c.c.Search(req)
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// referrals to other servers are not followed
+	for _, e := range res.Entries {
+		if err := f(newEntry(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// values returns all values of the attribute of the entry. Active Directory
+// returns large multi-valued attributes in ranges, e.g. "member;range=0-1499",
+// and the remaining ranges have to be requested one by one.
+func (c *conn) values(e *entry, attribute string) ([]string, error) {
+	attribute = strings.ToLower(attribute)
+	values := e.attributes[attribute]
+	for {
+		var next int
+		var ranged bool
+		for name, vs := range e.attributes {
+			if !strings.HasPrefix(name, attribute+";range=") {
+				continue
+			}
+			values = append(values, vs...)
+			bounds := strings.SplitN(strings.TrimPrefix(name, attribute+";range="), "-", 2)
+			if len(bounds) != 2 {
+				return nil, errors.Errorf("ldap: malformed range %q", name)
+			}
+			if bounds[1] == "*" {
+				return values, nil
+			}
+			end, err := strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, errors.Errorf("ldap: malformed range %q", name)
+			}
+			next, ranged = end+1, true
+		}
+		if !ranged {
+			return values, nil
+		}
+
+		requested := attribute + ";range=" + strconv.Itoa(next) + "-*"
+		var found *entry
+		if err := c.search(e.dn, ldap.ScopeBaseObject, "(objectClass=*)", []string{requested}, 0, func(r *entry) error {
+			found = r
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return values, nil
+		}
+		e = found
+		delete(e.attributes, attribute)
+	}
+}
+
+func (c *conn) close() {
+	// The unbind closes the connection, but not if it fails.
+	_ = c.c.Unbind()
+	c.c.Close()
+}
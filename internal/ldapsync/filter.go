@@ -0,0 +1,31 @@
+package ldapsync
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+// missingAttribute matches items without an attribute, e.g. "(=a)", which
+// the search filter compiler accepts. Parentheses in assertion values are
+// escaped, so every "(" opens a filter.
+var missingAttribute = regexp.MustCompile(`\([~<>]?=`)
+
+// compileFilter validates a search filter in the string representation of RFC
+// 4515, e.g. "(&(objectClass=group)(cn=eng-*))", and adds the enclosing
+// parentheses if they are missing.
+func compileFilter(filter string) (string, error) {
+	filter = strings.TrimSpace(filter)
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+	if _, err := ldap.CompileFilter(filter); err != nil {
+		return "", errors.Wrapf(err, "ldap: invalid filter %q", filter)
+	}
+	if loc := missingAttribute.FindStringIndex(filter); loc != nil {
+		return "", errors.Errorf("ldap: invalid filter %q: item without attribute at %d", filter, loc[0])
+	}
+	return filter, nil
+}
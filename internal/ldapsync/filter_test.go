@@ -0,0 +1,47 @@
+package ldapsync
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/ldapsync/ldapsynctest"
+)
+
+func TestCompileFilter(t *testing.T) {
+	entry := &ldapsynctest.Entry{DN: "cn=devs", Attributes: map[string][]string{
+		"objectClass": {"top", "group"},
+		"cn":          {"eng-devs (berlin)"},
+	}}
+
+	for _, tc := range []struct {
+		filter  string
+		matches bool
+	}{
+		{filter: "objectClass=group", matches: true},
+		{filter: "(&(objectClass=group)(cn=eng-*))", matches: true},
+		{filter: "(|(cn=ops)(cn=*devs*))", matches: true},
+		{filter: "(!(objectClass=person))", matches: true},
+		{filter: "(cn=eng-devs \\28berlin\\29)", matches: true},
+		{filter: "(cn=*berlin)", matches: false},
+		{filter: "(&(objectClass=group)(description=*))", matches: false},
+	} {
+		t.Run("filter="+tc.filter, func(t *testing.T) {
+			f, err := compileFilter(tc.filter)
+			require.NoError(t, err)
+
+			encoded, err := ldap.CompileFilter(f)
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, entry.Matches(encoded))
+		})
+	}
+
+	for _, filter := range []string{"", "(cn=devs", "(&(cn=a)", "(cn=\\2)", "(cn=a))", "(=a)"} {
+		t.Run("invalid="+filter, func(t *testing.T) {
+			_, err := compileFilter(filter)
+			assert.Error(t, err)
+		})
+	}
+}
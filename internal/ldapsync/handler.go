@@ -0,0 +1,93 @@
+package ldapsync
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const RouteBase = "/admin/ldap/sync"
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.POST(RouteBase, h.syncLDAPGroups)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:parameters syncLDAPGroups
+// nolint:deadcode,unused
+type syncLDAPGroupsParameters struct {
+	// Only report the changes without applying them.
+	//
+	// in: query
+	DryRun bool `json:"dry_run"`
+}
+
+// swagger:route POST /admin/ldap/sync write syncLDAPGroups
+//
+// Synchronize the LDAP groups
+//
+// Reads the groups from the LDAP server and reconciles them into the groups
+// namespace right away, instead of waiting for the next interval.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: ldapSyncReport
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) syncLDAPGroups(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if _, enabled := h.d.Config(r.Context()).LDAPSync(); !enabled {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("The LDAP group synchronization is disabled, set %s to enable it.", config.KeyLDAPSyncEnabled)))
+		return
+	}
+
+	var dryRun bool
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("The dry_run parameter has to be a boolean, got %q.", raw).
+				WithDetail(errorcode.DetailField, "dry_run")))
+			return
+		}
+	}
+
+	report, err := h.d.LDAPSyncer().Sync(r.Context(), dryRun)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, report)
+}
@@ -0,0 +1,280 @@
+// Package ldapsynctest provides an in-memory LDAP directory for tests.
+package ldapsynctest
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// Server is an in-memory LDAP directory that answers binds and searches,
+	// including paged results and, if RangeSize is set, ranged attribute
+	// values like Active Directory.
+	Server struct {
+		URL                  string
+		BindDN, BindPassword string
+		RangeSize            int
+
+		mu      sync.Mutex
+		entries []*Entry
+	}
+	Entry struct {
+		DN         string
+		Attributes map[string][]string
+	}
+)
+
+func NewServer(t *testing.T, bindDN, bindPassword string) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	s := &Server{URL: "ldap://" + l.Addr().String(), BindDN: bindDN, BindPassword: bindPassword}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+// SetEntries replaces the entries of the directory.
+func (s *Server) SetEntries(entries ...*Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = entries
+}
+
+func octetString(s string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, s, "")
+}
+
+func ldapResult(op ber.Tag, code int64, message string) *ber.Packet {
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	res.AppendChild(octetString(""))
+	res.AppendChild(octetString(message))
+	return res
+}
+
+func (s *Server) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		p, err := ber.ReadPacket(r)
+		if err != nil || len(p.Children) < 2 {
+			return
+		}
+		id, op := p.Children[0].Value, p.Children[1]
+		respond := func(op *ber.Packet, controls ...ldap.Control) bool {
+			msg := ber.NewSequence("")
+			msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+			msg.AppendChild(op)
+			if len(controls) > 0 {
+				cs := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "")
+				for _, ctrl := range controls {
+					cs.AppendChild(ctrl.Encode())
+				}
+				msg.AppendChild(cs)
+			}
+			_, err := c.Write(msg.Bytes())
+			return err == nil
+		}
+
+		switch {
+		case op.ClassType == ber.ClassApplication && op.Tag == ldap.ApplicationBindRequest:
+			code := int64(ldap.LDAPResultSuccess)
+			if op.Children[1].Data.String() != s.BindDN || op.Children[2].Data.String() != s.BindPassword {
+				code = ldap.LDAPResultInvalidCredentials
+			}
+			if !respond(ldapResult(ldap.ApplicationBindResponse, code, "")) {
+				return
+			}
+		case op.ClassType == ber.ClassApplication && op.Tag == ldap.ApplicationSearchRequest:
+			var controls []ldap.Control
+			if len(p.Children) > 2 {
+				for _, child := range p.Children[2].Children {
+					if ctrl, err := ldap.DecodeControl(child); err == nil {
+						controls = append(controls, ctrl)
+					}
+				}
+			}
+			entries, done := s.search(op, controls)
+			for _, e := range entries {
+				if !respond(e) {
+					return
+				}
+			}
+			if !respond(ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, ""), done...) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (e *Entry) values(attribute string) []string {
+	for name, vs := range e.Attributes {
+		if strings.EqualFold(name, attribute) {
+			return vs
+		}
+	}
+	return nil
+}
+
+// Matches returns whether the entry matches the encoded search filter.
+func (e *Entry) Matches(f *ber.Packet) bool {
+	switch f.Tag {
+	case ldap.FilterAnd:
+		for _, c := range f.Children {
+			if !e.Matches(c) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, c := range f.Children {
+			if e.Matches(c) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !e.Matches(f.Children[0])
+	case ldap.FilterPresent:
+		return strings.EqualFold(f.Data.String(), "objectClass") || len(e.values(f.Data.String())) > 0
+	case ldap.FilterEqualityMatch:
+		for _, v := range e.values(f.Children[0].Data.String()) {
+			if strings.EqualFold(v, f.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterSubstrings:
+		for _, v := range e.values(f.Children[0].Data.String()) {
+			v = strings.ToLower(v)
+			ok := true
+			for _, part := range f.Children[1].Children {
+				sub := strings.ToLower(part.Data.String())
+				switch part.Tag {
+				case ldap.FilterSubstringsInitial:
+					ok = ok && strings.HasPrefix(v, sub)
+					v = strings.TrimPrefix(v, sub)
+				case ldap.FilterSubstringsAny:
+					i := strings.Index(v, sub)
+					ok = ok && i >= 0
+					if i >= 0 {
+						v = v[i+len(sub):]
+					}
+				case ldap.FilterSubstringsFinal:
+					ok = ok && strings.HasSuffix(v, sub)
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attribute returns the values of the requested attribute. Requests like
+// "member;range=1500-*" return the next range.
+func (s *Server) attribute(e *Entry, requested string) (string, []string) {
+	name, from := requested, 0
+	if i := strings.Index(requested, ";range="); i >= 0 {
+		name = requested[:i]
+		from, _ = strconv.Atoi(strings.SplitN(requested[i+len(";range="):], "-", 2)[0])
+	}
+	values := e.values(name)
+	if s.RangeSize <= 0 || len(values) <= s.RangeSize && from == 0 {
+		return name, values
+	}
+	if from > len(values) {
+		from = len(values)
+	}
+	to := from + s.RangeSize
+	if to >= len(values) {
+		return name + ";range=" + strconv.Itoa(from) + "-*", values[from:]
+	}
+	return name + ";range=" + strconv.Itoa(from) + "-" + strconv.Itoa(to-1), values[from:to]
+}
+
+func (s *Server) search(op *ber.Packet, controls []ldap.Control) (entries []*ber.Packet, done []ldap.Control) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	base := strings.ToLower(op.Children[0].Data.String())
+	scope, _ := op.Children[1].Value.(int64)
+	filter, requested := op.Children[6], op.Children[7].Children
+
+	var matching []*Entry
+	for _, e := range s.entries {
+		dn := strings.ToLower(e.DN)
+		inScope := dn == base
+		if scope == ldap.ScopeWholeSubtree {
+			inScope = inScope || strings.HasSuffix(dn, ","+base)
+		}
+		if inScope && e.Matches(filter) {
+			matching = append(matching, e)
+		}
+	}
+
+	offset, size := 0, len(matching)
+	paging, _ := ldap.FindControl(controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+	if paging != nil {
+		size = int(paging.PagingSize)
+		offset, _ = strconv.Atoi(string(paging.Cookie))
+	}
+	if offset > len(matching) {
+		offset = len(matching)
+	}
+	end := offset + size
+	if end > len(matching) {
+		end = len(matching)
+	}
+
+	for _, e := range matching[offset:end] {
+		attrs := ber.NewSequence("")
+		for _, a := range requested {
+			name, values := s.attribute(e, a.Data.String())
+			if len(values) == 0 {
+				continue
+			}
+			set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+			for _, v := range values {
+				set.AppendChild(octetString(v))
+			}
+			attr := ber.NewSequence("")
+			attr.AppendChild(octetString(name))
+			attr.AppendChild(set)
+			attrs.AppendChild(attr)
+		}
+		res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+		res.AppendChild(octetString(e.DN))
+		res.AppendChild(attrs)
+		entries = append(entries, res)
+	}
+
+	if paging != nil {
+		next := &ldap.ControlPaging{}
+		if end < len(matching) {
+			next.SetCookie([]byte(strconv.Itoa(end)))
+		}
+		done = append(done, next)
+	}
+	return entries, done
+}
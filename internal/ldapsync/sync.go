@@ -0,0 +1,397 @@
+package ldapsync
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// The groups of the directory are reconciled into the groups namespace through
+// the group manager. Synchronized groups are recorded in the configured
+// namespace, e.g. "ldap:groups#synced@devs", so that they are deleted once
+// they disappear from the directory. Groups that were never in the directory
+// are not touched.
+
+type (
+	Provider interface {
+		LDAPSyncer() *Syncer
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+		groups.Provider
+		x.LoggerProvider
+	}
+	Syncer struct {
+		d  dependencies
+		mu sync.Mutex
+	}
+	// The changes of an LDAP synchronization
+	//
+	// swagger:model ldapSyncReport
+	Report struct {
+		// Whether the changes were only computed, but not applied
+		//
+		// required: true
+		DryRun bool `json:"dry_run"`
+		// The number of groups in the directory
+		//
+		// required: true
+		Groups int `json:"groups"`
+		// required: true
+		Added []*Change `json:"added"`
+		// required: true
+		Removed []*Change `json:"removed"`
+		// The groups that were deleted, as they disappeared from the
+		// directory
+		//
+		// required: true
+		DeletedGroups []string `json:"deleted_groups"`
+		// The members that could not be added, e.g. because of cyclic nested
+		// groups
+		//
+		// required: true
+		Skipped []*Change `json:"skipped"`
+		// The values of the member attribute that are neither users nor
+		// synchronized groups
+		//
+		// required: true
+		Unresolved []string `json:"unresolved"`
+	}
+	// A member added to or removed from a group
+	//
+	// swagger:model ldapSyncChange
+	Change struct {
+		// required: true
+		Group string `json:"group"`
+		// required: true
+		Member *groups.Member `json:"member"`
+		// Why the change was skipped
+		Reason string `json:"reason,omitempty"`
+	}
+	// directory are the members of the groups read from the directory, by
+	// group name.
+	directory struct {
+		members    map[string][]*groups.Member
+		unresolved []string
+	}
+)
+
+const (
+	syncedObject   = "groups"
+	syncedRelation = "synced"
+)
+
+func NewSyncer(d dependencies) *Syncer {
+	return &Syncer{d: d}
+}
+
+// normalizeDN makes DNs comparable that only differ in case or in the spaces
+// around the separators.
+func normalizeDN(dn string) string {
+	rdns := strings.Split(dn, ",")
+	for i, rdn := range rdns {
+		kv := strings.SplitN(rdn, "=", 2)
+		for j := range kv {
+			kv[j] = strings.TrimSpace(kv[j])
+		}
+		rdns[i] = strings.Join(kv, "=")
+	}
+	return strings.ToLower(strings.Join(rdns, ","))
+}
+
+func first(e *entry, attribute string) string {
+	if vs := e.attributes[strings.ToLower(attribute)]; len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+func (s *Syncer) read(ctx context.Context, c config.LDAPSync) (*directory, error) {
+	groupFilter, err := compileFilter(c.GroupFilter)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial(ctx, c.URL, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+	if err := conn.bind(c.BindDN, c.BindPassword); err != nil {
+		return nil, err
+	}
+
+	users := map[string]string{}
+	if c.UserFilter != "" {
+		userFilter, err := compileFilter(c.UserFilter)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.search(c.BaseDN, ldap.ScopeWholeSubtree, userFilter, []string{c.UserIDAttribute}, c.PageSize, func(e *entry) error {
+			if id := first(e, c.UserIDAttribute); id != "" {
+				users[normalizeDN(e.dn)] = id
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// The entries are collected first, as the remaining ranges of the member
+	// attribute can't be requested while the search is running.
+	var entries []*entry
+	if err := conn.search(c.BaseDN, ldap.ScopeWholeSubtree, groupFilter, []string{c.GroupNameAttribute, c.MemberAttribute}, c.PageSize, func(e *entry) error {
+		entries = append(entries, e)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	groupDNs := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if name := first(e, c.GroupNameAttribute); name != "" {
+			groupDNs[normalizeDN(e.dn)] = name
+		}
+	}
+
+	dir := &directory{members: make(map[string][]*groups.Member, len(entries)), unresolved: []string{}}
+	for _, e := range entries {
+		name := first(e, c.GroupNameAttribute)
+		if name == "" {
+			continue
+		}
+		values, err := conn.values(e, c.MemberAttribute)
+		if err != nil {
+			return nil, err
+		}
+		members := dir.members[name]
+		if members == nil {
+			members = []*groups.Member{}
+		}
+		for _, v := range values {
+			v := v
+			if c.UserFilter == "" {
+				members = append(members, &groups.Member{SubjectID: &v})
+				continue
+			}
+			dn := normalizeDN(v)
+			if group, ok := groupDNs[dn]; ok {
+				members = append(members, &groups.Member{Group: &group})
+			} else if id, ok := users[dn]; ok {
+				members = append(members, &groups.Member{SubjectID: &id})
+			} else {
+				dir.unresolved = append(dir.unresolved, v)
+			}
+		}
+		dir.members[name] = members
+	}
+	return dir, nil
+}
+
+// memberKey identifies the members the synchronization manages. Subject sets
+// of other namespaces are not managed.
+func memberKey(m *groups.Member) (string, bool) {
+	switch {
+	case m.SubjectID != nil:
+		return "id:" + *m.SubjectID, true
+	case m.Group != nil:
+		return "group:" + *m.Group, true
+	}
+	return "", false
+}
+
+func (s *Syncer) synced(ctx context.Context, ns string) (map[string]*relationtuple.InternalRelationTuple, error) {
+	synced := map[string]*relationtuple.InternalRelationTuple{}
+	var page string
+	for {
+		res, next, err := s.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: ns, Object: syncedObject, Relation: syncedRelation},
+			x.WithToken(page), x.WithSize(s.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rt := range res {
+			if id, ok := rt.Subject.(*relationtuple.SubjectID); ok {
+				synced[id.ID] = rt
+			}
+		}
+		if next == "" {
+			return synced, nil
+		}
+		page = next
+	}
+}
+
+func (s *Syncer) members(ctx context.Context, group string) ([]*groups.Member, error) {
+	var all []*groups.Member
+	var page string
+	for {
+		res, next, err := s.d.GroupManager().Members(ctx, group, x.WithToken(page), x.WithSize(s.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, res...)
+		if next == "" {
+			return all, nil
+		}
+		page = next
+	}
+}
+
+// reconcile writes the difference between the directory and the groups
+// namespace, unless it is a dry run.
+func (s *Syncer) reconcile(ctx context.Context, c config.LDAPSync, dir *directory, dryRun bool) (*Report, error) {
+	report := &Report{
+		DryRun:        dryRun,
+		Groups:        len(dir.members),
+		Added:         []*Change{},
+		Removed:       []*Change{},
+		DeletedGroups: []string{},
+		Skipped:       []*Change{},
+		Unresolved:    dir.unresolved,
+	}
+
+	synced, err := s.synced(ctx, c.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	// Groups that disappeared from the directory come last, as deleting them
+	// also removes them from the other groups, which then would not be
+	// reported.
+	names := make([]string, 0, len(dir.members)+len(synced))
+	for name := range dir.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var vanished []string
+	for name := range synced {
+		if _, ok := dir.members[name]; !ok {
+			vanished = append(vanished, name)
+		}
+	}
+	sort.Strings(vanished)
+	names = append(names, vanished...)
+
+	for _, name := range names {
+		desired, inDirectory := dir.members[name]
+		current, err := s.members(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		desiredKeys := make(map[string]bool, len(desired))
+		for _, m := range desired {
+			key, _ := memberKey(m)
+			desiredKeys[key] = true
+		}
+		currentKeys := make(map[string]bool, len(current))
+		for _, m := range current {
+			key, managed := memberKey(m)
+			if !managed {
+				continue
+			}
+			currentKeys[key] = true
+			if !desiredKeys[key] {
+				report.Removed = append(report.Removed, &Change{Group: name, Member: m})
+				if !dryRun && inDirectory {
+					if err := s.d.GroupManager().RemoveMember(ctx, name, m); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		for _, m := range desired {
+			key, _ := memberKey(m)
+			if currentKeys[key] {
+				continue
+			}
+			currentKeys[key] = true
+			if !dryRun {
+				if err := s.d.GroupManager().AddMember(ctx, name, m); errorcode.Is(err, errorcode.GroupCycle) {
+					report.Skipped = append(report.Skipped, &Change{Group: name, Member: m, Reason: herodot.ToDefaultError(err, "").Reason()})
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+			}
+			report.Added = append(report.Added, &Change{Group: name, Member: m})
+		}
+
+		if !inDirectory {
+			report.DeletedGroups = append(report.DeletedGroups, name)
+			if !dryRun {
+				if err := s.d.GroupManager().Delete(ctx, name); err != nil {
+					return nil, err
+				}
+				if err := s.d.RelationTupleManager().DeleteRelationTuples(ctx, synced[name]); err != nil {
+					return nil, err
+				}
+			}
+		} else if _, ok := synced[name]; !ok && !dryRun {
+			if err := s.d.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+				Namespace: c.Namespace,
+				Object:    syncedObject,
+				Relation:  syncedRelation,
+				Subject:   &relationtuple.SubjectID{ID: name},
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// Sync reads the groups from the directory and reconciles them. A dry run
+// only reports the changes. Synchronizations don't run concurrently.
+func (s *Syncer) Sync(ctx context.Context, dryRun bool) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, _ := s.d.Config(ctx).LDAPSync()
+	dir, err := s.read(ctx, c)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not read the groups from the LDAP server: %s", err).WithWrap(err))
+	}
+	return s.reconcile(ctx, c, dir, dryRun)
+}
+
+// Run synchronizes the groups periodically while the synchronization is
+// enabled, until the context is canceled.
+func (s *Syncer) Run(ctx context.Context) error {
+	for {
+		c, enabled := s.d.Config(ctx).LDAPSync()
+		if enabled {
+			start := time.Now()
+			if report, err := s.Sync(ctx, false); err != nil {
+				s.d.Logger().WithError(err).Error("Could not synchronize the LDAP groups, retrying at the next interval.")
+			} else {
+				s.d.Logger().
+					WithField("groups", report.Groups).
+					WithField("added", len(report.Added)).
+					WithField("removed", len(report.Removed)).
+					WithField("deleted_groups", len(report.DeletedGroups)).
+					WithField("skipped", len(report.Skipped)).
+					WithField("unresolved", len(report.Unresolved)).
+					WithField("duration", time.Since(start).String()).
+					Info("Synchronized the LDAP groups.")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.Interval):
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package ldapsync_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/groups/groupstest"
+	"github.com/ory/keto/internal/ldapsync"
+	"github.com/ory/keto/internal/ldapsync/ldapsynctest"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+const (
+	bindDN       = "CN=keto,OU=Service,DC=example,DC=com"
+	bindPassword = "secret"
+)
+
+func user(cn, id string) *ldapsynctest.Entry {
+	return &ldapsynctest.Entry{
+		DN:         "CN=" + cn + ",OU=Users,DC=example,DC=com",
+		Attributes: map[string][]string{"objectClass": {"person"}, "sAMAccountName": {id}},
+	}
+}
+
+func group(cn string, members ...string) *ldapsynctest.Entry {
+	return &ldapsynctest.Entry{
+		DN:         "CN=" + cn + ",OU=Groups,DC=example,DC=com",
+		Attributes: map[string][]string{"objectClass": {"group"}, "cn": {cn}, "member": members},
+	}
+}
+
+func setup(t *testing.T) (*driver.RegistryDefault, *ldapsynctest.Server) {
+	ctx := context.Background()
+	server := ldapsynctest.NewServer(t, bindDN, bindPassword)
+
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "groups"}, {ID: 1, Name: "ldap"}, {ID: 2, Name: "teams"}}))
+	require.NoError(t, reg.Config(ctx).Set("ldap_sync", map[string]interface{}{
+		"enabled":       true,
+		"url":           server.URL,
+		"bind_dn":       bindDN,
+		"bind_password": bindPassword,
+		"base_dn":       "DC=example,DC=com",
+		"group_filter":  "(&(objectClass=group)(cn=*))",
+		"user_filter":   "(&(objectClass=person)(!(sAMAccountName=svc-*)))",
+	}))
+	return reg, server
+}
+
+func members(t *testing.T, reg *driver.RegistryDefault, group string) []*groups.Member {
+	res, _, err := reg.GroupManager().Members(context.Background(), group)
+	require.NoError(t, err)
+	return res
+}
+
+func TestSyncer(t *testing.T) {
+	ctx := context.Background()
+
+	alice, bob, carol, svc := user("Alice Smith", "alice"), user("Bob Jones", "bob"), user("Carol White", "carol"), user("Build Bot", "svc-build")
+	unknown := "CN=Gone,OU=Users,DC=example,DC=com"
+
+	t.Run("case=reconciles the directory", func(t *testing.T) {
+		reg, server := setup(t)
+		server.SetEntries(alice, bob, carol, svc,
+			group("devs", "cn=alice smith, ou=users, dc=example, dc=com", bob.DN, "CN=admins,OU=Groups,DC=example,DC=com", unknown, svc.DN),
+			group("admins", carol.DN),
+		)
+
+		local := &groups.Member{SubjectID: pointerx.String("dave")}
+		require.NoError(t, reg.GroupManager().Create(ctx, "local", []*groups.Member{local}))
+		require.NoError(t, reg.GroupManager().AddMember(ctx, "devs", &groups.Member{SubjectID: pointerx.String("mallory")}))
+		grant := &groups.Member{SubjectSet: &relationtuple.SubjectSet{Namespace: "teams", Object: "eng", Relation: "member"}}
+		require.NoError(t, reg.GroupManager().AddMember(ctx, "devs", grant))
+
+		report, err := reg.LDAPSyncer().Sync(ctx, true)
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 2, report.Groups)
+		assert.Len(t, report.Added, 4)
+		assert.Equal(t, []*ldapsync.Change{{Group: "devs", Member: &groups.Member{SubjectID: pointerx.String("mallory")}}}, report.Removed)
+		assert.ElementsMatch(t, []string{unknown, svc.DN}, report.Unresolved)
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"), "dry runs don't write")
+
+		report, err = reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.Len(t, report.Added, 4)
+		assert.Len(t, report.Removed, 1)
+		for _, id := range []string{"alice", "bob", "carol"} {
+			assert.True(t, groupstest.IsMember(t, reg, "devs", id), id)
+		}
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "mallory"))
+		assert.Contains(t, members(t, reg, "devs"), grant, "subject sets of other namespaces are kept")
+
+		report, err = reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.Empty(t, report.Added, "synchronizing again is a no-op")
+		assert.Empty(t, report.Removed)
+
+		server.SetEntries(alice, bob, carol, group("devs", alice.DN))
+		report, err = reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins"}, report.DeletedGroups)
+		assert.Len(t, report.Removed, 3)
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "bob"))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "carol"))
+		assert.Empty(t, members(t, reg, "admins"))
+
+		assert.Equal(t, []*groups.Member{local}, members(t, reg, "local"), "groups that were never synchronized are not touched")
+	})
+
+	t.Run("case=pages and follows attribute ranges", func(t *testing.T) {
+		reg, server := setup(t)
+		require.NoError(t, reg.Config(ctx).Set("ldap_sync.page_size", 1))
+		server.RangeSize = 2
+		server.SetEntries(alice, bob, carol, group("devs", alice.DN, bob.DN, carol.DN), group("ops", bob.DN))
+
+		report, err := reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Groups)
+		assert.Len(t, report.Added, 4)
+		assert.Len(t, members(t, reg, "devs"), 3)
+	})
+
+	t.Run("case=uses member values as IDs without user filter", func(t *testing.T) {
+		reg, server := setup(t)
+		require.NoError(t, reg.Config(ctx).Set("ldap_sync.user_filter", ""))
+		require.NoError(t, reg.Config(ctx).Set("ldap_sync.attributes.member", "memberUid"))
+		server.SetEntries(&ldapsynctest.Entry{
+			DN:         "cn=devs,ou=groups,dc=example,dc=com",
+			Attributes: map[string][]string{"objectClass": {"group"}, "cn": {"devs"}, "memberUid": {"alice", "bob"}},
+		})
+
+		_, err := reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "bob"))
+	})
+
+	t.Run("case=skips cyclic nested groups", func(t *testing.T) {
+		reg, server := setup(t)
+		a, b := group("a", "CN=b,OU=Groups,DC=example,DC=com"), group("b", "CN=a,OU=Groups,DC=example,DC=com")
+		server.SetEntries(a, b)
+
+		report, err := reg.LDAPSyncer().Sync(ctx, false)
+		require.NoError(t, err)
+		assert.Len(t, report.Added, 1)
+		require.Len(t, report.Skipped, 1)
+		assert.NotEmpty(t, report.Skipped[0].Reason)
+	})
+
+	t.Run("case=fails on invalid credentials", func(t *testing.T) {
+		reg, _ := setup(t)
+		require.NoError(t, reg.Config(ctx).Set("ldap_sync.bind_password", "wrong"))
+		_, err := reg.LDAPSyncer().Sync(ctx, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	reg, server := setup(t)
+	server.SetEntries(user("Alice", "alice"), group("devs", "CN=Alice,OU=Users,DC=example,DC=com"))
+
+	r := httprouter.New()
+	ldapsync.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	post := func(t *testing.T, query string, expectedStatus int) *ldapsync.Report {
+		resp, err := ts.Client().Post(ts.URL+ldapsync.RouteBase+query, "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, expectedStatus, resp.StatusCode)
+		var report ldapsync.Report
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		return &report
+	}
+
+	report := post(t, "?dry_run=true", http.StatusOK)
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.Added, 1)
+	assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+	post(t, "?dry_run=maybe", http.StatusBadRequest)
+
+	post(t, "", http.StatusOK)
+	assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyLDAPSyncEnabled, false))
+	post(t, "", http.StatusNotFound)
+}
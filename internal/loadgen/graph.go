@@ -0,0 +1,310 @@
+// Package loadgen generates synthetic relation tuples for load testing.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+type (
+	// Distribution is the shape of a random distribution.
+	Distribution string
+
+	// GraphOptions parameterize the generated graph. The first namespace
+	// holds a hierarchy of nested groups with users as members. All other
+	// namespaces hold hierarchies of folders with documents as leaves. Folders
+	// pass their viewers and editors on to their children, and every node is
+	// shared with a few groups and users.
+	GraphOptions struct {
+		// Depth is the number of levels of each hierarchy.
+		Depth int
+		// Fanout is the mean number of children of a node and of members of
+		// a group.
+		Fanout int
+		// Namespaces is the number of namespaces, at least one.
+		Namespaces int
+		// Users is the number of distinct users.
+		Users int
+		// FanoutDistribution is the shape of the number of children and
+		// members.
+		FanoutDistribution Distribution
+		// Popularity is the shape by which users and groups are picked as
+		// members and grantees. With DistributionZipf, few of them are picked
+		// very often, like an "everyone" group.
+		Popularity Distribution
+		// MaxTuples limits the size of the graph, which otherwise grows with
+		// Fanout^Depth. It is split evenly among the namespaces, and the
+		// hierarchies are generated top-down until the share is used up.
+		MaxTuples int
+		// Seed makes the generated graph reproducible.
+		Seed int64
+		// NamespacePrefix is prepended to the generated namespace names.
+		NamespacePrefix string
+		// NamespaceIDOffset is the ID of the first namespace.
+		NamespaceIDOffset int32
+	}
+
+	generator struct {
+		o      *GraphOptions
+		rng    *rand.Rand
+		zipfs  map[int]*rand.Zipf
+		emit   func(*relationtuple.InternalRelationTuple) error
+		budget int
+		groups []string
+	}
+)
+
+const (
+	DistributionConstant Distribution = "constant"
+	DistributionUniform  Distribution = "uniform"
+	DistributionZipf     Distribution = "zipf"
+)
+
+var (
+	Distributions = []Distribution{DistributionConstant, DistributionUniform, DistributionZipf}
+
+	errBudgetExhausted = errors.New("the tuple budget of the namespace is exhausted")
+)
+
+// Validate returns an error if the options are out of range.
+func (o *GraphOptions) Validate() error {
+	for name, v := range map[string]int{
+		"depth":      o.Depth,
+		"fanout":     o.Fanout,
+		"namespaces": o.Namespaces,
+		"users":      o.Users,
+		"max tuples": o.MaxTuples,
+	} {
+		if v < 1 {
+			return errors.Errorf("the %s must be at least 1, but got %d", name, v)
+		}
+	}
+	for _, d := range []Distribution{o.FanoutDistribution, o.Popularity} {
+		if !validDistribution(d) {
+			return errors.Errorf("unknown distribution %q, expected one of %v", d, Distributions)
+		}
+	}
+	return nil
+}
+
+func validDistribution(d Distribution) bool {
+	for _, v := range Distributions {
+		if d == v {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceDefinitions returns the namespaces the graph uses. They have to be
+// configured to write or read the generated relation tuples.
+func (o *GraphOptions) NamespaceDefinitions() []*namespace.Namespace {
+	nn := make([]*namespace.Namespace, o.Namespaces)
+	nn[0] = &namespace.Namespace{ID: o.NamespaceIDOffset, Name: o.groupsNamespace()}
+	for i := 1; i < o.Namespaces; i++ {
+		nn[i] = &namespace.Namespace{ID: o.NamespaceIDOffset + int32(i), Name: fmt.Sprintf("%sdocuments-%d", o.NamespacePrefix, i)}
+	}
+	return nn
+}
+
+func (o *GraphOptions) groupsNamespace() string {
+	return o.NamespacePrefix + "groups"
+}
+
+// GenerateGraph generates the relation tuples of the graph and passes them to
+// emit, parents before children. It returns the number of generated tuples.
+// The same options always generate the same tuples in the same order.
+func GenerateGraph(ctx context.Context, o GraphOptions, emit func(*relationtuple.InternalRelationTuple) error) (int, error) {
+	if err := o.Validate(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	g := &generator{
+		o:     &o,
+		rng:   rand.New(rand.NewSource(o.Seed)), // #nosec G404 -- reproducibility is required, not unpredictability
+		zipfs: make(map[int]*rand.Zipf),
+		emit: func(t *relationtuple.InternalRelationTuple) error {
+			if err := ctx.Err(); err != nil {
+				return errors.WithStack(err)
+			}
+			count++
+			return emit(t)
+		},
+	}
+
+	for i, n := range o.NamespaceDefinitions() {
+		g.budget = o.MaxTuples / o.Namespaces
+		if i < o.MaxTuples%o.Namespaces {
+			g.budget++
+		}
+
+		var err error
+		if i == 0 {
+			err = g.groupHierarchy(n.Name)
+		} else {
+			err = g.documentHierarchy(n.Name)
+		}
+		if err != nil && !errors.Is(err, errBudgetExhausted) {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func (g *generator) groupHierarchy(ns string) error {
+	return g.tree(func(id, parent, _ int) error {
+		group := fmt.Sprintf("group-%d", id)
+		g.groups = append(g.groups, group)
+
+		if parent >= 0 {
+			// Members of subgroups are members of the parent group.
+			if err := g.add(ns, fmt.Sprintf("group-%d", parent), "member", &relationtuple.SubjectSet{
+				Namespace: ns,
+				Object:    group,
+				Relation:  "member",
+			}); err != nil {
+				return err
+			}
+		}
+		for i, members := 0, g.fanout(); i < members; i++ {
+			if err := g.add(ns, group, "member", g.user()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (g *generator) documentHierarchy(ns string) error {
+	name := func(id, level int) string {
+		if level == g.o.Depth-1 {
+			return fmt.Sprintf("doc-%d", id)
+		}
+		return fmt.Sprintf("folder-%d", id)
+	}
+
+	return g.tree(func(id, parent, level int) error {
+		object := name(id, level)
+
+		if parent >= 0 {
+			for _, rel := range []string{"viewer", "editor"} {
+				if err := g.add(ns, object, rel, &relationtuple.SubjectSet{
+					Namespace: ns,
+					Object:    name(parent, level-1),
+					Relation:  rel,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Most nodes are shared with a few grantees.
+		for i, grants := 0, g.rng.Intn(3); i < grants; i++ {
+			rel := "viewer"
+			if g.rng.Intn(4) == 0 {
+				rel = "editor"
+			}
+			var s relationtuple.Subject = g.user()
+			if len(g.groups) > 0 && g.rng.Intn(2) == 0 {
+				s = &relationtuple.SubjectSet{
+					Namespace: g.o.groupsNamespace(),
+					Object:    g.groups[g.pick(len(g.groups))],
+					Relation:  "member",
+				}
+			}
+			if err := g.add(ns, object, rel, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// tree generates a hierarchy top-down, level by level, and calls visit for
+// every node. The parent of root nodes is -1.
+func (g *generator) tree(visit func(id, parent, level int) error) error {
+	type node struct{ id, level int }
+	var queue []node
+	next := 0
+	add := func(parent, level int) error {
+		next++
+		queue = append(queue, node{id: next, level: level})
+		return visit(next, parent, level)
+	}
+
+	for i, roots := 0, g.fanout(); i < roots; i++ {
+		if err := add(-1, 0); err != nil {
+			return err
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.level+1 >= g.o.Depth {
+			continue
+		}
+		for i, children := 0, g.fanout(); i < children; i++ {
+			if err := add(n.id, n.level+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) add(ns, object, relation string, s relationtuple.Subject) error {
+	if g.budget <= 0 {
+		return errBudgetExhausted
+	}
+	g.budget--
+	return g.emit(&relationtuple.InternalRelationTuple{
+		Namespace: ns,
+		Object:    object,
+		Relation:  relation,
+		Subject:   s,
+	})
+}
+
+func (g *generator) user() *relationtuple.SubjectID {
+	return &relationtuple.SubjectID{ID: fmt.Sprintf("user-%d", g.pick(g.o.Users))}
+}
+
+// fanout draws a number of children with a mean of about Fanout. The Zipf
+// distribution is heavy-tailed: most nodes have few children, some have many.
+func (g *generator) fanout() int {
+	switch f := g.o.Fanout; g.o.FanoutDistribution {
+	case DistributionUniform:
+		return 1 + g.rng.Intn(2*f-1)
+	case DistributionZipf:
+		return 1 + g.zipf(4*f)
+	default:
+		return f
+	}
+}
+
+// pick draws an index in [0, n).
+func (g *generator) pick(n int) int {
+	if g.o.Popularity == DistributionZipf {
+		return g.zipf(n)
+	}
+	return g.rng.Intn(n)
+}
+
+func (g *generator) zipf(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	z, ok := g.zipfs[n]
+	if !ok {
+		z = rand.NewZipf(g.rng, 1.1, 1, uint64(n-1))
+		g.zipfs[n] = z
+	}
+	return int(z.Uint64())
+}
@@ -0,0 +1,124 @@
+package loadgen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/loadgen"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func generate(t *testing.T, o loadgen.GraphOptions) []*relationtuple.InternalRelationTuple {
+	var tuples []*relationtuple.InternalRelationTuple
+	n, err := loadgen.GenerateGraph(context.Background(), o, func(r *relationtuple.InternalRelationTuple) error {
+		tuples = append(tuples, r)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, len(tuples), n)
+	return tuples
+}
+
+func TestGenerateGraph(t *testing.T) {
+	opts := loadgen.GraphOptions{
+		Depth:              4,
+		Fanout:             3,
+		Namespaces:         3,
+		Users:              50,
+		FanoutDistribution: loadgen.DistributionUniform,
+		Popularity:         loadgen.DistributionZipf,
+		MaxTuples:          100000,
+		Seed:               42,
+		NamespacePrefix:    "lg-",
+	}
+
+	t.Run("case=is deterministic", func(t *testing.T) {
+		first := generate(t, opts)
+		assert.Equal(t, first, generate(t, opts))
+
+		other := opts
+		other.Seed++
+		assert.NotEqual(t, first, generate(t, other))
+	})
+
+	t.Run("case=uses the given namespaces", func(t *testing.T) {
+		nn := opts.NamespaceDefinitions()
+		require.Len(t, nn, 3)
+		assert.Equal(t, []string{"lg-groups", "lg-documents-1", "lg-documents-2"}, []string{nn[0].Name, nn[1].Name, nn[2].Name})
+
+		seen := make(map[string]bool)
+		for _, r := range generate(t, opts) {
+			seen[r.Namespace] = true
+			if s, ok := r.Subject.(*relationtuple.SubjectSet); ok {
+				seen[s.Namespace] = true
+			}
+		}
+		assert.Equal(t, map[string]bool{"lg-groups": true, "lg-documents-1": true, "lg-documents-2": true}, seen)
+	})
+
+	t.Run("case=respects the depth", func(t *testing.T) {
+		const constantFanout = 2
+		o := opts
+		o.Namespaces, o.Fanout, o.FanoutDistribution = 1, constantFanout, loadgen.DistributionConstant
+
+		var subgroups, members int
+		for _, r := range generate(t, o) {
+			if _, ok := r.Subject.(*relationtuple.SubjectSet); ok {
+				subgroups++
+			} else {
+				members++
+			}
+		}
+		// 2 + 4 + 8 + 16 groups, all but the roots are a subgroup
+		assert.Equal(t, 28, subgroups)
+		assert.Equal(t, 30*constantFanout, members)
+	})
+
+	t.Run("case=respects the max tuples", func(t *testing.T) {
+		o := opts
+		o.Depth, o.Fanout, o.MaxTuples = 8, 20, 1000
+
+		tuples := generate(t, o)
+		assert.Len(t, tuples, 1000)
+
+		perNamespace := make(map[string]int)
+		for _, r := range tuples {
+			perNamespace[r.Namespace]++
+		}
+		assert.Equal(t, map[string]int{"lg-groups": 334, "lg-documents-1": 333, "lg-documents-2": 333}, perNamespace)
+	})
+
+	t.Run("case=documents inherit from folders", func(t *testing.T) {
+		for _, r := range generate(t, opts) {
+			s, ok := r.Subject.(*relationtuple.SubjectSet)
+			if !ok || r.Namespace != "lg-documents-1" || s.Namespace != r.Namespace {
+				continue
+			}
+			assert.True(t, strings.HasPrefix(s.Object, "folder-"), "%s", r)
+			assert.Equal(t, r.Relation, s.Relation)
+		}
+	})
+
+	t.Run("case=stops on emit errors", func(t *testing.T) {
+		_, err := loadgen.GenerateGraph(context.Background(), opts, func(*relationtuple.InternalRelationTuple) error {
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("case=validates the options", func(t *testing.T) {
+		o := opts
+		o.Popularity = "normal"
+		_, err := loadgen.GenerateGraph(context.Background(), o, nil)
+		assert.ErrorContains(t, err, `unknown distribution "normal"`)
+
+		o = opts
+		o.Depth = 0
+		_, err = loadgen.GenerateGraph(context.Background(), o, nil)
+		assert.ErrorContains(t, err, "the depth must be at least 1")
+	})
+}
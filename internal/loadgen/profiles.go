@@ -0,0 +1,88 @@
+package loadgen
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Profile is a named data set with fixed options and seed. The same profile
+// always generates the same relation tuples, so that the performance of
+// different versions can be compared on identical data.
+//
+// Changing the options of a profile, or the generator in a way that changes
+// the generated tuples, breaks the comparison with earlier versions. Add a new
+// profile instead.
+type Profile struct {
+	Name        string
+	Description string
+	Options     GraphOptions
+}
+
+var profiles = map[string]*Profile{
+	"gdrive-like": {
+		Name:        "gdrive-like",
+		Description: "deep folder trees shared with a few popular groups and many individual users",
+		Options: GraphOptions{
+			Depth:              6,
+			Fanout:             8,
+			Namespaces:         3,
+			Users:              10000,
+			FanoutDistribution: DistributionUniform,
+			Popularity:         DistributionZipf,
+			MaxTuples:          1000000,
+			Seed:               1,
+			NamespacePrefix:    "gdrive-",
+		},
+	},
+	"github-like": {
+		Name:        "github-like",
+		Description: "shallow organizations with nested teams and heavy-tailed repository counts",
+		Options: GraphOptions{
+			Depth:              3,
+			Fanout:             12,
+			Namespaces:         2,
+			Users:              50000,
+			FanoutDistribution: DistributionZipf,
+			Popularity:         DistributionZipf,
+			MaxTuples:          1000000,
+			Seed:               2,
+			NamespacePrefix:    "github-",
+		},
+	},
+	"iot-fleet": {
+		Name:        "iot-fleet",
+		Description: "wide, regular fleet, site, gateway and device hierarchies operated by few users",
+		Options: GraphOptions{
+			Depth:              4,
+			Fanout:             20,
+			Namespaces:         2,
+			Users:              500,
+			FanoutDistribution: DistributionConstant,
+			Popularity:         DistributionUniform,
+			MaxTuples:          2000000,
+			Seed:               3,
+			NamespacePrefix:    "iot-",
+		},
+	},
+}
+
+// GetProfile returns the profile with the given name.
+func GetProfile(name string) (*Profile, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return nil, errors.Errorf("unknown profile %q, expected one of %v", name, ProfileNames())
+	}
+	cp := *p
+	return &cp, nil
+}
+
+// ProfileNames returns the names of all profiles in alphabetical order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package loadgen_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/loadgen"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestProfiles(t *testing.T) {
+	// The fingerprints of the first tuples of each profile. If they change,
+	// the profile does not generate the same data as earlier versions did.
+	fingerprints := map[string]string{
+		"gdrive-like": "535c980aeb02c79c19627f5583227bf41ec6003e0a60ec9ab8cdd25358e75784",
+		"github-like": "78d6b38cd23ea266259f815a638aa82dfd68e811d11df2897b48b5ba0c384eca",
+		"iot-fleet":   "f0ca282188c98e58ad42d2eb801c28551697fc3452b4ccf9d878f8e65eccb182",
+	}
+	require.Equal(t, len(fingerprints), len(loadgen.ProfileNames()), "every profile needs a fingerprint")
+
+	errStop := errors.New("stop")
+	for _, name := range loadgen.ProfileNames() {
+		t.Run("profile="+name, func(t *testing.T) {
+			p, err := loadgen.GetProfile(name)
+			require.NoError(t, err)
+			require.NoError(t, p.Options.Validate())
+
+			h, n := sha256.New(), 0
+			_, err = loadgen.GenerateGraph(context.Background(), p.Options, func(r *relationtuple.InternalRelationTuple) error {
+				_, _ = fmt.Fprintln(h, r.String())
+				if n++; n == 10000 {
+					return errStop
+				}
+				return nil
+			})
+			require.ErrorIs(t, err, errStop)
+			assert.Equal(t, fingerprints[name], hex.EncodeToString(h.Sum(nil)))
+		})
+	}
+
+	_, err := loadgen.GetProfile("unknown")
+	assert.ErrorContains(t, err, `unknown profile "unknown"`)
+}
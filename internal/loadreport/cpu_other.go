@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+
+package loadreport
+
+import "time"
+
+// cpuTime is not supported on this platform, so the CPU utilization is
+// reported as zero.
+func cpuTime() time.Duration {
+	return 0
+}
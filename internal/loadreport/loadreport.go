@@ -0,0 +1,132 @@
+// Package loadreport implements the ORCA (Open Request Cost Aggregation)
+// out-of-band load reporting service. gRPC clients and xDS load balancers
+// subscribe to it to weigh Keto replicas by their actual load instead of
+// spreading requests evenly.
+package loadreport
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	orcadata "github.com/cncf/xds/go/xds/data/orca/v3"
+	orca "github.com/cncf/xds/go/xds/service/orca/v3"
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultReportInterval is used if the client does not request an
+	// interval.
+	DefaultReportInterval = 10 * time.Second
+	// MinReportInterval bounds the interval clients can request, so that
+	// subscribers cannot make the server busy with reporting.
+	MinReportInterval = time.Second
+)
+
+// Reporter counts the requests served by this instance and streams its load
+// to subscribed clients. One reporter is shared by the servers of all APIs, as
+// the load is reported per instance.
+type Reporter struct {
+	orca.UnimplementedOpenRcaServiceServer
+
+	requests uint64
+	started  sample
+}
+
+var _ orca.OpenRcaServiceServer = (*Reporter)(nil)
+
+func New() *Reporter {
+	r := &Reporter{}
+	r.started = r.sample()
+	return r
+}
+
+func (r *Reporter) Register(s *grpc.Server) {
+	orca.RegisterOpenRcaServiceServer(s, r)
+}
+
+func (r *Reporter) served() {
+	atomic.AddUint64(&r.requests, 1)
+}
+
+func (r *Reporter) HTTPMiddleware(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	r.served()
+	next(rw, req)
+}
+
+func (r *Reporter) UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	r.served()
+	return handler(ctx, req)
+}
+
+func (r *Reporter) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	// The load reports themselves are not load.
+	if info.FullMethod != "/xds.service.orca.v3.OpenRcaService/StreamCoreMetrics" {
+		r.served()
+	}
+	return handler(srv, stream)
+}
+
+// reportInterval returns the interval requested by the client, bounded by
+// MinReportInterval.
+func reportInterval(req *orca.OrcaLoadReportRequest) time.Duration {
+	if req.GetReportInterval() == nil {
+		return DefaultReportInterval
+	}
+	if i := req.GetReportInterval().AsDuration(); i > MinReportInterval {
+		return i
+	}
+	return MinReportInterval
+}
+
+// StreamCoreMetrics sends the load of this instance at the interval requested
+// by the client, until it unsubscribes. The first report is sent right away
+// and covers the time since the reporter was created.
+func (r *Reporter) StreamCoreMetrics(req *orca.OrcaLoadReportRequest, stream orca.OpenRcaService_StreamCoreMetricsServer) error {
+	interval := reportInterval(req)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := r.started
+	for {
+		cur := r.sample()
+		if err := stream.Send(cur.report(last)); err != nil {
+			return err
+		}
+		last = cur
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+type sample struct {
+	at       time.Time
+	requests uint64
+	cpu      time.Duration
+}
+
+func (r *Reporter) sample() sample {
+	return sample{
+		at:       time.Now(),
+		requests: atomic.LoadUint64(&r.requests),
+		cpu:      cpuTime(),
+	}
+}
+
+// report returns the load between the previous sample and this one.
+func (s sample) report(prev sample) *orcadata.OrcaLoadReport {
+	elapsed := s.at.Sub(prev.at)
+	if elapsed <= 0 {
+		return &orcadata.OrcaLoadReport{}
+	}
+	return &orcadata.OrcaLoadReport{
+		Rps:            uint64(float64(s.requests-prev.requests) / elapsed.Seconds()),
+		CpuUtilization: float64(s.cpu-prev.cpu) / float64(elapsed) / float64(runtime.NumCPU()),
+	}
+}
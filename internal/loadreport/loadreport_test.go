@@ -0,0 +1,64 @@
+package loadreport
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	orca "github.com/cncf/xds/go/xds/service/orca/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	grpcHealthV1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestReportInterval(t *testing.T) {
+	assert.Equal(t, DefaultReportInterval, reportInterval(&orca.OrcaLoadReportRequest{}))
+	assert.Equal(t, MinReportInterval, reportInterval(&orca.OrcaLoadReportRequest{ReportInterval: durationpb.New(time.Millisecond)}))
+	assert.Equal(t, time.Minute, reportInterval(&orca.OrcaLoadReportRequest{ReportInterval: durationpb.New(time.Minute)}))
+}
+
+func TestStreamCoreMetrics(t *testing.T) {
+	r := New()
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(r.UnaryInterceptor),
+		grpc.StreamInterceptor(r.StreamInterceptor),
+	)
+	grpcHealthV1.RegisterHealthServer(s, health.NewServer())
+	r.Register(s)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		_, err := grpcHealthV1.NewHealthClient(conn).Check(ctx, &grpcHealthV1.HealthCheckRequest{})
+		require.NoError(t, err)
+	}
+
+	stream, err := orca.NewOpenRcaServiceClient(conn).StreamCoreMetrics(ctx, &orca.OrcaLoadReportRequest{
+		ReportInterval: durationpb.New(MinReportInterval),
+	})
+	require.NoError(t, err)
+
+	report, err := stream.Recv()
+	require.NoError(t, err)
+	assert.NotZero(t, report.Rps, "the health checks were served since the reporter was created")
+	assert.GreaterOrEqual(t, report.CpuUtilization, 0.)
+
+	report, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Zero(t, report.Rps, "subscribing to the load reports is not load")
+}
@@ -0,0 +1,103 @@
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/objectstore"
+)
+
+var csvHeader = []string{"network_id", "from", "to", "checks", "writes", "stored_tuples"}
+
+// WriteCSV writes the usage as CSV with a header row.
+func WriteCSV(w io.Writer, usage []*Usage) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, u := range usage {
+		if err := cw.Write([]string{
+			u.NetworkID.String(),
+			u.From.Format(time.RFC3339),
+			u.To.Format(time.RFC3339),
+			strconv.FormatInt(u.Checks, 10),
+			strconv.FormatInt(u.Writes, 10),
+			strconv.FormatInt(u.StoredTuples, 10),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	cw.Flush()
+	return errors.WithStack(cw.Error())
+}
+
+// ExportPeriodically writes the usage of every period to the configured
+// directory or object storage location until the context is canceled. The
+// last, incomplete period is exported as well, so that its counts are not lost
+// on shutdown.
+func (m *Meter) ExportPeriodically(ctx context.Context) error {
+	c := m.d.Config(ctx)
+	dir, interval := c.MeteringExport()
+	if !c.MeteringEnabled() || dir == "" {
+		return nil
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := m.Export(ctx, dir); err != nil {
+				m.d.Logger().WithError(err).Error("Could not export the usage, it is added to the next period.")
+			}
+		case <-ctx.Done():
+			if err := m.Export(context.Background(), dir); err != nil {
+				m.d.Logger().WithError(err).Error("Could not export the usage of the last period.")
+			}
+			return nil
+		}
+	}
+}
+
+// Export writes the usage of the current period to a CSV file in the directory
+// or object storage location and starts a new period. If the file cannot be
+// written, the counts are kept for the next period.
+func (m *Meter) Export(ctx context.Context, dir string) (err error) {
+	b, err := objectstore.Open(m.d.Config(ctx), dir)
+	if err != nil {
+		return err
+	}
+	usage, err := m.rotate(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			m.restore(usage)
+		}
+	}()
+
+	host, err := os.Hostname()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// All usage records of a period share its end.
+	to := time.Now().UTC()
+	if len(usage) > 0 {
+		to = usage[0].To
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, usage); err != nil {
+		return err
+	}
+	return b.Put(ctx, fmt.Sprintf("keto-usage-%s-%s.csv", host, to.Format("20060102T150405Z")), buf.Bytes(), "text/csv")
+}
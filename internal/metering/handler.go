@@ -0,0 +1,99 @@
+package metering
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.LoggerProvider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const RouteBase = "/admin/usage"
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.getUsage)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// The usage of all networks in the current period
+//
+// swagger:model getUsageResponse
+type getUsageResponse struct {
+	// required: true
+	Usage []*Usage `json:"usage"`
+}
+
+// swagger:parameters getUsage
+// nolint:deadcode,unused
+type getUsageParameters struct {
+	// Set to `csv` to get the usage in the same format as the periodic
+	// export.
+	//
+	// in: query
+	Format string `json:"format"`
+}
+
+// swagger:route GET /admin/usage write getUsage
+//
+// Get the usage of all networks
+//
+// Returns the number of checks and writes of every network in the current
+// period of this instance, and the number of stored relation tuples. Sum the
+// usage of all instances to get the usage of the deployment.
+//
+//     Produces:
+//     - application/json
+//     - text/csv
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getUsageResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) getUsage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if !h.d.Config(r.Context()).MeteringEnabled() {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("Usage metering is disabled, set %s to enable it.", config.KeyMeteringEnabled)))
+		return
+	}
+
+	usage, err := h.d.Meter().Usage(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		if err := WriteCSV(w, usage); err != nil {
+			h.d.Logger().WithError(err).Error("Could not write the usage.")
+		}
+		return
+	}
+	h.d.Writer().Write(w, r, &getUsageResponse{Usage: usage})
+}
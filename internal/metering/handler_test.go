@@ -0,0 +1,61 @@
+package metering_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/metering"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+
+	r := httprouter.New()
+	metering.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T, path string) (*http.Response, string) {
+		resp, err := ts.Client().Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("case=not found when disabled", func(t *testing.T) {
+		resp, _ := get(t, metering.RouteBase)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyMeteringEnabled, true))
+	reg.Meter().CountCheck(ctx)
+	nid := reg.Persister().NetworkID(ctx).String()
+
+	t.Run("case=returns JSON", func(t *testing.T) {
+		resp, body := get(t, metering.RouteBase)
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, nid, gjson.Get(body, "usage.0.network_id").String(), body)
+		assert.EqualValues(t, 1, gjson.Get(body, "usage.0.checks").Int(), body)
+	})
+
+	t.Run("case=returns CSV", func(t *testing.T) {
+		resp, body := get(t, metering.RouteBase+"?format=csv")
+		require.Equal(t, http.StatusOK, resp.StatusCode, body)
+		assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+		assert.Contains(t, body, "network_id,from,to,checks,writes,stored_tuples\n"+nid+",")
+	})
+}
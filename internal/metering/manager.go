@@ -0,0 +1,42 @@
+package metering
+
+import (
+	"context"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// meteredManager counts every successful call that changes relation tuples as
+// one write.
+type meteredManager struct {
+	relationtuple.Manager
+	m *Meter
+}
+
+// Manager wraps the relation tuple manager to count its writes.
+func (m *Meter) Manager(inner relationtuple.Manager) relationtuple.Manager {
+	return relationtuple.KeepObjectsGetter(&meteredManager{Manager: inner, m: m}, inner)
+}
+
+func (mm *meteredManager) counted(ctx context.Context, err error) error {
+	if err == nil {
+		mm.m.CountWrite(ctx)
+	}
+	return err
+}
+
+func (mm *meteredManager) WriteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return mm.counted(ctx, mm.Manager.WriteRelationTuples(ctx, rs...))
+}
+
+func (mm *meteredManager) DeleteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return mm.counted(ctx, mm.Manager.DeleteRelationTuples(ctx, rs...))
+}
+
+func (mm *meteredManager) DeleteAllRelationTuples(ctx context.Context, query *relationtuple.RelationQuery) error {
+	return mm.counted(ctx, mm.Manager.DeleteAllRelationTuples(ctx, query))
+}
+
+func (mm *meteredManager) TransactRelationTuples(ctx context.Context, insert []*relationtuple.InternalRelationTuple, delete []*relationtuple.InternalRelationTuple) error {
+	return mm.counted(ctx, mm.Manager.TransactRelationTuples(ctx, insert, delete))
+}
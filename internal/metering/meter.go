@@ -0,0 +1,159 @@
+// Package metering counts the checks and writes of every network, so that the
+// usage of a shared Keto deployment can be charged back to its tenants.
+package metering
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+		persistence.Provider
+		x.LoggerProvider
+	}
+	Provider interface {
+		Meter() *Meter
+	}
+	// Meter counts the checks and writes of every network in memory. The
+	// counts of a period are reset once they are exported.
+	Meter struct {
+		d dependencies
+
+		mu       sync.Mutex
+		since    time.Time
+		counters map[uuid.UUID]*counters
+	}
+	counters struct {
+		checks, writes int64
+	}
+	// Usage is the usage of a network in a period.
+	Usage struct {
+		NetworkID uuid.UUID `json:"network_id"`
+		From      time.Time `json:"from"`
+		To        time.Time `json:"to"`
+		Checks    int64     `json:"checks"`
+		// Writes is the number of requests that changed relation tuples.
+		Writes int64 `json:"writes"`
+		// StoredTuples is the number of relation tuples at the end of the
+		// period.
+		StoredTuples int64 `json:"stored_tuples"`
+	}
+)
+
+func NewMeter(d dependencies) *Meter {
+	return &Meter{
+		d:        d,
+		since:    time.Now().UTC(),
+		counters: map[uuid.UUID]*counters{},
+	}
+}
+
+func (m *Meter) count(ctx context.Context, f func(c *counters)) {
+	if !m.d.Config(ctx).MeteringEnabled() {
+		return
+	}
+	nid := m.d.Persister().NetworkID(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counters[nid]
+	if !ok {
+		c = &counters{}
+		m.counters[nid] = c
+	}
+	f(c)
+}
+
+// CountCheck counts a check of the network in the context.
+func (m *Meter) CountCheck(ctx context.Context) {
+	m.count(ctx, func(c *counters) { c.checks++ })
+}
+
+// CountWrite counts a request that changed relation tuples of the network in
+// the context.
+func (m *Meter) CountWrite(ctx context.Context) {
+	m.count(ctx, func(c *counters) { c.writes++ })
+}
+
+// Usage returns the usage of all networks in the current period.
+func (m *Meter) Usage(ctx context.Context) ([]*Usage, error) {
+	return m.report(ctx, false)
+}
+
+// rotate returns the usage of all networks in the current period and starts a
+// new one.
+func (m *Meter) rotate(ctx context.Context) ([]*Usage, error) {
+	return m.report(ctx, true)
+}
+
+func (m *Meter) report(ctx context.Context, reset bool) ([]*Usage, error) {
+	// Count the tuples first, so that a failing query does not lose the
+	// counters of the period.
+	stored := map[uuid.UUID]int64{}
+	if tc, ok := m.d.Persister().(persistence.TupleCounter); ok {
+		var err error
+		stored, err = tc.CountRelationTuplesByNetwork(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	from, to, cs := m.since, time.Now().UTC(), m.counters
+	if reset {
+		m.since, m.counters = to, map[uuid.UUID]*counters{}
+	} else {
+		cs = make(map[uuid.UUID]*counters, len(m.counters))
+		for nid, c := range m.counters {
+			cc := *c
+			cs[nid] = &cc
+		}
+	}
+	m.mu.Unlock()
+
+	usage := make([]*Usage, 0, len(cs)+len(stored))
+	for nid, c := range cs {
+		usage = append(usage, &Usage{NetworkID: nid, Checks: c.checks, Writes: c.writes, StoredTuples: stored[nid]})
+	}
+	for nid, n := range stored {
+		if _, ok := cs[nid]; !ok {
+			usage = append(usage, &Usage{NetworkID: nid, StoredTuples: n})
+		}
+	}
+	for _, u := range usage {
+		u.From, u.To = from, to
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].NetworkID.String() < usage[j].NetworkID.String()
+	})
+	return usage, nil
+}
+
+// restore adds the counts of a rotated period that could not be exported back
+// to the current period.
+func (m *Meter) restore(usage []*Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range usage {
+		if u.From.Before(m.since) {
+			m.since = u.From
+		}
+		c, ok := m.counters[u.NetworkID]
+		if !ok {
+			c = &counters{}
+			m.counters[u.NetworkID] = c
+		}
+		c.checks += u.Checks
+		c.writes += u.Writes
+	}
+}
@@ -0,0 +1,96 @@
+package metering_test
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/metering"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestMeter(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+	nid := reg.Persister().NetworkID(ctx)
+
+	use := func(t *testing.T, object string) {
+		tuple := &relationtuple.InternalRelationTuple{
+			Namespace: "n",
+			Object:    object,
+			Relation:  "r",
+			Subject:   &relationtuple.SubjectID{ID: "s"},
+		}
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+		for i := 0; i < 2; i++ {
+			allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple, 0)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+		}
+	}
+	usage := func(t *testing.T) *metering.Usage {
+		usage, err := reg.Meter().Usage(ctx)
+		require.NoError(t, err)
+		require.Len(t, usage, 1)
+		assert.Equal(t, nid, usage[0].NetworkID)
+		return usage[0]
+	}
+
+	t.Run("case=does not count when disabled", func(t *testing.T) {
+		use(t, "o1")
+		u := usage(t)
+		assert.Zero(t, u.Checks)
+		assert.Zero(t, u.Writes)
+		assert.EqualValues(t, 1, u.StoredTuples)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyMeteringEnabled, true))
+
+	t.Run("case=counts checks and writes", func(t *testing.T) {
+		use(t, "o2")
+		u := usage(t)
+		assert.EqualValues(t, 2, u.Checks)
+		assert.EqualValues(t, 1, u.Writes)
+		assert.EqualValues(t, 2, u.StoredTuples)
+		assert.True(t, u.From.Before(u.To))
+	})
+
+	t.Run("case=keeps the counts if the export fails", func(t *testing.T) {
+		require.Error(t, reg.Meter().Export(ctx, filepath.Join(t.TempDir(), "missing")))
+		assert.EqualValues(t, 2, usage(t).Checks)
+	})
+
+	t.Run("case=exports the period and starts a new one", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, reg.Meter().Export(ctx, dir))
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Regexp(t, `^keto-usage-.+-[0-9]{8}T[0-9]{6}Z\.csv$`, filepath.Base(files[0]))
+
+		f, err := os.Open(files[0])
+		require.NoError(t, err)
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"network_id", "from", "to", "checks", "writes", "stored_tuples"}, records[0])
+		assert.Equal(t, nid.String(), records[1][0])
+		assert.Equal(t, []string{"2", "1", "2"}, records[1][3:])
+
+		u := usage(t)
+		assert.Zero(t, u.Checks)
+		assert.Zero(t, u.Writes)
+		assert.EqualValues(t, 2, u.StoredTuples)
+	})
+}
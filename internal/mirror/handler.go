@@ -0,0 +1,72 @@
+package mirror
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const RouteReconciliation = "/admin/mirror/reconciliation"
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteReconciliation, h.getReconciliation)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:route GET /admin/mirror/reconciliation write getMirrorReconciliation
+//
+// Compare the relation tuples with the secondary Keto
+//
+// Lists the relation tuples of every namespace that are only stored by this
+// or only by the secondary Keto that writes are mirrored to. This reads all
+// relation tuples of both, so only use it to verify a migration.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: reconciliationReport
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getReconciliation(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if _, write := h.d.Config(r.Context()).MirrorRemotes(); write == "" {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrNotFound.WithReasonf("Writes are not mirrored, set %s to enable it.", config.KeyMirrorWriteRemote)))
+		return
+	}
+
+	report, err := h.d.Mirror().Reconcile(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, report)
+}
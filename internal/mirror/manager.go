@@ -0,0 +1,42 @@
+package mirror
+
+import (
+	"context"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// mirroredManager queues every successful call that changes relation tuples
+// to be forwarded to the secondary Keto.
+type mirroredManager struct {
+	relationtuple.Manager
+	m *Mirror
+}
+
+// Manager wraps the relation tuple manager to mirror its writes.
+func (m *Mirror) Manager(inner relationtuple.Manager) relationtuple.Manager {
+	return relationtuple.KeepObjectsGetter(&mirroredManager{Manager: inner, m: m}, inner)
+}
+
+func (mm *mirroredManager) mirrored(ctx context.Context, op *operation, err error) error {
+	if err == nil {
+		mm.m.enqueue(ctx, op)
+	}
+	return err
+}
+
+func (mm *mirroredManager) WriteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return mm.mirrored(ctx, &operation{insert: rs}, mm.Manager.WriteRelationTuples(ctx, rs...))
+}
+
+func (mm *mirroredManager) DeleteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	return mm.mirrored(ctx, &operation{delete: rs}, mm.Manager.DeleteRelationTuples(ctx, rs...))
+}
+
+func (mm *mirroredManager) DeleteAllRelationTuples(ctx context.Context, query *relationtuple.RelationQuery) error {
+	return mm.mirrored(ctx, &operation{deleteAll: query}, mm.Manager.DeleteAllRelationTuples(ctx, query))
+}
+
+func (mm *mirroredManager) TransactRelationTuples(ctx context.Context, insert []*relationtuple.InternalRelationTuple, delete []*relationtuple.InternalRelationTuple) error {
+	return mm.mirrored(ctx, &operation{insert: insert, delete: delete}, mm.Manager.TransactRelationTuples(ctx, insert, delete))
+}
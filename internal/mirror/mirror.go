@@ -0,0 +1,197 @@
+package mirror
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	Provider interface {
+		Mirror() *Mirror
+	}
+	dependencies interface {
+		relationtuple.ManagerProvider
+		config.Provider
+		x.LoggerProvider
+	}
+	// Mirror forwards writes to a secondary Keto in the order they were
+	// applied locally.
+	Mirror struct {
+		d     dependencies
+		queue chan *operation
+		// pending also counts the write that is being forwarded.
+		pending int64
+
+		connLock sync.Mutex
+		conns    map[string]*grpc.ClientConn
+	}
+	operation struct {
+		insert, delete []*relationtuple.InternalRelationTuple
+		deleteAll      *relationtuple.RelationQuery
+		appliedAt      time.Time
+	}
+)
+
+const maxRetryInterval = 30 * time.Second
+
+var (
+	lagSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "keto",
+		Name:      "mirror_lag_seconds",
+		Help:      "The time between applying the latest mirrored write locally and on the secondary Keto.",
+	})
+	queuedWrites = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "keto",
+		Name:      "mirror_queued_writes",
+		Help:      "The number of writes waiting to be forwarded to the secondary Keto.",
+	})
+	failuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "keto",
+		Name:      "mirror_failures_total",
+		Help:      "The number of failed attempts to forward a write to the secondary Keto. Failed writes are retried.",
+	})
+	droppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "keto",
+		Name:      "mirror_dropped_total",
+		Help:      "The number of writes that were not mirrored because the queue was full.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(lagSeconds, queuedWrites, failuresTotal, droppedTotal)
+}
+
+func NewMirror(d dependencies) *Mirror {
+	return &Mirror{
+		d:     d,
+		queue: make(chan *operation, d.Config(context.Background()).MirrorQueueSize()),
+		conns: map[string]*grpc.ClientConn{},
+	}
+}
+
+func (m *Mirror) enabled(ctx context.Context) bool {
+	_, write := m.d.Config(ctx).MirrorRemotes()
+	return write != ""
+}
+
+func (m *Mirror) enqueue(ctx context.Context, op *operation) {
+	if !m.enabled(ctx) {
+		return
+	}
+
+	op.appliedAt = time.Now()
+	atomic.AddInt64(&m.pending, 1)
+	queuedWrites.Inc()
+	select {
+	case m.queue <- op:
+	default:
+		atomic.AddInt64(&m.pending, -1)
+		queuedWrites.Dec()
+		droppedTotal.Inc()
+		m.d.Logger().Error("Dropped a write because the mirror queue is full, reconcile the secondary Keto later.")
+	}
+}
+
+// Pending returns the number of writes waiting to be forwarded.
+func (m *Mirror) Pending() int {
+	return int(atomic.LoadInt64(&m.pending))
+}
+
+// Run forwards the queued writes until the context is canceled. Failed
+// writes are retried until they succeed, as skipping them would reorder the
+// writes.
+func (m *Mirror) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case op := <-m.queue:
+			m.forward(ctx, op)
+			atomic.AddInt64(&m.pending, -1)
+			queuedWrites.Dec()
+		}
+	}
+}
+
+func (m *Mirror) forward(ctx context.Context, op *operation) {
+	for wait := time.Second; ; wait *= 2 {
+		err := m.apply(ctx, op)
+		if err == nil {
+			lagSeconds.Set(time.Since(op.appliedAt).Seconds())
+			return
+		}
+
+		if wait > maxRetryInterval {
+			wait = maxRetryInterval
+		}
+		failuresTotal.Inc()
+		m.d.Logger().WithError(err).Warnf("Could not forward a write to the secondary Keto, retrying in %s.", wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (m *Mirror) apply(ctx context.Context, op *operation) error {
+	_, remote := m.d.Config(ctx).MirrorRemotes()
+	conn, err := m.conn(remote)
+	if err != nil {
+		return err
+	}
+	c := rts.NewWriteServiceClient(conn)
+
+	if op.deleteAll != nil {
+		q := &rts.DeleteRelationTuplesRequest_Query{
+			Namespace: op.deleteAll.Namespace,
+			Object:    op.deleteAll.Object,
+			Relation:  op.deleteAll.Relation,
+		}
+		if s := op.deleteAll.Subject(); s != nil {
+			q.Subject = s.ToProto()
+		}
+		_, err := c.DeleteRelationTuples(ctx, &rts.DeleteRelationTuplesRequest{Query: q})
+		return errors.WithStack(err)
+	}
+
+	deltas := make([]*rts.RelationTupleDelta, 0, len(op.insert)+len(op.delete))
+	for _, r := range op.insert {
+		deltas = append(deltas, &rts.RelationTupleDelta{Action: rts.RelationTupleDelta_ACTION_INSERT, RelationTuple: r.ToProto()})
+	}
+	for _, r := range op.delete {
+		deltas = append(deltas, &rts.RelationTupleDelta{Action: rts.RelationTupleDelta_ACTION_DELETE, RelationTuple: r.ToProto()})
+	}
+	_, err = c.TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{RelationTupleDeltas: deltas})
+	return errors.WithStack(err)
+}
+
+// conn returns a connection to the remote. It is established in the
+// background, so that an unavailable secondary does not block the server.
+func (m *Mirror) conn(remote string) (*grpc.ClientConn, error) {
+	m.connLock.Lock()
+	defer m.connLock.Unlock()
+
+	if conn, ok := m.conns[remote]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(remote, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m.conns[remote] = conn
+	return conn, nil
+}
@@ -0,0 +1,105 @@
+package mirror_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	nn := []*namespace.Namespace{{ID: 0, Name: "doc"}}
+
+	secondary := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, secondary.Config(ctx).Set(config.KeyNamespaces, nn))
+	s := grpc.NewServer()
+	h := relationtuple.NewHandler(secondary)
+	h.RegisterReadGRPC(s)
+	h.RegisterWriteGRPC(s)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, nn))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyMirrorReadRemote, l.Addr().String()))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyMirrorWriteRemote, l.Addr().String()))
+
+	tuple := func(object string) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{Namespace: "doc", Object: object, Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice"}}
+	}
+	secondaryTuples := func() []*relationtuple.InternalRelationTuple {
+		res, _, err := secondary.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "doc"})
+		require.NoError(t, err)
+		return res
+	}
+	forward := func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = reg.Mirror().Run(ctx)
+		}()
+		assert.Eventually(t, func() bool { return reg.Mirror().Pending() == 0 }, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+	}
+
+	t.Run("case=forwards writes in order", func(t *testing.T) {
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("a"), tuple("b")))
+		require.NoError(t, reg.RelationTupleManager().TransactRelationTuples(ctx, []*relationtuple.InternalRelationTuple{tuple("c")}, []*relationtuple.InternalRelationTuple{tuple("a")}))
+		require.NoError(t, reg.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "doc", Object: "b"}))
+		assert.Equal(t, 3, reg.Mirror().Pending())
+
+		forward(t)
+		assert.Equal(t, []*relationtuple.InternalRelationTuple{tuple("c")}, secondaryTuples())
+
+		report, err := reg.Mirror().Reconcile(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Namespaces, 1)
+		assert.Equal(t, 1, report.Namespaces[0].Local)
+		assert.Equal(t, 1, report.Namespaces[0].Remote)
+		assert.Zero(t, report.Namespaces[0].MissingCount)
+		assert.Zero(t, report.Namespaces[0].ExtraCount)
+	})
+
+	t.Run("case=reports differences", func(t *testing.T) {
+		require.NoError(t, secondary.RelationTupleManager().WriteRelationTuples(ctx, tuple("extra")))
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("missing")))
+
+		report, err := reg.Mirror().Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Pending)
+		rec := report.Namespaces[0]
+		assert.Equal(t, []*relationtuple.InternalRelationTuple{tuple("missing")}, rec.Missing)
+		assert.Equal(t, 1, rec.MissingCount)
+		assert.Equal(t, []*relationtuple.InternalRelationTuple{tuple("extra")}, rec.Extra)
+		assert.Equal(t, 1, rec.ExtraCount)
+
+		forward(t)
+		report, err = reg.Mirror().Reconcile(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, report.Namespaces[0].MissingCount)
+	})
+
+	t.Run("case=does not queue writes if mirroring is disabled", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyMirrorWriteRemote, ""))
+		t.Cleanup(func() {
+			require.NoError(t, reg.Config(ctx).Set(config.KeyMirrorWriteRemote, l.Addr().String()))
+		})
+
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("local")))
+		assert.Zero(t, reg.Mirror().Pending())
+	})
+}
@@ -0,0 +1,138 @@
+package mirror
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	// The differences between the relation tuples of this and the secondary
+	// Keto
+	//
+	// swagger:model reconciliationReport
+	ReconciliationReport struct {
+		// Pending is the number of writes that were not forwarded yet when
+		// the report was created. They usually explain some differences.
+		Pending    int                        `json:"pending"`
+		Namespaces []*NamespaceReconciliation `json:"namespaces"`
+	}
+	NamespaceReconciliation struct {
+		Namespace string `json:"namespace"`
+		Local     int    `json:"local"`
+		Remote    int    `json:"remote"`
+		// Missing are the relation tuples only stored locally, Extra the ones
+		// only stored on the secondary Keto. At most 100 of each are listed.
+		Missing      []*relationtuple.InternalRelationTuple `json:"missing"`
+		MissingCount int                                    `json:"missing_count"`
+		Extra        []*relationtuple.InternalRelationTuple `json:"extra"`
+		ExtraCount   int                                    `json:"extra_count"`
+	}
+)
+
+const (
+	maxListedTuples = 100
+	pageSize        = 1000
+)
+
+// Reconcile compares the relation tuples of every namespace with the ones of
+// the secondary Keto. It keeps all local tuples of a namespace in memory.
+func (m *Mirror) Reconcile(ctx context.Context) (*ReconciliationReport, error) {
+	read, _ := m.d.Config(ctx).MirrorRemotes()
+	if read == "" {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithReason("No read API of the secondary Keto is configured."))
+	}
+	conn, err := m.conn(read)
+	if err != nil {
+		return nil, err
+	}
+	remote := rts.NewReadServiceClient(conn)
+
+	nm, err := m.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	nn, err := nm.Namespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nn, func(i, j int) bool { return nn[i].Name < nn[j].Name })
+
+	report := &ReconciliationReport{Pending: m.Pending(), Namespaces: make([]*NamespaceReconciliation, len(nn))}
+	for i, n := range nn {
+		rec := &NamespaceReconciliation{
+			Namespace: n.Name,
+			Missing:   []*relationtuple.InternalRelationTuple{},
+			Extra:     []*relationtuple.InternalRelationTuple{},
+		}
+		report.Namespaces[i] = rec
+
+		local := map[string]*relationtuple.InternalRelationTuple{}
+		for page := ""; ; {
+			rels, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: n.Name}, x.WithSize(pageSize), x.WithToken(page))
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rels {
+				local[r.String()] = r
+			}
+			if next == "" {
+				break
+			}
+			page = next
+		}
+		rec.Local = len(local)
+
+		for page := ""; ; {
+			res, err := remote.ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+				Query:     &rts.ListRelationTuplesRequest_Query{Namespace: n.Name},
+				PageSize:  pageSize,
+				PageToken: page,
+			})
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for _, pr := range res.RelationTuples {
+				r, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(pr)
+				if err != nil {
+					return nil, err
+				}
+				rec.Remote++
+				if _, ok := local[r.String()]; ok {
+					delete(local, r.String())
+					continue
+				}
+				rec.ExtraCount++
+				if len(rec.Extra) < maxListedTuples {
+					rec.Extra = append(rec.Extra, r)
+				}
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			page = res.NextPageToken
+		}
+
+		missing := make([]string, 0, len(local))
+		for key := range local {
+			missing = append(missing, key)
+		}
+		sort.Strings(missing)
+		rec.MissingCount = len(missing)
+		for _, key := range missing {
+			if len(rec.Missing) == maxListedTuples {
+				break
+			}
+			rec.Missing = append(rec.Missing, local[key])
+		}
+	}
+
+	return report, nil
+}
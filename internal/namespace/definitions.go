@@ -3,6 +3,15 @@ package namespace
 import (
 	"context"
 	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ory/keto/internal/errorcode"
 )
 
 type (
@@ -11,6 +20,56 @@ type (
 		Name   string          `json:"name" db:"-" toml:"name"`
 		Config json.RawMessage `json:"config,omitempty" db:"-" toml:"config,omitempty"`
 	}
+	// Options are the options in the config of a namespace.
+	Options struct {
+		// Extends names the namespace this namespace inherits the options
+		// from. Options set here override the inherited ones, relation by
+		// relation for the options that are configured per relation.
+		Extends string `json:"extends,omitempty"`
+		// UnicodeNormalization is the normalization form that objects and
+		// subject IDs are converted to when they are written or queried.
+		UnicodeNormalization string `json:"unicode_normalization,omitempty"`
+		// CaseInsensitive lower-cases objects and subject IDs when they are
+		// written or queried.
+		CaseInsensitive bool `json:"case_insensitive,omitempty"`
+		// Hierarchy makes objects inherit relations from the objects that
+		// are prefixes of their path.
+		Hierarchy *Hierarchy `json:"hierarchy,omitempty"`
+		// MaxDepth caps the indirection depth of checks that start at or
+		// pass through the relations, below the global max read depth.
+		MaxDepth map[string]int `json:"max_depth,omitempty"`
+		// Deny lists for a relation the relations of the same object that
+		// override any grant of it.
+		Deny map[string][]string `json:"deny,omitempty"`
+		// Aliases maps old relation names to the relations they were
+		// renamed to. Checks of an alias evaluate the renamed relation.
+		Aliases map[string]string `json:"aliases,omitempty"`
+		// TenantBoundary declares the relation that assigns objects to
+		// tenants, so that checks and subject sets don't cross tenants.
+		TenantBoundary *TenantBoundary `json:"tenant_boundary,omitempty"`
+		// Roles maps role names to the relations that subjects bound to the
+		// role have on the object of the binding.
+		Roles map[string][]string `json:"roles,omitempty"`
+	}
+	// TenantBoundary assigns every object to the subjects of a relation,
+	// e.g. "docs:report#tenant@acme" assigns the object "report" to the
+	// tenant "acme". Objects without the relation belong to no tenant.
+	TenantBoundary struct {
+		// Relation assigns the objects to tenants.
+		Relation string `json:"relation"`
+		// Mode is TenantBoundaryEnforce or TenantBoundaryFlag. It defaults
+		// to TenantBoundaryEnforce.
+		Mode string `json:"mode,omitempty"`
+	}
+	// Hierarchy structures the objects of a namespace as paths, e.g.
+	// "drive/folder/file". An object has the inherited relations of all its
+	// ancestors, here "drive/folder" and "drive".
+	Hierarchy struct {
+		// Separator separates the segments of the path. It defaults to "/".
+		Separator string `json:"separator,omitempty"`
+		// Relations are inherited from the ancestors.
+		Relations []string `json:"relations"`
+	}
 	Manager interface {
 		GetNamespaceByName(ctx context.Context, name string) (*Namespace, error)
 		GetNamespaceByConfigID(ctx context.Context, id int32) (*Namespace, error)
@@ -20,4 +79,333 @@ type (
 	ManagerProvider interface {
 		NamespaceManager() (Manager, error)
 	}
+	// ConfigVersion is a version of the namespace configs stored in the
+	// database.
+	//
+	// swagger:model namespaceConfigVersion
+	ConfigVersion struct {
+		// The version number. Versions increase monotonically.
+		//
+		// required: true
+		Version int64 `json:"version"`
+		// The namespaces of this version.
+		//
+		// required: true
+		Namespaces []*Namespace `json:"namespaces"`
+		// When the version was stored.
+		CreatedAt time.Time `json:"created_at"`
+		// Whether this version is the one served by all instances.
+		Active bool `json:"active"`
+	}
+	// ConfigStore can optionally be implemented by a Persister to store the
+	// namespace configs in the database. Every change adds a new version, and
+	// an activation pointer selects the version that is served.
+	ConfigStore interface {
+		// AddNamespaceConfig stores the namespaces as the version following
+		// the latest one. The new version is not activated. The base version
+		// is the latest version the change is based on, or 0 for the first
+		// version. If another version was added since, the change is
+		// rejected with a conflict.
+		AddNamespaceConfig(ctx context.Context, baseVersion int64, nn []*Namespace) (*ConfigVersion, error)
+		// ActivateNamespaceConfig points the activation pointer to the
+		// version.
+		ActivateNamespaceConfig(ctx context.Context, version int64) (*ConfigVersion, error)
+		// ActiveNamespaceConfigVersion returns the number of the active
+		// version, or 0 if no version was activated yet.
+		ActiveNamespaceConfigVersion(ctx context.Context) (int64, error)
+		GetNamespaceConfig(ctx context.Context, version int64) (*ConfigVersion, error)
+		// NamespaceConfigHistory returns all versions, the latest first.
+		NamespaceConfigHistory(ctx context.Context) ([]*ConfigVersion, error)
+	}
 )
+
+const (
+	// TenantBoundaryEnforce denies checks through subject sets of other
+	// tenants and rejects writing such subject sets.
+	TenantBoundaryEnforce = "enforce"
+	// TenantBoundaryFlag only logs checks and writes crossing tenants.
+	TenantBoundaryFlag = "flag"
+)
+
+// RoleRelationPrefix prefixes the relations that bind subjects to roles, e.g.
+// "docs:report#role:editor@alice".
+const RoleRelationPrefix = "role:"
+
+// NormalizationNFC composes characters, so that e.g. "e" followed by a
+// combining acute accent becomes "é".
+const NormalizationNFC = "nfc"
+
+// denyCycle returns the relations of a cycle of deny relations, starting and
+// ending with the same relation, or nil if there is none. Aliases are resolved
+// the same way the check engine resolves them.
+func (o *Options) denyCycle() []string {
+	relations := make([]string, 0, len(o.Deny))
+	for relation := range o.Deny {
+		relations = append(relations, relation)
+	}
+	sort.Strings(relations)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(relations))
+	var path []string
+	var visit func(relation string) []string
+	visit = func(relation string) []string {
+		switch state[relation] {
+		case visiting:
+			for i, r := range path {
+				if r == relation {
+					return append(append([]string{}, path[i:]...), relation)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[relation] = visiting
+		path = append(path, relation)
+		for _, d := range o.Deny[relation] {
+			if resolved, ok := o.Aliases[d]; ok {
+				d = resolved
+			}
+			if cycle := visit(d); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[relation] = done
+		return nil
+	}
+	for _, relation := range relations {
+		if cycle := visit(relation); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the namespaces can't be served together, i.e.
+// if names or IDs are used twice or a config is invalid.
+func Validate(nn []*Namespace) error {
+	names, ids := make(map[string]bool, len(nn)), make(map[int32]bool, len(nn))
+	for _, n := range nn {
+		invalid := herodot.ErrBadRequest.WithID(errorcode.NamespaceConfigInvalid)
+		switch {
+		case n.Name == "":
+			return errors.WithStack(invalid.WithReasonf("The namespace with id %d has no name.", n.ID))
+		case names[n.Name]:
+			return errors.WithStack(invalid.WithReasonf("The namespace name %q is used more than once.", n.Name))
+		case ids[n.ID]:
+			return errors.WithStack(invalid.WithReasonf("The namespace id %d is used more than once.", n.ID))
+		}
+		opts, err := n.resolvedOptions(func(name string) (*Namespace, error) {
+			for _, base := range nn {
+				if base.Name == name {
+					return base, nil
+				}
+			}
+			return nil, errors.Errorf("the extended namespace %q is unknown", name)
+		})
+		if err != nil {
+			return errors.WithStack(invalid.WithReason(err.Error()))
+		}
+		for relation, depth := range opts.MaxDepth {
+			if depth < 1 {
+				return errors.WithStack(invalid.WithReasonf("The max depth of relation %q in namespace %q has to be at least 1, got %d.", relation, n.Name, depth))
+			}
+		}
+		for relation, deny := range opts.Deny {
+			for _, d := range deny {
+				if d == relation {
+					return errors.WithStack(invalid.WithReasonf("The relation %q in namespace %q can't deny itself.", relation, n.Name))
+				}
+			}
+		}
+		if cycle := opts.denyCycle(); len(cycle) > 0 {
+			return errors.WithStack(invalid.WithReasonf("The deny relations in namespace %q deny each other: %s.", n.Name, strings.Join(cycle, " -> ")))
+		}
+		for alias, relation := range opts.Aliases {
+			if _, chained := opts.Aliases[relation]; chained || relation == "" {
+				return errors.WithStack(invalid.WithReasonf("The alias %q in namespace %q has to name a relation that is not an alias itself, got %q.", alias, n.Name, relation))
+			}
+		}
+		for role, permissions := range opts.Roles {
+			if len(permissions) == 0 {
+				return errors.WithStack(invalid.WithReasonf("The role %q in namespace %q grants no relations.", role, n.Name))
+			}
+			for _, p := range permissions {
+				if p == "" || strings.HasPrefix(p, RoleRelationPrefix) {
+					return errors.WithStack(invalid.WithReasonf("The role %q in namespace %q has to grant relations that don't start with %q, got %q.", role, n.Name, RoleRelationPrefix, p))
+				}
+			}
+		}
+		if b := opts.TenantBoundary; b != nil {
+			if b.Relation == "" {
+				return errors.WithStack(invalid.WithReasonf("The tenant boundary of namespace %q has no relation.", n.Name))
+			}
+			if b.Mode != "" && b.Mode != TenantBoundaryEnforce && b.Mode != TenantBoundaryFlag {
+				return errors.WithStack(invalid.WithReasonf("The tenant boundary mode of namespace %q has to be %q or %q, got %q.", n.Name, TenantBoundaryEnforce, TenantBoundaryFlag, b.Mode))
+			}
+		}
+		names[n.Name], ids[n.ID] = true, true
+	}
+	return nil
+}
+
+// Options parses the options from the config of the namespace.
+func (n *Namespace) Options() (*Options, error) {
+	o := &Options{}
+	if len(n.Config) == 0 {
+		return o, nil
+	}
+	if err := json.Unmarshal(n.Config, o); err != nil {
+		return nil, errors.Wrapf(err, "invalid config of namespace %q", n.Name)
+	}
+	return o, nil
+}
+
+// mergedPerRelation are the options that are merged relation by relation, or
+// role by role, when a namespace extends another one.
+var mergedPerRelation = map[string]bool{"max_depth": true, "deny": true, "aliases": true, "roles": true}
+
+// ResolvedOptions returns the options of the namespace, including the options
+// inherited from the namespaces it extends.
+func ResolvedOptions(ctx context.Context, m Manager, n *Namespace) (*Options, error) {
+	return n.resolvedOptions(func(name string) (*Namespace, error) {
+		return m.GetNamespaceByName(ctx, name)
+	})
+}
+
+func (n *Namespace) resolvedOptions(get func(name string) (*Namespace, error)) (*Options, error) {
+	config, err := n.resolvedConfig(get, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return (&Namespace{Name: n.Name, Config: config}).Options()
+}
+
+// resolvedConfig returns the config of the namespace merged into the ones of
+// the namespaces it extends.
+func (n *Namespace) resolvedConfig(get func(name string) (*Namespace, error), extending map[string]bool) (json.RawMessage, error) {
+	opts, err := n.Options()
+	if err != nil || opts.Extends == "" {
+		return n.Config, err
+	}
+	if extending[n.Name] {
+		return nil, errors.Errorf("namespace %q extends itself through the namespaces it extends", n.Name)
+	}
+	extending[n.Name] = true
+
+	base, err := get(opts.Extends)
+	if err != nil {
+		return nil, err
+	}
+	baseConfig, err := base.resolvedConfig(get, extending)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]json.RawMessage{}
+	if len(baseConfig) > 0 {
+		if err := json.Unmarshal(baseConfig, &merged); err != nil {
+			return nil, errors.Wrapf(err, "invalid config of namespace %q", base.Name)
+		}
+	}
+	var own map[string]json.RawMessage
+	if err := json.Unmarshal(n.Config, &own); err != nil {
+		return nil, errors.Wrapf(err, "invalid config of namespace %q", n.Name)
+	}
+	delete(merged, "extends")
+	for k, v := range own {
+		if inherited, ok := merged[k]; ok && mergedPerRelation[k] {
+			if v, err = mergeObjects(inherited, v); err != nil {
+				return nil, errors.Wrapf(err, "invalid config of namespace %q", n.Name)
+			}
+		}
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+func mergeObjects(base, override json.RawMessage) (json.RawMessage, error) {
+	var b, o map[string]json.RawMessage
+	if err := json.Unmarshal(base, &b); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(override, &o); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if b == nil {
+		b = map[string]json.RawMessage{}
+	}
+	for k, v := range o {
+		b[k] = v
+	}
+	return json.Marshal(b)
+}
+
+// Normalize returns the identifier in the normalization form of the options,
+// lower-cased if the namespace is case-insensitive.
+func (o *Options) Normalize(s string) string {
+	if o.UnicodeNormalization == NormalizationNFC {
+		s = norm.NFC.String(s)
+	}
+	if o.CaseInsensitive {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// CapDepth returns the rest depth capped at the max depth of the relation.
+func (o *Options) CapDepth(relation string, restDepth int) int {
+	if max, ok := o.MaxDepth[relation]; ok && max < restDepth {
+		return max
+	}
+	return restDepth
+}
+
+// Resolve returns the relation the alias stands for, or the relation itself
+// if it is not an alias.
+func (o *Options) Resolve(relation string) (string, bool) {
+	if r, ok := o.Aliases[relation]; ok {
+		return r, true
+	}
+	return relation, false
+}
+
+// Enforced returns true if crossing the boundary is denied, not only logged.
+func (b *TenantBoundary) Enforced() bool {
+	return b.Mode != TenantBoundaryFlag
+}
+
+// Inherits returns true if the relation is inherited from the ancestors.
+func (h *Hierarchy) Inherits(relation string) bool {
+	if h == nil {
+		return false
+	}
+	for _, r := range h.Relations {
+		if r == relation {
+			return true
+		}
+	}
+	return false
+}
+
+// Ancestors returns the ancestors of the object, the nearest first. Empty
+// segments, e.g. of a leading separator, are not ancestors.
+func (h *Hierarchy) Ancestors(object string) []string {
+	sep := h.Separator
+	if sep == "" {
+		sep = "/"
+	}
+
+	var ancestors []string
+	for i := strings.LastIndex(object, sep); i > 0; i = strings.LastIndex(object, sep) {
+		object = object[:i]
+		if !strings.HasSuffix(object, sep) {
+			ancestors = append(ancestors, object)
+		}
+	}
+	return ancestors
+}
@@ -0,0 +1,91 @@
+package namespace_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+)
+
+func TestExtends(t *testing.T) {
+	ctx := context.Background()
+	nn := []*namespace.Namespace{
+		{ID: 0, Name: "base", Config: json.RawMessage(`{"case_insensitive": true}`)},
+		{ID: 1, Name: "document", Config: json.RawMessage(`{"extends": "base", "deny": {"viewer": ["legal_hold"], "editor": ["legal_hold"]}, "hierarchy": {"relations": ["viewer"]}}`)},
+		{ID: 2, Name: "report", Config: json.RawMessage(`{"extends": "document", "deny": {"editor": []}, "aliases": {"reader": "viewer"}}`)},
+		{ID: 3, Name: "memo", Config: json.RawMessage(`{"extends": "document", "case_insensitive": false, "hierarchy": {"separator": ":", "relations": ["editor"]}}`)},
+	}
+	require.NoError(t, namespace.Validate(nn))
+	m := config.NewMemoryNamespaceManager(nn...)
+
+	t.Run("case=inherits and overrides per relation", func(t *testing.T) {
+		opts, err := namespace.ResolvedOptions(ctx, m, nn[2])
+		require.NoError(t, err)
+		assert.True(t, opts.CaseInsensitive)
+		assert.Equal(t, map[string][]string{"viewer": {"legal_hold"}, "editor": {}}, opts.Deny)
+		assert.Equal(t, map[string]string{"reader": "viewer"}, opts.Aliases)
+		assert.Equal(t, []string{"viewer"}, opts.Hierarchy.Relations)
+	})
+
+	t.Run("case=overrides other options as a whole", func(t *testing.T) {
+		opts, err := namespace.ResolvedOptions(ctx, m, nn[3])
+		require.NoError(t, err)
+		assert.False(t, opts.CaseInsensitive)
+		assert.Equal(t, &namespace.Hierarchy{Separator: ":", Relations: []string{"editor"}}, opts.Hierarchy)
+	})
+
+	t.Run("case=namespaces without extends are unchanged", func(t *testing.T) {
+		opts, err := namespace.ResolvedOptions(ctx, m, nn[0])
+		require.NoError(t, err)
+		assert.Equal(t, &namespace.Options{CaseInsensitive: true}, opts)
+	})
+
+	t.Run("case=rejects invalid extends", func(t *testing.T) {
+		for _, tc := range []struct {
+			desc string
+			nn   []*namespace.Namespace
+		}{
+			{
+				desc: "unknown namespace",
+				nn:   []*namespace.Namespace{{Name: "a", Config: json.RawMessage(`{"extends": "b"}`)}},
+			},
+			{
+				desc: "cycle",
+				nn: []*namespace.Namespace{
+					{ID: 0, Name: "a", Config: json.RawMessage(`{"extends": "b"}`)},
+					{ID: 1, Name: "b", Config: json.RawMessage(`{"extends": "a"}`)},
+				},
+			},
+			{
+				desc: "invalid merged options",
+				nn: []*namespace.Namespace{
+					{ID: 0, Name: "a", Config: json.RawMessage(`{"aliases": {"viewer": "watcher"}}`)},
+					{ID: 1, Name: "b", Config: json.RawMessage(`{"extends": "a", "aliases": {"reader": "viewer"}}`)},
+				},
+			},
+			{
+				desc: "deny relations denying each other",
+				nn: []*namespace.Namespace{
+					{ID: 0, Name: "a", Config: json.RawMessage(`{"deny": {"viewer": ["blocked"]}, "aliases": {"banned": "viewer"}}`)},
+					{ID: 1, Name: "b", Config: json.RawMessage(`{"extends": "a", "deny": {"blocked": ["banned"]}}`)},
+				},
+			},
+			{
+				desc: "role granting role relations",
+				nn: []*namespace.Namespace{
+					{ID: 0, Name: "a", Config: json.RawMessage(`{"roles": {"viewer": ["view"]}}`)},
+					{ID: 1, Name: "b", Config: json.RawMessage(`{"extends": "a", "roles": {"admin": ["role:viewer"]}}`)},
+				},
+			},
+		} {
+			t.Run("case="+tc.desc, func(t *testing.T) {
+				assert.Error(t, namespace.Validate(tc.nn))
+			})
+		}
+	})
+}
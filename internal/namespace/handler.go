@@ -0,0 +1,338 @@
+package namespace
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	ConfigStoreProvider interface {
+		// NamespaceConfigStore returns nil if the storage backend does not
+		// support storing namespace configs.
+		NamespaceConfigStore() ConfigStore
+	}
+	handlerDependencies interface {
+		ConfigStoreProvider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	ConfigRouteBase   = "/admin/namespace-configs"
+	ActiveConfigRoute = ConfigRouteBase + "/active"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(ConfigRouteBase, h.getConfigHistory)
+	r.POST(ConfigRouteBase, h.createConfig)
+	r.GET(ConfigRouteBase+"/:version", h.getConfig)
+	r.PUT(ActiveConfigRoute, h.activateConfig)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// ErrPreconditionRequired is returned for changes that do not say which
+// version they are based on.
+var ErrPreconditionRequired = herodot.DefaultError{
+	IDField:       errorcode.PreconditionRequired,
+	StatusField:   http.StatusText(http.StatusPreconditionRequired),
+	ErrorField:    "The change has to state the version it is based on, in the If-Match header or the base_version field.",
+	CodeField:     http.StatusPreconditionRequired,
+	GRPCCodeField: codes.FailedPrecondition,
+}
+
+// versionETag returns the entity tag of the version. The tag of the history
+// is the one of the latest version.
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// baseVersion returns the version a change is based on, from the If-Match
+// header or, if it is not set, from the body.
+func baseVersion(r *http.Request, fromBody *int64) (int64, error) {
+	ifMatch := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-Match")), "W/")
+	if ifMatch == "" {
+		if fromBody == nil {
+			return 0, errors.WithStack(ErrPreconditionRequired)
+		}
+		return *fromBody, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithReasonf("The If-Match header has to hold the entity tag of a version, got %s.", ifMatch))
+	}
+	return version, nil
+}
+
+func (h *Handler) store() (ConfigStore, error) {
+	s := h.d.NamespaceConfigStore()
+	if s == nil {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not support storing namespace configs"))
+	}
+	return s, nil
+}
+
+// The stored versions of the namespace configs
+//
+// swagger:model getNamespaceConfigHistoryResponse
+type getConfigHistoryResponse struct {
+	// The versions, the latest first.
+	//
+	// required: true
+	Versions []*ConfigVersion `json:"versions"`
+}
+
+// swagger:route GET /admin/namespace-configs write getNamespaceConfigHistory
+//
+// List the versions of the namespace configs
+//
+// Returns all versions of the namespace configs stored in the database, the
+// latest first, and which one is active. The ETag of the response is the one
+// of the latest version.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getNamespaceConfigHistoryResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) getConfigHistory(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := h.store()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	vv, err := s.NamespaceConfigHistory(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	var latest int64
+	if len(vv) > 0 {
+		latest = vv[0].Version
+	}
+	w.Header().Set("ETag", versionETag(latest))
+	h.d.Writer().Write(w, r, &getConfigHistoryResponse{Versions: vv})
+}
+
+// The request to store a version of the namespace configs
+//
+// swagger:model createNamespaceConfigBody
+type createConfigBody struct {
+	// The namespaces of the new version.
+	//
+	// required: true
+	Namespaces []*Namespace `json:"namespaces"`
+
+	// The latest version the change is based on, or 0 if there is none.
+	// Alternatively, send the ETag of that version in the If-Match header.
+	BaseVersion *int64 `json:"base_version"`
+
+	// Activate the new version right away.
+	Activate bool `json:"activate"`
+}
+
+// swagger:parameters createNamespaceConfig
+// nolint:deadcode,unused
+type createConfigParameters struct {
+	// The ETag of the latest version the change is based on.
+	//
+	// in: header
+	IfMatch string `json:"If-Match"`
+
+	// in: body
+	Body createConfigBody
+}
+
+// swagger:route POST /admin/namespace-configs write createNamespaceConfig
+//
+// Store a version of the namespace configs
+//
+// Stores the namespaces as the version following the latest one. Instances
+// only serve the new version once it is activated.
+//
+// The change has to state the latest version it is based on, either in the
+// If-Match header or in the base_version field. If another version was added
+// since, the change is rejected with a 409 Conflict, so that concurrent
+// changes don't overwrite each other.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: namespaceConfigVersion
+//       400: genericError
+//       409: genericError
+//       428: genericError
+//       500: genericError
+func (h *Handler) createConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := h.store()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var body createConfigBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	base, err := baseVersion(r, body.BaseVersion)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	v, err := s.AddNamespaceConfig(r.Context(), base, body.Namespaces)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	if body.Activate {
+		if v, err = s.ActivateNamespaceConfig(r.Context(), v.Version); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("ETag", versionETag(v.Version))
+	h.d.Writer().WriteCreated(w, r, ConfigRouteBase+"/"+strconv.FormatInt(v.Version, 10), v)
+}
+
+// swagger:parameters getNamespaceConfig
+// nolint:deadcode,unused
+type getConfigParameters struct {
+	// The version number.
+	//
+	// in: path
+	// required: true
+	Version int64 `json:"version"`
+}
+
+// swagger:route GET /admin/namespace-configs/{version} write getNamespaceConfig
+//
+// Get a version of the namespace configs
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: namespaceConfigVersion
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	s, err := h.store()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	version, err := strconv.ParseInt(ps.ByName("version"), 10, 64)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	v, err := s.GetNamespaceConfig(r.Context(), version)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", versionETag(v.Version))
+	h.d.Writer().Write(w, r, v)
+}
+
+// The request to activate a version of the namespace configs
+//
+// swagger:model activateNamespaceConfigBody
+type activateConfigBody struct {
+	// The version to activate.
+	//
+	// required: true
+	Version int64 `json:"version"`
+}
+
+// swagger:parameters activateNamespaceConfig
+// nolint:deadcode,unused
+type activateConfigParameters struct {
+	// in: body
+	Body activateConfigBody
+}
+
+// swagger:route PUT /admin/namespace-configs/active write activateNamespaceConfig
+//
+// Activate a version of the namespace configs
+//
+// Points the activation pointer to the version. All instances that serve the
+// namespaces from the database switch to it within their poll interval. Any
+// earlier version can be activated again to roll back.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: namespaceConfigVersion
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) activateConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := h.store()
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var body activateConfigBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	v, err := s.ActivateNamespaceConfig(r.Context(), body.Version)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, v)
+}
@@ -0,0 +1,93 @@
+package namespace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestHandler(t *testing.T) {
+	reg := driver.NewSqliteTestRegistry(t, false)
+	r := httprouter.New()
+	namespace.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string, expectedStatus int, header ...string) *namespace.ConfigVersion {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		require.Equal(t, expectedStatus, resp.StatusCode, buf.String())
+
+		var v namespace.ConfigVersion
+		_ = json.Unmarshal(buf.Bytes(), &v)
+		return &v
+	}
+
+	t.Run("case=creates and activates versions", func(t *testing.T) {
+		v := do(t, http.MethodPost, namespace.ConfigRouteBase, `{"namespaces": [{"id": 1, "name": "files"}], "base_version": 0}`, http.StatusCreated)
+		assert.Equal(t, int64(1), v.Version)
+		assert.False(t, v.Active)
+
+		v = do(t, http.MethodPost, namespace.ConfigRouteBase, `{"namespaces": [{"id": 1, "name": "documents"}], "activate": true}`, http.StatusCreated, "If-Match", `"1"`)
+		assert.Equal(t, int64(2), v.Version)
+		assert.True(t, v.Active)
+
+		v = do(t, http.MethodPut, namespace.ActiveConfigRoute, `{"version": 1}`, http.StatusOK)
+		assert.True(t, v.Active)
+		assert.Equal(t, "files", v.Namespaces[0].Name)
+
+		v = do(t, http.MethodGet, namespace.ConfigRouteBase+"/2", "", http.StatusOK)
+		assert.False(t, v.Active)
+		assert.Equal(t, "documents", v.Namespaces[0].Name)
+	})
+
+	t.Run("case=lists the history", func(t *testing.T) {
+		resp, err := ts.Client().Get(ts.URL + namespace.ConfigRouteBase)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Versions []*namespace.ConfigVersion `json:"versions"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Versions, 2)
+		assert.Equal(t, int64(2), body.Versions[0].Version)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("case=requires preconditions", func(t *testing.T) {
+		update := `{"namespaces": [{"id": 1, "name": "files"}]}`
+		do(t, http.MethodPost, namespace.ConfigRouteBase, update, http.StatusPreconditionRequired)
+		do(t, http.MethodPost, namespace.ConfigRouteBase, update, http.StatusConflict, "If-Match", `"1"`)
+		do(t, http.MethodPost, namespace.ConfigRouteBase, `{"namespaces": [], "base_version": 1}`, http.StatusConflict)
+		do(t, http.MethodPost, namespace.ConfigRouteBase, update, http.StatusBadRequest, "If-Match", `"latest"`)
+	})
+
+	t.Run("case=rejects invalid requests", func(t *testing.T) {
+		do(t, http.MethodPost, namespace.ConfigRouteBase, `{"namespaces": [{"id": 1, "name": "a"}, {"id": 1, "name": "b"}], "base_version": 2}`, http.StatusBadRequest)
+		do(t, http.MethodGet, namespace.ConfigRouteBase+"/42", "", http.StatusNotFound)
+		do(t, http.MethodGet, namespace.ConfigRouteBase+"/latest", "", http.StatusBadRequest)
+		do(t, http.MethodPut, namespace.ActiveConfigRoute, `{"version": 42}`, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,245 @@
+package objectfilter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/bloom"
+)
+
+type (
+	Provider interface {
+		ObjectFilter() *Filter
+	}
+	dependencies interface {
+		persistence.Provider
+		config.Provider
+		x.LoggerProvider
+	}
+	// Filter tracks the objects of every namespace in bloom filters, so that
+	// checks of objects without relation tuples can be denied without
+	// querying the database. Only the network of the server is filtered.
+	Filter struct {
+		d dependencies
+
+		mu  sync.RWMutex
+		nid uuid.UUID
+		// current is nil until the first build finished.
+		current map[string]*bloom.Filter
+		// written collects the objects written during a rebuild, which might
+		// be missing from the scanned tuples. It is nil otherwise.
+		written           map[string][]string
+		falsePositiveRate float64
+	}
+	bypassContextKey struct{}
+)
+
+const (
+	pageSize = 1000
+	// The filters are sized with headroom for writes until the next rebuild.
+	growthFactor = 2
+	// minCapacity is the size of filters of namespaces without objects.
+	minCapacity = 1024
+)
+
+var shortCircuitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "object_filter_short_circuits_total",
+	Help:      "The number of checks that were denied by the object filter without querying the database.",
+})
+
+func init() {
+	prometheus.MustRegister(shortCircuitsTotal)
+}
+
+func NewFilter(d dependencies) *Filter {
+	return &Filter{d: d}
+}
+
+// WithoutFilter returns a context in which checks are not filtered, e.g. to
+// verify the results of filtered checks.
+func WithoutFilter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassContextKey{}, true)
+}
+
+// MayHaveTuples returns false if the object has no relation tuples in the
+// namespace. It returns true if that is unknown, e.g. while the filters are
+// built.
+func (f *Filter) MayHaveTuples(ctx context.Context, namespace, object string) bool {
+	if !f.d.Config(ctx).ObjectFilterEnabled() || config.IsCanary(ctx) || ctx.Value(bypassContextKey{}) != nil {
+		return true
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.current == nil || f.d.Persister().NetworkID(ctx) != f.nid {
+		return true
+	}
+	if nf, ok := f.current[namespace]; ok && nf.Test(f.normalize(ctx, namespace, object)) {
+		return true
+	}
+	shortCircuitsTotal.Inc()
+	return false
+}
+
+// normalize returns the object as it is stored, so that it can be tested
+// against the filters.
+func (f *Filter) normalize(ctx context.Context, ns, object string) string {
+	nm, err := f.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return object
+	}
+	n, err := nm.GetNamespaceByName(ctx, ns)
+	if err != nil {
+		return object
+	}
+	opts, err := namespace.ResolvedOptions(ctx, nm, n)
+	if err != nil {
+		return object
+	}
+	return opts.Normalize(object)
+}
+
+// ApplyChange adds the objects written by other servers, as received from
+// the changefeed.
+func (f *Filter) ApplyChange(c *persistence.Change) {
+	if !c.Deleted {
+		f.add(context.Background(), c.NetworkID, []*relationtuple.InternalRelationTuple{c.RelationTuple})
+	}
+}
+
+// add adds the objects of the relation tuples in their stored form, as the
+// written tuples are the ones of the caller, before the persister normalized
+// them.
+func (f *Filter) add(ctx context.Context, nid uuid.UUID, rs []*relationtuple.InternalRelationTuple) {
+	objects := make([]string, len(rs))
+	for i, r := range rs {
+		objects[i] = f.normalize(ctx, r.Namespace, r.Object)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if nid != f.nid {
+		return
+	}
+	for i, r := range rs {
+		if f.written != nil {
+			f.written[r.Namespace] = append(f.written[r.Namespace], objects[i])
+		}
+		if f.current == nil {
+			continue
+		}
+		nf, ok := f.current[r.Namespace]
+		if !ok {
+			nf = bloom.New(minCapacity, f.falsePositiveRate)
+			f.current[r.Namespace] = nf
+		}
+		nf.Add(objects[i])
+	}
+}
+
+// Rebuild reads the objects of all relation tuples of the network in the
+// context and replaces the filters.
+func (f *Filter) Rebuild(ctx context.Context) error {
+	_, falsePositiveRate := f.d.Config(ctx).ObjectFilter()
+
+	nm, err := f.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return err
+	}
+	nn, err := nm.Namespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	if nid := f.d.Persister().NetworkID(ctx); nid != f.nid {
+		f.nid, f.current = nid, nil
+	}
+	f.written, f.falsePositiveRate = map[string][]string{}, falsePositiveRate
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.written = nil
+		f.mu.Unlock()
+	}()
+
+	// The objects are collected first to size the filters.
+
+	objects := make(map[string]map[string]struct{}, len(nn))
+	for _, n := range nn {
+		seen := map[string]struct{}{}
+		for page := ""; ; {
+			rels, next, err := f.d.Persister().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: n.Name}, x.WithSize(pageSize), x.WithToken(page))
+			if err != nil {
+				return err
+			}
+			for _, r := range rels {
+				seen[r.Object] = struct{}{}
+			}
+			if next == "" {
+				break
+			}
+			page = next
+		}
+		objects[n.Name] = seen
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for namespace, written := range f.written {
+		if objects[namespace] == nil {
+			objects[namespace] = map[string]struct{}{}
+		}
+		for _, object := range written {
+			objects[namespace][object] = struct{}{}
+		}
+	}
+	filters := make(map[string]*bloom.Filter, len(objects))
+	for namespace, seen := range objects {
+		capacity := len(seen) * growthFactor
+		if capacity < minCapacity {
+			capacity = minCapacity
+		}
+		nf := bloom.New(capacity, falsePositiveRate)
+		for object := range seen {
+			nf.Add(object)
+		}
+		filters[namespace] = nf
+	}
+	f.current = filters
+	return nil
+}
+
+// Run builds the filters and rebuilds them in the configured
+// interval until the context is canceled.
+func (f *Filter) Run(ctx context.Context) error {
+	for {
+		interval, _ := f.d.Config(ctx).ObjectFilter()
+		if f.d.Config(ctx).ObjectFilterEnabled() {
+			if err := f.Rebuild(ctx); err != nil {
+				f.d.Logger().WithError(err).Error("Could not rebuild the object filter, checks are not filtered.")
+				f.mu.Lock()
+				f.current = nil
+				f.mu.Unlock()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
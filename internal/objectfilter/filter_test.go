@@ -0,0 +1,110 @@
+package objectfilter_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestFilter(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) *driver.RegistryDefault {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+			{Name: "files", ID: 1},
+			{Name: "folders", ID: 2},
+			{Name: "tags", ID: 3, Config: json.RawMessage(`{"case_insensitive": true}`)},
+		}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyObjectFilterEnabled, true))
+		return reg
+	}
+	tuple := func(namespace, object string) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{
+			Namespace: namespace,
+			Object:    object,
+			Relation:  "view",
+			Subject:   &relationtuple.SubjectID{ID: "user"},
+		}
+	}
+
+	t.Run("case=allows everything before the first build", func(t *testing.T) {
+		reg := setup(t)
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "unknown"))
+	})
+
+	t.Run("case=filters the objects per namespace", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("files", "a"), tuple("folders", "b")))
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+		f := reg.ObjectFilter()
+		assert.True(t, f.MayHaveTuples(ctx, "files", "a"))
+		assert.True(t, f.MayHaveTuples(ctx, "folders", "b"))
+		assert.False(t, f.MayHaveTuples(ctx, "files", "b"))
+		assert.False(t, f.MayHaveTuples(ctx, "folders", "a"))
+		assert.False(t, f.MayHaveTuples(ctx, "unknown", "a"))
+	})
+
+	t.Run("case=adds written objects", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("files", "a")))
+		require.NoError(t, reg.RelationTupleManager().TransactRelationTuples(ctx, []*relationtuple.InternalRelationTuple{tuple("folders", "b")}, nil))
+
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "a"))
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "folders", "b"))
+	})
+
+	t.Run("case=adds written objects in their stored form", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("tags", "Urgent")))
+
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "tags", "Urgent"))
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "tags", "urgent"))
+	})
+
+	t.Run("case=adds objects written by other servers", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+
+		nid := reg.Persister().NetworkID(ctx)
+		reg.ObjectFilter().ApplyChange(&persistence.Change{NetworkID: nid, RelationTuple: tuple("files", "a")})
+		reg.ObjectFilter().ApplyChange(&persistence.Change{NetworkID: uuid.Must(uuid.NewV4()), RelationTuple: tuple("files", "b")})
+
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "a"))
+		assert.False(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "b"))
+	})
+
+	t.Run("case=drops deleted objects on rebuild", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("files", "a")))
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+		require.NoError(t, reg.RelationTupleManager().DeleteRelationTuples(ctx, tuple("files", "a")))
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "a"))
+
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+		assert.False(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "a"))
+	})
+
+	t.Run("case=allows everything when disabled", func(t *testing.T) {
+		reg := setup(t)
+		require.NoError(t, reg.ObjectFilter().Rebuild(ctx))
+		require.NoError(t, reg.Config(ctx).Set(config.KeyObjectFilterEnabled, false))
+		assert.True(t, reg.ObjectFilter().MayHaveTuples(ctx, "files", "unknown"))
+	})
+}
@@ -0,0 +1,36 @@
+package objectfilter
+
+import (
+	"context"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// filteredManager adds the objects of written relation tuples to the filter.
+// Deleted tuples are not removed, bloom filters don't support that. Their
+// objects are dropped with the next rebuild.
+type filteredManager struct {
+	relationtuple.Manager
+	f *Filter
+}
+
+// Manager wraps the relation tuple manager to keep the filter up to date.
+func (f *Filter) Manager(inner relationtuple.Manager) relationtuple.Manager {
+	return relationtuple.KeepObjectsGetter(&filteredManager{Manager: inner, f: f}, inner)
+}
+
+func (fm *filteredManager) WriteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
+	if err := fm.Manager.WriteRelationTuples(ctx, rs...); err != nil {
+		return err
+	}
+	fm.f.add(ctx, fm.f.d.Persister().NetworkID(ctx), rs)
+	return nil
+}
+
+func (fm *filteredManager) TransactRelationTuples(ctx context.Context, insert []*relationtuple.InternalRelationTuple, delete []*relationtuple.InternalRelationTuple) error {
+	if err := fm.Manager.TransactRelationTuples(ctx, insert, delete); err != nil {
+		return err
+	}
+	fm.f.add(ctx, fm.f.d.Persister().NetworkID(ctx), insert)
+	return nil
+}
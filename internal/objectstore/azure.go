@@ -0,0 +1,76 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type azureBucket struct {
+	opts              config.AzureStorage
+	container, prefix string
+	client            *azblob.ContainerClient
+}
+
+// newAzure uses the default credential chain of the Azure SDK, e.g. the
+// service principal of the environment, workload and managed identities, and
+// the Azure CLI.
+func newAzure(opts config.AzureStorage, container, prefix string) (*azureBucket, error) {
+	if opts.Account == "" {
+		opts.Account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+	}
+	if opts.Endpoint == "" {
+		if opts.Account == "" {
+			return nil, errors.New("the storage account or endpoint has to be set to write to Azure Blob Storage")
+		}
+		opts.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", opts.Account)
+	}
+
+	// The credentials are retrieved with the context of the upload.
+	clientOpts := azcore.ClientOptions{Transport: client}
+	creds, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: clientOpts})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load the Azure credentials")
+	}
+	c, err := azblob.NewContainerClient(
+		fmt.Sprintf("%s/%s", strings.TrimSuffix(opts.Endpoint, "/"), container),
+		creds,
+		&azblob.ClientOptions{Transport: client},
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &azureBucket{opts: opts, container: container, prefix: prefix, client: c}, nil
+}
+
+func (b *azureBucket) Location(name string) string {
+	return fmt.Sprintf("%s://%s/%s", SchemeAzure, b.container, key(b.prefix, name))
+}
+
+// Put uploads the object as block blob in a single request.
+func (b *azureBucket) Put(ctx context.Context, name string, body []byte, contentType string) error {
+	blob, err := b.client.NewBlockBlobClient(key(b.prefix, name))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	opts := &azblob.BlockBlobUploadOptions{
+		HTTPHeaders: &azblob.BlobHTTPHeaders{BlobContentType: &contentType},
+	}
+	if b.opts.EncryptionScope != "" {
+		opts.CpkScopeInfo = &azblob.CpkScopeInfo{EncryptionScope: &b.opts.EncryptionScope}
+	}
+	if _, err := blob.Upload(ctx, streaming.NopCloser(bytes.NewReader(body)), opts); err != nil {
+		return errors.Wrapf(err, "could not upload %s", b.Location(name))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type gcsBucket struct {
+	opts           config.GCSStorage
+	bucket, prefix string
+	tokens         oauth2.TokenSource
+}
+
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+func newGCS(opts config.GCSStorage, bucket, prefix string) (*gcsBucket, error) {
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://storage.googleapis.com"
+	}
+
+	// The token source refreshes the tokens in the background, so it must not
+	// use the context of a request.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	var creds *google.Credentials
+	if opts.CredentialsFile != "" {
+		key, err := os.ReadFile(opts.CredentialsFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if creds, err = google.CredentialsFromJSON(ctx, key, gcsScope); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else {
+		var err error
+		if creds, err = google.FindDefaultCredentials(ctx, gcsScope); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return &gcsBucket{opts: opts, bucket: bucket, prefix: prefix, tokens: creds.TokenSource}, nil
+}
+
+func (b *gcsBucket) Location(name string) string {
+	return fmt.Sprintf("%s://%s/%s", SchemeGCS, b.bucket, key(b.prefix, name))
+}
+
+// Put uploads the object with the XML API.
+func (b *gcsBucket) Put(ctx context.Context, name string, body []byte, contentType string) error {
+	token, err := b.tokens.Token()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	u := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(b.opts.Endpoint, "/"), b.bucket, escapeObjectPath(key(b.prefix, name)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if b.opts.KMSKeyName != "" {
+		req.Header.Set("X-Goog-Encryption-Kms-Key-Name", b.opts.KMSKeyName)
+	}
+	token.SetAuthHeader(req)
+	return put(req)
+}
@@ -0,0 +1,175 @@
+// Package objectstore writes exports to local directories or object storage,
+// so that instances without persistent volumes can keep their exports.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type (
+	// Bucket stores objects below the prefix of a location.
+	Bucket interface {
+		// Put stores the object under the name relative to the prefix.
+		// Objects are never visible partially written.
+		Put(ctx context.Context, name string, body []byte, contentType string) error
+		// Location returns where the object of the name is stored.
+		Location(name string) string
+	}
+	directory string
+)
+
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeAzure = "azblob"
+)
+
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// IsURL returns whether the location is in object storage instead of the
+// local file system.
+func IsURL(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case SchemeS3, SchemeGCS, SchemeAzure:
+		return true
+	}
+	return false
+}
+
+// Open returns the bucket of the location, an object storage URL like
+// s3://<bucket>/<prefix>, gs://<bucket>/<prefix>, or
+// azblob://<container>/<prefix>, or an existing local directory.
+func Open(c *config.Config, location string) (Bucket, error) {
+	if !IsURL(location) {
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !info.IsDir() {
+			return nil, errors.Errorf("%s is not a directory", location)
+		}
+		return directory(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("the location %s has no bucket", location)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case SchemeS3:
+		return newS3(c.S3Storage(), u.Host, prefix)
+	case SchemeGCS:
+		return newGCS(c.GCSStorage(), u.Host, prefix)
+	default:
+		return newAzure(c.AzureStorage(), u.Host, prefix)
+	}
+}
+
+// Split splits the location of an object into the location of its bucket and
+// its name. The name is empty if the location ends with a slash.
+func Split(location string) (bucket, name string) {
+	if strings.HasSuffix(location, "/") {
+		return location, ""
+	}
+	if IsURL(location) {
+		if u, _ := url.Parse(location); strings.Trim(u.Path, "/") == "" {
+			// only the bucket
+			return location, ""
+		}
+		i := strings.LastIndex(location, "/")
+		return location[:i], location[i+1:]
+	}
+	return filepath.Dir(location), filepath.Base(location)
+}
+
+// Name returns the name of an object of the kind that was created at the
+// time, e.g. snapshots/keto-snapshot-20230201T100000Z.pb.gz. Objects of a
+// kind share a prefix, so that lifecycle rules can expire them separately,
+// and sort by their creation.
+func Name(kind string, t time.Time, ext string) string {
+	return fmt.Sprintf("%ss/keto-%s-%s%s", kind, kind, t.UTC().Format("20060102T150405Z"), ext)
+}
+
+func key(prefix, name string) string {
+	return strings.TrimPrefix(path.Join(prefix, name), "/")
+}
+
+// escapeObjectPath escapes all but the unreserved characters and slashes of
+// the key, so that it can be used as path of an object URL.
+func escapeObjectPath(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// put sends the request and returns an error if it was not successful.
+func put(req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("could not upload %s, got status code %d: %s", req.URL.Redacted(), resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (d directory) Location(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// Put writes to a temporary file first and renames it afterwards.
+func (d directory) Put(_ context.Context, name string, body []byte, _ string) (err error) {
+	target := d.Location(name)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if _, err := f.Write(body); err != nil {
+		_ = f.Close()
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(f.Name(), target))
+}
@@ -0,0 +1,11 @@
+package objectstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeObjectPath(t *testing.T) {
+	assert.Equal(t, "backups/test%24file%20%281%29.text", escapeObjectPath("backups/test$file (1).text"))
+}
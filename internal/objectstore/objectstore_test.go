@@ -0,0 +1,244 @@
+package objectstore_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/objectstore"
+)
+
+type upload struct {
+	path   string
+	header http.Header
+	body   string
+}
+
+func newConfig(t *testing.T, kv ...string) *config.Config {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	c := config.New(ctx, logrusx.New("test", "today"), cp)
+	for i := 0; i+1 < len(kv); i += 2 {
+		require.NoError(t, c.Set(kv[i], kv[i+1]))
+	}
+	return c
+}
+
+// newServer returns a server that records the uploads, and answers token
+// requests of the GCS credentials and the Azure managed identity.
+func newServer(t *testing.T) (*httptest.Server, <-chan upload) {
+	uploads := make(chan upload, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"gcs-token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		if r.URL.Path == "/msi/token" {
+			if r.Header.Get("X-Identity-Header") != "identity-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"access_token":"azure-token","token_type":"Bearer","expires_on":"%d","resource":%q}`, time.Now().Add(time.Hour).Unix(), r.URL.Query().Get("resource"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		uploads <- upload{path: r.URL.RequestURI(), header: r.Header, body: string(body)}
+		// Azure expects the status of a created blob, the others accept it.
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, uploads
+}
+
+func TestBuckets(t *testing.T) {
+	ctx := context.Background()
+	ts, uploads := newServer(t)
+
+	t.Run("case=local directory", func(t *testing.T) {
+		dir := t.TempDir()
+		b, err := objectstore.Open(newConfig(t), dir)
+		require.NoError(t, err)
+		require.NoError(t, b.Put(ctx, "snapshots/a.pb.gz", []byte("snapshot"), "application/gzip"))
+
+		actual, err := os.ReadFile(filepath.Join(dir, "snapshots", "a.pb.gz"))
+		require.NoError(t, err)
+		assert.Equal(t, "snapshot", string(actual))
+		assert.Equal(t, filepath.Join(dir, "snapshots", "a.pb.gz"), b.Location("snapshots/a.pb.gz"))
+
+		_, err = objectstore.Open(newConfig(t), filepath.Join(dir, "missing"))
+		assert.Error(t, err)
+	})
+
+	t.Run("case=s3", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_SESSION_TOKEN", "session")
+		c := newConfig(t,
+			config.KeyObjectStorageS3Endpoint, ts.URL,
+			config.KeyObjectStorageS3Region, "eu-central-1",
+			config.KeyObjectStorageS3ServerSideEncryption, "aws:kms",
+			config.KeyObjectStorageS3KMSKeyID, "my-key",
+		)
+		b, err := objectstore.Open(c, "s3://bucket/keto/")
+		require.NoError(t, err)
+		require.NoError(t, b.Put(ctx, "usage/a b.csv", []byte("usage"), "text/csv"))
+		assert.Equal(t, "s3://bucket/keto/usage/a b.csv", b.Location("usage/a b.csv"))
+
+		u := <-uploads
+		assert.Equal(t, "/bucket/keto/usage/a%20b.csv?x-id=PutObject", u.path)
+		assert.Equal(t, "usage", u.body)
+		assert.Equal(t, "aws:kms", u.header.Get("X-Amz-Server-Side-Encryption"))
+		assert.Equal(t, "my-key", u.header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+		assert.Equal(t, "session", u.header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(u.header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"+time.Now().UTC().Format("20060102")+"/eu-central-1/s3/aws4_request, "), u.header.Get("Authorization"))
+	})
+
+	t.Run("case=s3 requires credentials", func(t *testing.T) {
+		// no credentials in the environment, the shared files, or the
+		// instance metadata
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+		b, err := objectstore.Open(newConfig(t, config.KeyObjectStorageS3Endpoint, ts.URL), "s3://bucket")
+		require.NoError(t, err)
+		assert.Error(t, b.Put(ctx, "a.csv", []byte("usage"), "text/csv"))
+	})
+
+	t.Run("case=s3 reads the shared credentials file", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_SESSION_TOKEN", "")
+		t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+		credentials := filepath.Join(t.TempDir(), "credentials")
+		require.NoError(t, os.WriteFile(credentials, []byte("[default]\naws_access_key_id = FILEKEY\naws_secret_access_key = secret\n"), 0600))
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+
+		b, err := objectstore.Open(newConfig(t, config.KeyObjectStorageS3Endpoint, ts.URL), "s3://bucket")
+		require.NoError(t, err)
+		require.NoError(t, b.Put(ctx, "a.csv", []byte("usage"), "text/csv"))
+
+		u := <-uploads
+		assert.True(t, strings.HasPrefix(u.header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=FILEKEY/"), u.header.Get("Authorization"))
+	})
+
+	t.Run("case=gcs", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		creds, err := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "keto@example.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+			"token_uri":    ts.URL + "/token",
+		})
+		require.NoError(t, err)
+		credsFile := filepath.Join(t.TempDir(), "creds.json")
+		require.NoError(t, os.WriteFile(credsFile, creds, 0600))
+
+		c := newConfig(t,
+			config.KeyObjectStorageGCSEndpoint, ts.URL,
+			config.KeyObjectStorageGCSCredentialsFile, credsFile,
+			config.KeyObjectStorageGCSKMSKeyName, "projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		)
+		b, err := objectstore.Open(c, "gs://bucket")
+		require.NoError(t, err)
+		require.NoError(t, b.Put(ctx, "a.csv", []byte("usage"), "text/csv"))
+
+		u := <-uploads
+		assert.Equal(t, "/bucket/a.csv", u.path)
+		assert.Equal(t, "usage", u.body)
+		assert.Equal(t, "Bearer gcs-token", u.header.Get("Authorization"))
+		assert.Equal(t, "projects/p/locations/global/keyRings/r/cryptoKeys/k", u.header.Get("X-Goog-Encryption-Kms-Key-Name"))
+	})
+
+	t.Run("case=azure with managed identity", func(t *testing.T) {
+		// no service principal in the environment
+		t.Setenv("AZURE_TENANT_ID", "")
+		t.Setenv("IDENTITY_ENDPOINT", ts.URL+"/msi/token")
+		t.Setenv("IDENTITY_HEADER", "identity-secret")
+		c := newConfig(t,
+			config.KeyObjectStorageAzureAccount, "account",
+			config.KeyObjectStorageAzureEndpoint, ts.URL,
+			config.KeyObjectStorageAzureEncryptionScope, "scope",
+		)
+		b, err := objectstore.Open(c, "azblob://container/keto")
+		require.NoError(t, err)
+		require.NoError(t, b.Put(ctx, "usage/a b.csv", []byte("usage"), "text/csv"))
+		assert.Equal(t, "azblob://container/keto/usage/a b.csv", b.Location("usage/a b.csv"))
+
+		u := <-uploads
+		assert.Equal(t, "/container/keto/usage/a%20b.csv", u.path)
+		assert.Equal(t, "usage", u.body)
+		assert.Equal(t, "text/csv", u.header.Get("X-Ms-Blob-Content-Type"))
+		assert.Equal(t, "BlockBlob", u.header.Get("X-Ms-Blob-Type"))
+		assert.Equal(t, "scope", u.header.Get("X-Ms-Encryption-Scope"))
+		assert.Equal(t, "Bearer azure-token", u.header.Get("Authorization"))
+	})
+
+	t.Run("case=azure requires the account or endpoint", func(t *testing.T) {
+		t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+		_, err := objectstore.Open(newConfig(t), "azblob://container")
+		assert.Error(t, err)
+	})
+
+	t.Run("case=reports failed uploads", func(t *testing.T) {
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+		}))
+		defer denied.Close()
+
+		b, err := objectstore.Open(newConfig(t, config.KeyObjectStorageS3Endpoint, denied.URL), "s3://bucket")
+		require.NoError(t, err)
+		err = b.Put(ctx, "a.csv", []byte("usage"), "text/csv")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "AccessDenied")
+	})
+}
+
+func TestSplit(t *testing.T) {
+	for _, tc := range []struct{ location, bucket, name string }{
+		{"s3://bucket/backups/snapshot.pb.gz", "s3://bucket/backups", "snapshot.pb.gz"},
+		{"s3://bucket/snapshot.pb.gz", "s3://bucket", "snapshot.pb.gz"},
+		{"gs://bucket/backups/", "gs://bucket/backups/", ""},
+		{"azblob://container", "azblob://container", ""},
+		{"backups/snapshot.pb.gz", "backups", "snapshot.pb.gz"},
+	} {
+		bucket, name := objectstore.Split(tc.location)
+		assert.Equal(t, tc.bucket, bucket, tc.location)
+		assert.Equal(t, tc.name, name, tc.location)
+	}
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "snapshots/keto-snapshot-20230201T100000Z.pb.gz", objectstore.Name("snapshot", time.Date(2023, 2, 1, 11, 0, 0, 0, time.FixedZone("CET", 3600)), ".pb.gz"))
+}
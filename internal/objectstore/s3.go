@@ -0,0 +1,88 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type s3Bucket struct {
+	opts           config.S3Storage
+	bucket, prefix string
+	client         *s3.Client
+	credentials    aws.CredentialsProvider
+}
+
+// newS3 uses the default credential chain of the AWS SDK, e.g. the
+// environment, the shared config files, web identities, and the instance
+// roles.
+func newS3(opts config.S3Storage, bucket, prefix string) (*s3Bucket, error) {
+	if opts.KMSKeyID != "" && opts.ServerSideEncryption != "aws:kms" {
+		return nil, errors.New("a KMS key can only be set with the server-side encryption aws:kms")
+	}
+
+	loadOpts := []func(*awsconfig.LoadOptions) error{
+		// The buildable client takes custom CA bundles, e.g. of AWS_CA_BUNDLE.
+		awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(client.Timeout)),
+		awsconfig.WithDefaultRegion("us-east-1"),
+	}
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+	// Loading only reads the environment and the shared config files. The
+	// credentials are retrieved with the context of the upload.
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), loadOpts...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	c := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.Endpoint != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(strings.TrimSuffix(opts.Endpoint, "/"))
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Bucket{opts: opts, bucket: bucket, prefix: prefix, client: c, credentials: cfg.Credentials}, nil
+}
+
+func (b *s3Bucket) Location(name string) string {
+	return fmt.Sprintf("%s://%s/%s", SchemeS3, b.bucket, key(b.prefix, name))
+}
+
+func (b *s3Bucket) Put(ctx context.Context, name string, body []byte, contentType string) error {
+	// The S3 client signs with empty credentials if the chain fails, so the
+	// failure would only surface as an access denied error of the bucket.
+	creds, err := b.credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve the AWS credentials")
+	} else if !creds.HasKeys() {
+		return errors.New("the AWS credential chain did not return any credentials")
+	}
+
+	in := &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(key(b.prefix, name)),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	}
+	if b.opts.ServerSideEncryption != "" {
+		in.ServerSideEncryption = types.ServerSideEncryption(b.opts.ServerSideEncryption)
+	}
+	if b.opts.KMSKeyID != "" {
+		in.SSEKMSKeyId = aws.String(b.opts.KMSKeyID)
+	}
+	if _, err := b.client.PutObject(ctx, in); err != nil {
+		return errors.Wrapf(err, "could not upload %s", b.Location(name))
+	}
+	return nil
+}
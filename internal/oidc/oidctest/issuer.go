@@ -0,0 +1,59 @@
+// Package oidctest provides an OpenID Connect issuer for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// Issuer serves a JSON Web Key Set and signs tokens with the private key.
+type Issuer struct {
+	URL     string
+	KeysURL string
+	key     *rsa.PrivateKey
+}
+
+const keyID = "test-key"
+
+func NewIssuer(t *testing.T) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": keyID,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	return &Issuer{URL: ts.URL + "/", KeysURL: ts.URL + "/.well-known/jwks.json", key: key}
+}
+
+// Sign returns an RS256 signed token with the given claims. The issuer claim
+// is added if it is not set.
+func (i *Issuer) Sign(t *testing.T, claims map[string]interface{}) string {
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = i.URL
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(i.key)
+	require.NoError(t, err)
+	return signed
+}
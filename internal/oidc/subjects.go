@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// BearerToken returns the bearer token of the request, if any.
+func BearerToken(r *http.Request) (string, bool) {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(parts[1]), true
+}
+
+// BearerTokenFromContext returns the bearer token of the authorization
+// metadata of a gRPC request, if any.
+func BearerTokenFromContext(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		parts := strings.SplitN(v, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+			return strings.TrimSpace(parts[1]), true
+		}
+	}
+	return "", false
+}
+
+// Subjects validates the token and maps its claims to the subject and, if
+// configured, the subject sets of the groups the subject is a member of. The
+// subject always comes first.
+func (v *Verifier) Subjects(ctx context.Context, token string) ([]relationtuple.Subject, error) {
+	claims, err := v.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	c := v.d.Config(ctx)
+	sub := gjson.GetBytes(claims, c.OIDCSubjectClaim())
+	if sub.String() == "" {
+		return nil, errors.WithStack(ErrInvalidToken.WithReasonf("The token has no %q claim.", c.OIDCSubjectClaim()))
+	}
+	prefix, subjectNamespace, subjectRelation := c.OIDCSubject()
+	var subject relationtuple.Subject = &relationtuple.SubjectID{ID: prefix + sub.String()}
+	if subjectNamespace != "" {
+		subject = &relationtuple.SubjectSet{Namespace: subjectNamespace, Object: prefix + sub.String(), Relation: subjectRelation}
+	}
+	subjects := []relationtuple.Subject{subject}
+
+	claim, namespace, relation := c.OIDCGroups()
+	if claim == "" {
+		return subjects, nil
+	}
+	groups := gjson.GetBytes(claims, claim)
+	if !groups.IsArray() && groups.Exists() {
+		groups = gjson.Parse("[" + groups.Raw + "]")
+	}
+	for _, g := range groups.Array() {
+		if g.String() == "" {
+			continue
+		}
+		subjects = append(subjects, &relationtuple.SubjectSet{
+			Namespace: namespace,
+			Object:    g.String(),
+			Relation:  relation,
+		})
+	}
+	return subjects, nil
+}
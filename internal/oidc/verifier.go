@@ -0,0 +1,215 @@
+// Package oidc validates OpenID Connect bearer tokens and maps their claims to
+// subjects.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+		x.LoggerProvider
+	}
+	VerifierProvider interface {
+		OIDCVerifier() *Verifier
+	}
+	// Verifier validates JSON Web Tokens against the JSON Web Key Set of the
+	// configured issuer. The key set is cached and refetched when a token is
+	// signed by an unknown key.
+	Verifier struct {
+		d      dependencies
+		client *http.Client
+		parser *jwt.Parser
+		now    func() time.Time
+
+		mu        sync.Mutex
+		keysURL   string
+		keys      *keyfunc.JWKS
+		fetchedAt time.Time
+	}
+	keySet struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+)
+
+const (
+	// minRefreshInterval limits how often the key set is refetched because of
+	// tokens with unknown keys.
+	minRefreshInterval = time.Minute
+	// maxKeySetAge is the time after which the key set is refetched in any case.
+	maxKeySetAge = time.Hour
+	// leeway is the allowed clock skew when validating the token lifetime.
+	leeway = 30 * time.Second
+)
+
+var (
+	ErrInvalidToken = herodot.ErrUnauthorized.WithID(errorcode.TokenInvalid).WithError("the bearer token is invalid")
+	ErrNotEnabled   = herodot.ErrBadRequest.WithError("bearer tokens are not enabled on this endpoint")
+
+	// signingMethods are the supported signing algorithms. Symmetric ones are
+	// excluded, so that public keys can't be used as HMAC secrets.
+	signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+)
+
+func NewVerifier(d dependencies) *Verifier {
+	return &Verifier{
+		d:      d,
+		client: &http.Client{Timeout: 10 * time.Second},
+		// The claims are validated with the leeway below.
+		parser: jwt.NewParser(jwt.WithValidMethods(signingMethods), jwt.WithoutClaimsValidation()),
+		now:    time.Now,
+	}
+}
+
+// Verify validates the signature, issuer, audience, and lifetime of the token
+// and returns its raw claims.
+func (v *Verifier) Verify(ctx context.Context, token string) ([]byte, error) {
+	c := v.d.Config(ctx)
+	if !c.OIDCEnabled() {
+		return nil, errors.WithStack(ErrNotEnabled)
+	}
+	if c.OIDCIssuer() == "" || c.OIDCJWKSURL() == "" {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithReason("Bearer tokens are enabled but no issuer or JSON Web Key Set URL is configured."))
+	}
+
+	t, err := v.parser.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		keys, err := v.keySet(ctx, c.OIDCJWKSURL(), kid)
+		if err != nil {
+			return nil, err
+		}
+		return keys.Keyfunc(t)
+	})
+	if err != nil {
+		// Errors of the key set are returned as they are.
+		herr := new(herodot.DefaultError)
+		if errors.As(err, &herr) {
+			return nil, errors.WithStack(herr)
+		}
+		return nil, errors.WithStack(ErrInvalidToken.WithReasonf("The token could not be verified: %s", err))
+	}
+
+	if err := v.validateClaims(c, t.Claims.(jwt.MapClaims)); err != nil {
+		return nil, err
+	}
+	claims, err := jwt.DecodeSegment(strings.Split(t.Raw, ".")[1])
+	if err != nil || !gjson.ValidBytes(claims) {
+		return nil, errors.WithStack(ErrInvalidToken.WithReason("The token claims are not valid base64url encoded JSON."))
+	}
+	return claims, nil
+}
+
+func (v *Verifier) validateClaims(c *config.Config, claims jwt.MapClaims) error {
+	if iss, _ := claims["iss"].(string); iss != c.OIDCIssuer() {
+		return errors.WithStack(ErrInvalidToken.WithReasonf("The token was issued by %q but %q is expected.", iss, c.OIDCIssuer()))
+	}
+
+	now := v.now()
+	if _, ok := claims["exp"]; !ok {
+		return errors.WithStack(ErrInvalidToken.WithReason("The token does not expire."))
+	}
+	if !claims.VerifyExpiresAt(now.Add(-leeway).Unix(), true) {
+		return errors.WithStack(ErrInvalidToken.WithReason("The token is expired."))
+	}
+	if !claims.VerifyNotBefore(now.Add(leeway).Unix(), false) {
+		return errors.WithStack(ErrInvalidToken.WithReason("The token is not valid yet."))
+	}
+
+	if expected := c.OIDCAudiences(); len(expected) > 0 {
+		for _, aud := range expected {
+			if claims.VerifyAudience(aud, true) {
+				return nil
+			}
+		}
+		return errors.WithStack(ErrInvalidToken.WithReason("The token is not intended for this audience."))
+	}
+	return nil
+}
+
+// keySet returns the key set that has the key with the ID, refetching it if
+// necessary.
+func (v *Verifier) keySet(ctx context.Context, keysURL, kid string) (*keyfunc.JWKS, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetchedAt)
+	if v.hasKey(kid) && keysURL == v.keysURL && age < maxKeySetAge {
+		return v.keys, nil
+	}
+	if keysURL != v.keysURL || age >= minRefreshInterval {
+		if err := v.fetchKeys(ctx, keysURL); err != nil {
+			return nil, err
+		}
+	}
+	if v.hasKey(kid) {
+		return v.keys, nil
+	}
+	return nil, errors.WithStack(ErrInvalidToken.WithReasonf("The token is signed with the unknown key %q.", kid))
+}
+
+// hasKey must be called with v.mu held.
+func (v *Verifier) hasKey(kid string) bool {
+	if v.keys == nil {
+		return false
+	}
+	_, ok := v.keys.ReadOnlyKeys()[kid]
+	return ok
+}
+
+// fetchKeys must be called with v.mu held.
+func (v *Verifier) fetchKeys(ctx context.Context, keysURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, keysURL, nil)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not create the request for the JSON Web Key Set: %s", err))
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not fetch the JSON Web Key Set: %s", err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not fetch the JSON Web Key Set, got status code %d.", resp.StatusCode))
+	}
+
+	var set keySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not decode the JSON Web Key Set: %s", err))
+	}
+	// Only keep the signing keys.
+	sig := keySet{Keys: make([]json.RawMessage, 0, len(set.Keys))}
+	for _, k := range set.Keys {
+		if use := gjson.GetBytes(k, "use").String(); use == "" || use == "sig" {
+			sig.Keys = append(sig.Keys, k)
+		}
+	}
+	raw, err := json.Marshal(&sig)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	keys, err := keyfunc.NewJSON(raw)
+	if err != nil {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf("Could not parse the JSON Web Key Set: %s", err))
+	}
+	if ignored := len(sig.Keys) - keys.Len(); ignored > 0 {
+		v.d.Logger().WithField("ignored_keys", ignored).Warn("ignoring unsupported keys of the JSON Web Key Set")
+	}
+
+	v.keys, v.keysURL, v.fetchedAt = keys, keysURL, v.now()
+	return nil
+}
@@ -0,0 +1,140 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/oidc"
+	"github.com/ory/keto/internal/oidc/oidctest"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestVerifier(t *testing.T) {
+	ctx := context.Background()
+	issuer := oidctest.NewIssuer(t)
+	reg := driver.NewSqliteTestRegistry(t, false)
+	v := reg.OIDCVerifier()
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub":    "alice",
+			"aud":    []string{"keto"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+			"groups": []string{"dev", "ops"},
+		}
+	}
+
+	t.Run("case=fails if disabled", func(t *testing.T) {
+		_, err := v.Verify(ctx, issuer.Sign(t, valid()))
+		assert.ErrorIs(t, err, oidc.ErrNotEnabled)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set("oidc", map[string]interface{}{
+		"enabled":   true,
+		"issuer":    issuer.URL,
+		"jwks_url":  issuer.KeysURL,
+		"audiences": []string{"keto"},
+	}))
+
+	t.Run("case=maps the subject", func(t *testing.T) {
+		subjects, err := v.Subjects(ctx, issuer.Sign(t, valid()))
+		require.NoError(t, err)
+		assert.Equal(t, []relationtuple.Subject{&relationtuple.SubjectID{ID: "alice"}}, subjects)
+	})
+
+	for _, tc := range []struct {
+		desc   string
+		modify func(claims map[string]interface{})
+		token  string
+	}{
+		{desc: "expired", modify: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{desc: "no expiry", modify: func(c map[string]interface{}) { delete(c, "exp") }},
+		{desc: "not yet valid", modify: func(c map[string]interface{}) { c["nbf"] = time.Now().Add(time.Hour).Unix() }},
+		{desc: "other issuer", modify: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com/" }},
+		{desc: "other audience", modify: func(c map[string]interface{}) { c["aud"] = "other" }},
+		{desc: "no subject", modify: func(c map[string]interface{}) { delete(c, "sub") }},
+		{desc: "malformed", token: "not.a-token"},
+	} {
+		t.Run("case=rejects "+tc.desc, func(t *testing.T) {
+			token := tc.token
+			if token == "" {
+				claims := valid()
+				tc.modify(claims)
+				token = issuer.Sign(t, claims)
+			}
+			_, err := v.Subjects(ctx, token)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "%+v", err)
+		})
+	}
+
+	t.Run("case=rejects tampered tokens", func(t *testing.T) {
+		claims := valid()
+		token := issuer.Sign(t, claims)
+		claims["sub"] = "mallory"
+		tampered := issuer.Sign(t, claims)
+
+		// the signature of the original token does not match the tampered claims
+		_, err := v.Verify(ctx, tampered[:strings.LastIndex(tampered, ".")]+token[strings.LastIndex(token, "."):])
+		assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "%+v", err)
+	})
+
+	t.Run("case=rejects symmetric signatures", func(t *testing.T) {
+		claims := valid()
+		claims["iss"] = issuer.URL
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims))
+		token.Header["kid"] = "test-key"
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = v.Verify(ctx, signed)
+		assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "%+v", err)
+	})
+
+	t.Run("case=rejects tokens signed by another issuer", func(t *testing.T) {
+		_, err := v.Verify(ctx, oidctest.NewIssuer(t).Sign(t, valid()))
+		assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "%+v", err)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set("oidc.groups", map[string]interface{}{
+		"claim":     "groups",
+		"namespace": "groups",
+		"relation":  "member",
+	}))
+
+	t.Run("case=maps groups to subject sets", func(t *testing.T) {
+		subjects, err := v.Subjects(ctx, issuer.Sign(t, valid()))
+		require.NoError(t, err)
+		assert.Equal(t, []relationtuple.Subject{
+			&relationtuple.SubjectID{ID: "alice"},
+			&relationtuple.SubjectSet{Namespace: "groups", Object: "dev", Relation: "member"},
+			&relationtuple.SubjectSet{Namespace: "groups", Object: "ops", Relation: "member"},
+		}, subjects)
+	})
+
+	t.Run("case=maps the subject with a prefix", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set("oidc.subject_prefix", "user:"))
+		t.Cleanup(func() { require.NoError(t, reg.Config(ctx).Set("oidc.subject_prefix", "")) })
+
+		subjects, err := v.Subjects(ctx, issuer.Sign(t, valid()))
+		require.NoError(t, err)
+		assert.Equal(t, &relationtuple.SubjectID{ID: "user:alice"}, subjects[0])
+	})
+
+	t.Run("case=maps the subject to a subject set", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set("oidc.subject_set", map[string]interface{}{"namespace": "users"}))
+
+		subjects, err := v.Subjects(ctx, issuer.Sign(t, valid()))
+		require.NoError(t, err)
+		assert.Equal(t, &relationtuple.SubjectSet{Namespace: "users", Object: "alice"}, subjects[0])
+		assert.Len(t, subjects, 3)
+	})
+}
@@ -0,0 +1,346 @@
+package opa
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// BundleRoot is the root path of the bundle in OPA's data document. Policies
+// can look up the subjects of a relation as
+// `data.keto.relation_tuples[namespace][object][relation]`.
+const BundleRoot = "keto"
+
+// bundlePageSize is the page size used to read all relation tuples.
+const bundlePageSize = 1000
+
+// maxCachedBundles limits the number of cached bundles, as every combination
+// of requested namespaces is cached separately. Further bundles are built on
+// every request.
+const maxCachedBundles = 64
+
+// bundleBuildTimeout limits a shared build, as it doesn't end with the
+// requests waiting for it.
+const bundleBuildTimeout = time.Minute
+
+type (
+	// bundleData maps namespace, object, and relation to the string
+	// representations of the direct subjects.
+	bundleData map[string]map[string]map[string][]string
+	manifest   struct {
+		Revision string   `json:"revision"`
+		Roots    []string `json:"roots"`
+	}
+	// bundle is a built bundle and the snapshot it was read at.
+	bundle struct {
+		networkID  uuid.UUID
+		namespaces []string
+		snapshot   string
+		revision   string
+		content    []byte
+	}
+)
+
+var bundleRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "opa_bundle_requests_total",
+	Help:      "The number of OPA bundle requests, by whether the bundle was cached for the current snapshot.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(bundleRequestsTotal)
+}
+
+// swagger:parameters getOPABundle
+// nolint:deadcode,unused
+type getBundle struct {
+	// The namespaces to include. Defaults to all namespaces.
+	//
+	// in: query
+	Namespace []string `json:"namespace"`
+}
+
+// swagger:route GET /opa/bundle write getOPABundle
+//
+// Get an OPA bundle of the relation tuples
+//
+// Returns a gzipped OPA bundle containing the direct relation tuples of the
+// requested namespaces. The bundle is cached per snapshot, so it is only
+// regenerated after relation tuples changed. Its revision is also sent as
+// ETag, so OPA only downloads it when it changed.
+//
+//     Produces:
+//     - application/gzip
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: emptyResponse
+//       304: emptyResponse
+//       404: genericError
+//       500: genericError
+func (h *Handler) getBundle(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	namespaces := r.URL.Query()["namespace"]
+	if len(namespaces) == 0 {
+		nm, err := h.d.Config(r.Context()).NamespaceManager()
+		if err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+		nn, err := nm.Namespaces(r.Context())
+		if err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+		for _, n := range nn {
+			namespaces = append(namespaces, n.Name)
+		}
+	}
+
+	b, err := h.bundle(r.Context(), namespaces)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	etag := `"` + b.revision + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.content)
+}
+
+// bundle returns the bundle of the namespaces at the current snapshot of the
+// network in the context. Bundles are only cached if the persister supports
+// snapshots, otherwise they are built on every request.
+func (h *Handler) bundle(ctx context.Context, namespaces []string) (*bundle, error) {
+	namespaces = append([]string(nil), namespaces...)
+	sort.Strings(namespaces)
+	nid := h.d.Persister().NetworkID(ctx)
+
+	sr, ok := h.d.Persister().(persistence.SnapshotReader)
+	if !ok {
+		bundleRequestsTotal.WithLabelValues("uncached").Inc()
+		return h.buildBundle(ctx, namespaces)
+	}
+	snapshot, err := sr.SnapshotToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := nid.String() + "\x00" + strings.Join(namespaces, "\x00")
+	h.mu.Lock()
+	cached, ok := h.bundles[key]
+	h.mu.Unlock()
+	if ok && cached.snapshot == snapshot {
+		bundleRequestsTotal.WithLabelValues("hit").Inc()
+		return cached, nil
+	}
+	bundleRequestsTotal.WithLabelValues("miss").Inc()
+
+	// Concurrent polls of the same snapshot share one build. It must not
+	// fail because the request that started it was cancelled, so it only
+	// keeps the values of the context, e.g. the network.
+	builds := h.builds.DoChan(key+"\x00"+snapshot, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(x.DetachedContext(ctx), bundleBuildTimeout)
+		defer cancel()
+
+		ctx, err := sr.AtSnapshot(ctx, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		b, err := h.buildBundle(ctx, namespaces)
+		if err != nil {
+			return nil, err
+		}
+		b.snapshot = snapshot
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.bundles[key]; ok || len(h.bundles) < maxCachedBundles {
+			h.bundles[key] = b
+		}
+		return b, nil
+	})
+	select {
+	case res := <-builds:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*bundle), nil
+	case <-ctx.Done():
+		return nil, errors.WithStack(ctx.Err())
+	}
+}
+
+// applyChange drops the cached bundles of the network of the change, as
+// received from the changefeed. The bundles of the default network are
+// rebuilt right away, so that OPA doesn't wait for the build on its next poll.
+// Bundles of other networks are rebuilt by their next request, as their
+// network can't be put into a context here.
+func (h *Handler) applyChange(c *persistence.Change) {
+	if _, ok := h.d.Persister().(persistence.SnapshotReader); !ok {
+		return
+	}
+	defaultNetwork := h.d.Persister().NetworkID(context.Background())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, b := range h.bundles {
+		if b.networkID != c.NetworkID {
+			continue
+		}
+		delete(h.bundles, key)
+		if b.networkID == defaultNetwork {
+			h.stale[key] = b.namespaces
+		}
+	}
+	if len(h.stale) > 0 && !h.rebuilding {
+		h.rebuilding = true
+		go h.rebuildStale()
+	}
+}
+
+// rebuildStale rebuilds the stale bundles until no more changes arrive.
+// Changes received during a rebuild are collected and rebuilt afterwards.
+func (h *Handler) rebuildStale() {
+	ctx := context.Background()
+	for {
+		h.mu.Lock()
+		stale := h.stale
+		if len(stale) == 0 {
+			h.rebuilding = false
+			h.mu.Unlock()
+			return
+		}
+		h.stale = make(map[string][]string)
+		h.mu.Unlock()
+
+		for _, namespaces := range stale {
+			if _, err := h.bundle(ctx, namespaces); err != nil {
+				h.d.Logger().WithError(err).WithField("namespaces", namespaces).Warn("Could not rebuild the OPA bundle after a change.")
+			}
+		}
+	}
+}
+
+// buildBundle reads the relation tuples of the namespaces and writes them as
+// bundle.
+func (h *Handler) buildBundle(ctx context.Context, namespaces []string) (*bundle, error) {
+	data, err := h.bundleData(ctx, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := json.Marshal(map[string]interface{}{
+		BundleRoot: map[string]interface{}{"relation_tuples": data},
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sum := sha256.Sum256(rawData)
+	revision := hex.EncodeToString(sum[:])
+	content, err := writeBundle(rawData, &manifest{Revision: revision, Roots: []string{BundleRoot}})
+	if err != nil {
+		return nil, err
+	}
+	return &bundle{
+		networkID:  h.d.Persister().NetworkID(ctx),
+		namespaces: namespaces,
+		revision:   revision,
+		content:    content,
+	}, nil
+}
+
+func (h *Handler) bundleData(ctx context.Context, namespaces []string) (bundleData, error) {
+	data := make(bundleData, len(namespaces))
+	for _, n := range namespaces {
+		objects := make(map[string]map[string][]string)
+		data[n] = objects
+
+		for pageToken := ""; ; {
+			rels, next, err := h.d.RelationTupleManager().GetRelationTuples(ctx,
+				&relationtuple.RelationQuery{Namespace: n},
+				x.WithToken(pageToken), x.WithSize(bundlePageSize),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rel := range rels {
+				if objects[rel.Object] == nil {
+					objects[rel.Object] = make(map[string][]string)
+				}
+				objects[rel.Object][rel.Relation] = append(objects[rel.Object][rel.Relation], rel.Subject.String())
+			}
+
+			if next == "" {
+				break
+			}
+			pageToken = next
+		}
+	}
+	return data, nil
+}
+
+// writeBundle creates a gzipped tarball in the OPA bundle format.
+func writeBundle(data []byte, m *manifest) ([]byte, error) {
+	rawManifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range []struct {
+		name    string
+		content []byte
+	}{
+		{name: "/.manifest", content: rawManifest},
+		{name: "/data.json", content: data},
+	} {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     f.name,
+			Mode:     0600,
+			Size:     int64(len(f.content)),
+			Typeflag: tar.TypeReg,
+			ModTime:  time.Unix(0, 0),
+		}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
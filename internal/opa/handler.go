@@ -0,0 +1,200 @@
+// Package opa integrates Keto with the Open Policy Agent. OPA policies can
+// either call the batched check endpoint through `http.send`, or pull the
+// relation tuples as an OPA bundle and evaluate them locally.
+package opa
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/changefeed"
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		relationtuple.ManagerProvider
+		check.EngineProvider
+		changefeed.Provider
+		config.Provider
+		persistence.Provider
+		x.LoggerProvider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+
+		// the bundles by network and namespaces, see bundle.go
+		mu         sync.Mutex
+		bundles    map[string]*bundle
+		stale      map[string][]string
+		rebuilding bool
+		builds     singleflight.Group
+	}
+)
+
+const (
+	RouteBase       = "/opa"
+	BatchCheckRoute = RouteBase + "/check"
+	BundleRoute     = RouteBase + "/bundle"
+
+	// maxBatchSize limits the number of checks in one batch request.
+	maxBatchSize = 100
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	h := &Handler{d: d, bundles: make(map[string]*bundle), stale: make(map[string][]string)}
+	d.ChangeFeed().Subscribe(h.applyChange)
+	return h
+}
+
+func (h *Handler) RegisterReadRoutes(r *x.ReadRouter) {
+	r.POST(BatchCheckRoute, h.postBatchCheck)
+}
+
+// RegisterWriteRoutes registers the bundle on the write API, as it contains
+// all relation tuples of the network.
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(BundleRoute, h.getBundle)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// BatchCheckRequest is the body of a batch check request.
+//
+// swagger:model postOPABatchCheckBody
+type BatchCheckRequest struct {
+	// The relation tuples to check.
+	//
+	// required: true
+	Checks []*relationtuple.InternalRelationTuple `json:"checks"`
+
+	// The maximum depth to evaluate each check with.
+	MaxDepth int `json:"max_depth"`
+
+	// The snapshot token to evaluate all checks at. Defaults to the current
+	// snapshot.
+	Snaptoken string `json:"snaptoken"`
+}
+
+// BatchCheckResult is the result of a single check in a batch.
+type BatchCheckResult struct {
+	// whether the relation tuple is allowed
+	//
+	// required: true
+	Allowed bool `json:"allowed"`
+
+	// The reason the check could not be evaluated, if any. Failing checks do
+	// not fail the whole batch.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchCheckResponse is the response of a batch check request.
+//
+// swagger:model postOPABatchCheckResponse
+type BatchCheckResponse struct {
+	// The results in the same order as the requested checks.
+	//
+	// required: true
+	Results []*BatchCheckResult `json:"results"`
+
+	// The snapshot token the checks were evaluated at, so that OPA decision
+	// logs can record it. It is empty if the storage does not support
+	// snapshots.
+	Snaptoken string `json:"snaptoken"`
+}
+
+// swagger:parameters postOPABatchCheck
+// nolint:deadcode,unused
+type postBatchCheck struct {
+	// in: body
+	Body BatchCheckRequest
+}
+
+// swagger:route POST /opa/check read postOPABatchCheck
+//
+// Check a batch of relation tuples
+//
+// Evaluates up to 100 checks in one request, intended to be called from OPA
+// policies using `http.send`. All checks are evaluated at the same snapshot.
+// The status code is 200 even if some checks are denied or failed.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: postOPABatchCheckResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) postBatchCheck(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var req BatchCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+	if len(req.Checks) > maxBatchSize {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.BatchTooLarge).WithReasonf("A batch can contain at most %d checks, got %d.", maxBatchSize, len(req.Checks))))
+		return
+	}
+
+	// All checks are evaluated at the same snapshot.
+	ctx := r.Context()
+	if sr, ok := h.d.Persister().(persistence.SnapshotReader); ok {
+		if req.Snaptoken == "" {
+			token, err := sr.SnapshotToken(ctx)
+			if err != nil {
+				h.d.Writer().WriteError(w, r, err)
+				return
+			}
+			req.Snaptoken = token
+		}
+		var err error
+		if ctx, err = sr.AtSnapshot(ctx, req.Snaptoken); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+	} else if req.Snaptoken != "" {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithReason("The persister does not support snapshots.")))
+		return
+	}
+
+	resp := &BatchCheckResponse{
+		Results:   make([]*BatchCheckResult, len(req.Checks)),
+		Snaptoken: req.Snaptoken,
+	}
+	for i, tuple := range req.Checks {
+		if tuple == nil {
+			resp.Results[i] = &BatchCheckResult{Error: "The check must not be null."}
+			continue
+		}
+		allowed, err := h.d.PermissionEngine().SubjectIsAllowed(ctx, tuple, req.MaxDepth)
+		if err != nil {
+			requestid.Logger(r.Context(), h.d.Logger()).WithError(err).WithFields(tuple.ToLoggerFields()).Debug("check in batch failed")
+			resp.Results[i] = &BatchCheckResult{Error: err.Error()}
+			continue
+		}
+		resp.Results[i] = &BatchCheckResult{Allowed: allowed}
+	}
+
+	h.d.Writer().Write(w, r, resp)
+}
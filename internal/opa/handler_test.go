@@ -0,0 +1,244 @@
+package opa_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/opa"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	nspaces := []*namespace.Namespace{{Name: "docs", ID: 1}, {Name: "groups", ID: 2}}
+
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, nspaces))
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedPollInterval, "10ms"))
+
+	h := opa.NewHandler(reg)
+	r, wr := httprouter.New(), httprouter.New()
+	h.RegisterReadRoutes(&x.ReadRouter{Router: r})
+	h.RegisterWriteRoutes(&x.WriteRouter{Router: wr})
+	ts, wts := httptest.NewServer(r), httptest.NewServer(wr)
+	t.Cleanup(ts.Close)
+	t.Cleanup(wts.Close)
+
+	feedCtx, cancel := context.WithCancel(ctx)
+	feedDone := make(chan error)
+	go func() { feedDone <- reg.ChangeFeed().Run(feedCtx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-feedDone)
+	})
+
+	tuples := []*relationtuple.InternalRelationTuple{{
+		Namespace: "docs",
+		Object:    "readme",
+		Relation:  "view",
+		Subject:   &relationtuple.SubjectSet{Namespace: "groups", Object: "dev", Relation: "member"},
+	}, {
+		Namespace: "groups",
+		Object:    "dev",
+		Relation:  "member",
+		Subject:   &relationtuple.SubjectID{ID: "alice"},
+	}}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuples...))
+
+	t.Run("endpoint=batch check", func(t *testing.T) {
+		post := func(t *testing.T, body interface{}) (int, string) {
+			raw, err := json.Marshal(body)
+			require.NoError(t, err)
+			resp, err := ts.Client().Post(ts.URL+opa.BatchCheckRoute, "application/json", bytes.NewReader(raw))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp.StatusCode, string(respBody)
+		}
+
+		t.Run("case=checks all tuples in order", func(t *testing.T) {
+			code, body := post(t, &opa.BatchCheckRequest{
+				Checks: []*relationtuple.InternalRelationTuple{
+					{Namespace: "docs", Object: "readme", Relation: "view", Subject: &relationtuple.SubjectID{ID: "alice"}},
+					{Namespace: "docs", Object: "readme", Relation: "view", Subject: &relationtuple.SubjectID{ID: "bob"}},
+					nil,
+				},
+			})
+			require.Equal(t, http.StatusOK, code, body)
+			assert.NotEmpty(t, gjson.Get(body, "snaptoken").String())
+			assert.Equal(t, []interface{}{true, false, false}, gjson.Get(body, "results.#.allowed").Value())
+			assert.False(t, gjson.Get(body, "results.1.error").Exists())
+			assert.NotEmpty(t, gjson.Get(body, "results.2.error").String())
+		})
+
+		t.Run("case=checks at the snapshot", func(t *testing.T) {
+			check := []*relationtuple.InternalRelationTuple{{Namespace: "docs", Object: "readme", Relation: "view", Subject: &relationtuple.SubjectID{ID: "carol"}}}
+			code, body := post(t, &opa.BatchCheckRequest{Checks: check})
+			require.Equal(t, http.StatusOK, code, body)
+			snaptoken := gjson.Get(body, "snaptoken").String()
+
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, check...))
+
+			code, body = post(t, &opa.BatchCheckRequest{Checks: check, Snaptoken: snaptoken})
+			require.Equal(t, http.StatusOK, code, body)
+			assert.Equal(t, snaptoken, gjson.Get(body, "snaptoken").String())
+			assert.False(t, gjson.Get(body, "results.0.allowed").Bool(), body)
+
+			code, body = post(t, &opa.BatchCheckRequest{Checks: check})
+			require.Equal(t, http.StatusOK, code, body)
+			assert.NotEqual(t, snaptoken, gjson.Get(body, "snaptoken").String())
+			assert.True(t, gjson.Get(body, "results.0.allowed").Bool(), body)
+		})
+
+		t.Run("case=rejects malformed snapshot tokens", func(t *testing.T) {
+			code, body := post(t, &opa.BatchCheckRequest{Checks: tuples[:1], Snaptoken: "token"})
+			assert.Equal(t, http.StatusBadRequest, code, body)
+		})
+
+		t.Run("case=rejects too large batches", func(t *testing.T) {
+			checks := make([]*relationtuple.InternalRelationTuple, 101)
+			for i := range checks {
+				checks[i] = tuples[0]
+			}
+			code, _ := post(t, &opa.BatchCheckRequest{Checks: checks})
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	})
+
+	t.Run("endpoint=bundle", func(t *testing.T) {
+		get := func(t *testing.T, path, etag string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, wts.URL+path, nil)
+			require.NoError(t, err)
+			if etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = resp.Body.Close() })
+			return resp
+		}
+		readBundle := func(t *testing.T, r io.Reader) map[string]string {
+			gr, err := gzip.NewReader(r)
+			require.NoError(t, err)
+			tr := tar.NewReader(gr)
+			files := map[string]string{}
+			for {
+				h, err := tr.Next()
+				if err == io.EOF {
+					return files
+				}
+				require.NoError(t, err)
+				content, err := io.ReadAll(tr)
+				require.NoError(t, err)
+				files[h.Name] = string(content)
+			}
+		}
+
+		bundleRequests := func(t *testing.T, result string) float64 {
+			mfs, err := prometheus.DefaultGatherer.Gather()
+			require.NoError(t, err)
+			for _, mf := range mfs {
+				if mf.GetName() != "keto_opa_bundle_requests_total" {
+					continue
+				}
+				for _, m := range mf.Metric {
+					if m.Label[0].GetValue() == result {
+						return m.Counter.GetValue()
+					}
+				}
+			}
+			return 0
+		}
+
+		t.Run("case=is not served on the read API", func(t *testing.T) {
+			resp, err := ts.Client().Get(ts.URL + opa.BundleRoute)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+
+		t.Run("case=contains all namespaces", func(t *testing.T) {
+			resp := get(t, opa.BundleRoute, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			files := readBundle(t, resp.Body)
+
+			assert.Equal(t, "groups:dev#member", gjson.Get(files["/data.json"], "keto.relation_tuples.docs.readme.view.0").String())
+			assert.Equal(t, "alice", gjson.Get(files["/data.json"], "keto.relation_tuples.groups.dev.member.0").String())
+			assert.Equal(t, `"`+gjson.Get(files["/.manifest"], "revision").String()+`"`, resp.Header.Get("ETag"))
+		})
+
+		t.Run("case=filters namespaces", func(t *testing.T) {
+			resp := get(t, opa.BundleRoute+"?namespace=groups", "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			files := readBundle(t, resp.Body)
+
+			assert.False(t, gjson.Get(files["/data.json"], "keto.relation_tuples.docs").Exists())
+			assert.True(t, gjson.Get(files["/data.json"], "keto.relation_tuples.groups").Exists())
+		})
+
+		t.Run("case=not modified", func(t *testing.T) {
+			etag := get(t, opa.BundleRoute, "").Header.Get("ETag")
+			assert.Equal(t, http.StatusNotModified, get(t, opa.BundleRoute, etag).StatusCode)
+
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+				Namespace: "groups",
+				Object:    "dev",
+				Relation:  "member",
+				Subject:   &relationtuple.SubjectID{ID: "bob"},
+			}))
+			assert.Equal(t, http.StatusOK, get(t, opa.BundleRoute, etag).StatusCode)
+		})
+
+		t.Run("case=caches the bundle per snapshot", func(t *testing.T) {
+			etag := get(t, opa.BundleRoute+"?namespace=docs", "").Header.Get("ETag")
+			hits := bundleRequests(t, "hit")
+			assert.Equal(t, etag, get(t, opa.BundleRoute+"?namespace=docs", "").Header.Get("ETag"))
+			assert.Equal(t, hits+1, bundleRequests(t, "hit"))
+		})
+
+		t.Run("case=rebuilds the bundle on changes", func(t *testing.T) {
+			etag := get(t, opa.BundleRoute+"?namespace=docs", "").Header.Get("ETag")
+			misses := bundleRequests(t, "miss")
+
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+				Namespace: "docs",
+				Object:    "readme",
+				Relation:  "view",
+				Subject:   &relationtuple.SubjectID{ID: "dave"},
+			}))
+			// The changefeed rebuilds the bundle without a request.
+			require.Eventually(t, func() bool { return bundleRequests(t, "miss") > misses }, 5*time.Second, 10*time.Millisecond)
+
+			hits := bundleRequests(t, "hit")
+			require.Eventually(t, func() bool {
+				resp := get(t, opa.BundleRoute+"?namespace=docs", etag)
+				return resp.StatusCode == http.StatusOK && bundleRequests(t, "hit") > hits
+			}, 5*time.Second, 10*time.Millisecond)
+		})
+
+		t.Run("case=unknown namespace", func(t *testing.T) {
+			assert.Equal(t, http.StatusNotFound, get(t, opa.BundleRoute+"?namespace=unknown", "").StatusCode)
+		})
+	})
+}
@@ -0,0 +1,211 @@
+package orgs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase     = "/orgs"
+	ProjectsRoute = RouteBase + "/:org/projects"
+	NodesRoute    = "/admin/orgs/nodes"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(r *x.ReadRouter) {
+	r.GET(ProjectsRoute, h.getVisibleProjects)
+}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.PUT(NodesRoute, h.registerNode)
+	r.DELETE(NodesRoute, h.deleteNode)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:parameters getVisibleProjects
+// nolint:deadcode,unused
+type getVisibleProjectsParameters struct {
+	// required: true
+	// in: path
+	Org string `json:"org"`
+
+	// The relation the subject needs on the projects, e.g. "view".
+	//
+	// required: true
+	// in: query
+	Relation string `json:"relation"`
+
+	// in: query
+	SubjectID string `json:"subject_id"`
+
+	// in: query
+	SubjectSetNamespace string `json:"subject_set.namespace"`
+
+	// in: query
+	SubjectSetObject string `json:"subject_set.object"`
+
+	// in: query
+	SubjectSetRelation string `json:"subject_set.relation"`
+}
+
+// The projects visible to a subject
+//
+// swagger:model getVisibleProjectsResponse
+type getVisibleProjectsResponse struct {
+	// The paths of the projects, sorted.
+	//
+	// required: true
+	Projects []string `json:"projects"`
+}
+
+// swagger:route GET /orgs/{org}/projects read getVisibleProjects
+//
+// List the projects a subject can see in an organization
+//
+// Lists the projects of the organization that the subject has the relation on,
+// directly or through a role bound on the organization or one of its teams.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getVisibleProjectsResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getVisibleProjects(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q := r.URL.Query()
+	query, err := (&relationtuple.RelationQuery{}).FromURLQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+	subject := query.Subject()
+	if subject == nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).
+			WithReason("Either subject_id or subject_set has to be set.").
+			WithDetail(errorcode.DetailField, "subject")))
+		return
+	}
+	relation := q.Get("relation")
+	if relation == "" {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReason("The relation is required.").
+			WithDetail(errorcode.DetailField, "relation")))
+		return
+	}
+
+	projects, err := h.d.OrgManager().VisibleProjects(r.Context(), ps.ByName("org"), relation, subject)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, &getVisibleProjectsResponse{Projects: projects})
+}
+
+// The organization, team, or project to register
+//
+// swagger:model orgNode
+type orgNode struct {
+	// The path of the object, e.g. "acme/platform/apollo".
+	//
+	// required: true
+	Path string `json:"path"`
+}
+
+// swagger:parameters registerNode
+// nolint:deadcode,unused
+type registerNodeParameters struct {
+	// in: body
+	Body orgNode
+}
+
+// swagger:route PUT /admin/orgs/nodes write registerNode
+//
+// Register an organization, team, or project
+//
+// Registers the object at the path and all its ancestors. Registering an object
+// again is a no-op.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: orgNode
+//       400: genericError
+//       500: genericError
+func (h *Handler) registerNode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body orgNode
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	if err := h.d.OrgManager().Register(r.Context(), body.Path); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().WriteCreated(w, r, NodesRoute+"?"+url.Values{"path": {body.Path}}.Encode(), &body)
+}
+
+// swagger:parameters deleteNode
+// nolint:deadcode,unused
+type deleteNodeParameters struct {
+	// required: true
+	// in: query
+	Path string `json:"path"`
+}
+
+// swagger:route DELETE /admin/orgs/nodes write deleteNode
+//
+// Delete an organization, team, or project
+//
+// Deletes the object at the path and everything below it, including all role
+// bindings on these objects.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) deleteNode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if err := h.d.OrgManager().Delete(r.Context(), r.URL.Query().Get("path")); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,265 @@
+package orgs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/check"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// Organizations, their teams, and the teams' projects are the objects of one
+// namespace with a hierarchy, e.g. "acme", "acme/platform", and
+// "acme/platform/apollo". Roles bound on an organization or a team apply to
+// all its projects. Every object is registered with a node tuple, e.g.
+// "orgs:acme/platform/apollo#node@project", so that the projects of an
+// organization can be listed.
+
+type (
+	Provider interface {
+		OrgManager() *Manager
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+		relationtuple.SearcherProvider
+		check.EngineProvider
+	}
+	Manager struct {
+		d dependencies
+	}
+)
+
+const (
+	Separator    = "/"
+	NodeRelation = "node"
+
+	KindOrg     = "org"
+	KindTeam    = "team"
+	KindProject = "project"
+)
+
+var kinds = []string{KindOrg, KindTeam, KindProject}
+
+// Template returns the namespace of the organization hierarchy with the
+// default roles. Viewers see, editors change, and admins manage the objects
+// that the role is bound on and all objects below.
+func Template(id int32, name string) *namespace.Namespace {
+	opts := &namespace.Options{
+		Hierarchy: &namespace.Hierarchy{
+			Separator: Separator,
+			Relations: []string{"view", "edit", "manage"},
+		},
+		Roles: map[string][]string{
+			"viewer": {"view"},
+			"editor": {"view", "edit"},
+			"admin":  {"view", "edit", "manage"},
+		},
+	}
+	// Marshalling the options can't fail.
+	cfg, _ := json.Marshal(opts)
+	return &namespace.Namespace{ID: id, Name: name, Config: cfg}
+}
+
+func NewManager(d dependencies) *Manager {
+	return &Manager{d: d}
+}
+
+// Kind returns the kind of the object at the path, by its depth.
+func Kind(path string) (string, error) {
+	segments := strings.Split(path, Separator)
+	for _, s := range segments {
+		if s == "" {
+			return "", errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("The path %q has an empty segment.", path).
+				WithDetail(errorcode.DetailField, "path"))
+		}
+	}
+	if len(segments) > len(kinds) {
+		return "", errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReasonf("The path %q is deeper than organization, team, and project.", path).
+			WithDetail(errorcode.DetailField, "path"))
+	}
+	return kinds[len(segments)-1], nil
+}
+
+func (m *Manager) node(ctx context.Context, path string) (*relationtuple.InternalRelationTuple, error) {
+	kind, err := Kind(path)
+	if err != nil {
+		return nil, err
+	}
+	return &relationtuple.InternalRelationTuple{
+		Namespace: m.d.Config(ctx).OrgsNamespace(),
+		Object:    path,
+		Relation:  NodeRelation,
+		Subject:   &relationtuple.SubjectID{ID: kind},
+	}, nil
+}
+
+// Register registers the organization, team, or project at the path, and its
+// ancestors. Registering an object again is a no-op.
+func (m *Manager) Register(ctx context.Context, path string) error {
+	segments := strings.Split(path, Separator)
+	var insert []*relationtuple.InternalRelationTuple
+	for i := range segments {
+		rt, err := m.node(ctx, strings.Join(segments[:i+1], Separator))
+		if err != nil {
+			return err
+		}
+		res, _, err := m.d.RelationTupleManager().GetRelationTuples(ctx, rt.ToQuery(), x.WithSize(1))
+		if err != nil {
+			return err
+		}
+		if len(res) == 0 {
+			insert = append(insert, rt)
+		}
+	}
+	if len(insert) == 0 {
+		return nil
+	}
+	return m.d.RelationTupleManager().TransactRelationTuples(ctx, insert, nil)
+}
+
+// subtree calls f for every relation tuple of the object at the path and of
+// the objects below it.
+func (m *Manager) subtree(ctx context.Context, path string, f func(rt *relationtuple.InternalRelationTuple)) error {
+	s := m.d.RelationTupleSearcher()
+	if s == nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not support searching relation tuples"))
+	}
+
+	var page string
+	for {
+		res, next, err := s.SearchRelationTuples(ctx, &relationtuple.SearchQuery{
+			Namespace: m.d.Config(ctx).OrgsNamespace(),
+			Object:    path,
+			Match:     relationtuple.SearchMatchPrefix,
+		}, x.WithToken(page), x.WithSize(m.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return err
+		}
+		for _, rt := range res {
+			// The prefix "acme" matches "acme-corp" as well.
+			if rt.Object == path || strings.HasPrefix(rt.Object, path+Separator) {
+				f(rt)
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		page = next
+	}
+}
+
+// Delete deletes the object at the path and everything below it, including
+// the role bindings and grants on these objects.
+func (m *Manager) Delete(ctx context.Context, path string) error {
+	if _, err := Kind(path); err != nil {
+		return err
+	}
+	var del []*relationtuple.InternalRelationTuple
+	if err := m.subtree(ctx, path, func(rt *relationtuple.InternalRelationTuple) {
+		del = append(del, rt)
+	}); err != nil {
+		return err
+	}
+	if len(del) == 0 {
+		return nil
+	}
+	return m.d.RelationTupleManager().TransactRelationTuples(ctx, nil, del)
+}
+
+// VisibleProjects returns the projects of the organization that the subject
+// has the relation on, sorted by path. If the relation is inherited, a team
+// that the subject has the relation on grants all its projects without
+// checking them one by one, and so does the organization.
+func (m *Manager) VisibleProjects(ctx context.Context, org, relation string, subject relationtuple.Subject) ([]string, error) {
+	if kind, err := Kind(org); err != nil {
+		return nil, err
+	} else if kind != KindOrg {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReasonf("%q is not an organization.", org).
+			WithDetail(errorcode.DetailField, "org"))
+	}
+
+	teams := map[string][]string{}
+	if err := m.subtree(ctx, org, func(rt *relationtuple.InternalRelationTuple) {
+		if rt.Relation != NodeRelation {
+			return
+		}
+		if id, ok := rt.Subject.(*relationtuple.SubjectID); ok && id.ID == KindProject {
+			team := rt.Object[:strings.LastIndex(rt.Object, Separator)]
+			teams[team] = append(teams[team], rt.Object)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	ns := m.d.Config(ctx).OrgsNamespace()
+	opts, err := m.options(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	inherited := opts.Hierarchy.Inherits(relation)
+
+	allowed := func(object string) (bool, error) {
+		return m.d.PermissionEngine().SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: ns,
+			Object:    object,
+			Relation:  relation,
+			Subject:   subject,
+		}, 0)
+	}
+
+	visible := []string{}
+	all := false
+	if inherited {
+		if all, err = allowed(org); err != nil {
+			return nil, err
+		}
+	}
+	for team, projects := range teams {
+		teamAllowed := all
+		if inherited && !teamAllowed {
+			if teamAllowed, err = allowed(team); err != nil {
+				return nil, err
+			}
+		}
+		if teamAllowed {
+			visible = append(visible, projects...)
+			continue
+		}
+		for _, p := range projects {
+			ok, err := allowed(p)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				visible = append(visible, p)
+			}
+		}
+	}
+	sort.Strings(visible)
+	return visible, nil
+}
+
+func (m *Manager) options(ctx context.Context, name string) (*namespace.Options, error) {
+	nm, err := m.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	n, err := nm.GetNamespaceByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return namespace.ResolvedOptions(ctx, nm, n)
+}
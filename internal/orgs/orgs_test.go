@@ -0,0 +1,171 @@
+package orgs_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/orgs"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/roles"
+	"github.com/ory/keto/internal/x"
+)
+
+func newRegistry(t *testing.T) *driver.RegistryDefault {
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(context.Background()).Set(config.KeyNamespaces, []*namespace.Namespace{orgs.Template(0, "orgs")}))
+	return reg
+}
+
+func bind(t *testing.T, reg *driver.RegistryDefault, object, role, subject string) {
+	require.NoError(t, reg.RoleManager().Bind(context.Background(), &roles.Binding{
+		Namespace: "orgs",
+		Object:    object,
+		Role:      role,
+		SubjectID: pointerx.String(subject),
+	}))
+}
+
+func TestKind(t *testing.T) {
+	for _, tc := range []struct {
+		path, kind string
+	}{
+		{path: "acme", kind: orgs.KindOrg},
+		{path: "acme/platform", kind: orgs.KindTeam},
+		{path: "acme/platform/apollo", kind: orgs.KindProject},
+		{path: "acme/platform/apollo/api"},
+		{path: "acme//apollo"},
+		{path: ""},
+	} {
+		t.Run("path="+tc.path, func(t *testing.T) {
+			kind, err := orgs.Kind(tc.path)
+			if tc.kind == "" {
+				assert.True(t, errorcode.Is(err, errorcode.RequestInvalid), "%+v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.kind, kind)
+		})
+	}
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=template is valid", func(t *testing.T) {
+		require.NoError(t, namespace.Validate([]*namespace.Namespace{orgs.Template(0, "orgs")}))
+	})
+
+	t.Run("case=lists visible projects", func(t *testing.T) {
+		reg := newRegistry(t)
+		m := reg.OrgManager()
+
+		for _, p := range []string{
+			"acme/platform/apollo",
+			"acme/platform/gemini",
+			"acme/web/shop",
+			"acme/web/blog",
+			"acme-corp/web/shop",
+		} {
+			require.NoError(t, m.Register(ctx, p))
+		}
+		require.NoError(t, m.Register(ctx, "acme/platform"), "registering again is a no-op")
+
+		bind(t, reg, "acme/platform", "viewer", "alice")
+		bind(t, reg, "acme/web/blog", "editor", "alice")
+		bind(t, reg, "acme", "admin", "bob")
+		bind(t, reg, "acme-corp", "admin", "alice")
+
+		for _, tc := range []struct {
+			relation, subject string
+			expected          []string
+		}{
+			{relation: "view", subject: "alice", expected: []string{"acme/platform/apollo", "acme/platform/gemini", "acme/web/blog"}},
+			{relation: "edit", subject: "alice", expected: []string{"acme/web/blog"}},
+			{relation: "manage", subject: "bob", expected: []string{"acme/platform/apollo", "acme/platform/gemini", "acme/web/blog", "acme/web/shop"}},
+			{relation: "view", subject: "carol", expected: []string{}},
+		} {
+			t.Run("relation="+tc.relation+"/subject="+tc.subject, func(t *testing.T) {
+				projects, err := m.VisibleProjects(ctx, "acme", tc.relation, &relationtuple.SubjectID{ID: tc.subject})
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, projects)
+			})
+		}
+
+		_, err := m.VisibleProjects(ctx, "acme/platform", "view", &relationtuple.SubjectID{ID: "alice"})
+		assert.True(t, errorcode.Is(err, errorcode.RequestInvalid), "%+v", err)
+	})
+
+	t.Run("case=deletes subtrees", func(t *testing.T) {
+		reg := newRegistry(t)
+		m := reg.OrgManager()
+
+		require.NoError(t, m.Register(ctx, "acme/platform/apollo"))
+		require.NoError(t, m.Register(ctx, "acme/platform-old/gemini"))
+		bind(t, reg, "acme/platform", "viewer", "alice")
+		bind(t, reg, "acme/platform-old", "viewer", "alice")
+
+		require.NoError(t, m.Delete(ctx, "acme/platform"))
+
+		projects, err := m.VisibleProjects(ctx, "acme", "view", &relationtuple.SubjectID{ID: "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acme/platform-old/gemini"}, projects, "siblings sharing the prefix are kept")
+
+		bindings, _, err := reg.RoleManager().Bindings(ctx, "orgs", "acme/platform", "viewer")
+		require.NoError(t, err)
+		assert.Empty(t, bindings)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	reg := newRegistry(t)
+	r := httprouter.New()
+	h := orgs.NewHandler(reg)
+	h.RegisterReadRoutes(&x.ReadRouter{Router: r})
+	h.RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string, expectedStatus int) []byte {
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		require.Equal(t, expectedStatus, resp.StatusCode, buf.String())
+		return buf.Bytes()
+	}
+
+	do(t, http.MethodPut, orgs.NodesRoute, `{"path": "acme/platform/apollo"}`, http.StatusCreated)
+	do(t, http.MethodPut, orgs.NodesRoute, `{"path": "acme/web/shop"}`, http.StatusCreated)
+	do(t, http.MethodPut, orgs.NodesRoute, `{"path": "acme/web/shop/api"}`, http.StatusBadRequest)
+	bind(t, reg, "acme/web", "viewer", "alice")
+
+	var body struct {
+		Projects []string `json:"projects"`
+	}
+	require.NoError(t, json.Unmarshal(do(t, http.MethodGet, "/orgs/acme/projects?relation=view&subject_id=alice", "", http.StatusOK), &body))
+	assert.Equal(t, []string{"acme/web/shop"}, body.Projects)
+
+	do(t, http.MethodGet, "/orgs/acme/projects?relation=view", "", http.StatusBadRequest)
+	do(t, http.MethodGet, "/orgs/acme/projects?subject_id=alice", "", http.StatusBadRequest)
+
+	do(t, http.MethodDelete, orgs.NodesRoute+"?path=acme/web", "", http.StatusNoContent)
+	require.NoError(t, json.Unmarshal(do(t, http.MethodGet, "/orgs/acme/projects?relation=view&subject_id=alice", "", http.StatusOK), &body))
+	assert.Empty(t, body.Projects)
+}
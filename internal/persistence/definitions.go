@@ -3,21 +3,20 @@ package persistence
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/ory/x/popx"
 
-	"github.com/gobuffalo/pop/v6"
-
 	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/ketodriver"
 )
 
 type (
-	Persister interface {
-		relationtuple.Manager
-
-		Connection(ctx context.Context) *pop.Connection
-	}
-	Migrator interface {
+	Persister = ketodriver.Persister
+	Migrator  interface {
 		MigrationBox(ctx context.Context) (*popx.MigrationBox, error)
 		MigrateUp(ctx context.Context) error
 		MigrateDown(ctx context.Context) error
@@ -25,10 +24,153 @@ type (
 	Provider interface {
 		Persister() Persister
 	}
+	// SubjectEraser can optionally be implemented by a Persister to erase all
+	// data of a subject, e.g. to comply with a GDPR erasure request.
+	SubjectEraser interface {
+		// EraseSubject deletes all relation tuples with the subject ID, and,
+		// if the ID has the form <namespace>:<object>, all relation tuples
+		// with a subject set of that object. With dryRun, the tuples are only
+		// reported.
+		EraseSubject(ctx context.Context, subjectID string, dryRun bool) (*ErasureReport, error)
+	}
+	// TupleCounter can optionally be implemented by a Persister to report the
+	// number of stored relation tuples for usage metering.
+	TupleCounter interface {
+		// CountRelationTuplesByNetwork counts the relation tuples of all
+		// networks, not only of the network in the context.
+		CountRelationTuplesByNetwork(ctx context.Context) (map[uuid.UUID]int64, error)
+	}
+	// SnapshotCache can optionally be implemented by a Persister to cache
+	// query results in the database. Values are only returned for the
+	// snapshot token they were cached for, which changes with every write and
+	// deletion of the network.
+	SnapshotCache interface {
+		SnapshotReader
+		GetCachedValue(ctx context.Context, key, snapshot string) ([]byte, bool, error)
+		SetCachedValue(ctx context.Context, key, snapshot string, value []byte) error
+	}
+	// SnapshotReader can optionally be implemented by a Persister to read
+	// relation tuples as they were at an earlier snapshot. Deleted tuples are
+	// restored from the deletion log, which is only kept for a retention
+	// period.
+	SnapshotReader interface {
+		// SnapshotToken returns an opaque token of the current snapshot of
+		// the network in the context.
+		SnapshotToken(ctx context.Context) (string, error)
+		// AtSnapshot returns a context in which relation tuples are read as
+		// of the snapshot of the token. It fails with SnapshotTokenExpired if
+		// deletions since then were already pruned.
+		AtSnapshot(ctx context.Context, token string) (context.Context, error)
+	}
+	// DeltaReader can optionally be implemented by a Persister to list the
+	// changes to the relation tuples since a snapshot token of the
+	// SnapshotReader. Deleted tuples are only kept for a retention period.
+	DeltaReader interface {
+		// ChangesSince returns the relation tuples deleted and written after
+		// the snapshot of the token. It fails with SnapshotTokenExpired if
+		// deletions since then were already pruned.
+		ChangesSince(ctx context.Context, token string) (*Delta, error)
+	}
+	Delta struct {
+		// Token is the snapshot token of the state after the changes.
+		Token string
+		// Deleted are the relation tuples deleted since the token. Tuples
+		// that were written again afterwards are also in Written, so the
+		// deletions have to be applied first.
+		Deleted []*relationtuple.InternalRelationTuple
+		Written []*relationtuple.InternalRelationTuple
+	}
+	// ChangeFeed can optionally be implemented by a Persister to stream the
+	// changes to relation tuples of all networks, regardless of which server
+	// wrote them.
+	ChangeFeed interface {
+		// WatchChanges calls f for every change until the context is
+		// canceled. It returns ErrChangeFeedUnsupported if the database
+		// can't stream changes.
+		WatchChanges(ctx context.Context, f func(*Change)) error
+	}
+	Change struct {
+		NetworkID     uuid.UUID
+		RelationTuple *relationtuple.InternalRelationTuple
+		// Deleted is true if the relation tuple was deleted, otherwise it was
+		// written.
+		Deleted bool
+	}
+	// SeedStore can optionally be implemented by a Persister to seed an
+	// empty network exactly once.
+	SeedStore interface {
+		// ApplySeed calls apply in a transaction and records the seed with
+		// the digest, if the network was not seeded yet and holds neither
+		// relation tuples nor namespace configs. If the network was seeded
+		// before, it returns SeedAlreadyApplied and the digest of that seed.
+		ApplySeed(ctx context.Context, digest string, apply func(ctx context.Context) error) (status SeedStatus, appliedDigest string, err error)
+	}
+	SeedStatus    string
+	ErasureReport struct {
+		Subject  string    `json:"subject"`
+		DryRun   bool      `json:"dry_run"`
+		ErasedAt time.Time `json:"erased_at"`
+		// Tombstoned is true if the deleted tuples left a tombstone, which
+		// holds only a hash of the tuple, so that replicas do not restore
+		// them.
+		Tombstoned bool `json:"tombstoned"`
+		// PurgedDeletions is the number of entries of the deletion log that
+		// held the subject from earlier deletions. They are purged together
+		// with the entries of the erased tuples, so that reads at earlier
+		// snapshots and deltas don't restore the subject.
+		PurgedDeletions int                                    `json:"purged_deletions"`
+		RelationTuples  []*relationtuple.InternalRelationTuple `json:"relation_tuples"`
+	}
+	bypassCacheContextKey struct{}
+)
+
+const (
+	SeedApplied        SeedStatus = "applied"
+	SeedAlreadyApplied SeedStatus = "already_applied"
+	SeedNotEmpty       SeedStatus = "not_empty"
 )
 
 var (
 	ErrNamespaceUnknown         = errors.New("namespace unknown")
 	ErrMalformedPageToken       = errors.New("malformed page token")
 	ErrNetworkMigrationsMissing = errors.New("networkx migrations are not yet applied")
+	ErrChangeFeedUnsupported    = errors.New("the database does not support change feeds")
 )
+
+// WithoutCache returns a context in which no results are read from or written
+// to the SnapshotCache, e.g. to verify results independently of it.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheContextKey{}, true)
+}
+
+// CacheBypassed returns whether the context was returned by WithoutCache.
+func CacheBypassed(ctx context.Context) bool {
+	return ctx.Value(bypassCacheContextKey{}) != nil
+}
+
+func (r *ErasureReport) String() string {
+	var b strings.Builder
+	verb := "Erased"
+	if r.DryRun {
+		verb = "Would erase"
+	}
+	_, _ = fmt.Fprintf(&b, "%s %d relation tuples of the subject %s", verb, len(r.RelationTuples), r.Subject)
+	if !r.DryRun {
+		_, _ = fmt.Fprintf(&b, " at %s", r.ErasedAt.Format(time.RFC3339))
+	}
+	if r.Tombstoned {
+		b.WriteString(", leaving tombstones")
+	}
+	if r.PurgedDeletions > 0 {
+		_, _ = fmt.Fprintf(&b, ", purging %d entries of the deletion log", r.PurgedDeletions)
+	}
+	b.WriteString(".\n")
+	for _, rt := range r.RelationTuples {
+		_, _ = fmt.Fprintf(&b, "  %s\n", rt)
+	}
+	return b.String()
+}
+
+func (r *ErasureReport) Interface() interface{} {
+	return r
+}
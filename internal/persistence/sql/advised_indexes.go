@@ -0,0 +1,91 @@
+package sql
+
+import (
+	"context"
+	"embed"
+
+	"github.com/ory/x/sqlcon"
+)
+
+// AdvisedIndexMigrations create covering indexes for common list queries.
+// They are only applied if migrations.advised_indexes is enabled, because they
+// slow down writes.
+//
+//go:embed migrations/advised/*.sql
+var AdvisedIndexMigrations embed.FS
+
+type (
+	// IndexReport reports which of the advised indexes exist.
+	IndexReport struct {
+		Indexes []*AdvisedIndex `json:"indexes"`
+		// SequentialScans is the number of sequential scans of the relation
+		// tuple table since the statistics were reset. Only PostgreSQL
+		// reports it.
+		SequentialScans *int64 `json:"sequential_scans,omitempty"`
+	}
+	AdvisedIndex struct {
+		Name string `json:"name"`
+		// QueryShape describes the queries the index is for.
+		QueryShape string `json:"query_shape"`
+		Present    bool   `json:"present"`
+		// Scans is the number of scans of the index since the statistics
+		// were reset. Only PostgreSQL reports it.
+		Scans *int64 `json:"scans,omitempty"`
+	}
+)
+
+var advisedIndexes = []*AdvisedIndex{
+	{Name: "keto_relation_tuples_subject_id_commit_time_idx", QueryShape: "list by subject ID"},
+	{Name: "keto_relation_tuples_object_relation_commit_time_idx", QueryShape: "list by object and relation"},
+	{Name: "keto_relation_tuples_subject_set_commit_time_idx", QueryShape: "list by subject set (reverse lookup)"},
+}
+
+// AdvisedIndexes reports which of the advised indexes exist, and how often
+// they and the relation tuple table were scanned, if the database reports it.
+func (p *Persister) AdvisedIndexes(ctx context.Context) (*IndexReport, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.AdvisedIndexes")
+	defer span.End()
+
+	conn := p.Connection(ctx)
+	dialect := conn.Dialect.Name()
+	report := &IndexReport{}
+
+	for _, advised := range advisedIndexes {
+		idx := *advised
+
+		var count int
+		var q string
+		switch dialect {
+		case "mysql":
+			q = "SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'keto_relation_tuples' AND index_name = ?"
+		case "sqlite3":
+			q = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?"
+		default:
+			q = "SELECT COUNT(*) FROM pg_indexes WHERE tablename = 'keto_relation_tuples' AND indexname = ?"
+		}
+		if err := conn.RawQuery(q, idx.Name).First(&count); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		idx.Present = count > 0
+
+		if dialect == "postgres" && idx.Present {
+			var scans int64
+			if err := conn.RawQuery("SELECT idx_scan FROM pg_stat_user_indexes WHERE indexrelname = ?", idx.Name).First(&scans); err != nil {
+				return nil, sqlcon.HandleError(err)
+			}
+			idx.Scans = &scans
+		}
+
+		report.Indexes = append(report.Indexes, &idx)
+	}
+
+	if dialect == "postgres" {
+		var scans int64
+		if err := conn.RawQuery("SELECT seq_scan FROM pg_stat_user_tables WHERE relname = 'keto_relation_tuples'").First(&scans); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		report.SequentialScans = &scans
+	}
+
+	return report, nil
+}
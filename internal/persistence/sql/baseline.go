@@ -0,0 +1,116 @@
+package sql
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/fsx"
+	"github.com/ory/x/networkx"
+	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
+)
+
+// A fresh database does not have to replay every historical migration. The
+// baseline migration runs before all other migrations and, if none of them was
+// applied yet, creates the schema from a snapshot and marks all migrations up
+// to BaselineVersion as applied. Only the migrations after the baseline are
+// then run one by one. On databases that were migrated before, the baseline
+// migration does nothing.
+
+const (
+	// BaselineVersion is the version of the latest migration contained in the
+	// schema snapshots.
+	BaselineVersion = "20220512151000000000"
+
+	// baselineMigrationVersion sorts before all other migrations, including
+	// the ones of networkx.
+	baselineMigrationVersion = "20150100000000000000"
+)
+
+var (
+	//go:embed migrations/baseline/*.sql
+	baselines embed.FS
+
+	nonWordRegex = regexp.MustCompile(`\W`)
+)
+
+func baselineMigrations() (popx.Migrations, error) {
+	versions, err := migrationVersions(fsx.Merge(Migrations, networkx.Migrations))
+	if err != nil {
+		return nil, err
+	}
+	var squashed []string
+	for v := range versions {
+		if v <= BaselineVersion {
+			squashed = append(squashed, v)
+		}
+	}
+
+	return popx.Migrations{{
+		Version:   baselineMigrationVersion,
+		Path:      "baseline",
+		Name:      "baseline",
+		DBType:    "all",
+		Direction: "up",
+		Type:      "go",
+		Runner: func(_ popx.Migration, c *pop.Connection, tx *pop.Tx) error {
+			return applyBaseline(c, tx, squashed)
+		},
+	}, {
+		Version:   baselineMigrationVersion,
+		Path:      "baseline",
+		Name:      "baseline",
+		DBType:    "all",
+		Direction: "down",
+		Type:      "go",
+		// The squashed migrations are recorded as applied and migrated down
+		// individually.
+		Runner: func(popx.Migration, *pop.Connection, *pop.Tx) error {
+			return nil
+		},
+	}}, nil
+}
+
+func applyBaseline(c *pop.Connection, tx *pop.Tx, squashed []string) error {
+	schema, err := fs.ReadFile(baselines, "migrations/baseline/"+c.Dialect.Name()+".sql")
+	if errors.Is(err, fs.ErrNotExist) {
+		// No snapshot for this dialect, replay all migrations.
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	mtn := nonWordRegex.ReplaceAllString(c.MigrationTableName(), "")
+
+	// Versions with more than 14 digits might have been recorded in their
+	// legacy form by older versions of the migrator.
+	versions := make([]interface{}, 0, 2*len(squashed))
+	for _, v := range squashed {
+		versions = append(versions, v, v[:14])
+	}
+	var applied int
+	// #nosec G201 - mtn is a system-wide const
+	if err := tx.Get(&applied, tx.Rebind(fmt.Sprintf(
+		"SELECT COUNT(*) FROM %s WHERE version IN (?%s)", mtn, strings.Repeat(", ?", len(versions)-1),
+	)), versions...); err != nil {
+		return errors.WithStack(err)
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(string(schema)); err != nil {
+		return errors.Wrap(err, "could not apply the baseline schema")
+	}
+	for _, v := range squashed {
+		// #nosec G201 - mtn is a system-wide const
+		if _, err := tx.Exec(tx.Rebind(fmt.Sprintf("INSERT INTO %s (version) VALUES (?)", mtn)), v); err != nil {
+			return errors.Wrapf(err, "could not mark migration %s as applied", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/fsx"
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/networkx"
+	"github.com/ory/x/popx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestBaseline(t *testing.T) {
+	ctx := context.Background()
+	l := logrusx.New("", "")
+
+	connect := func(t *testing.T) *pop.Connection {
+		c, err := pop.NewConnection(&pop.ConnectionDetails{URL: dbx.GetSqlite(t, dbx.SQLiteMemory).Conn})
+		require.NoError(t, err)
+		require.NoError(t, c.Open())
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+	replayBox := func(t *testing.T, c *pop.Connection) *popx.MigrationBox {
+		mb, err := popx.NewMigrationBox(fsx.Merge(sql.Migrations, networkx.Migrations), popx.NewMigrator(c, l, nil, 0))
+		require.NoError(t, err)
+		return mb
+	}
+	baselineBox := func(t *testing.T, c *pop.Connection) *popx.MigrationBox {
+		mb, err := sql.NewMigrationBox(c, l, nil)
+		require.NoError(t, err)
+		return mb
+	}
+	// schema describes the columns, indices, and foreign keys of all tables.
+	schema := func(t *testing.T, c *pop.Connection) map[string][]string {
+		var tables []string
+		require.NoError(t, c.RawQuery("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migration' ORDER BY name").All(&tables))
+
+		res := make(map[string][]string)
+		for _, table := range tables {
+			for _, q := range []string{
+				"SELECT name || ' ' || type || ' ' || \"notnull\" || ' ' || pk FROM pragma_table_info('%s') ORDER BY cid",
+				"SELECT name || ' ' || \"unique\" || ' ' || partial FROM pragma_index_list('%s') ORDER BY name",
+				"SELECT \"table\" || ' ' || \"from\" || ' ' || \"to\" FROM pragma_foreign_key_list('%s') ORDER BY id",
+			} {
+				var rows []string
+				require.NoError(t, c.RawQuery(fmt.Sprintf(q, table)).All(&rows))
+				res[table] = append(res[table], rows...)
+			}
+		}
+		return res
+	}
+
+	t.Run("case=equals the replayed schema", func(t *testing.T) {
+		replayed, baseline := connect(t), connect(t)
+		require.NoError(t, replayBox(t, replayed).Up(ctx))
+		require.NoError(t, baselineBox(t, baseline).Up(ctx))
+
+		expected := schema(t, replayed)
+		require.Contains(t, expected, "keto_relation_tuples")
+		assert.Equal(t, expected, schema(t, baseline))
+
+		var count int
+		require.NoError(t, baseline.RawQuery("SELECT COUNT(*) FROM schema_migration WHERE version = ?", sql.BaselineVersion).First(&count))
+		assert.Equal(t, 1, count)
+
+		s, err := baselineBox(t, baseline).Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, s.HasPending())
+	})
+
+	t.Run("case=does nothing on migrated databases", func(t *testing.T) {
+		c := connect(t)
+		_, err := replayBox(t, c).UpTo(ctx, 3)
+		require.NoError(t, err)
+
+		mb := baselineBox(t, c)
+		require.NoError(t, mb.Up(ctx))
+		s, err := mb.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, s.HasPending())
+
+		expected := connect(t)
+		require.NoError(t, replayBox(t, expected).Up(ctx))
+		assert.Equal(t, schema(t, expected), schema(t, c))
+	})
+
+	t.Run("case=migrates down", func(t *testing.T) {
+		c := connect(t)
+		mb := baselineBox(t, c)
+		require.NoError(t, mb.Up(ctx))
+		require.NoError(t, mb.Down(ctx, -1))
+		assert.Empty(t, schema(t, c))
+	})
+}
@@ -0,0 +1,152 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+var _ persistence.ChangeFeed = &Persister{}
+
+type (
+	// changefeedRow is a row of keto_relation_tuples as encoded by the
+	// changefeed.
+	changefeedRow struct {
+		ID                    uuid.UUID `json:"shard_id"`
+		NetworkID             uuid.UUID `json:"nid"`
+		NamespaceID           int32     `json:"namespace_id"`
+		Object                string    `json:"object"`
+		Relation              string    `json:"relation"`
+		SubjectID             *string   `json:"subject_id"`
+		SubjectSetNamespaceID *int32    `json:"subject_set_namespace_id"`
+		SubjectSetObject      *string   `json:"subject_set_object"`
+		SubjectSetRelation    *string   `json:"subject_set_relation"`
+	}
+	changefeedValue struct {
+		After  *changefeedRow `json:"after"`
+		Before *changefeedRow `json:"before"`
+	}
+	queryer interface {
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	}
+)
+
+func (r *changefeedRow) toRelationTuple() *RelationTuple {
+	rt := &RelationTuple{
+		ID:          r.ID,
+		NetworkID:   r.NetworkID,
+		NamespaceID: r.NamespaceID,
+		Object:      r.Object,
+		Relation:    r.Relation,
+	}
+	if r.SubjectID != nil {
+		rt.SubjectID = sql.NullString{String: *r.SubjectID, Valid: true}
+	}
+	if r.SubjectSetNamespaceID != nil {
+		rt.SubjectSetNamespaceID = sql.NullInt32{Int32: *r.SubjectSetNamespaceID, Valid: true}
+	}
+	if r.SubjectSetObject != nil {
+		rt.SubjectSetObject = sql.NullString{String: *r.SubjectSetObject, Valid: true}
+	}
+	if r.SubjectSetRelation != nil {
+		rt.SubjectSetRelation = sql.NullString{String: *r.SubjectSetRelation, Valid: true}
+	}
+	return rt
+}
+
+// WatchChanges streams the changes from a changefeed on CockroachDB, and from
+// the notifications of a trigger on PostgreSQL. MySQL and SQLite have neither,
+// so their relation tuples are polled. Changes before the call are not
+// replayed. The databases of all regions are watched, f is never called
+// concurrently.
+func (p *Persister) WatchChanges(ctx context.Context, f func(*persistence.Change)) error {
+	var mu sync.Mutex
+	publish := func(c *persistence.Change) {
+		mu.Lock()
+		defer mu.Unlock()
+		f(c)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, c := range p.databases() {
+		c := c
+		eg.Go(func() error {
+			return p.watchDatabase(ctx, c, publish)
+		})
+	}
+	return eg.Wait()
+}
+
+func (p *Persister) watchDatabase(ctx context.Context, c *pop.Connection, f func(*persistence.Change)) error {
+	switch c.Dialect.Name() {
+	case "cockroach":
+		return p.watchChangefeed(ctx, c, f)
+	case "postgres":
+		return p.listenForChanges(ctx, c, f)
+	case "mysql", "sqlite3":
+		return p.pollChanges(ctx, c, f)
+	}
+	return errors.WithStack(persistence.ErrChangeFeedUnsupported)
+}
+
+func (p *Persister) publishChange(ctx context.Context, row *changefeedRow, deleted bool, f func(*persistence.Change)) {
+	rt, err := row.toRelationTuple().toInternal(ctx, p.namespaceNames())
+	if err != nil {
+		// Tuples of deleted namespaces can't be resolved.
+		return
+	}
+	f(&persistence.Change{NetworkID: row.NetworkID, RelationTuple: rt, Deleted: deleted})
+}
+
+// watchChangefeed subscribes to a core changefeed, which streams the changes
+// over the SQL connection without a sink. This requires the cluster setting
+// kv.rangefeed.enabled.
+func (p *Persister) watchChangefeed(ctx context.Context, c *pop.Connection, f func(*persistence.Change)) error {
+	q, ok := c.Store.(queryer)
+	if !ok {
+		return errors.WithStack(persistence.ErrChangeFeedUnsupported)
+	}
+
+	rows, err := q.QueryContext(ctx, "EXPERIMENTAL CHANGEFEED FOR keto_relation_tuples WITH diff")
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table      string
+			key, value []byte
+		)
+		if err := rows.Scan(&table, &key, &value); err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		var v changefeedValue
+		if err := json.Unmarshal(value, &v); err != nil {
+			return errors.WithStack(err)
+		}
+		row, deleted := v.After, false
+		if row == nil {
+			row, deleted = v.Before, true
+		}
+		if row == nil {
+			continue
+		}
+
+		p.publishChange(ctx, row, deleted, f)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sqlcon.HandleError(rows.Err())
+}
@@ -0,0 +1,100 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestChangeFeed(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+
+			// Deleted rows can only be reported by streaming databases, the
+			// others are polled.
+			streamsDeletes := false
+			switch dsn.Name {
+			case "cockroach":
+				c, err := reg.PopConnection(ctx)
+				require.NoError(t, err)
+				require.NoError(t, c.RawQuery("SET CLUSTER SETTING kv.rangefeed.enabled = true").Exec())
+				streamsDeletes = true
+			case "postgres":
+				streamsDeletes = true
+			default:
+				require.NoError(t, reg.Config(ctx).Set(config.KeyChangeFeedPollInterval, "10ms"))
+			}
+			before := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "before", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, before))
+
+			ctx, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			changes := make(chan *persistence.Change, 10)
+			done := make(chan error)
+			go func() {
+				done <- p.WatchChanges(ctx, func(c *persistence.Change) { changes <- c })
+			}()
+
+			// Changes are only streamed once the subscription is set up.
+			probes := 0
+			require.Eventually(t, func() bool {
+				probes++
+				require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+					Namespace: "n", Object: fmt.Sprintf("probe-%d", probes), Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"},
+				}))
+				select {
+				case c := <-changes:
+					assert.NotEqual(t, before, c.RelationTuple, "changes before the call must not be replayed")
+					return true
+				case <-time.After(100 * time.Millisecond):
+					return false
+				}
+			}, 30*time.Second, time.Millisecond)
+			for drained := false; !drained; {
+				select {
+				case <-changes:
+				case <-time.After(time.Second):
+					drained = true
+				}
+			}
+
+			tuple := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectSet{Namespace: "n", Object: "other", Relation: "r"}}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+			c := <-changes
+			assert.Equal(t, reg.Persister().NetworkID(ctx), c.NetworkID)
+			assert.Equal(t, tuple, c.RelationTuple)
+			assert.False(t, c.Deleted)
+
+			if streamsDeletes {
+				require.NoError(t, reg.RelationTupleManager().DeleteRelationTuples(ctx, tuple))
+				c := <-changes
+				assert.Equal(t, tuple, c.RelationTuple)
+				assert.True(t, c.Deleted)
+			}
+			cancel()
+			assert.NoError(t, <-done)
+		})
+	}
+}
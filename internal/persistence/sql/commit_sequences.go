@@ -0,0 +1,129 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+)
+
+// Commit times are taken from the hybrid logical clock when a write starts, so
+// a transaction can commit after another one with a later commit time. They
+// order the writes by causality, but can't tell what was committed at a
+// snapshot. Every transaction that changes the relation tuples of a network
+// therefore advances the commit sequence of the network, and holds the lock of
+// its row until it commits. The sequences of the transactions are in the order
+// of their commits, and a sequence that was read was committed with all
+// sequences before it.
+
+type commitSequence struct {
+	// An ID field is required to make pop happy. The network is the primary key.
+	ID        uuid.UUID `db:"nid"`
+	Seq       int64     `db:"seq"`
+	ChangedAt time.Time `db:"changed_at"`
+}
+
+func (commitSequence) TableName(_ context.Context) string {
+	return "keto_commit_sequences"
+}
+
+// errCommitSequenceCreated is returned if another transaction created the
+// commit sequence of the network concurrently. The transaction is retried.
+var errCommitSequenceCreated = errors.New("the commit sequence was created concurrently")
+
+// nextCommit returns the commit time and sequence for the next write. It has
+// to be called in the transaction of the write. The first call in a
+// transaction advances the commit sequence of the network, which locks it
+// until the transaction ends, and lets the clock observe the commit time of
+// the latest change, so that commit times are ordered by causality even if the
+// clocks of the servers writing to the database are skewed. Later writes in
+// the transaction only advance the clock.
+//
+// In multi-primary mode the clock observes the latest commit time of the
+// tuples instead. The tombstones decide by the clocks of the regions whether a
+// write that is replicated after a deletion supersedes it.
+func (p *Persister) nextCommit(ctx context.Context) (time.Time, int64, error) {
+	state := txStateFromContext(ctx)
+	if state == nil {
+		return time.Time{}, 0, errors.New("commits have to be taken in a transaction")
+	}
+	if state.seq == 0 {
+		seq, err := p.advanceCommitSequence(ctx)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		latest := seq.ChangedAt
+		if p.d.Config(ctx).MultiPrimary() {
+			if latest, err = p.latestCommitTime(ctx); err != nil {
+				return time.Time{}, 0, err
+			}
+		}
+		if !latest.IsZero() {
+			p.clock.Observe(latest)
+		}
+		state.seq = seq.Seq
+	}
+	state.changedAt = p.clock.Now()
+	return state.changedAt, state.seq, nil
+}
+
+// advanceCommitSequence increments the commit sequence of the network, or
+// creates it, and returns it.
+func (p *Persister) advanceCommitSequence(ctx context.Context) (*commitSequence, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.advanceCommitSequence")
+	defer span.End()
+
+	c := p.Connection(ctx)
+	updated, err := c.RawQuery("UPDATE keto_commit_sequences SET seq = seq + 1 WHERE nid = ?", p.NetworkID(ctx)).ExecWithCount()
+	if err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	if updated == 0 {
+		// The tuples of the network might have been written before the
+		// commit sequences were introduced.
+		latest, err := p.latestCommitTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if latest.IsZero() {
+			latest = p.clock.Now()
+		}
+		seq := &commitSequence{ID: p.NetworkID(ctx), Seq: 1, ChangedAt: latest}
+		if err := sqlcon.HandleError(c.Create(seq)); errors.Is(err, sqlcon.ErrUniqueViolation) {
+			return nil, errors.WithStack(errCommitSequenceCreated)
+		} else if err != nil {
+			return nil, err
+		}
+		return seq, nil
+	}
+
+	var seq commitSequence
+	if err := c.Where("nid = ?", p.NetworkID(ctx)).First(&seq); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	return &seq, nil
+}
+
+// recordChangedAt stores the latest commit time of the transaction with its
+// commit sequence, right before it commits.
+func (p *Persister) recordChangedAt(ctx context.Context, state *txState) error {
+	return sqlcon.HandleError(
+		p.Connection(ctx).RawQuery("UPDATE keto_commit_sequences SET changed_at = ? WHERE nid = ?", state.changedAt, p.NetworkID(ctx)).Exec(),
+	)
+}
+
+// latestCommit returns the commit sequence of the latest change of the
+// network, and its commit time. Both are zero if there was none.
+func (p *Persister) latestCommit(ctx context.Context) (time.Time, int64, error) {
+	var seq commitSequence
+	err := p.Connection(ctx).Where("nid = ?", p.NetworkID(ctx)).First(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, 0, nil
+	} else if err != nil {
+		return time.Time{}, 0, sqlcon.HandleError(err)
+	}
+	return seq.ChangedAt, seq.Seq, nil
+}
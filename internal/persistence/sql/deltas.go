@@ -0,0 +1,273 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+var _ persistence.DeltaReader = &Persister{}
+
+// Deleted relation tuples are copied into a deletion log, so that deltas can
+// report them and snapshots can restore them. Entries older than the delta
+// retention are pruned on the next deletion of the network, and the horizon
+// records up to which commit sequence, so that deltas and snapshots before
+// older tokens are refused instead of silently missing deletions.
+
+type (
+	TupleDeletion struct {
+		// An ID field is required to make pop happy. The actual ID is a composite primary key.
+		ID                    uuid.UUID      `db:"shard_id"`
+		NetworkID             uuid.UUID      `db:"nid"`
+		NamespaceID           int32          `db:"namespace_id"`
+		Object                string         `db:"object"`
+		Relation              string         `db:"relation"`
+		SubjectID             sql.NullString `db:"subject_id"`
+		SubjectSetNamespaceID sql.NullInt32  `db:"subject_set_namespace_id"`
+		SubjectSetObject      sql.NullString `db:"subject_set_object"`
+		SubjectSetRelation    sql.NullString `db:"subject_set_relation"`
+		// CommitTime is the commit time of the deleted tuple. It is unknown
+		// for deletions that were logged before it was recorded.
+		CommitTime sql.NullTime `db:"commit_time"`
+		// Seq is the commit sequence of the deleted tuple, and DeletedSeq the
+		// one of the deletion. Both are 0 for deletions that were logged
+		// before the commit sequences were introduced.
+		Seq        int64     `db:"seq"`
+		DeletedAt  time.Time `db:"deleted_at"`
+		DeletedSeq int64     `db:"deleted_seq"`
+	}
+	tupleDeletions  []*TupleDeletion
+	deletionHorizon struct {
+		// An ID field is required to make pop happy. The network is the primary key.
+		ID           uuid.UUID `db:"nid"`
+		PrunedBefore time.Time `db:"pruned_before"`
+		PrunedSeq    int64     `db:"pruned_seq"`
+	}
+)
+
+func (TupleDeletion) TableName(_ context.Context) string {
+	return "keto_relation_tuple_deletions"
+}
+
+func (tupleDeletions) TableName(_ context.Context) string {
+	return "keto_relation_tuple_deletions"
+}
+
+func (deletionHorizon) TableName(_ context.Context) string {
+	return "keto_relation_tuple_deletion_horizons"
+}
+
+// toRow returns the deleted tuple as it was stored before its deletion.
+func (d *TupleDeletion) toRow() *RelationTuple {
+	return &RelationTuple{
+		ID:                    d.ID,
+		NetworkID:             d.NetworkID,
+		NamespaceID:           d.NamespaceID,
+		Object:                d.Object,
+		Relation:              d.Relation,
+		SubjectID:             d.SubjectID,
+		SubjectSetNamespaceID: d.SubjectSetNamespaceID,
+		SubjectSetObject:      d.SubjectSetObject,
+		SubjectSetRelation:    d.SubjectSetRelation,
+		CommitTime:            d.CommitTime.Time,
+		Seq:                   d.Seq,
+	}
+}
+
+func (d *TupleDeletion) toInternal(ctx context.Context, names *namespaceNames) (*relationtuple.InternalRelationTuple, error) {
+	return d.toRow().toInternal(ctx, names)
+}
+
+// deleteRelationTuples deletes all tuples matching the query and records the
+// deletions. In multi-primary mode, the tuples leave tombstones.
+func (p *Persister) deleteRelationTuples(ctx context.Context, q *pop.Query) error {
+	if p.d.Config(ctx).MultiPrimary() {
+		return p.tombstoneRelationTuples(ctx, q)
+	}
+
+	var res relationTuples
+	if err := q.All(&res); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	deletedAt, deletedSeq, err := p.nextCommit(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.recordDeletions(ctx, res, deletedAt, deletedSeq); err != nil {
+		return err
+	}
+	return sqlcon.HandleError(q.Delete(&RelationTuple{}))
+}
+
+// deletionBatchSize is the number of rows copied into the deletion log by one
+// statement.
+const deletionBatchSize = 500
+
+// deletionColumns are the columns copied from the relation tuples into the
+// deletion log.
+const deletionColumns = "shard_id, nid, namespace_id, object, relation, subject_id, subject_set_namespace_id, subject_set_object, subject_set_relation"
+
+// recordDeletions adds the rows to the deletion log and prunes the entries
+// that are older than the retention. The rows must not be deleted yet.
+func (p *Persister) recordDeletions(ctx context.Context, rows relationTuples, deletedAt time.Time, deletedSeq int64) error {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.recordDeletions")
+	defer span.End()
+
+	if len(rows) == 0 {
+		return nil
+	}
+	nid := p.NetworkID(ctx)
+	for start := 0; start < len(rows); start += deletionBatchSize {
+		end := start + deletionBatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		ids := make([]uuid.UUID, 0, end-start)
+		for _, r := range rows[start:end] {
+			ids = append(ids, r.ID)
+		}
+
+		// In multi-primary mode, the same tuple can be deleted again after
+		// it was written again.
+		if err := p.Connection(ctx).RawQuery("DELETE FROM keto_relation_tuple_deletions WHERE nid = ? AND shard_id IN (?)", nid, ids).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		// The rows are copied by the database, as they are still stored.
+		if err := p.Connection(ctx).RawQuery(
+			"INSERT INTO keto_relation_tuple_deletions ("+deletionColumns+", commit_time, seq, deleted_at, deleted_seq) "+
+				"SELECT "+deletionColumns+", commit_time, seq, ?, ? FROM keto_relation_tuples WHERE nid = ? AND shard_id IN (?)",
+			deletedAt, deletedSeq, nid, ids,
+		).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+	}
+
+	cutoff := deletedAt.Add(-p.d.Config(ctx).SnapshotDeltaRetention())
+	c := p.Connection(ctx)
+	// The horizon is the latest deletion that is pruned.
+	var prunedSeq sql.NullInt64
+	if err := c.RawQuery("SELECT MAX(deleted_seq) FROM keto_relation_tuple_deletions WHERE nid = ? AND deleted_at < ?", p.NetworkID(ctx), cutoff).First(&prunedSeq); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if !prunedSeq.Valid {
+		return nil
+	}
+	if err := c.RawQuery("DELETE FROM keto_relation_tuple_deletions WHERE nid = ? AND deleted_at < ?", p.NetworkID(ctx), cutoff).Exec(); err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	exists, err := c.Where("nid = ?", p.NetworkID(ctx)).Exists(&deletionHorizon{})
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	if !exists {
+		return sqlcon.HandleError(c.Create(&deletionHorizon{ID: p.NetworkID(ctx), PrunedBefore: cutoff, PrunedSeq: prunedSeq.Int64}))
+	}
+	return sqlcon.HandleError(
+		c.RawQuery(
+			"UPDATE keto_relation_tuple_deletion_horizons SET pruned_before = ?, pruned_seq = ? WHERE nid = ? AND pruned_seq < ?",
+			cutoff, prunedSeq.Int64, p.NetworkID(ctx), prunedSeq.Int64,
+		).Exec(),
+	)
+}
+
+// deletionsPrunedSeq returns the commit sequence up to which the deletion log
+// of the network is incomplete, or 0 if it is complete since the commit
+// sequences were introduced.
+func (p *Persister) deletionsPrunedSeq(ctx context.Context) (int64, error) {
+	var horizon deletionHorizon
+	err := p.Connection(ctx).Where("nid = ?", p.NetworkID(ctx)).First(&horizon)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, sqlcon.HandleError(err)
+	}
+	return horizon.PrunedSeq, nil
+}
+
+func (p *Persister) ChangesSince(ctx context.Context, token string) (*persistence.Delta, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.ChangesSince")
+	defer span.End()
+
+	since, err := parseSnapshotToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &persistence.Delta{
+		Deleted: []*relationtuple.InternalRelationTuple{},
+		Written: []*relationtuple.InternalRelationTuple{},
+	}
+	err = p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+		prunedSeq, err := p.deletionsPrunedSeq(ctx)
+		if err != nil {
+			return err
+		}
+		if since < prunedSeq {
+			return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SnapshotTokenExpired).
+				WithReasonf("The deletions since the snapshot token %q are no longer retained, download a full snapshot instead.", token).
+				WithDetail(errorcode.DetailLimit, "snapshots.delta_retention"))
+		}
+
+		changedAt, until, err := p.latestCommit(ctx)
+		if err != nil {
+			return err
+		}
+		delta.Token = formatSnapshotToken(changedAt, until)
+		if until <= since {
+			delta.Token = token
+			return nil
+		}
+
+		var deleted tupleDeletions
+		if err := p.QueryWithNetwork(ctx).
+			Where("deleted_seq > ?", since).
+			Where("deleted_seq <= ?", until).
+			Order("deleted_seq, shard_id").
+			All(&deleted); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		names := p.namespaceNames()
+		for _, d := range deleted {
+			if rt, err := d.toInternal(ctx, names); err == nil {
+				// Tuples of deleted namespaces can't be resolved.
+				delta.Deleted = append(delta.Deleted, rt)
+			}
+		}
+
+		// The tuples are read at the snapshot, so that tuples deleted by
+		// transactions after it are still reported as written.
+		ctx = context.WithValue(ctx, snapshotSeqKey{}, until)
+		var page string
+		for {
+			rts, next, err := p.listRelationTuples(ctx, func(q *pop.Query) error {
+				q.Where("seq > ?", since)
+				return nil
+			}, x.WithToken(page), x.WithSize(p.d.Config(ctx).MaxPageSize()))
+			if err != nil {
+				return err
+			}
+			delta.Written = append(delta.Written, rts...)
+			if next == "" {
+				return nil
+			}
+			page = next
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delta, nil
+}
@@ -0,0 +1,88 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestChangesSince(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			tuple := func(object string) *relationtuple.InternalRelationTuple {
+				return &relationtuple.InternalRelationTuple{Namespace: "n", Object: object, Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+			}
+			objects := func(rts []*relationtuple.InternalRelationTuple) []string {
+				objects := make([]string, len(rts))
+				for i, r := range rts {
+					objects[i] = r.Object
+				}
+				return objects
+			}
+			changesSince := func(t *testing.T, token string) *persistence.Delta {
+				delta, err := p.ChangesSince(ctx, token)
+				require.NoError(t, err)
+				return delta
+			}
+
+			empty, err := p.SnapshotToken(ctx)
+			require.NoError(t, err)
+			require.NoError(t, p.WriteRelationTuples(ctx, tuple("a"), tuple("b")))
+
+			first := changesSince(t, empty)
+			assert.Equal(t, []string{}, objects(first.Deleted))
+			assert.ElementsMatch(t, []string{"a", "b"}, objects(first.Written))
+
+			require.NoError(t, p.DeleteRelationTuples(ctx, tuple("a")))
+			require.NoError(t, p.WriteRelationTuples(ctx, tuple("c")))
+			second := changesSince(t, first.Token)
+			assert.Equal(t, []string{"a"}, objects(second.Deleted))
+			assert.Equal(t, []string{"c"}, objects(second.Written))
+
+			t.Run("case=deletions advance the token", func(t *testing.T) {
+				require.NoError(t, p.DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "n", Object: "b"}))
+				third := changesSince(t, second.Token)
+				assert.NotEqual(t, second.Token, third.Token)
+				assert.Equal(t, []string{"b"}, objects(third.Deleted))
+				assert.Empty(t, third.Written)
+
+				unchanged := changesSince(t, third.Token)
+				assert.Equal(t, third.Token, unchanged.Token)
+				assert.Empty(t, unchanged.Deleted)
+				assert.Empty(t, unchanged.Written)
+			})
+
+			t.Run("case=refuses tokens before pruned deletions", func(t *testing.T) {
+				require.NoError(t, reg.Config(ctx).Set(config.KeySnapshotDeltaRetention, "1ns"))
+				require.NoError(t, p.DeleteRelationTuples(ctx, tuple("c")))
+
+				_, err := p.ChangesSince(ctx, first.Token)
+				assert.True(t, errorcode.Is(err, errorcode.SnapshotTokenExpired), "%+v", err)
+			})
+
+			_, err = p.ChangesSince(ctx, "not a token")
+			assert.True(t, errorcode.Is(err, errorcode.SnapshotTokenInvalid), "%+v", err)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// checkMaxDepth rejects a subject set written to a relation with a max depth
+// if subject sets are already nested below it so deeply that checks could not
+// reach all of them. Only the subject sets below the relation tuple are
+// considered, as relation tuples written later can only be checked when they
+// are written themselves.
+func (p *Persister) checkMaxDepth(ctx context.Context, rt *relationtuple.InternalRelationTuple) error {
+	set, ok := rt.Subject.(*relationtuple.SubjectSet)
+	if !ok {
+		return nil
+	}
+	_, opts, err := p.namespaceOptions(ctx, rt.Namespace)
+	if err != nil {
+		return err
+	}
+	max, ok := opts.MaxDepth[rt.Relation]
+	if !ok {
+		return nil
+	}
+
+	// The relation tuples of the object are read at depth max, the ones of
+	// the subject set at max-1, and so on.
+	root := &relationtuple.SubjectSet{Namespace: rt.Namespace, Object: rt.Object, Relation: rt.Relation}
+	exceeded, err := p.nestedDeeper(ctx, set, max-1, map[string]bool{root.String(): true})
+	if err != nil {
+		return err
+	}
+	if exceeded {
+		return errors.WithStack(herodot.ErrBadRequest.
+			WithID(errorcode.MaxDepthExceeded).
+			WithReasonf("The relation %q of namespace %q has a max depth of %d, but the subject set %s is nested deeper.", rt.Relation, rt.Namespace, max, set).
+			WithDetail(errorcode.DetailLimit, "max_depth"))
+	}
+	return nil
+}
+
+// nestedDeeper returns true if the subject set can't be expanded with the rest
+// depth, or one of the subject sets nested below it. Like checks, it does not
+// follow cycles back to the subject sets on the path.
+func (p *Persister) nestedDeeper(ctx context.Context, set *relationtuple.SubjectSet, restDepth int, path map[string]bool) (bool, error) {
+	if path[set.String()] {
+		return false, nil
+	}
+	if restDepth <= 0 {
+		return true, nil
+	}
+	path[set.String()] = true
+	defer delete(path, set.String())
+
+	query := &relationtuple.RelationQuery{Namespace: set.Namespace, Object: set.Object, Relation: set.Relation}
+	var page string
+	for {
+		rels, next, err := p.GetRelationTuples(ctx, query, x.WithToken(page))
+		if err != nil {
+			return false, err
+		}
+		for _, r := range rels {
+			nested, ok := r.Subject.(*relationtuple.SubjectSet)
+			if !ok {
+				continue
+			}
+			exceeded, err := p.nestedDeeper(ctx, nested, restDepth-1, path)
+			if exceeded || err != nil {
+				return exceeded, err
+			}
+		}
+		if next == "" {
+			return false, nil
+		}
+		page = next
+	}
+}
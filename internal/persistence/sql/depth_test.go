@@ -0,0 +1,59 @@
+package sql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestRelationMaxDepthOnWrite(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+		{ID: 0, Name: "groups", Config: json.RawMessage(`{"max_depth": {"member": 2, "owner": 1}}`)},
+	}))
+	m := reg.RelationTupleManager()
+	member := func(object string, sub relationtuple.Subject) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{Namespace: "groups", Object: object, Relation: "member", Subject: sub}
+	}
+	group := func(name string) *relationtuple.SubjectSet {
+		return &relationtuple.SubjectSet{Namespace: "groups", Object: name, Relation: "member"}
+	}
+
+	require.NoError(t, m.WriteRelationTuples(ctx,
+		member("c", &relationtuple.SubjectID{ID: "alice"}),
+		member("b", group("c")),
+	))
+
+	t.Run("case=rejects nesting beyond the max depth", func(t *testing.T) {
+		err := m.WriteRelationTuples(ctx, member("a", group("b")))
+		assert.True(t, errorcode.Is(err, errorcode.MaxDepthExceeded), "%+v", err)
+
+		res, _, err := m.GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "groups", Object: "a"})
+		require.NoError(t, err)
+		assert.Empty(t, res)
+	})
+
+	t.Run("case=allows nesting within the max depth", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, member("a", group("c"))))
+	})
+
+	t.Run("case=max depth of one allows no subject sets", func(t *testing.T) {
+		err := m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "a", Relation: "owner", Subject: group("empty")})
+		assert.True(t, errorcode.Is(err, errorcode.MaxDepthExceeded), "%+v", err)
+		require.NoError(t, m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{Namespace: "groups", Object: "a", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice"}}))
+	})
+
+	t.Run("case=cycles are not nested deeper", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, member("c", group("b"))))
+	})
+}
@@ -0,0 +1,161 @@
+package sql
+
+import (
+	"context"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ory/x/cmdx"
+	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
+)
+
+type (
+	// DownMigration is a down migration that migrating down would run.
+	// DataLoss lists the reasons why it deletes data, it is empty for
+	// migrations that only change the schema, e.g. drop indices.
+	DownMigration struct {
+		Version  string   `json:"version"`
+		Name     string   `json:"name"`
+		DataLoss []string `json:"data_loss"`
+	}
+	DownMigrations []DownMigration
+)
+
+var (
+	_ cmdx.Table = DownMigrations(nil)
+
+	sqlCommentRegex   = regexp.MustCompile(`--[^\n]*`)
+	dropTableRegex    = regexp.MustCompile(`(?i)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\S+)`)
+	deleteRegex       = regexp.MustCompile(`(?i)^(?:DELETE\s+FROM|TRUNCATE(?:\s+TABLE)?)\s+(\S+)`)
+	alterTableRegex   = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(\S+)\s+(.*)$`)
+	renameToRegex     = regexp.MustCompile(`(?i)^RENAME\s+TO\s+(\S+)`)
+	dropColumnRegex   = regexp.MustCompile(`(?i)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(\S+)`)
+	changeTypeRegex   = regexp.MustCompile(`(?i)^(?:ALTER\s+(?:COLUMN\s+)?(\S+)\s+(?:SET\s+DATA\s+)?TYPE|(?:MODIFY|CHANGE)\s+(?:COLUMN\s+)?(\S+))\b`)
+	notColumnKeywords = map[string]bool{"INDEX": true, "CONSTRAINT": true, "FOREIGN": true, "PRIMARY": true, "KEY": true, "CHECK": true, "DEFAULT": true, "NOT": true}
+)
+
+// AnalyzeDown returns the down migrations that migrating down the given
+// number of steps would run, in the order they would run. Zero steps migrate
+// down all applied migrations.
+//
+// The SQL of each migration is checked for statements that delete data:
+// dropped tables and columns, deleted rows, and column type changes which
+// might truncate values. Tables that are rebuilt, i.e. dropped and replaced by
+// a copy, are not reported. Go migrations are not analyzed.
+func AnalyzeDown(ctx context.Context, mb *popx.MigrationBox, steps int) (DownMigrations, error) {
+	status, err := mb.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []string
+	for _, s := range status {
+		if s.State == popx.Applied {
+			applied = append(applied, s.Version)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(applied)))
+	if steps > 0 && steps < len(applied) {
+		applied = applied[:steps]
+	}
+
+	downs := make(map[string]popx.Migration)
+	for _, m := range mb.Migrations["down"].SortAndFilter(mb.Connection.Dialect.Name()) {
+		downs[m.Version] = m
+	}
+
+	plan := make(DownMigrations, 0, len(applied))
+	for _, v := range applied {
+		m, ok := downs[v]
+		if !ok {
+			continue
+		}
+		dm := DownMigration{Version: m.Version, Name: m.Name}
+		if m.Type == "sql" {
+			content, err := fs.ReadFile(mb.Dir, m.Path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			dm.DataLoss = analyzeDownSQL(string(content))
+		}
+		plan = append(plan, dm)
+	}
+	return plan, nil
+}
+
+func analyzeDownSQL(content string) []string {
+	var (
+		reasons []string
+		dropped []string
+		renamed = make(map[string]bool)
+	)
+	for _, stmt := range strings.Split(sqlCommentRegex.ReplaceAllString(content, ""), ";") {
+		stmt = strings.Join(strings.Fields(stmt), " ")
+
+		if m := dropTableRegex.FindStringSubmatch(stmt); m != nil {
+			dropped = append(dropped, unquoteIdentifier(m[1]))
+		} else if m := deleteRegex.FindStringSubmatch(stmt); m != nil {
+			reasons = append(reasons, "deletes rows of the table "+unquoteIdentifier(m[1]))
+		} else if m := alterTableRegex.FindStringSubmatch(stmt); m != nil {
+			table := unquoteIdentifier(m[1])
+			for _, clause := range strings.Split(m[2], ",") {
+				clause = strings.TrimSpace(clause)
+				if r := renameToRegex.FindStringSubmatch(clause); r != nil {
+					renamed[unquoteIdentifier(r[1])] = true
+				} else if c := dropColumnRegex.FindStringSubmatch(clause); c != nil && !notColumnKeywords[strings.ToUpper(c[1])] {
+					reasons = append(reasons, "drops the column "+unquoteIdentifier(c[1])+" of the table "+table)
+				} else if c := changeTypeRegex.FindStringSubmatch(clause); c != nil {
+					reasons = append(reasons, "changes the type of the column "+unquoteIdentifier(c[1]+c[2])+" of the table "+table+", values might be truncated")
+				}
+			}
+		}
+	}
+
+	for _, table := range dropped {
+		// A table that is replaced by a renamed copy is rebuilt.
+		if !renamed[table] {
+			reasons = append(reasons, "drops the table "+table)
+		}
+	}
+	return reasons
+}
+
+func unquoteIdentifier(id string) string {
+	return strings.Trim(id, "`\"")
+}
+
+// Destructive returns whether any of the migrations deletes data.
+func (m DownMigrations) Destructive() bool {
+	for _, dm := range m {
+		if len(dm.DataLoss) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m DownMigrations) Header() []string {
+	return []string{"Version", "Name", "Data Loss"}
+}
+
+func (m DownMigrations) Table() [][]string {
+	t := make([][]string, len(m))
+	for i, dm := range m {
+		loss := "none"
+		if len(dm.DataLoss) > 0 {
+			loss = strings.Join(dm.DataLoss, "; ")
+		}
+		t[i] = []string{dm.Version, dm.Name, loss}
+	}
+	return t
+}
+
+func (m DownMigrations) Interface() interface{} {
+	return m
+}
+
+func (m DownMigrations) Len() int {
+	return len(m)
+}
@@ -0,0 +1,70 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestAnalyzeDown(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := pop.NewConnection(&pop.ConnectionDetails{URL: dbx.GetSqlite(t, dbx.SQLiteMemory).Conn})
+	require.NoError(t, err)
+	require.NoError(t, c.Open())
+	t.Cleanup(func() { _ = c.Close() })
+
+	mb, err := sql.NewMigrationBox(c, logrusx.New("", ""), nil, fstest.MapFS{
+		"30000000000000000001_drop_column.sqlite3.up.sql":   {Data: []byte("CREATE TABLE extra (id INTEGER, name TEXT);")},
+		"30000000000000000001_drop_column.sqlite3.down.sql": {Data: []byte("-- DROP TABLE is mentioned in a comment\nALTER TABLE extra DROP COLUMN name;\nDROP TABLE \"extra\";")},
+		"30000000000000000002_index.sqlite3.up.sql":         {Data: []byte("CREATE INDEX extra_idx ON extra (id); DELETE FROM extra;")},
+		"30000000000000000002_index.sqlite3.down.sql":       {Data: []byte("DROP INDEX extra_idx;")},
+	})
+	require.NoError(t, err)
+
+	plan, err := sql.AnalyzeDown(ctx, mb, 0)
+	require.NoError(t, err)
+	assert.Empty(t, plan, "nothing is applied yet")
+
+	require.NoError(t, mb.Up(ctx))
+
+	t.Run("case=schema changes only", func(t *testing.T) {
+		plan, err := sql.AnalyzeDown(ctx, mb, 1)
+		require.NoError(t, err)
+		assert.Equal(t, sql.DownMigrations{{Version: "30000000000000000002", Name: "index"}}, plan)
+		assert.False(t, plan.Destructive())
+	})
+
+	t.Run("case=drops tables and columns", func(t *testing.T) {
+		plan, err := sql.AnalyzeDown(ctx, mb, 2)
+		require.NoError(t, err)
+		require.Len(t, plan, 2)
+		assert.Equal(t, []string{"drops the column name of the table extra", "drops the table extra"}, plan[1].DataLoss)
+		assert.True(t, plan.Destructive())
+	})
+
+	t.Run("case=all migrations", func(t *testing.T) {
+		plan, err := sql.AnalyzeDown(ctx, mb, 0)
+		require.NoError(t, err)
+
+		s, err := mb.Status(ctx)
+		require.NoError(t, err)
+		assert.Len(t, plan, len(s))
+
+		loss := make(map[string][]string)
+		for _, dm := range plan {
+			loss[dm.Name] = dm.DataLoss
+		}
+		assert.Equal(t, []string{"drops the table keto_relation_tuple_tombstones"}, loss["tombstones"])
+		// The table is rebuilt without the foreign key.
+		assert.Empty(t, loss["nid_fk"])
+	})
+}
@@ -0,0 +1,96 @@
+package sql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+var _ persistence.SubjectEraser = &Persister{}
+
+func (p *Persister) EraseSubject(ctx context.Context, subjectID string, dryRun bool) (*persistence.ErasureReport, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.EraseSubject")
+	defer span.End()
+
+	queries := []func(ctx context.Context) *pop.Query{
+		func(ctx context.Context) *pop.Query {
+			return p.QueryWithNetwork(ctx).
+				Where("subject_id = ?", subjectID).
+				Where("subject_set_namespace_id IS NULL").
+				Where("subject_set_object IS NULL").
+				Where("subject_set_relation IS NULL")
+		},
+	}
+	if i := strings.Index(subjectID, ":"); i > 0 {
+		n, err := p.GetNamespaceByName(ctx, subjectID[:i])
+		if err == nil {
+			queries = append(queries, func(ctx context.Context) *pop.Query {
+				// Any relation of the object references the subject.
+				return p.QueryWithNetwork(ctx).
+					Where("subject_set_namespace_id = ?", n.ID).
+					Where("subject_set_object = ?", subjectID[i+1:]).
+					Where("subject_id IS NULL")
+			})
+		} else if !errorcode.Is(err, errorcode.NamespaceUnknown) {
+			return nil, err
+		}
+	}
+
+	report := &persistence.ErasureReport{
+		Subject:        subjectID,
+		DryRun:         dryRun,
+		Tombstoned:     !dryRun && p.d.Config(ctx).MultiPrimary(),
+		RelationTuples: []*relationtuple.InternalRelationTuple{},
+	}
+	names := p.namespaceNames()
+	err := p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+		for _, q := range queries {
+			// Tombstoned rows are included, they still hold the subject.
+			var res relationTuples
+			if err := q(ctx).All(&res); err != nil {
+				return sqlcon.HandleError(err)
+			}
+			for _, r := range res {
+				if rt, err := r.toInternal(ctx, names); err == nil {
+					// Tuples of deleted namespaces are erased, but can't be reported.
+					report.RelationTuples = append(report.RelationTuples, rt)
+				}
+			}
+
+			n, err := q(ctx).Count(&TupleDeletion{})
+			if err != nil {
+				return sqlcon.HandleError(err)
+			}
+			report.PurgedDeletions += n
+
+			if dryRun {
+				continue
+			}
+			if err := p.deleteRelationTuples(ctx, q(ctx)); err != nil {
+				return err
+			}
+			// The deletion log still holds the subject, both of earlier
+			// deletions and of the tuples just erased, and would restore it
+			// at earlier snapshots.
+			if err := q(ctx).Delete(&TupleDeletion{}); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		report.ErasedAt = time.Now().UTC()
+	}
+	return report, nil
+}
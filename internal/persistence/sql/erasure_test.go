@@ -0,0 +1,141 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestEraseSubject(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "user"}, {ID: 1, Name: "doc"}}))
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+
+			setup := func(t *testing.T, multiPrimary bool) (*sql.Persister, []*relationtuple.InternalRelationTuple) {
+				mode := config.ReplicationModeSinglePrimary
+				if multiPrimary {
+					mode = config.ReplicationModeMultiPrimary
+				}
+				require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, mode))
+				require.NoError(t, p.Connection(ctx).RawQuery("DELETE FROM keto_relation_tuples").Exec())
+				require.NoError(t, p.Connection(ctx).RawQuery("DELETE FROM keto_relation_tuple_tombstones").Exec())
+				require.NoError(t, p.Connection(ctx).RawQuery("DELETE FROM keto_relation_tuple_deletions").Exec())
+
+				erased := []*relationtuple.InternalRelationTuple{
+					{Namespace: "doc", Object: "d1", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "user:alice"}},
+					{Namespace: "doc", Object: "d2", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "user", Object: "alice", Relation: "friends"}},
+				}
+				require.NoError(t, p.WriteRelationTuples(ctx, append([]*relationtuple.InternalRelationTuple{
+					{Namespace: "doc", Object: "d1", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "user:bob"}},
+					{Namespace: "doc", Object: "d1", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "user", Object: "bob", Relation: "friends"}},
+					{Namespace: "user", Object: "alice", Relation: "friends", Subject: &relationtuple.SubjectID{ID: "user:bob"}},
+				}, erased...)...))
+				return p, erased
+			}
+			count := func(t *testing.T, p *sql.Persister) int {
+				res, _, err := p.GetRelationTuples(ctx, &relationtuple.RelationQuery{})
+				require.NoError(t, err)
+				return len(res)
+			}
+
+			t.Run("case=dry run only reports", func(t *testing.T) {
+				p, erased := setup(t, false)
+
+				report, err := p.EraseSubject(ctx, "user:alice", true)
+				require.NoError(t, err)
+				assert.True(t, report.DryRun)
+				assert.True(t, report.ErasedAt.IsZero())
+				assert.ElementsMatch(t, erased, report.RelationTuples)
+				assert.Equal(t, 5, count(t, p))
+			})
+
+			for _, multiPrimary := range []bool{false, true} {
+				multiPrimary := multiPrimary
+				t.Run(fmt.Sprintf("case=erases direct subjects and subject sets/multi_primary=%v", multiPrimary), func(t *testing.T) {
+					p, erased := setup(t, multiPrimary)
+
+					report, err := p.EraseSubject(ctx, "user:alice", false)
+					require.NoError(t, err)
+					assert.False(t, report.DryRun)
+					assert.False(t, report.ErasedAt.IsZero())
+					assert.Equal(t, multiPrimary, report.Tombstoned)
+					assert.ElementsMatch(t, erased, report.RelationTuples)
+					assert.Equal(t, 3, count(t, p))
+
+					var rows []sql.RelationTuple
+					require.NoError(t, p.QueryWithNetwork(ctx).All(&rows))
+					assert.Len(t, rows, 3, "the rows are deleted, not only hidden")
+
+					var tombstones []sql.Tombstone
+					require.NoError(t, p.QueryWithNetwork(ctx).All(&tombstones))
+					if multiPrimary {
+						assert.Len(t, tombstones, 2)
+					} else {
+						assert.Len(t, tombstones, 0)
+					}
+				})
+			}
+
+			t.Run("case=purges the deletion log", func(t *testing.T) {
+				p, _ := setup(t, false)
+				earlier := &relationtuple.InternalRelationTuple{Namespace: "doc", Object: "d3", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "user:alice"}}
+				require.NoError(t, p.WriteRelationTuples(ctx, earlier))
+				snapshot, err := p.SnapshotToken(ctx)
+				require.NoError(t, err)
+				require.NoError(t, p.DeleteRelationTuples(ctx, earlier))
+
+				report, err := p.EraseSubject(ctx, "user:alice", true)
+				require.NoError(t, err)
+				assert.Equal(t, 1, report.PurgedDeletions)
+
+				report, err = p.EraseSubject(ctx, "user:alice", false)
+				require.NoError(t, err)
+				assert.Equal(t, 1, report.PurgedDeletions)
+				assert.Contains(t, report.String(), "purging 1 entries of the deletion log")
+
+				var deletions []sql.TupleDeletion
+				require.NoError(t, p.QueryWithNetwork(ctx).All(&deletions))
+				for _, d := range deletions {
+					assert.NotEqual(t, "user:alice", d.SubjectID.String)
+					assert.NotEqual(t, "alice", d.SubjectSetObject.String)
+				}
+
+				// The snapshot before the erasure doesn't restore the subject.
+				atSnapshot, err := p.AtSnapshot(ctx, snapshot)
+				require.NoError(t, err)
+				res, _, err := p.GetRelationTuples(atSnapshot, &relationtuple.RelationQuery{})
+				require.NoError(t, err)
+				for _, rt := range res {
+					assert.NotContains(t, rt.Subject.String(), "alice", rt.String())
+				}
+			})
+
+			t.Run("case=subject without a namespace", func(t *testing.T) {
+				p, _ := setup(t, false)
+
+				report, err := p.EraseSubject(ctx, "unknown:alice", false)
+				require.NoError(t, err)
+				assert.Empty(t, report.RelationTuples)
+				assert.Equal(t, 5, count(t, p))
+			})
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package sql
+
+import (
+	"io/fs"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/fsx"
+	"github.com/ory/x/networkx"
+	"github.com/pkg/errors"
+)
+
+// MergeMigrations merges Keto's migrations with additional ones, e.g. for
+// tables an embedder maintains in the same database. The migrator orders all
+// migrations by version, so additional migrations can run before or after any
+// of Keto's migrations. To keep the migration status unambiguous, additional
+// migrations must not reuse a version of Keto's migrations.
+func MergeMigrations(extra ...fs.FS) (fs.FS, error) {
+	core := fsx.Merge(Migrations, networkx.Migrations)
+	if len(extra) == 0 {
+		return core, nil
+	}
+
+	coreVersions, err := migrationVersions(core)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range extra {
+		versions, err := migrationVersions(e)
+		if err != nil {
+			return nil, err
+		}
+		for v, name := range versions {
+			if coreName, ok := coreVersions[v]; ok {
+				return nil, errors.Errorf("additional migration %s uses the version %s of the migration %s", name, v, coreName)
+			}
+		}
+	}
+
+	return fsx.Merge(append([]fs.FS{core}, extra...)...), nil
+}
+
+func migrationVersions(fsys fs.FS) (map[string]string, error) {
+	versions := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		match, err := pop.ParseMigrationFilename(d.Name())
+		if err != nil || match == nil {
+			// The migrator ignores files it can not parse as well.
+			return nil
+		}
+		versions[match.Version] = match.Name
+		return nil
+	})
+	return versions, err
+}
@@ -0,0 +1,56 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/persistence/sql"
+)
+
+func TestMergeMigrations(t *testing.T) {
+	t.Run("case=applies additional migrations", func(t *testing.T) {
+		ctx := context.Background()
+		reg := driver.NewSqliteTestRegistry(t, false)
+
+		extra := fstest.MapFS{
+			"20220101000000000000_extra_table.up.sql":   {Data: []byte("CREATE TABLE extra_table (id INTEGER PRIMARY KEY);")},
+			"20220101000000000000_extra_table.down.sql": {Data: []byte("DROP TABLE extra_table;")},
+			"README.md": {Data: []byte("not a migration")},
+		}
+
+		c, err := reg.PopConnection(ctx)
+		require.NoError(t, err)
+		mb, err := sql.NewMigrationBox(c, reg.Logger(), reg.Tracer(ctx), extra)
+		require.NoError(t, err)
+
+		s, err := mb.Status(ctx)
+		require.NoError(t, err)
+		require.True(t, s.HasPending())
+
+		require.NoError(t, mb.Up(ctx))
+		require.NoError(t, c.RawQuery("INSERT INTO extra_table (id) VALUES (1)").Exec())
+
+		s, err = mb.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, s.HasPending())
+	})
+
+	t.Run("case=rejects colliding versions", func(t *testing.T) {
+		entries, err := sql.Migrations.ReadDir("migrations/sql")
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+
+		version := entries[0].Name()[:20]
+		_, err = sql.MergeMigrations(fstest.MapFS{
+			version + "_collision.up.sql":   {Data: []byte("")},
+			version + "_collision.down.sql": {Data: []byte("")},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), version)
+	})
+}
@@ -64,6 +64,14 @@ func TestPersister(t *testing.T) {
 				relationtuple.ManagerTest(t, p, addNamespace(r, nspaces), removeAllNamespaces(r, nspaces))
 			})
 
+			t.Run("relationtuple.ManagerTest/mode=multi_primary", func(t *testing.T) {
+				var nspaces []*namespace.Namespace
+				p, r, _ := setup(t, dsn)
+				require.NoError(t, r.Config(context.Background()).Set(config.KeyReplicationMode, config.ReplicationModeMultiPrimary))
+
+				relationtuple.ManagerTest(t, p, addNamespace(r, nspaces), removeAllNamespaces(r, nspaces))
+			})
+
 			t.Run("relationtuple.IsolationTest", func(t *testing.T) {
 				var nspaces []*namespace.Namespace
 				p0, r, _ := setup(t, dsn)
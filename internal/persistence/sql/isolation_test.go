@@ -0,0 +1,59 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestInMemoryIsolation(t *testing.T) {
+	t.Parallel()
+
+	registries := map[string]func(t *testing.T) *driver.RegistryDefault{
+		"test registry": func(t *testing.T) *driver.RegistryDefault {
+			return driver.NewSqliteTestRegistry(t, false)
+		},
+		"memory DSN": func(t *testing.T) *driver.RegistryDefault {
+			return driver.NewTestRegistry(t, &dbx.DsnT{Name: "memory", Conn: "memory", MigrateUp: true})
+		},
+	}
+
+	for name, newRegistry := range registries {
+		newRegistry := newRegistry
+		t.Run("registry="+name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 0; i < 5; i++ {
+				obj := fmt.Sprintf("object %d", i)
+				t.Run("instance="+obj, func(t *testing.T) {
+					t.Parallel()
+					ctx := context.Background()
+
+					reg := newRegistry(t)
+					require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+					require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+						Namespace: "n",
+						Object:    obj,
+						Relation:  "r",
+						Subject:   &relationtuple.SubjectID{ID: "s"},
+					}))
+
+					res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "n"}, x.WithSize(100))
+					require.NoError(t, err)
+					require.Len(t, res, 1)
+					assert.Equal(t, obj, res[0].Object)
+				})
+			}
+		})
+	}
+}
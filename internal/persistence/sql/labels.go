@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+)
+
+// Labels are stored in a side table, so that tuples without labels cost
+// nothing. They are deleted together with their tuple by the foreign key.
+
+type Label struct {
+	ShardID uuid.UUID `db:"shard_id"`
+	Key     string    `db:"label_key"`
+	Value   string    `db:"label_value"`
+}
+
+func (Label) TableName(_ context.Context) string {
+	return "keto_relation_tuple_labels"
+}
+
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setLabels replaces the labels of the tuple with the given ID.
+func (p *Persister) setLabels(ctx context.Context, id uuid.UUID, labels map[string]string) error {
+	c := p.Connection(ctx)
+	if err := c.RawQuery("DELETE FROM keto_relation_tuple_labels WHERE nid = ? AND shard_id = ?", p.NetworkID(ctx), id).Exec(); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	for _, k := range sortedLabelKeys(labels) {
+		if err := c.RawQuery(
+			"INSERT INTO keto_relation_tuple_labels (shard_id, nid, label_key, label_value) VALUES (?, ?, ?, ?)",
+			id, p.NetworkID(ctx), k, labels[k],
+		).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+	}
+	return nil
+}
+
+// getLabels returns the labels of the given tuples by their ID. Tuples
+// without labels are not contained in the result.
+func (p *Persister) getLabels(ctx context.Context, rs relationTuples) (map[uuid.UUID]map[string]string, error) {
+	if len(rs) == 0 {
+		return nil, nil
+	}
+	ids := make([]interface{}, len(rs))
+	for i, r := range rs {
+		ids[i] = r.ID
+	}
+
+	var res []Label
+	if err := p.QueryWithNetwork(ctx).Where("shard_id IN (?)", ids...).All(&res); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	labels := make(map[uuid.UUID]map[string]string)
+	for _, l := range res {
+		if labels[l.ShardID] == nil {
+			labels[l.ShardID] = make(map[string]string)
+		}
+		labels[l.ShardID][l.Key] = l.Value
+	}
+	return labels, nil
+}
+
+// whereLabels restricts the query to tuples having all the labels.
+func whereLabels(q *pop.Query, labels map[string]string) {
+	for _, k := range sortedLabelKeys(labels) {
+		q.Where(`EXISTS (SELECT 1 FROM keto_relation_tuple_labels l
+WHERE l.nid = keto_relation_tuples.nid AND l.shard_id = keto_relation_tuples.shard_id AND l.label_key = ? AND l.label_value = ?)`, k, labels[k])
+	}
+}
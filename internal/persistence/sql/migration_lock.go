@@ -0,0 +1,168 @@
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+)
+
+const (
+	// migrationLockID is the key of the PostgreSQL advisory lock.
+	migrationLockID int64 = 0x6f72796b65746f
+	// migrationLockName is the name of the MySQL user-level lock.
+	migrationLockName = "ory_keto_migrations"
+	// migrationLockTable holds the lock on CockroachDB, which does not
+	// support advisory locks.
+	migrationLockTable = "keto_migration_locks"
+
+	migrationLockTTL          = time.Minute
+	migrationLockPollInterval = time.Second
+)
+
+// ErrMigrationLocked is returned by TryWithMigrationLock if another process
+// holds the migration lock.
+var ErrMigrationLocked = errors.New("another process is currently migrating the database, wait until it is done and try again")
+
+// WithMigrationLock runs f while holding a database-wide lock, so that only
+// one process migrates the database at a time. Other processes calling
+// WithMigrationLock wait until the lock is released, or until ctx is done.
+//
+// SQLite databases are not shared between processes and are not locked.
+func WithMigrationLock(ctx context.Context, c *pop.Connection, f func(context.Context) error) error {
+	return withMigrationLock(ctx, c, true, f)
+}
+
+// TryWithMigrationLock is like WithMigrationLock, but fails with
+// ErrMigrationLocked instead of waiting if the lock is held.
+func TryWithMigrationLock(ctx context.Context, c *pop.Connection, f func(context.Context) error) error {
+	return withMigrationLock(ctx, c, false, f)
+}
+
+func withMigrationLock(ctx context.Context, c *pop.Connection, wait bool, f func(context.Context) error) error {
+	var unlock func() error
+	var err error
+	switch c.Dialect.Name() {
+	case "postgres", "mysql":
+		unlock, err = lockSession(ctx, c, wait)
+	case "cockroach":
+		unlock, err = lockTable(ctx, c, wait)
+	default:
+		return f(ctx)
+	}
+	if errors.Is(err, ErrMigrationLocked) {
+		return err
+	} else if err != nil {
+		return errors.WithMessage(err, "could not acquire the migration lock")
+	}
+
+	if err := f(ctx); err != nil {
+		_ = unlock()
+		return err
+	}
+	return errors.WithMessage(unlock(), "could not release the migration lock")
+}
+
+// lockSession takes an advisory lock that is bound to a database session. The
+// session is pinned by a transaction that stays open until unlock is called.
+func lockSession(ctx context.Context, c *pop.Connection, wait bool) (unlock func() error, err error) {
+	tx, err := c.Store.TransactionContext(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if c.Dialect.Name() == "postgres" {
+		// The transaction-level lock is released when the transaction ends.
+		if wait {
+			_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID)
+		} else {
+			var locked bool
+			if err = tx.GetContext(ctx, &locked, "SELECT pg_try_advisory_xact_lock($1)", migrationLockID); err == nil && !locked {
+				_ = tx.Rollback()
+				return nil, errors.WithStack(ErrMigrationLocked)
+			}
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, errors.WithStack(err)
+		}
+		return func() error { return errors.WithStack(tx.Rollback()) }, nil
+	}
+
+	// A negative timeout waits forever; the query is canceled with ctx.
+	timeout := 0
+	if wait {
+		timeout = -1
+	}
+	var locked *int
+	if err := tx.GetContext(ctx, &locked, "SELECT GET_LOCK(?, ?)", migrationLockName, timeout); err != nil {
+		_ = tx.Rollback()
+		return nil, errors.WithStack(err)
+	} else if locked != nil && *locked == 0 {
+		_ = tx.Rollback()
+		return nil, errors.WithStack(ErrMigrationLocked)
+	} else if locked == nil || *locked != 1 {
+		_ = tx.Rollback()
+		return nil, errors.Errorf("GET_LOCK returned %v", locked)
+	}
+	return func() error {
+		// Use a fresh context, the lock has to be released even if ctx is done.
+		_, err := tx.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+		if rbErr := tx.Rollback(); err == nil {
+			err = rbErr
+		}
+		return errors.WithStack(err)
+	}, nil
+}
+
+// lockTable takes the lock by inserting a row into the lock table. The row
+// expires unless it is refreshed, so that a crashed process does not hold the
+// lock forever.
+func lockTable(ctx context.Context, c *pop.Connection, wait bool) (unlock func() error, err error) {
+	if err := c.RawQuery("CREATE TABLE IF NOT EXISTS " + migrationLockTable + " (id INT PRIMARY KEY, expires_at TIMESTAMP NOT NULL)").Exec(); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	ttl := int(migrationLockTTL.Seconds())
+	for {
+		if err := c.RawQuery("DELETE FROM " + migrationLockTable + " WHERE id = 1 AND expires_at < now()").Exec(); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		err := sqlcon.HandleError(c.RawQuery("INSERT INTO "+migrationLockTable+" (id, expires_at) VALUES (1, now() + ? * INTERVAL '1 second')", ttl).Exec())
+		if err == nil {
+			break
+		} else if !errors.Is(err, sqlcon.ErrUniqueViolation) {
+			return nil, err
+		} else if !wait {
+			return nil, errors.WithStack(ErrMigrationLocked)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+
+	done := make(chan struct{})
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(migrationLockTTL / 4):
+				_ = c.RawQuery("UPDATE "+migrationLockTable+" SET expires_at = now() + ? * INTERVAL '1 second' WHERE id = 1", ttl).Exec()
+			}
+		}
+	}()
+
+	return func() error {
+		close(done)
+		<-refreshed
+		return sqlcon.HandleError(c.WithContext(context.Background()).RawQuery("DELETE FROM " + migrationLockTable + " WHERE id = 1").Exec())
+	}, nil
+}
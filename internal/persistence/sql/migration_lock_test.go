@@ -0,0 +1,80 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestWithMigrationLock(t *testing.T) {
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run(fmt.Sprintf("dsn=%s", dsn.Name), func(t *testing.T) {
+			connect := func(t *testing.T) *pop.Connection {
+				c, err := pop.NewConnection(&pop.ConnectionDetails{URL: dsn.Conn})
+				require.NoError(t, err)
+				require.NoError(t, c.Open())
+				t.Cleanup(func() { _ = c.Close() })
+				return c
+			}
+			first, second := connect(t), connect(t)
+			ctx := context.Background()
+
+			if first.Dialect.Name() == "sqlite3" {
+				for _, lock := range []func(context.Context, *pop.Connection, func(context.Context) error) error{sql.WithMigrationLock, sql.TryWithMigrationLock} {
+					var called bool
+					require.NoError(t, lock(ctx, first, func(context.Context) error {
+						called = true
+						return nil
+					}))
+					assert.True(t, called)
+				}
+				return
+			}
+
+			locked, release, released := make(chan struct{}), make(chan struct{}), make(chan error)
+			go func() {
+				released <- sql.WithMigrationLock(ctx, first, func(context.Context) error {
+					close(locked)
+					<-release
+					return nil
+				})
+			}()
+			<-locked
+
+			waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			err := sql.WithMigrationLock(waitCtx, second, func(context.Context) error {
+				t.Error("the lock was acquired twice")
+				return nil
+			})
+			assert.Error(t, err)
+
+			start := time.Now()
+			err = sql.TryWithMigrationLock(ctx, second, func(context.Context) error {
+				t.Error("the lock was acquired twice")
+				return nil
+			})
+			assert.ErrorIs(t, err, sql.ErrMigrationLocked)
+			assert.Less(t, time.Since(start), time.Second, "should fail fast")
+
+			close(release)
+			require.NoError(t, <-released)
+
+			var called bool
+			require.NoError(t, sql.WithMigrationLock(ctx, second, func(context.Context) error {
+				called = true
+				return nil
+			}))
+			assert.True(t, called)
+		})
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX keto_relation_tuples_subject_id_commit_time_idx ON keto_relation_tuples;
+DROP INDEX keto_relation_tuples_object_relation_commit_time_idx ON keto_relation_tuples;
+DROP INDEX keto_relation_tuples_subject_set_commit_time_idx ON keto_relation_tuples;
@@ -0,0 +1,21 @@
+CREATE INDEX keto_relation_tuples_subject_id_commit_time_idx ON keto_relation_tuples (nid,
+                                                                                      subject_id,
+                                                                                      commit_time,
+                                                                                      shard_id
+    );
+
+CREATE INDEX keto_relation_tuples_object_relation_commit_time_idx ON keto_relation_tuples (nid,
+                                                                                           namespace_id,
+                                                                                           object,
+                                                                                           relation,
+                                                                                           commit_time,
+                                                                                           shard_id
+    );
+
+CREATE INDEX keto_relation_tuples_subject_set_commit_time_idx ON keto_relation_tuples (nid,
+                                                                                       subject_set_namespace_id,
+                                                                                       subject_set_object,
+                                                                                       subject_set_relation,
+                                                                                       commit_time,
+                                                                                       shard_id
+    );
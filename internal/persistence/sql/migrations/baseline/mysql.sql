@@ -0,0 +1,43 @@
+-- Schema snapshot of all migrations up to and including version 20220512151000000000. It is applied instead of these
+-- migrations when migrating an empty database. Changes to the schema go into new migrations, never into this file.
+
+CREATE TABLE `networks` (
+`id` char(36) NOT NULL,
+PRIMARY KEY(`id`),
+`created_at` DATETIME NOT NULL,
+`updated_at` DATETIME NOT NULL
+) ENGINE=InnoDB;
+
+CREATE TABLE keto_relation_tuples
+(
+    shard_id                 char(36)    NOT NULL,
+    nid                      char(36)    NOT NULL,
+    namespace_id             INTEGER     NOT NULL,
+    object                   VARCHAR(64) NOT NULL,
+    relation                 VARCHAR(64) NOT NULL,
+    subject_id               VARCHAR(64) NULL,
+    subject_set_namespace_id INTEGER NULL,
+    subject_set_object       VARCHAR(64) NULL,
+    subject_set_relation     VARCHAR(64) NULL,
+    commit_time              TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (shard_id, nid),
+
+    -- enforce to have exactly one of subject_id or subject_set
+    CONSTRAINT chk_keto_rt_subject_type CHECK
+        ((subject_id IS NULL AND
+          subject_set_namespace_id IS NOT NULL AND subject_set_object IS NOT NULL AND subject_set_relation IS NOT NULL)
+            OR
+         (subject_id IS NOT NULL AND
+          subject_set_namespace_id IS NULL AND subject_set_object IS NULL AND subject_set_relation IS NULL))
+);
+
+-- mysql has no partial indexes
+CREATE INDEX keto_relation_tuples_full_idx ON keto_relation_tuples (nid, namespace_id, object, relation, subject_id, subject_set_namespace_id, subject_set_object, subject_set_relation, commit_time);
+
+CREATE INDEX keto_relation_tuples_reverse_subject_idx ON keto_relation_tuples (nid, subject_id, subject_set_namespace_id, subject_set_object, subject_set_relation, relation, namespace_id);
+
+-- added after the indices so that mysql does not create a separate index for the foreign key
+ALTER TABLE keto_relation_tuples
+    ADD CONSTRAINT keto_relation_tuples_nid_fk
+        FOREIGN KEY (nid) REFERENCES networks (id);
@@ -130,6 +130,16 @@ func NewToSingleTableMigrator(d dependencies) *toSingleTableMigrator {
 	}
 }
 
+// connection returns the connection of the SQL persister, as the legacy tables
+// only exist in SQL databases.
+func (m *toSingleTableMigrator) connection(ctx context.Context) (*pop.Connection, error) {
+	p, ok := m.d.Persister().(*sql.Persister)
+	if !ok {
+		return nil, errors.New("the legacy namespace migration is only supported by the SQL persister")
+	}
+	return p.Connection(ctx), nil
+}
+
 func (m *toSingleTableMigrator) NamespaceMigrationBox(ctx context.Context, n *namespace.Namespace) (*popx.MigrationBox, error) {
 	c, err := m.d.PopConnectionWithOpts(ctx, func(d *pop.ConnectionDetails) {
 		d.Options = map[string]string{
@@ -150,7 +160,11 @@ func (m *toSingleTableMigrator) NamespaceMigrationBox(ctx context.Context, n *na
 }
 
 func (m *toSingleTableMigrator) GetOldRelationTuples(ctx context.Context, n *namespace.Namespace, page, perPage int) (relationTuples, bool, error) {
-	q := m.d.Persister().Connection(ctx).
+	c, err := m.connection(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	q := c.
 		WithContext(context.WithValue(ctx, namespaceCtxKey, n)).
 		Order("object, relation, subject, commit_time").
 		Paginate(page, perPage)
@@ -173,7 +187,11 @@ func (m *toSingleTableMigrator) InsertOldRelationTuples(ctx context.Context, n *
 
 		m.d.Logger().WithFields(r.ToLoggerFields()).Trace("creating in legacy database")
 
-		if err := m.d.Persister().Connection(context.WithValue(ctx, namespaceCtxKey, n)).Create(&relationTuple{
+		c, err := m.connection(context.WithValue(ctx, namespaceCtxKey, n))
+		if err != nil {
+			return err
+		}
+		if err := c.Create(&relationTuple{
 			ID:         "testing only",
 			Object:     r.Object,
 			Relation:   r.Relation,
@@ -186,7 +204,20 @@ func (m *toSingleTableMigrator) InsertOldRelationTuples(ctx context.Context, n *
 	return nil
 }
 
+// MigrateNamespace copies the relation tuples of the legacy namespace table
+// into the single table. It fails with sql.ErrMigrationLocked if another
+// process is migrating the database at the same time.
 func (m *toSingleTableMigrator) MigrateNamespace(ctx context.Context, n *namespace.Namespace) error {
+	c, err := m.d.PopConnection(ctx)
+	if err != nil {
+		return err
+	}
+	return sql.TryWithMigrationLock(ctx, c, func(ctx context.Context) error {
+		return m.migrateNamespace(ctx, n)
+	})
+}
+
+func (m *toSingleTableMigrator) migrateNamespace(ctx context.Context, n *namespace.Namespace) error {
 	p, ok := m.d.Persister().(*sql.Persister)
 	if !ok {
 		panic("got unexpected persister")
@@ -291,8 +322,11 @@ func (m *toSingleTableMigrator) MigrateDown(ctx context.Context, n *namespace.Na
 	if err != nil {
 		return err
 	}
-	if err := mb.Down(ctx, 0); err != nil {
-		return errors.WithStack(err)
+	c, err := m.d.PopConnection(ctx)
+	if err != nil {
+		return err
 	}
-	return nil
+	return sql.TryWithMigrationLock(ctx, c, func(ctx context.Context) error {
+		return errors.WithStack(mb.Down(ctx, 0))
+	})
 }
@@ -0,0 +1,12 @@
+-- Tombstones record when a relation tuple was deleted in multi-primary mode. A tuple is only visible if it was
+-- (re-)written after its most recent tombstone, so that replicated inserts and deletes commute.
+CREATE TABLE keto_relation_tuple_tombstones
+(
+    shard_id   char(36)  NOT NULL,
+    nid        char(36)  NOT NULL,
+    deleted_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (shard_id, nid),
+
+    CONSTRAINT keto_relation_tuple_tombstones_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
@@ -0,0 +1 @@
+DROP INDEX keto_relation_tuples_commit_time_idx;
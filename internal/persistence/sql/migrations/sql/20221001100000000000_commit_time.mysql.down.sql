@@ -0,0 +1,4 @@
+DROP INDEX keto_relation_tuples_commit_time_idx ON keto_relation_tuples;
+
+ALTER TABLE keto_relation_tuple_tombstones MODIFY deleted_at TIMESTAMP NOT NULL;
+ALTER TABLE keto_relation_tuples MODIFY commit_time TIMESTAMP NOT NULL;
@@ -0,0 +1,6 @@
+-- The hybrid logical clock of the persister advances in microseconds, so the timestamps need a fractional part.
+ALTER TABLE keto_relation_tuples MODIFY commit_time TIMESTAMP(6) NOT NULL;
+ALTER TABLE keto_relation_tuple_tombstones MODIFY deleted_at TIMESTAMP(6) NOT NULL;
+
+-- Used to observe the latest commit time of a network before writing, see the hybrid logical clock of the persister.
+CREATE INDEX keto_relation_tuples_commit_time_idx ON keto_relation_tuples (nid, commit_time);
@@ -0,0 +1,2 @@
+-- Used to observe the latest commit time of a network before writing, see the hybrid logical clock of the persister.
+CREATE INDEX keto_relation_tuples_commit_time_idx ON keto_relation_tuples (nid, commit_time);
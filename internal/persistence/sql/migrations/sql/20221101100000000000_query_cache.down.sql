@@ -0,0 +1,2 @@
+DROP TABLE keto_query_cache;
+DROP TABLE keto_snapshots;
@@ -0,0 +1,26 @@
+-- The snapshot of a network advances with every write, so that cached query results are only reused until the
+-- relation tuples change.
+CREATE TABLE keto_snapshots
+(
+    nid     TEXT    NOT NULL,
+    version INTEGER NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_snapshots_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+CREATE TABLE keto_query_cache
+(
+    nid       TEXT    NOT NULL,
+    cache_key TEXT    NOT NULL,
+    snapshot  INTEGER NOT NULL,
+    value     TEXT    NOT NULL,
+
+    PRIMARY KEY (nid, cache_key),
+
+    CONSTRAINT keto_query_cache_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+-- Used to delete the entries of past snapshots.
+CREATE INDEX keto_query_cache_snapshot_idx ON keto_query_cache (nid, snapshot);
@@ -0,0 +1,2 @@
+DROP TRIGGER keto_relation_tuples_notify ON keto_relation_tuples;
+DROP FUNCTION keto_notify_relation_tuple_change();
@@ -0,0 +1,14 @@
+CREATE FUNCTION keto_notify_relation_tuple_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    PERFORM pg_notify('keto_relation_tuple_changes', json_build_object('deleted', true, 'row', row_to_json(OLD))::text);
+    RETURN OLD;
+  END IF;
+  PERFORM pg_notify('keto_relation_tuple_changes', json_build_object('deleted', false, 'row', row_to_json(NEW))::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER keto_relation_tuples_notify
+  AFTER INSERT OR UPDATE OR DELETE ON keto_relation_tuples
+  FOR EACH ROW EXECUTE PROCEDURE keto_notify_relation_tuple_change();
@@ -0,0 +1,16 @@
+-- Labels tag relation tuples with their origin, e.g. source=scim, so that importers can list and delete only their own
+-- tuples. They are deleted together with the tuple.
+CREATE TABLE keto_relation_tuple_labels
+(
+    shard_id    UUID        NOT NULL,
+    nid         UUID        NOT NULL,
+    label_key   VARCHAR(64) NOT NULL,
+    label_value VARCHAR(64) NOT NULL,
+
+    PRIMARY KEY (shard_id, nid, label_key),
+
+    CONSTRAINT keto_relation_tuple_labels_tuple_fk FOREIGN KEY (shard_id, nid) REFERENCES keto_relation_tuples (shard_id, nid) ON DELETE CASCADE
+);
+
+-- Used to filter the tuples by label.
+CREATE INDEX keto_relation_tuple_labels_label_idx ON keto_relation_tuple_labels (nid, label_key, label_value);
@@ -0,0 +1,3 @@
+DROP INDEX keto_relation_tuples_object_order_idx;
+DROP INDEX keto_relation_tuples_subject_id_order_idx;
+DROP INDEX keto_relation_tuples_subject_set_order_idx;
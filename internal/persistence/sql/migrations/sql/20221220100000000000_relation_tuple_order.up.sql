@@ -0,0 +1,17 @@
+CREATE INDEX keto_relation_tuples_object_order_idx ON keto_relation_tuples (nid,
+                                                                            namespace_id,
+                                                                            object,
+                                                                            shard_id
+    );
+
+CREATE INDEX keto_relation_tuples_subject_id_order_idx ON keto_relation_tuples (nid,
+                                                                                subject_id,
+                                                                                shard_id
+    ) WHERE subject_id IS NOT NULL;
+
+CREATE INDEX keto_relation_tuples_subject_set_order_idx ON keto_relation_tuples (nid,
+                                                                                 subject_set_namespace_id,
+                                                                                 subject_set_object,
+                                                                                 subject_set_relation,
+                                                                                 shard_id
+    ) WHERE subject_id IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX keto_relation_tuples_object_pattern_idx;
+DROP INDEX keto_relation_tuples_subject_id_pattern_idx;
+DROP INDEX keto_relation_tuples_subject_set_object_pattern_idx;
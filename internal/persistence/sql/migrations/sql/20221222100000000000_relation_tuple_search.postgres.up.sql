@@ -0,0 +1,12 @@
+CREATE INDEX keto_relation_tuples_object_pattern_idx ON keto_relation_tuples (nid,
+                                                                              namespace_id,
+                                                                              object text_pattern_ops
+    );
+
+CREATE INDEX keto_relation_tuples_subject_id_pattern_idx ON keto_relation_tuples (nid,
+                                                                                  subject_id text_pattern_ops
+    );
+
+CREATE INDEX keto_relation_tuples_subject_set_object_pattern_idx ON keto_relation_tuples (nid,
+                                                                                          subject_set_object text_pattern_ops
+    );
@@ -0,0 +1,2 @@
+DROP TABLE keto_active_namespace_configs;
+DROP TABLE keto_namespace_configs;
@@ -0,0 +1,24 @@
+-- Every change of the namespace configs adds a version. Versions are never changed, so that the history can be
+-- queried and an earlier version can be activated again.
+CREATE TABLE keto_namespace_configs
+(
+    nid        char(36)   NOT NULL,
+    version    BIGINT     NOT NULL,
+    namespaces MEDIUMTEXT NOT NULL,
+    created_at TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (nid, version),
+
+    CONSTRAINT keto_namespace_configs_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+-- The activation pointer selects the version that all instances serve.
+CREATE TABLE keto_active_namespace_configs
+(
+    nid     char(36) NOT NULL,
+    version BIGINT   NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_active_namespace_configs_version_fk FOREIGN KEY (nid, version) REFERENCES keto_namespace_configs (nid, version)
+);
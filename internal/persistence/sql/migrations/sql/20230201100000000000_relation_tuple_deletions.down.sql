@@ -0,0 +1,2 @@
+DROP TABLE keto_relation_tuple_deletion_horizons;
+DROP TABLE keto_relation_tuple_deletions;
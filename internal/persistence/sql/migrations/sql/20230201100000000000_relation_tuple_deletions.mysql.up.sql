@@ -0,0 +1,34 @@
+-- Deleted relation tuples are kept for the delta retention, so that clients can request the changes since a snapshot
+-- token instead of downloading a full snapshot. The horizon is the time before which deletions were pruned, or not yet
+-- recorded for networks that existed before this migration.
+CREATE TABLE keto_relation_tuple_deletions
+(
+    shard_id                 char(36)     NOT NULL,
+    nid                      char(36)     NOT NULL,
+    namespace_id             INTEGER      NOT NULL,
+    object                   VARCHAR(64)  NOT NULL,
+    relation                 VARCHAR(64)  NOT NULL,
+    subject_id               VARCHAR(64)  NULL,
+    subject_set_namespace_id INTEGER      NULL,
+    subject_set_object       VARCHAR(64)  NULL,
+    subject_set_relation     VARCHAR(64)  NULL,
+    deleted_at               TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (shard_id, nid),
+
+    CONSTRAINT keto_relation_tuple_deletions_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+CREATE INDEX keto_relation_tuple_deletions_deleted_at_idx ON keto_relation_tuple_deletions (nid, deleted_at);
+
+CREATE TABLE keto_relation_tuple_deletion_horizons
+(
+    nid           char(36)     NOT NULL,
+    pruned_before TIMESTAMP(6) NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_relation_tuple_deletion_horizons_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+INSERT INTO keto_relation_tuple_deletion_horizons (nid, pruned_before) SELECT id, CURRENT_TIMESTAMP FROM networks;
@@ -0,0 +1,12 @@
+-- A network is seeded at most once, from the seed files of the config on first boot. The digest identifies the seed
+-- files, so that changing them afterwards can be reported.
+CREATE TABLE keto_seeds
+(
+    nid        TEXT        NOT NULL,
+    digest     VARCHAR(64) NOT NULL,
+    applied_at TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_seeds_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE keto_snapshots
+(
+    nid     UUID   NOT NULL,
+    version BIGINT NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_snapshots_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+ALTER TABLE keto_relation_tuple_deletions DROP COLUMN commit_time;
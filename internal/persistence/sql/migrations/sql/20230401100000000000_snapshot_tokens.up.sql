@@ -0,0 +1,7 @@
+-- Snapshot tokens are the commit time of the latest change of a network. The deletion log records the commit times of
+-- the deleted relation tuples, so that reading at a snapshot restores the tuples deleted after it. Tuples deleted before
+-- this migration have no commit time, so snapshots before their deletion are refused. The counter of the writes, which
+-- keyed the query cache before, is replaced by the snapshot tokens.
+ALTER TABLE keto_relation_tuple_deletions ADD COLUMN commit_time TIMESTAMP NULL;
+
+DROP TABLE keto_snapshots;
@@ -0,0 +1,6 @@
+ALTER TABLE keto_relation_tuple_deletion_horizons DROP COLUMN pruned_seq;
+ALTER TABLE keto_relation_tuple_deletions DROP COLUMN deleted_seq;
+ALTER TABLE keto_relation_tuple_deletions DROP COLUMN seq;
+ALTER TABLE keto_relation_tuples DROP COLUMN seq;
+
+DROP TABLE keto_commit_sequences;
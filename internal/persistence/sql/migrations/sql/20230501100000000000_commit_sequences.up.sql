@@ -0,0 +1,23 @@
+-- Every transaction that changes the relation tuples of a network advances its commit sequence, and holds the lock of
+-- the row until it commits. The sequence therefore orders the transactions by their commit, unlike the commit times,
+-- which are taken when a write starts. Snapshot tokens, deltas and reads at a snapshot use the sequence. The column
+-- changed_at is the commit time of the latest change. Relation tuples and deletions before this migration are at the
+-- sequence 0.
+CREATE TABLE keto_commit_sequences
+(
+    nid        UUID      NOT NULL,
+    seq        BIGINT    NOT NULL,
+    changed_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (nid),
+
+    CONSTRAINT keto_commit_sequences_nid_fk FOREIGN KEY (nid) REFERENCES networks (id)
+);
+
+ALTER TABLE keto_relation_tuples ADD COLUMN seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE keto_relation_tuple_deletions ADD COLUMN seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE keto_relation_tuple_deletions ADD COLUMN deleted_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE keto_relation_tuple_deletion_horizons ADD COLUMN pruned_seq BIGINT NOT NULL DEFAULT 0;
+
+-- The cached values are keyed by the commit time tokens.
+DELETE FROM keto_query_cache;
@@ -0,0 +1,2 @@
+DROP INDEX keto_relation_tuple_deletions_deleted_seq_idx;
+DROP INDEX keto_relation_tuples_seq_idx;
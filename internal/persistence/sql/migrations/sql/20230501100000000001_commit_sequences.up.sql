@@ -0,0 +1,4 @@
+-- Used to list the relation tuples in the order of their commits, e.g. for the changes since a snapshot token and the
+-- relation tuples at a snapshot.
+CREATE INDEX keto_relation_tuples_seq_idx ON keto_relation_tuples (nid, seq, commit_time, shard_id);
+CREATE INDEX keto_relation_tuple_deletions_deleted_seq_idx ON keto_relation_tuple_deletions (nid, deleted_seq);
@@ -0,0 +1,2 @@
+DROP INDEX keto_relation_tuple_deletions_namespace_deleted_seq_idx ON keto_relation_tuple_deletions;
+DROP INDEX keto_relation_tuples_namespace_seq_idx ON keto_relation_tuples;
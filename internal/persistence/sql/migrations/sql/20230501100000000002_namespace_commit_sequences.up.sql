@@ -0,0 +1,3 @@
+-- Used to find the latest change of a namespace, e.g. for the ETags of list responses.
+CREATE INDEX keto_relation_tuples_namespace_seq_idx ON keto_relation_tuples (nid, namespace_id, seq);
+CREATE INDEX keto_relation_tuple_deletions_namespace_deleted_seq_idx ON keto_relation_tuple_deletions (nid, namespace_id, deleted_seq);
@@ -0,0 +1,196 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+)
+
+var _ namespace.ConfigStore = &Persister{}
+
+type (
+	NamespaceConfig struct {
+		Version    int64     `db:"version"`
+		Namespaces string    `db:"namespaces"`
+		CreatedAt  time.Time `db:"created_at"`
+	}
+	ActiveNamespaceConfig struct {
+		// An ID field is required to make pop happy. The network is the primary key.
+		ID      uuid.UUID `db:"nid"`
+		Version int64     `db:"version"`
+	}
+)
+
+func (NamespaceConfig) TableName(_ context.Context) string {
+	return "keto_namespace_configs"
+}
+
+func (ActiveNamespaceConfig) TableName(_ context.Context) string {
+	return "keto_active_namespace_configs"
+}
+
+func (c *NamespaceConfig) toConfigVersion(active int64) (*namespace.ConfigVersion, error) {
+	v := &namespace.ConfigVersion{
+		Version:   c.Version,
+		CreatedAt: c.CreatedAt,
+		Active:    c.Version == active,
+	}
+	if err := json.Unmarshal([]byte(c.Namespaces), &v.Namespaces); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v, nil
+}
+
+func (p *Persister) AddNamespaceConfig(ctx context.Context, baseVersion int64, nn []*namespace.Namespace) (*namespace.ConfigVersion, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.AddNamespaceConfig")
+	defer span.End()
+
+	if err := namespace.Validate(nn); err != nil {
+		return nil, err
+	}
+	if nn == nil {
+		nn = []*namespace.Namespace{}
+	}
+	enc, err := json.Marshal(nn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var v *namespace.ConfigVersion
+	err = p.Transaction(ctx, func(ctx context.Context, c *pop.Connection) error {
+		var latest []NamespaceConfig
+		if err := p.QueryWithNetwork(ctx).Order("version DESC").Limit(1).All(&latest); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		var latestVersion int64
+		if len(latest) > 0 {
+			latestVersion = latest[0].Version
+		}
+		if latestVersion != baseVersion {
+			return namespaceConfigConflict(latestVersion, baseVersion)
+		}
+		row := NamespaceConfig{Version: latestVersion + 1, Namespaces: string(enc), CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+
+		// Concurrent writers based on the same version pick the same new
+		// version, all but one fail on the primary key.
+		err := c.RawQuery(
+			"INSERT INTO keto_namespace_configs (nid, version, namespaces, created_at) VALUES (?, ?, ?, ?)",
+			p.NetworkID(ctx), row.Version, row.Namespaces, row.CreatedAt,
+		).Exec()
+		if err := sqlcon.HandleError(err); errors.Is(err, sqlcon.ErrUniqueViolation) {
+			return namespaceConfigConflict(row.Version, baseVersion)
+		} else if err != nil {
+			return err
+		}
+
+		v, err = row.toConfigVersion(0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func namespaceConfigConflict(latest, base int64) error {
+	return errors.WithStack(herodot.ErrConflict.
+		WithID(errorcode.NamespaceConfigConflict).
+		WithReasonf("The change is based on version %d of the namespace configs, but version %d was added since.", base, latest).
+		WithDetail("latest_version", latest))
+}
+
+func (p *Persister) ActivateNamespaceConfig(ctx context.Context, version int64) (*namespace.ConfigVersion, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.ActivateNamespaceConfig")
+	defer span.End()
+
+	var v *namespace.ConfigVersion
+	err := p.Transaction(ctx, func(ctx context.Context, c *pop.Connection) error {
+		var err error
+		v, err = p.GetNamespaceConfig(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		n, err := c.RawQuery("UPDATE keto_active_namespace_configs SET version = ? WHERE nid = ?", version, p.NetworkID(ctx)).ExecWithCount()
+		if err != nil {
+			return sqlcon.HandleError(err)
+		}
+		if n == 0 {
+			// The first activation of the network.
+			if err := c.Create(&ActiveNamespaceConfig{ID: p.NetworkID(ctx), Version: version}); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+		v.Active = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (p *Persister) ActiveNamespaceConfigVersion(ctx context.Context) (int64, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.ActiveNamespaceConfigVersion")
+	defer span.End()
+
+	var a ActiveNamespaceConfig
+	err := p.Connection(ctx).Where("nid = ?", p.NetworkID(ctx)).First(&a)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	return a.Version, nil
+}
+
+func (p *Persister) GetNamespaceConfig(ctx context.Context, version int64) (*namespace.ConfigVersion, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.GetNamespaceConfig")
+	defer span.End()
+
+	var rows []NamespaceConfig
+	if err := p.QueryWithNetwork(ctx).Where("version = ?", version).Limit(1).All(&rows); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	if len(rows) == 0 {
+		return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceConfigUnknown).WithReasonf("Unknown namespace config version %d.", version))
+	}
+
+	active, err := p.ActiveNamespaceConfigVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rows[0].toConfigVersion(active)
+}
+
+func (p *Persister) NamespaceConfigHistory(ctx context.Context) ([]*namespace.ConfigVersion, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.NamespaceConfigHistory")
+	defer span.End()
+
+	var rows []NamespaceConfig
+	if err := p.QueryWithNetwork(ctx).Order("version DESC").All(&rows); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+
+	active, err := p.ActiveNamespaceConfigVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vv := make([]*namespace.ConfigVersion, len(rows))
+	for i := range rows {
+		if vv[i], err = rows[i].toConfigVersion(active); err != nil {
+			return nil, err
+		}
+	}
+	return vv, nil
+}
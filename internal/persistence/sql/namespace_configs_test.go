@@ -0,0 +1,115 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestNamespaceConfigs(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			s := reg.NamespaceConfigStore()
+			require.NotNil(t, s)
+
+			t.Run("case=nothing is active initially", func(t *testing.T) {
+				version, err := s.ActiveNamespaceConfigVersion(ctx)
+				require.NoError(t, err)
+				assert.Zero(t, version)
+			})
+
+			v1, err := s.AddNamespaceConfig(ctx, 0, []*namespace.Namespace{{ID: 1, Name: "files"}})
+			require.NoError(t, err)
+			v2, err := s.AddNamespaceConfig(ctx, v1.Version, []*namespace.Namespace{{ID: 1, Name: "files"}, {ID: 2, Name: "folders"}})
+			require.NoError(t, err)
+
+			t.Run("case=versions increase", func(t *testing.T) {
+				assert.Equal(t, int64(1), v1.Version)
+				assert.Equal(t, int64(2), v2.Version)
+				assert.False(t, v2.Active)
+			})
+
+			t.Run("case=rejects changes based on outdated versions", func(t *testing.T) {
+				for _, base := range []int64{0, v1.Version, v2.Version + 1} {
+					_, err := s.AddNamespaceConfig(ctx, base, []*namespace.Namespace{{ID: 3, Name: "conflict"}})
+					assert.True(t, errorcode.Is(err, errorcode.NamespaceConfigConflict), "%+v", err)
+				}
+			})
+
+			t.Run("case=activates versions", func(t *testing.T) {
+				active, err := s.ActivateNamespaceConfig(ctx, v2.Version)
+				require.NoError(t, err)
+				assert.True(t, active.Active)
+
+				active, err = s.ActivateNamespaceConfig(ctx, v1.Version)
+				require.NoError(t, err)
+				assert.Equal(t, v1.Namespaces, active.Namespaces)
+
+				version, err := s.ActiveNamespaceConfigVersion(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, v1.Version, version)
+			})
+
+			t.Run("case=lists the history", func(t *testing.T) {
+				vv, err := s.NamespaceConfigHistory(ctx)
+				require.NoError(t, err)
+				require.Len(t, vv, 2)
+				assert.Equal(t, v2.Version, vv[0].Version)
+				assert.Equal(t, v2.Namespaces, vv[0].Namespaces)
+				assert.False(t, vv[0].Active)
+				assert.True(t, vv[1].Active)
+			})
+
+			t.Run("case=unknown version", func(t *testing.T) {
+				_, err := s.GetNamespaceConfig(ctx, 42)
+				assert.True(t, errorcode.Is(err, errorcode.NamespaceConfigUnknown), "%+v", err)
+				_, err = s.ActivateNamespaceConfig(ctx, 42)
+				assert.True(t, errorcode.Is(err, errorcode.NamespaceConfigUnknown), "%+v", err)
+			})
+
+			t.Run("case=invalid namespaces", func(t *testing.T) {
+				_, err := s.AddNamespaceConfig(ctx, v2.Version, []*namespace.Namespace{{ID: 1, Name: "files"}, {ID: 1, Name: "folders"}})
+				assert.True(t, errorcode.Is(err, errorcode.NamespaceConfigInvalid), "%+v", err)
+			})
+
+			t.Run("case=serves the active version", func(t *testing.T) {
+				require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaceStorePollInterval, "10ms"))
+				require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, config.NamespacesDatabase))
+
+				names := func() []string {
+					nm, err := reg.Config(ctx).NamespaceManager()
+					require.NoError(t, err)
+					nn, err := nm.Namespaces(ctx)
+					require.NoError(t, err)
+					names := make([]string, len(nn))
+					for i, n := range nn {
+						names[i] = n.Name
+					}
+					return names
+				}
+				assert.Equal(t, []string{"files"}, names())
+
+				_, err := s.ActivateNamespaceConfig(ctx, v2.Version)
+				require.NoError(t, err)
+				assert.Eventually(t, func() bool {
+					return len(names()) == 2
+				}, 5*time.Second, 10*time.Millisecond)
+			})
+		})
+	}
+}
@@ -17,6 +17,23 @@ func (p *Persister) GetNamespaceByName(ctx context.Context, name string) (*names
 	return nm.GetNamespaceByName(ctx, name)
 }
 
+// namespaceOptions returns the namespace and the options of its config.
+func (p *Persister) namespaceOptions(ctx context.Context, name string) (*namespace.Namespace, *namespace.Options, error) {
+	n, err := p.GetNamespaceByName(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	nm, err := p.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, err := namespace.ResolvedOptions(ctx, nm, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, opts, nil
+}
+
 func (p *Persister) GetNamespaceByID(ctx context.Context, id int32) (*namespace.Namespace, error) {
 	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.GetNamespaceByID")
 	defer span.End()
@@ -27,3 +44,26 @@ func (p *Persister) GetNamespaceByID(ctx context.Context, id int32) (*namespace.
 	}
 	return nm.GetNamespaceByConfigID(ctx, id)
 }
+
+// namespaceNames resolves the namespace IDs of rows to names. The rows of
+// one result mostly share a few namespaces, so each ID is looked up once.
+type namespaceNames struct {
+	p     *Persister
+	names map[int32]string
+}
+
+func (p *Persister) namespaceNames() *namespaceNames {
+	return &namespaceNames{p: p, names: make(map[int32]string)}
+}
+
+func (n *namespaceNames) name(ctx context.Context, id int32) (string, error) {
+	if name, ok := n.names[id]; ok {
+		return name, nil
+	}
+	ns, err := n.p.GetNamespaceByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	n.names[id] = ns.Name
+	return ns.Name, nil
+}
@@ -0,0 +1,99 @@
+package sql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestIdentifierNormalization(t *testing.T) {
+	const composed, decomposed = "caf\u00e9", "cafe\u0301"
+
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+		{ID: 0, Name: "normalized", Config: json.RawMessage(`{"unicode_normalization": "nfc"}`)},
+		{ID: 1, Name: "verbatim"},
+		{ID: 2, Name: "case-insensitive", Config: json.RawMessage(`{"case_insensitive": true}`)},
+	}))
+	m := reg.RelationTupleManager()
+
+	get := func(t *testing.T, q *relationtuple.RelationQuery) []*relationtuple.InternalRelationTuple {
+		res, _, err := m.GetRelationTuples(ctx, q, x.WithSize(100))
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("case=normalizes objects and subject IDs", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "normalized",
+			Object:    decomposed,
+			Relation:  "owner",
+			Subject:   &relationtuple.SubjectID{ID: decomposed},
+		}))
+
+		res := get(t, &relationtuple.RelationQuery{Namespace: "normalized", Object: composed, SubjectID: pointerx.String(composed)})
+		require.Len(t, res, 1)
+		assert.Equal(t, composed, res[0].Object)
+		assert.Equal(t, composed, res[0].Subject.(*relationtuple.SubjectID).ID)
+
+		assert.Len(t, get(t, &relationtuple.RelationQuery{Namespace: "normalized", Object: decomposed}), 1)
+
+		require.NoError(t, m.DeleteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "normalized",
+			Object:    composed,
+			Relation:  "owner",
+			Subject:   &relationtuple.SubjectID{ID: decomposed},
+		}))
+		assert.Len(t, get(t, &relationtuple.RelationQuery{Namespace: "normalized"}), 0)
+	})
+
+	t.Run("case=normalizes subject set objects by their namespace", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "verbatim",
+			Object:    decomposed,
+			Relation:  "viewer",
+			Subject:   &relationtuple.SubjectSet{Namespace: "normalized", Object: decomposed, Relation: "owner"},
+		}))
+
+		res := get(t, &relationtuple.RelationQuery{Namespace: "verbatim", Object: decomposed})
+		require.Len(t, res, 1)
+		assert.Equal(t, &relationtuple.SubjectSet{Namespace: "normalized", Object: composed, Relation: "owner"}, res[0].Subject)
+	})
+
+	t.Run("case=keeps identifiers of other namespaces", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "verbatim",
+			Object:    composed,
+			Relation:  "owner",
+			Subject:   &relationtuple.SubjectID{ID: decomposed},
+		}))
+
+		assert.Len(t, get(t, &relationtuple.RelationQuery{Namespace: "verbatim", Object: composed}), 1)
+		assert.Len(t, get(t, &relationtuple.RelationQuery{Namespace: "verbatim", Object: composed, SubjectID: pointerx.String(composed)}), 0)
+	})
+
+	t.Run("case=lower-cases identifiers of case-insensitive namespaces", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "case-insensitive",
+			Object:    "Reports",
+			Relation:  "viewer",
+			Subject:   &relationtuple.SubjectID{ID: "Alice@Example.com"},
+		}))
+
+		res := get(t, &relationtuple.RelationQuery{Namespace: "case-insensitive", Object: "REPORTS", SubjectID: pointerx.String("alice@example.com")})
+		require.Len(t, res, 1)
+		assert.Equal(t, "reports", res[0].Object)
+		assert.Equal(t, &relationtuple.SubjectID{ID: "alice@example.com"}, res[0].Subject)
+	})
+}
@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/jackc/pgx/v4"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+// changeNotificationChannel is notified by a trigger on keto_relation_tuples,
+// which is installed by the change_notifications migration.
+const changeNotificationChannel = "keto_relation_tuple_changes"
+
+type changeNotification struct {
+	Deleted bool           `json:"deleted"`
+	Row     *changefeedRow `json:"row"`
+}
+
+// listenForChanges opens a dedicated connection to LISTEN for change
+// notifications, as the pooled connections can't receive them. Notifications
+// are only sent when the writing transaction commits, so rolled back writes
+// are never published.
+func (p *Persister) listenForChanges(ctx context.Context, c *pop.Connection, f func(*persistence.Change)) error {
+	conn, err := pgx.Connect(ctx, c.URL())
+	if err != nil {
+		return sqlcon.HandleError(err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+changeNotificationChannel); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			return sqlcon.HandleError(err)
+		}
+
+		var cn changeNotification
+		if err := json.Unmarshal([]byte(n.Payload), &cn); err != nil {
+			return errors.WithStack(err)
+		}
+		if cn.Row != nil {
+			p.publishChange(ctx, cn.Row, cn.Deleted, f)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package sql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	// internalPagination implements keyset pagination. The default order is
+	// (nid, seq, commit_time, shard_id), i.e. the order in which the writes
+	// committed, and the order of the writes within a transaction. A
+	// transaction only gets its commit sequence after the one before it
+	// committed, so rows written while paginating are ordered after the rows
+	// that were already read, and no committed row is skipped. In the other
+	// orders, rows written while paginating are returned if they are ordered
+	// after the current page.
+	internalPagination struct {
+		PerPage    int
+		OrderBy    x.OrderBy
+		Descending bool
+		// After is the key of the last row of the previous page, or nil for
+		// the first page.
+		After *pageKey
+	}
+	// pageKey holds the sort columns of a row. Only the columns of the order
+	// are set.
+	pageKey struct {
+		CommitTime            time.Time `json:"t"`
+		Seq                   int64     `json:"q,omitempty"`
+		ID                    uuid.UUID `json:"id"`
+		NamespaceID           int32     `json:"n,omitempty"`
+		Object                string    `json:"o,omitempty"`
+		SubjectID             string    `json:"s,omitempty"`
+		SubjectSetNamespaceID int32     `json:"sn,omitempty"`
+		SubjectSetObject      string    `json:"so,omitempty"`
+		SubjectSetRelation    string    `json:"sr,omitempty"`
+		// Segment is the index of the orderSegment of the row.
+		Segment int `json:"seg,omitempty"`
+	}
+	// orderedPageToken is the page token of all but the default order, which
+	// has a shorter format for compatibility.
+	orderedPageToken struct {
+		OrderBy    x.OrderBy `json:"o"`
+		Descending bool      `json:"d,omitempty"`
+		Key        pageKey   `json:"k"`
+	}
+	// orderSegment is a part of an order that is read with one query, so
+	// that it can be backed by an index without NULL values.
+	orderSegment struct {
+		where   string
+		columns []string
+		key     func(*pageKey) []interface{}
+	}
+)
+
+const (
+	defaultPageSize int = 100
+)
+
+// orderSegments are the segments of the orders, in ascending order. The shard
+// ID breaks ties. Sorting by subject reads the subject IDs first, then the
+// subject sets, as they are stored in different columns.
+var orderSegments = map[x.OrderBy][]orderSegment{
+	x.OrderByCommitTime: {{
+		columns: []string{"seq", "commit_time", "shard_id"},
+		key:     func(k *pageKey) []interface{} { return []interface{}{k.Seq, k.CommitTime, k.ID} },
+	}},
+	x.OrderByObject: {{
+		columns: []string{"namespace_id", "object", "shard_id"},
+		key:     func(k *pageKey) []interface{} { return []interface{}{k.NamespaceID, k.Object, k.ID} },
+	}},
+	x.OrderBySubject: {{
+		where:   "subject_id IS NOT NULL",
+		columns: []string{"subject_id", "shard_id"},
+		key:     func(k *pageKey) []interface{} { return []interface{}{k.SubjectID, k.ID} },
+	}, {
+		where:   "subject_id IS NULL",
+		columns: []string{"subject_set_namespace_id", "subject_set_object", "subject_set_relation", "shard_id"},
+		key: func(k *pageKey) []interface{} {
+			return []interface{}{k.SubjectSetNamespaceID, k.SubjectSetObject, k.SubjectSetRelation, k.ID}
+		},
+	}},
+}
+
+func internalPaginationFromOptions(opts ...x.PaginationOptionSetter) (*internalPagination, error) {
+	xp := x.GetPaginationOptions(opts...)
+	ip := &internalPagination{
+		PerPage:    xp.Size,
+		OrderBy:    xp.OrderBy,
+		Descending: xp.Descending,
+	}
+	if ip.PerPage == 0 {
+		ip.PerPage = defaultPageSize
+	}
+	if _, ok := orderSegments[ip.OrderBy]; !ok {
+		return ip, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.OrderInvalid).WithErrorf("unknown order %q", ip.OrderBy))
+	}
+	return ip, ip.parsePageToken(xp.Token)
+}
+
+func (p *internalPagination) defaultOrder() bool {
+	return (p.OrderBy == "" || p.OrderBy == x.OrderByCommitTime) && !p.Descending
+}
+
+// segments returns the segments of the order in the direction of the
+// pagination.
+func (p *internalPagination) segments() []orderSegment {
+	segs := orderSegments[p.OrderBy]
+	if !p.Descending {
+		return segs
+	}
+	reversed := make([]orderSegment, len(segs))
+	for i, s := range segs {
+		reversed[len(segs)-1-i] = s
+	}
+	return reversed
+}
+
+// query restricts q to the rows of the segment after the key of the previous
+// page, and sorts them.
+func (p *internalPagination) query(q *pop.Query, segment int) {
+	seg := p.segments()[segment]
+	if seg.where != "" {
+		q.Where(seg.where)
+	}
+
+	direction, op := "", ">"
+	if p.Descending {
+		direction, op = " DESC", "<"
+	}
+	order := make([]string, len(seg.columns))
+	for i, c := range seg.columns {
+		order[i] = c + direction
+	}
+	q.Order("nid, " + strings.Join(order, ", "))
+
+	if p.After == nil || p.After.Segment != segment {
+		return
+	}
+	// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+	values := seg.key(p.After)
+	clauses := make([]string, len(seg.columns))
+	var args []interface{}
+	for i, c := range seg.columns {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, seg.columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+		clauses[i] = strings.Join(append(parts, c+" "+op+" ?"), " AND ")
+		args = append(args, values[i])
+	}
+	q.Where("(("+strings.Join(clauses, ") OR (")+"))", args...)
+}
+
+func newPageKey(r *RelationTuple, segment int) pageKey {
+	return pageKey{
+		CommitTime:            r.CommitTime,
+		Seq:                   r.Seq,
+		ID:                    r.ID,
+		NamespaceID:           r.NamespaceID,
+		Object:                r.Object,
+		SubjectID:             r.SubjectID.String,
+		SubjectSetNamespaceID: r.SubjectSetNamespaceID.Int32,
+		SubjectSetObject:      r.SubjectSetObject.String,
+		SubjectSetRelation:    r.SubjectSetRelation.String,
+		Segment:               segment,
+	}
+}
+
+// parsePageToken decodes the key of the last row of the previous page.
+func (p *internalPagination) parsePageToken(t string) error {
+	if t == "" {
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(t)
+	if err != nil {
+		return errors.WithStack(persistence.ErrMalformedPageToken)
+	}
+
+	if !p.defaultOrder() {
+		var token orderedPageToken
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return errors.WithStack(persistence.ErrMalformedPageToken)
+		}
+		if token.OrderBy != p.OrderBy || token.Descending != p.Descending || token.Key.Segment < 0 || token.Key.Segment >= len(p.segments()) {
+			// Tokens are only valid for the order they were returned for.
+			return errors.WithStack(persistence.ErrMalformedPageToken)
+		}
+		p.After = &token.Key
+		return nil
+	}
+
+	// Tokens of an older version have no commit sequence. They can only
+	// point to rows written before the commit sequences were introduced,
+	// which are all at the sequence 0.
+	parts := strings.Split(string(raw), "/")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return errors.WithStack(persistence.ErrMalformedPageToken)
+	}
+	seq, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return errors.WithStack(persistence.ErrMalformedPageToken)
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return errors.WithStack(persistence.ErrMalformedPageToken)
+	}
+	id, err := uuid.FromString(parts[2])
+	if err != nil {
+		return errors.WithStack(persistence.ErrMalformedPageToken)
+	}
+
+	p.After = &pageKey{Seq: seq, CommitTime: time.Unix(0, nanos).UTC(), ID: id}
+	return nil
+}
+
+// encodeNextPageToken encodes the key of the last row of the current page.
+func (p *internalPagination) encodeNextPageToken(last pageKey) string {
+	if p.defaultOrder() {
+		return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d/%d/%s", last.Seq, last.CommitTime.UnixNano(), last.ID)))
+	}
+
+	// Only keep the columns of the order to keep the token short.
+	k := pageKey{ID: last.ID, Segment: last.Segment}
+	switch p.OrderBy {
+	case x.OrderByCommitTime:
+		k.Seq, k.CommitTime = last.Seq, last.CommitTime
+	case x.OrderByObject:
+		k.NamespaceID, k.Object = last.NamespaceID, last.Object
+	case x.OrderBySubject:
+		k.SubjectID, k.SubjectSetNamespaceID, k.SubjectSetObject, k.SubjectSetRelation = last.SubjectID, last.SubjectSetNamespaceID, last.SubjectSetObject, last.SubjectSetRelation
+	}
+	raw, _ := json.Marshal(&orderedPageToken{OrderBy: p.OrderBy, Descending: p.Descending, Key: k})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -1,10 +1,13 @@
 package sql
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ory/keto/internal/persistence"
@@ -14,38 +17,87 @@ import (
 func TestPaginationToken(t *testing.T) {
 	t.Parallel()
 
+	key := pageKey{
+		Seq:        42,
+		CommitTime: time.Date(2022, 10, 1, 12, 0, 0, 1000, time.UTC),
+		ID:         uuid.Must(uuid.FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8")),
+	}
+	legacyKey := pageKey{CommitTime: key.CommitTime, ID: key.ID}
+	validToken := (&internalPagination{}).encodeNextPageToken(key)
+
 	for i, tc := range []struct {
 		size            int
 		token           string
 		expectedErr     error
-		expectedPage    int
+		expectedAfter   *pageKey
 		expectedPerPage int
 	}{
 		{
 			size:            10,
-			token:           "10",
-			expectedPage:    10,
+			token:           validToken,
+			expectedAfter:   &key,
 			expectedPerPage: 10,
 		},
+		{
+			size:            0,
+			token:           "",
+			expectedPerPage: defaultPageSize,
+		},
 		{
 			size:            0,
 			token:           "15",
-			expectedPage:    15,
+			expectedErr:     persistence.ErrMalformedPageToken,
 			expectedPerPage: defaultPageSize,
 		},
 		{
 			size:            0,
-			token:           "-15",
+			token:           base64.RawURLEncoding.EncodeToString([]byte("15/16/not-a-uuid")),
 			expectedErr:     persistence.ErrMalformedPageToken,
 			expectedPerPage: defaultPageSize,
 		},
+		{
+			// tokens of an older version have no commit sequence
+			size:            0,
+			token:           base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d/%s", key.CommitTime.UnixNano(), key.ID))),
+			expectedAfter:   &legacyKey,
+			expectedPerPage: defaultPageSize,
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/size:%d token:%s", i, tc.size, tc.token), func(t *testing.T) {
 			pagination, err := internalPaginationFromOptions(x.WithSize(tc.size), x.WithToken(tc.token))
 
 			assert.True(t, errors.Is(err, tc.expectedErr))
 			assert.Equal(t, tc.expectedPerPage, pagination.PerPage)
-			assert.Equal(t, tc.expectedPage, pagination.Page)
+			assert.Equal(t, tc.expectedAfter, pagination.After)
 		})
 	}
+
+	t.Run("case=ordered token", func(t *testing.T) {
+		last := pageKey{
+			Seq:        key.Seq,
+			CommitTime: key.CommitTime,
+			ID:         key.ID,
+			SubjectID:  "subject",
+			Object:     "object",
+			Segment:    0,
+		}
+		ordered := &internalPagination{OrderBy: x.OrderBySubject, Descending: true}
+		token := ordered.encodeNextPageToken(last)
+
+		pagination, err := internalPaginationFromOptions(x.WithToken(token), x.WithOrder(x.OrderBySubject, true))
+		assert.NoError(t, err)
+		// only the columns of the order are kept
+		assert.Equal(t, &pageKey{ID: key.ID, SubjectID: "subject"}, pagination.After)
+
+		for _, opt := range []x.PaginationOptionSetter{
+			x.WithOrder(x.OrderBySubject, false),
+			x.WithOrder(x.OrderByObject, true),
+			x.WithOrder(x.OrderByCommitTime, false),
+		} {
+			_, err := internalPaginationFromOptions(x.WithToken(token), opt)
+			assert.True(t, errors.Is(err, persistence.ErrMalformedPageToken))
+		}
+		_, err = internalPaginationFromOptions(x.WithToken(validToken), x.WithOrder(x.OrderByCommitTime, true))
+		assert.True(t, errors.Is(err, persistence.ErrMalformedPageToken))
+	})
 }
@@ -3,48 +3,41 @@ package sql
 import (
 	"context"
 	"embed"
-	"fmt"
+	"io/fs"
 	"reflect"
-	"strconv"
+	"sort"
 
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
-	"github.com/ory/x/fsx"
 	"github.com/ory/x/logrusx"
-	"github.com/ory/x/networkx"
 	"github.com/ory/x/otelx"
 	"github.com/ory/x/popx"
-	"github.com/pkg/errors"
 
 	"github.com/ory/keto/internal/driver/config"
 	"github.com/ory/keto/internal/persistence"
 	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/hlc"
 	"github.com/ory/keto/ketoctx"
 )
 
 type (
 	Persister struct {
-		conn *pop.Connection
-		d    dependencies
-		nid  uuid.UUID
-	}
-	internalPagination struct {
-		Page, PerPage int
+		conn    *pop.Connection
+		regions map[string]*pop.Connection
+		d       dependencies
+		nid     uuid.UUID
+		clock   *hlc.Clock
 	}
 	dependencies interface {
 		config.Provider
 		x.LoggerProvider
-		x.TracingProvider
 		ketoctx.ContextualizerProvider
 
+		Tracer(ctx context.Context) *otelx.Tracer
 		PopConnection(ctx context.Context) (*pop.Connection, error)
 	}
 )
 
-const (
-	defaultPageSize int = 100
-)
-
 var (
 	//go:embed migrations/sql/*.sql
 	Migrations embed.FS
@@ -52,27 +45,86 @@ var (
 	_ persistence.Persister = &Persister{}
 )
 
-func NewPersister(ctx context.Context, reg dependencies, nid uuid.UUID) (*Persister, error) {
+// PersisterOption configures a Persister.
+type PersisterOption func(*Persister)
+
+// WithClock sets the clock that takes the commit times, e.g. to simulate a
+// server with a skewed clock in tests.
+func WithClock(c *hlc.Clock) PersisterOption {
+	return func(p *Persister) {
+		p.clock = c
+	}
+}
+
+// WithRegionConnections sets the connections to the region-local databases,
+// keyed by region. The relation tuples of networks homed in one of these
+// regions are stored in its database instead of the default one.
+func WithRegionConnections(conns map[string]*pop.Connection) PersisterOption {
+	return func(p *Persister) {
+		p.regions = conns
+	}
+}
+
+func NewPersister(ctx context.Context, reg dependencies, nid uuid.UUID, opts ...PersisterOption) (*Persister, error) {
 	conn, err := reg.PopConnection(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	p := &Persister{
-		d:    reg,
-		nid:  nid,
-		conn: conn,
+		d:     reg,
+		nid:   nid,
+		conn:  conn,
+		clock: hlc.New(),
+	}
+	for _, o := range opts {
+		o(p)
 	}
 
 	return p, nil
 }
 
-func NewMigrationBox(c *pop.Connection, logger *logrusx.Logger, tracer *otelx.Tracer) (*popx.MigrationBox, error) {
-	return popx.NewMigrationBox(fsx.Merge(Migrations, networkx.Migrations), popx.NewMigrator(c, logger, tracer, 0))
+func NewMigrationBox(c *pop.Connection, logger *logrusx.Logger, tracer *otelx.Tracer, extra ...fs.FS) (*popx.MigrationBox, error) {
+	migrations, err := MergeMigrations(extra...)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := baselineMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return popx.NewMigrationBox(migrations, popx.NewMigrator(c, logger, tracer, 0), popx.WithGoMigrations(baseline))
 }
 
 func (p *Persister) Connection(ctx context.Context) *pop.Connection {
-	return popx.GetConnection(ctx, p.conn.WithContext(ctx))
+	return popx.GetConnection(ctx, p.database(ctx).WithContext(ctx))
+}
+
+// database returns the connection to the database of the network's home
+// region, or the default one if the region has no database of its own.
+func (p *Persister) database(ctx context.Context) *pop.Connection {
+	if len(p.regions) == 0 {
+		return p.conn
+	}
+	if c, ok := p.regions[p.d.Config(ctx).NetworkRegion(p.NetworkID(ctx))]; ok {
+		return c
+	}
+	return p.conn
+}
+
+// databases returns the connections to all databases, the default one first.
+func (p *Persister) databases() []*pop.Connection {
+	regions := make([]string, 0, len(p.regions))
+	for r := range p.regions {
+		regions = append(regions, r)
+	}
+	sort.Strings(regions)
+
+	conns := []*pop.Connection{p.conn}
+	for _, r := range regions {
+		conns = append(conns, p.regions[r])
+	}
+	return conns
 }
 
 func (p *Persister) CreateWithNetwork(ctx context.Context, v interface{}) error {
@@ -97,40 +149,6 @@ func (p *Persister) QueryWithNetwork(ctx context.Context) *pop.Query {
 	return p.Connection(ctx).Where("nid = ?", p.NetworkID(ctx))
 }
 
-func (p *Persister) Transaction(ctx context.Context, f func(ctx context.Context, c *pop.Connection) error) error {
-	return popx.Transaction(ctx, p.conn.WithContext(ctx), f)
-}
-
 func (p *Persister) NetworkID(ctx context.Context) uuid.UUID {
 	return p.d.Contextualizer().Network(ctx, p.nid)
 }
-
-func internalPaginationFromOptions(opts ...x.PaginationOptionSetter) (*internalPagination, error) {
-	xp := x.GetPaginationOptions(opts...)
-	ip := &internalPagination{
-		PerPage: xp.Size,
-	}
-	if ip.PerPage == 0 {
-		ip.PerPage = defaultPageSize
-	}
-	return ip, ip.parsePageToken(xp.Token)
-}
-
-func (p *internalPagination) parsePageToken(t string) error {
-	if t == "" {
-		p.Page = 1
-		return nil
-	}
-
-	i, err := strconv.ParseUint(t, 10, 32)
-	if err != nil {
-		return errors.WithStack(persistence.ErrMalformedPageToken)
-	}
-
-	p.Page = int(i)
-	return nil
-}
-
-func (p *internalPagination) encodeNextPageToken() string {
-	return fmt.Sprintf("%d", p.Page+1)
-}
@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+const (
+	// pollLookback is how far behind the newest commit time rows are read
+	// again. Commit times are taken before the transaction commits, so a
+	// slow transaction can commit rows older than rows that were already
+	// polled.
+	pollLookback = 10 * time.Second
+	pollPageSize = 1000
+)
+
+// pollChanges reads the rows of all networks by their commit time, for
+// databases that can't stream changes. Deleted rows are gone and can't be
+// reported. Rows of transactions that take longer than the lookback to
+// commit are missed.
+func (p *Persister) pollChanges(ctx context.Context, c *pop.Connection, f func(*persistence.Change)) error {
+	c = c.WithContext(ctx)
+	var latest RelationTuple
+	err := c.Order("commit_time DESC").First(&latest)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return sqlcon.HandleError(err)
+	}
+	newest := latest.CommitTime
+
+	// seen holds the rows within the lookback that were already reported.
+	seen := map[pageKey]struct{}{}
+	poll := func(publish bool) error {
+		var after *pageKey
+		for {
+			q := c.
+				Where("commit_time > ?", newest.Add(-pollLookback)).
+				Order("commit_time, shard_id").
+				Limit(pollPageSize)
+			if after != nil {
+				q.Where("(commit_time > ? OR (commit_time = ? AND shard_id > ?))", after.CommitTime, after.CommitTime, after.ID)
+			}
+			var res relationTuples
+			if err := q.All(&res); err != nil {
+				return sqlcon.HandleError(err)
+			}
+
+			names := p.namespaceNames()
+			for _, r := range res {
+				key := pageKey{CommitTime: r.CommitTime, ID: r.ID}
+				after = &key
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				if r.CommitTime.After(newest) {
+					newest = r.CommitTime
+				}
+				if !publish {
+					continue
+				}
+				if rt, err := r.toInternal(ctx, names); err == nil {
+					// Tuples of deleted namespaces can't be resolved.
+					f(&persistence.Change{NetworkID: r.NetworkID, RelationTuple: rt})
+				}
+			}
+			if len(res) < pollPageSize {
+				break
+			}
+		}
+
+		for key := range seen {
+			if !key.CommitTime.After(newest.Add(-pollLookback)) {
+				delete(seen, key)
+			}
+		}
+		return nil
+	}
+
+	// The rows within the lookback were written before the call.
+	if err := poll(false); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.d.Config(ctx).ChangeFeedPollInterval()):
+		}
+		if err := poll(true); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+var _ persistence.SnapshotCache = &Persister{}
+
+type queryCacheEntry struct {
+	Value string `db:"value"`
+}
+
+func (queryCacheEntry) TableName(_ context.Context) string {
+	return "keto_query_cache"
+}
+
+// Values are cached for the snapshot token of the network. It is the commit
+// sequence of the latest change, so every write and deletion makes the cached
+// values of the earlier snapshots obsolete.
+
+// cacheSnapshot parses the snapshot token into the snapshot column.
+func cacheSnapshot(snapshot string) (int64, error) {
+	return parseSnapshotToken(snapshot)
+}
+
+func cacheKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+func (p *Persister) GetCachedValue(ctx context.Context, key, snapshot string) ([]byte, bool, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.GetCachedValue")
+	defer span.End()
+
+	at, err := cacheSnapshot(snapshot)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var res []queryCacheEntry
+	if err := p.QueryWithNetwork(ctx).
+		Where("cache_key = ?", cacheKey(key)).
+		Where("snapshot = ?", at).
+		Limit(1).
+		All(&res); err != nil {
+		return nil, false, sqlcon.HandleError(err)
+	}
+	if len(res) == 0 {
+		return nil, false, nil
+	}
+	return []byte(res[0].Value), true, nil
+}
+
+func (p *Persister) SetCachedValue(ctx context.Context, key, snapshot string, value []byte) error {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.SetCachedValue")
+	defer span.End()
+
+	at, err := cacheSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = p.Transaction(ctx, func(ctx context.Context, c *pop.Connection) error {
+		// Entries of past snapshots are never read again.
+		if err := c.RawQuery(
+			"DELETE FROM keto_query_cache WHERE nid = ? AND (cache_key = ? OR snapshot < ?)",
+			p.NetworkID(ctx), cacheKey(key), at,
+		).Exec(); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		return sqlcon.HandleError(c.RawQuery(
+			"INSERT INTO keto_query_cache (nid, cache_key, snapshot, value) VALUES (?, ?, ?, ?)",
+			p.NetworkID(ctx), cacheKey(key), at, string(value),
+		).Exec())
+	})
+	if errors.Is(err, sqlcon.ErrUniqueViolation) {
+		// Another request cached the same value concurrently.
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ory/x/networkx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestSnapshotCache(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			tuple := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+
+			snapshot := func(t *testing.T, p *sql.Persister) string {
+				s, err := p.SnapshotToken(ctx)
+				require.NoError(t, err)
+				return s
+			}
+			get := func(t *testing.T, key, snapshot string) (string, bool) {
+				v, ok, err := p.GetCachedValue(ctx, key, snapshot)
+				require.NoError(t, err)
+				return string(v), ok
+			}
+
+			t.Run("case=every write advances the snapshot", func(t *testing.T) {
+				s0 := snapshot(t, p)
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				s1 := snapshot(t, p)
+				assert.NotEqual(t, s0, s1)
+
+				require.NoError(t, p.DeleteRelationTuples(ctx, tuple))
+				s2 := snapshot(t, p)
+				assert.NotEqual(t, s1, s2)
+
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				s3 := snapshot(t, p)
+				require.NoError(t, p.DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "n"}))
+				assert.NotEqual(t, s3, snapshot(t, p))
+			})
+
+			t.Run("case=values are only returned for their snapshot", func(t *testing.T) {
+				s := snapshot(t, p)
+				_, ok := get(t, "key", s)
+				assert.False(t, ok)
+
+				require.NoError(t, p.SetCachedValue(ctx, "key", s, []byte("value")))
+				v, ok := get(t, "key", s)
+				assert.True(t, ok)
+				assert.Equal(t, "value", v)
+
+				require.NoError(t, p.SetCachedValue(ctx, "key", s, []byte("updated")))
+				v, _ = get(t, "key", s)
+				assert.Equal(t, "updated", v)
+
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				_, ok = get(t, "key", snapshot(t, p))
+				assert.False(t, ok)
+			})
+
+			t.Run("case=snapshots are per network", func(t *testing.T) {
+				n1 := networkx.NewNetwork()
+				require.NoError(t, p.Connection(ctx).Create(n1))
+				p1, err := sql.NewPersister(ctx, reg, n1.ID)
+				require.NoError(t, err)
+
+				assert.Equal(t, "0", snapshot(t, p1))
+				s := snapshot(t, p)
+				require.NoError(t, p.SetCachedValue(ctx, "key", s, []byte("value")))
+
+				_, ok, err := p1.GetCachedValue(ctx, "key", s)
+				require.NoError(t, err)
+				assert.False(t, ok)
+			})
+
+			t.Run("case=rejects malformed snapshots", func(t *testing.T) {
+				_, _, err := p.GetCachedValue(ctx, "key", "not a snapshot")
+				assert.Error(t, err)
+			})
+		})
+	}
+}
@@ -7,13 +7,21 @@ import (
 
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
+	"github.com/ory/herodot"
 	"github.com/ory/x/sqlcon"
 	"github.com/pkg/errors"
 
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
 	"github.com/ory/keto/internal/relationtuple"
 	"github.com/ory/keto/internal/x"
 )
 
+var (
+	_ relationtuple.Counter       = &Persister{}
+	_ relationtuple.ObjectsGetter = &Persister{}
+)
+
 type (
 	RelationTuple struct {
 		// An ID field is required to make pop happy. The actual ID is a composite primary key.
@@ -27,6 +35,8 @@ type (
 		SubjectSetObject      sql.NullString `db:"subject_set_object"`
 		SubjectSetRelation    sql.NullString `db:"subject_set_relation"`
 		CommitTime            time.Time      `db:"commit_time"`
+		// Seq is the commit sequence of the transaction that wrote the tuple.
+		Seq int64 `db:"seq"`
 	}
 	relationTuples []*RelationTuple
 )
@@ -39,12 +49,12 @@ func (RelationTuple) TableName(_ context.Context) string {
 	return "keto_relation_tuples"
 }
 
-func (r *RelationTuple) toInternal(ctx context.Context, p *Persister) (*relationtuple.InternalRelationTuple, error) {
+func (r *RelationTuple) toInternal(ctx context.Context, names *namespaceNames) (*relationtuple.InternalRelationTuple, error) {
 	if r == nil {
 		return nil, nil
 	}
 
-	n, err := p.GetNamespaceByID(ctx, r.NamespaceID)
+	n, err := names.name(ctx, r.NamespaceID)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +62,7 @@ func (r *RelationTuple) toInternal(ctx context.Context, p *Persister) (*relation
 	rt := &relationtuple.InternalRelationTuple{
 		Relation:  r.Relation,
 		Object:    r.Object,
-		Namespace: n.Name,
+		Namespace: n,
 	}
 
 	if r.SubjectID.Valid {
@@ -60,16 +70,12 @@ func (r *RelationTuple) toInternal(ctx context.Context, p *Persister) (*relation
 			ID: r.SubjectID.String,
 		}
 	} else {
-		n, err := p.GetNamespaceByID(ctx, r.SubjectSetNamespaceID.Int32)
-		if err != nil {
-			return nil, err
-		}
-		sn, err := p.GetNamespaceByID(ctx, n.ID)
+		sn, err := names.name(ctx, r.SubjectSetNamespaceID.Int32)
 		if err != nil {
 			return nil, err
 		}
 		rt.Subject = &relationtuple.SubjectSet{
-			Namespace: sn.Name,
+			Namespace: sn,
 			Object:    r.SubjectSetObject.String,
 			Relation:  r.SubjectSetRelation.String,
 		}
@@ -78,18 +84,18 @@ func (r *RelationTuple) toInternal(ctx context.Context, p *Persister) (*relation
 	return rt, nil
 }
 
-func (r *RelationTuple) insertSubject(ctx context.Context, p *Persister, s relationtuple.Subject) error {
+func (r *RelationTuple) insertSubject(ctx context.Context, p *Persister, opts *namespace.Options, s relationtuple.Subject) error {
 	switch st := s.(type) {
 	case *relationtuple.SubjectID:
 		r.SubjectID = sql.NullString{
-			String: st.ID,
+			String: opts.Normalize(st.ID),
 			Valid:  true,
 		}
 		r.SubjectSetNamespaceID = sql.NullInt32{}
 		r.SubjectSetObject = sql.NullString{}
 		r.SubjectSetRelation = sql.NullString{}
 	case *relationtuple.SubjectSet:
-		n, err := p.GetNamespaceByName(ctx, st.Namespace)
+		n, setOpts, err := p.namespaceOptions(ctx, st.Namespace)
 		if err != nil {
 			return err
 		}
@@ -100,7 +106,7 @@ func (r *RelationTuple) insertSubject(ctx context.Context, p *Persister, s relat
 			Valid: true,
 		}
 		r.SubjectSetObject = sql.NullString{
-			String: st.Object,
+			String: setOpts.Normalize(st.Object),
 			Valid:  true,
 		}
 		r.SubjectSetRelation = sql.NullString{
@@ -115,16 +121,16 @@ func (r *RelationTuple) FromInternal(ctx context.Context, p *Persister, rt *rela
 	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.FromInternal")
 	defer span.End()
 
-	n, err := p.GetNamespaceByName(ctx, rt.Namespace)
+	n, opts, err := p.namespaceOptions(ctx, rt.Namespace)
 	if err != nil {
 		return err
 	}
 
 	r.NamespaceID = n.ID
-	r.Object = rt.Object
+	r.Object = opts.Normalize(rt.Object)
 	r.Relation = rt.Relation
 
-	return r.insertSubject(ctx, p, rt.Subject)
+	return r.insertSubject(ctx, p, opts, rt.Subject)
 }
 
 func (p *Persister) InsertRelationTuple(ctx context.Context, rel *relationtuple.InternalRelationTuple) error {
@@ -134,41 +140,92 @@ func (p *Persister) InsertRelationTuple(ctx context.Context, rel *relationtuple.
 	if rel.Subject == nil {
 		return errors.WithStack(relationtuple.ErrNilSubject)
 	}
+	if err := rel.CheckLengths(relationtuple.MaxLengths(p.d.Config(ctx).MaxLengths())); err != nil {
+		return err
+	}
 
 	rt := &RelationTuple{
-		ID:         uuid.Must(uuid.NewV4()),
-		CommitTime: time.Now(),
+		ID: uuid.Must(uuid.NewV4()),
 	}
 	if err := rt.FromInternal(ctx, p, rel); err != nil {
 		return err
 	}
 
-	if err := sqlcon.HandleError(
-		p.CreateWithNetwork(ctx, rt),
-	); err != nil {
+	return p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+		commitTime, seq, err := p.nextCommit(ctx)
+		if err != nil {
+			return err
+		}
+		rt.CommitTime, rt.Seq = commitTime, seq
+
+		if err := p.checkMaxDepth(ctx, rel); err != nil {
+			return err
+		}
+		if err := p.checkTenantBoundary(ctx, rel); err != nil {
+			return err
+		}
+		if p.d.Config(ctx).MultiPrimary() {
+			if err := p.upsertRelationTuple(ctx, rt); err != nil {
+				return err
+			}
+			// The tuple might exist already, so its labels are replaced.
+			if err := p.setLabels(ctx, rt.ID, rel.Labels); err != nil {
+				return err
+			}
+			return p.recordStored(ctx, rt, rel.Labels)
+		}
+
+		if err := sqlcon.HandleError(
+			p.CreateWithNetwork(ctx, rt),
+		); err != nil {
+			return err
+		}
+		if len(rel.Labels) > 0 {
+			if err := p.setLabels(ctx, rt.ID, rel.Labels); err != nil {
+				return err
+			}
+		}
+		return p.recordStored(ctx, rt, rel.Labels)
+	})
+}
+
+// recordStored adds the stored tuple to the write receipt of the context, if
+// there is one.
+func (p *Persister) recordStored(ctx context.Context, rt *RelationTuple, labels map[string]string) error {
+	receipt := relationtuple.WriteReceiptFromContext(ctx)
+	if receipt == nil {
+		return nil
+	}
+	stored, err := rt.toInternal(ctx, p.namespaceNames())
+	if err != nil {
 		return err
 	}
+	stored.Labels = labels
+	receipt.Record(&relationtuple.StoredRelationTuple{InternalRelationTuple: stored, ID: rt.ID, CommitTime: rt.CommitTime})
 	return nil
 }
 
-func (p *Persister) whereSubject(ctx context.Context, q *pop.Query, sub relationtuple.Subject) error {
+// whereSubject normalizes subject IDs with the options of the namespace of the
+// relation tuples, and subject set objects with the options of their own
+// namespace.
+func (p *Persister) whereSubject(ctx context.Context, q *pop.Query, opts *namespace.Options, sub relationtuple.Subject) error {
 	switch s := sub.(type) {
 	case *relationtuple.SubjectID:
 		q.
-			Where("subject_id = ?", s.ID).
+			Where("subject_id = ?", opts.Normalize(s.ID)).
 			// NULL checks to leverage partial indexes
 			Where("subject_set_namespace_id IS NULL").
 			Where("subject_set_object IS NULL").
 			Where("subject_set_relation IS NULL")
 	case *relationtuple.SubjectSet:
-		n, err := p.GetNamespaceByName(ctx, s.Namespace)
+		n, setOpts, err := p.namespaceOptions(ctx, s.Namespace)
 		if err != nil {
 			return err
 		}
 
 		q.
 			Where("subject_set_namespace_id = ?", n.ID).
-			Where("subject_set_object = ?", s.Object).
+			Where("subject_set_object = ?", setOpts.Normalize(s.Object)).
 			Where("subject_set_relation = ?", s.Relation).
 			// NULL checks to leverage partial indexes
 			Where("subject_id IS NULL")
@@ -178,25 +235,34 @@ func (p *Persister) whereSubject(ctx context.Context, q *pop.Query, sub relation
 	return nil
 }
 
+// whereQuery normalizes the object and subject ID only if the query names the
+// namespace.
 func (p *Persister) whereQuery(ctx context.Context, q *pop.Query, rq *relationtuple.RelationQuery) error {
+	opts := &namespace.Options{}
 	if rq.Namespace != "" {
-		n, err := p.GetNamespaceByName(ctx, rq.Namespace)
+		n, nOpts, err := p.namespaceOptions(ctx, rq.Namespace)
 		if err != nil {
 			return err
 		}
 		q.Where("namespace_id = ?", n.ID)
+		opts = nOpts
 	}
 	if rq.Object != "" {
-		q.Where("object = ?", rq.Object)
+		q.Where("object = ?", opts.Normalize(rq.Object))
 	}
 	if rq.Relation != "" {
 		q.Where("relation = ?", rq.Relation)
 	}
 	if s := rq.Subject(); s != nil {
-		if err := p.whereSubject(ctx, q, s); err != nil {
+		if err := p.whereSubject(ctx, q, opts, s); err != nil {
 			return err
 		}
 	}
+	if _, ok := snapshotSeq(ctx); ok && len(rq.Labels) > 0 {
+		// The deletion log does not keep the labels.
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).WithReason("Relation tuples at a snapshot can't be filtered by labels.").WithDetail(errorcode.DetailField, "labels"))
+	}
+	whereLabels(q, rq.Labels)
 	return nil
 }
 
@@ -206,24 +272,23 @@ func (p *Persister) DeleteRelationTuples(ctx context.Context, rs ...*relationtup
 
 	return p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
 		for _, r := range rs {
-			n, err := p.GetNamespaceByName(ctx, r.Namespace)
+			n, opts, err := p.namespaceOptions(ctx, r.Namespace)
 			if err != nil {
 				return err
 			}
 
 			q := p.QueryWithNetwork(ctx).
 				Where("namespace_id = ?", n.ID).
-				Where("object = ?", r.Object).
+				Where("object = ?", opts.Normalize(r.Object)).
 				Where("relation = ?", r.Relation)
-			if err := p.whereSubject(ctx, q, r.Subject); err != nil {
+			if err := p.whereSubject(ctx, q, opts, r.Subject); err != nil {
 				return err
 			}
 
-			if err := q.Delete(&RelationTuple{}); err != nil {
+			if err := p.deleteRelationTuples(ctx, q); err != nil {
 				return err
 			}
 		}
-
 		return nil
 	})
 }
@@ -239,8 +304,7 @@ func (p *Persister) DeleteAllRelationTuples(ctx context.Context, query *relation
 			return err
 		}
 
-		var res relationTuples
-		return sqlQuery.Delete(&res)
+		return p.deleteRelationTuples(ctx, sqlQuery)
 	})
 }
 
@@ -248,33 +312,110 @@ func (p *Persister) GetRelationTuples(ctx context.Context, query *relationtuple.
 	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.GetRelationTuples")
 	defer span.End()
 
-	pagination, err := internalPaginationFromOptions(options...)
+	return p.listRelationTuples(ctx, func(q *pop.Query) error {
+		return p.whereQuery(ctx, q, query)
+	}, options...)
+}
+
+// GetRelationTuplesOfObjects returns a page of the relation tuples that match
+// the query and have any of the objects, which are normalized like the object
+// of the query.
+func (p *Persister) GetRelationTuplesOfObjects(ctx context.Context, query *relationtuple.RelationQuery, objects []string, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.GetRelationTuplesOfObjects")
+	defer span.End()
+
+	if query.Namespace == "" {
+		return nil, "", errors.WithStack(herodot.ErrBadRequest.WithError("the namespace is required").WithDetail(errorcode.DetailField, "namespace"))
+	}
+	if len(objects) == 0 {
+		return nil, "", nil
+	}
+	_, opts, err := p.namespaceOptions(ctx, query.Namespace)
 	if err != nil {
 		return nil, "", err
 	}
+	args := make([]interface{}, len(objects))
+	for i, o := range objects {
+		args[i] = opts.Normalize(o)
+	}
 
-	sqlQuery := p.QueryWithNetwork(ctx).
-		Order("nid, namespace_id, object, relation, subject_id, subject_set_namespace_id, subject_set_object, subject_set_relation, commit_time").
-		Paginate(pagination.Page, pagination.PerPage)
+	return p.listRelationTuples(ctx, func(q *pop.Query) error {
+		q.Where("object IN (?)", args...)
+		return p.whereQuery(ctx, q, query)
+	}, options...)
+}
 
-	err = p.whereQuery(ctx, sqlQuery, query)
+// listRelationTuples returns a page of the relation tuples matching where.
+func (p *Persister) listRelationTuples(ctx context.Context, where func(*pop.Query) error, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	pagination, err := internalPaginationFromOptions(options...)
 	if err != nil {
 		return nil, "", err
 	}
-	var res relationTuples
-	if err := sqlQuery.All(&res); err != nil {
-		return nil, "", sqlcon.HandleError(err)
+	at, atSnapshot := snapshotSeq(ctx)
+	if atSnapshot && !pagination.defaultOrder() {
+		// The restored tuples are merged in by their commit sequence.
+		return nil, "", errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.OrderInvalid).WithReason("Relation tuples at a snapshot can only be listed by commit time."))
+	}
+
+	// Each segment of the order is read with one query, until one more row
+	// than requested was fetched to know whether there is a next page.
+	var (
+		res      relationTuples
+		segments []int
+	)
+	start := 0
+	if pagination.After != nil {
+		start = pagination.After.Segment
+	}
+	for seg := start; seg < len(pagination.segments()) && len(res) <= pagination.PerPage; seg++ {
+		limit := pagination.PerPage + 1 - len(res)
+		sqlQuery := p.QueryWithNetwork(ctx).Limit(limit)
+		pagination.query(sqlQuery, seg)
+		if err := where(sqlQuery); err != nil {
+			return nil, "", err
+		}
+		if p.d.Config(ctx).MultiPrimary() {
+			whereNotTombstoned(sqlQuery)
+		}
+		whereSnapshot(ctx, sqlQuery)
+
+		var segRes relationTuples
+		if err := sqlQuery.All(&segRes); err != nil {
+			return nil, "", sqlcon.HandleError(err)
+		}
+		if atSnapshot {
+			restoreQuery := p.QueryWithNetwork(ctx).Limit(limit)
+			pagination.query(restoreQuery, seg)
+			restored, err := p.restoredRelationTuples(ctx, restoreQuery, where, at)
+			if err != nil {
+				return nil, "", err
+			}
+			segRes = mergeBySeq(segRes, restored, limit)
+		}
+		res = append(res, segRes...)
+		for range segRes {
+			segments = append(segments, seg)
+		}
+	}
+
+	nextPageToken := ""
+	if len(res) > pagination.PerPage {
+		res = res[:pagination.PerPage]
+		last := len(res) - 1
+		nextPageToken = pagination.encodeNextPageToken(newPageKey(res[last], segments[last]))
 	}
 
-	nextPageToken := pagination.encodeNextPageToken()
-	if sqlQuery.Paginator.Page >= sqlQuery.Paginator.TotalPages {
-		nextPageToken = ""
+	labels, err := p.getLabels(ctx, res)
+	if err != nil {
+		return nil, "", err
 	}
 
+	names := p.namespaceNames()
 	internalRes := make([]*relationtuple.InternalRelationTuple, 0, len(res))
 	for _, r := range res {
-		if rt, err := r.toInternal(ctx, p); err == nil {
+		if rt, err := r.toInternal(ctx, names); err == nil {
 			// Ignore error here, which stems from a deleted namespace.
+			rt.Labels = labels[r.ID]
 			internalRes = append(internalRes, rt)
 		}
 	}
@@ -282,6 +423,37 @@ func (p *Persister) GetRelationTuples(ctx context.Context, query *relationtuple.
 	return internalRes, nextPageToken, nil
 }
 
+func (p *Persister) CountRelationTuples(ctx context.Context, query *relationtuple.RelationQuery) (int64, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.CountRelationTuples")
+	defer span.End()
+
+	sqlQuery := p.QueryWithNetwork(ctx)
+	if err := p.whereQuery(ctx, sqlQuery, query); err != nil {
+		return 0, err
+	}
+	if p.d.Config(ctx).MultiPrimary() {
+		whereNotTombstoned(sqlQuery)
+	}
+	whereSnapshot(ctx, sqlQuery)
+
+	n, err := sqlQuery.Count(&RelationTuple{})
+	if err != nil {
+		return 0, sqlcon.HandleError(err)
+	}
+	if at, ok := snapshotSeq(ctx); ok {
+		restoreQuery := p.QueryWithNetwork(ctx)
+		if err := p.whereQuery(ctx, restoreQuery, query); err != nil {
+			return 0, err
+		}
+		restored, err := restoreQuery.Where("deleted_seq > ?", at).Where("seq <= ?", at).Count(&TupleDeletion{})
+		if err != nil {
+			return 0, sqlcon.HandleError(err)
+		}
+		n += restored
+	}
+	return int64(n), nil
+}
+
 func (p *Persister) WriteRelationTuples(ctx context.Context, rs ...*relationtuple.InternalRelationTuple) error {
 	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.WriteRelationTuples")
 	defer span.End()
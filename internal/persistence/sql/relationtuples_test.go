@@ -3,18 +3,26 @@ package sql_test
 import (
 	"context"
 	stdSql "database/sql"
+	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ory/x/networkx"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
 	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
 	"github.com/ory/keto/internal/x/dbx"
 )
 
@@ -114,3 +122,127 @@ func TestRelationTupleSubjectTypeCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestCommitTimeIsMonotonic(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			c, err := reg.PopConnection(ctx)
+			require.NoError(t, err)
+
+			// a tuple written by another server with a clock that is one hour ahead
+			skewed := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+			other := rt(&networkx.Network{ID: p.NetworkID(ctx)}, true, false, false, false)
+			other.CommitTime = skewed
+			require.NoError(t, c.Create(other))
+
+			// The latest commit time is read once for the batch.
+			require.NoError(t, p.WriteRelationTuples(ctx,
+				&relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}},
+				&relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectID{ID: "t"}},
+			))
+
+			var written []sql.RelationTuple
+			require.NoError(t, c.Where("shard_id != ?", other.ID).Order("commit_time").All(&written))
+			require.Len(t, written, 2)
+			assert.True(t, written[0].CommitTime.After(skewed), "%s is not after %s", written[0].CommitTime, skewed)
+			assert.True(t, written[1].CommitTime.After(written[0].CommitTime))
+		})
+	}
+}
+
+func TestPaginationWithConcurrentWriters(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+
+			const writers, writes = 4, 10
+			var eg errgroup.Group
+			for w := 0; w < writers; w++ {
+				w := w
+				eg.Go(func() error {
+					for i := 0; i < writes; i++ {
+						// Transactions that take longer to commit than
+						// later ones must not be skipped by readers.
+						if err := p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+							if err := p.WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+								Namespace: "n",
+								Object:    fmt.Sprintf("%d-%d", w, i),
+								Relation:  "r",
+								Subject:   &relationtuple.SubjectID{ID: "s"},
+							}); err != nil {
+								return err
+							}
+							time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+							return nil
+						}); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			}
+			done := make(chan error)
+			go func() { done <- eg.Wait() }()
+
+			// The reader pages through the tuples one by one while they are
+			// written. The last page has no next token, so it is read again
+			// until there are more tuples.
+			var (
+				read    []string
+				token   string
+				writing = true
+			)
+			for {
+				select {
+				case err := <-done:
+					require.NoError(t, err)
+					writing = false
+				default:
+				}
+				var (
+					rs   []*relationtuple.InternalRelationTuple
+					next string
+				)
+				// Each page is read in a transaction, as SQLite does not
+				// allow reads of tables that are locked by a write.
+				require.NoError(t, p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) (err error) {
+					rs, next, err = p.GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "n"}, x.WithToken(token), x.WithSize(1))
+					return err
+				}))
+				if len(rs) == 1 && (len(read) == 0 || read[len(read)-1] != rs[0].Object) {
+					read = append(read, rs[0].Object)
+				}
+				if next != "" {
+					token = next
+				} else if !writing {
+					break
+				}
+			}
+
+			expected := make([]string, 0, writers*writes)
+			for w := 0; w < writers; w++ {
+				for i := 0; i < writes; i++ {
+					expected = append(expected, fmt.Sprintf("%d-%d", w, i))
+				}
+			}
+			assert.ElementsMatch(t, expected, read)
+		})
+	}
+}
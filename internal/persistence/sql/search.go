@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+var _ relationtuple.Searcher = &Persister{}
+
+// likeEscaper escapes the wildcards of LIKE patterns. The escape character
+// is not a backslash, as MySQL treats it specially in string literals.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// likePattern returns the pattern matching s according to m. Whether the
+// match is case sensitive depends on the database and its collation.
+func likePattern(s string, m relationtuple.SearchMatch) (string, error) {
+	s = likeEscaper.Replace(s)
+	switch m {
+	case "", relationtuple.SearchMatchPrefix:
+		return s + "%", nil
+	case relationtuple.SearchMatchSubstring:
+		return "%" + s + "%", nil
+	}
+	return "", errors.WithStack(herodot.ErrBadRequest.WithErrorf("unknown match %q, expected %q or %q", m, relationtuple.SearchMatchPrefix, relationtuple.SearchMatchSubstring))
+}
+
+func (p *Persister) SearchRelationTuples(ctx context.Context, query *relationtuple.SearchQuery, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.SearchRelationTuples")
+	defer span.End()
+
+	if query.Object == "" && query.Subject == "" {
+		return nil, "", errors.WithStack(herodot.ErrBadRequest.WithError("either the object or the subject to search for is required"))
+	}
+
+	return p.listRelationTuples(ctx, func(q *pop.Query) error {
+		if err := p.whereQuery(ctx, q, &relationtuple.RelationQuery{Namespace: query.Namespace}); err != nil {
+			return err
+		}
+		if query.Object != "" {
+			pattern, err := likePattern(query.Object, query.Match)
+			if err != nil {
+				return err
+			}
+			q.Where("object LIKE ? ESCAPE '!'", pattern)
+		}
+		if query.Subject != "" {
+			pattern, err := likePattern(query.Subject, query.Match)
+			if err != nil {
+				return err
+			}
+			q.Where("(subject_id LIKE ? ESCAPE '!' OR subject_set_object LIKE ? ESCAPE '!')", pattern, pattern)
+		}
+		return nil
+	}, options...)
+}
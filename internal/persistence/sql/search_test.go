@@ -0,0 +1,122 @@
+package sql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ory/herodot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestSearchRelationTuples(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 0}, {Name: "groups", ID: 1}}))
+
+			tuples := []*relationtuple.InternalRelationTuple{
+				{Namespace: "files", Object: "reports/2022", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "user-alice"}},
+				{Namespace: "files", Object: "reports/2023", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: "groups", Object: "team-alice", Relation: "member"}},
+				{Namespace: "files", Object: "100%_done", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "bob"}},
+				{Namespace: "groups", Object: "reports", Relation: "member", Subject: &relationtuple.SubjectID{ID: "user-bob"}},
+			}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuples...))
+
+			s := reg.RelationTupleSearcher()
+			require.NotNil(t, s)
+
+			for _, tc := range []struct {
+				desc     string
+				query    relationtuple.SearchQuery
+				expected []int
+			}{
+				{
+					desc:     "object prefix",
+					query:    relationtuple.SearchQuery{Object: "reports/"},
+					expected: []int{0, 1},
+				},
+				{
+					desc:     "object prefix in namespace",
+					query:    relationtuple.SearchQuery{Namespace: "groups", Object: "reports"},
+					expected: []int{3},
+				},
+				{
+					desc:     "subject prefix",
+					query:    relationtuple.SearchQuery{Subject: "user-"},
+					expected: []int{0, 3},
+				},
+				{
+					desc:     "subject substring matches subject sets",
+					query:    relationtuple.SearchQuery{Subject: "alice", Match: relationtuple.SearchMatchSubstring},
+					expected: []int{0, 1},
+				},
+				{
+					desc:     "object and subject",
+					query:    relationtuple.SearchQuery{Object: "reports", Subject: "team", Match: relationtuple.SearchMatchSubstring},
+					expected: []int{1},
+				},
+				{
+					desc:     "wildcards are escaped",
+					query:    relationtuple.SearchQuery{Object: "100%_", Match: relationtuple.SearchMatchSubstring},
+					expected: []int{2},
+				},
+				{
+					desc:  "wildcards only match themselves",
+					query: relationtuple.SearchQuery{Object: "report_"},
+				},
+			} {
+				t.Run("case="+tc.desc, func(t *testing.T) {
+					res, next, err := s.SearchRelationTuples(ctx, &tc.query)
+					require.NoError(t, err)
+					assert.Equal(t, "", next)
+
+					expected := make([]*relationtuple.InternalRelationTuple, len(tc.expected))
+					for i, j := range tc.expected {
+						expected[i] = tuples[j]
+					}
+					assert.ElementsMatch(t, expected, res)
+				})
+			}
+
+			t.Run("case=paginates", func(t *testing.T) {
+				res, next, err := s.SearchRelationTuples(ctx, &relationtuple.SearchQuery{Object: "reports"}, x.WithSize(2))
+				require.NoError(t, err)
+				require.NotEqual(t, "", next)
+				assert.Equal(t, tuples[:2], res)
+
+				res, next, err = s.SearchRelationTuples(ctx, &relationtuple.SearchQuery{Object: "reports"}, x.WithSize(2), x.WithToken(next))
+				require.NoError(t, err)
+				assert.Equal(t, "", next)
+				assert.Equal(t, tuples[3:], res)
+			})
+
+			t.Run("case=requires a search term", func(t *testing.T) {
+				_, _, err := s.SearchRelationTuples(ctx, &relationtuple.SearchQuery{Namespace: "files"})
+				var he *herodot.DefaultError
+				require.ErrorAs(t, err, &he)
+				assert.Equal(t, http.StatusBadRequest, he.StatusCode())
+			})
+
+			t.Run("case=unknown match", func(t *testing.T) {
+				_, _, err := s.SearchRelationTuples(ctx, &relationtuple.SearchQuery{Object: "reports", Match: "fuzzy"})
+				var he *herodot.DefaultError
+				require.ErrorAs(t, err, &he)
+				assert.Equal(t, http.StatusBadRequest, he.StatusCode())
+			})
+		})
+	}
+}
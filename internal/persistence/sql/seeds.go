@@ -0,0 +1,73 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+var _ persistence.SeedStore = &Persister{}
+
+type Seed struct {
+	// An ID field is required to make pop happy. The network is the primary key.
+	ID        uuid.UUID `db:"nid"`
+	Digest    string    `db:"digest"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+func (Seed) TableName(_ context.Context) string {
+	return "keto_seeds"
+}
+
+func (p *Persister) ApplySeed(ctx context.Context, digest string, apply func(ctx context.Context) error) (status persistence.SeedStatus, appliedDigest string, err error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.ApplySeed")
+	defer span.End()
+
+	err = p.Transaction(ctx, func(ctx context.Context, c *pop.Connection) error {
+		var applied Seed
+		err := p.QueryWithNetwork(ctx).First(&applied)
+		if err == nil {
+			status, appliedDigest = persistence.SeedAlreadyApplied, applied.Digest
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return sqlcon.HandleError(err)
+		}
+
+		for _, model := range []interface{}{&RelationTuple{}, &NamespaceConfig{}} {
+			exists, err := p.QueryWithNetwork(ctx).Exists(model)
+			if err != nil {
+				return sqlcon.HandleError(err)
+			}
+			if exists {
+				status = persistence.SeedNotEmpty
+				return nil
+			}
+		}
+
+		// Concurrent instances seeding the same network all but one fail on
+		// the primary key.
+		seed := Seed{ID: p.NetworkID(ctx), Digest: digest, AppliedAt: time.Now().UTC().Truncate(time.Microsecond)}
+		if err := sqlcon.HandleError(c.Create(&seed)); err != nil {
+			return err
+		}
+		status = persistence.SeedApplied
+		return apply(ctx)
+	})
+	if errors.Is(err, sqlcon.ErrUniqueViolation) {
+		var applied Seed
+		if err := p.QueryWithNetwork(ctx).First(&applied); err != nil {
+			return "", "", sqlcon.HandleError(err)
+		}
+		return persistence.SeedAlreadyApplied, applied.Digest, nil
+	} else if err != nil {
+		return "", "", err
+	}
+	return status, appliedDigest, nil
+}
@@ -0,0 +1,93 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/luna-duclos/instrumentedsql"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+var slowQueriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "sql_slow_queries_total",
+	Help:      "The number of SQL statements that took longer than the slow query threshold, by the fingerprint of their shape.",
+}, []string{"fingerprint"})
+
+func init() {
+	prometheus.MustRegister(slowQueriesTotal)
+}
+
+var (
+	sqlLiterals     = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+	sqlPlaceholders = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlWhitespace   = regexp.MustCompile(`\s+`)
+)
+
+// queryShape replaces the literals, placeholders, and lists of placeholders
+// in the statement by a single placeholder, so that statements that only
+// differ in their arguments have the same shape. Its fingerprint is a short
+// hash of the shape that can be used as a metric label.
+func queryShape(query string) (shape, fingerprint string) {
+	shape = sqlLiterals.ReplaceAllString(query, "?")
+	shape = sqlPlaceholders.ReplaceAllString(shape, "(?)")
+	shape = strings.TrimSpace(sqlWhitespace.ReplaceAllString(shape, " "))
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(shape))
+	return shape, fmt.Sprintf("%016x", h.Sum64())
+}
+
+// NewSlowQueryLogger returns a logger for the instrumented SQL driver that
+// logs the statements that took longer than the configured threshold. The
+// driver has to omit the arguments, so that they are never passed to it.
+func NewSlowQueryLogger(d interface {
+	config.Provider
+	x.LoggerProvider
+}) instrumentedsql.Logger {
+	return instrumentedsql.LoggerFunc(func(ctx context.Context, op string, keyvals ...interface{}) {
+		var (
+			query    string
+			duration time.Duration
+			err      error
+		)
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			switch keyvals[i] {
+			case "query":
+				query, _ = keyvals[i+1].(string)
+			case "duration":
+				duration, _ = keyvals[i+1].(time.Duration)
+			case "err":
+				err, _ = keyvals[i+1].(error)
+			}
+		}
+		if query == "" {
+			return
+		}
+		enabled, threshold := d.Config(ctx).SlowQueryLog()
+		if !enabled || duration < threshold {
+			return
+		}
+
+		shape, fingerprint := queryShape(query)
+		slowQueriesTotal.WithLabelValues(fingerprint).Inc()
+
+		l := requestid.Logger(ctx, d.Logger()).
+			WithField("operation", op).
+			WithField("query_shape", shape).
+			WithField("query_fingerprint", fingerprint).
+			WithField("duration", duration)
+		if err != nil {
+			l = l.WithError(err)
+		}
+		l.Warn("Slow SQL statement.")
+	})
+}
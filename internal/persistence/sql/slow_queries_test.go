@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/driver/config"
+)
+
+type slowQueryDeps struct {
+	c *config.Config
+	l *logrusx.Logger
+}
+
+func (d *slowQueryDeps) Config(context.Context) *config.Config { return d.c }
+func (d *slowQueryDeps) Logger() *logrusx.Logger               { return d.l }
+
+func TestQueryShape(t *testing.T) {
+	for _, tc := range []struct{ query, shape string }{
+		{
+			query: "SELECT * FROM keto_relation_tuples WHERE nid = $1 AND object = $2 LIMIT 101",
+			shape: "SELECT * FROM keto_relation_tuples WHERE nid = ? AND object = ? LIMIT ?",
+		},
+		{
+			query: "SELECT *\n\tFROM t   WHERE id IN (?, ?,?) AND name = 'it''s'",
+			shape: "SELECT * FROM t WHERE id IN (?) AND name = ?",
+		},
+		{
+			query: "DELETE FROM t WHERE id IN ($1, $2, $3, $4)",
+			shape: "DELETE FROM t WHERE id IN (?)",
+		},
+	} {
+		shape, fingerprint := queryShape(tc.query)
+		assert.Equal(t, tc.shape, shape)
+		assert.Len(t, fingerprint, 16)
+	}
+
+	_, a := queryShape("SELECT * FROM t WHERE id IN ($1, $2)")
+	_, b := queryShape("SELECT * FROM t WHERE id IN ($1)")
+	_, c := queryShape("SELECT * FROM u WHERE id IN ($1)")
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestSlowQueryLogger(t *testing.T) {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	hook := &test.Hook{}
+	d := &slowQueryDeps{c: config.New(ctx, logrusx.New("test", "today"), cp), l: logrusx.New("test", "today", logrusx.WithHook(hook))}
+	require.NoError(t, d.c.Set(config.KeySlowQueryLogEnabled, true))
+	require.NoError(t, d.c.Set(config.KeySlowQueryLogThreshold, "50ms"))
+
+	l := NewSlowQueryLogger(d)
+	l.Log(ctx, "sql-conn-query", "query", "SELECT 1 WHERE a = $1", "err", nil, "duration", 10*time.Millisecond)
+	l.Log(ctx, "sql-tx-commit", "query", "", "err", nil, "duration", time.Second)
+	assert.Empty(t, hook.AllEntries())
+
+	l.Log(ctx, "sql-conn-query", "query", "SELECT 1 WHERE a = $1", "err", nil, "duration", 80*time.Millisecond)
+	require.Len(t, hook.AllEntries(), 1)
+	e := hook.LastEntry()
+	assert.Equal(t, "SELECT ? WHERE a = ?", e.Data["query_shape"])
+	assert.Equal(t, 80*time.Millisecond, e.Data["duration"])
+	assert.NotContains(t, e.Data, "args")
+
+	require.NoError(t, d.c.Set(config.KeySlowQueryLogEnabled, false))
+	l.Log(ctx, "sql-conn-query", "query", "SELECT 1", "err", nil, "duration", time.Second)
+	assert.Len(t, hook.AllEntries(), 1)
+}
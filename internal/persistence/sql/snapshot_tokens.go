@@ -0,0 +1,194 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/herodot"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+)
+
+var _ persistence.SnapshotReader = &Persister{}
+
+type snapshotSeqKey struct{}
+
+// A snapshot token is the commit sequence of the latest change of the network,
+// i.e. of the latest write or deletion, prefixed by its commit time. Reading
+// at a snapshot hides the tuples written after it and restores the tuples
+// deleted after it from the deletion log, so snapshots older than its
+// retention are refused. The upserts in multi-primary mode keep the commit
+// sequence of tuples that stay visible, so that reads at a snapshot are
+// repeatable.
+
+func (p *Persister) SnapshotToken(ctx context.Context) (string, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.SnapshotToken")
+	defer span.End()
+
+	changedAt, seq, err := p.latestCommit(ctx)
+	if err != nil {
+		return "", err
+	}
+	return formatSnapshotToken(changedAt, seq), nil
+}
+
+// NamespaceChangeToken returns the commit sequence of the latest change of
+// the tuples of the namespace, found in the tuples and the deletion log. The
+// horizon of the pruned deletions is its lower bound, so that the token does
+// not go back when the latest deletions are pruned.
+func (p *Persister) NamespaceChangeToken(ctx context.Context, namespace string) (string, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.NamespaceChangeToken")
+	defer span.End()
+
+	n, err := p.GetNamespaceByName(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	prunedSeq, err := p.deletionsPrunedSeq(ctx)
+	if err != nil {
+		return "", err
+	}
+	var written, deleted sql.NullInt64
+	if err := p.Connection(ctx).RawQuery("SELECT MAX(seq) FROM keto_relation_tuples WHERE nid = ? AND namespace_id = ?", p.NetworkID(ctx), n.ID).First(&written); err != nil {
+		return "", sqlcon.HandleError(err)
+	}
+	if err := p.Connection(ctx).RawQuery("SELECT MAX(deleted_seq) FROM keto_relation_tuple_deletions WHERE nid = ? AND namespace_id = ?", p.NetworkID(ctx), n.ID).First(&deleted); err != nil {
+		return "", sqlcon.HandleError(err)
+	}
+	latest := prunedSeq
+	for _, seq := range []sql.NullInt64{written, deleted} {
+		if seq.Int64 > latest {
+			latest = seq.Int64
+		}
+	}
+	return strconv.FormatInt(latest, 10), nil
+}
+
+func (p *Persister) AtSnapshot(ctx context.Context, token string) (context.Context, error) {
+	seq, err := parseSnapshotToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commits after the latest one would show up in the snapshot later, e.g.
+	// if the token is read from a replica that is behind.
+	_, latest, err := p.latestCommit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if seq > latest {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SnapshotTokenInvalid).
+			WithReasonf("The snapshot token %q is ahead of the latest change, so the snapshot can't be read yet.", token))
+	}
+	prunedSeq, err := p.deletionsPrunedSeq(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if seq < prunedSeq {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SnapshotTokenExpired).
+			WithReasonf("The deletions since the snapshot token %q are no longer retained, so the snapshot can't be read.", token).
+			WithDetail(errorcode.DetailLimit, "snapshots.delta_retention"))
+	}
+	return context.WithValue(ctx, snapshotSeqKey{}, seq), nil
+}
+
+// latestCommitTime returns the latest commit time of the tuples of the
+// network, or the zero time if there are none.
+func (p *Persister) latestCommitTime(ctx context.Context) (time.Time, error) {
+	var latest RelationTuple
+	err := p.QueryWithNetwork(ctx).Order("commit_time DESC").First(&latest)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, sqlcon.HandleError(err)
+	}
+	return latest.CommitTime, nil
+}
+
+// formatSnapshotToken returns the token of the commit sequence. The commit
+// time only tells clients when the snapshot was taken.
+func formatSnapshotToken(changedAt time.Time, seq int64) string {
+	if seq == 0 {
+		return "0"
+	}
+	return strconv.FormatInt(changedAt.UnixNano(), 10) + "-" + strconv.FormatInt(seq, 10)
+}
+
+// parseSnapshotToken returns the commit sequence of the token. Tokens that
+// are only a commit time were issued before the commit sequences were
+// introduced, and are refused as expired.
+func parseSnapshotToken(token string) (int64, error) {
+	if token == "0" {
+		return 0, nil
+	}
+	if _, err := strconv.ParseInt(token, 10, 64); err == nil {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SnapshotTokenExpired).
+			WithReasonf("The snapshot token %q was issued before the commit sequences were introduced, download a full snapshot instead.", token))
+	}
+	nanos, seq, _ := strings.Cut(token, "-")
+	n, err := strconv.ParseInt(seq, 10, 64)
+	if _, tErr := strconv.ParseInt(nanos, 10, 64); err != nil || tErr != nil || n <= 0 {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.SnapshotTokenInvalid).WithReasonf("Malformed snapshot token %q.", token))
+	}
+	return n, nil
+}
+
+func snapshotSeq(ctx context.Context) (int64, bool) {
+	seq, ok := ctx.Value(snapshotSeqKey{}).(int64)
+	return seq, ok
+}
+
+func whereSnapshot(ctx context.Context, q *pop.Query) {
+	if seq, ok := snapshotSeq(ctx); ok {
+		q.Where("seq <= ?", seq)
+	}
+}
+
+// restoredRelationTuples returns the rows of the deletion log that match
+// where and were visible at the snapshot, as relation tuples.
+func (p *Persister) restoredRelationTuples(ctx context.Context, q *pop.Query, where func(*pop.Query) error, at int64) (relationTuples, error) {
+	if err := where(q); err != nil {
+		return nil, err
+	}
+	var deleted tupleDeletions
+	if err := q.Where("deleted_seq > ?", at).Where("seq <= ?", at).All(&deleted); err != nil {
+		return nil, sqlcon.HandleError(err)
+	}
+	rows := make(relationTuples, len(deleted))
+	for i, d := range deleted {
+		rows[i] = d.toRow()
+	}
+	return rows, nil
+}
+
+// mergeBySeq merges the rows, which are both sorted by commit sequence, commit
+// time and ID, and returns at most limit of them.
+func mergeBySeq(a, b relationTuples, limit int) relationTuples {
+	res := make(relationTuples, 0, len(a)+len(b))
+	for len(res) < limit && (len(a) > 0 || len(b) > 0) {
+		if len(b) == 0 || len(a) > 0 && lessBySeq(a[0], b[0]) {
+			res, a = append(res, a[0]), a[1:]
+		} else {
+			res, b = append(res, b[0]), b[1:]
+		}
+	}
+	return res
+}
+
+func lessBySeq(a, b *RelationTuple) bool {
+	if a.Seq != b.Seq {
+		return a.Seq < b.Seq
+	}
+	if !a.CommitTime.Equal(b.CommitTime) {
+		return a.CommitTime.Before(b.CommitTime)
+	}
+	return bytes.Compare(a.ID.Bytes(), b.ID.Bytes()) < 0
+}
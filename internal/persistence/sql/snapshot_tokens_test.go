@@ -0,0 +1,120 @@
+package sql_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestSnapshotReader(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			tuple := func(object string) *relationtuple.InternalRelationTuple {
+				return &relationtuple.InternalRelationTuple{Namespace: "n", Object: object, Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+			}
+			objects := func(t *testing.T, ctx context.Context) []string {
+				rs, _, err := p.GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "n"})
+				require.NoError(t, err)
+				objects := make([]string, len(rs))
+				for i, r := range rs {
+					objects[i] = r.Object
+				}
+				return objects
+			}
+
+			empty, err := p.SnapshotToken(ctx)
+			require.NoError(t, err)
+
+			require.NoError(t, p.WriteRelationTuples(ctx, tuple("a")))
+			first, err := p.SnapshotToken(ctx)
+			require.NoError(t, err)
+			require.NoError(t, p.WriteRelationTuples(ctx, tuple("b")))
+
+			second, err := p.SnapshotToken(ctx)
+			require.NoError(t, err)
+			require.NoError(t, p.DeleteRelationTuples(ctx, tuple("a")))
+			deleted, err := p.SnapshotToken(ctx)
+			require.NoError(t, err)
+			assert.NotEqual(t, second, deleted, "deletions advance the token")
+
+			for token, expected := range map[string][]string{empty: {}, first: {"a"}, second: {"a", "b"}, deleted: {"b"}} {
+				snapshotCtx, err := p.AtSnapshot(ctx, token)
+				require.NoError(t, err)
+				assert.Equal(t, expected, objects(t, snapshotCtx), "token %s", token)
+
+				n, err := p.CountRelationTuples(snapshotCtx, &relationtuple.RelationQuery{Namespace: "n"})
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(expected)), n, "token %s", token)
+			}
+			assert.Equal(t, []string{"b"}, objects(t, ctx))
+
+			t.Run("case=pages include restored tuples", func(t *testing.T) {
+				snapshotCtx, err := p.AtSnapshot(ctx, second)
+				require.NoError(t, err)
+				var objects []string
+				for page := ""; ; {
+					rs, next, err := p.GetRelationTuples(snapshotCtx, &relationtuple.RelationQuery{Namespace: "n"}, x.WithToken(page), x.WithSize(1))
+					require.NoError(t, err)
+					require.Len(t, rs, 1)
+					objects = append(objects, rs[0].Object)
+					if next == "" {
+						break
+					}
+					page = next
+				}
+				assert.Equal(t, []string{"a", "b"}, objects)
+			})
+
+			t.Run("case=rejects unsupported reads", func(t *testing.T) {
+				snapshotCtx, err := p.AtSnapshot(ctx, second)
+				require.NoError(t, err)
+				_, _, err = p.GetRelationTuples(snapshotCtx, &relationtuple.RelationQuery{Namespace: "n"}, x.WithOrder(x.OrderByObject, false))
+				assert.True(t, errorcode.Is(err, errorcode.OrderInvalid), "%+v", err)
+				_, _, err = p.GetRelationTuples(snapshotCtx, &relationtuple.RelationQuery{Namespace: "n", Labels: map[string]string{"k": "v"}})
+				assert.True(t, errorcode.Is(err, errorcode.RequestInvalid), "%+v", err)
+			})
+
+			t.Run("case=refuses tokens ahead of the latest change", func(t *testing.T) {
+				changedAt, seq, ok := strings.Cut(deleted, "-")
+				require.True(t, ok)
+				n, err := strconv.ParseInt(seq, 10, 64)
+				require.NoError(t, err)
+
+				_, err = p.AtSnapshot(ctx, changedAt+"-"+strconv.FormatInt(n+1, 10))
+				assert.True(t, errorcode.Is(err, errorcode.SnapshotTokenInvalid), "%+v", err)
+			})
+
+			t.Run("case=refuses tokens of commit times", func(t *testing.T) {
+				_, err = p.AtSnapshot(ctx, strconv.FormatInt(time.Now().UnixNano(), 10))
+				assert.True(t, errorcode.Is(err, errorcode.SnapshotTokenExpired), "%+v", err)
+			})
+
+			_, err = p.AtSnapshot(ctx, "not a token")
+			assert.True(t, errorcode.Is(err, errorcode.SnapshotTokenInvalid), "%+v", err)
+		})
+	}
+}
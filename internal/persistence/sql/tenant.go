@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+// checkTenantBoundary rejects a subject set pointing to an object of another
+// tenant, if the namespace of the relation tuple enforces its tenant
+// boundary. Subject sets of objects without a tenant are not rejected.
+func (p *Persister) checkTenantBoundary(ctx context.Context, rt *relationtuple.InternalRelationTuple) error {
+	set, ok := rt.Subject.(*relationtuple.SubjectSet)
+	if !ok {
+		return nil
+	}
+	_, opts, err := p.namespaceOptions(ctx, rt.Namespace)
+	if err != nil || opts.TenantBoundary == nil || opts.TenantBoundary.Relation == rt.Relation {
+		return err
+	}
+	_, setOpts, err := p.namespaceOptions(ctx, set.Namespace)
+	if err != nil || setOpts.TenantBoundary == nil {
+		return err
+	}
+
+	tenants, err := p.tenants(ctx, rt.Namespace, rt.Object, opts.TenantBoundary)
+	if err != nil || len(tenants) == 0 {
+		return err
+	}
+	setTenants, err := p.tenants(ctx, set.Namespace, set.Object, setOpts.TenantBoundary)
+	if err != nil || len(setTenants) == 0 {
+		return err
+	}
+	for _, t := range setTenants {
+		for _, u := range tenants {
+			if t == u {
+				return nil
+			}
+		}
+	}
+
+	if !opts.TenantBoundary.Enforced() {
+		requestid.Logger(ctx, p.d.Logger()).
+			WithFields(rt.ToLoggerFields()).
+			Warn("Wrote a subject set of another tenant.")
+		return nil
+	}
+	return errors.WithStack(herodot.ErrBadRequest.
+		WithID(errorcode.TenantBoundaryCrossed).
+		WithReasonf("The object %q of namespace %q belongs to the tenants %v, but the subject set %s belongs to the tenants %v.", rt.Object, rt.Namespace, tenants, set, setTenants).
+		WithDetail(errorcode.DetailField, "subject_set"))
+}
+
+// tenants returns the sorted tenants of the object.
+func (p *Persister) tenants(ctx context.Context, ns, object string, b *namespace.TenantBoundary) ([]string, error) {
+	var tenants []string
+	query := &relationtuple.RelationQuery{Namespace: ns, Object: object, Relation: b.Relation}
+	for page := ""; ; {
+		rels, next, err := p.GetRelationTuples(ctx, query, x.WithToken(page), x.WithSize(p.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rels {
+			tenants = append(tenants, r.Subject.String())
+		}
+		if next == "" {
+			break
+		}
+		page = next
+	}
+	sort.Strings(tenants)
+	return tenants, nil
+}
@@ -0,0 +1,65 @@
+package sql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestTenantBoundaryOnWrite(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: json.RawMessage(`{"tenant_boundary": {"relation": "tenant"}}`)},
+		{ID: 1, Name: "groups", Config: json.RawMessage(`{"tenant_boundary": {"relation": "tenant"}}`)},
+		{ID: 2, Name: "flagged", Config: json.RawMessage(`{"tenant_boundary": {"relation": "tenant", "mode": "flag"}}`)},
+		{ID: 3, Name: "open"},
+	}))
+	m := reg.RelationTupleManager()
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+
+	require.NoError(t, m.WriteRelationTuples(ctx,
+		tuple("docs:report#tenant@acme"),
+		tuple("flagged:report#tenant@acme"),
+		tuple("groups:acme-staff#tenant@acme"),
+		tuple("groups:evil-staff#tenant@evil"),
+	))
+
+	t.Run("case=rejects subject sets of other tenants", func(t *testing.T) {
+		err := m.WriteRelationTuples(ctx, tuple("docs:report#viewer@groups:evil-staff#member"))
+		assert.True(t, errorcode.Is(err, errorcode.TenantBoundaryCrossed), "%+v", err)
+
+		res, _, err := m.GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "docs", Object: "report", Relation: "viewer"})
+		require.NoError(t, err)
+		assert.Empty(t, res)
+	})
+
+	t.Run("case=allows subject sets of the same tenant", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, tuple("docs:report#viewer@groups:acme-staff#member")))
+	})
+
+	t.Run("case=allows subject sets without tenant", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx,
+			tuple("docs:report#viewer@groups:new-staff#member"),
+			tuple("docs:draft#viewer@groups:evil-staff#member"),
+			tuple("docs:report#viewer@open:anything#member"),
+		))
+	})
+
+	t.Run("case=flag mode allows crossing subject sets", func(t *testing.T) {
+		require.NoError(t, m.WriteRelationTuples(ctx, tuple("flagged:report#viewer@groups:evil-staff#member")))
+	})
+}
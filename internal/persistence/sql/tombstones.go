@@ -0,0 +1,177 @@
+package sql
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+)
+
+// In multi-primary mode more than one region writes to the same replicated
+// database. To make concurrent writes commute, relation tuples get an ID
+// derived from their content, so that the same tuple written in two regions
+// ends up as the same row, and deletions leave a tombstone. A tuple is only
+// visible if it was written after its latest tombstone, so the last write
+// wins regardless of the order in which rows are replicated. Writes never
+// move the commit time of a row backwards, and only supersede tombstones that
+// are older than the write, so that a write and a delete end in the same
+// state in either order. Commit times and tombstones are both taken from the
+// hybrid logical clock of the persister. Tombstones older than the retention
+// are pruned on the next deletion of the network, like the deletion log,
+// together with the tuples they still hide.
+
+type Tombstone struct {
+	// An ID field is required to make pop happy. The actual ID is a composite primary key.
+	ID        uuid.UUID `db:"shard_id"`
+	NetworkID uuid.UUID `db:"nid"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+func (Tombstone) TableName(_ context.Context) string {
+	return "keto_relation_tuple_tombstones"
+}
+
+// deterministicID derives the ID of the tuple from the network and the tuple
+// columns. It must be called after the columns were populated.
+func (r *RelationTuple) deterministicID(nid uuid.UUID) uuid.UUID {
+	nullString := func(s string, valid bool) string {
+		if !valid {
+			return "\x01"
+		}
+		return s
+	}
+
+	return uuid.NewV5(nid, strings.Join([]string{
+		strconv.FormatInt(int64(r.NamespaceID), 10),
+		r.Object,
+		r.Relation,
+		nullString(r.SubjectID.String, r.SubjectID.Valid),
+		nullString(strconv.FormatInt(int64(r.SubjectSetNamespaceID.Int32), 10), r.SubjectSetNamespaceID.Valid),
+		nullString(r.SubjectSetObject.String, r.SubjectSetObject.Valid),
+		nullString(r.SubjectSetRelation.String, r.SubjectSetRelation.Valid),
+	}, "\x00"))
+}
+
+// upsertRelationTuple inserts the tuple or, if it already exists, bumps its
+// commit time if the write is newer. Tombstones of the tuple that are older
+// than the write are superseded by it. The commit sequence only changes if the
+// tuple was hidden by a tombstone, a visible tuple stays in the snapshots
+// since it was first written.
+func (p *Persister) upsertRelationTuple(ctx context.Context, rt *RelationTuple) error {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.upsertRelationTuple")
+	defer span.End()
+
+	rt.ID = rt.deterministicID(p.NetworkID(ctx))
+
+	return p.Transaction(ctx, func(ctx context.Context, c *pop.Connection) error {
+		var existing relationTuples
+		if err := p.QueryWithNetwork(ctx).Where("shard_id = ?", rt.ID).All(&existing); err != nil {
+			return sqlcon.HandleError(err)
+		}
+		if len(existing) == 0 {
+			// A write that is older than the tombstone is not inserted, a
+			// hidden row would reappear once the tombstone is pruned.
+			covered, err := p.QueryWithNetwork(ctx).Where("shard_id = ?", rt.ID).Where("deleted_at >= ?", rt.CommitTime).Exists(&Tombstone{})
+			if err != nil {
+				return sqlcon.HandleError(err)
+			}
+			if covered {
+				return nil
+			}
+			if err := p.CreateWithNetwork(ctx, rt); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		} else {
+			hidden, err := p.QueryWithNetwork(ctx).Where("shard_id = ?", rt.ID).Where("deleted_at >= ?", existing[0].CommitTime).Exists(&Tombstone{})
+			if err != nil {
+				return sqlcon.HandleError(err)
+			}
+			seq := existing[0].Seq
+			if hidden {
+				seq = rt.Seq
+			}
+			if err := c.RawQuery(
+				"UPDATE keto_relation_tuples SET commit_time = ?, seq = ? WHERE shard_id = ? AND nid = ? AND commit_time < ?",
+				rt.CommitTime, seq, rt.ID, p.NetworkID(ctx), rt.CommitTime,
+			).Exec(); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+
+		// A newer tombstone keeps hiding the tuple.
+		return sqlcon.HandleError(
+			p.QueryWithNetwork(ctx).Where("shard_id = ?", rt.ID).Where("deleted_at < ?", rt.CommitTime).Delete(&Tombstone{}),
+		)
+	})
+}
+
+// tombstoneRelationTuples writes tombstones for all tuples matching the query
+// and deletes them afterwards. The deletions are recorded like in the
+// single-primary mode.
+func (p *Persister) tombstoneRelationTuples(ctx context.Context, q *pop.Query) error {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.tombstoneRelationTuples")
+	defer span.End()
+
+	var res relationTuples
+	if err := q.All(&res); err != nil {
+		return sqlcon.HandleError(err)
+	}
+
+	deletedAt, deletedSeq, err := p.nextCommit(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.recordDeletions(ctx, res, deletedAt, deletedSeq); err != nil {
+		return err
+	}
+	for _, r := range res {
+		// The commit time of the delete is after the commit times of all
+		// tuples, but a tombstone replicated from another region can be
+		// newer. The newest tombstone is kept.
+		newer, err := p.QueryWithNetwork(ctx).Where("shard_id = ?", r.ID).Where("deleted_at >= ?", deletedAt).Exists(&Tombstone{})
+		if err != nil {
+			return sqlcon.HandleError(err)
+		}
+		if !newer {
+			if err := p.QueryWithNetwork(ctx).Where("shard_id = ?", r.ID).Delete(&Tombstone{}); err != nil {
+				return sqlcon.HandleError(err)
+			}
+			if err := p.CreateWithNetwork(ctx, &Tombstone{ID: r.ID, DeletedAt: deletedAt}); err != nil {
+				return sqlcon.HandleError(err)
+			}
+		}
+		if err := p.QueryWithNetwork(ctx).Where("shard_id = ?", r.ID).Delete(&RelationTuple{}); err != nil {
+			return sqlcon.HandleError(err)
+		}
+	}
+	return p.pruneTombstones(ctx, deletedAt)
+}
+
+// pruneTombstones deletes the tombstones of the network that are older than
+// the retention at the time of the deletion. Tuples that are still hidden by
+// these tombstones, e.g. because they were replicated after the deletion, are
+// deleted with them, so that they don't reappear.
+func (p *Persister) pruneTombstones(ctx context.Context, deletedAt time.Time) error {
+	cutoff := deletedAt.Add(-p.d.Config(ctx).TombstoneRetention())
+	c := p.Connection(ctx)
+	if err := c.RawQuery(`DELETE FROM keto_relation_tuples WHERE nid = ? AND EXISTS (SELECT 1 FROM keto_relation_tuple_tombstones t
+WHERE t.nid = keto_relation_tuples.nid AND t.shard_id = keto_relation_tuples.shard_id AND t.deleted_at >= keto_relation_tuples.commit_time AND t.deleted_at < ?)`,
+		p.NetworkID(ctx), cutoff,
+	).Exec(); err != nil {
+		return sqlcon.HandleError(err)
+	}
+	return sqlcon.HandleError(
+		c.RawQuery("DELETE FROM keto_relation_tuple_tombstones WHERE nid = ? AND deleted_at < ?", p.NetworkID(ctx), cutoff).Exec(),
+	)
+}
+
+// whereNotTombstoned hides tuples that were replicated from another region but
+// deleted afterwards.
+func whereNotTombstoned(q *pop.Query) {
+	q.Where(`NOT EXISTS (SELECT 1 FROM keto_relation_tuple_tombstones t
+WHERE t.nid = keto_relation_tuples.nid AND t.shard_id = keto_relation_tuples.shard_id AND t.deleted_at >= keto_relation_tuples.commit_time)`)
+}
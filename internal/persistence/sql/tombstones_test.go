@@ -0,0 +1,238 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+	"github.com/ory/keto/internal/x/hlc"
+)
+
+func TestMultiPrimary(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, config.ReplicationModeMultiPrimary))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			c, err := reg.PopConnection(ctx)
+			require.NoError(t, err)
+
+			tuple := &relationtuple.InternalRelationTuple{
+				Namespace: "n",
+				Object:    "o",
+				Relation:  "r",
+				Subject:   &relationtuple.SubjectID{ID: "s"},
+			}
+			get := func(t *testing.T) []*relationtuple.InternalRelationTuple {
+				res, _, err := p.GetRelationTuples(ctx, tuple.ToQuery())
+				require.NoError(t, err)
+				return res
+			}
+			// replicate simulates the deleted tuple arriving from another region.
+			replicate := func(t *testing.T, commitTime time.Time) {
+				var tombstone sql.Tombstone
+				require.NoError(t, c.First(&tombstone))
+
+				row := sql.RelationTuple{ID: tombstone.ID, NetworkID: p.NetworkID(ctx), CommitTime: commitTime}
+				require.NoError(t, row.FromInternal(ctx, p, tuple))
+				require.NoError(t, c.Create(&row))
+			}
+
+			t.Run("case=writing a tuple twice is idempotent", func(t *testing.T) {
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				assert.Len(t, get(t), 1)
+			})
+
+			t.Run("case=writing a tuple again keeps it in earlier snapshots", func(t *testing.T) {
+				token, err := p.SnapshotToken(ctx)
+				require.NoError(t, err)
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+
+				snapshotCtx, err := p.AtSnapshot(ctx, token)
+				require.NoError(t, err)
+				res, _, err := p.GetRelationTuples(snapshotCtx, tuple.ToQuery())
+				require.NoError(t, err)
+				assert.Len(t, res, 1)
+			})
+
+			t.Run("case=deletes leave a tombstone", func(t *testing.T) {
+				require.NoError(t, p.DeleteRelationTuples(ctx, tuple))
+				assert.Len(t, get(t), 0)
+
+				var tombstones []sql.Tombstone
+				require.NoError(t, c.All(&tombstones))
+				assert.Len(t, tombstones, 1)
+			})
+
+			t.Run("case=replicated writes older than the tombstone are hidden", func(t *testing.T) {
+				replicate(t, time.Now().UTC().Add(-time.Hour))
+				assert.Len(t, get(t), 0)
+				require.NoError(t, c.RawQuery("DELETE FROM keto_relation_tuples").Exec())
+			})
+
+			t.Run("case=replicated writes newer than the tombstone are visible", func(t *testing.T) {
+				replicate(t, time.Now().UTC().Add(time.Hour))
+				assert.Len(t, get(t), 1)
+			})
+
+			t.Run("case=local writes supersede the tombstone", func(t *testing.T) {
+				require.NoError(t, p.DeleteAllRelationTuples(ctx, tuple.ToQuery()))
+				assert.Len(t, get(t), 0)
+
+				require.NoError(t, p.WriteRelationTuples(ctx, tuple))
+				assert.Len(t, get(t), 1)
+
+				var tombstones []sql.Tombstone
+				require.NoError(t, c.All(&tombstones))
+				assert.Len(t, tombstones, 0)
+			})
+
+			t.Run("case=prunes tombstones older than the retention", func(t *testing.T) {
+				other := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "other", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+				require.NoError(t, p.WriteRelationTuples(ctx, other))
+				require.NoError(t, p.DeleteRelationTuples(ctx, tuple))
+				require.NoError(t, c.RawQuery("UPDATE keto_relation_tuple_tombstones SET deleted_at = ?", time.Now().UTC().Add(-2*time.Hour)).Exec())
+
+				require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationTombstoneRetention, "1h"))
+				require.NoError(t, p.DeleteRelationTuples(ctx, other))
+
+				var tombstones []sql.Tombstone
+				require.NoError(t, c.All(&tombstones))
+				require.Len(t, tombstones, 1, "only the tombstone of the latest deletion is kept")
+				assert.True(t, tombstones[0].DeletedAt.After(time.Now().UTC().Add(-time.Hour)))
+			})
+		})
+	}
+}
+
+func TestMultiPrimaryPrunedTombstones(t *testing.T) {
+	t.Parallel()
+
+	tuple := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+	other := &relationtuple.InternalRelationTuple{Namespace: "n", Object: "other", Relation: "r", Subject: &relationtuple.SubjectID{ID: "s"}}
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, config.ReplicationModeMultiPrimary))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationTombstoneRetention, "1h"))
+
+			// The persisters of regions whose clocks are behind and ahead.
+			withOffset := func(offset time.Duration) *sql.Persister {
+				p, err := sql.NewPersister(ctx, reg, reg.Persister().NetworkID(ctx), sql.WithClock(hlc.NewWithWallClock(func() time.Time {
+					return time.Now().Add(offset)
+				})))
+				require.NoError(t, err)
+				return p
+			}
+			current, behind, ahead := withOffset(0), withOffset(-time.Hour), withOffset(2*time.Hour)
+
+			// The older write arrives after the deletion.
+			require.NoError(t, current.WriteRelationTuples(ctx, tuple))
+			require.NoError(t, current.DeleteRelationTuples(ctx, tuple))
+			require.NoError(t, behind.WriteRelationTuples(ctx, tuple))
+
+			// A later deletion prunes the tombstone of the tuple.
+			require.NoError(t, ahead.WriteRelationTuples(ctx, other))
+			require.NoError(t, ahead.DeleteRelationTuples(ctx, other))
+
+			c, err := reg.PopConnection(ctx)
+			require.NoError(t, err)
+			tombstones, err := c.Count(&sql.Tombstone{})
+			require.NoError(t, err)
+			require.Equal(t, 1, tombstones, "only the tombstone of the latest deletion is kept")
+
+			res, _, err := current.GetRelationTuples(ctx, tuple.ToQuery())
+			require.NoError(t, err)
+			assert.Empty(t, res, "the deleted tuple must not reappear")
+			rows, err := c.Count(&sql.RelationTuple{})
+			require.NoError(t, err)
+			assert.Equal(t, 0, rows)
+		})
+	}
+}
+
+func TestMultiPrimaryCommutes(t *testing.T) {
+	t.Parallel()
+
+	tuple := &relationtuple.InternalRelationTuple{
+		Namespace: "n",
+		Object:    "o",
+		Relation:  "r",
+		Subject:   &relationtuple.SubjectID{ID: "s"},
+	}
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			// apply writes the tuple, and then runs the steps with the
+			// persister of the current region and one of a region with a
+			// clock that is one hour behind. It returns the visible tuples
+			// and the number of tombstones.
+			apply := func(t *testing.T, steps ...func(t *testing.T, current, behind *sql.Persister)) ([]*relationtuple.InternalRelationTuple, int) {
+				reg := driver.NewTestRegistry(t, dsn)
+				require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, config.ReplicationModeMultiPrimary))
+				require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+				current, ok := reg.Persister().(*sql.Persister)
+				require.True(t, ok)
+				behind, err := sql.NewPersister(ctx, reg, current.NetworkID(ctx), sql.WithClock(hlc.NewWithWallClock(func() time.Time {
+					return time.Now().Add(-time.Hour)
+				})))
+				require.NoError(t, err)
+
+				require.NoError(t, current.WriteRelationTuples(ctx, tuple))
+				for _, step := range steps {
+					step(t, current, behind)
+				}
+
+				res, _, err := current.GetRelationTuples(ctx, tuple.ToQuery())
+				require.NoError(t, err)
+				c, err := reg.PopConnection(ctx)
+				require.NoError(t, err)
+				tombstones, err := c.Count(&sql.Tombstone{})
+				require.NoError(t, err)
+				return res, tombstones
+			}
+			del := func(t *testing.T, current, _ *sql.Persister) {
+				require.NoError(t, current.DeleteRelationTuples(ctx, tuple))
+			}
+			olderWrite := func(t *testing.T, _, behind *sql.Persister) {
+				require.NoError(t, behind.WriteRelationTuples(ctx, tuple))
+			}
+
+			deleteFirst, deleteFirstTombstones := apply(t, del, olderWrite)
+			writeFirst, writeFirstTombstones := apply(t, olderWrite, del)
+
+			assert.Empty(t, deleteFirst, "the newer delete wins")
+			assert.Equal(t, writeFirst, deleteFirst)
+			assert.Equal(t, 1, deleteFirstTombstones)
+			assert.Equal(t, writeFirstTombstones, deleteFirstTombstones)
+		})
+	}
+}
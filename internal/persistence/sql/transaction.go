@@ -0,0 +1,161 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+var (
+	transactionRetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "keto",
+		Name:      "transaction_retries_total",
+		Help:      "The number of transactions that were retried, by the kind of error of the failed attempt.",
+	}, []string{"reason"})
+	transactionRetriesExhaustedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "keto",
+		Name:      "transaction_retries_exhausted_total",
+		Help:      "The number of transactions that still failed with a retryable error after the last attempt.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(transactionRetriesTotal, transactionRetriesExhaustedTotal)
+}
+
+const (
+	retryReasonSerialization = "serialization_failure"
+	retryReasonDeadlock      = "deadlock"
+)
+
+// retryReason returns why the transaction that failed with err can be retried,
+// or an empty string if it can't.
+func retryReason(err error) string {
+	var st interface{ SQLState() string }
+	if errors.As(err, &st) {
+		switch st.SQLState() {
+		case "40001", "CR000":
+			return retryReasonSerialization
+		case "40P01":
+			return retryReasonDeadlock
+		}
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == 1213 {
+		return retryReasonDeadlock
+	}
+	if errors.Is(err, errCommitSequenceCreated) {
+		return retryReasonSerialization
+	}
+	return ""
+}
+
+type txStateContextKey struct{}
+
+// txState is shared by the nested calls in one attempt of a transaction, so
+// that work that is only needed once per transaction is done once.
+type txState struct {
+	// seq is the commit sequence of the transaction, or 0 if it did not
+	// change any relation tuples yet.
+	seq int64
+	// changedAt is the latest commit time taken in the transaction.
+	changedAt time.Time
+}
+
+// txStateFromContext returns the state of the transaction of the context, or
+// nil outside of Transaction.
+func txStateFromContext(ctx context.Context) *txState {
+	s, _ := ctx.Value(txStateContextKey{}).(*txState)
+	return s
+}
+
+// Transaction runs f in a transaction with the configured isolation level. If
+// the transaction fails with a serialization failure or deadlock, it is rolled
+// back and run again after a jittered backoff. Nested calls run in the
+// transaction of the outermost call, which retries as a whole.
+func (p *Persister) Transaction(ctx context.Context, f func(ctx context.Context, c *pop.Connection) error) error {
+	if c := p.Connection(ctx); c.TX != nil {
+		return f(ctx, c)
+	}
+
+	receipt := relationtuple.WriteReceiptFromContext(ctx)
+	maxAttempts, backoff, maxBackoff := p.d.Config(ctx).TransactionRetry()
+	for attempt := 1; ; attempt++ {
+		if receipt != nil {
+			// Only the tuples of the attempt that commits are stored.
+			receipt.Reset()
+		}
+		state := &txState{}
+		err := p.transaction(ctx, state, f)
+		if err == nil && receipt != nil {
+			// The token of the transaction itself, later transactions might
+			// have committed already.
+			token := formatSnapshotToken(state.changedAt, state.seq)
+			if state.seq == 0 {
+				if token, err = p.SnapshotToken(ctx); err != nil {
+					return err
+				}
+			}
+			receipt.SetSnapshotToken(token)
+			return nil
+		}
+		reason := retryReason(err)
+		if reason == "" {
+			return err
+		}
+		if attempt >= maxAttempts {
+			transactionRetriesExhaustedTotal.Inc()
+			return err
+		}
+
+		transactionRetriesTotal.WithLabelValues(reason).Inc()
+		p.d.Logger().WithError(err).WithField("attempt", attempt).Debug("Retrying transaction.")
+
+		// Full jitter keeps concurrent writers that conflicted from retrying
+		// in lockstep.
+		wait := time.Duration(rand.Int63n(int64(backoff) + 1))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (p *Persister) transaction(ctx context.Context, state *txState, f func(ctx context.Context, c *pop.Connection) error) error {
+	conn := p.database(ctx).WithContext(ctx)
+	return conn.Dialect.Lock(func() error {
+		tx, err := conn.NewTransactionContextOptions(ctx, &sql.TxOptions{
+			Isolation: p.d.Config(ctx).IsolationLevel(conn.Dialect.Name()),
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx := context.WithValue(popx.WithTransaction(ctx, tx), txStateContextKey{}, state)
+		err = f(ctx, tx)
+		if err == nil && state.seq != 0 {
+			err = p.recordChangedAt(ctx, state)
+		}
+		if err != nil {
+			if rbErr := tx.TX.Rollback(); rbErr != nil {
+				return fmt.Errorf("error rolling back transaction: %v: %w", rbErr, err)
+			}
+			return err
+		}
+		return errors.WithStack(tx.TX.Commit())
+	})
+}
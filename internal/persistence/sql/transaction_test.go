@@ -0,0 +1,83 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/ory/x/sqlcon"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence/sql"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "SQLSTATE " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestTransactionRetry(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyTransactionInitialBackoff, "1ms"))
+	p, ok := reg.Persister().(*sql.Persister)
+	require.True(t, ok)
+
+	failing := func(failures int, err error) (func(context.Context, *pop.Connection) error, *int) {
+		attempts := 0
+		return func(context.Context, *pop.Connection) error {
+			attempts++
+			if attempts <= failures {
+				return err
+			}
+			return nil
+		}, &attempts
+	}
+
+	for _, err := range []error{
+		sqlStateError("40001"),
+		sqlStateError("40P01"),
+		sqlcon.HandleError(sqlStateError("40001")),
+		&mysql.MySQLError{Number: 1213},
+	} {
+		t.Run(fmt.Sprintf("case=retries %s", err), func(t *testing.T) {
+			f, attempts := failing(2, err)
+			require.NoError(t, p.Transaction(ctx, f))
+			assert.Equal(t, 3, *attempts)
+		})
+	}
+
+	t.Run("case=does not retry other errors", func(t *testing.T) {
+		f, attempts := failing(2, sqlStateError("23505"))
+		assert.ErrorIs(t, p.Transaction(ctx, f), sqlStateError("23505"))
+		assert.Equal(t, 1, *attempts)
+	})
+
+	t.Run("case=gives up after the last attempt", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyTransactionMaxAttempts, 2))
+		t.Cleanup(func() {
+			require.NoError(t, reg.Config(ctx).Set(config.KeyTransactionMaxAttempts, 5))
+		})
+
+		f, attempts := failing(5, sqlStateError("40001"))
+		assert.ErrorIs(t, p.Transaction(ctx, f), sqlStateError("40001"))
+		assert.Equal(t, 2, *attempts)
+	})
+
+	t.Run("case=nested transactions retry as a whole", func(t *testing.T) {
+		f, inner := failing(1, sqlStateError("40001"))
+		outer := 0
+		require.NoError(t, p.Transaction(ctx, func(ctx context.Context, _ *pop.Connection) error {
+			outer++
+			return errors.WithStack(p.Transaction(ctx, f))
+		}))
+		assert.Equal(t, 2, outer)
+		assert.Equal(t, 2, *inner)
+	})
+}
@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/sqlcon"
+
+	"github.com/ory/keto/internal/persistence"
+)
+
+var _ persistence.TupleCounter = &Persister{}
+
+type networkTupleCount struct {
+	NetworkID uuid.UUID `db:"nid"`
+	Tuples    int64     `db:"tuples"`
+}
+
+func (networkTupleCount) TableName(_ context.Context) string {
+	return "keto_relation_tuples"
+}
+
+func (p *Persister) CountRelationTuplesByNetwork(ctx context.Context) (map[uuid.UUID]int64, error) {
+	ctx, span := p.d.Tracer(ctx).Tracer().Start(ctx, "persistence.sql.CountRelationTuplesByNetwork")
+	defer span.End()
+
+	// The networks homed in a region are counted in its database.
+	counts := map[uuid.UUID]int64{}
+	for _, c := range p.databases() {
+		q := c.WithContext(ctx).Select("nid", "COUNT(*) AS tuples").GroupBy("nid")
+		if p.d.Config(ctx).MultiPrimary() {
+			whereNotTombstoned(q)
+		}
+
+		var res []networkTupleCount
+		if err := q.All(&res); err != nil {
+			return nil, sqlcon.HandleError(err)
+		}
+		for _, r := range res {
+			counts[r.NetworkID] += r.Tuples
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,74 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ory/x/networkx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence/sql"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+func TestCountRelationTuplesByNetwork(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range dbx.GetDSNs(t, false) {
+		dsn := dsn
+		t.Run("dsn="+dsn.Name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			reg := driver.NewTestRegistry(t, dsn)
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "n"}}))
+
+			p0, ok := reg.Persister().(*sql.Persister)
+			require.True(t, ok)
+			n1 := networkx.NewNetwork()
+			require.NoError(t, p0.Connection(ctx).Create(n1))
+			p1, err := sql.NewPersister(ctx, reg, n1.ID)
+			require.NoError(t, err)
+
+			tuples := func(prefix string, n int) []*relationtuple.InternalRelationTuple {
+				rs := make([]*relationtuple.InternalRelationTuple, n)
+				for i := range rs {
+					rs[i] = &relationtuple.InternalRelationTuple{
+						Namespace: "n",
+						Object:    fmt.Sprintf("%s%d", prefix, i),
+						Relation:  "r",
+						Subject:   &relationtuple.SubjectID{ID: "s"},
+					}
+				}
+				return rs
+			}
+			require.NoError(t, p0.WriteRelationTuples(ctx, tuples("o", 3)...))
+			require.NoError(t, p1.WriteRelationTuples(ctx, tuples("o", 2)...))
+
+			counts, err := p0.CountRelationTuplesByNetwork(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), counts[p0.NetworkID(ctx)])
+			assert.Equal(t, int64(2), counts[n1.ID])
+
+			t.Run("case=tombstoned tuples are not counted", func(t *testing.T) {
+				require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, config.ReplicationModeMultiPrimary))
+				t.Cleanup(func() {
+					require.NoError(t, reg.Config(ctx).Set(config.KeyReplicationMode, config.ReplicationModeSinglePrimary))
+				})
+
+				rs := tuples("replicated", 2)
+				require.NoError(t, p0.WriteRelationTuples(ctx, rs...))
+				require.NoError(t, p0.DeleteRelationTuples(ctx, rs[0]))
+
+				counts, err := p0.CountRelationTuplesByNetwork(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, int64(4), counts[p0.NetworkID(ctx)])
+			})
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+type (
+	Provider interface {
+		Proxy() *Proxy
+	}
+	dependencies interface {
+		config.Provider
+		x.LoggerProvider
+		relationtuple.ManagerProvider
+	}
+	// Proxy answers checks and expands with the results of an upstream Keto.
+	// Results are cached while the watch on the upstream is connected, and
+	// dropped whenever the upstream reports a write.
+	Proxy struct {
+		d dependencies
+
+		connLock sync.Mutex
+		conns    map[string]*grpc.ClientConn
+
+		mu sync.Mutex
+		// watching is true while the watch is connected. Results are
+		// neither cached nor served from the cache otherwise.
+		watching bool
+		snapshot string
+		// generation changes whenever the cache is dropped, so that results
+		// requested before are not cached afterwards.
+		generation uint64
+		cache      map[string]interface{}
+
+		// localSnapshot is the snapshot of the upstream that the local copy
+		// of the sidecar namespaces was downloaded at, and syncedAt when the
+		// copy was last known to match the upstream.
+		localSnapshot string
+		syncedAt      time.Time
+	}
+)
+
+const (
+	maxRetryInterval = 30 * time.Second
+	watchTimeout     = 5 * time.Second
+)
+
+var cacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "proxy_cache_lookups_total",
+	Help:      "The number of proxied checks and expands, by whether the result was cached.",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(cacheLookupsTotal)
+}
+
+func NewProxy(d dependencies) *Proxy {
+	return &Proxy{
+		d:     d,
+		conns: map[string]*grpc.ClientConn{},
+		cache: map[string]interface{}{},
+	}
+}
+
+// Enabled returns whether checks and expands are proxied to an upstream.
+func (p *Proxy) Enabled(ctx context.Context) bool {
+	remote, _ := p.d.Config(ctx).ProxyUpstream()
+	return remote != ""
+}
+
+// Check returns the result of the check on the upstream.
+func (p *Proxy) Check(ctx context.Context, r *relationtuple.InternalRelationTuple, restDepth int) (bool, error) {
+	res, err := p.cached(ctx, fmt.Sprintf("check %s %d", r, restDepth), func(c *grpc.ClientConn) (interface{}, error) {
+		resp, err := rts.NewCheckServiceClient(c).Check(ctx, &rts.CheckRequest{
+			Namespace: r.Namespace,
+			Object:    r.Object,
+			Relation:  r.Relation,
+			Subject:   r.Subject.ToProto(),
+			MaxDepth:  int32(restDepth),
+		})
+		if err != nil {
+			return false, err
+		}
+		return resp.Allowed, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// Expand returns the tree of the subject on the upstream. The tree is nil if
+// the upstream returned none.
+func (p *Proxy) Expand(ctx context.Context, subject relationtuple.Subject, restDepth int) (*rts.SubjectTree, error) {
+	res, err := p.cached(ctx, fmt.Sprintf("expand %s %d", subject, restDepth), func(c *grpc.ClientConn) (interface{}, error) {
+		resp, err := rts.NewExpandServiceClient(c).Expand(ctx, &rts.ExpandRequest{
+			Subject:  subject.ToProto(),
+			MaxDepth: int32(restDepth),
+		})
+		if err != nil {
+			return (*rts.SubjectTree)(nil), err
+		}
+		return resp.Tree, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*rts.SubjectTree), nil
+}
+
+// cached returns the cached result of the key, or requests it from the
+// upstream and caches it. Results must not be modified by the callers.
+func (p *Proxy) cached(ctx context.Context, key string, request func(*grpc.ClientConn) (interface{}, error)) (interface{}, error) {
+	p.mu.Lock()
+	res, hit := p.cache[key]
+	watching, generation := p.watching, p.generation
+	p.mu.Unlock()
+	if hit && watching {
+		cacheLookupsTotal.WithLabelValues("hit").Inc()
+		return res, nil
+	}
+	cacheLookupsTotal.WithLabelValues("miss").Inc()
+
+	remote, _ := p.d.Config(ctx).ProxyUpstream()
+	conn, err := p.conn(remote)
+	if err != nil {
+		return nil, err
+	}
+	res, err = request(conn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.watching && p.generation == generation {
+		if len(p.cache) >= p.d.Config(ctx).ProxyMaxCachedResults() {
+			for k := range p.cache {
+				delete(p.cache, k)
+				break
+			}
+		}
+		p.cache[key] = res
+	}
+	return res, nil
+}
+
+// observe records the snapshot of the upstream and drops the cache if it
+// changed.
+func (p *Proxy) observe(snapshot string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if snapshot != p.snapshot {
+		p.drop()
+		p.snapshot = snapshot
+	}
+	p.watching = true
+}
+
+// disconnected stops using the cache until the watch is connected again.
+// The cache is kept, as the next snapshot tells whether it is still valid.
+func (p *Proxy) disconnected() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.watching = false
+	p.generation++
+}
+
+func (p *Proxy) drop() {
+	p.cache = map[string]interface{}{}
+	p.generation++
+}
+
+// Run watches the upstream for writes until the context is canceled, and keeps
+// the local copy of the sidecar namespaces up to date. It returns immediately
+// if checks are not proxied or the upstream URL is not set, in which case
+// results are not cached.
+func (p *Proxy) Run(ctx context.Context) error {
+	remote, upstream := p.d.Config(ctx).ProxyUpstream()
+	if remote == "" || upstream == "" {
+		return nil
+	}
+
+	c := &http.Client{Timeout: 2 * watchTimeout}
+	wait := time.Second
+	for {
+		connected, err := p.watch(ctx, c, upstream)
+		p.disconnected()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if connected {
+			wait = time.Second
+		}
+
+		p.d.Logger().WithError(err).Warnf("The watch on the upstream Keto was interrupted, reconnecting in %s.", wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > maxRetryInterval {
+			wait = maxRetryInterval
+		}
+	}
+}
+
+// watch repeats the watch request with the previous snapshot until a request
+// fails. It returns whether any request succeeded.
+func (p *Proxy) watch(ctx context.Context, c *http.Client, upstream string) (connected bool, _ error) {
+	var snapshot string
+	for {
+		u, err := url.Parse(strings.TrimSuffix(upstream, "/") + relationtuple.WatchRoute)
+		if err != nil {
+			return connected, errors.WithStack(err)
+		}
+		q := url.Values{"timeout": {p.watchTimeout(ctx).String()}}
+		if snapshot != "" {
+			q.Set("snapshot", snapshot)
+		}
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return connected, errors.WithStack(err)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return connected, errors.WithStack(err)
+		}
+		var body relationtuple.WatchResponse
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return connected, errors.Errorf("unexpected status code %d", resp.StatusCode)
+		} else if err != nil {
+			return connected, errors.WithStack(err)
+		}
+
+		snapshot, connected = body.Snapshot, true
+		p.observe(snapshot)
+		if err := p.syncLocal(ctx, snapshot); err != nil {
+			return connected, err
+		}
+	}
+}
+
+// watchTimeout returns how long a watch request waits for a write. Sidecars
+// wait shorter, so that the local copy is confirmed before it gets stale.
+func (p *Proxy) watchTimeout(ctx context.Context) time.Duration {
+	c := p.d.Config(ctx)
+	if len(c.SidecarNamespaces()) > 0 && c.SidecarMaxStaleness()/2 < watchTimeout {
+		return c.SidecarMaxStaleness() / 2
+	}
+	return watchTimeout
+}
+
+// conn returns a connection to the remote. It is established in the
+// background, so that an unavailable upstream does not block the server.
+func (p *Proxy) conn(remote string) (*grpc.ClientConn, error) {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+
+	if conn, ok := p.conns[remote]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(remote, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.conns[remote] = conn
+	return conn, nil
+}
@@ -0,0 +1,213 @@
+package proxy_test
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/expand"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type upstream struct {
+	driver.Registry
+	srv *grpc.Server
+	ts  *httptest.Server
+}
+
+var nn = []*namespace.Namespace{{ID: 0, Name: "docs"}, {ID: 1, Name: "groups"}}
+
+func newUpstream(t *testing.T) (*upstream, string) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, nn))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := reg.ReadGRPCServer(ctx)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	r := &x.ReadRouter{Router: httprouter.New()}
+	relationtuple.NewHandler(reg).RegisterReadRoutes(r)
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	return &upstream{Registry: reg, srv: srv, ts: ts}, l.Addr().String()
+}
+
+// newProxy returns a registry proxying to the upstream, with the Proxy
+// running until the test ends.
+func newProxy(t *testing.T, remote, url string, cfg ...interface{}) *driver.RegistryDefault {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, nn))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyWatchPollInterval, "10ms"))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyProxyReadRemote, remote))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyProxyReadURL, url))
+	for i := 0; i+1 < len(cfg); i += 2 {
+		require.NoError(t, reg.Config(ctx).Set(cfg[i].(string), cfg[i+1]))
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- reg.Proxy().Run(runCtx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return reg
+}
+
+// cacheHits returns how many proxied checks and expands were served from the
+// cache.
+func cacheHits(t *testing.T) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "keto_proxy_cache_lookups_total" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			for _, l := range m.Label {
+				if l.GetName() == "result" && l.GetValue() == "hit" {
+					return m.Counter.GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestProxy(t *testing.T) {
+	ctx := context.Background()
+	upstream, remote := newUpstream(t)
+	srv := upstream.srv
+	reg := newProxy(t, remote, upstream.ts.URL)
+
+	rt := &relationtuple.InternalRelationTuple{
+		Namespace: "docs",
+		Object:    "a",
+		Relation:  "viewer",
+		Subject:   &relationtuple.SubjectID{ID: "alice"},
+	}
+	check := func(t *testing.T) bool {
+		allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, rt, 0)
+		require.NoError(t, err)
+		return allowed
+	}
+
+	t.Run("case=follows upstream writes", func(t *testing.T) {
+		assert.False(t, check(t))
+
+		require.NoError(t, upstream.RelationTupleManager().WriteRelationTuples(ctx, rt))
+		assert.Eventually(t, func() bool { return check(t) }, 5*time.Second, 20*time.Millisecond)
+
+		// the proxy has no tuples of its own
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "docs"})
+		require.NoError(t, err)
+		assert.Empty(t, res)
+	})
+
+	t.Run("case=expands on the upstream", func(t *testing.T) {
+		subject := &relationtuple.SubjectSet{Namespace: "docs", Object: "a", Relation: "viewer"}
+		expected, err := upstream.ExpandEngine().BuildTree(ctx, subject, 0)
+		require.NoError(t, err)
+		actual, err := reg.ExpandEngine().BuildTree(ctx, subject, 0)
+		require.NoError(t, err)
+		assert.Equal(t, expected.String(), actual.String())
+		assert.Equal(t, expand.Union, actual.Type)
+	})
+
+	t.Run("case=serves cached results while the upstream is unavailable", func(t *testing.T) {
+		// The result is only cached once the proxy observed the snapshot of
+		// the write.
+		assert.Eventually(t, func() bool {
+			hits := cacheHits(t)
+			return check(t) && cacheHits(t) > hits
+		}, 5*time.Second, 20*time.Millisecond)
+
+		srv.Stop()
+		assert.True(t, check(t))
+
+		_, err := reg.PermissionEngine().SubjectIsAllowed(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "docs",
+			Object:    "b",
+			Relation:  "viewer",
+			Subject:   &relationtuple.SubjectID{ID: "alice"},
+		}, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestSidecar(t *testing.T) {
+	ctx := context.Background()
+	upstream, remote := newUpstream(t)
+	reg := newProxy(t, remote, upstream.ts.URL,
+		config.KeySidecarNamespaces, []string{"docs"},
+		config.KeySidecarMaxStaleness, "1s",
+	)
+
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+	eventuallyLocal := func(t *testing.T, expected string) {
+		assert.Eventually(t, func() bool {
+			// reads fail while the proxy copies the tuples, as the test
+			// database locks the table
+			res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "docs"})
+			return err == nil && len(res) == 1 && res[0].String() == expected
+		}, 5*time.Second, 20*time.Millisecond)
+	}
+
+	require.NoError(t, upstream.RelationTupleManager().WriteRelationTuples(ctx, tuple("docs:a#viewer@alice"), tuple("groups:g#member@alice")))
+
+	t.Run("case=copies the namespaces on start", func(t *testing.T) {
+		eventuallyLocal(t, "docs:a#viewer@alice")
+		assert.True(t, reg.Proxy().Local(ctx, "docs"))
+		assert.False(t, reg.Proxy().Local(ctx, "groups"))
+	})
+
+	t.Run("case=follows upstream writes", func(t *testing.T) {
+		require.NoError(t, upstream.RelationTupleManager().TransactRelationTuples(ctx,
+			[]*relationtuple.InternalRelationTuple{tuple("docs:b#viewer@bob")},
+			[]*relationtuple.InternalRelationTuple{tuple("docs:a#viewer@alice")},
+		))
+		eventuallyLocal(t, "docs:b#viewer@bob")
+	})
+
+	t.Run("case=checks locally while fresh", func(t *testing.T) {
+		upstream.srv.Stop()
+
+		allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple("docs:b#viewer@bob"), 0)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		// other namespaces are proxied
+		_, err = reg.PermissionEngine().SubjectIsAllowed(ctx, tuple("groups:g#member@alice"), 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=proxies once stale", func(t *testing.T) {
+		upstream.ts.CloseClientConnections()
+		upstream.ts.Close()
+
+		assert.Eventually(t, func() bool { return !reg.Proxy().Local(ctx, "docs") }, 5*time.Second, 50*time.Millisecond)
+		_, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple("docs:b#viewer@bob"), 0)
+		assert.Error(t, err)
+	})
+}
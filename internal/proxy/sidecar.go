@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+var lastSyncTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "keto",
+	Name:      "sidecar_last_sync_timestamp_seconds",
+	Help:      "When the local copy of the relation tuples was last known to match the upstream.",
+})
+
+func init() {
+	prometheus.MustRegister(lastSyncTimestamp)
+}
+
+// Local returns true if checks of the namespace are evaluated on the local
+// copy of its relation tuples, i.e. if the namespace is copied and the copy
+// is not stale.
+func (p *Proxy) Local(ctx context.Context, namespace string) bool {
+	c := p.d.Config(ctx)
+	copied := false
+	for _, n := range c.SidecarNamespaces() {
+		copied = copied || n == namespace
+	}
+	if !copied {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.syncedAt.IsZero() && time.Since(p.syncedAt) <= c.SidecarMaxStaleness()
+}
+
+// syncLocal brings the local copy up to date with the snapshot of the
+// upstream. The relation tuples are only downloaded if the snapshot changed
+// since the last download.
+func (p *Proxy) syncLocal(ctx context.Context, snapshot string) error {
+	namespaces := p.d.Config(ctx).SidecarNamespaces()
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	upToDate := p.localSnapshot == snapshot
+	p.mu.Unlock()
+	if !upToDate {
+		for _, n := range namespaces {
+			if err := p.syncNamespace(ctx, n); err != nil {
+				return err
+			}
+		}
+		p.d.Logger().WithField("snapshot", snapshot).Debug("Copied the relation tuples of the upstream Keto.")
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	p.localSnapshot, p.syncedAt = snapshot, now
+	p.mu.Unlock()
+	lastSyncTimestamp.Set(float64(now.Unix()))
+	return nil
+}
+
+// syncNamespace replaces the local relation tuples of the namespace with the
+// ones of the upstream in one transaction.
+func (p *Proxy) syncNamespace(ctx context.Context, namespace string) error {
+	remote, _ := p.d.Config(ctx).ProxyUpstream()
+	conn, err := p.conn(remote)
+	if err != nil {
+		return err
+	}
+	upstream := map[string]*relationtuple.InternalRelationTuple{}
+	var page string
+	for {
+		resp, err := rts.NewReadServiceClient(conn).ListRelationTuples(ctx, &rts.ListRelationTuplesRequest{
+			Query:     &rts.ListRelationTuplesRequest_Query{Namespace: namespace},
+			PageToken: page,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, rt := range resp.RelationTuples {
+			it, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(rt)
+			if err != nil {
+				return err
+			}
+			upstream[it.String()] = it
+		}
+		if page = resp.NextPageToken; page == "" {
+			break
+		}
+	}
+
+	m := p.d.RelationTupleManager()
+	var inserts, deletes []*relationtuple.InternalRelationTuple
+	page = ""
+	for {
+		local, next, err := m.GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: namespace}, x.WithToken(page))
+		if err != nil {
+			return err
+		}
+		for _, rt := range local {
+			if _, ok := upstream[rt.String()]; ok {
+				delete(upstream, rt.String())
+			} else {
+				deletes = append(deletes, rt)
+			}
+		}
+		if page = next; page == "" {
+			break
+		}
+	}
+	for _, rt := range upstream {
+		inserts = append(inserts, rt)
+	}
+
+	if len(inserts) == 0 && len(deletes) == 0 {
+		return nil
+	}
+	return m.TransactRelationTuples(ctx, inserts, deletes)
+}
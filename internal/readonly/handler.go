@@ -0,0 +1,140 @@
+package readonly
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+	// The read-only mode
+	//
+	// swagger:model readOnlyMode
+	State struct {
+		// Whether writes are rejected
+		//
+		// required: true
+		Enabled bool `json:"enabled"`
+		// Whether the mode was toggled at runtime on this instance instead of
+		// following the config
+		//
+		// required: true
+		Overridden bool `json:"overridden"`
+	}
+)
+
+const RouteBase = "/admin/read-only"
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.getReadOnlyMode)
+	r.PUT(RouteBase, h.setReadOnlyMode)
+	r.DELETE(RouteBase, h.resetReadOnlyMode)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:parameters setReadOnlyMode
+// nolint:deadcode,unused
+type setReadOnlyModeParameters struct {
+	// in: body
+	// required: true
+	Body struct {
+		// required: true
+		Enabled *bool `json:"enabled"`
+	}
+}
+
+func (h *Handler) state(r *http.Request) *State {
+	enabled, overridden := h.d.ReadOnlyMode().Enabled(r.Context())
+	return &State{Enabled: enabled, Overridden: overridden}
+}
+
+// swagger:route GET /admin/read-only write getReadOnlyMode
+//
+// Get the read-only mode
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: readOnlyMode
+//       500: genericError
+func (h *Handler) getReadOnlyMode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.d.Writer().Write(w, r, h.state(r))
+}
+
+// swagger:route PUT /admin/read-only write setReadOnlyMode
+//
+// Toggle the read-only mode
+//
+// Enables or disables the read-only mode regardless of the config. The toggle
+// only applies to the instance that serves this request and is lost on
+// restart.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: readOnlyMode
+//       400: genericError
+//       500: genericError
+func (h *Handler) setReadOnlyMode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Enabled == nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReason(`The body has to be like {"enabled": true}.`).
+			WithDetail(errorcode.DetailField, "enabled")))
+		return
+	}
+
+	h.d.ReadOnlyMode().Set(body.Enabled)
+	h.d.Writer().Write(w, r, h.state(r))
+}
+
+// swagger:route DELETE /admin/read-only write resetReadOnlyMode
+//
+// Reset the read-only mode to the config
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: readOnlyMode
+//       500: genericError
+func (h *Handler) resetReadOnlyMode(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.d.ReadOnlyMode().Set(nil)
+	h.d.Writer().Write(w, r, h.state(r))
+}
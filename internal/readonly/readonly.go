@@ -0,0 +1,138 @@
+// Package readonly rejects mutations while the read-only mode is enabled, e.g.
+// during migrations, incident response, or when the DSN points at a replica.
+// Reads stay available.
+package readonly
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+type (
+	Provider interface {
+		ReadOnlyMode() *Mode
+	}
+	dependencies interface {
+		config.Provider
+		x.WriterProvider
+	}
+	// Mode is enabled in the config or with the --read-only flag of serve,
+	// and can be toggled at runtime. While enabled, requests with unsafe
+	// methods to the write API and calls of the gRPC write service are
+	// rejected.
+	Mode struct {
+		d dependencies
+
+		mu sync.RWMutex
+		// override is the runtime toggle, nil if the config applies.
+		override *bool
+	}
+)
+
+var ErrReadOnly = herodot.DefaultError{
+	IDField:       errorcode.ReadOnly,
+	StatusField:   http.StatusText(http.StatusServiceUnavailable),
+	ErrorField:    "The server is in read-only mode and rejects all writes.",
+	CodeField:     http.StatusServiceUnavailable,
+	GRPCCodeField: codes.Unavailable,
+}
+
+var writeServicePrefix = "/" + rts.WriteService_ServiceDesc.ServiceName + "/"
+
+func New(d dependencies) *Mode {
+	return &Mode{d: d}
+}
+
+// Enabled returns whether mutations are rejected, and whether that is because
+// of the runtime toggle instead of the config.
+func (m *Mode) Enabled(ctx context.Context) (enabled, overridden bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.override != nil {
+		return *m.override, true
+	}
+	return m.d.Config(ctx).ReadOnly(), false
+}
+
+// Set toggles the mode at runtime regardless of the config, or makes the
+// config apply again if enabled is nil. The toggle only applies to this
+// instance and is lost on restart.
+func (m *Mode) Set(enabled *bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if enabled == nil {
+		m.override = nil
+		return
+	}
+	e := *enabled
+	m.override = &e
+}
+
+func (m *Mode) reject(ctx context.Context) error {
+	if enabled, _ := m.Enabled(ctx); enabled {
+		return errors.WithStack(ErrReadOnly.WithReasonf("Writes are rejected until the read-only mode is disabled again with %s or %s.", config.KeyReadOnly, RouteBase))
+	}
+	return nil
+}
+
+func safe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// operational returns whether the path is an admin route that only changes the
+// runtime state of this instance, and not the stored data. Operators need
+// them during incidents, which is when the read-only mode is typically on.
+func operational(path string) bool {
+	return path == RouteBase || strings.HasPrefix(path, featureflag.RouteBase+"/")
+}
+
+// HTTPMiddleware rejects requests to the write API with unsafe methods, except
+// for the operational ones, e.g. toggling the mode or overriding feature flags.
+func (m *Mode) HTTPMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !safe(r.Method) && !operational(r.URL.Path) {
+		if err := m.reject(r.Context()); err != nil {
+			m.d.Writer().WriteError(rw, r, err)
+			return
+		}
+	}
+	next(rw, r)
+}
+
+// UnaryInterceptor rejects calls of the gRPC write service.
+func (m *Mode) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if strings.HasPrefix(info.FullMethod, writeServicePrefix) {
+		if err := m.reject(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor rejects streams of the gRPC write service.
+func (m *Mode) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, writeServicePrefix) {
+		if err := m.reject(ss.Context()); err != nil {
+			return err
+		}
+	}
+	return handler(srv, ss)
+}
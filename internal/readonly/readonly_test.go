@@ -0,0 +1,127 @@
+package readonly_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/featureflag"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/readonly"
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "docs"}}))
+
+	ts := httptest.NewServer(reg.WriteRouter(ctx))
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string) (*http.Response, []byte) {
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, raw
+	}
+	write := func(t *testing.T) (*http.Response, []byte) {
+		return do(t, http.MethodPut, relationtuple.WriteRouteBase, `{"namespace": "docs", "object": "a", "relation": "view", "subject_id": "alice"}`)
+	}
+	assertRejected := func(t *testing.T) {
+		resp, raw := write(t)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "%s", raw)
+		assert.Equal(t, errorcode.ReadOnly, gjson.GetBytes(raw, "error.id").String())
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := reg.WriteGRPCServer(ctx)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	transact := func() error {
+		_, err := rts.NewWriteServiceClient(conn).TransactRelationTuples(ctx, &rts.TransactRelationTuplesRequest{
+			RelationTupleDeltas: []*rts.RelationTupleDelta{{
+				Action:        rts.RelationTupleDelta_ACTION_INSERT,
+				RelationTuple: &rts.RelationTuple{Namespace: "docs", Object: "b", Relation: "view", Subject: rts.NewSubjectID("alice")},
+			}},
+		})
+		return err
+	}
+
+	t.Run("case=accepts writes by default", func(t *testing.T) {
+		resp, raw := write(t)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, "%s", raw)
+		assert.NoError(t, transact())
+	})
+
+	t.Run("case=rejects writes if enabled in the config", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, true))
+		t.Cleanup(func() { require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, false)) })
+
+		assertRejected(t)
+		assert.Equal(t, codes.Unavailable, status.Code(transact()))
+
+		resp, _ := do(t, http.MethodGet, readonly.RouteBase, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "safe methods pass")
+	})
+
+	t.Run("case=toggles at runtime", func(t *testing.T) {
+		resp, raw := do(t, http.MethodPut, readonly.RouteBase, `{"enabled": true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "%s", raw)
+		assert.JSONEq(t, `{"enabled": true, "overridden": true}`, string(raw))
+		assertRejected(t)
+
+		// the runtime toggle takes precedence over the config
+		require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, true))
+		_, raw = do(t, http.MethodPut, readonly.RouteBase, `{"enabled": false}`)
+		assert.JSONEq(t, `{"enabled": false, "overridden": true}`, string(raw))
+		resp, raw = write(t)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, "%s", raw)
+
+		_, raw = do(t, http.MethodDelete, readonly.RouteBase, "")
+		assert.JSONEq(t, `{"enabled": true, "overridden": false}`, string(raw))
+		assertRejected(t)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, false))
+
+		resp, _ = do(t, http.MethodPut, readonly.RouteBase, `{}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("case=accepts feature flag overrides", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, true))
+		t.Cleanup(func() { require.NoError(t, reg.Config(ctx).Set(config.KeyReadOnly, false)) })
+		assertRejected(t)
+
+		route := strings.Replace(featureflag.RouteNamespace, ":namespace", "docs", 1)
+		resp, raw := do(t, http.MethodPut, route, `{"`+string(featureflag.DirectMatchFirst)+`": true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode, "%s", raw)
+		assert.True(t, gjson.GetBytes(raw, "overrides."+string(featureflag.DirectMatchFirst)).Bool(), "%s", raw)
+
+		resp, raw = do(t, http.MethodDelete, route, "")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode, "%s", raw)
+	})
+}
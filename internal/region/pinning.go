@@ -0,0 +1,126 @@
+package region
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/healthx"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	dependencies interface {
+		config.Provider
+		persistence.Provider
+		x.LoggerProvider
+		x.WriterProvider
+	}
+	// Pinning guards requests of networks that are pinned to a home region.
+	// Requests for networks homed in this region, or not pinned at all, are
+	// served. The persister reads and writes the relation tuples of a pinned
+	// network in the database of its home region, so misrouted requests are
+	// either rejected or proxied to that database.
+	Pinning struct {
+		d dependencies
+	}
+)
+
+var ErrMisdirectedRequest = herodot.DefaultError{
+	IDField:       errorcode.RegionMisdirected,
+	StatusField:   http.StatusText(http.StatusMisdirectedRequest),
+	ErrorField:    "The network of this request is pinned to another region.",
+	CodeField:     http.StatusMisdirectedRequest,
+	GRPCCodeField: codes.FailedPrecondition,
+}
+
+func NewPinning(d dependencies) *Pinning {
+	return &Pinning{d: d}
+}
+
+// homeRegion returns the home region of the request's network if it differs
+// from the local region.
+func (p *Pinning) homeRegion(ctx context.Context) (string, bool) {
+	c := p.d.Config(ctx)
+	local := c.Region()
+	if local == "" {
+		return "", false
+	}
+
+	home := c.NetworkRegion(p.d.Persister().NetworkID(ctx))
+	if home == "" || home == local {
+		return "", false
+	}
+	return home, true
+}
+
+// admit returns an error if the request's network is homed in another region
+// and can't be proxied to the database of that region.
+func (p *Pinning) admit(ctx context.Context, target string) error {
+	home, misrouted := p.homeRegion(ctx)
+	if !misrouted {
+		return nil
+	}
+
+	c := p.d.Config(ctx)
+	if c.MisroutedRequests() != config.MisroutedRequestsProxy {
+		return errors.WithStack(ErrMisdirectedRequest.WithReasonf("The network is homed in region %q.", home).WithDetail("home_region", home))
+	}
+	if _, ok := c.RegionDSNs()[home]; !ok {
+		return errors.WithStack(ErrMisdirectedRequest.WithReasonf("The network is homed in region %q, which has no database configured.", home).WithDetail("home_region", home))
+	}
+
+	requestid.Logger(ctx, p.d.Logger()).
+		WithField("home_region", home).
+		WithField("target", target).
+		Debug("proxying request to the database of the home region of the network")
+	return nil
+}
+
+// HTTPMiddleware returns a middleware that rejects requests of networks homed
+// in another region, unless they are proxied to its database.
+func (p *Pinning) HTTPMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if strings.HasPrefix(r.URL.Path, healthx.AliveCheckPath) || strings.HasPrefix(r.URL.Path, healthx.ReadyCheckPath) {
+		next(rw, r)
+		return
+	}
+
+	if err := p.admit(r.Context(), r.URL.Path); err != nil {
+		p.d.Writer().WriteError(rw, r, err)
+		return
+	}
+	next(rw, r)
+}
+
+// UnaryInterceptor rejects gRPC requests of networks homed in another region,
+// unless they are proxied to its database.
+func (p *Pinning) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+		return handler(ctx, req)
+	}
+	if err := p.admit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor rejects gRPC streams of networks homed in another region,
+// unless they are proxied to its database.
+func (p *Pinning) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, "/grpc.health.v1.Health/") {
+		return handler(srv, ss)
+	}
+	if err := p.admit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
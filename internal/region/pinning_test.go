@@ -0,0 +1,229 @@
+package region_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/herodot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/urfave/negroni"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/region"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestPinning(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	nid := reg.Persister().NetworkID(ctx)
+
+	n := negroni.New()
+	n.UseFunc(region.NewPinning(reg).HTTPMiddleware)
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "local "+r.URL.Path)
+	})
+	ts := httptest.NewServer(n)
+	t.Cleanup(ts.Close)
+
+	get := func(t *testing.T) (int, string) {
+		resp, err := ts.Client().Get(ts.URL + "/relation-tuples")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("case=serves locally without region", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "local /relation-tuples", body)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionName, "eu"))
+
+	t.Run("case=serves unpinned networks locally", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "local /relation-tuples", body)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "eu"}))
+
+	t.Run("case=serves networks homed in the local region", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "local /relation-tuples", body)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "us"}))
+
+	t.Run("case=rejects misrouted requests", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusMisdirectedRequest, code)
+		assert.Equal(t, "us", gjson.Get(body, "error.details.home_region").String(), body)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionMisroutedRequests, config.MisroutedRequestsProxy))
+
+	t.Run("case=rejects misrouted requests without database of the home region", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusMisdirectedRequest, code)
+		assert.Equal(t, "us", gjson.Get(body, "error.details.home_region").String(), body)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionDatabases, map[string]interface{}{"us": regionDSN()}))
+
+	t.Run("case=proxies misrouted requests to the database of the home region", func(t *testing.T) {
+		code, body := get(t)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "local /relation-tuples", body)
+	})
+}
+
+func regionDSN() string {
+	return fmt.Sprintf(config.DSNMemory, uuid.Must(uuid.NewV4()))
+}
+
+type testService struct {
+	grpc_testing.UnimplementedTestServiceServer
+}
+
+func (s *testService) UnaryCall(context.Context, *grpc_testing.SimpleRequest) (*grpc_testing.SimpleResponse, error) {
+	return &grpc_testing.SimpleResponse{Username: "local"}, nil
+}
+
+func (s *testService) StreamingOutputCall(_ *grpc_testing.StreamingOutputCallRequest, stream grpc_testing.TestService_StreamingOutputCallServer) error {
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&grpc_testing.StreamingOutputCallResponse{Payload: &grpc_testing.Payload{Body: []byte("local")}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestGRPCPinning(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	nid := reg.Persister().NetworkID(ctx)
+	p := region.NewPinning(reg)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(herodot.UnaryErrorUnwrapInterceptor, p.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(herodot.StreamErrorUnwrapInterceptor, p.StreamInterceptor),
+	)
+	grpc_testing.RegisterTestServiceServer(s, &testService{})
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := grpc_testing.NewTestServiceClient(conn)
+
+	unary := func(t *testing.T) (string, error) {
+		res, err := client.UnaryCall(ctx, &grpc_testing.SimpleRequest{})
+		if err != nil {
+			return "", err
+		}
+		return res.Username, nil
+	}
+	stream := func(t *testing.T) ([]string, error) {
+		s, err := client.StreamingOutputCall(ctx, &grpc_testing.StreamingOutputCallRequest{})
+		require.NoError(t, err)
+		var bodies []string
+		for {
+			res, err := s.Recv()
+			if errors.Is(err, io.EOF) {
+				return bodies, nil
+			} else if err != nil {
+				return nil, err
+			}
+			bodies = append(bodies, string(res.Payload.Body))
+		}
+	}
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionName, "eu"))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "eu"}))
+
+	t.Run("case=serves networks homed in the local region", func(t *testing.T) {
+		name, err := unary(t)
+		require.NoError(t, err)
+		assert.Equal(t, "local", name)
+
+		bodies, err := stream(t)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"local", "local"}, bodies)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "us"}))
+
+	t.Run("case=rejects misrouted requests", func(t *testing.T) {
+		_, err := unary(t)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "%+v", err)
+
+		_, err = stream(t)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "%+v", err)
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionMisroutedRequests, config.MisroutedRequestsProxy))
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionDatabases, map[string]interface{}{"us": regionDSN()}))
+
+	t.Run("case=proxies misrouted requests to the database of the home region", func(t *testing.T) {
+		name, err := unary(t)
+		require.NoError(t, err)
+		assert.Equal(t, "local", name)
+
+		bodies, err := stream(t)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"local", "local"}, bodies)
+	})
+}
+
+func TestRegionDatabases(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false, map[string]interface{}{
+		config.KeyRegionDatabases: map[string]interface{}{"us": regionDSN()},
+	})
+	nid := reg.Persister().NetworkID(ctx)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}}))
+
+	rt := &relationtuple.InternalRelationTuple{Namespace: "files", Object: "report", Relation: "view", Subject: &relationtuple.SubjectID{ID: "alice"}}
+	count := func(t *testing.T) int {
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "files"})
+		require.NoError(t, err)
+		return len(res)
+	}
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "us"}))
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, rt))
+
+	t.Run("case=reads the network homed in a region from its database", func(t *testing.T) {
+		assert.Equal(t, 1, count(t))
+	})
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyRegionNetworks, map[string]interface{}{nid.String(): "eu"}))
+
+	t.Run("case=reads networks homed in regions without database from the default one", func(t *testing.T) {
+		assert.Equal(t, 0, count(t))
+	})
+}
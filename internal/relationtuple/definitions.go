@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 
@@ -18,6 +19,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/x"
 )
 
@@ -32,6 +34,69 @@ type (
 		DeleteAllRelationTuples(ctx context.Context, query *RelationQuery) error
 		TransactRelationTuples(ctx context.Context, insert []*InternalRelationTuple, delete []*InternalRelationTuple) error
 	}
+	// Counter can optionally be implemented by the storage backend to count
+	// the relation tuples matching a query.
+	Counter interface {
+		CountRelationTuples(ctx context.Context, query *RelationQuery) (int64, error)
+	}
+	CounterProvider interface {
+		// RelationTupleCounter returns nil if the storage backend cannot count
+		// relation tuples.
+		RelationTupleCounter() Counter
+	}
+	// Snapshotter can optionally be implemented by the storage backend to
+	// report the current snapshot token of the relation tuples. The token
+	// changes with every write and deletion of the network.
+	Snapshotter interface {
+		SnapshotToken(ctx context.Context) (string, error)
+		// NamespaceChangeToken returns an opaque token that changes with
+		// every write and deletion of the relation tuples of the namespace.
+		// It is not a snapshot token.
+		NamespaceChangeToken(ctx context.Context, namespace string) (string, error)
+	}
+	SnapshotterProvider interface {
+		// RelationTupleSnapshotter returns nil if the storage backend does
+		// not track snapshots.
+		RelationTupleSnapshotter() Snapshotter
+	}
+	// Searcher can optionally be implemented by the storage backend to find
+	// relation tuples by a part of their object or subject.
+	Searcher interface {
+		SearchRelationTuples(ctx context.Context, query *SearchQuery, options ...x.PaginationOptionSetter) ([]*InternalRelationTuple, string, error)
+	}
+	// ObjectsGetter can optionally be implemented by the storage backend to
+	// get the relation tuples of several objects in one query.
+	ObjectsGetter interface {
+		// GetRelationTuplesOfObjects gets the relation tuples that match the
+		// query, except that their object is any of the objects. The
+		// namespace of the query is required.
+		GetRelationTuplesOfObjects(ctx context.Context, query *RelationQuery, objects []string, options ...x.PaginationOptionSetter) ([]*InternalRelationTuple, string, error)
+	}
+	SearcherProvider interface {
+		// RelationTupleSearcher returns nil if the storage backend cannot
+		// search relation tuples.
+		RelationTupleSearcher() Searcher
+	}
+
+	// SearchQuery matches relation tuples by a part of their object or
+	// subject. At least one of Object and Subject is required. If both are
+	// set, tuples have to match both.
+	SearchQuery struct {
+		// Namespace optionally restricts the search to one namespace.
+		Namespace string
+		Object    string
+		// Subject matches subject IDs and the objects of subject sets.
+		Subject string
+		Match   SearchMatch
+	}
+	SearchMatch string
+
+	// MaxLengths are the maximum numbers of characters of relation tuple
+	// identifiers. The limits for namespaces, objects, and relations also
+	// apply to subject sets. Zero means not limited.
+	MaxLengths struct {
+		Namespace, Object, Relation, SubjectID int
+	}
 
 	RelationCollection struct {
 		protoRelations    []*rts.RelationTuple
@@ -42,6 +107,32 @@ type (
 	}
 )
 
+// managerWithObjectsGetter is a wrapping manager that forwards the queries of
+// several objects to the wrapped manager.
+type managerWithObjectsGetter struct {
+	Manager
+	ObjectsGetter
+}
+
+// KeepObjectsGetter returns the wrapper of the manager inner, so that it still
+// implements ObjectsGetter if inner does. It is meant for wrappers that only
+// change writes, the queries of several objects bypass them.
+func KeepObjectsGetter(wrapper, inner Manager) Manager {
+	if getter, ok := inner.(ObjectsGetter); ok {
+		return &managerWithObjectsGetter{Manager: wrapper, ObjectsGetter: getter}
+	}
+	return wrapper
+}
+
+const (
+	// SearchMatchPrefix matches the beginning of the value. It is the
+	// default and can use an index.
+	SearchMatchPrefix SearchMatch = "prefix"
+	// SearchMatchSubstring matches anywhere in the value. It scans all
+	// relation tuples of the namespace.
+	SearchMatchSubstring SearchMatch = "substring"
+)
+
 type RelationQuery struct {
 	// Namespace of the Relation Tuple
 	Namespace string `json:"namespace"`
@@ -62,6 +153,11 @@ type RelationQuery struct {
 	//
 	// swagger:allOf
 	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
+
+	// Labels of the Relation Tuple
+	//
+	// When querying, the relation tuples must have all of the labels.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // swagger:ignore
@@ -96,6 +192,8 @@ type InternalRelationTuple struct {
 	Object    string  `json:"object"`
 	Relation  string  `json:"relation"`
 	Subject   Subject `json:"subject"`
+
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // swagger:parameters getExpand
@@ -119,11 +217,11 @@ type SubjectSet struct {
 var (
 	_, _ Subject = &SubjectID{}, &SubjectSet{}
 
-	ErrMalformedInput    = herodot.ErrBadRequest.WithError("malformed string input")
-	ErrNilSubject        = herodot.ErrBadRequest.WithError("subject is not allowed to be nil").WithDebug("Please provide a subject.")
-	ErrDuplicateSubject  = herodot.ErrBadRequest.WithError("exactly one of subject_set or subject_id has to be provided")
-	ErrDroppedSubjectKey = herodot.ErrBadRequest.WithDebug(`provide "subject_id" or "subject_set.*"; support for "subject" was dropped`)
-	ErrIncompleteSubject = herodot.ErrBadRequest.WithError(`incomplete subject, provide "subject_id" or a complete "subject_set.*"`)
+	ErrMalformedInput    = herodot.ErrBadRequest.WithID(errorcode.TupleMalformed).WithError("malformed string input")
+	ErrNilSubject        = herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithError("subject is not allowed to be nil").WithDebug("Please provide a subject.").WithDetail(errorcode.DetailField, "subject")
+	ErrDuplicateSubject  = herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithError("exactly one of subject_set or subject_id has to be provided").WithDetail(errorcode.DetailField, "subject")
+	ErrDroppedSubjectKey = herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithDebug(`provide "subject_id" or "subject_set.*"; support for "subject" was dropped`).WithDetail(errorcode.DetailField, "subject")
+	ErrIncompleteSubject = herodot.ErrBadRequest.WithID(errorcode.SubjectInvalid).WithError(`incomplete subject, provide "subject_id" or a complete "subject_set.*"`).WithDetail(errorcode.DetailField, "subject")
 )
 
 // swagger:enum patchAction
@@ -304,6 +402,37 @@ func (r *InternalRelationTuple) FromString(s string) (*InternalRelationTuple, er
 	return r, nil
 }
 
+// CheckLengths returns a bad request error naming the first identifier that
+// is longer than allowed.
+func (r *InternalRelationTuple) CheckLengths(max MaxLengths) error {
+	type field struct {
+		name, value string
+		max         int
+	}
+	fields := []field{
+		{"namespace", r.Namespace, max.Namespace},
+		{"object", r.Object, max.Object},
+		{"relation", r.Relation, max.Relation},
+	}
+	switch s := r.Subject.(type) {
+	case *SubjectID:
+		fields = append(fields, field{"subject_id", s.ID, max.SubjectID})
+	case *SubjectSet:
+		fields = append(fields,
+			field{"subject_set.namespace", s.Namespace, max.Namespace},
+			field{"subject_set.object", s.Object, max.Object},
+			field{"subject_set.relation", s.Relation, max.Relation},
+		)
+	}
+
+	for _, f := range fields {
+		if n := utf8.RuneCountInString(f.value); f.max > 0 && n > f.max {
+			return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.TupleFieldTooLong).WithErrorf("%s is %d characters long, but at most %d are allowed", f.name, n, f.max).WithDetail(errorcode.DetailField, f.name))
+		}
+	}
+	return nil
+}
+
 func (r *InternalRelationTuple) DeriveSubject() *SubjectSet {
 	return &SubjectSet{
 		Namespace: r.Namespace,
@@ -326,6 +455,7 @@ func (r *InternalRelationTuple) UnmarshalJSON(raw []byte) error {
 	r.Namespace = rq.Namespace
 	r.Object = rq.Object
 	r.Relation = rq.Relation
+	r.Labels = rq.Labels
 
 	// validation was done before already
 	if rq.SubjectID == nil {
@@ -351,6 +481,7 @@ func (r *InternalRelationTuple) FromDataProvider(d TupleData) (*InternalRelation
 	r.Object = d.GetObject()
 	r.Namespace = d.GetNamespace()
 	r.Relation = d.GetRelation()
+	r.Labels = labelsFromProto(d)
 
 	return r, nil
 }
@@ -361,6 +492,7 @@ func (r *InternalRelationTuple) ToProto() *rts.RelationTuple {
 		Object:    r.Object,
 		Relation:  r.Relation,
 		Subject:   r.Subject.ToProto(),
+		Labels:    r.Labels,
 	}
 }
 
@@ -371,6 +503,7 @@ func (r *InternalRelationTuple) ToQuery() *RelationQuery {
 		Relation:   r.Relation,
 		SubjectID:  r.Subject.SubjectID(),
 		SubjectSet: r.Subject.SubjectSet(),
+		Labels:     r.Labels,
 	}
 }
 
@@ -425,6 +558,7 @@ func (q *RelationQuery) FromProto(query TupleData) (*RelationQuery, error) {
 	q.Namespace = query.GetNamespace()
 	q.Object = query.GetObject()
 	q.Relation = query.GetRelation()
+	q.Labels = labelsFromProto(query)
 	// reset subject
 	q.SubjectID = nil
 	q.SubjectSet = nil
@@ -452,6 +586,7 @@ const (
 	subjectSetNamespaceKey = "subject_set.namespace"
 	subjectSetObjectKey    = "subject_set.object"
 	subjectSetRelationKey  = "subject_set.relation"
+	labelKey               = "label"
 )
 
 func (q *RelationQuery) FromURLQuery(query url.Values) (*RelationQuery, error) {
@@ -488,6 +623,12 @@ func (q *RelationQuery) FromURLQuery(query url.Values) (*RelationQuery, error) {
 	q.Relation = query.Get("relation")
 	q.Namespace = query.Get("namespace")
 
+	labels, err := labelsFromURLQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	q.Labels = labels
+
 	return q, nil
 }
 
@@ -510,6 +651,9 @@ func (q *RelationQuery) ToURLQuery() url.Values {
 		v.Add(subjectSetObjectKey, q.SubjectSet.Object)
 		v.Add(subjectSetRelationKey, q.SubjectSet.Relation)
 	}
+	for _, l := range labelsToURLQuery(q.Labels) {
+		v.Add(labelKey, l)
+	}
 
 	return v
 }
@@ -536,6 +680,7 @@ func (r *InternalRelationTuple) Header() []string {
 		"OBJECT ID",
 		"RELATION NAME",
 		"SUBJECT",
+		"LABELS",
 	}
 }
 
@@ -545,6 +690,7 @@ func (r *InternalRelationTuple) Columns() []string {
 		r.Object,
 		r.Relation,
 		r.Subject.String(),
+		formatLabels(r.Labels),
 	}
 }
 
@@ -570,6 +716,7 @@ func (r *RelationCollection) Header() []string {
 		"OBJECT",
 		"RELATION NAME",
 		"SUBJECT",
+		"LABELS",
 	}
 }
 
@@ -581,7 +728,7 @@ func (r *RelationCollection) Table() [][]string {
 
 	data := make([][]string, len(ir))
 	for i, rel := range ir {
-		data[i] = []string{rel.Namespace, rel.Object, rel.Relation, rel.Subject.String()}
+		data[i] = []string{rel.Namespace, rel.Object, rel.Relation, rel.Subject.String(), formatLabels(rel.Labels)}
 	}
 
 	return data
@@ -649,6 +796,7 @@ type ManagerWrapper struct {
 
 var (
 	_ Manager         = (*ManagerWrapper)(nil)
+	_ ObjectsGetter   = (*ManagerWrapper)(nil)
 	_ ManagerProvider = (*ManagerWrapper)(nil)
 )
 
@@ -665,6 +813,16 @@ func (t *ManagerWrapper) GetRelationTuples(ctx context.Context, query *RelationQ
 	return t.Reg.RelationTupleManager().GetRelationTuples(ctx, query, append(t.PageOpts, options...)...)
 }
 
+func (t *ManagerWrapper) GetRelationTuplesOfObjects(ctx context.Context, query *RelationQuery, objects []string, options ...x.PaginationOptionSetter) ([]*InternalRelationTuple, string, error) {
+	getter, ok := t.Reg.RelationTupleManager().(ObjectsGetter)
+	if !ok {
+		return nil, "", errors.New("the wrapped manager can't query several objects at once")
+	}
+	opts := x.GetPaginationOptions(options...)
+	t.RequestedPages = append(t.RequestedPages, opts.Token)
+	return getter.GetRelationTuplesOfObjects(ctx, query, objects, append(t.PageOpts, options...)...)
+}
+
 func (t *ManagerWrapper) WriteRelationTuples(ctx context.Context, rs ...*InternalRelationTuple) error {
 	return t.Reg.RelationTupleManager().WriteRelationTuples(ctx, rs...)
 }
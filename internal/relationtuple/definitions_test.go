@@ -3,6 +3,7 @@ package relationtuple
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 
+	"github.com/ory/herodot"
 	"github.com/ory/x/pointerx"
 
 	"github.com/pkg/errors"
@@ -464,6 +466,28 @@ func TestInternalRelationTuple(t *testing.T) {
 					},
 				},
 			},
+			{
+				proto: &rts.RelationTuple{
+					Namespace: "n",
+					Object:    "o",
+					Relation:  "r",
+					Subject: &rts.Subject{
+						Ref: &rts.Subject_Id{
+							Id: "user",
+						},
+					},
+					Labels: map[string]string{"source": "scim"},
+				},
+				expected: &InternalRelationTuple{
+					Namespace: "n",
+					Object:    "o",
+					Relation:  "r",
+					Subject: &SubjectID{
+						ID: "user",
+					},
+					Labels: map[string]string{"source": "scim"},
+				},
+			},
 		} {
 			t.Run(fmt.Sprintf("case=%d", i), func(t *testing.T) {
 				actual, err := (&InternalRelationTuple{}).FromDataProvider(tc.proto)
@@ -518,6 +542,26 @@ func TestInternalRelationTuple(t *testing.T) {
 		"object": "so",
 		"relation": "sr"
 	}
+}`,
+				},
+				{
+					name: "with labels",
+					rt: &InternalRelationTuple{
+						Namespace: "n",
+						Object:    "o",
+						Relation:  "r",
+						Subject:   &SubjectID{ID: "s"},
+						Labels:    map[string]string{"source": "scim"},
+					},
+					expected: `
+{
+	"namespace": "n",
+	"object": "o",
+	"relation": "r",
+	"subject_id": "s",
+	"labels": {
+		"source": "scim"
+	}
 }`,
 				},
 			} {
@@ -535,6 +579,52 @@ func TestInternalRelationTuple(t *testing.T) {
 	})
 }
 
+func TestCheckLengths(t *testing.T) {
+	max := MaxLengths{Namespace: 3, Object: 4, Relation: 5, SubjectID: 6}
+
+	for _, tc := range []struct {
+		rt       *InternalRelationTuple
+		expected string
+	}{
+		{rt: &InternalRelationTuple{Namespace: "nnn", Object: "oooo", Relation: "rrrrr", Subject: &SubjectID{ID: "ssssss"}}},
+		{rt: &InternalRelationTuple{Namespace: "nnn", Object: "öööö", Relation: "r", Subject: &SubjectID{ID: "s"}}},
+		{
+			rt:       &InternalRelationTuple{Namespace: "nnnn", Object: "o", Relation: "r", Subject: &SubjectID{ID: "s"}},
+			expected: "namespace is 4 characters long, but at most 3 are allowed",
+		},
+		{
+			rt:       &InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &SubjectID{ID: "sssssss"}},
+			expected: "subject_id is 7 characters long, but at most 6 are allowed",
+		},
+		{
+			rt:       &InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &SubjectSet{Namespace: "n", Object: "ooooo", Relation: "r"}},
+			expected: "subject_set.object is 5 characters long, but at most 4 are allowed",
+		},
+		{
+			rt:       &InternalRelationTuple{Namespace: "n", Object: "o", Relation: "r", Subject: &SubjectSet{Namespace: "n", Object: "o", Relation: "rrrrrr"}},
+			expected: "subject_set.relation is 6 characters long, but at most 5 are allowed",
+		},
+	} {
+		t.Run("case="+tc.rt.String(), func(t *testing.T) {
+			err := tc.rt.CheckLengths(max)
+			if tc.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var herr *herodot.DefaultError
+			require.True(t, errors.As(err, &herr))
+			assert.Equal(t, http.StatusBadRequest, herr.StatusCode())
+			assert.Equal(t, tc.expected, herr.Error())
+		})
+	}
+
+	t.Run("case=zero is not limited", func(t *testing.T) {
+		rt := &InternalRelationTuple{Namespace: "nnnnnnnn", Object: "o", Relation: "r", Subject: &SubjectID{ID: "s"}}
+		assert.NoError(t, rt.CheckLengths(MaxLengths{Object: 1, Relation: 1, SubjectID: 1}))
+	})
+}
+
 func TestRelationQuery(t *testing.T) {
 	t.Run("case=url encoding-decoding-encoding", func(t *testing.T) {
 		for i, tc := range []struct {
@@ -585,6 +675,16 @@ func TestRelationQuery(t *testing.T) {
 					Relation:  "r",
 				},
 			},
+			{
+				v: url.Values{
+					"namespace": []string{"n"},
+					"label":     []string{"source=scim", "temporary=", "x=a=b"},
+				},
+				r: &RelationQuery{
+					Namespace: "n",
+					Labels:    map[string]string{"source": "scim", "temporary": "", "x": "a=b"},
+				},
+			},
 		} {
 			t.Run(fmt.Sprintf("case=%d", i), func(t *testing.T) {
 				enc := tc.r.ToURLQuery()
@@ -596,6 +696,13 @@ func TestRelationQuery(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("case=malformed labels", func(t *testing.T) {
+		for _, l := range []string{"source", "=scim", ""} {
+			_, err := (&RelationQuery{}).FromURLQuery(url.Values{"label": []string{l}})
+			assert.ErrorIs(t, err, ErrMalformedLabel, l)
+		}
+	})
 }
 
 func TestRelationCollection(t *testing.T) {
@@ -614,6 +721,7 @@ func TestRelationCollection(t *testing.T) {
 			Object:    "so",
 			Relation:  "sr",
 		}
+		expected[1].Labels = map[string]string{"source": "scim", "temporary": "true"}
 
 		proto := make([]*rts.RelationTuple, 3)
 		for i := range expected {
@@ -629,6 +737,7 @@ func TestRelationCollection(t *testing.T) {
 			Object:    "so",
 			Relation:  "sr",
 		}).ToProto()
+		proto[1].Labels = expected[1].Labels
 
 		NewRelationCollection([]*InternalRelationTuple{})
 		NewProtoRelationCollection([]*rts.RelationTuple{})
@@ -657,6 +766,8 @@ func TestRelationCollection(t *testing.T) {
 						switch v.Kind() {
 						case reflect.String:
 							assert.Contains(t, vals, v.String())
+						case reflect.Map:
+							assert.Contains(t, vals, formatLabels(v.Interface().(map[string]string)))
 						default:
 							str := v.MethodByName("String").Call(nil)[0].String()
 							assert.Contains(t, vals, str)
@@ -1,6 +1,9 @@
 package relationtuple
 
 import (
+	"context"
+	"time"
+
 	"google.golang.org/grpc"
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
@@ -9,8 +12,24 @@ import (
 )
 
 type (
+	// Config is the part of the config the handler reads, implemented by
+	// *config.Config. The package does not import the config, so that the
+	// relation tuple types also build for js/wasm.
+	Config interface {
+		TemplateSource
+		DefaultPageSize() int
+		MaxPageSize() int
+		WatchPollInterval() time.Duration
+	}
+	ConfigProvider interface {
+		RelationTupleConfig(ctx context.Context) Config
+	}
 	handlerDeps interface {
 		ManagerProvider
+		CounterProvider
+		SearcherProvider
+		SnapshotterProvider
+		ConfigProvider
 		x.LoggerProvider
 		x.WriterProvider
 	}
@@ -26,11 +45,16 @@ type GetResponse struct {
 	// to get the next page. It is the empty string iff this is
 	// the last page.
 	NextPageToken string `json:"next_page_token"`
+	// The total number of relation tuples matching the query. It is only
+	// returned if the request set `count=true`.
+	TotalCount *int64 `json:"total_count,omitempty"`
 }
 
 const (
-	ReadRouteBase  = "/relation-tuples"
-	WriteRouteBase = "/admin/relation-tuples"
+	ReadRouteBase     = "/relation-tuples"
+	WriteRouteBase    = "/admin/relation-tuples"
+	SearchRouteBase   = WriteRouteBase + "/search"
+	SubjectsRouteBase = WriteRouteBase + "/subjects"
 )
 
 func NewHandler(d handlerDeps) *handler {
@@ -41,12 +65,15 @@ func NewHandler(d handlerDeps) *handler {
 
 func (h *handler) RegisterReadRoutes(r *x.ReadRouter) {
 	r.GET(ReadRouteBase, h.getRelations)
+	r.GET(WatchRoute, h.watch)
 }
 
 func (h *handler) RegisterWriteRoutes(r *x.WriteRouter) {
 	r.PUT(WriteRouteBase, h.createRelation)
 	r.DELETE(WriteRouteBase, h.deleteRelations)
 	r.PATCH(WriteRouteBase, h.patchRelations)
+	r.GET(SearchRouteBase, h.searchRelations)
+	r.PATCH(SubjectsRouteBase, h.patchSubjects)
 }
 
 func (h *handler) RegisterReadGRPC(s *grpc.Server) {
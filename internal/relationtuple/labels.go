@@ -0,0 +1,75 @@
+package relationtuple
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+)
+
+// ErrMalformedLabel is returned for labels that are not of the form
+// "key=value" or have an empty key.
+var ErrMalformedLabel = herodot.ErrBadRequest.WithID(errorcode.LabelMalformed).WithError(`malformed label, expected "key=value" with a non-empty key`)
+
+// labelsFromProto returns the labels of relation tuples and queries, which
+// are the proto messages with a labels field.
+func labelsFromProto(d interface{}) map[string]string {
+	l, ok := d.(interface{ GetLabels() map[string]string })
+	if !ok || len(l.GetLabels()) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(l.GetLabels()))
+	for k, v := range l.GetLabels() {
+		labels[k] = v
+	}
+	return labels
+}
+
+// ParseLabel parses a label of the form "key=value".
+func ParseLabel(s string) (key, value string, err error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return "", "", errors.WithStack(ErrMalformedLabel.WithDebugf("got %q", s))
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// ParseLabels parses labels of the form "key=value". It returns nil if there
+// are no labels.
+func ParseLabels(ss []string) (map[string]string, error) {
+	if len(ss) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(ss))
+	for _, s := range ss {
+		k, v, err := ParseLabel(s)
+		if err != nil {
+			return nil, err
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+func labelsFromURLQuery(query url.Values) (map[string]string, error) {
+	return ParseLabels(query[labelKey])
+}
+
+// labelsToURLQuery formats the labels sorted by key, so that equal queries
+// have equal URLs.
+func labelsToURLQuery(labels map[string]string) []string {
+	ls := make([]string, 0, len(labels))
+	for k, v := range labels {
+		ls = append(ls, k+"="+v)
+	}
+	sort.Strings(ls)
+	return ls
+}
+
+func formatLabels(labels map[string]string) string {
+	return strings.Join(labelsToURLQuery(labels), ",")
+}
@@ -2,17 +2,16 @@ package relationtuple
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strconv"
 	"testing"
 
 	"github.com/ory/x/pointerx"
 
-	"github.com/ory/herodot"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/x"
 )
 
@@ -63,7 +62,7 @@ func ManagerTest(t *testing.T, m Manager,
 				Subject:   &SubjectID{},
 			})
 			assert.NotNil(t, err)
-			assert.True(t, errors.Is(err, herodot.ErrNotFound), "actual error: %+v", err)
+			assert.True(t, errorcode.Is(err, errorcode.NamespaceUnknown), "actual error: %+v", err)
 		})
 	})
 
@@ -248,6 +247,112 @@ func ManagerTest(t *testing.T, m Manager,
 			assert.Equal(t, notEncounteredTuples, res)
 		})
 
+		t.Run("case=pagination with concurrent writes", func(t *testing.T) {
+			nspace := t.Name()
+			addNamespace(context.Background(), t, nspace)
+
+			tuple := func(i int) *InternalRelationTuple {
+				return &InternalRelationTuple{
+					Namespace: nspace,
+					Object:    "o",
+					Relation:  "r",
+					Subject:   &SubjectID{ID: strconv.Itoa(i)},
+				}
+			}
+			for i := 0; i < 10; i++ {
+				require.NoError(t, m.WriteRelationTuples(context.Background(), tuple(i)))
+			}
+
+			var (
+				encountered []*InternalRelationTuple
+				nextPage    string
+				written     = 10
+			)
+			for {
+				res, next, err := m.GetRelationTuples(context.Background(), &RelationQuery{Namespace: nspace}, x.WithSize(3), x.WithToken(nextPage))
+				require.NoError(t, err)
+				encountered = append(encountered, res...)
+				if next == "" {
+					break
+				}
+				nextPage = next
+
+				// tuples written while paginating are returned on later pages
+				require.NoError(t, m.WriteRelationTuples(context.Background(), tuple(written)))
+				written++
+			}
+
+			// all tuples are returned exactly once, in the order they were written
+			expected := make([]*InternalRelationTuple, written)
+			for i := range expected {
+				expected[i] = tuple(i)
+			}
+			assert.Equal(t, expected, encountered)
+		})
+
+		t.Run("case=order", func(t *testing.T) {
+			nspace := t.Name()
+			addNamespace(context.Background(), t, nspace)
+
+			tuples := []*InternalRelationTuple{
+				{Namespace: nspace, Object: "c", Relation: "r", Subject: &SubjectID{ID: "b"}},
+				{Namespace: nspace, Object: "e", Relation: "r", Subject: &SubjectSet{Namespace: nspace, Object: "y", Relation: "r"}},
+				{Namespace: nspace, Object: "a", Relation: "r", Subject: &SubjectID{ID: "c"}},
+				{Namespace: nspace, Object: "d", Relation: "r", Subject: &SubjectID{ID: "a"}},
+				{Namespace: nspace, Object: "b", Relation: "r", Subject: &SubjectSet{Namespace: nspace, Object: "x", Relation: "r"}},
+			}
+			for _, tup := range tuples {
+				require.NoError(t, m.WriteRelationTuples(context.Background(), tup))
+			}
+
+			for _, tc := range []struct {
+				by         x.OrderBy
+				descending bool
+				expected   []int
+			}{
+				{by: x.OrderByCommitTime, expected: []int{0, 1, 2, 3, 4}},
+				{by: x.OrderByCommitTime, descending: true, expected: []int{4, 3, 2, 1, 0}},
+				{by: x.OrderByObject, expected: []int{2, 4, 0, 3, 1}},
+				{by: x.OrderByObject, descending: true, expected: []int{1, 3, 0, 4, 2}},
+				{by: x.OrderBySubject, expected: []int{3, 0, 2, 4, 1}},
+				{by: x.OrderBySubject, descending: true, expected: []int{1, 4, 2, 0, 3}},
+			} {
+				t.Run(fmt.Sprintf("order_by=%s/descending=%v", tc.by, tc.descending), func(t *testing.T) {
+					var (
+						encountered []*InternalRelationTuple
+						nextPage    string
+					)
+					for {
+						res, next, err := m.GetRelationTuples(context.Background(), &RelationQuery{Namespace: nspace},
+							x.WithSize(2), x.WithToken(nextPage), x.WithOrder(tc.by, tc.descending))
+						require.NoError(t, err)
+						encountered = append(encountered, res...)
+						if next == "" {
+							break
+						}
+						nextPage = next
+					}
+
+					expected := make([]*InternalRelationTuple, len(tc.expected))
+					for i, j := range tc.expected {
+						expected[i] = tuples[j]
+					}
+					assert.Equal(t, expected, encountered)
+				})
+			}
+
+			t.Run("case=token of another order", func(t *testing.T) {
+				_, next, err := m.GetRelationTuples(context.Background(), &RelationQuery{Namespace: nspace},
+					x.WithSize(2), x.WithOrder(x.OrderByObject, false))
+				require.NoError(t, err)
+				require.NotEqual(t, "", next)
+
+				_, _, err = m.GetRelationTuples(context.Background(), &RelationQuery{Namespace: nspace},
+					x.WithSize(2), x.WithToken(next), x.WithOrder(x.OrderBySubject, false))
+				assert.Error(t, err)
+			})
+		})
+
 		t.Run("case=empty list", func(t *testing.T) {
 			nspace := t.Name()
 			addNamespace(context.Background(), t, nspace)
@@ -382,6 +487,61 @@ func ManagerTest(t *testing.T, m Manager,
 		})
 	})
 
+	t.Run("method=Labels", func(t *testing.T) {
+		ctx := context.Background()
+		nspace := t.Name()
+		addNamespace(ctx, t, nspace)
+
+		rs := []*InternalRelationTuple{
+			{
+				Namespace: nspace,
+				Object:    "o0",
+				Relation:  "r",
+				Subject:   &SubjectID{ID: "s"},
+				Labels:    map[string]string{"source": "scim"},
+			},
+			{
+				Namespace: nspace,
+				Object:    "o1",
+				Relation:  "r",
+				Subject:   &SubjectID{ID: "s"},
+				Labels:    map[string]string{"source": "scim", "temporary": "true"},
+			},
+			{
+				Namespace: nspace,
+				Object:    "o2",
+				Relation:  "r",
+				Subject:   &SubjectID{ID: "s"},
+			},
+		}
+		require.NoError(t, m.WriteRelationTuples(ctx, rs...))
+
+		for i, tc := range []struct {
+			labels   map[string]string
+			expected []*InternalRelationTuple
+		}{
+			{labels: nil, expected: rs},
+			{labels: map[string]string{"source": "scim"}, expected: rs[:2]},
+			{labels: map[string]string{"source": "scim", "temporary": "true"}, expected: rs[1:2]},
+			{labels: map[string]string{"source": "ldap"}, expected: []*InternalRelationTuple{}},
+			{labels: map[string]string{"temporary": ""}, expected: []*InternalRelationTuple{}},
+		} {
+			t.Run(fmt.Sprintf("case=%d", i), func(t *testing.T) {
+				res, _, err := m.GetRelationTuples(ctx, &RelationQuery{Namespace: nspace, Labels: tc.labels})
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, res)
+			})
+		}
+
+		t.Run("case=delete by label", func(t *testing.T) {
+			require.NoError(t, m.DeleteAllRelationTuples(ctx, &RelationQuery{Namespace: nspace, Labels: map[string]string{"source": "scim"}}))
+
+			res, _, err := m.GetRelationTuples(ctx, &RelationQuery{Namespace: nspace})
+			require.NoError(t, err)
+			assert.Equal(t, rs[2:], res)
+		})
+	})
+
 	t.Run("method=Transact", func(t *testing.T) {
 		t.Run("case=success", func(t *testing.T) {
 			nspace := t.Name()
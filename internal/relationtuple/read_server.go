@@ -3,6 +3,7 @@ package relationtuple
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
@@ -13,7 +14,9 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/ory/keto/internal/errorcode"
 	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/internal/requestid"
 )
 
 var (
@@ -31,9 +34,20 @@ func (h *handler) ListRelationTuples(ctx context.Context, req *rts.ListRelationT
 		return nil, err
 	}
 
+	size, err := h.pageSize(ctx, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := x.ParseOrder(req.OrderBy, req.Order)
+	if err != nil {
+		return nil, err
+	}
+
 	rels, nextPage, err := h.d.RelationTupleManager().GetRelationTuples(ctx, q,
-		x.WithSize(int(req.PageSize)),
+		x.WithSize(size),
 		x.WithToken(req.PageToken),
+		order,
 	)
 	if err != nil {
 		return nil, err
@@ -47,9 +61,93 @@ func (h *handler) ListRelationTuples(ctx context.Context, req *rts.ListRelationT
 		resp.RelationTuples[i] = r.ToProto()
 	}
 
+	if req.Count {
+		if resp.TotalCount, err = h.count(ctx, q); err != nil {
+			return nil, err
+		}
+	}
+
 	return resp, nil
 }
 
+// keyLimitMaxPageSize is the config key of the max page size.
+const keyLimitMaxPageSize = "limit.max_page_size"
+
+// pageSize applies the configured default and maximum to the requested page
+// size. Zero requests the default.
+func (h *handler) pageSize(ctx context.Context, requested int) (int, error) {
+	limit := h.d.RelationTupleConfig(ctx).MaxPageSize()
+	switch {
+	case requested == 0:
+		return h.d.RelationTupleConfig(ctx).DefaultPageSize(), nil
+	case requested < 0:
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.PageSizeInvalid).WithErrorf("page size %d must not be negative", requested).WithDetail(errorcode.DetailField, "page_size"))
+	case requested > limit:
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.PageSizeInvalid).WithErrorf("page size %d exceeds the maximum of %d", requested, limit).WithDetail(errorcode.DetailLimit, keyLimitMaxPageSize))
+	}
+	return requested, nil
+}
+
+// urlPageSize parses the page_size URL parameter and applies the limits.
+func (h *handler) urlPageSize(ctx context.Context, q url.Values) (int, error) {
+	var requested int64
+	if raw := q.Get("page_size"); raw != "" {
+		var err error
+		if requested, err = strconv.ParseInt(raw, 0, 0); err != nil {
+			return 0, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error()))
+		}
+	}
+	return h.pageSize(ctx, int(requested))
+}
+
+// etag returns the ETag of a list response, derived from the latest change of
+// the namespace of the query and the query of the request. It is empty if the
+// storage backend does not track snapshots. The change token is read before
+// the relation tuples, so a concurrent write results in an outdated tag and
+// another download, but never in a missed update.
+func (h *handler) etag(r *http.Request) string {
+	s := h.d.RelationTupleSnapshotter()
+	if s == nil {
+		return ""
+	}
+	var (
+		token string
+		err   error
+	)
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		token, err = s.NamespaceChangeToken(r.Context(), namespace)
+	} else {
+		token, err = s.SnapshotToken(r.Context())
+	}
+	if err != nil {
+		requestid.Logger(r.Context(), h.d.Logger()).WithError(err).Warn("Could not get the snapshot, the response has no ETag.")
+		return ""
+	}
+	return x.ETag(token, r.URL.Query().Encode())
+}
+
+// notModified writes the 304 response if the If-None-Match header matches
+// the ETag.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && x.ETagMatches(inm, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+func (h *handler) count(ctx context.Context, q *RelationQuery) (int64, error) {
+	c := h.d.RelationTupleCounter()
+	if c == nil {
+		return 0, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not support counting relation tuples"))
+	}
+	return c.CountRelationTuples(ctx, q)
+}
+
 // swagger:parameters getRelationTuples
 type getRelationsParams struct {
 	// Namespace of the Relation Tuple
@@ -91,6 +189,33 @@ type getRelationsParams struct {
 	// Either subject_set.* or subject_id are required.
 	SRelation string `json:"subject_set.relation"`
 
+	// Labels of the Relation Tuple, formatted as "key=value"
+	//
+	// in: query
+	// collection format: multi
+	// The relation tuples must have all labels.
+	Labels []string `json:"label"`
+
+	// Return the total number of matching relation tuples
+	//
+	// in: query
+	Count bool `json:"count"`
+
+	// Sort the relation tuples by this field
+	//
+	// in: query
+	// enum: commit_time,object,subject
+	// default: commit_time
+	// When sorting by subject, subject IDs come before subject sets.
+	OrderBy string `json:"order_by"`
+
+	// Sort direction
+	//
+	// in: query
+	// enum: asc,desc
+	// default: asc
+	Order string `json:"order"`
+
 	// swagger:allOf
 	x.PaginationOptions
 }
@@ -101,6 +226,16 @@ type getRelationsParams struct {
 //
 // Get all relation tuples that match the query. Only the namespace field is required.
 //
+// Responses carry an ETag. Send it in the If-None-Match header to get an
+// empty 304 response if no relation tuples of the namespace were written or
+// deleted since.
+//
+// By default, relation tuples are returned in the order their writes
+// committed. Tuples that are written while paginating are then returned on a
+// later page, none are missed. The listing is not a consistent snapshot
+// though: tuples deleted while paginating are not returned if they were not
+// read yet. Page tokens are only valid for the order they were returned for.
+//
 //     Consumes:
 //     -  application/x-www-form-urlencoded
 //
@@ -111,9 +246,11 @@ type getRelationsParams struct {
 //
 //     Responses:
 //       200: getRelationTuplesResponse
+//       304: emptyResponse
 //       404: genericError
 //       500: genericError
 func (h *handler) getRelations(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	etag := h.etag(r)
 	q := r.URL.Query()
 	query, err := (&RelationQuery{}).FromURLQuery(q)
 	if err != nil {
@@ -121,7 +258,7 @@ func (h *handler) getRelations(w http.ResponseWriter, r *http.Request, _ httprou
 		return
 	}
 
-	l := h.d.Logger()
+	l := requestid.Logger(r.Context(), h.d.Logger())
 	for k := range q {
 		l = l.WithField(k, q.Get(k))
 	}
@@ -132,13 +269,30 @@ func (h *handler) getRelations(w http.ResponseWriter, r *http.Request, _ httprou
 		paginationOpts = append(paginationOpts, x.WithToken(pageToken))
 	}
 
-	if pageSize := q.Get("page_size"); pageSize != "" {
-		s, err := strconv.ParseInt(pageSize, 0, 0)
-		if err != nil {
+	size, err := h.urlPageSize(r.Context(), q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	paginationOpts = append(paginationOpts, x.WithSize(size))
+
+	order, err := x.ParseOrder(q.Get("order_by"), q.Get("order"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	paginationOpts = append(paginationOpts, order)
+
+	var count bool
+	if c := q.Get("count"); c != "" {
+		if count, err = strconv.ParseBool(c); err != nil {
 			h.d.Writer().WriteError(w, r, herodot.ErrBadRequest.WithError(err.Error()))
 			return
 		}
-		paginationOpts = append(paginationOpts, x.WithSize(int(s)))
+	}
+
+	if notModified(w, r, etag) {
+		return
 	}
 
 	rels, nextPage, err := h.d.RelationTupleManager().GetRelationTuples(r.Context(), query, paginationOpts...)
@@ -151,6 +305,14 @@ func (h *handler) getRelations(w http.ResponseWriter, r *http.Request, _ httprou
 		RelationTuples: rels,
 		NextPageToken:  nextPage,
 	}
+	if count {
+		total, err := h.count(r.Context(), query)
+		if err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+		resp.TotalCount = &total
+	}
 
 	h.d.Writer().Write(w, r, resp)
 }
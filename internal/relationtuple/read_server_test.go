@@ -3,6 +3,7 @@ package relationtuple_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -118,6 +119,47 @@ func TestReadHandlers(t *testing.T) {
 			assert.Equal(t, "", respMsg.NextPageToken)
 		})
 
+		t.Run("case=filters by label", func(t *testing.T) {
+			obj := t.Name()
+
+			rts := []*relationtuple.InternalRelationTuple{
+				{
+					Namespace: nspace.Name,
+					Object:    obj,
+					Relation:  "r1",
+					Subject:   &relationtuple.SubjectID{ID: "s1"},
+					Labels:    map[string]string{"source": "scim"},
+				},
+				{
+					Namespace: nspace.Name,
+					Object:    obj,
+					Relation:  "r1",
+					Subject:   &relationtuple.SubjectID{ID: "s2"},
+				},
+			}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), rts...))
+
+			resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{
+				"object": {obj},
+				"label":  {"source=scim"},
+			}.Encode())
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var respMsg relationtuple.GetResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&respMsg))
+			assert.Equal(t, rts[:1], respMsg.RelationTuples)
+		})
+
+		t.Run("case=returns bad request on malformed label", func(t *testing.T) {
+			resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{
+				"label": {"scim"},
+			}.Encode())
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+
 		t.Run("case=returns bad request on malformed subject", func(t *testing.T) {
 			resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{
 				"subject": {"not#a valid subject"},
@@ -194,5 +236,232 @@ func TestReadHandlers(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 			assert.Contains(t, string(body), "invalid syntax")
 		})
+
+		t.Run("case=sorts by the requested order", func(t *testing.T) {
+			get := func(t *testing.T, orderBy, order string) (int, []byte) {
+				resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{
+					"namespace": {nspace.Name},
+					"order_by":  {orderBy},
+					"order":     {order},
+				}.Encode())
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				return resp.StatusCode, body
+			}
+
+			status, body := get(t, "object", "desc")
+			require.Equal(t, http.StatusOK, status, "%s", body)
+			objects := gjson.GetBytes(body, "relation_tuples.#.object").Array()
+			require.NotEmpty(t, objects)
+			for i := 1; i < len(objects); i++ {
+				assert.GreaterOrEqual(t, objects[i-1].Str, objects[i].Str, "%s", body)
+			}
+
+			status, body = get(t, "relation", "")
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Contains(t, string(body), `unknown order_by \"relation\"`)
+
+			status, body = get(t, "", "up")
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Contains(t, string(body), `unknown order \"up\"`)
+		})
+
+		t.Run("case=page size limits", func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, reg.Config(ctx).Set(config.KeyLimitDefaultPageSize, 1))
+			require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxPageSize, 2))
+			t.Cleanup(func() {
+				require.NoError(t, reg.Config(ctx).Set(config.KeyLimitDefaultPageSize, 100))
+				require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxPageSize, 1000))
+			})
+
+			get := func(t *testing.T, pageSize string) (int, []byte) {
+				resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{
+					"namespace": {nspace.Name},
+					"page_size": {pageSize},
+				}.Encode())
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				return resp.StatusCode, body
+			}
+
+			t.Run("case=applies the default", func(t *testing.T) {
+				status, body := get(t, "")
+				require.Equal(t, http.StatusOK, status, "%s", body)
+				assert.Len(t, gjson.GetBytes(body, "relation_tuples").Array(), 1)
+				assert.NotEmpty(t, gjson.GetBytes(body, "next_page_token").Str)
+			})
+
+			t.Run("case=allows the maximum", func(t *testing.T) {
+				status, body := get(t, "2")
+				require.Equal(t, http.StatusOK, status, "%s", body)
+				assert.Len(t, gjson.GetBytes(body, "relation_tuples").Array(), 2)
+			})
+
+			t.Run("case=rejects larger sizes", func(t *testing.T) {
+				status, body := get(t, "3")
+				assert.Equal(t, http.StatusBadRequest, status)
+				assert.Contains(t, string(body), "page size 3 exceeds the maximum of 2")
+			})
+
+			t.Run("case=rejects negative sizes", func(t *testing.T) {
+				status, body := get(t, "-1")
+				assert.Equal(t, http.StatusBadRequest, status)
+				assert.Contains(t, string(body), "must not be negative")
+			})
+		})
+
+		t.Run("case=counts matching tuples", func(t *testing.T) {
+			nspace := &namespace.Namespace{Name: "count test", ID: 1}
+			ctx := context.Background()
+			require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "relation tuple read test"}, nspace}))
+
+			tuples := make([]*relationtuple.InternalRelationTuple, 5)
+			for i := range tuples {
+				tuples[i] = &relationtuple.InternalRelationTuple{
+					Namespace: nspace.Name,
+					Object:    fmt.Sprintf("o%d", i%2),
+					Relation:  "r",
+					Subject:   &relationtuple.SubjectID{ID: fmt.Sprintf("s%d", i)},
+				}
+			}
+			tuples[0].Labels = map[string]string{"source": "scim"}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuples...))
+
+			for _, tc := range []struct {
+				query url.Values
+				count int64
+			}{
+				{query: url.Values{"namespace": {nspace.Name}, "count": {"true"}, "page_size": {"2"}}, count: 5},
+				{query: url.Values{"namespace": {nspace.Name}, "object": {"o0"}, "count": {"true"}}, count: 3},
+				{query: url.Values{"namespace": {nspace.Name}, "label": {"source=scim"}, "count": {"true"}}, count: 1},
+				{query: url.Values{"namespace": {nspace.Name}, "object": {"unknown"}, "count": {"true"}}, count: 0},
+			} {
+				resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + tc.query.Encode())
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				var respMsg relationtuple.GetResponse
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&respMsg))
+				require.NotNil(t, respMsg.TotalCount, "%v", tc.query)
+				assert.Equal(t, tc.count, *respMsg.TotalCount, "%v", tc.query)
+			}
+
+			t.Run("case=omitted unless requested", func(t *testing.T) {
+				resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{"namespace": {nspace.Name}}.Encode())
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.False(t, gjson.GetBytes(body, "total_count").Exists(), "%s", body)
+			})
+		})
+
+		t.Run("case=conditional get", func(t *testing.T) {
+			ctx := context.Background()
+			u := ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{"namespace": {nspace.Name}, "object": {"etag"}}.Encode()
+			get := func(t *testing.T, etag string) *http.Response {
+				req, err := http.NewRequest(http.MethodGet, u, nil)
+				require.NoError(t, err)
+				if etag != "" {
+					req.Header.Set("If-None-Match", etag)
+				}
+				resp, err := ts.Client().Do(req)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = resp.Body.Close() })
+				return resp
+			}
+
+			resp := get(t, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			etag := resp.Header.Get("ETag")
+			require.NotEmpty(t, etag)
+
+			resp = get(t, etag)
+			assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+			assert.Equal(t, etag, resp.Header.Get("ETag"))
+
+			t.Run("case=other queries have other tags", func(t *testing.T) {
+				resp, err := ts.Client().Get(ts.URL + relationtuple.ReadRouteBase + "?" + url.Values{"namespace": {nspace.Name}}.Encode())
+				require.NoError(t, err)
+				defer resp.Body.Close()
+				assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+			})
+
+			t.Run("case=writes change the tag", func(t *testing.T) {
+				require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+					Namespace: nspace.Name,
+					Object:    "etag",
+					Relation:  "r",
+					Subject:   &relationtuple.SubjectID{ID: "s"},
+				}))
+
+				resp := get(t, etag)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Len(t, gjson.GetBytes(body, "relation_tuples").Array(), 1)
+			})
+
+			t.Run("case=changes of other namespaces keep the tag", func(t *testing.T) {
+				other := &namespace.Namespace{Name: "other", ID: 2}
+				require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{nspace, other}))
+				etag := get(t, "").Header.Get("ETag")
+				require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+					Namespace: other.Name,
+					Object:    "etag",
+					Relation:  "r",
+					Subject:   &relationtuple.SubjectID{ID: "s"},
+				}))
+
+				resp := get(t, etag)
+				assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+			})
+
+			t.Run("case=deletions change the tag", func(t *testing.T) {
+				etag := get(t, "").Header.Get("ETag")
+				require.NoError(t, reg.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: nspace.Name, Object: "etag"}))
+
+				resp := get(t, etag)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Len(t, gjson.GetBytes(body, "relation_tuples").Array(), 0)
+			})
+		})
+	})
+
+	t.Run("method=watch", func(t *testing.T) {
+		watch := func(t *testing.T, snapshot, timeout string) string {
+			resp, err := ts.Client().Get(ts.URL + relationtuple.WatchRoute + "?" + url.Values{
+				"snapshot": {snapshot},
+				"timeout":  {timeout},
+			}.Encode())
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body relationtuple.WatchResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			return body.Snapshot
+		}
+
+		initial := watch(t, "", "")
+		require.NotEmpty(t, initial)
+		assert.Equal(t, initial, watch(t, initial, "100ms"))
+
+		changed := make(chan string)
+		go func() {
+			changed <- watch(t, initial, "5s")
+		}()
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), &relationtuple.InternalRelationTuple{
+			Namespace: nspace.Name,
+			Object:    "watched",
+			Relation:  "r",
+			Subject:   &relationtuple.SubjectID{ID: "s"},
+		}))
+		assert.NotEqual(t, initial, <-changed)
 	})
 }
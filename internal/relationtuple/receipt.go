@@ -0,0 +1,101 @@
+package relationtuple
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+type (
+	// StoredRelationTuple is a relation tuple as the storage backend stored
+	// it, i.e. with the object and subject normalized.
+	StoredRelationTuple struct {
+		*InternalRelationTuple
+		ID         uuid.UUID
+		CommitTime time.Time
+	}
+	// WriteReceipt collects the relation tuples that the storage backend
+	// stored while writing with the context of the receipt. Storage backends
+	// that don't support receipts leave it empty.
+	WriteReceipt struct {
+		mu            sync.Mutex
+		written       []*StoredRelationTuple
+		snapshotToken string
+	}
+	writeReceiptContextKey struct{}
+)
+
+// WithWriteReceipt returns a context in which the storage backend records the
+// stored relation tuples in the receipt.
+func WithWriteReceipt(ctx context.Context) (context.Context, *WriteReceipt) {
+	r := &WriteReceipt{}
+	return context.WithValue(ctx, writeReceiptContextKey{}, r), r
+}
+
+// WriteReceiptFromContext returns the receipt of the context, or nil.
+func WriteReceiptFromContext(ctx context.Context) *WriteReceipt {
+	r, _ := ctx.Value(writeReceiptContextKey{}).(*WriteReceipt)
+	return r
+}
+
+// Record adds the stored relation tuples to the receipt.
+func (r *WriteReceipt) Record(stored ...*StoredRelationTuple) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written = append(r.written, stored...)
+}
+
+// Reset empties the receipt, e.g. before a transaction is retried.
+func (r *WriteReceipt) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.written = nil
+	r.snapshotToken = ""
+}
+
+// Written returns the stored relation tuples in the order they were written.
+func (r *WriteReceipt) Written() []*StoredRelationTuple {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*StoredRelationTuple(nil), r.written...)
+}
+
+// SetSnapshotToken records a snapshot token of a snapshot that includes the
+// write.
+func (r *WriteReceipt) SetSnapshotToken(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshotToken = token
+}
+
+// SnapshotToken returns a snapshot token of a snapshot that includes the
+// write, or an empty string if the storage backend does not support snapshots.
+func (r *WriteReceipt) SnapshotToken() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshotToken
+}
+
+func (s *StoredRelationTuple) ToProto() *rts.StoredRelationTuple {
+	return &rts.StoredRelationTuple{
+		RelationTuple: s.InternalRelationTuple.ToProto(),
+		Id:            s.ID.String(),
+		CommitTime:    timestamppb.New(s.CommitTime),
+	}
+}
+
+// ToCreated returns the REST representation of the stored relation tuple.
+func (s *StoredRelationTuple) ToCreated(snapshotToken string) *CreatedRelationTuple {
+	commitTime := s.CommitTime.UTC()
+	return &CreatedRelationTuple{
+		RelationQuery: *s.ToQuery(),
+		ID:            s.ID.String(),
+		CommitTime:    &commitTime,
+		SnapshotToken: snapshotToken,
+	}
+}
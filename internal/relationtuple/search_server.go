@@ -0,0 +1,100 @@
+package relationtuple
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+// swagger:parameters searchRelationTuples
+// nolint:deadcode,unused
+type searchRelationsParams struct {
+	// Namespace to search in. All namespaces are searched if it is empty.
+	//
+	// in: query
+	Namespace string `json:"namespace"`
+
+	// Part of the object to search for
+	//
+	// in: query
+	Object string `json:"object"`
+
+	// Part of the subject ID, or of the object of the subject set, to search for
+	//
+	// in: query
+	Subject string `json:"subject"`
+
+	// How the object and subject are matched
+	//
+	// in: query
+	// enum: prefix,substring
+	// default: prefix
+	Match string `json:"match"`
+
+	// swagger:allOf
+	x.PaginationOptions
+}
+
+// swagger:route GET /admin/relation-tuples/search write searchRelationTuples
+//
+// Search relation tuples
+//
+// Find relation tuples by the beginning or any part of their object or
+// subject, for ad-hoc investigation. At least one of the object and the
+// subject is required. Prefix searches can use an index, substring searches
+// scan all relation tuples of the namespace. Whether matching is case
+// sensitive depends on the database.
+//
+// Responses carry an ETag like the ones of the list API.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getRelationTuplesResponse
+//       304: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *handler) searchRelations(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s := h.d.RelationTupleSearcher()
+	if s == nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not support searching relation tuples")))
+		return
+	}
+
+	etag := h.etag(r)
+	q := r.URL.Query()
+	size, err := h.urlPageSize(r.Context(), q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if notModified(w, r, etag) {
+		return
+	}
+
+	rels, nextPage, err := s.SearchRelationTuples(r.Context(), &SearchQuery{
+		Namespace: q.Get("namespace"),
+		Object:    q.Get("object"),
+		Subject:   q.Get("subject"),
+		Match:     SearchMatch(q.Get("match")),
+	}, x.WithSize(size), x.WithToken(q.Get("page_token")))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.d.Writer().Write(w, r, &GetResponse{
+		RelationTuples: rels,
+		NextPageToken:  nextPage,
+	})
+}
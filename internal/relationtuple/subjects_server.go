@@ -0,0 +1,315 @@
+package relationtuple
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/requestid"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	// SubjectPatch changes the subjects of the relation tuples with the
+	// namespace, object, and relation.
+	//
+	// swagger:model patchSubjectsBody
+	SubjectPatch struct {
+		// Namespace of the Relation Tuples
+		//
+		// required: true
+		Namespace string `json:"namespace"`
+		// Object of the Relation Tuples
+		//
+		// required: true
+		Object string `json:"object"`
+		// Relation of the Relation Tuples
+		//
+		// required: true
+		Relation string `json:"relation"`
+		// The operations, applied in order
+		//
+		// required: true
+		Operations []*SubjectOperation `json:"operations"`
+	}
+	// SubjectOperation is an operation on the subjects of a SubjectPatch.
+	//
+	// swagger:model subjectOperation
+	SubjectOperation struct {
+		// The operation
+		//
+		// add: adds the subject, fails if it is a subject already.
+		// remove: removes the subject, fails if it is not a subject.
+		// replace: replaces all subjects by the subjects.
+		// test: fails if the subject is not a subject.
+		//
+		// required: true
+		Op subjectOp `json:"op"`
+		// The subject of the add, remove, and test operations
+		SubjectReference
+		// The subjects of the replace operation
+		Subjects []*SubjectReference `json:"subjects,omitempty"`
+	}
+	// SubjectReference is either a subject ID or a subject set.
+	//
+	// swagger:model subjectReference
+	SubjectReference struct {
+		// SubjectID of the Relation Tuple
+		//
+		// Either SubjectSet or SubjectID are required.
+		SubjectID *string `json:"subject_id,omitempty"`
+		// SubjectSet of the Relation Tuple
+		//
+		// Either SubjectSet or SubjectID are required.
+		SubjectSet *SubjectSet `json:"subject_set,omitempty"`
+	}
+	// swagger:model patchSubjectsResponse
+	patchSubjectsResponse struct {
+		// The subjects after the patch
+		Subjects []*SubjectReference `json:"subjects"`
+	}
+)
+
+// swagger:enum subjectOp
+type subjectOp string
+
+const (
+	SubjectOpAdd     subjectOp = "add"
+	SubjectOpRemove  subjectOp = "remove"
+	SubjectOpReplace subjectOp = "replace"
+	SubjectOpTest    subjectOp = "test"
+)
+
+var ErrSubjectPatchConflict = herodot.ErrConflict.WithID(errorcode.SubjectPatchConflict)
+
+// swagger:parameters patchSubjects
+// nolint:deadcode,unused
+type patchSubjectsParams struct {
+	// in: body
+	Body SubjectPatch
+}
+
+func (s *SubjectReference) subject() (Subject, error) {
+	switch {
+	case s.SubjectID != nil && s.SubjectSet != nil:
+		return nil, errors.WithStack(ErrDuplicateSubject)
+	case s.SubjectID != nil:
+		return &SubjectID{ID: *s.SubjectID}, nil
+	case s.SubjectSet != nil:
+		return s.SubjectSet, nil
+	}
+	return nil, errors.WithStack(ErrNilSubject)
+}
+
+func subjectReference(s Subject) *SubjectReference {
+	return &SubjectReference{SubjectID: s.SubjectID(), SubjectSet: s.SubjectSet()}
+}
+
+// subjectKey distinguishes subject IDs from subject sets with the same string
+// representation.
+func subjectKey(s Subject) string {
+	return fmt.Sprintf("%T %s", s, s)
+}
+
+func (p *SubjectPatch) query() *RelationQuery {
+	return &RelationQuery{Namespace: p.Namespace, Object: p.Object, Relation: p.Relation}
+}
+
+func (p *SubjectPatch) tuple(s Subject) *InternalRelationTuple {
+	return &InternalRelationTuple{Namespace: p.Namespace, Object: p.Object, Relation: p.Relation, Subject: s}
+}
+
+// Apply applies the operations in order to the current relation tuples of the
+// patch. If any operation conflicts with the subjects at that point, nothing
+// is applied. It returns the relation tuples to insert and delete, and the
+// subjects afterwards.
+func (p *SubjectPatch) Apply(current []*InternalRelationTuple) (insert, del []*InternalRelationTuple, subjects []Subject, err error) {
+	type member struct {
+		subject Subject
+		stored  *InternalRelationTuple
+	}
+	var order []string
+	members := map[string]*member{}
+	add := func(s Subject, stored *InternalRelationTuple) {
+		key := subjectKey(s)
+		if _, ok := members[key]; !ok {
+			order = append(order, key)
+		}
+		members[key] = &member{subject: s, stored: stored}
+	}
+	stored := map[string]*InternalRelationTuple{}
+	for _, rt := range current {
+		stored[subjectKey(rt.Subject)] = rt
+		add(rt.Subject, rt)
+	}
+
+	conflict := func(i int, format string, args ...interface{}) error {
+		return errors.WithStack(ErrSubjectPatchConflict.
+			WithReasonf("Operation %d conflicts with the subjects: "+format, append([]interface{}{i}, args...)...).
+			WithDetail(errorcode.DetailField, fmt.Sprintf("operations[%d]", i)))
+	}
+	for i, op := range p.Operations {
+		if op == nil {
+			return nil, nil, nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("Operation %d is empty.", i))
+		}
+		if op.Op == SubjectOpReplace {
+			members = map[string]*member{}
+			order = nil
+			for _, ref := range op.Subjects {
+				s, err := ref.subject()
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				add(s, stored[subjectKey(s)])
+			}
+			continue
+		}
+
+		s, err := op.subject()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		_, exists := members[subjectKey(s)]
+		switch op.Op {
+		case SubjectOpAdd:
+			if exists {
+				return nil, nil, nil, conflict(i, "%s is a subject already.", s)
+			}
+			add(s, stored[subjectKey(s)])
+		case SubjectOpRemove:
+			if !exists {
+				return nil, nil, nil, conflict(i, "%s is not a subject.", s)
+			}
+			delete(members, subjectKey(s))
+		case SubjectOpTest:
+			if !exists {
+				return nil, nil, nil, conflict(i, "%s is not a subject.", s)
+			}
+		default:
+			return nil, nil, nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("Operation %d has the unknown op %q.", i, op.Op).
+				WithDetail(errorcode.DetailField, fmt.Sprintf("operations[%d].op", i)))
+		}
+	}
+
+	for _, key := range order {
+		m, ok := members[key]
+		if !ok {
+			continue
+		}
+		subjects = append(subjects, m.subject)
+		if m.stored == nil {
+			insert = append(insert, p.tuple(m.subject))
+		}
+	}
+	for _, rt := range current {
+		if _, ok := members[subjectKey(rt.Subject)]; !ok {
+			del = append(del, rt)
+		}
+	}
+	return insert, del, subjects, nil
+}
+
+func (h *handler) currentSubjects(ctx context.Context, query *RelationQuery) ([]*InternalRelationTuple, error) {
+	var (
+		current []*InternalRelationTuple
+		page    string
+	)
+	for {
+		res, next, err := h.d.RelationTupleManager().GetRelationTuples(ctx, query, x.WithToken(page), x.WithSize(h.d.RelationTupleConfig(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, res...)
+		if next == "" {
+			return current, nil
+		}
+		page = next
+	}
+}
+
+// swagger:route PATCH /admin/relation-tuples/subjects write patchSubjects
+//
+// Patch the Subjects of a Relation
+//
+// Use this endpoint to add, remove, and replace the subjects of the relation
+// tuples with a namespace, object, and relation in one request. The
+// operations are applied in order to the subjects as they are when the
+// request is handled. If an operation conflicts with the subjects at that
+// point, e.g. because a subject to add is a subject already, the request fails
+// with a 409 Conflict and nothing is changed. Test operations let clients make
+// the patch depend on subjects they have seen.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: patchSubjectsResponse
+//       400: genericError
+//       404: genericError
+//       409: genericError
+//       500: genericError
+func (h *handler) patchSubjects(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var patch SubjectPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+	for _, f := range []struct{ name, value string }{
+		{"namespace", patch.Namespace},
+		{"object", patch.Object},
+		{"relation", patch.Relation},
+	} {
+		if f.value == "" {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("The %s is required.", f.name).
+				WithDetail(errorcode.DetailField, f.name)))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	current, err := h.currentSubjects(ctx, patch.query())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	insert, del, subjects, err := patch.Apply(current)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	requestid.Logger(ctx, h.d.Logger()).
+		WithField("namespace", patch.Namespace).
+		WithField("object", patch.Object).
+		WithField("relation", patch.Relation).
+		WithField("inserted", len(insert)).
+		WithField("deleted", len(del)).
+		Debug("patching subjects")
+
+	if len(insert) > 0 || len(del) > 0 {
+		if err := h.d.RelationTupleManager().TransactRelationTuples(ctx, insert, del); err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+	}
+
+	refs := make([]*SubjectReference, len(subjects))
+	for i, s := range subjects {
+		refs[i] = subjectReference(s)
+	}
+	h.d.Writer().Write(w, r, &patchSubjectsResponse{Subjects: refs})
+}
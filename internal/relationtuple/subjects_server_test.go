@@ -0,0 +1,147 @@
+package relationtuple_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+func TestPatchSubjects(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}, {Name: "groups", ID: 2}}))
+
+	r := httprouter.New()
+	relationtuple.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	tuple := func(object string, s relationtuple.Subject) *relationtuple.InternalRelationTuple {
+		return &relationtuple.InternalRelationTuple{Namespace: "files", Object: object, Relation: "viewer", Subject: s}
+	}
+	subjects := func(t *testing.T, object string) []string {
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "files", Object: object, Relation: "viewer"})
+		require.NoError(t, err)
+		s := make([]string, len(res))
+		for i, rt := range res {
+			s[i] = rt.Subject.String()
+		}
+		return s
+	}
+	patch := func(t *testing.T, object string, ops ...*relationtuple.SubjectOperation) (int, []byte) {
+		body, err := json.Marshal(&relationtuple.SubjectPatch{Namespace: "files", Object: object, Relation: "viewer", Operations: ops})
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPatch, ts.URL+relationtuple.SubjectsRouteBase, bytes.NewReader(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&raw))
+		return resp.StatusCode, raw
+	}
+	ref := func(id string) relationtuple.SubjectReference {
+		return relationtuple.SubjectReference{SubjectID: pointerx.String(id)}
+	}
+	add := func(id string) *relationtuple.SubjectOperation {
+		return &relationtuple.SubjectOperation{Op: relationtuple.SubjectOpAdd, SubjectReference: ref(id)}
+	}
+	remove := func(id string) *relationtuple.SubjectOperation {
+		return &relationtuple.SubjectOperation{Op: relationtuple.SubjectOpRemove, SubjectReference: ref(id)}
+	}
+	test := func(id string) *relationtuple.SubjectOperation {
+		return &relationtuple.SubjectOperation{Op: relationtuple.SubjectOpTest, SubjectReference: ref(id)}
+	}
+
+	t.Run("case=adds and removes subjects", func(t *testing.T) {
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+			tuple("doc", &relationtuple.SubjectID{ID: "alice"}),
+			tuple("doc", &relationtuple.SubjectID{ID: "bob"}),
+		))
+
+		status, body := patch(t, "doc",
+			add("carol"),
+			remove("bob"),
+			&relationtuple.SubjectOperation{
+				Op: relationtuple.SubjectOpAdd,
+				SubjectReference: relationtuple.SubjectReference{
+					SubjectSet: &relationtuple.SubjectSet{Namespace: "groups", Object: "eng", Relation: "member"},
+				},
+			},
+		)
+		require.Equal(t, http.StatusOK, status, "%s", body)
+		assert.Equal(t, "alice", gjson.GetBytes(body, "subjects.0.subject_id").String())
+		assert.Equal(t, "carol", gjson.GetBytes(body, "subjects.1.subject_id").String())
+		assert.Equal(t, "eng", gjson.GetBytes(body, "subjects.2.subject_set.object").String())
+		assert.ElementsMatch(t, []string{"alice", "carol", "groups:eng#member"}, subjects(t, "doc"))
+	})
+
+	t.Run("case=replaces subjects", func(t *testing.T) {
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+			tuple("sheet", &relationtuple.SubjectID{ID: "alice"}),
+			tuple("sheet", &relationtuple.SubjectID{ID: "bob"}),
+		))
+
+		status, body := patch(t, "sheet",
+			test("alice"),
+			&relationtuple.SubjectOperation{
+				Op: relationtuple.SubjectOpReplace,
+				Subjects: []*relationtuple.SubjectReference{
+					{SubjectID: pointerx.String("bob")},
+					{SubjectID: pointerx.String("dave")},
+				},
+			},
+		)
+		require.Equal(t, http.StatusOK, status, "%s", body)
+		assert.JSONEq(t, `[{"subject_id":"bob"},{"subject_id":"dave"}]`, gjson.GetBytes(body, "subjects").Raw)
+		assert.ElementsMatch(t, []string{"bob", "dave"}, subjects(t, "sheet"))
+	})
+
+	t.Run("case=conflicts change nothing", func(t *testing.T) {
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("slides", &relationtuple.SubjectID{ID: "alice"})))
+
+		for _, tc := range []struct {
+			name string
+			ops  []*relationtuple.SubjectOperation
+		}{
+			{name: "add existing subject", ops: []*relationtuple.SubjectOperation{add("bob"), add("alice")}},
+			{name: "remove missing subject", ops: []*relationtuple.SubjectOperation{add("bob"), remove("carol")}},
+			{name: "test missing subject", ops: []*relationtuple.SubjectOperation{add("bob"), test("carol")}},
+		} {
+			t.Run("case="+tc.name, func(t *testing.T) {
+				status, body := patch(t, "slides", tc.ops...)
+				assert.Equal(t, http.StatusConflict, status)
+				assert.Equal(t, errorcode.SubjectPatchConflict, gjson.GetBytes(body, "error.id").String())
+				assert.Equal(t, "operations[1]", gjson.GetBytes(body, "error.details.field").String())
+				assert.Equal(t, []string{"alice"}, subjects(t, "slides"))
+			})
+		}
+	})
+
+	t.Run("case=invalid requests", func(t *testing.T) {
+		status, _ := patch(t, "doc", &relationtuple.SubjectOperation{Op: "move", SubjectReference: ref("alice")})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = patch(t, "doc", &relationtuple.SubjectOperation{Op: relationtuple.SubjectOpAdd})
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		status, _ = patch(t, "")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -1,5 +1,7 @@
 package relationtuple
 
+import "time"
+
 var (
 	_ = (*relationTupleWithRequired)(nil)
 	_ = (*patchPayload)(nil)
@@ -30,6 +32,27 @@ type relationTupleWithRequired struct {
 	//
 	// Either SubjectSet or SubjectID are required.
 	SubjectSet *SubjectSet `json:"subject_set,omitempty"`
+
+	// Labels of the Relation Tuple
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// The relation tuple as it was stored
+//
+// swagger:model createdRelationTuple
+type CreatedRelationTuple struct {
+	RelationQuery
+
+	// The internal ID of the Relation Tuple
+	ID string `json:"id,omitempty"`
+
+	// The time the Relation Tuple was committed
+	CommitTime *time.Time `json:"commit_time,omitempty"`
+
+	// A snapshot token of a snapshot that includes the Relation Tuple
+	//
+	// Reads with this token see the Relation Tuple.
+	SnapshotToken string `json:"snapshot_token,omitempty"`
 }
 
 // The patch request payload
@@ -43,4 +66,8 @@ type patchPayload struct {
 type PatchDelta struct {
 	Action        patchAction            `json:"action"`
 	RelationTuple *InternalRelationTuple `json:"relation_tuple"`
+	// Template to expand into the relation tuples of the delta
+	//
+	// Either relation_tuple or template is required.
+	Template *TupleTemplate `json:"template,omitempty"`
 }
@@ -0,0 +1,111 @@
+package relationtuple
+
+import (
+	"regexp"
+	"sort"
+
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+)
+
+// TemplateSource looks up the relation tuple templates of the
+// configuration, e.g. *config.Config.
+type TemplateSource interface {
+	TupleTemplate(name string) (parameters, tuples []string, ok bool)
+}
+
+// TupleTemplate references a relation tuple template of the configuration.
+type TupleTemplate struct {
+	// Name of the template
+	//
+	// required: true
+	Name string `json:"name"`
+
+	// Parameters of the template
+	//
+	// The values of all parameters of the template.
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+var (
+	templatePlaceholder = regexp.MustCompile(`\{([a-zA-Z0-9_]+)\}`)
+
+	ErrTupleAndTemplate = herodot.ErrBadRequest.WithID(errorcode.TemplateInvalid).WithError("provide either a relation tuple or a template, not both")
+)
+
+func (t *TupleTemplate) FromProto(pt *rts.RelationTupleTemplate) *TupleTemplate {
+	t.Name = pt.Name
+	t.Parameters = pt.Parameters
+	return t
+}
+
+// Expand returns the relation tuples of the configured template, with the
+// placeholders replaced by the parameter values. The values are substituted
+// after the template's relation tuples were parsed, so they can not change
+// the structure of the relation tuples.
+func (t *TupleTemplate) Expand(c TemplateSource) ([]*InternalRelationTuple, error) {
+	params, tuples, ok := c.TupleTemplate(t.Name)
+	if !ok {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.TemplateInvalid).WithErrorf("unknown relation tuple template %q", t.Name))
+	}
+
+	declared := make(map[string]bool, len(params))
+	for _, p := range params {
+		declared[p] = true
+		if t.Parameters[p] == "" {
+			return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.TemplateInvalid).WithErrorf("parameter %q of the relation tuple template %q is missing", p, t.Name))
+		}
+	}
+	var unknown []string
+	for p := range t.Parameters {
+		if !declared[p] {
+			unknown = append(unknown, p)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.TemplateInvalid).WithErrorf("the relation tuple template %q has no parameter %q", t.Name, unknown[0]))
+	}
+
+	var undeclared string
+	substitute := func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := placeholder[1 : len(placeholder)-1]
+			if !declared[name] {
+				undeclared = name
+				return placeholder
+			}
+			return t.Parameters[name]
+		})
+	}
+
+	expanded := make([]*InternalRelationTuple, len(tuples))
+	for i, raw := range tuples {
+		rt, err := (&InternalRelationTuple{}).FromString(raw)
+		if err != nil {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithErrorf("the relation tuple template %q contains the malformed relation tuple %q", t.Name, raw))
+		}
+
+		rt.Namespace = substitute(rt.Namespace)
+		rt.Object = substitute(rt.Object)
+		rt.Relation = substitute(rt.Relation)
+		switch s := rt.Subject.(type) {
+		case *SubjectID:
+			s.ID = substitute(s.ID)
+		case *SubjectSet:
+			s.Namespace = substitute(s.Namespace)
+			s.Object = substitute(s.Object)
+			s.Relation = substitute(s.Relation)
+		}
+		if undeclared != "" {
+			return nil, errors.WithStack(herodot.ErrInternalServerError.WithErrorf("the relation tuple template %q uses the undeclared parameter %q", t.Name, undeclared))
+		}
+
+		expanded[i] = rt
+	}
+	return expanded, nil
+}
@@ -0,0 +1,98 @@
+package relationtuple
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ory/herodot"
+	"github.com/ory/x/configx"
+	"github.com/ory/x/logrusx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/embedx"
+	"github.com/ory/keto/internal/driver/config"
+)
+
+func TestTupleTemplate(t *testing.T) {
+	ctx := context.Background()
+	cp, err := configx.New(ctx, embedx.ConfigSchema, configx.SkipValidation())
+	require.NoError(t, err)
+	c := config.New(ctx, logrusx.New("test", "today"), cp)
+	require.NoError(t, c.Set(config.KeyTupleTemplates, map[string]interface{}{
+		"project-roles": map[string]interface{}{
+			"parameters": []string{"team", "project"},
+			"tuples": []string{
+				"projects:{project}#viewer@(groups:{team}#member)",
+				"projects:{project}#owner@{team}-lead",
+			},
+		},
+		"undeclared": map[string]interface{}{
+			"tuples": []string{"projects:{project}#viewer@alice"},
+		},
+	}))
+
+	t.Run("case=expands the template", func(t *testing.T) {
+		tuples, err := (&TupleTemplate{
+			Name:       "project-roles",
+			Parameters: map[string]string{"team": "core", "project": "keto"},
+		}).Expand(c)
+		require.NoError(t, err)
+		assert.Equal(t, []*InternalRelationTuple{
+			{Namespace: "projects", Object: "keto", Relation: "viewer", Subject: &SubjectSet{Namespace: "groups", Object: "core", Relation: "member"}},
+			{Namespace: "projects", Object: "keto", Relation: "owner", Subject: &SubjectID{ID: "core-lead"}},
+		}, tuples)
+	})
+
+	t.Run("case=values do not change the structure", func(t *testing.T) {
+		tuples, err := (&TupleTemplate{
+			Name:       "project-roles",
+			Parameters: map[string]string{"team": "core#admin", "project": "{team}"},
+		}).Expand(c)
+		require.NoError(t, err)
+		assert.Equal(t, &SubjectSet{Namespace: "groups", Object: "core#admin", Relation: "member"}, tuples[0].Subject)
+		assert.Equal(t, "{team}", tuples[0].Object)
+	})
+
+	for _, tc := range []struct {
+		desc   string
+		tmpl   *TupleTemplate
+		status int
+		err    string
+	}{
+		{
+			desc:   "unknown template",
+			tmpl:   &TupleTemplate{Name: "unknown"},
+			status: http.StatusBadRequest,
+			err:    `unknown relation tuple template "unknown"`,
+		},
+		{
+			desc:   "missing parameter",
+			tmpl:   &TupleTemplate{Name: "project-roles", Parameters: map[string]string{"team": "core"}},
+			status: http.StatusBadRequest,
+			err:    `parameter "project" of the relation tuple template "project-roles" is missing`,
+		},
+		{
+			desc:   "unknown parameter",
+			tmpl:   &TupleTemplate{Name: "project-roles", Parameters: map[string]string{"team": "core", "project": "keto", "foo": "bar"}},
+			status: http.StatusBadRequest,
+			err:    `the relation tuple template "project-roles" has no parameter "foo"`,
+		},
+		{
+			desc:   "undeclared placeholder",
+			tmpl:   &TupleTemplate{Name: "undeclared"},
+			status: http.StatusInternalServerError,
+			err:    `uses the undeclared parameter "project"`,
+		},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			_, err := tc.tmpl.Expand(c)
+			var herr *herodot.DefaultError
+			require.True(t, errors.As(err, &herr), "%+v", err)
+			assert.Equal(t, tc.status, herr.StatusCode())
+			assert.Contains(t, herr.Error(), tc.err)
+		})
+	}
+}
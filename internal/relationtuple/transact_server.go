@@ -10,6 +10,8 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/ory/herodot"
 	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/requestid"
 )
 
 var (
@@ -18,30 +20,43 @@ var (
 	_                        = (*queryRelationTuple)(nil)
 )
 
-func protoTuplesWithAction(deltas []*rts.RelationTupleDelta, action rts.RelationTupleDelta_Action) (filtered []*InternalRelationTuple, err error) {
+func (h *handler) protoTuplesWithAction(ctx context.Context, deltas []*rts.RelationTupleDelta, action rts.RelationTupleDelta_Action) (filtered []*InternalRelationTuple, err error) {
 	for _, d := range deltas {
-		if d.Action == action {
-			it, err := (&InternalRelationTuple{}).FromDataProvider(d.RelationTuple)
+		if d.Action != action {
+			continue
+		}
+		if d.Template != nil {
+			if d.RelationTuple != nil {
+				return nil, errors.WithStack(ErrTupleAndTemplate)
+			}
+			expanded, err := (&TupleTemplate{}).FromProto(d.Template).Expand(h.d.RelationTupleConfig(ctx))
 			if err != nil {
 				return nil, err
 			}
-			filtered = append(filtered, it)
+			filtered = append(filtered, expanded...)
+			continue
+		}
+		it, err := (&InternalRelationTuple{}).FromDataProvider(d.RelationTuple)
+		if err != nil {
+			return nil, err
 		}
+		filtered = append(filtered, it)
 	}
 	return
 }
 
 func (h *handler) TransactRelationTuples(ctx context.Context, req *rts.TransactRelationTuplesRequest) (*rts.TransactRelationTuplesResponse, error) {
-	insertTuples, err := protoTuplesWithAction(req.RelationTupleDeltas, rts.RelationTupleDelta_ACTION_INSERT)
+	insertTuples, err := h.protoTuplesWithAction(ctx, req.RelationTupleDeltas, rts.RelationTupleDelta_ACTION_INSERT)
 	if err != nil {
 		return nil, err
 	}
 
-	deleteTuples, err := protoTuplesWithAction(req.RelationTupleDeltas, rts.RelationTupleDelta_ACTION_DELETE)
+	deleteTuples, err := h.protoTuplesWithAction(ctx, req.RelationTupleDeltas, rts.RelationTupleDelta_ACTION_DELETE)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, receipt := WithWriteReceipt(ctx)
 	err = h.d.RelationTupleManager().TransactRelationTuples(ctx, insertTuples, deleteTuples)
 	if err != nil {
 		return nil, err
@@ -51,8 +66,15 @@ func (h *handler) TransactRelationTuples(ctx context.Context, req *rts.TransactR
 	for i := range insertTuples {
 		snaptokens[i] = "not yet implemented"
 	}
+	written := receipt.Written()
+	stored := make([]*rts.StoredRelationTuple, len(written))
+	for i, rt := range written {
+		stored[i] = rt.ToProto()
+	}
 	return &rts.TransactRelationTuplesResponse{
-		Snaptokens: snaptokens,
+		Snaptokens:     snaptokens,
+		RelationTuples: stored,
+		SnapshotToken:  receipt.SnapshotToken(),
 	}, nil
 }
 
@@ -81,9 +103,24 @@ type bodyRelationTuple struct {
 	Payload RelationQuery
 }
 
+// The relation tuples to delete
+//
+// swagger:parameters deleteRelationTuples
+type deleteRelationTuplesParams struct {
+	// swagger:allOf
+	queryRelationTuple
+
+	// Labels of the Relation Tuple, formatted as "key=value"
+	//
+	// in: query
+	// collection format: multi
+	// The relation tuples must have all labels.
+	Labels []string `json:"label"`
+}
+
 // The basic ACL relation tuple
 //
-// swagger:parameters getCheck deleteRelationTuples
+// swagger:parameters getCheck
 type queryRelationTuple struct {
 	// Namespace of the Relation Tuple
 	//
@@ -129,7 +166,8 @@ type queryRelationTuple struct {
 //
 // Create a Relation Tuple
 //
-// Use this endpoint to create a relation tuple.
+// Use this endpoint to create a relation tuple. The response contains the
+// relation tuple as it was stored, with its commit time and a snapshot token.
 //
 //     Consumes:
 //     -  application/json
@@ -140,7 +178,7 @@ type queryRelationTuple struct {
 //     Schemes: http, https
 //
 //     Responses:
-//       201: RelationQuery
+//       201: createdRelationTuple
 //       400: genericError
 //       500: genericError
 func (h *handler) createRelation(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -151,10 +189,11 @@ func (h *handler) createRelation(w http.ResponseWriter, r *http.Request, _ httpr
 		return
 	}
 
-	h.d.Logger().WithFields(rel.ToLoggerFields()).Debug("creating relation tuple")
+	requestid.Logger(r.Context(), h.d.Logger()).WithFields(rel.ToLoggerFields()).Debug("creating relation tuple")
 
-	if err := h.d.RelationTupleManager().WriteRelationTuples(r.Context(), &rel); err != nil {
-		h.d.Logger().WithError(err).WithFields(rel.ToLoggerFields()).Errorf("got an error while creating the relation tuple")
+	ctx, receipt := WithWriteReceipt(r.Context())
+	if err := h.d.RelationTupleManager().WriteRelationTuples(ctx, &rel); err != nil {
+		requestid.Logger(r.Context(), h.d.Logger()).WithError(err).WithFields(rel.ToLoggerFields()).Errorf("got an error while creating the relation tuple")
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
@@ -165,7 +204,11 @@ func (h *handler) createRelation(w http.ResponseWriter, r *http.Request, _ httpr
 		return
 	}
 
-	h.d.Writer().WriteCreated(w, r, ReadRouteBase+"?"+q.Encode(), &rel)
+	created := &CreatedRelationTuple{RelationQuery: *rel.ToQuery()}
+	if written := receipt.Written(); len(written) > 0 {
+		created = written[0].ToCreated(receipt.SnapshotToken())
+	}
+	h.d.Writer().WriteCreated(w, r, ReadRouteBase+"?"+q.Encode(), created)
 }
 
 // swagger:route DELETE /admin/relation-tuples write deleteRelationTuples
@@ -194,7 +237,7 @@ func (h *handler) deleteRelations(w http.ResponseWriter, r *http.Request, _ http
 		return
 	}
 
-	l := h.d.Logger()
+	l := requestid.Logger(r.Context(), h.d.Logger())
 	for k := range q {
 		l = l.WithField(k, q.Get(k))
 	}
@@ -209,11 +252,20 @@ func (h *handler) deleteRelations(w http.ResponseWriter, r *http.Request, _ http
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func internalTuplesWithAction(deltas []*PatchDelta, action patchAction) (filtered []*InternalRelationTuple) {
+func (h *handler) internalTuplesWithAction(ctx context.Context, deltas []*PatchDelta, action patchAction) (filtered []*InternalRelationTuple, err error) {
 	for _, d := range deltas {
-		if d.Action == action {
-			filtered = append(filtered, d.RelationTuple)
+		if d.Action != action {
+			continue
 		}
+		if d.Template != nil {
+			expanded, err := d.Template.Expand(h.d.RelationTupleConfig(ctx))
+			if err != nil {
+				return nil, err
+			}
+			filtered = append(filtered, expanded...)
+			continue
+		}
+		filtered = append(filtered, d.RelationTuple)
 	}
 	return
 }
@@ -222,7 +274,9 @@ func internalTuplesWithAction(deltas []*PatchDelta, action patchAction) (filtere
 //
 // Patch Multiple Relation Tuples
 //
-// Use this endpoint to patch one or more relation tuples.
+// Use this endpoint to patch one or more relation tuples. Instead of a
+// relation tuple, a delta can reference a relation tuple template of the
+// configuration, which is expanded into all of its relation tuples.
 //
 //     Consumes:
 //     - application/json
@@ -244,10 +298,14 @@ func (h *handler) patchRelations(w http.ResponseWriter, r *http.Request, _ httpr
 		return
 	}
 	for _, d := range deltas {
-		if d.RelationTuple == nil {
+		if d.RelationTuple == nil && d.Template == nil {
 			h.d.Writer().WriteError(w, r, herodot.ErrBadRequest.WithError("relation_tuple is missing"))
 			return
 		}
+		if d.RelationTuple != nil && d.Template != nil {
+			h.d.Writer().WriteError(w, r, errors.WithStack(ErrTupleAndTemplate))
+			return
+		}
 		switch d.Action {
 		case ActionDelete, ActionInsert:
 		default:
@@ -256,7 +314,18 @@ func (h *handler) patchRelations(w http.ResponseWriter, r *http.Request, _ httpr
 		}
 	}
 
-	if err := h.d.RelationTupleManager().TransactRelationTuples(r.Context(), internalTuplesWithAction(deltas, ActionInsert), internalTuplesWithAction(deltas, ActionDelete)); err != nil {
+	insertTuples, err := h.internalTuplesWithAction(r.Context(), deltas, ActionInsert)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	deleteTuples, err := h.internalTuplesWithAction(r.Context(), deltas, ActionDelete)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	if err := h.d.RelationTupleManager().TransactRelationTuples(r.Context(), insertTuples, deleteTuples); err != nil {
 		h.d.Writer().WriteError(w, r, err)
 		return
 	}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/ory/keto/internal/driver/config"
@@ -19,8 +20,10 @@ import (
 
 	"github.com/ory/keto/internal/driver"
 	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
 	"github.com/ory/keto/internal/relationtuple"
 	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
 )
 
 func TestWriteHandlers(t *testing.T) {
@@ -74,10 +77,22 @@ func TestWriteHandlers(t *testing.T) {
 
 			assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-			body, err := io.ReadAll(resp.Body)
-			require.NoError(t, err)
-
-			assert.JSONEq(t, string(payload), string(body))
+			created := relationtuple.CreatedRelationTuple{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+			assert.Equal(t, *rt.ToQuery(), created.RelationQuery)
+			assert.NotEmpty(t, created.ID)
+			require.NotNil(t, created.CommitTime)
+			assert.False(t, created.CommitTime.IsZero())
+
+			t.Run("check=snapshot token includes the tuple", func(t *testing.T) {
+				sr, ok := reg.Persister().(persistence.SnapshotReader)
+				require.True(t, ok)
+				ctx, err := sr.AtSnapshot(context.Background(), created.SnapshotToken)
+				require.NoError(t, err)
+				actualRTs, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, rt.ToQuery())
+				require.NoError(t, err)
+				assert.Equal(t, []*relationtuple.InternalRelationTuple{rt}, actualRTs)
+			})
 
 			t.Run("check=is contained in the manager", func(t *testing.T) {
 				// set a size > 1 just to make sure it gets all
@@ -102,6 +117,25 @@ func TestWriteHandlers(t *testing.T) {
 			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 		})
 
+		t.Run("case=rejects identifiers that are too long", func(t *testing.T) {
+			nspace := addNamespace(t)
+
+			payload, err := json.Marshal(&relationtuple.InternalRelationTuple{
+				Namespace: nspace.Name,
+				Object:    strings.Repeat("o", 65),
+				Relation:  "rel",
+				Subject:   &relationtuple.SubjectID{ID: "subj"},
+			})
+			require.NoError(t, err)
+
+			resp := doCreate(payload)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), "object is 65 characters long, but at most 64 are allowed")
+		})
+
 		t.Run("case=special chars", func(t *testing.T) {
 			nspace := addNamespace(t)
 
@@ -210,6 +244,29 @@ func TestWriteHandlers(t *testing.T) {
 		})
 	})
 
+	t.Run("method=transact", func(t *testing.T) {
+		nspace := addNamespace(t)
+		rt := &relationtuple.InternalRelationTuple{
+			Namespace: nspace.Name,
+			Object:    "obj",
+			Relation:  "rel",
+			Subject:   &relationtuple.SubjectID{ID: "subj"},
+		}
+
+		resp, err := h.TransactRelationTuples(context.Background(), &rts.TransactRelationTuplesRequest{
+			RelationTupleDeltas: []*rts.RelationTupleDelta{{
+				Action:        rts.RelationTupleDelta_ACTION_INSERT,
+				RelationTuple: rt.ToProto(),
+			}},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.RelationTuples, 1)
+		assert.Equal(t, rt.Object, resp.RelationTuples[0].RelationTuple.Object)
+		assert.NotEmpty(t, resp.RelationTuples[0].Id)
+		assert.False(t, resp.RelationTuples[0].CommitTime.AsTime().IsZero())
+		assert.NotEmpty(t, resp.SnapshotToken)
+	})
+
 	t.Run("method=patch", func(t *testing.T) {
 		t.Run("case=create and delete", func(t *testing.T) {
 			nspace := addNamespace(t)
@@ -397,5 +454,105 @@ func TestWriteHandlers(t *testing.T) {
 			require.NoError(t, err)
 			assert.Contains(t, string(errContent), "unknown_action_foo")
 		})
+
+		t.Run("case=expands templates", func(t *testing.T) {
+			nspace := addNamespace(t)
+			require.NoError(t, reg.Config(context.Background()).Set(config.KeyTupleTemplates, map[string]interface{}{
+				"project-roles": map[string]interface{}{
+					"parameters": []string{"ns", "project", "team"},
+					"tuples": []string{
+						"{ns}:{project}#viewer@({ns}:{team}#member)",
+						"{ns}:{project}#editor@({ns}:{team}#admin)",
+					},
+				},
+			}))
+
+			template := func(project string) *relationtuple.TupleTemplate {
+				return &relationtuple.TupleTemplate{
+					Name:       "project-roles",
+					Parameters: map[string]string{"ns": nspace.Name, "project": project, "team": "core"},
+				}
+			}
+			doPatch := func(t *testing.T, delta *relationtuple.PatchDelta) *http.Response {
+				body, err := json.Marshal([]*relationtuple.PatchDelta{delta})
+				require.NoError(t, err)
+				req, err := http.NewRequest(http.MethodPatch, ts.URL+relationtuple.WriteRouteBase, bytes.NewBuffer(body))
+				require.NoError(t, err)
+				resp, err := ts.Client().Do(req)
+				require.NoError(t, err)
+				return resp
+			}
+			list := func(t *testing.T) []*relationtuple.InternalRelationTuple {
+				actualRTs, _, err := reg.RelationTupleManager().GetRelationTuples(context.Background(), &relationtuple.RelationQuery{Namespace: nspace.Name}, x.WithSize(10))
+				require.NoError(t, err)
+				return actualRTs
+			}
+
+			assert.Equal(t, http.StatusNoContent, doPatch(t, &relationtuple.PatchDelta{Action: relationtuple.ActionInsert, Template: template("keto")}).StatusCode)
+			assert.ElementsMatch(t, []*relationtuple.InternalRelationTuple{
+				{Namespace: nspace.Name, Object: "keto", Relation: "viewer", Subject: &relationtuple.SubjectSet{Namespace: nspace.Name, Object: "core", Relation: "member"}},
+				{Namespace: nspace.Name, Object: "keto", Relation: "editor", Subject: &relationtuple.SubjectSet{Namespace: nspace.Name, Object: "core", Relation: "admin"}},
+			}, list(t))
+
+			assert.Equal(t, http.StatusNoContent, doPatch(t, &relationtuple.PatchDelta{Action: relationtuple.ActionDelete, Template: template("keto")}).StatusCode)
+			assert.Len(t, list(t), 0)
+
+			resp := doPatch(t, &relationtuple.PatchDelta{Action: relationtuple.ActionInsert, Template: template("")})
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			defer resp.Body.Close()
+			errContent, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(errContent), `parameter \"project\"`)
+		})
+	})
+
+	t.Run("method=search", func(t *testing.T) {
+		nspace := addNamespace(t)
+		rts := []*relationtuple.InternalRelationTuple{
+			{Namespace: nspace.Name, Object: "invoice-1", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice"}},
+			{Namespace: nspace.Name, Object: "invoice-2", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "bob"}},
+			{Namespace: nspace.Name, Object: "receipt-1", Relation: "owner", Subject: &relationtuple.SubjectID{ID: "alice"}},
+		}
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(context.Background(), rts...))
+
+		search := func(t *testing.T, q url.Values) (int, []byte) {
+			q.Set("namespace", nspace.Name)
+			resp, err := ts.Client().Get(ts.URL + relationtuple.SearchRouteBase + "?" + q.Encode())
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp.StatusCode, body
+		}
+
+		t.Run("case=finds tuples by object prefix", func(t *testing.T) {
+			status, body := search(t, url.Values{"object": {"invoice-"}, "page_size": {"1"}})
+			require.Equal(t, http.StatusOK, status, "%s", body)
+
+			var resp relationtuple.GetResponse
+			require.NoError(t, json.Unmarshal(body, &resp))
+			assert.Equal(t, rts[:1], resp.RelationTuples)
+			require.NotEmpty(t, resp.NextPageToken)
+
+			status, body = search(t, url.Values{"object": {"invoice-"}, "page_token": {resp.NextPageToken}})
+			require.Equal(t, http.StatusOK, status, "%s", body)
+			require.NoError(t, json.Unmarshal(body, &resp))
+			assert.Equal(t, rts[1:2], resp.RelationTuples)
+			assert.Empty(t, resp.NextPageToken)
+		})
+
+		t.Run("case=finds tuples by subject substring", func(t *testing.T) {
+			status, body := search(t, url.Values{"subject": {"lic"}, "match": {"substring"}})
+			require.Equal(t, http.StatusOK, status, "%s", body)
+
+			var resp relationtuple.GetResponse
+			require.NoError(t, json.Unmarshal(body, &resp))
+			assert.Equal(t, []*relationtuple.InternalRelationTuple{rts[0], rts[2]}, resp.RelationTuples)
+		})
+
+		t.Run("case=requires a search term", func(t *testing.T) {
+			status, body := search(t, url.Values{})
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Contains(t, string(body), "either the object or the subject")
+		})
 	})
 }
@@ -0,0 +1,112 @@
+package relationtuple
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+)
+
+const WatchRoute = ReadRouteBase + "/watch"
+
+// maxWatchWait keeps watch requests below the write timeout of the REST
+// server.
+const maxWatchWait = 5 * time.Second
+
+// The current snapshot of the relation tuples
+//
+// swagger:model watchRelationTuplesResponse
+type WatchResponse struct {
+	// The opaque snapshot of the relation tuples. It changes with every
+	// write.
+	//
+	// required: true
+	Snapshot string `json:"snapshot"`
+}
+
+// swagger:parameters watchRelationTuples
+// nolint:deadcode,unused
+type watchRelationTuplesParameters struct {
+	// The snapshot of the previous response. The request returns as soon as
+	// the current snapshot differs from it.
+	//
+	// in: query
+	Snapshot string `json:"snapshot"`
+
+	// How long to wait for a new snapshot at most, e.g. 2s. Defaults to and
+	// is capped at 5s.
+	//
+	// in: query
+	Timeout string `json:"timeout"`
+}
+
+// swagger:route GET /relation-tuples/watch read watchRelationTuples
+//
+// Watch for writes
+//
+// Waits until the snapshot of the relation tuples differs from the given one,
+// i.e. until relation tuples were written, and returns the new snapshot. If
+// nothing was written until the timeout, the given snapshot is returned.
+// Requests without a snapshot return the current one right away. Clients watch
+// by repeating the request with the snapshot of the previous response.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: watchRelationTuplesResponse
+//       400: genericError
+//       500: genericError
+func (h *handler) watch(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s := h.d.RelationTupleSnapshotter()
+	if s == nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not track snapshots")))
+		return
+	}
+
+	wait := maxWatchWait
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error()).WithDetail(errorcode.DetailField, "timeout")))
+			return
+		}
+		if d < wait {
+			wait = d
+		}
+	}
+
+	ctx := r.Context()
+	known := r.URL.Query().Get("snapshot")
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	poll := time.NewTicker(h.d.RelationTupleConfig(ctx).WatchPollInterval())
+	defer poll.Stop()
+
+	for {
+		snapshot, err := s.SnapshotToken(ctx)
+		if err != nil {
+			h.d.Writer().WriteError(w, r, err)
+			return
+		}
+		if snapshot != known {
+			h.d.Writer().Write(w, r, &WatchResponse{Snapshot: snapshot})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			h.d.Writer().Write(w, r, &WatchResponse{Snapshot: snapshot})
+			return
+		case <-poll.C:
+		}
+	}
+}
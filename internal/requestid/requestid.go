@@ -0,0 +1,154 @@
+// Package requestid propagates the ID of a request to logs, error bodies, and
+// responses, so that a request can be correlated end to end.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/ory/x/logrusx"
+	"github.com/ory/x/reqlog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey struct{}
+
+const (
+	// Header is the HTTP header of the request ID. herodot reads the request
+	// ID of error bodies from it.
+	Header = "X-Request-ID"
+	// MetadataKey is the gRPC metadata key of the request ID.
+	MetadataKey = "x-request-id"
+	// LogField is the log field of the request ID.
+	LogField = "request_id"
+
+	maxLength = 128
+)
+
+// valid returns whether the client provided ID can be used. IDs are logged
+// and returned, so only short printable ASCII strings are accepted.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the ID provided by the client, or a new one if it provided
+// none or an invalid one.
+func resolve(id string) string {
+	if valid(id) {
+		return id
+	}
+	return uuid.Must(uuid.NewV4()).String()
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID, or an empty string if the context does
+// not belong to a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns the logger with the request ID of the context, if there is
+// one.
+func Logger(ctx context.Context, l *logrusx.Logger) *logrusx.Logger {
+	if id := FromContext(ctx); id != "" {
+		return l.WithField(LogField, id)
+	}
+	return l
+}
+
+// HTTPMiddleware assigns the request ID and returns it in the response header.
+// It has to run before the request log.
+func HTTPMiddleware(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	id := resolve(r.Header.Get(Header))
+	r.Header.Set(Header, id)
+	rw.Header().Set(Header, id)
+	next(rw, r.WithContext(WithID(r.Context(), id)))
+}
+
+// ReqlogBefore is the reqlog.BeforeFunc that adds the request ID to the
+// request log.
+func ReqlogBefore(l *logrusx.Logger, r *http.Request, remoteAddr string) *logrusx.Logger {
+	return Logger(r.Context(), reqlog.DefaultBefore(l, r, remoteAddr))
+}
+
+func fromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(MetadataKey); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+func withTags(ctx context.Context, id string) context.Context {
+	tags := grpc_ctxtags.Extract(ctx)
+	if tags == grpc_ctxtags.NoopTags {
+		tags = grpc_ctxtags.NewTags()
+		ctx = grpc_ctxtags.SetInContext(ctx, tags)
+	}
+	tags.Set(LogField, id)
+	return WithID(ctx, id)
+}
+
+// withDetails adds the request ID to the details of the error's status.
+func withDetails(err error, id string) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	withID, derr := st.WithDetails(&errdetails.RequestInfo{RequestId: id})
+	if derr != nil {
+		return err
+	}
+	return withID.Err()
+}
+
+// UnaryInterceptor assigns the request ID, adds it to the gRPC request log and
+// to the details of errors, and returns it in the response header. It has to
+// run before the herodot error unwrap interceptor and the request log.
+func UnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := resolve(fromMetadata(ctx))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataKey, id))
+
+	resp, err := handler(withTags(ctx, id), req)
+	if err != nil {
+		return nil, withDetails(err, id)
+	}
+	return resp, nil
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor is the UnaryInterceptor for streaming RPCs.
+func StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	id := resolve(fromMetadata(ss.Context()))
+	_ = ss.SetHeader(metadata.Pairs(MetadataKey, id))
+
+	if err := handler(srv, &stream{ServerStream: ss, ctx: withTags(ss.Context(), id)}); err != nil {
+		return withDetails(err, id)
+	}
+	return nil
+}
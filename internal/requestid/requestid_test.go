@@ -0,0 +1,117 @@
+package requestid_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/ory/herodot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ory/keto/internal/requestid"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var handled string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requestid.HTTPMiddleware(rw, r, func(rw http.ResponseWriter, r *http.Request) {
+			handled = requestid.FromContext(r.Context())
+			herodot.NewJSONWriter(nil).WriteError(rw, r, herodot.ErrNotFound)
+		})
+	}))
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, id string) (string, string) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		if id != "" {
+			req.Header.Set(requestid.Header, id)
+		}
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body herodot.ErrorContainer
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, resp.Header.Get(requestid.Header), handled)
+		return resp.Header.Get(requestid.Header), body.Error.RequestID()
+	}
+
+	t.Run("case=propagates the client ID", func(t *testing.T) {
+		returned, inBody := do(t, "client-id")
+		assert.Equal(t, "client-id", returned)
+		assert.Equal(t, "client-id", inBody)
+	})
+
+	t.Run("case=generates an ID", func(t *testing.T) {
+		returned, inBody := do(t, "")
+		assert.NotEmpty(t, returned)
+		assert.Equal(t, returned, inBody)
+	})
+
+	t.Run("case=replaces invalid IDs", func(t *testing.T) {
+		returned, _ := do(t, strings.Repeat("x", 200))
+		assert.NotEmpty(t, returned)
+		assert.NotEqual(t, strings.Repeat("x", 200), returned)
+	})
+}
+
+type transportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *transportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	call := func(t *testing.T, md metadata.MD, handler grpc.UnaryHandler) (*transportStream, error) {
+		ts := &transportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), ts)
+		if md != nil {
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+		_, err := requestid.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		return ts, err
+	}
+
+	t.Run("case=propagates the client ID", func(t *testing.T) {
+		ts, err := call(t, metadata.Pairs(requestid.MetadataKey, "client-id"), func(ctx context.Context, _ interface{}) (interface{}, error) {
+			assert.Equal(t, "client-id", requestid.FromContext(ctx))
+			assert.Equal(t, "client-id", grpc_ctxtags.Extract(ctx).Values()[requestid.LogField])
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"client-id"}, ts.header.Get(requestid.MetadataKey))
+	})
+
+	t.Run("case=adds the ID to errors", func(t *testing.T) {
+		ts, err := call(t, nil, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			return nil, status.Error(codes.InvalidArgument, "invalid")
+		})
+		require.Error(t, err)
+		id := ts.header.Get(requestid.MetadataKey)
+		require.Len(t, id, 1)
+
+		var found bool
+		for _, d := range status.Convert(err).Details() {
+			if info, ok := d.(*errdetails.RequestInfo); ok {
+				found = true
+				assert.Equal(t, id[0], info.RequestId)
+			}
+		}
+		assert.True(t, found, "%+v", status.Convert(err).Details())
+	})
+}
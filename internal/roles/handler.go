@@ -0,0 +1,330 @@
+package roles
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase     = "/admin/roles"
+	BindingsRoute = RouteBase + "/bindings"
+	SyncRoute     = RouteBase + "/sync"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.getRoles)
+	r.GET(BindingsRoute, h.getRoleBindings)
+	r.PUT(BindingsRoute, h.createRoleBinding)
+	r.DELETE(BindingsRoute, h.deleteRoleBinding)
+	r.POST(SyncRoute, h.syncRoles)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+func requiredQuery(q url.Values, keys ...string) error {
+	for _, k := range keys {
+		if q.Get(k) == "" {
+			return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+				WithReasonf("The query parameter %q is required.", k).
+				WithDetail(errorcode.DetailField, k))
+		}
+	}
+	return nil
+}
+
+// The roles of a namespace
+//
+// swagger:model getRolesResponse
+type getRolesResponse struct {
+	Roles []*Role `json:"roles"`
+}
+
+// swagger:parameters getRoles syncRoles
+// nolint:deadcode,unused
+type namespaceParameters struct {
+	// The namespace of the roles.
+	//
+	// required: true
+	// in: query
+	Namespace string `json:"namespace"`
+}
+
+// swagger:route GET /admin/roles write getRoles
+//
+// List the roles of a namespace
+//
+// Returns the roles defined in the config of the namespace, including the
+// ones of the namespaces it extends.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getRolesResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getRoles(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	if err := requiredQuery(q, "namespace"); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	roles, err := h.d.RoleManager().Roles(r.Context(), q.Get("namespace"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, &getRolesResponse{Roles: roles})
+}
+
+// A page of role bindings
+//
+// swagger:model getRoleBindingsResponse
+type getRoleBindingsResponse struct {
+	Bindings []*Binding `json:"bindings"`
+	// The opaque token to request the next page, empty on the last page.
+	NextPageToken string `json:"next_page_token"`
+}
+
+// swagger:parameters getRoleBindings
+// nolint:deadcode,unused
+type getRoleBindingsParameters struct {
+	// The namespace of the bindings.
+	//
+	// required: true
+	// in: query
+	Namespace string `json:"namespace"`
+
+	// The role of the bindings.
+	//
+	// required: true
+	// in: query
+	Role string `json:"role"`
+
+	// Only list the bindings on this object.
+	//
+	// in: query
+	Object string `json:"object"`
+
+	// in: query
+	PageToken string `json:"page_token"`
+
+	// in: query
+	PageSize int32 `json:"page_size"`
+}
+
+// swagger:route GET /admin/roles/bindings write getRoleBindings
+//
+// List the bindings of a role
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: getRoleBindingsResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) getRoleBindings(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	if err := requiredQuery(q, "namespace", "role"); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	c := h.d.Config(r.Context())
+	size := c.DefaultPageSize()
+	if raw := q.Get("page_size"); raw != "" {
+		requested, err := strconv.ParseInt(raw, 0, 0)
+		if err != nil || requested < 1 || int(requested) > c.MaxPageSize() {
+			h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.PageSizeInvalid).
+				WithReasonf("The page size has to be a number between 1 and %d, got %q.", c.MaxPageSize(), raw).
+				WithDetail(errorcode.DetailLimit, config.KeyLimitMaxPageSize)))
+			return
+		}
+		size = int(requested)
+	}
+
+	bindings, next, err := h.d.RoleManager().Bindings(r.Context(), q.Get("namespace"), q.Get("object"), q.Get("role"),
+		x.WithToken(q.Get("page_token")), x.WithSize(size))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, &getRoleBindingsResponse{Bindings: bindings, NextPageToken: next})
+}
+
+// swagger:parameters createRoleBinding
+// nolint:deadcode,unused
+type createRoleBindingParameters struct {
+	// in: body
+	Body Binding
+}
+
+// swagger:route PUT /admin/roles/bindings write createRoleBinding
+//
+// Bind a role to a subject
+//
+// Writes the relation tuple of the binding and the grants of the role on the
+// object. Binding a role again is a no-op.
+//
+//     Consumes:
+//     -  application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       201: roleBinding
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) createRoleBinding(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var b Binding
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	if err := h.d.RoleManager().Bind(r.Context(), &b); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	q := url.Values{"namespace": {b.Namespace}, "object": {b.Object}, "role": {b.Role}}
+	h.d.Writer().WriteCreated(w, r, BindingsRoute+"?"+q.Encode(), &b)
+}
+
+// swagger:parameters deleteRoleBinding
+// nolint:deadcode,unused
+type deleteRoleBindingParameters struct {
+	// required: true
+	// in: query
+	Namespace string `json:"namespace"`
+
+	// required: true
+	// in: query
+	Object string `json:"object"`
+
+	// required: true
+	// in: query
+	Role string `json:"role"`
+
+	// in: query
+	SubjectID string `json:"subject_id"`
+
+	// in: query
+	SubjectSetNamespace string `json:"subject_set.namespace"`
+
+	// in: query
+	SubjectSetObject string `json:"subject_set.object"`
+
+	// in: query
+	SubjectSetRelation string `json:"subject_set.relation"`
+}
+
+// swagger:route DELETE /admin/roles/bindings write deleteRoleBinding
+//
+// Unbind a role from a subject
+//
+// Deletes the relation tuple of the binding, and the grants of the role on
+// the object if no other binding of the role remains on it.
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       204: emptyResponse
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) deleteRoleBinding(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	query, err := (&relationtuple.RelationQuery{}).FromURLQuery(q)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError(err.Error())))
+		return
+	}
+
+	b := &Binding{
+		Namespace:  query.Namespace,
+		Object:     query.Object,
+		Role:       q.Get("role"),
+		SubjectID:  query.SubjectID,
+		SubjectSet: query.SubjectSet,
+	}
+	if err := h.d.RoleManager().Unbind(r.Context(), b); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// swagger:route POST /admin/roles/sync write syncRoles
+//
+// Sync the grants of the roles of a namespace
+//
+// Updates the grants of all role bindings in the namespace to the current role
+// definitions. Call it after changing the relations of a role.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: roleSyncResult
+//       400: genericError
+//       404: genericError
+//       500: genericError
+func (h *Handler) syncRoles(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	if err := requiredQuery(q, "namespace"); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	res, err := h.d.RoleManager().Sync(r.Context(), q.Get("namespace"))
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, res)
+}
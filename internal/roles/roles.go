@@ -0,0 +1,344 @@
+package roles
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// Roles are bundles of relations defined in the namespace config. Binding a
+// role to a subject on an object writes two kinds of managed relation tuples:
+//
+//	docs:report#role:editor@alice                 (the binding)
+//	docs:report#edit@docs:report#role:editor      (a grant, one per relation)
+//
+// Grants are shared by all bindings of the role on the object, and are
+// deleted together with the last binding. Relations that the namespace
+// inherits through its hierarchy apply to the whole subtree of the object.
+
+type (
+	Provider interface {
+		RoleManager() *Manager
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+	}
+	Manager struct {
+		d dependencies
+	}
+	// A role and the relations it grants
+	//
+	// swagger:model role
+	Role struct {
+		Name      string   `json:"name"`
+		Relations []string `json:"relations"`
+	}
+	// The binding of a role to a subject on an object
+	//
+	// swagger:model roleBinding
+	Binding struct {
+		// Namespace of the object
+		//
+		// required: true
+		Namespace string `json:"namespace"`
+		// Object the role is bound on
+		//
+		// required: true
+		Object string `json:"object"`
+		// Role to bind
+		//
+		// required: true
+		Role string `json:"role"`
+		// SubjectID the role is bound to
+		//
+		// Either SubjectSet or SubjectID has to be provided.
+		SubjectID *string `json:"subject_id,omitempty"`
+		// SubjectSet the role is bound to
+		//
+		// Either SubjectSet or SubjectID has to be provided.
+		//
+		// swagger:allOf
+		SubjectSet *relationtuple.SubjectSet `json:"subject_set,omitempty"`
+	}
+	// The changes made when syncing the grants of a namespace
+	//
+	// swagger:model roleSyncResult
+	SyncResult struct {
+		// Inserted is the number of grants that were missing.
+		Inserted int `json:"inserted"`
+		// Deleted is the number of grants that no role grants anymore.
+		Deleted int `json:"deleted"`
+	}
+)
+
+func NewManager(d dependencies) *Manager {
+	return &Manager{d: d}
+}
+
+// Relation returns the relation that binds subjects to the role.
+func Relation(role string) string {
+	return namespace.RoleRelationPrefix + role
+}
+
+func (b *Binding) subject() (relationtuple.Subject, error) {
+	switch {
+	case b.SubjectID != nil && b.SubjectSet != nil:
+		return nil, errors.WithStack(relationtuple.ErrDuplicateSubject)
+	case b.SubjectID != nil:
+		return &relationtuple.SubjectID{ID: *b.SubjectID}, nil
+	case b.SubjectSet != nil:
+		return b.SubjectSet, nil
+	}
+	return nil, errors.WithStack(relationtuple.ErrNilSubject)
+}
+
+// tuple returns the relation tuple that binds the role.
+func (b *Binding) tuple() (*relationtuple.InternalRelationTuple, error) {
+	if b.Namespace == "" || b.Object == "" || b.Role == "" {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).WithReason("A role binding requires a namespace, an object, and a role."))
+	}
+	s, err := b.subject()
+	if err != nil {
+		return nil, err
+	}
+	return &relationtuple.InternalRelationTuple{
+		Namespace: b.Namespace,
+		Object:    b.Object,
+		Relation:  Relation(b.Role),
+		Subject:   s,
+	}, nil
+}
+
+func bindingFromTuple(rt *relationtuple.InternalRelationTuple) *Binding {
+	b := &Binding{
+		Namespace: rt.Namespace,
+		Object:    rt.Object,
+		Role:      strings.TrimPrefix(rt.Relation, namespace.RoleRelationPrefix),
+	}
+	switch s := rt.Subject.(type) {
+	case *relationtuple.SubjectID:
+		b.SubjectID = &s.ID
+	case *relationtuple.SubjectSet:
+		b.SubjectSet = s
+	}
+	return b
+}
+
+// grants returns the relation tuples that give the bindings of the role on
+// the object the relations.
+func grants(ns, object, role string, relations []string) []*relationtuple.InternalRelationTuple {
+	rr := make([]*relationtuple.InternalRelationTuple, len(relations))
+	for i, r := range relations {
+		rr[i] = &relationtuple.InternalRelationTuple{
+			Namespace: ns,
+			Object:    object,
+			Relation:  r,
+			Subject:   &relationtuple.SubjectSet{Namespace: ns, Object: object, Relation: Relation(role)},
+		}
+	}
+	return rr
+}
+
+func (m *Manager) definitions(ctx context.Context, ns string) (map[string][]string, error) {
+	nm, err := m.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	n, err := nm.GetNamespaceByName(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := namespace.ResolvedOptions(ctx, nm, n)
+	if err != nil {
+		return nil, err
+	}
+	return opts.Roles, nil
+}
+
+func (m *Manager) relations(ctx context.Context, ns, role string) ([]string, error) {
+	defs, err := m.definitions(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	relations, ok := defs[role]
+	if !ok {
+		return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.RoleUnknown).
+			WithReasonf("The namespace %q defines no role %q.", ns, role).
+			WithDetail(errorcode.DetailField, "role"))
+	}
+	return relations, nil
+}
+
+// Roles returns the roles of the namespace, sorted by name.
+func (m *Manager) Roles(ctx context.Context, ns string) ([]*Role, error) {
+	defs, err := m.definitions(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]*Role, 0, len(defs))
+	for name, relations := range defs {
+		roles = append(roles, &Role{Name: name, Relations: relations})
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// exists returns whether the exact relation tuple is stored.
+func (m *Manager) exists(ctx context.Context, rt *relationtuple.InternalRelationTuple) (bool, error) {
+	res, _, err := m.d.RelationTupleManager().GetRelationTuples(ctx, rt.ToQuery(), x.WithSize(1))
+	if err != nil {
+		return false, err
+	}
+	return len(res) > 0, nil
+}
+
+// Bind binds the role to the subject on the object. Binding it again is a
+// no-op.
+func (m *Manager) Bind(ctx context.Context, b *Binding) error {
+	binding, err := b.tuple()
+	if err != nil {
+		return err
+	}
+	relations, err := m.relations(ctx, b.Namespace, b.Role)
+	if err != nil {
+		return err
+	}
+
+	var insert []*relationtuple.InternalRelationTuple
+	for _, rt := range append([]*relationtuple.InternalRelationTuple{binding}, grants(b.Namespace, b.Object, b.Role, relations)...) {
+		exists, err := m.exists(ctx, rt)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			insert = append(insert, rt)
+		}
+	}
+	if len(insert) == 0 {
+		return nil
+	}
+	return m.d.RelationTupleManager().TransactRelationTuples(ctx, insert, nil)
+}
+
+// Unbind removes the binding. The grants of the role on the object are
+// removed with the last binding of the role on the object.
+func (m *Manager) Unbind(ctx context.Context, b *Binding) error {
+	binding, err := b.tuple()
+	if err != nil {
+		return err
+	}
+	relations, err := m.relations(ctx, b.Namespace, b.Role)
+	if err != nil {
+		return err
+	}
+
+	// Two tuples are enough to tell whether another binding remains.
+	remaining, _, err := m.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{
+		Namespace: b.Namespace,
+		Object:    b.Object,
+		Relation:  binding.Relation,
+	}, x.WithSize(2))
+	if err != nil {
+		return err
+	}
+	last := true
+	for _, rt := range remaining {
+		if !rt.Subject.Equals(binding.Subject) {
+			last = false
+		}
+	}
+
+	del := []*relationtuple.InternalRelationTuple{binding}
+	if last {
+		del = append(del, grants(b.Namespace, b.Object, b.Role, relations)...)
+	}
+	return m.d.RelationTupleManager().TransactRelationTuples(ctx, nil, del)
+}
+
+// Bindings returns a page of the bindings of the role in the namespace,
+// optionally only those on the object.
+func (m *Manager) Bindings(ctx context.Context, ns, object, role string, options ...x.PaginationOptionSetter) ([]*Binding, string, error) {
+	if _, err := m.relations(ctx, ns, role); err != nil {
+		return nil, "", err
+	}
+	res, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{
+		Namespace: ns,
+		Object:    object,
+		Relation:  Relation(role),
+	}, options...)
+	if err != nil {
+		return nil, "", err
+	}
+	bb := make([]*Binding, len(res))
+	for i, rt := range res {
+		bb[i] = bindingFromTuple(rt)
+	}
+	return bb, next, nil
+}
+
+// Sync updates the grants of all bindings in the namespace to the current
+// role definitions, e.g. after relations were added to or removed from a
+// role. Grants of roles that are no longer defined are deleted, their
+// bindings are kept.
+func (m *Manager) Sync(ctx context.Context, ns string) (*SyncResult, error) {
+	defs, err := m.definitions(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	want := map[string]*relationtuple.InternalRelationTuple{}
+	have := map[string]*relationtuple.InternalRelationTuple{}
+	var page string
+	for {
+		res, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: ns},
+			x.WithToken(page), x.WithSize(m.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		for _, rt := range res {
+			if strings.HasPrefix(rt.Relation, namespace.RoleRelationPrefix) {
+				role := strings.TrimPrefix(rt.Relation, namespace.RoleRelationPrefix)
+				for _, g := range grants(ns, rt.Object, role, defs[role]) {
+					want[g.String()] = g
+				}
+			} else if s, ok := rt.Subject.(*relationtuple.SubjectSet); ok &&
+				s.Namespace == ns && s.Object == rt.Object && strings.HasPrefix(s.Relation, namespace.RoleRelationPrefix) {
+				have[rt.String()] = rt
+			}
+		}
+		if next == "" {
+			break
+		}
+		page = next
+	}
+
+	var insert, del []*relationtuple.InternalRelationTuple
+	for k, rt := range want {
+		if _, ok := have[k]; !ok {
+			insert = append(insert, rt)
+		}
+	}
+	for k, rt := range have {
+		if _, ok := want[k]; !ok {
+			del = append(del, rt)
+		}
+	}
+	if len(insert)+len(del) > 0 {
+		if err := m.d.RelationTupleManager().TransactRelationTuples(ctx, insert, del); err != nil {
+			return nil, err
+		}
+	}
+	return &SyncResult{Inserted: len(insert), Deleted: len(del)}, nil
+}
@@ -0,0 +1,183 @@
+package roles_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/pointerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/roles"
+	"github.com/ory/keto/internal/x"
+)
+
+func newRegistry(t *testing.T, roleDefs map[string][]string) *driver.RegistryDefault {
+	reg := driver.NewSqliteTestRegistry(t, false)
+	setRoles(t, reg, roleDefs)
+	return reg
+}
+
+func setRoles(t *testing.T, reg *driver.RegistryDefault, roleDefs map[string][]string) {
+	ctx := context.Background()
+	cfg, err := json.Marshal(map[string]interface{}{
+		"roles":     roleDefs,
+		"hierarchy": map[string]interface{}{"separator": "/", "relations": []string{"view", "edit"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+		{ID: 0, Name: "docs", Config: cfg},
+		{ID: 1, Name: "groups"},
+	}))
+}
+
+func tuples(t *testing.T, reg *driver.RegistryDefault) []string {
+	res, _, err := reg.RelationTupleManager().GetRelationTuples(context.Background(), &relationtuple.RelationQuery{Namespace: "docs"})
+	require.NoError(t, err)
+	ss := make([]string, len(res))
+	for i, rt := range res {
+		ss[i] = rt.String()
+	}
+	sort.Strings(ss)
+	return ss
+}
+
+func check(t *testing.T, reg *driver.RegistryDefault, tuple string) bool {
+	rt, err := (&relationtuple.InternalRelationTuple{}).FromString(tuple)
+	require.NoError(t, err)
+	allowed, err := reg.PermissionEngine().SubjectIsAllowed(context.Background(), rt, 0)
+	require.NoError(t, err)
+	return allowed
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("case=binds and unbinds roles", func(t *testing.T) {
+		reg := newRegistry(t, map[string][]string{"editor": {"view", "edit"}, "viewer": {"view"}})
+		m := reg.RoleManager()
+
+		alice := &roles.Binding{Namespace: "docs", Object: "projects", Role: "editor", SubjectID: pointerx.String("alice")}
+		require.NoError(t, m.Bind(ctx, alice))
+		require.NoError(t, m.Bind(ctx, alice))
+		assert.Equal(t, []string{
+			"docs:projects#edit@docs:projects#role:editor",
+			"docs:projects#role:editor@alice",
+			"docs:projects#view@docs:projects#role:editor",
+		}, tuples(t, reg))
+
+		assert.True(t, check(t, reg, "docs:projects/apollo/readme#edit@alice"))
+		assert.False(t, check(t, reg, "docs:other#view@alice"))
+
+		devs := &roles.Binding{Namespace: "docs", Object: "projects", Role: "editor", SubjectSet: &relationtuple.SubjectSet{Namespace: "groups", Object: "devs", Relation: "member"}}
+		require.NoError(t, m.Bind(ctx, devs))
+
+		bindings, next, err := m.Bindings(ctx, "docs", "", "editor")
+		require.NoError(t, err)
+		assert.Empty(t, next)
+		assert.ElementsMatch(t, []*roles.Binding{alice, devs}, bindings)
+
+		require.NoError(t, m.Unbind(ctx, alice))
+		assert.False(t, check(t, reg, "docs:projects#view@alice"))
+		assert.Len(t, tuples(t, reg), 3, "the grants are kept for the remaining binding")
+
+		require.NoError(t, m.Unbind(ctx, devs))
+		assert.Empty(t, tuples(t, reg))
+	})
+
+	t.Run("case=rejects unknown roles", func(t *testing.T) {
+		reg := newRegistry(t, map[string][]string{"viewer": {"view"}})
+
+		err := reg.RoleManager().Bind(ctx, &roles.Binding{Namespace: "docs", Object: "a", Role: "owner", SubjectID: pointerx.String("alice")})
+		assert.True(t, errorcode.Is(err, errorcode.RoleUnknown), "%+v", err)
+
+		err = reg.RoleManager().Bind(ctx, &roles.Binding{Namespace: "docs", Object: "a", Role: "viewer"})
+		assert.True(t, errorcode.Is(err, errorcode.SubjectInvalid), "%+v", err)
+	})
+
+	t.Run("case=syncs changed definitions", func(t *testing.T) {
+		reg := newRegistry(t, map[string][]string{"editor": {"view", "edit"}})
+		require.NoError(t, reg.RoleManager().Bind(ctx, &roles.Binding{Namespace: "docs", Object: "a", Role: "editor", SubjectID: pointerx.String("alice")}))
+
+		setRoles(t, reg, map[string][]string{"editor": {"view", "comment"}})
+		res, err := reg.RoleManager().Sync(ctx, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, &roles.SyncResult{Inserted: 1, Deleted: 1}, res)
+		assert.Equal(t, []string{
+			"docs:a#comment@docs:a#role:editor",
+			"docs:a#role:editor@alice",
+			"docs:a#view@docs:a#role:editor",
+		}, tuples(t, reg))
+
+		res, err = reg.RoleManager().Sync(ctx, "docs")
+		require.NoError(t, err)
+		assert.Equal(t, &roles.SyncResult{}, res)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	reg := newRegistry(t, map[string][]string{"editor": {"view", "edit"}, "viewer": {"view"}})
+	r := httprouter.New()
+	roles.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	do := func(t *testing.T, method, path, body string, expectedStatus int) []byte {
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		require.Equal(t, expectedStatus, resp.StatusCode, buf.String())
+		return buf.Bytes()
+	}
+
+	t.Run("case=lists roles", func(t *testing.T) {
+		var body struct {
+			Roles []*roles.Role `json:"roles"`
+		}
+		require.NoError(t, json.Unmarshal(do(t, http.MethodGet, roles.RouteBase+"?namespace=docs", "", http.StatusOK), &body))
+		assert.Equal(t, []*roles.Role{{Name: "editor", Relations: []string{"view", "edit"}}, {Name: "viewer", Relations: []string{"view"}}}, body.Roles)
+
+		do(t, http.MethodGet, roles.RouteBase, "", http.StatusBadRequest)
+	})
+
+	t.Run("case=manages bindings", func(t *testing.T) {
+		do(t, http.MethodPut, roles.BindingsRoute, `{"namespace": "docs", "object": "a", "role": "viewer", "subject_id": "bob"}`, http.StatusCreated)
+		do(t, http.MethodPut, roles.BindingsRoute, `{"namespace": "docs", "object": "a", "role": "admin", "subject_id": "bob"}`, http.StatusNotFound)
+		assert.True(t, check(t, reg, "docs:a#view@bob"))
+
+		var body struct {
+			Bindings      []*roles.Binding `json:"bindings"`
+			NextPageToken string           `json:"next_page_token"`
+		}
+		require.NoError(t, json.Unmarshal(do(t, http.MethodGet, roles.BindingsRoute+"?namespace=docs&role=viewer&page_size=10", "", http.StatusOK), &body))
+		assert.Equal(t, []*roles.Binding{{Namespace: "docs", Object: "a", Role: "viewer", SubjectID: pointerx.String("bob")}}, body.Bindings)
+		do(t, http.MethodGet, roles.BindingsRoute+"?namespace=docs&role=viewer&page_size=-1", "", http.StatusBadRequest)
+
+		q := url.Values{"namespace": {"docs"}, "object": {"a"}, "role": {"viewer"}, "subject_id": {"bob"}}
+		do(t, http.MethodDelete, roles.BindingsRoute+"?"+q.Encode(), "", http.StatusNoContent)
+		assert.False(t, check(t, reg, "docs:a#view@bob"))
+	})
+
+	t.Run("case=syncs", func(t *testing.T) {
+		var res roles.SyncResult
+		require.NoError(t, json.Unmarshal(do(t, http.MethodPost, roles.SyncRoute+"?namespace=docs", "", http.StatusOK), &res))
+		assert.Equal(t, roles.SyncResult{}, res)
+	})
+}
@@ -0,0 +1,347 @@
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	handlerDependencies interface {
+		Provider
+		config.Provider
+		x.LoggerProvider
+	}
+	// Handler serves the SCIM protocol (RFC 7644). It is left out of the
+	// OpenAPI spec, as identity providers implement the protocol already.
+	Handler struct {
+		d handlerDependencies
+	}
+	scimError struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		SCIMType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail"`
+	}
+)
+
+const (
+	RouteBase                  = "/scim/v2"
+	ServiceProviderConfigRoute = RouteBase + "/ServiceProviderConfig"
+	UsersRoute                 = RouteBase + "/Users"
+	UserRoute                  = UsersRoute + "/:id"
+	GroupsRoute                = RouteBase + "/Groups"
+	GroupRoute                 = GroupsRoute + "/:id"
+
+	ContentType = "application/scim+json"
+)
+
+// filterPattern matches the only filters identity providers send for
+// provisioning, e.g. `userName eq "alice"`.
+var filterPattern = regexp.MustCompile(`^\s*(\w+)\s+(?i:eq)\s+"([^"]*)"\s*$`)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(ServiceProviderConfigRoute, h.protect(h.getServiceProviderConfig))
+	r.GET(UsersRoute, h.protect(h.listUsers))
+	r.POST(UsersRoute, h.protect(h.createUser))
+	r.GET(UserRoute, h.protect(h.getUser))
+	r.PUT(UserRoute, h.protect(h.replaceUser))
+	r.PATCH(UserRoute, h.protect(h.patchUser))
+	r.DELETE(UserRoute, h.protect(h.deleteUser))
+	r.GET(GroupsRoute, h.protect(h.listGroups))
+	r.POST(GroupsRoute, h.protect(h.createGroup))
+	r.GET(GroupRoute, h.protect(h.getGroup))
+	r.PUT(GroupRoute, h.protect(h.replaceGroup))
+	r.PATCH(GroupRoute, h.protect(h.patchGroup))
+	r.DELETE(GroupRoute, h.protect(h.deleteGroup))
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// protect hides the endpoints when SCIM is disabled and enforces the bearer
+// token otherwise. Both checks are done per request because the config is
+// contextualized.
+func (h *Handler) protect(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		c := h.d.Config(r.Context())
+		if !c.SCIMEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+
+		expected := c.SCIMBearerToken()
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="Ory Keto SCIM"`)
+			h.writeError(w, r, errors.WithStack(herodot.ErrUnauthorized.WithReason("Invalid or missing SCIM bearer token.")))
+			return
+		}
+		next(w, r, ps)
+	}
+}
+
+func (h *Handler) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) writeCreated(w http.ResponseWriter, location string, v interface{}) {
+	w.Header().Set("Location", location)
+	h.write(w, http.StatusCreated, v)
+}
+
+// writeError writes the error in the format of SCIM, which identity
+// providers show to administrators.
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	de := herodot.ToDefaultError(err, "")
+	status := de.StatusCode()
+	detail := de.Reason()
+	if detail == "" {
+		detail = de.Error()
+	}
+	if status >= http.StatusInternalServerError {
+		h.d.Logger().WithRequest(r).WithError(err).Error("Could not handle the SCIM request.")
+	}
+	scimType, _ := de.DetailsField[detailType].(string)
+	h.write(w, status, &scimError{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+			WithReasonf("The request body is malformed: %s", err).
+			WithDetail(detailType, "invalidSyntax"))
+	}
+	return nil
+}
+
+// listParameters parses the filter on the attribute and the pagination of a
+// list request. The count is capped at the maximum page size, as SCIM expects
+// servers to return fewer results instead of failing.
+func (h *Handler) listParameters(r *http.Request, attribute string) (value string, start, count int, err error) {
+	q := r.URL.Query()
+	c := h.d.Config(r.Context())
+
+	start, count = 1, c.DefaultPageSize()
+	if raw := q.Get("startIndex"); raw != "" {
+		if start, err = strconv.Atoi(raw); err != nil {
+			return "", 0, 0, invalid("invalidValue", "startIndex", "The startIndex has to be a number, got %q.", raw)
+		}
+	}
+	if raw := q.Get("count"); raw != "" {
+		if count, err = strconv.Atoi(raw); err != nil || count < 0 {
+			return "", 0, 0, invalid("invalidValue", "count", "The count has to be a non-negative number, got %q.", raw)
+		}
+	}
+	if count > c.MaxPageSize() {
+		count = c.MaxPageSize()
+	}
+
+	if raw := q.Get("filter"); raw != "" {
+		match := filterPattern.FindStringSubmatch(raw)
+		if match == nil || !strings.EqualFold(match[1], attribute) {
+			return "", 0, 0, invalid("invalidFilter", "filter", "Only filters of the form `%s eq \"value\"` are supported, got %q.", attribute, raw)
+		}
+		value = match[2]
+	}
+	return value, start, count, nil
+}
+
+func withMembers(r *http.Request) bool {
+	for _, a := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(a), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) getServiceProviderConfig(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.write(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": h.d.Config(r.Context()).MaxPageSize()},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]string{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "The token configured in scim.bearer_token.",
+		}},
+		"meta": &Meta{ResourceType: "ServiceProviderConfig", Location: ServiceProviderConfigRoute},
+	})
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	userName, start, count, err := h.listParameters(r, "userName")
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	list, err := h.d.SCIMManager().ListUsers(r.Context(), userName, start, count)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, list)
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var u User
+	if err := decode(r, &u); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	created, err := h.d.SCIMManager().CreateUser(r.Context(), &u)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeCreated(w, created.Meta.Location, created)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	u, err := h.d.SCIMManager().GetUser(r.Context(), ps.ByName("id"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, u)
+}
+
+func (h *Handler) replaceUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var u User
+	if err := decode(r, &u); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	replaced, err := h.d.SCIMManager().ReplaceUser(r.Context(), ps.ByName("id"), &u)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, replaced)
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var p PatchRequest
+	if err := decode(r, &p); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	u, err := h.d.SCIMManager().PatchUser(r.Context(), ps.ByName("id"), p.Operations)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, u)
+}
+
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := h.d.SCIMManager().DeleteUser(r.Context(), ps.ByName("id")); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listGroups(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	displayName, start, count, err := h.listParameters(r, "displayName")
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	list, err := h.d.SCIMManager().ListGroups(r.Context(), displayName, start, count, withMembers(r))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, list)
+}
+
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var g Group
+	if err := decode(r, &g); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	created, err := h.d.SCIMManager().CreateGroup(r.Context(), &g)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.writeCreated(w, created.Meta.Location, created)
+}
+
+func (h *Handler) getGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	g, err := h.d.SCIMManager().GetGroup(r.Context(), ps.ByName("id"), withMembers(r))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, g)
+}
+
+func (h *Handler) replaceGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var g Group
+	if err := decode(r, &g); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	replaced, err := h.d.SCIMManager().ReplaceGroup(r.Context(), ps.ByName("id"), &g)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, replaced)
+}
+
+func (h *Handler) patchGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var p PatchRequest
+	if err := decode(r, &p); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	g, err := h.d.SCIMManager().PatchGroup(r.Context(), ps.ByName("id"), p.Operations)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	h.write(w, http.StatusOK, g)
+}
+
+func (h *Handler) deleteGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := h.d.SCIMManager().DeleteGroup(r.Context(), ps.ByName("id")); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
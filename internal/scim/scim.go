@@ -0,0 +1,664 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/groups"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// The SCIM facade provisions users and groups from identity providers. The
+// users and groups it provisioned are recorded in the configured SCIM
+// namespace, e.g. "scim:users#active@alice" and "scim:groups#provisioned@devs",
+// while group members are written through the group manager, e.g.
+// "groups:devs#member@alice". Users and groups are identified by their
+// userName and displayName, which therefore can't be changed. Other
+// attributes are accepted but not stored.
+
+type (
+	Provider interface {
+		SCIMManager() *Manager
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+		groups.Provider
+	}
+	Manager struct {
+		d dependencies
+	}
+	User struct {
+		Schemas  []string `json:"schemas"`
+		ID       string   `json:"id"`
+		UserName string   `json:"userName"`
+		// Active defaults to true. Deactivating a user removes it from all
+		// groups.
+		Active *bool `json:"active,omitempty"`
+		Meta   *Meta `json:"meta,omitempty"`
+	}
+	Group struct {
+		Schemas     []string  `json:"schemas"`
+		ID          string    `json:"id"`
+		DisplayName string    `json:"displayName"`
+		Members     []*Member `json:"members,omitempty"`
+		Meta        *Meta     `json:"meta,omitempty"`
+	}
+	// Member is a user or, if the type is "Group", a nested group.
+	Member struct {
+		Value string `json:"value"`
+		Type  string `json:"type,omitempty"`
+	}
+	Meta struct {
+		ResourceType string `json:"resourceType"`
+		Location     string `json:"location"`
+	}
+	ListResponse struct {
+		Schemas      []string      `json:"schemas"`
+		TotalResults int           `json:"totalResults"`
+		StartIndex   int           `json:"startIndex"`
+		ItemsPerPage int           `json:"itemsPerPage"`
+		Resources    []interface{} `json:"Resources"`
+	}
+	PatchRequest struct {
+		Schemas    []string          `json:"schemas"`
+		Operations []*PatchOperation `json:"Operations"`
+	}
+	PatchOperation struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	MemberTypeUser  = "User"
+	MemberTypeGroup = "Group"
+
+	usersObject  = "users"
+	groupsObject = "groups"
+
+	relationActive      = "active"
+	relationInactive    = "inactive"
+	relationProvisioned = "provisioned"
+
+	// detailType carries the scimType of an error, e.g. "mutability".
+	detailType = "scim_type"
+)
+
+// memberFilterPath matches the paths Azure AD removes single members with,
+// e.g. `members[value eq "alice"]`.
+var memberFilterPath = regexp.MustCompile(`^members\[value eq "([^"]*)"\]$`)
+
+func NewManager(d dependencies) *Manager {
+	return &Manager{d: d}
+}
+
+func invalid(scimType, field, format string, args ...interface{}) error {
+	return errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.RequestInvalid).
+		WithReasonf(format, args...).
+		WithDetail(errorcode.DetailField, field).
+		WithDetail(detailType, scimType))
+}
+
+func notFound(resource, id string) error {
+	return errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NotFound).WithReasonf("The %s %q does not exist.", resource, id))
+}
+
+func exists(resource, id string) error {
+	return errors.WithStack(herodot.ErrConflict.WithID(errorcode.Conflict).
+		WithReasonf("The %s %q exists already.", resource, id).
+		WithDetail(detailType, "uniqueness"))
+}
+
+func (m *Manager) registration(ctx context.Context, object, relation, id string) *relationtuple.InternalRelationTuple {
+	return &relationtuple.InternalRelationTuple{
+		Namespace: m.d.Config(ctx).SCIMNamespace(),
+		Object:    object,
+		Relation:  relation,
+		Subject:   &relationtuple.SubjectID{ID: id},
+	}
+}
+
+// registrations returns the relation tuples recording the provisioned users
+// or groups. If the id is set, only those of the id are returned.
+func (m *Manager) registrations(ctx context.Context, object, id string) ([]*relationtuple.InternalRelationTuple, error) {
+	query := &relationtuple.RelationQuery{Namespace: m.d.Config(ctx).SCIMNamespace(), Object: object}
+	if id != "" {
+		query.SubjectID = &id
+	}
+	var all []*relationtuple.InternalRelationTuple
+	var page string
+	for {
+		res, next, err := m.d.RelationTupleManager().GetRelationTuples(ctx, query, x.WithToken(page), x.WithSize(m.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, res...)
+		if next == "" {
+			return all, nil
+		}
+		page = next
+	}
+}
+
+func registeredID(rt *relationtuple.InternalRelationTuple) string {
+	if id, ok := rt.Subject.(*relationtuple.SubjectID); ok {
+		return id.ID
+	}
+	return ""
+}
+
+func newList(resources []interface{}, start, count int) *ListResponse {
+	total := len(resources)
+	if start < 1 {
+		start = 1
+	}
+	if start > total {
+		resources = nil
+	} else if end := start - 1 + count; end < total {
+		resources = resources[start-1 : end]
+	} else {
+		resources = resources[start-1:]
+	}
+	if resources == nil {
+		resources = []interface{}{}
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func newUser(id string, active bool) *User {
+	return &User{
+		Schemas:  []string{SchemaUser},
+		ID:       id,
+		UserName: id,
+		Active:   &active,
+		Meta:     &Meta{ResourceType: "User", Location: UsersRoute + "/" + url.PathEscape(id)},
+	}
+}
+
+// user returns the user and whether it exists.
+func (m *Manager) user(ctx context.Context, id string) (*User, bool, error) {
+	res, err := m.registrations(ctx, usersObject, id)
+	if err != nil || len(res) == 0 {
+		return nil, false, err
+	}
+	return newUser(id, res[0].Relation == relationActive), true, nil
+}
+
+func (m *Manager) CreateUser(ctx context.Context, u *User) (*User, error) {
+	if u.UserName == "" {
+		return nil, invalid("invalidValue", "userName", "The userName is required.")
+	}
+	if _, ok, err := m.user(ctx, u.UserName); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, exists("user", u.UserName)
+	}
+
+	active := u.Active == nil || *u.Active
+	relation := relationActive
+	if !active {
+		relation = relationInactive
+	}
+	if err := m.d.RelationTupleManager().WriteRelationTuples(ctx, m.registration(ctx, usersObject, relation, u.UserName)); err != nil {
+		return nil, err
+	}
+	return newUser(u.UserName, active), nil
+}
+
+func (m *Manager) GetUser(ctx context.Context, id string) (*User, error) {
+	u, ok, err := m.user(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, notFound("user", id)
+	}
+	return u, nil
+}
+
+// ListUsers lists the users starting at the 1-based index. If the userName
+// is set, only the user of that name is listed.
+func (m *Manager) ListUsers(ctx context.Context, userName string, start, count int) (*ListResponse, error) {
+	var resources []interface{}
+	if userName != "" {
+		u, ok, err := m.user(ctx, userName)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			resources = append(resources, u)
+		}
+		return newList(resources, start, count), nil
+	}
+
+	res, err := m.registrations(ctx, usersObject, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, rt := range res {
+		resources = append(resources, newUser(registeredID(rt), rt.Relation == relationActive))
+	}
+	return newList(resources, start, count), nil
+}
+
+// setActive activates or deactivates the user. Deactivated users are removed
+// from all groups, and are not added back when they are activated again, as
+// identity providers push the memberships again.
+func (m *Manager) setActive(ctx context.Context, u *User, active bool) (*User, error) {
+	if *u.Active == active {
+		return u, nil
+	}
+	from, to := relationActive, relationInactive
+	if active {
+		from, to = to, from
+	}
+	if err := m.d.RelationTupleManager().TransactRelationTuples(ctx,
+		[]*relationtuple.InternalRelationTuple{m.registration(ctx, usersObject, to, u.ID)},
+		[]*relationtuple.InternalRelationTuple{m.registration(ctx, usersObject, from, u.ID)},
+	); err != nil {
+		return nil, err
+	}
+	if !active {
+		if err := m.removeFromGroups(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+	return newUser(u.ID, active), nil
+}
+
+func (m *Manager) removeFromGroups(ctx context.Context, id string) error {
+	ns, relation := m.d.Config(ctx).Groups()
+	return m.d.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: ns, Relation: relation, SubjectID: &id})
+}
+
+func (m *Manager) ReplaceUser(ctx context.Context, id string, u *User) (*User, error) {
+	if u.UserName != "" && u.UserName != id {
+		return nil, invalid("mutability", "userName", "The userName %q can't be changed.", id)
+	}
+	current, err := m.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return m.setActive(ctx, current, u.Active == nil || *u.Active)
+}
+
+// parseActive parses the active attribute of a patch operation. Azure AD sends
+// it as the string "True" or "False".
+func parseActive(raw json.RawMessage) (bool, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return false, invalid("invalidValue", "active", "The active attribute is malformed: %s", err)
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, nil
+		}
+	}
+	return false, invalid("invalidValue", "active", "The active attribute has to be a boolean, got %s.", raw)
+}
+
+// isName returns whether the JSON value is the string name.
+func isName(raw json.RawMessage, name string) bool {
+	var s string
+	return json.Unmarshal(raw, &s) == nil && s == name
+}
+
+// PatchUser applies the operations on the active attribute. Operations on
+// other attributes are ignored, as they are not stored.
+func (m *Manager) PatchUser(ctx context.Context, id string, ops []*PatchOperation) (*User, error) {
+	u, err := m.GetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	active := *u.Active
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		case "remove":
+			continue
+		default:
+			return nil, invalid("invalidSyntax", "op", "Unknown patch operation %q.", op.Op)
+		}
+
+		switch {
+		case strings.EqualFold(op.Path, "active"):
+			if active, err = parseActive(op.Value); err != nil {
+				return nil, err
+			}
+		case op.Path == "":
+			var attributes map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &attributes); err != nil {
+				return nil, invalid("invalidValue", "value", "The value of a patch operation without path has to be an object: %s", err)
+			}
+			if raw, ok := attributes["active"]; ok {
+				if active, err = parseActive(raw); err != nil {
+					return nil, err
+				}
+			}
+			if raw, ok := attributes["userName"]; ok && !isName(raw, id) {
+				return nil, invalid("mutability", "userName", "The userName %q can't be changed.", id)
+			}
+		case strings.EqualFold(op.Path, "userName"):
+			return nil, invalid("mutability", "userName", "The userName %q can't be changed.", id)
+		}
+	}
+	return m.setActive(ctx, u, active)
+}
+
+// DeleteUser deletes the user and removes it from all groups.
+func (m *Manager) DeleteUser(ctx context.Context, id string) error {
+	res, err := m.registrations(ctx, usersObject, id)
+	if err != nil {
+		return err
+	} else if len(res) == 0 {
+		return notFound("user", id)
+	}
+	if err := m.removeFromGroups(ctx, id); err != nil {
+		return err
+	}
+	return m.d.RelationTupleManager().DeleteRelationTuples(ctx, res...)
+}
+
+func toGroupMember(member *Member) *groups.Member {
+	value := member.Value
+	if strings.EqualFold(member.Type, MemberTypeGroup) {
+		return &groups.Member{Group: &value}
+	}
+	return &groups.Member{SubjectID: &value}
+}
+
+func (m *Manager) groupRegistered(ctx context.Context, id string) (bool, error) {
+	res, err := m.registrations(ctx, groupsObject, id)
+	return len(res) > 0, err
+}
+
+// members returns all direct members of the group. Members that are neither
+// users nor groups, e.g. subject sets written through the group API, are left
+// out.
+func (m *Manager) members(ctx context.Context, id string) ([]*Member, error) {
+	members := []*Member{}
+	var page string
+	for {
+		res, next, err := m.d.GroupManager().Members(ctx, id, x.WithToken(page), x.WithSize(m.d.Config(ctx).MaxPageSize()))
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range res {
+			switch {
+			case member.SubjectID != nil:
+				members = append(members, &Member{Value: *member.SubjectID, Type: MemberTypeUser})
+			case member.Group != nil:
+				members = append(members, &Member{Value: *member.Group, Type: MemberTypeGroup})
+			}
+		}
+		if next == "" {
+			return members, nil
+		}
+		page = next
+	}
+}
+
+func (m *Manager) group(ctx context.Context, id string, withMembers bool) (*Group, error) {
+	g := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          id,
+		DisplayName: id,
+		Meta:        &Meta{ResourceType: "Group", Location: GroupsRoute + "/" + url.PathEscape(id)},
+	}
+	if withMembers {
+		members, err := m.members(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		g.Members = members
+	}
+	return g, nil
+}
+
+// CreateGroup creates the group with its members. Members that are groups must
+// not make the group a member of itself.
+func (m *Manager) CreateGroup(ctx context.Context, g *Group) (*Group, error) {
+	if g.DisplayName == "" {
+		return nil, invalid("invalidValue", "displayName", "The displayName is required.")
+	}
+	if ok, err := m.groupRegistered(ctx, g.DisplayName); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, exists("group", g.DisplayName)
+	}
+
+	if len(g.Members) > 0 {
+		members := make([]*groups.Member, len(g.Members))
+		for i, member := range g.Members {
+			members[i] = toGroupMember(member)
+		}
+		if err := m.d.GroupManager().Create(ctx, g.DisplayName, members); err != nil {
+			if errorcode.Is(err, errorcode.GroupExists) {
+				return nil, exists("group", g.DisplayName)
+			}
+			return nil, err
+		}
+	}
+	if err := m.d.RelationTupleManager().WriteRelationTuples(ctx, m.registration(ctx, groupsObject, relationProvisioned, g.DisplayName)); err != nil {
+		return nil, err
+	}
+	return m.group(ctx, g.DisplayName, true)
+}
+
+func (m *Manager) GetGroup(ctx context.Context, id string, withMembers bool) (*Group, error) {
+	if ok, err := m.groupRegistered(ctx, id); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, notFound("group", id)
+	}
+	return m.group(ctx, id, withMembers)
+}
+
+// ListGroups lists the groups starting at the 1-based index. If the
+// displayName is set, only the group of that name is listed.
+func (m *Manager) ListGroups(ctx context.Context, displayName string, start, count int, withMembers bool) (*ListResponse, error) {
+	res, err := m.registrations(ctx, groupsObject, displayName)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(res))
+	for i, rt := range res {
+		ids[i] = registeredID(rt)
+	}
+
+	list := newList(make([]interface{}, len(ids)), start, count)
+	for i := range list.Resources {
+		if list.Resources[i], err = m.group(ctx, ids[list.StartIndex-1+i], withMembers); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func memberKey(member *Member) string {
+	if strings.EqualFold(member.Type, MemberTypeGroup) {
+		return MemberTypeGroup + ":" + member.Value
+	}
+	return MemberTypeUser + ":" + member.Value
+}
+
+// replaceMembers adds and removes members so that the group has exactly the
+// given members.
+func (m *Manager) replaceMembers(ctx context.Context, id string, members []*Member) error {
+	current, err := m.members(ctx, id)
+	if err != nil {
+		return err
+	}
+	desired := make(map[string]bool, len(members))
+	for _, member := range members {
+		desired[memberKey(member)] = true
+	}
+	for _, member := range current {
+		if !desired[memberKey(member)] {
+			if err := m.d.GroupManager().RemoveMember(ctx, id, toGroupMember(member)); err != nil {
+				return err
+			}
+		}
+	}
+	return m.addMembers(ctx, id, members)
+}
+
+func (m *Manager) addMembers(ctx context.Context, id string, members []*Member) error {
+	for _, member := range members {
+		if err := m.d.GroupManager().AddMember(ctx, id, toGroupMember(member)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeMember removes the users and groups with the value, as removals from
+// identity providers don't carry the type.
+func (m *Manager) removeMember(ctx context.Context, id, value string) error {
+	for _, typ := range []string{MemberTypeUser, MemberTypeGroup} {
+		if err := m.d.GroupManager().RemoveMember(ctx, id, toGroupMember(&Member{Value: value, Type: typ})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) ReplaceGroup(ctx context.Context, id string, g *Group) (*Group, error) {
+	if g.DisplayName != "" && g.DisplayName != id {
+		return nil, invalid("mutability", "displayName", "The displayName %q can't be changed.", id)
+	}
+	if _, err := m.GetGroup(ctx, id, false); err != nil {
+		return nil, err
+	}
+	if err := m.replaceMembers(ctx, id, g.Members); err != nil {
+		return nil, err
+	}
+	return m.group(ctx, id, true)
+}
+
+func parseMembers(raw json.RawMessage) ([]*Member, error) {
+	var members []*Member
+	if len(raw) == 0 {
+		return members, nil
+	}
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, invalid("invalidValue", "members", "The members are malformed: %s", err)
+	}
+	return members, nil
+}
+
+// PatchGroup applies the operations on the members of the group.
+func (m *Manager) PatchGroup(ctx context.Context, id string, ops []*PatchOperation) (*Group, error) {
+	if _, err := m.GetGroup(ctx, id, false); err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if err := m.patchGroup(ctx, id, op); err != nil {
+			return nil, err
+		}
+	}
+	return m.group(ctx, id, true)
+}
+
+func (m *Manager) patchGroup(ctx context.Context, id string, op *PatchOperation) error {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case "add", "remove", "replace":
+	default:
+		return invalid("invalidSyntax", "op", "Unknown patch operation %q.", op.Op)
+	}
+
+	if match := memberFilterPath.FindStringSubmatch(op.Path); match != nil {
+		if kind != "remove" {
+			return invalid("invalidPath", "path", "Members selected by a filter can only be removed.")
+		}
+		return m.removeMember(ctx, id, match[1])
+	}
+
+	switch {
+	case strings.EqualFold(op.Path, "members"):
+		members, err := parseMembers(op.Value)
+		if err != nil {
+			return err
+		}
+		switch {
+		case kind == "add":
+			return m.addMembers(ctx, id, members)
+		case kind == "replace", len(members) == 0:
+			// Removing without a value removes all members.
+			return m.replaceMembers(ctx, id, members)
+		}
+		for _, member := range members {
+			if err := m.removeMember(ctx, id, member.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.EqualFold(op.Path, "displayName"):
+		if !isName(op.Value, id) {
+			return invalid("mutability", "displayName", "The displayName %q can't be changed.", id)
+		}
+		return nil
+	case op.Path == "" && kind != "remove":
+		var g struct {
+			DisplayName *string         `json:"displayName"`
+			Members     json.RawMessage `json:"members"`
+		}
+		if err := json.Unmarshal(op.Value, &g); err != nil {
+			return invalid("invalidValue", "value", "The value of a patch operation without path has to be an object: %s", err)
+		}
+		if g.DisplayName != nil && *g.DisplayName != id {
+			return invalid("mutability", "displayName", "The displayName %q can't be changed.", id)
+		}
+		if g.Members == nil {
+			return nil
+		}
+		members, err := parseMembers(g.Members)
+		if err != nil {
+			return err
+		}
+		if kind == "add" {
+			return m.addMembers(ctx, id, members)
+		}
+		return m.replaceMembers(ctx, id, members)
+	}
+	return invalid("invalidPath", "path", "The path %q is not supported.", op.Path)
+}
+
+// DeleteGroup deletes the group with its members, and removes it from the
+// groups it is a member of.
+func (m *Manager) DeleteGroup(ctx context.Context, id string) error {
+	if _, err := m.GetGroup(ctx, id, false); err != nil {
+		return err
+	}
+	if err := m.d.GroupManager().Delete(ctx, id); err != nil {
+		return err
+	}
+	return m.d.RelationTupleManager().DeleteRelationTuples(ctx, m.registration(ctx, groupsObject, relationProvisioned, id))
+}
@@ -0,0 +1,200 @@
+package scim_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/groups/groupstest"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/scim"
+	"github.com/ory/keto/internal/x"
+)
+
+const token = "secret"
+
+func setup(t *testing.T) (*driver.RegistryDefault, func(t *testing.T, method, path, body string, expectedStatus int) gjson.Result) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "groups"}, {ID: 1, Name: "scim"}}))
+	require.NoError(t, reg.Config(ctx).Set(config.KeySCIMEnabled, true))
+	require.NoError(t, reg.Config(ctx).Set(config.KeySCIMBearerToken, token))
+
+	r := httprouter.New()
+	scim.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	return reg, func(t *testing.T, method, path, body string, expectedStatus int) gjson.Result {
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", scim.ContentType)
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(resp.Body)
+		require.Equal(t, expectedStatus, resp.StatusCode, buf.String())
+		return gjson.ParseBytes(buf.Bytes())
+	}
+}
+
+func memberValues(res gjson.Result) []string {
+	var values []string
+	for _, m := range res.Get("members").Array() {
+		values = append(values, m.Get("value").String())
+	}
+	return values
+}
+
+func TestAuthentication(t *testing.T) {
+	ctx := context.Background()
+	reg, _ := setup(t)
+	r := httprouter.New()
+	scim.NewHandler(reg).RegisterWriteRoutes(&x.WriteRouter{Router: r})
+
+	for _, tc := range []struct {
+		desc, authorization string
+		expected            int
+	}{
+		{desc: "missing token", expected: http.StatusUnauthorized},
+		{desc: "wrong token", authorization: "Bearer wrong", expected: http.StatusUnauthorized},
+		{desc: "valid token", authorization: "Bearer " + token, expected: http.StatusOK},
+	} {
+		t.Run("case="+tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, scim.ServiceProviderConfigRoute, nil)
+			req.Header.Set("Authorization", tc.authorization)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expected, rec.Code, rec.Body.String())
+			assert.Equal(t, scim.ContentType, rec.Header().Get("Content-Type"))
+		})
+	}
+
+	t.Run("case=is hidden when disabled", func(t *testing.T) {
+		require.NoError(t, reg.Config(ctx).Set(config.KeySCIMEnabled, false))
+		req := httptest.NewRequest(http.MethodGet, scim.ServiceProviderConfigRoute, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestUsers(t *testing.T) {
+	reg, do := setup(t)
+
+	created := do(t, http.MethodPost, scim.UsersRoute, `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"alice","name":{"givenName":"Alice"}}`, http.StatusCreated)
+	assert.Equal(t, "alice", created.Get("id").String())
+	assert.True(t, created.Get("active").Bool())
+	do(t, http.MethodPost, scim.UsersRoute, `{"userName":"alice"}`, http.StatusConflict)
+	do(t, http.MethodPost, scim.UsersRoute, `{"userName":"bob","active":false}`, http.StatusCreated)
+
+	t.Run("case=filters by userName", func(t *testing.T) {
+		list := do(t, http.MethodGet, scim.UsersRoute+`?filter=userName+eq+"alice"`, "", http.StatusOK)
+		assert.Equal(t, int64(1), list.Get("totalResults").Int())
+		assert.Equal(t, "alice", list.Get("Resources.0.userName").String())
+
+		list = do(t, http.MethodGet, scim.UsersRoute+`?filter=userName+eq+"carol"`, "", http.StatusOK)
+		assert.Equal(t, int64(0), list.Get("totalResults").Int())
+		assert.Len(t, list.Get("Resources").Array(), 0)
+
+		errRes := do(t, http.MethodGet, scim.UsersRoute+`?filter=emails+co+"example.com"`, "", http.StatusBadRequest)
+		assert.Equal(t, "invalidFilter", errRes.Get("scimType").String())
+		assert.Equal(t, "400", errRes.Get("status").String())
+	})
+
+	t.Run("case=paginates", func(t *testing.T) {
+		list := do(t, http.MethodGet, scim.UsersRoute+"?startIndex=2&count=1", "", http.StatusOK)
+		assert.Equal(t, int64(2), list.Get("totalResults").Int())
+		assert.Equal(t, int64(2), list.Get("startIndex").Int())
+		assert.Len(t, list.Get("Resources").Array(), 1)
+	})
+
+	t.Run("case=deactivation removes memberships", func(t *testing.T) {
+		do(t, http.MethodPost, scim.GroupsRoute, `{"displayName":"devs","members":[{"value":"alice"}]}`, http.StatusCreated)
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+		u := do(t, http.MethodPatch, scim.UsersRoute+"/alice", `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],"Operations":[{"op":"Replace","path":"active","value":"False"}]}`, http.StatusOK)
+		assert.False(t, u.Get("active").Bool())
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"))
+
+		u = do(t, http.MethodPatch, scim.UsersRoute+"/alice", `{"Operations":[{"op":"replace","value":{"active":true}}]}`, http.StatusOK)
+		assert.True(t, u.Get("active").Bool())
+		assert.False(t, do(t, http.MethodGet, scim.UsersRoute+"/bob", "", http.StatusOK).Get("active").Bool())
+	})
+
+	t.Run("case=rejects renames", func(t *testing.T) {
+		res := do(t, http.MethodPut, scim.UsersRoute+"/alice", `{"userName":"alicia"}`, http.StatusBadRequest)
+		assert.Equal(t, "mutability", res.Get("scimType").String())
+	})
+
+	t.Run("case=deletes users", func(t *testing.T) {
+		do(t, http.MethodPatch, scim.GroupsRoute+"/devs", `{"Operations":[{"op":"add","path":"members","value":[{"value":"bob"}]}]}`, http.StatusOK)
+		do(t, http.MethodDelete, scim.UsersRoute+"/bob", "", http.StatusNoContent)
+		do(t, http.MethodDelete, scim.UsersRoute+"/bob", "", http.StatusNotFound)
+		do(t, http.MethodGet, scim.UsersRoute+"/bob", "", http.StatusNotFound)
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "bob"))
+	})
+}
+
+func TestGroups(t *testing.T) {
+	reg, do := setup(t)
+
+	g := do(t, http.MethodPost, scim.GroupsRoute, `{"displayName":"admins","members":[{"value":"alice"}]}`, http.StatusCreated)
+	assert.Equal(t, []string{"alice"}, memberValues(g))
+	do(t, http.MethodPost, scim.GroupsRoute, `{"displayName":"admins"}`, http.StatusConflict)
+	do(t, http.MethodPost, scim.GroupsRoute, `{"displayName":"devs","members":[]}`, http.StatusCreated)
+
+	t.Run("case=patches members", func(t *testing.T) {
+		g := do(t, http.MethodPatch, scim.GroupsRoute+"/devs", `{"Operations":[{"op":"add","path":"members","value":[{"value":"bob"},{"value":"admins","type":"Group"}]}]}`, http.StatusOK)
+		assert.ElementsMatch(t, []string{"bob", "admins"}, memberValues(g))
+		assert.True(t, groupstest.IsMember(t, reg, "devs", "alice"), "members of nested groups are members")
+
+		do(t, http.MethodPatch, scim.GroupsRoute+"/admins", `{"Operations":[{"op":"add","path":"members","value":[{"value":"devs","type":"Group"}]}]}`, http.StatusBadRequest)
+
+		g = do(t, http.MethodPatch, scim.GroupsRoute+"/devs", `{"Operations":[{"op":"remove","path":"members[value eq \"admins\"]"}]}`, http.StatusOK)
+		assert.Equal(t, []string{"bob"}, memberValues(g))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "alice"))
+	})
+
+	t.Run("case=replaces members", func(t *testing.T) {
+		g := do(t, http.MethodPut, scim.GroupsRoute+"/devs", `{"displayName":"devs","members":[{"value":"carol"},{"value":"dave"}]}`, http.StatusOK)
+		assert.ElementsMatch(t, []string{"carol", "dave"}, memberValues(g))
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "bob"))
+
+		g = do(t, http.MethodPatch, scim.GroupsRoute+"/devs", `{"Operations":[{"op":"replace","value":{"id":"devs","displayName":"devs","members":[{"value":"dave"}]}}]}`, http.StatusOK)
+		assert.Equal(t, []string{"dave"}, memberValues(g))
+
+		res := do(t, http.MethodPatch, scim.GroupsRoute+"/devs", `{"Operations":[{"op":"replace","path":"displayName","value":"developers"}]}`, http.StatusBadRequest)
+		assert.Equal(t, "mutability", res.Get("scimType").String())
+	})
+
+	t.Run("case=lists groups", func(t *testing.T) {
+		list := do(t, http.MethodGet, scim.GroupsRoute+`?filter=displayName+eq+"devs"&excludedAttributes=members`, "", http.StatusOK)
+		assert.Equal(t, int64(1), list.Get("totalResults").Int())
+		assert.Equal(t, "devs", list.Get("Resources.0.id").String())
+		assert.False(t, list.Get("Resources.0.members").Exists())
+
+		list = do(t, http.MethodGet, scim.GroupsRoute, "", http.StatusOK)
+		assert.Equal(t, int64(2), list.Get("totalResults").Int())
+	})
+
+	t.Run("case=deletes groups", func(t *testing.T) {
+		do(t, http.MethodDelete, scim.GroupsRoute+"/devs", "", http.StatusNoContent)
+		do(t, http.MethodGet, scim.GroupsRoute+"/devs", "", http.StatusNotFound)
+		assert.False(t, groupstest.IsMember(t, reg, "devs", "dave"))
+		assert.Equal(t, int64(1), do(t, http.MethodGet, scim.GroupsRoute, "", http.StatusOK).Get("totalResults").Int())
+	})
+}
@@ -0,0 +1,199 @@
+// Package seed seeds an empty database on first boot with the namespace and
+// relation tuple files of the config, so that demo and preview environments
+// come up with the same data without init scripts.
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+type (
+	Provider interface {
+		Seeder() *Seeder
+	}
+	dependencies interface {
+		config.Provider
+		relationtuple.ManagerProvider
+		namespace.ConfigStoreProvider
+		persistence.Provider
+		x.LoggerProvider
+	}
+	Seeder struct {
+		d dependencies
+	}
+	// seed is the parsed content of the seed files.
+	seed struct {
+		namespaces []*namespace.Namespace
+		tuples     []*relationtuple.InternalRelationTuple
+		digest     string
+	}
+)
+
+func NewSeeder(d dependencies) *Seeder {
+	return &Seeder{d: d}
+}
+
+// read parses the seed files. The digest covers the names and contents of the
+// files in order.
+func read(namespaceFiles, tupleFiles []string) (*seed, error) {
+	s := &seed{}
+	h := sha256.New()
+	readFile := func(fn string) ([]byte, error) {
+		raw, err := os.ReadFile(fn) // #nosec G304 -- the seed files are configured by the operator
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		_, _ = h.Write([]byte(filepath.Base(fn)))
+		_, _ = h.Write(raw)
+		return raw, nil
+	}
+
+	for _, fn := range namespaceFiles {
+		raw, err := readFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		parse, err := config.GetParser(fn)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var n namespace.Namespace
+		if err := parse(raw, &n); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the seed namespace file %s", fn)
+		}
+		s.namespaces = append(s.namespaces, &n)
+	}
+	if err := namespace.Validate(s.namespaces); err != nil {
+		return nil, err
+	}
+
+	for _, fn := range tupleFiles {
+		raw, err := readFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) > 0 && raw[0] == '[' {
+			var tuples []*relationtuple.InternalRelationTuple
+			if err := json.Unmarshal(raw, &tuples); err != nil {
+				return nil, errors.Wrapf(err, "could not parse the seed relation tuple file %s", fn)
+			}
+			s.tuples = append(s.tuples, tuples...)
+			continue
+		}
+		var rt relationtuple.InternalRelationTuple
+		if err := json.Unmarshal(raw, &rt); err != nil {
+			return nil, errors.Wrapf(err, "could not parse the seed relation tuple file %s", fn)
+		}
+		s.tuples = append(s.tuples, &rt)
+	}
+
+	s.digest = hex.EncodeToString(h.Sum(nil))
+	return s, nil
+}
+
+// databaseNamespaces returns the store and the manager of the namespaces in
+// the database, or an error if the namespaces are located elsewhere.
+func (s *Seeder) databaseNamespaces(ctx context.Context) (namespace.ConfigStore, *config.DatabaseNamespaceManager, error) {
+	nm, err := s.d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	dbm, ok := nm.(*config.DatabaseNamespaceManager)
+	store := s.d.NamespaceConfigStore()
+	if !ok || store == nil {
+		return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(
+			"The seed has namespace files, but the namespaces are not located in the database. Set the namespaces to %q or remove %s from the config.",
+			config.NamespacesDatabase, config.KeySeedNamespaces))
+	}
+	return store, dbm, nil
+}
+
+// Seed applies the seed files of the config if the database is empty and was
+// not seeded before. Without seed files, it does nothing.
+func (s *Seeder) Seed(ctx context.Context) error {
+	namespaceFiles, tupleFiles := s.d.Config(ctx).Seed()
+	if len(namespaceFiles) == 0 && len(tupleFiles) == 0 {
+		return nil
+	}
+	l := s.d.Logger()
+
+	ss, ok := s.d.Persister().(persistence.SeedStore)
+	if !ok {
+		return errors.WithStack(herodot.ErrInternalServerError.WithReason("The config has seed files, but the storage backend does not support seeding."))
+	}
+	sd, err := read(namespaceFiles, tupleFiles)
+	if err != nil {
+		return err
+	}
+
+	var (
+		store namespace.ConfigStore
+		dbm   *config.DatabaseNamespaceManager
+	)
+	if len(sd.namespaces) > 0 {
+		if store, dbm, err = s.databaseNamespaces(ctx); err != nil {
+			return err
+		}
+	}
+
+	status, appliedDigest, err := ss.ApplySeed(ctx, sd.digest, func(ctx context.Context) error {
+		if store != nil {
+			v, err := store.AddNamespaceConfig(ctx, 0, sd.namespaces)
+			if err != nil {
+				return err
+			}
+			if _, err := store.ActivateNamespaceConfig(ctx, v.Version); err != nil {
+				return err
+			}
+			// The tuples can only be written once their namespaces are served.
+			if err := dbm.Reload(ctx); err != nil {
+				return err
+			}
+		}
+		if len(sd.tuples) == 0 {
+			return nil
+		}
+		return s.d.RelationTupleManager().WriteRelationTuples(ctx, sd.tuples...)
+	})
+	if err != nil {
+		if dbm != nil {
+			// Drop the namespaces of the rolled back seed.
+			if err := dbm.Reload(ctx); err != nil {
+				l.WithError(err).Warn("Could not reload the namespace configs after the seed failed.")
+			}
+		}
+		return err
+	}
+
+	l = l.WithField("digest", sd.digest)
+	switch status {
+	case persistence.SeedApplied:
+		l.WithField("namespaces", len(sd.namespaces)).
+			WithField("relation_tuples", len(sd.tuples)).
+			Info("Seeded the database.")
+	case persistence.SeedAlreadyApplied:
+		if appliedDigest != sd.digest {
+			l.WithField("applied_digest", appliedDigest).
+				Warn("The seed files changed since the database was seeded. They are not applied again.")
+			break
+		}
+		l.Debug("The database was seeded already.")
+	case persistence.SeedNotEmpty:
+		l.Info("The database holds data already, so the seed files are not applied.")
+	}
+	return nil
+}
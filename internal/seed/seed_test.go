@@ -0,0 +1,108 @@
+package seed_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+func TestSeeder(t *testing.T) {
+	ctx := context.Background()
+
+	writeFile := func(t *testing.T, name, content string) string {
+		fn := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(fn, []byte(content), 0600))
+		return fn
+	}
+	subjects := func(t *testing.T, reg *driver.RegistryDefault, object string) []string {
+		res, _, err := reg.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "files", Object: object})
+		require.NoError(t, err)
+		s := make([]string, len(res))
+		for i, rt := range res {
+			s[i] = rt.Subject.String()
+		}
+		return s
+	}
+	tuples := `[
+  {"namespace": "files", "object": "readme", "relation": "viewer", "subject_id": "alice"},
+  {"namespace": "files", "object": "readme", "relation": "viewer", "subject_id": "bob"}
+]`
+
+	t.Run("case=seeds the relation tuples once", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedRelationTuples, []string{writeFile(t, "tuples.json", tuples)}))
+
+		require.NoError(t, reg.Seeder().Seed(ctx))
+		assert.ElementsMatch(t, []string{"alice", "bob"}, subjects(t, reg, "readme"))
+
+		require.NoError(t, reg.RelationTupleManager().DeleteAllRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: "files"}))
+		require.NoError(t, reg.Seeder().Seed(ctx))
+		assert.Empty(t, subjects(t, reg, "readme"))
+	})
+
+	t.Run("case=seeds the namespaces", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, config.NamespacesDatabase))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedNamespaces, []string{writeFile(t, "files.yaml", "name: files\nid: 1\n")}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedRelationTuples, []string{writeFile(t, "tuples.json", tuples)}))
+
+		require.NoError(t, reg.Seeder().Seed(ctx))
+
+		nm, err := reg.Config(ctx).NamespaceManager()
+		require.NoError(t, err)
+		n, err := nm.GetNamespaceByName(ctx, "files")
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), n.ID)
+		assert.ElementsMatch(t, []string{"alice", "bob"}, subjects(t, reg, "readme"))
+	})
+
+	t.Run("case=skips a database with data", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{Name: "files", ID: 1}}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedRelationTuples, []string{writeFile(t, "tuples.json", tuples)}))
+		require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, &relationtuple.InternalRelationTuple{
+			Namespace: "files", Object: "notes", Relation: "viewer", Subject: &relationtuple.SubjectID{ID: "carol"},
+		}))
+
+		require.NoError(t, reg.Seeder().Seed(ctx))
+		assert.Empty(t, subjects(t, reg, "readme"))
+	})
+
+	t.Run("case=rolls back a failed seed", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, config.NamespacesDatabase))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedNamespaces, []string{writeFile(t, "files.yaml", "name: files\nid: 1\n")}))
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedRelationTuples, []string{writeFile(t, "tuples.json",
+			`{"namespace": "unknown", "object": "readme", "relation": "viewer", "subject_id": "alice"}`)}))
+
+		require.Error(t, reg.Seeder().Seed(ctx))
+
+		nm, err := reg.Config(ctx).NamespaceManager()
+		require.NoError(t, err)
+		nn, err := nm.Namespaces(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, nn)
+
+		// The seed was not recorded, so the fixed files are applied.
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedRelationTuples, []string{writeFile(t, "tuples.json", tuples)}))
+		require.NoError(t, reg.Seeder().Seed(ctx))
+		assert.ElementsMatch(t, []string{"alice", "bob"}, subjects(t, reg, "readme"))
+	})
+
+	t.Run("case=namespace files require the namespaces in the database", func(t *testing.T) {
+		reg := driver.NewSqliteTestRegistry(t, false)
+		require.NoError(t, reg.Config(ctx).Set(config.KeySeedNamespaces, []string{writeFile(t, "files.yaml", "name: files\nid: 1\n")}))
+
+		assert.Error(t, reg.Seeder().Seed(ctx))
+	})
+}
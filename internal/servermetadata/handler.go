@@ -0,0 +1,215 @@
+// Package servermetadata reports the version, the supported features, and the
+// schema migration level of the instance, so that clients can detect features
+// instead of failing on older instances.
+package servermetadata
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/ory/x/popx"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+type (
+	handlerDependencies interface {
+		persistence.Migrator
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+
+		mu         sync.Mutex
+		migrations *migrationStatus
+	}
+	// migrationStatus is the cached migration level of the database.
+	migrationStatus struct {
+		version   string
+		pending   int
+		checkedAt time.Time
+	}
+
+	// The metadata of the instance
+	//
+	// swagger:model serverMetadata
+	ServerMetadata struct {
+		// The version of Ory Keto.
+		//
+		// required: true
+		Version string `json:"version"`
+
+		// The version of the relation tuple APIs.
+		APIVersion string `json:"api_version"`
+
+		// The features the instance knows of, and whether it supports them.
+		// Features missing from the map are unknown to the instance.
+		Features map[string]bool `json:"features"`
+
+		// The version of the latest applied schema migration. It is empty if
+		// the storage backend does not use SQL migrations.
+		MigrationVersion string `json:"migration_version,omitempty"`
+
+		// The number of schema migrations that are not applied yet.
+		PendingMigrations int `json:"pending_migrations"`
+	}
+)
+
+// migrationStatusTTL is how long the migration level is cached, as the
+// migrations can be applied while the instance is running.
+const migrationStatusTTL = 30 * time.Second
+
+const (
+	RouteBase = "/version"
+
+	APIVersion = "v1alpha2"
+
+	FeatureBatchCheck      = "batch_check"
+	FeatureExpressionCheck = "expression_check"
+	FeatureTupleTemplates  = "tuple_templates"
+	FeatureWatch           = "watch"
+	FeatureCaveats         = "caveats"
+)
+
+var (
+	_ rts.VersionServiceServer = (*Handler)(nil)
+
+	// features lists what this version supports. Features are only ever
+	// added, so clients can rely on a missing key meaning an older instance.
+	features = map[string]bool{
+		FeatureBatchCheck:      true,
+		FeatureExpressionCheck: true,
+		FeatureTupleTemplates:  true,
+		FeatureWatch:           false,
+		FeatureCaveats:         false,
+	}
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(r *x.ReadRouter) {
+	r.GET(RouteBase, h.getVersion)
+}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.getVersion)
+}
+
+func (h *Handler) RegisterReadGRPC(s *grpc.Server) {
+	rts.RegisterVersionServiceServer(s, h)
+}
+
+func (h *Handler) RegisterWriteGRPC(s *grpc.Server) {
+	rts.RegisterVersionServiceServer(s, h)
+}
+
+// Metadata returns the metadata of the instance.
+func (h *Handler) Metadata(ctx context.Context) (*ServerMetadata, error) {
+	m := &ServerMetadata{
+		Version:    config.Version,
+		APIVersion: APIVersion,
+		Features:   make(map[string]bool, len(features)),
+	}
+	for f, supported := range features {
+		m.Features[f] = supported
+	}
+
+	status, err := h.migrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status != nil {
+		m.MigrationVersion, m.PendingMigrations = status.version, status.pending
+	}
+	return m, nil
+}
+
+// migrationStatus returns the migration level of the database, or nil if the
+// storage backend does not use SQL migrations. It is cached for
+// migrationStatusTTL, so that the metadata doesn't query the database on every
+// request.
+func (h *Handler) migrationStatus(ctx context.Context) (*migrationStatus, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.migrations != nil && time.Since(h.migrations.checkedAt) < migrationStatusTTL {
+		return h.migrations, nil
+	}
+
+	mb, err := h.d.MigrationBox(ctx)
+	if err != nil {
+		// The storage backend does not use SQL migrations.
+		return nil, nil
+	}
+	statuses, err := mb.Status(ctx)
+	if err != nil {
+		return nil, errors.WithStack(herodot.ErrInternalServerError.WithErrorf("could not determine the migration status: %s", err))
+	}
+	status := &migrationStatus{checkedAt: time.Now()}
+	for _, s := range statuses {
+		switch {
+		case s.State == popx.Pending:
+			status.pending++
+		case s.Version > status.version:
+			status.version = s.Version
+		}
+	}
+	h.migrations = status
+	return status, nil
+}
+
+// swagger:route GET /version metadata getVersion
+//
+// Return the version and metadata of the instance
+//
+// Returns the version of Ory Keto, the version of the relation tuple APIs,
+// the features the instance supports, and its schema migration level. SDKs
+// use it to detect features instead of failing on older instances.
+//
+// Be aware that if you are running multiple nodes of this service, the
+// metadata will never refer to the cluster state, only to a single instance.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: serverMetadata
+//       500: genericError
+func (h *Handler) getVersion(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	m, err := h.Metadata(r.Context())
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	h.d.Writer().Write(w, r, m)
+}
+
+func (h *Handler) GetVersion(_ context.Context, _ *rts.GetVersionRequest) (*rts.GetVersionResponse, error) {
+	return &rts.GetVersionResponse{Version: config.Version}, nil
+}
+
+func (h *Handler) GetServerMetadata(ctx context.Context, _ *rts.GetServerMetadataRequest) (*rts.GetServerMetadataResponse, error) {
+	m, err := h.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rts.GetServerMetadataResponse{
+		Version:           m.Version,
+		ApiVersion:        m.APIVersion,
+		Features:          m.Features,
+		MigrationVersion:  m.MigrationVersion,
+		PendingMigrations: int32(m.PendingMigrations),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package servermetadata_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/x/popx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/servermetadata"
+	"github.com/ory/keto/internal/x"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// countingMigrator counts the calls to MigrationBox.
+type countingMigrator struct {
+	*driver.RegistryDefault
+	calls int
+}
+
+func (m *countingMigrator) MigrationBox(ctx context.Context) (*popx.MigrationBox, error) {
+	m.calls++
+	return m.RegistryDefault.MigrationBox(ctx)
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	h := servermetadata.NewHandler(reg)
+
+	mb, err := reg.MigrationBox(ctx)
+	require.NoError(t, err)
+	statuses, err := mb.Status(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	latest := statuses[len(statuses)-1].Version
+
+	t.Run("case=GET /version", func(t *testing.T) {
+		r := httprouter.New()
+		h.RegisterReadRoutes(&x.ReadRouter{Router: r})
+		ts := httptest.NewServer(r)
+		t.Cleanup(ts.Close)
+
+		resp, err := ts.Client().Get(ts.URL + servermetadata.RouteBase)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var m servermetadata.ServerMetadata
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+		assert.Equal(t, config.Version, m.Version)
+		assert.Equal(t, servermetadata.APIVersion, m.APIVersion)
+		assert.True(t, m.Features[servermetadata.FeatureBatchCheck])
+		supported, known := m.Features[servermetadata.FeatureWatch]
+		assert.True(t, known)
+		assert.False(t, supported)
+		assert.Equal(t, latest, m.MigrationVersion)
+		assert.Zero(t, m.PendingMigrations)
+	})
+
+	t.Run("case=GetServerMetadata", func(t *testing.T) {
+		resp, err := h.GetServerMetadata(ctx, &rts.GetServerMetadataRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, servermetadata.APIVersion, resp.ApiVersion)
+		assert.True(t, resp.Features[servermetadata.FeatureTupleTemplates])
+		assert.Equal(t, latest, resp.MigrationVersion)
+	})
+
+	t.Run("case=caches the migration status", func(t *testing.T) {
+		m := &countingMigrator{RegistryDefault: reg}
+		h := servermetadata.NewHandler(m)
+		for i := 0; i < 3; i++ {
+			md, err := h.Metadata(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, latest, md.MigrationVersion)
+		}
+		assert.Equal(t, 1, m.calls)
+	})
+}
@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/ketooffline"
+)
+
+// ExportDependencies are the dependencies of Export, e.g. Keto's registry.
+type ExportDependencies interface {
+	config.Provider
+	relationtuple.ManagerProvider
+	persistence.Provider
+}
+
+// Export reads all relation tuples of the configured namespaces into a
+// snapshot. If the database supports reading snapshots, all relation tuples
+// are read at the same snapshot, otherwise writes during the export may be
+// partially included.
+func Export(ctx context.Context, d ExportDependencies) (*ketooffline.Snapshot, error) {
+	nm, err := d.Config(ctx).NamespaceManager()
+	if err != nil {
+		return nil, err
+	}
+	nn, err := nm.Namespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nn, func(i, j int) bool {
+		return nn[i].ID < nn[j].ID
+	})
+
+	s := &ketooffline.Snapshot{
+		Version:        ketooffline.FormatVersion,
+		CreatedAt:      time.Now().UTC(),
+		Namespaces:     make([]*ketooffline.Namespace, len(nn)),
+		RelationTuples: []*ketooffline.RelationTuple{},
+	}
+	if sr, ok := d.Persister().(persistence.SnapshotReader); ok {
+		if s.SnapshotToken, err = sr.SnapshotToken(ctx); err != nil {
+			return nil, err
+		}
+		if ctx, err = sr.AtSnapshot(ctx, s.SnapshotToken); err != nil {
+			return nil, err
+		}
+	}
+
+	pageSize := d.Config(ctx).MaxPageSize()
+	for i, n := range nn {
+		if s.Namespaces[i], err = compile(ctx, nm, n); err != nil {
+			return nil, err
+		}
+
+		var page string
+		for {
+			rts, next, err := d.RelationTupleManager().GetRelationTuples(ctx, &relationtuple.RelationQuery{Namespace: n.Name}, x.WithToken(page), x.WithSize(pageSize))
+			if err != nil {
+				return nil, err
+			}
+			s.RelationTuples = append(s.RelationTuples, rts...)
+			if next == "" {
+				break
+			}
+			page = next
+		}
+	}
+	return s, nil
+}
+
+// ExportDelta reads the changes since the snapshot token. It fails with
+// KETO_SNAPSHOT_TOKEN_EXPIRED if the deletions since then are no longer
+// retained, in which case a full snapshot has to be exported instead.
+func ExportDelta(ctx context.Context, d ExportDependencies, since string) (*ketooffline.Delta, error) {
+	dr, ok := d.Persister().(persistence.DeltaReader)
+	if !ok {
+		return nil, errors.WithStack(herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("the storage backend does not support deltas"))
+	}
+	changes, err := dr.ChangesSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return &ketooffline.Delta{
+		Version:       ketooffline.DeltaFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		SinceToken:    since,
+		SnapshotToken: changes.Token,
+		Deleted:       changes.Deleted,
+		Written:       changes.Written,
+	}, nil
+}
+
+// compile returns the namespace with the options it inherits included in its
+// config, so that the snapshot does not depend on the namespaces it extends.
+func compile(ctx context.Context, nm namespace.Manager, n *namespace.Namespace) (*ketooffline.Namespace, error) {
+	opts, err := namespace.ResolvedOptions(ctx, nm, n)
+	if err != nil {
+		return nil, err
+	}
+	opts.Extends = ""
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(raw) == "{}" {
+		raw = nil
+	}
+	return &ketooffline.Namespace{ID: n.ID, Name: n.Name, Config: raw}, nil
+}
@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/ketooffline"
+)
+
+type (
+	handlerDependencies interface {
+		ExportDependencies
+		x.WriterProvider
+	}
+	Handler struct {
+		d handlerDependencies
+	}
+)
+
+const (
+	RouteBase  = "/admin/snapshot"
+	DeltaRoute = RouteBase + "/delta"
+
+	ContentType      = "application/vnd.ory.keto.snapshot+gzip"
+	DeltaContentType = "application/vnd.ory.keto.delta+gzip"
+)
+
+func NewHandler(d handlerDependencies) *Handler {
+	return &Handler{d: d}
+}
+
+func (h *Handler) RegisterReadRoutes(_ *x.ReadRouter) {}
+
+func (h *Handler) RegisterWriteRoutes(r *x.WriteRouter) {
+	r.GET(RouteBase, h.exportSnapshot)
+	r.GET(DeltaRoute, h.exportDelta)
+}
+
+func (h *Handler) RegisterReadGRPC(_ *grpc.Server) {}
+
+func (h *Handler) RegisterWriteGRPC(_ *grpc.Server) {}
+
+// swagger:route GET /admin/snapshot write exportSnapshot
+//
+// # Export a snapshot
+//
+// Exports all relation tuples and the compiled namespace configs as a
+// gzip-compressed protobuf snapshot, the format described in
+// ketooffline/snapshot.proto. Snapshots are read by the offline evaluator and
+// by `keto offline restore`. If the database supports it, the relation tuples
+// are read at one snapshot token, which is included in the snapshot.
+//
+//	Produces:
+//	- application/vnd.ory.keto.snapshot+gzip
+//
+//	Schemes: http, https
+//
+//	Responses:
+//	  200: emptyResponse
+//	  500: genericError
+func (h *Handler) exportSnapshot(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	s, err := Export(r.Context(), h.d)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	// The snapshot is buffered, so that errors are still reported with a
+	// status code.
+	var buf bytes.Buffer
+	if err := ketooffline.Write(&buf, s); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	writeFile(w, &buf, ContentType, "snapshot.pb.gz")
+}
+
+// swagger:parameters exportSnapshotDelta
+// nolint:deadcode,unused
+type exportSnapshotDeltaParameters struct {
+	// The snapshot token of the snapshot or the previous delta.
+	//
+	// required: true
+	// in: query
+	Since string `json:"since"`
+}
+
+// swagger:route GET /admin/snapshot/delta write exportSnapshotDelta
+//
+// Export a delta
+//
+// Exports the relation tuples deleted and written since the snapshot token as
+// a gzip-compressed protobuf delta, so that a snapshot can be updated without
+// downloading it again. Deleted relation tuples are only retained for
+// `snapshots.delta_retention`; older tokens are refused with
+// KETO_SNAPSHOT_TOKEN_EXPIRED, and a full snapshot has to be downloaded
+// instead.
+//
+//     Produces:
+//     - application/vnd.ory.keto.delta+gzip
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: emptyResponse
+//       400: genericError
+//       500: genericError
+func (h *Handler) exportDelta(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		h.d.Writer().WriteError(w, r, errors.WithStack(herodot.ErrBadRequest.WithError("the snapshot token is required").WithDetail(errorcode.DetailField, "since")))
+		return
+	}
+	d, err := ExportDelta(r.Context(), h.d, since)
+	if err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ketooffline.WriteDelta(&buf, d); err != nil {
+		h.d.Writer().WriteError(w, r, err)
+		return
+	}
+	writeFile(w, &buf, DeltaContentType, "delta.pb.gz")
+}
+
+func writeFile(w http.ResponseWriter, buf *bytes.Buffer, contentType, name string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	_, _ = buf.WriteTo(w)
+}
@@ -0,0 +1,67 @@
+package snapshot_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/snapshot"
+	"github.com/ory/keto/internal/x"
+	"github.com/ory/keto/ketooffline"
+)
+
+func TestExportSnapshot(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "files"}}))
+	rt := &relationtuple.InternalRelationTuple{Namespace: "files", Object: "report", Relation: "view", Subject: &relationtuple.SubjectID{ID: "alice"}}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, rt))
+
+	r := &x.WriteRouter{Router: httprouter.New()}
+	snapshot.NewHandler(reg).RegisterWriteRoutes(r)
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	resp, err := ts.Client().Get(ts.URL + snapshot.RouteBase)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, snapshot.ContentType, resp.Header.Get("Content-Type"))
+
+	s, err := ketooffline.Read(resp.Body)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.SnapshotToken)
+	assert.Equal(t, []*ketooffline.RelationTuple{rt}, s.RelationTuples)
+
+	t.Run("case=delta", func(t *testing.T) {
+		require.NoError(t, reg.RelationTupleManager().DeleteRelationTuples(ctx, rt))
+
+		resp, err := ts.Client().Get(ts.URL + snapshot.DeltaRoute + "?" + url.Values{"since": {s.SnapshotToken}}.Encode())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, snapshot.DeltaContentType, resp.Header.Get("Content-Type"))
+
+		d, err := ketooffline.ReadDelta(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, []*ketooffline.RelationTuple{rt}, d.Deleted)
+		assert.Empty(t, d.Written)
+	})
+
+	t.Run("case=delta requires a token", func(t *testing.T) {
+		resp, err := ts.Client().Get(ts.URL + snapshot.DeltaRoute)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,66 @@
+// Package bloom implements a bloom filter, a set that can tell for sure that
+// an element was never added, but may report false positives for elements
+// that were not added.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// Filter is safe for concurrent use.
+type Filter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	hashes uint64
+}
+
+// New creates a filter that reports false positives at the given rate once n
+// elements were added. More elements increase the rate.
+func New(n int, falsePositiveRate float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	m := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(n)*math.Ln2))
+	return &Filter{
+		bits:   make([]uint64, (int(m)+63)/64),
+		hashes: uint64(k),
+	}
+}
+
+// locations derives the bit locations of the element by double hashing.
+func (f *Filter) locations(element string, do func(word int, mask uint64)) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(element))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31 | 1
+
+	size := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < f.hashes; i++ {
+		loc := (h1 + i*h2) % size
+		do(int(loc/64), 1<<(loc%64))
+	}
+}
+
+func (f *Filter) Add(element string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.locations(element, func(word int, mask uint64) {
+		f.bits[word] |= mask
+	})
+}
+
+// Test returns false if the element was never added.
+func (f *Filter) Test(element string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	found := true
+	f.locations(element, func(word int, mask uint64) {
+		found = found && f.bits[word]&mask != 0
+	})
+	return found
+}
@@ -0,0 +1,35 @@
+package bloom_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/keto/internal/x/bloom"
+)
+
+func TestFilter(t *testing.T) {
+	const n = 10000
+	f := bloom.New(n, 0.01)
+
+	for i := 0; i < n; i++ {
+		f.Add(fmt.Sprintf("added-%d", i))
+	}
+
+	t.Run("case=added elements are always found", func(t *testing.T) {
+		for i := 0; i < n; i++ {
+			assert.True(t, f.Test(fmt.Sprintf("added-%d", i)))
+		}
+	})
+
+	t.Run("case=false positives are rare", func(t *testing.T) {
+		falsePositives := 0
+		for i := 0; i < n; i++ {
+			if f.Test(fmt.Sprintf("other-%d", i)) {
+				falsePositives++
+			}
+		}
+		assert.Less(t, falsePositives, n/50)
+	})
+}
@@ -0,0 +1,34 @@
+package x
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext keeps the values of its parent, but is never cancelled and
+// has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+// DetachedContext returns a context with the values of the parent, e.g. the
+// network, that is neither cancelled with the parent nor has its deadline.
+func DetachedContext(parent context.Context) context.Context {
+	return detachedContext{parent}
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,26 @@
+package x
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetachedContext(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), 0)
+	cancel()
+	<-parent.Done()
+
+	ctx := DetachedContext(parent)
+	assert.Equal(t, "value", ctx.Value(key{}))
+	assert.NoError(t, ctx.Err())
+	assert.Nil(t, ctx.Done())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	ctx2, cancel := context.WithCancel(ctx)
+	defer cancel()
+	assert.NoError(t, ctx2.Err(), "derived contexts are not cancelled by the parent")
+}
@@ -4,36 +4,30 @@ package dbx
 
 import (
 	"fmt"
-	"math/rand"
-	"os"
+	"path/filepath"
 	"testing"
 )
 
+// GetSqlite returns a new database for every call, so that tests using it can
+// run in parallel. Only SQLiteDebug databases outlive the test.
 func GetSqlite(t testing.TB, mode sqliteMode) *DsnT {
 	dsn := &DsnT{
 		MigrateUp:   true,
 		MigrateDown: false,
 	}
 
-	fn := fmt.Sprintf("TestDB_%s_%d.sqlite", t.Name(), rand.Int31())
-	// init switch
+	fn := dbName(t.Name()) + ".sqlite"
 	switch mode {
 	case SQLiteMemory:
 		dsn.Name = "memory"
-		dsn.Conn = fmt.Sprintf("sqlite://file:%s?_fk=true&cache=shared&mode=memory", fn)
-		t.Cleanup(func() {
-			_ = os.Remove(fn)
-		})
-	case SQLiteFile, SQLiteDebug:
+		dsn.Conn = fmt.Sprintf("sqlite://file:%s?_fk=true&mode=memory&cache=shared", fn)
+	case SQLiteFile:
+		dsn.Name = "sqlite"
+		dsn.Conn = fmt.Sprintf("sqlite://file:%s?_fk=true", filepath.Join(t.TempDir(), fn))
+	case SQLiteDebug:
 		dsn.Name = "sqlite"
 		dsn.Conn = fmt.Sprintf("sqlite://file:%s?_fk=true", fn)
-	}
-	// cleanup switch
-	switch mode {
-	case SQLiteMemory, SQLiteFile:
-		t.Cleanup(func() {
-			_ = os.Remove(fn)
-		})
+		t.Logf("Keeping the SQLite database %s", fn)
 	}
 
 	return dsn
@@ -149,6 +149,35 @@ func GetDSNs(t testing.TB, debugSqliteOnDisk bool) []*DsnT {
 	return dsns
 }
 
+// GetDSN returns a new database of the DsnT.Name, or nil if the dialect was
+// disabled by a build tag.
+func GetDSN(t testing.TB, name string) *DsnT {
+	var conn string
+	switch name {
+	case "memory":
+		return GetSqlite(t, SQLiteMemory)
+	case "sqlite":
+		return GetSqlite(t, SQLiteFile)
+	case "mysql":
+		conn = RunMySQL(t, dbName(t.Name()))
+	case "postgres":
+		conn = RunPostgres(t, dbName(t.Name()))
+	case "cockroach":
+		conn = RunCockroach(t, dbName(t.Name()))
+	default:
+		t.Fatalf("unknown database %q", name)
+	}
+	if conn == "" {
+		return nil
+	}
+	return &DsnT{
+		Name:        name,
+		Conn:        conn,
+		MigrateUp:   true,
+		MigrateDown: true,
+	}
+}
+
 type sqliteMode = int
 
 const (
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"strings"
+
+	"github.com/ory/x/logrusx"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// Cycle is a path of subject sets that references its first subject set
+// again, which is usually a modeling error.
+type Cycle []relationtuple.Subject
+
+var cyclesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "keto",
+	Name:      "subject_set_cycles_total",
+	Help:      "The number of cycles in subject set references found by the check and expand engines, by the namespace the cycle starts in.",
+}, []string{"engine", "namespace"})
+
+func init() {
+	prometheus.MustRegister(cyclesTotal)
+}
+
+func (c Cycle) Strings() []string {
+	s := make([]string, len(c))
+	for i, sub := range c {
+		s[i] = sub.String()
+	}
+	return s
+}
+
+func (c Cycle) String() string {
+	return strings.Join(c.Strings(), " → ")
+}
+
+// Report logs the cycle and counts it in the keto_subject_set_cycles_total
+// metric. The engine is either "check" or "expand".
+func (c Cycle) Report(l *logrusx.Logger, engine string) {
+	namespace := ""
+	if s, ok := c[0].(*relationtuple.SubjectSet); ok {
+		namespace = s.Namespace
+	}
+	cyclesTotal.WithLabelValues(engine, namespace).Inc()
+
+	l.WithField("engine", engine).
+		WithField("cycle", c.Strings()).
+		Warnf("Found a cycle in subject set references: %s", c)
+}
@@ -8,7 +8,17 @@ import (
 
 type contextKey string
 
-const visitedMapKey = contextKey("visitedMap")
+const (
+	visitedMapKey = contextKey("visitedMap")
+	pathKey       = contextKey("path")
+)
+
+// pathElement is one subject on the path from the root of the traversal to the
+// subject that is currently expanded.
+type pathElement struct {
+	subject relationtuple.Subject
+	parent  *pathElement
+}
 
 func CheckAndAddVisited(ctx context.Context, current relationtuple.Subject) (context.Context, bool) {
 	visitedMap, ok := ctx.Value(visitedMapKey).(map[string]struct{})
@@ -16,7 +26,7 @@ func CheckAndAddVisited(ctx context.Context, current relationtuple.Subject) (con
 		// for the first time initialize the map
 		visitedMap = make(map[string]struct{})
 		visitedMap[current.String()] = struct{}{}
-		return context.WithValue(ctx, visitedMapKey, visitedMap), false
+		return ExtendPath(context.WithValue(ctx, visitedMapKey, visitedMap), current), false
 	}
 
 	// check if current node was already visited
@@ -27,9 +37,55 @@ func CheckAndAddVisited(ctx context.Context, current relationtuple.Subject) (con
 	// set current entry to visited
 	visitedMap[current.String()] = struct{}{}
 
-	return context.WithValue(
+	return ExtendPath(context.WithValue(
 		ctx,
 		visitedMapKey,
 		visitedMap,
-	), false
+	), current), false
+}
+
+// NewTraversal returns a context in which no subject was visited yet, e.g.
+// for a check that is evaluated as part of another one. The path is kept so
+// that cycles through both traversals are reported.
+func NewTraversal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, visitedMapKey, make(map[string]struct{}))
+}
+
+// ExtendPath appends the subject to the path of the traversal. Visited subjects
+// are appended by CheckAndAddVisited.
+func ExtendPath(ctx context.Context, current relationtuple.Subject) context.Context {
+	parent, _ := ctx.Value(pathKey).(*pathElement)
+	return context.WithValue(ctx, pathKey, &pathElement{subject: current, parent: parent})
+}
+
+// FindCycle returns the cycle that current closes if it is already on the path
+// of the traversal. Subjects that were visited on another path do not form a
+// cycle.
+func FindCycle(ctx context.Context, current relationtuple.Subject) (Cycle, bool) {
+	var reversed Cycle
+	for e, _ := ctx.Value(pathKey).(*pathElement); e != nil; e = e.parent {
+		reversed = append(reversed, e.subject)
+		if e.subject.Equals(current) {
+			c := make(Cycle, 0, len(reversed)+1)
+			for i := len(reversed) - 1; i >= 0; i-- {
+				c = append(c, reversed[i])
+			}
+			return append(c, current), true
+		}
+	}
+	return nil, false
+}
+
+// Path returns the subjects from the root of the traversal to the subject
+// that is currently expanded.
+func Path(ctx context.Context) []relationtuple.Subject {
+	var reversed []relationtuple.Subject
+	for e, _ := ctx.Value(pathKey).(*pathElement); e != nil; e = e.parent {
+		reversed = append(reversed, e.subject)
+	}
+	path := make([]relationtuple.Subject, len(reversed))
+	for i, s := range reversed {
+		path[len(reversed)-1-i] = s
+	}
+	return path
 }
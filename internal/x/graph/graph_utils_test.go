@@ -4,9 +4,14 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ory/x/logrusx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+
 	"github.com/ory/keto/internal/relationtuple"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEngineUtilsProvider_CheckVisited(t *testing.T) {
@@ -81,4 +86,43 @@ func TestEngineUtilsProvider_CheckVisited(t *testing.T) {
 
 		assert.Equal(t, isThereACycle, false)
 	})
+
+	t.Run("case=reports the path of a cycle", func(t *testing.T) {
+		a := &relationtuple.SubjectSet{Namespace: "default", Object: "A", Relation: "connected"}
+		b := &relationtuple.SubjectSet{Namespace: "default", Object: "B", Relation: "connected"}
+		c := &relationtuple.SubjectSet{Namespace: "default", Object: "C", Relation: "connected"}
+
+		ctx := ExtendPath(context.Background(), a)
+		ctxB, _ := CheckAndAddVisited(ctx, b)
+		ctxC, _ := CheckAndAddVisited(ctxB, c)
+		assert.Equal(t, []relationtuple.Subject{a, b, c}, Path(ctxC))
+
+		cycle, ok := FindCycle(ctxC, b)
+		require.True(t, ok)
+		assert.Equal(t, "default:B#connected → default:C#connected → default:B#connected", cycle.String())
+
+		cycle, ok = FindCycle(ctxC, a)
+		require.True(t, ok)
+		assert.Equal(t, Cycle{a, b, c, a}, cycle)
+
+		// C was visited on another path, which is not a cycle
+		_, visited := CheckAndAddVisited(ctxB, c)
+		assert.True(t, visited)
+		_, ok = FindCycle(ctxB, c)
+		assert.False(t, ok)
+	})
+
+	t.Run("case=counts reported cycles", func(t *testing.T) {
+		a := &relationtuple.SubjectSet{Namespace: "metrics", Object: "A", Relation: "connected"}
+		counter := cyclesTotal.WithLabelValues("check", "metrics")
+		before := testutil.ToFloat64(counter)
+
+		l := logrusx.New("test", "test")
+		hook := test.NewLocal(l.Logger)
+		Cycle{a, a}.Report(l, "check")
+
+		assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		require.Len(t, hook.Entries, 1)
+		assert.Equal(t, []string{"metrics:A#connected", "metrics:A#connected"}, hook.LastEntry().Data["cycle"])
+	})
 }
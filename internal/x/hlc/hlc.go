@@ -0,0 +1,54 @@
+// Package hlc implements a hybrid logical clock. Its timestamps follow the
+// wall clock, but never go backwards and always advance past every timestamp
+// the clock observed, even if the wall clocks of different servers are skewed.
+package hlc
+
+import (
+	"sync"
+	"time"
+)
+
+// Resolution is the smallest step of the clock. It matches the precision of
+// the timestamp columns of all supported databases.
+const Resolution = time.Microsecond
+
+type Clock struct {
+	mu   sync.Mutex
+	last time.Time
+	wall func() time.Time
+}
+
+func New() *Clock {
+	return NewWithWallClock(time.Now)
+}
+
+// NewWithWallClock creates a clock on top of the given wall clock, mainly
+// useful for tests.
+func NewWithWallClock(wall func() time.Time) *Clock {
+	return &Clock{wall: wall}
+}
+
+// Now returns a timestamp in UTC that is strictly after every timestamp
+// returned or observed before.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := c.wall().UTC().Truncate(Resolution)
+	if !t.After(c.last) {
+		t = c.last.Add(Resolution)
+	}
+	c.last = t
+	return t
+}
+
+// Observe advances the clock to the given timestamp, e.g. one written by
+// another server, if it is ahead.
+func (c *Clock) Observe(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t = t.UTC().Truncate(Resolution); t.After(c.last) {
+		c.last = t
+	}
+}
@@ -0,0 +1,48 @@
+package hlc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/keto/internal/x/hlc"
+)
+
+func TestClock(t *testing.T) {
+	wall := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	c := hlc.NewWithWallClock(func() time.Time { return wall })
+
+	t.Run("case=follows the wall clock", func(t *testing.T) {
+		assert.Equal(t, wall, c.Now())
+		wall = wall.Add(time.Second)
+		assert.Equal(t, wall, c.Now())
+	})
+
+	t.Run("case=is strictly monotonic if the wall clock stands still", func(t *testing.T) {
+		assert.Equal(t, wall.Add(hlc.Resolution), c.Now())
+		assert.Equal(t, wall.Add(2*hlc.Resolution), c.Now())
+	})
+
+	t.Run("case=does not go backwards with the wall clock", func(t *testing.T) {
+		last := c.Now()
+		wall = wall.Add(-time.Hour)
+		assert.Equal(t, last.Add(hlc.Resolution), c.Now())
+	})
+
+	t.Run("case=advances past observed timestamps", func(t *testing.T) {
+		remote := wall.Add(24 * time.Hour)
+		c.Observe(remote)
+		assert.Equal(t, remote.Add(hlc.Resolution), c.Now())
+
+		c.Observe(remote.Add(-time.Hour))
+		assert.Equal(t, remote.Add(2*hlc.Resolution), c.Now())
+	})
+
+	t.Run("case=returns UTC truncated to the resolution", func(t *testing.T) {
+		c := hlc.NewWithWallClock(func() time.Time {
+			return time.Date(2022, 10, 1, 14, 0, 0, 1500, time.FixedZone("CEST", 2*60*60))
+		})
+		assert.Equal(t, time.Date(2022, 10, 1, 12, 0, 0, 1000, time.UTC), c.Now())
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/ory/herodot"
+
+	"github.com/ory/keto/internal/errorcode"
 )
 
 func GetMaxDepthFromQuery(q url.Values) (int, error) {
@@ -14,7 +16,7 @@ func GetMaxDepthFromQuery(q url.Values) (int, error) {
 
 	maxDepth, err := strconv.ParseInt(q.Get("max-depth"), 0, 0)
 	if err != nil {
-		return 0, herodot.ErrBadRequest.WithErrorf("unable to parse 'max-depth' query parameter to int: %s", err)
+		return 0, herodot.ErrBadRequest.WithID(errorcode.MaxDepthInvalid).WithErrorf("unable to parse 'max-depth' query parameter to int: %s", err).WithDetail(errorcode.DetailField, "max-depth")
 	}
 
 	return int(maxDepth), err
@@ -1,11 +1,38 @@
 package x
 
+import (
+	"github.com/ory/herodot"
+
+	"github.com/ory/keto/internal/errorcode"
+)
+
 type (
 	PaginationOptions struct {
 		Token string `json:"page_token"`
 		Size  int    `json:"page_size"`
+		// OrderBy and Descending define the order of the results. Page tokens
+		// are only valid for the order they were returned for.
+		OrderBy    OrderBy `json:"-"`
+		Descending bool    `json:"-"`
 	}
 	PaginationOptionSetter func(*PaginationOptions) *PaginationOptions
+	// OrderBy is the field relation tuples are sorted by. Ties are broken in
+	// an unspecified but stable order.
+	OrderBy string
+)
+
+const (
+	// OrderByCommitTime sorts by the time the relation tuples were written.
+	// It is the default.
+	OrderByCommitTime OrderBy = "commit_time"
+	// OrderByObject sorts by namespace and object.
+	OrderByObject OrderBy = "object"
+	// OrderBySubject sorts subject IDs before subject sets. Subject sets are
+	// sorted by namespace, object, and relation.
+	OrderBySubject OrderBy = "subject"
+
+	OrderAscending  = "asc"
+	OrderDescending = "desc"
 )
 
 func WithToken(t string) PaginationOptionSetter {
@@ -22,10 +49,42 @@ func WithSize(size int) PaginationOptionSetter {
 	}
 }
 
+func WithOrder(by OrderBy, descending bool) PaginationOptionSetter {
+	return func(opts *PaginationOptions) *PaginationOptions {
+		opts.OrderBy = by
+		opts.Descending = descending
+		return opts
+	}
+}
+
 func GetPaginationOptions(modifiers ...PaginationOptionSetter) *PaginationOptions {
 	opts := &PaginationOptions{}
 	for _, f := range modifiers {
 		opts = f(opts)
 	}
+	if opts.OrderBy == "" {
+		opts.OrderBy = OrderByCommitTime
+	}
 	return opts
 }
+
+// ParseOrder parses the `order_by` and `order` request parameters. Empty
+// values select the default, oldest relation tuples first.
+func ParseOrder(orderBy, order string) (PaginationOptionSetter, error) {
+	by := OrderBy(orderBy)
+	switch by {
+	case "":
+		by = OrderByCommitTime
+	case OrderByCommitTime, OrderByObject, OrderBySubject:
+	default:
+		return nil, herodot.ErrBadRequest.WithID(errorcode.OrderInvalid).WithErrorf("unknown order_by %q, expected one of %q, %q, or %q", orderBy, OrderByCommitTime, OrderByObject, OrderBySubject).WithDetail(errorcode.DetailField, "order_by")
+	}
+
+	switch order {
+	case "", OrderAscending:
+		return WithOrder(by, false), nil
+	case OrderDescending:
+		return WithOrder(by, true), nil
+	}
+	return nil, herodot.ErrBadRequest.WithID(errorcode.OrderInvalid).WithErrorf("unknown order %q, expected %q or %q", order, OrderAscending, OrderDescending).WithDetail(errorcode.DetailField, "order")
+}
@@ -1,11 +1,8 @@
 package x
 
 import (
-	"context"
-
 	"github.com/ory/herodot"
 	"github.com/ory/x/logrusx"
-	"github.com/ory/x/otelx"
 )
 
 type LoggerProvider interface {
@@ -15,7 +12,3 @@ type LoggerProvider interface {
 type WriterProvider interface {
 	Writer() herodot.Writer
 }
-
-type TracingProvider interface {
-	Tracer(ctx context.Context) *otelx.Tracer
-}
@@ -1,6 +1,7 @@
 package ketoctx
 
 import (
+	"io/fs"
 	"net/http"
 
 	"github.com/ory/x/logrusx"
@@ -14,6 +15,7 @@ type (
 		httpMiddlewares        []func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc)
 		grpcUnaryInterceptors  []grpc.UnaryServerInterceptor
 		grpcStreamInterceptors []grpc.StreamServerInterceptor
+		migrations             []fs.FS
 	}
 	Option func(o *opts)
 )
@@ -48,6 +50,14 @@ func WithGRPCStreamInterceptors(i ...grpc.StreamServerInterceptor) Option {
 	}
 }
 
+// WithMigrations registers additional SQL migrations that are applied together
+// with Keto's own migrations, ordered by version.
+func WithMigrations(fss ...fs.FS) Option {
+	return func(o *opts) {
+		o.migrations = append(o.migrations, fss...)
+	}
+}
+
 func (o *opts) Logger() *logrusx.Logger {
 	return o.logger
 }
@@ -68,6 +78,10 @@ func (o *opts) GRPCStreamInterceptors() []grpc.StreamServerInterceptor {
 	return o.grpcStreamInterceptors
 }
 
+func (o *opts) Migrations() []fs.FS {
+	return o.migrations
+}
+
 func Options(options ...Option) *opts {
 	o := &opts{
 		contextualizer: &DefaultContextualizer{},
@@ -1,7 +1,9 @@
 package ketoctx
 
 import (
+	"io/fs"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,4 +22,11 @@ func TestOptions(t *testing.T) {
 		opts := Options(WithContextualizer(ctxer))
 		assert.Equal(t, ctxer, opts.Contextualizer())
 	})
+
+	t.Run("case=appends migrations", func(t *testing.T) {
+		a, b := fstest.MapFS{"a": {}}, fstest.MapFS{"b": {}}
+
+		opts := Options(WithMigrations(a), WithMigrations(b))
+		assert.Equal(t, []fs.FS{a, b}, opts.Migrations())
+	})
 }
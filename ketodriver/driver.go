@@ -0,0 +1,118 @@
+// Package ketodriver defines the interfaces a storage backend has to implement
+// to be used by Ory Keto, and the registry through which out-of-tree backends
+// make themselves known.
+//
+// A backend registers itself for one or more DSN schemes, typically in an init
+// function:
+//
+//	func init() {
+//		ketodriver.Register("fdb", &Driver{})
+//	}
+//
+// Keto uses the registered driver whenever the scheme of the configured DSN
+// matches, e.g. `dsn: fdb://cluster-file`. All other DSNs are handled by the
+// built-in SQL persister.
+package ketodriver
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/ory/x/logrusx"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/ketoctx"
+)
+
+type (
+	// Persister stores relation tuples. The semantics of the embedded Manager
+	// methods, including pagination and the errors returned for unknown
+	// namespaces, must match the built-in SQL persister.
+	Persister interface {
+		Manager
+
+		// NetworkID returns the network the data of the request belongs to.
+		NetworkID(ctx context.Context) uuid.UUID
+	}
+	// Migrator can optionally be implemented by a Persister that manages its
+	// own schema. It is called by `keto migrate up` and `keto migrate down`.
+	Migrator interface {
+		MigrateUp(ctx context.Context) error
+		MigrateDown(ctx context.Context) error
+	}
+	// Dependencies are passed to the driver when opening a Persister.
+	Dependencies interface {
+		Logger() *logrusx.Logger
+		ketoctx.ContextualizerProvider
+	}
+	// Driver opens a Persister for a DSN.
+	Driver interface {
+		Open(ctx context.Context, d Dependencies, dsn string) (Persister, error)
+	}
+)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+
+	// ErrUnknownScheme is returned by Open if no driver is registered for the
+	// scheme of the DSN.
+	ErrUnknownScheme = errors.New("no storage driver is registered for the DSN scheme")
+)
+
+// Register makes a driver available for the given DSN scheme. It panics if the
+// driver is nil or a driver is already registered for the scheme.
+func Register(scheme string, d Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if d == nil {
+		panic("ketodriver: Register driver is nil")
+	}
+	if _, dup := drivers[scheme]; dup {
+		panic("ketodriver: Register called twice for scheme " + scheme)
+	}
+	drivers[scheme] = d
+}
+
+// Lookup returns the driver registered for the scheme of the DSN.
+func Lookup(dsn string) (Driver, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	d, ok := drivers[Scheme(dsn)]
+	return d, ok
+}
+
+// Open opens a Persister using the driver registered for the scheme of the DSN.
+func Open(ctx context.Context, d Dependencies, dsn string) (Persister, error) {
+	drv, ok := Lookup(dsn)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownScheme, "scheme %q", Scheme(dsn))
+	}
+	return drv.Open(ctx, d, dsn)
+}
+
+// Schemes returns the sorted list of registered DSN schemes.
+func Schemes() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	schemes := make([]string, 0, len(drivers))
+	for s := range drivers {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Scheme returns the scheme of the DSN, i.e. everything before "://".
+func Scheme(dsn string) string {
+	if i := strings.Index(dsn, "://"); i > 0 {
+		return dsn[:i]
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package ketodriver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/x/dbx"
+	"github.com/ory/keto/ketodriver"
+)
+
+type (
+	fakeDriver struct {
+		p   ketodriver.Persister
+		dsn string
+	}
+	fakePersister struct {
+		ketodriver.Persister
+		migratedUp, migratedDown bool
+	}
+)
+
+func (d *fakeDriver) Open(_ context.Context, _ ketodriver.Dependencies, dsn string) (ketodriver.Persister, error) {
+	d.dsn = dsn
+	return d.p, nil
+}
+
+func (p *fakePersister) MigrateUp(context.Context) error {
+	p.migratedUp = true
+	return nil
+}
+
+func (p *fakePersister) MigrateDown(context.Context) error {
+	p.migratedDown = true
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("case=looks up by scheme", func(t *testing.T) {
+		d := &fakeDriver{}
+		ketodriver.Register("lookup", d)
+
+		actual, ok := ketodriver.Lookup("lookup://host/db")
+		require.True(t, ok)
+		assert.Same(t, d, actual)
+		assert.Contains(t, ketodriver.Schemes(), "lookup")
+
+		_, ok = ketodriver.Lookup("postgres://host/db")
+		assert.False(t, ok)
+		_, ok = ketodriver.Lookup("memory")
+		assert.False(t, ok)
+	})
+
+	t.Run("case=panics on duplicate scheme", func(t *testing.T) {
+		ketodriver.Register("duplicate", &fakeDriver{})
+		assert.Panics(t, func() { ketodriver.Register("duplicate", &fakeDriver{}) })
+	})
+
+	t.Run("case=panics on nil driver", func(t *testing.T) {
+		assert.Panics(t, func() { ketodriver.Register("nil", nil) })
+	})
+
+	t.Run("case=open fails for unknown scheme", func(t *testing.T) {
+		_, err := ketodriver.Open(context.Background(), nil, "unknown://host")
+		assert.ErrorIs(t, err, ketodriver.ErrUnknownScheme)
+	})
+}
+
+func TestRegistryUsesDriver(t *testing.T) {
+	ctx := context.Background()
+	nspace := &namespace.Namespace{Name: "driver", ID: 1}
+
+	backend := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, backend.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{nspace}))
+
+	p := &fakePersister{Persister: backend.Persister()}
+	d := &fakeDriver{p: p}
+	ketodriver.Register("fake", d)
+
+	reg := driver.NewTestRegistry(t, &dbx.DsnT{Name: "fake", Conn: "fake://backend", MigrateUp: true, MigrateDown: true})
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{nspace}))
+
+	assert.Equal(t, "fake://backend", d.dsn)
+	assert.True(t, p.migratedUp)
+	assert.Same(t, p, reg.Persister())
+
+	_, err := reg.MigrationBox(ctx)
+	assert.Error(t, err)
+
+	tuple := &ketodriver.RelationTuple{
+		Namespace: nspace.Name,
+		Object:    "o",
+		Relation:  "r",
+		Subject:   &ketodriver.SubjectID{ID: "s"},
+	}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+
+	actual, _, err := backend.RelationTupleManager().GetRelationTuples(ctx, &ketodriver.RelationQuery{Namespace: nspace.Name})
+	require.NoError(t, err)
+	assert.Equal(t, []*ketodriver.RelationTuple{tuple}, actual)
+
+	require.NoError(t, reg.MigrateDown(ctx))
+	assert.True(t, p.migratedDown)
+}
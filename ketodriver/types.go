@@ -0,0 +1,35 @@
+package ketodriver
+
+import (
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// The types below are aliases of Keto's internal types, so that out-of-tree
+// drivers can implement the Persister interface without importing internal
+// packages.
+type (
+	Manager                = relationtuple.Manager
+	RelationTuple          = relationtuple.InternalRelationTuple
+	RelationQuery          = relationtuple.RelationQuery
+	Subject                = relationtuple.Subject
+	SubjectID              = relationtuple.SubjectID
+	SubjectSet             = relationtuple.SubjectSet
+	PaginationOptions      = x.PaginationOptions
+	PaginationOptionSetter = x.PaginationOptionSetter
+	OrderBy                = x.OrderBy
+)
+
+const (
+	OrderByCommitTime = x.OrderByCommitTime
+	OrderByObject     = x.OrderByObject
+	OrderBySubject    = x.OrderBySubject
+)
+
+var (
+	// GetPaginationOptions applies the setters passed to GetRelationTuples.
+	GetPaginationOptions = x.GetPaginationOptions
+	WithToken            = x.WithToken
+	WithSize             = x.WithSize
+	WithOrder            = x.WithOrder
+)
@@ -0,0 +1,134 @@
+package ketooffline
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DeltaFormatVersion is the version of the delta format written by WriteDelta,
+// the Delta message of snapshot.proto.
+const DeltaFormatVersion = 1
+
+// Delta holds the changes to the relation tuples between two snapshot tokens,
+// so that a snapshot can be updated without downloading it again.
+type Delta struct {
+	Version   int
+	CreatedAt time.Time
+	// SinceToken is the snapshot token the delta applies to, and
+	// SnapshotToken the token of the snapshot after applying it.
+	SinceToken    string
+	SnapshotToken string
+	// Deleted are applied before Written, as tuples that were deleted and
+	// written again are in both.
+	Deleted []*RelationTuple
+	Written []*RelationTuple
+}
+
+// Apply updates the snapshot with the changes of the delta. The delta has to
+// start at the snapshot token of the snapshot, i.e. deltas are applied in the
+// order they were exported.
+func (s *Snapshot) Apply(d *Delta) error {
+	if s.SnapshotToken == "" || d.SinceToken != s.SnapshotToken {
+		return errors.Errorf("the delta applies to the snapshot token %q, but the snapshot is at %q", d.SinceToken, s.SnapshotToken)
+	}
+
+	removed := make(map[string]struct{}, len(d.Deleted)+len(d.Written))
+	for _, rt := range d.Deleted {
+		removed[rt.String()] = struct{}{}
+	}
+	// Written tuples replace their current version, e.g. with new labels.
+	for _, rt := range d.Written {
+		removed[rt.String()] = struct{}{}
+	}
+	kept := make([]*RelationTuple, 0, len(s.RelationTuples)+len(d.Written))
+	for _, rt := range s.RelationTuples {
+		if _, ok := removed[rt.String()]; !ok {
+			kept = append(kept, rt)
+		}
+	}
+
+	s.RelationTuples = append(kept, d.Written...)
+	s.SnapshotToken = d.SnapshotToken
+	return nil
+}
+
+// TokenTime returns the time of a snapshot token, i.e. the time of the latest
+// change the snapshot or delta includes. Tokens are the commit time followed by
+// the commit sequence, or only the commit time if they were issued by an older
+// version.
+func TokenTime(token string) (time.Time, error) {
+	t, _, _ := strings.Cut(token, "-")
+	nanos, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Errorf("malformed snapshot token %q", token)
+	}
+	return time.Unix(0, nanos).UTC(), nil
+}
+
+// Replay applies the deltas in order, up to the first one that includes
+// changes after the time, and returns how many it applied. Deltas are applied
+// as a whole, so the snapshot shows the state at the end of the last applied
+// delta: changes up to the time that were exported in a later delta are
+// missing. The snapshot itself has to be at or before the time.
+func (s *Snapshot) Replay(at time.Time, deltas ...*Delta) (int, error) {
+	if s.SnapshotToken == "" {
+		return 0, errors.New("the snapshot has no snapshot token, so its time is unknown")
+	}
+	taken, err := TokenTime(s.SnapshotToken)
+	if err != nil {
+		return 0, err
+	}
+	if taken.After(at) {
+		return 0, errors.Errorf("the snapshot is at %s, after %s", taken.Format(time.RFC3339Nano), at.Format(time.RFC3339Nano))
+	}
+
+	for i, d := range deltas {
+		until, err := TokenTime(d.SnapshotToken)
+		if err != nil {
+			return i, err
+		}
+		if until.After(at) {
+			return i, nil
+		}
+		if err := s.Apply(d); err != nil {
+			return i, err
+		}
+	}
+	return len(deltas), nil
+}
+
+// WriteDelta writes the delta as gzip-compressed protobuf.
+func WriteDelta(w io.Writer, d *Delta) error {
+	b, err := marshalDelta(d)
+	if err != nil {
+		return err
+	}
+	return writeCompressed(w, b)
+}
+
+// ReadDelta reads a delta written by WriteDelta.
+func ReadDelta(r io.Reader) (*Delta, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "not a gzip-compressed delta")
+	}
+	defer zr.Close()
+
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid delta")
+	}
+	d, err := unmarshalDelta(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid delta")
+	}
+	if d.Version != DeltaFormatVersion {
+		return nil, errors.Errorf("unsupported delta format version %d, expected %d", d.Version, DeltaFormatVersion)
+	}
+	return d, nil
+}
@@ -0,0 +1,125 @@
+package ketooffline_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/snapshot"
+	"github.com/ory/keto/ketooffline"
+)
+
+func TestDelta(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{{ID: 0, Name: "groups"}}))
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+	tuples := func(rts []*ketooffline.RelationTuple) []string {
+		ss := make([]string, len(rts))
+		for i, rt := range rts {
+			ss[i] = rt.String()
+		}
+		return ss
+	}
+
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple("groups:staff#member@alice"), tuple("groups:staff#member@bob")))
+	s, err := snapshot.Export(ctx, reg)
+	require.NoError(t, err)
+	base := s.SnapshotToken
+
+	require.NoError(t, reg.RelationTupleManager().TransactRelationTuples(ctx,
+		[]*relationtuple.InternalRelationTuple{tuple("groups:staff#member@carol")},
+		[]*relationtuple.InternalRelationTuple{tuple("groups:staff#member@bob")},
+	))
+	d, err := snapshot.ExportDelta(ctx, reg, base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"groups:staff#member@bob"}, tuples(d.Deleted))
+	assert.Equal(t, []string{"groups:staff#member@carol"}, tuples(d.Written))
+
+	var buf bytes.Buffer
+	require.NoError(t, ketooffline.WriteDelta(&buf, d))
+	t.Run("case=deltas are not snapshots", func(t *testing.T) {
+		_, err := ketooffline.Read(bytes.NewReader(buf.Bytes()))
+		assert.Error(t, err)
+	})
+	read, err := ketooffline.ReadDelta(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, d, read)
+
+	require.NoError(t, s.Apply(read))
+	assert.Equal(t, d.SnapshotToken, s.SnapshotToken)
+	assert.ElementsMatch(t, []string{"groups:staff#member@alice", "groups:staff#member@carol"}, tuples(s.RelationTuples))
+
+	t.Run("case=rejects deltas of other tokens", func(t *testing.T) {
+		assert.Error(t, s.Apply(read))
+	})
+
+	t.Run("case=empty delta keeps the token", func(t *testing.T) {
+		empty, err := snapshot.ExportDelta(ctx, reg, s.SnapshotToken)
+		require.NoError(t, err)
+		assert.Empty(t, empty.Deleted)
+		assert.Empty(t, empty.Written)
+		assert.Equal(t, s.SnapshotToken, empty.SnapshotToken)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+	snapshot := func() *ketooffline.Snapshot {
+		return &ketooffline.Snapshot{SnapshotToken: "100", RelationTuples: []*ketooffline.RelationTuple{tuple("groups:staff#member@alice")}}
+	}
+	deltas := []*ketooffline.Delta{
+		{SinceToken: "100", SnapshotToken: "200", Written: []*ketooffline.RelationTuple{tuple("groups:staff#member@bob")}},
+		{SinceToken: "200", SnapshotToken: "300", Deleted: []*ketooffline.RelationTuple{tuple("groups:staff#member@alice")}},
+	}
+	tuples := func(s *ketooffline.Snapshot) []string {
+		ss := make([]string, len(s.RelationTuples))
+		for i, rt := range s.RelationTuples {
+			ss[i] = rt.String()
+		}
+		return ss
+	}
+
+	t.Run("case=stops at the time", func(t *testing.T) {
+		s := snapshot()
+		n, err := s.Replay(time.Unix(0, 250), deltas...)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, "200", s.SnapshotToken)
+		assert.ElementsMatch(t, []string{"groups:staff#member@alice", "groups:staff#member@bob"}, tuples(s))
+	})
+
+	t.Run("case=applies deltas ending at the time", func(t *testing.T) {
+		s := snapshot()
+		n, err := s.Replay(time.Unix(0, 300), deltas...)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{"groups:staff#member@bob"}, tuples(s))
+	})
+
+	t.Run("case=rejects snapshots after the time", func(t *testing.T) {
+		_, err := snapshot().Replay(time.Unix(0, 50), deltas...)
+		assert.Error(t, err)
+	})
+
+	t.Run("case=rejects snapshots without token", func(t *testing.T) {
+		_, err := (&ketooffline.Snapshot{}).Replay(time.Now(), deltas...)
+		assert.Error(t, err)
+	})
+}
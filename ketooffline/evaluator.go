@@ -0,0 +1,88 @@
+package ketooffline
+
+import (
+	"context"
+	"io"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/keto/internal/check/evaluator"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// MaxDepth is the max depth of checks, the default of the server's
+// limit.max_read_depth.
+const MaxDepth = 5
+
+// Evaluator evaluates checks against a snapshot. It is safe for concurrent
+// use.
+type Evaluator struct {
+	e *evaluator.Evaluator
+}
+
+// dependencies provides the evaluator with the snapshot instead of the config
+// and a database. The object filter and feature flags of the server only
+// speed up checks, so they are not needed.
+type dependencies struct {
+	l *logrusx.Logger
+	s *store
+}
+
+var _ evaluator.Dependencies = (*dependencies)(nil)
+
+func (d *dependencies) Logger() *logrusx.Logger                            { return d.l }
+func (d *dependencies) Manager(context.Context) relationtuple.Manager      { return d.s }
+func (d *dependencies) MayHaveTuples(context.Context, string, string) bool { return true }
+func (d *dependencies) DirectMatchFirst(context.Context, string) bool      { return false }
+func (d *dependencies) ObjectsGetter(context.Context) (relationtuple.ObjectsGetter, bool) {
+	return d.s, true
+}
+
+func (d *dependencies) NamespaceOptions(_ context.Context, name string) (*namespace.Options, error) {
+	if opts, ok := d.s.options[name]; ok {
+		return opts, nil
+	}
+	return &namespace.Options{}, nil
+}
+
+// Load reads the snapshot and returns an Evaluator for it.
+func Load(ctx context.Context, r io.Reader) (*Evaluator, error) {
+	s, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return New(ctx, s)
+}
+
+// New returns an Evaluator for the snapshot. The snapshot must not be modified
+// afterwards.
+func New(ctx context.Context, s *Snapshot) (*Evaluator, error) {
+	if err := namespace.Validate(s.Namespaces); err != nil {
+		return nil, err
+	}
+	m := namespaces(s.Namespaces)
+	options := make(map[string]*namespace.Options, len(s.Namespaces))
+	for _, n := range s.Namespaces {
+		opts, err := namespace.ResolvedOptions(ctx, m, n)
+		if err != nil {
+			return nil, err
+		}
+		options[n.Name] = opts
+	}
+
+	d := &dependencies{
+		l: logrusx.New("Ory Keto", "offline"),
+		s: newStore(options, s.RelationTuples),
+	}
+	return &Evaluator{e: evaluator.New(d)}, nil
+}
+
+// Check returns whether the subject of the relation tuple has the relation on
+// the object. The max depth is capped at MaxDepth; values below 1 select it.
+func (e *Evaluator) Check(ctx context.Context, r *RelationTuple, maxDepth int) (bool, error) {
+	if maxDepth <= 0 || maxDepth > MaxDepth {
+		maxDepth = MaxDepth
+	}
+	return e.e.Evaluate(ctx, r, maxDepth)
+}
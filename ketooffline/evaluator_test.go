@@ -0,0 +1,82 @@
+package ketooffline_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/snapshot"
+	"github.com/ory/keto/ketooffline"
+)
+
+func TestEvaluator(t *testing.T) {
+	ctx := context.Background()
+	reg := driver.NewSqliteTestRegistry(t, false)
+	require.NoError(t, reg.Config(ctx).Set(config.KeyNamespaces, []*namespace.Namespace{
+		{ID: 0, Name: "document", Config: json.RawMessage(`{"case_insensitive": true, "deny": {"viewer": ["blocked"]}}`)},
+		{ID: 1, Name: "files", Config: json.RawMessage(`{"extends": "document", "hierarchy": {"relations": ["viewer"]}}`)},
+		{ID: 2, Name: "groups"},
+	}))
+	tuple := func(s string) *relationtuple.InternalRelationTuple {
+		rt, err := (&relationtuple.InternalRelationTuple{}).FromString(s)
+		require.NoError(t, err)
+		return rt
+	}
+	require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx,
+		tuple("files:drive#viewer@groups:staff#member"),
+		tuple("files:drive/report#blocked@mallory"),
+		tuple("groups:staff#member@Alice"),
+		tuple("groups:staff#member@mallory"),
+	))
+
+	require.NoError(t, reg.Config(ctx).Set(config.KeyLimitMaxPageSize, 2))
+	s, err := snapshot.Export(ctx, reg)
+	require.NoError(t, err)
+	assert.Len(t, s.RelationTuples, 4)
+	assert.JSONEq(t, `{"case_insensitive": true, "deny": {"viewer": ["blocked"]}, "hierarchy": {"relations": ["viewer"]}}`, string(s.Namespaces[1].Config))
+
+	var buf bytes.Buffer
+	require.NoError(t, ketooffline.Write(&buf, s))
+	e, err := ketooffline.Load(ctx, &buf)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		rt       string
+		expected bool
+	}{
+		{rt: "files:drive/report#viewer@Alice", expected: true},
+		{rt: "files:DRIVE/Report#viewer@Alice", expected: true},
+		{rt: "files:drive/report#viewer@mallory", expected: false},
+		{rt: "files:drive/other#viewer@mallory", expected: true},
+		{rt: "groups:staff#member@bob", expected: false},
+		{rt: "unknown:object#relation@alice", expected: false},
+	} {
+		t.Run("case="+tc.rt, func(t *testing.T) {
+			online, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple(tc.rt), 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, online, "the case does not match the server")
+
+			allowed, err := e.Check(ctx, tuple(tc.rt), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+
+	t.Run("case=rejects other formats", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ketooffline.Write(&buf, &ketooffline.Snapshot{Version: ketooffline.FormatVersion + 1}))
+		_, err := ketooffline.Load(ctx, &buf)
+		assert.Error(t, err)
+
+		_, err = ketooffline.Load(ctx, bytes.NewBufferString("not gzip"))
+		assert.Error(t, err)
+	})
+}
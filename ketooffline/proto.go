@@ -0,0 +1,222 @@
+package ketooffline
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ory/keto/internal/relationtuple"
+	rts "github.com/ory/keto/proto/ory/keto/relation_tuples/v1alpha2"
+)
+
+// The field numbers of the messages in snapshot.proto.
+const (
+	fieldVersion        protowire.Number = 1
+	fieldCreatedAt      protowire.Number = 2
+	fieldSnapshotToken  protowire.Number = 3
+	fieldNamespaces     protowire.Number = 4
+	fieldRelationTuples protowire.Number = 5
+
+	fieldDeltaVersion       protowire.Number = 15
+	fieldDeltaCreatedAt     protowire.Number = 2
+	fieldDeltaSinceToken    protowire.Number = 3
+	fieldDeltaSnapshotToken protowire.Number = 4
+	fieldDeltaDeleted       protowire.Number = 5
+	fieldDeltaWritten       protowire.Number = 6
+
+	fieldTimestampSeconds protowire.Number = 1
+	fieldTimestampNanos   protowire.Number = 2
+
+	fieldNamespaceID     protowire.Number = 1
+	fieldNamespaceName   protowire.Number = 2
+	fieldNamespaceConfig protowire.Number = 3
+)
+
+func marshalSnapshot(s *Snapshot) ([]byte, error) {
+	var b []byte
+	b = protowire.AppendTag(b, fieldVersion, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(s.Version))
+	b = appendTimestamp(b, fieldCreatedAt, s.CreatedAt)
+
+	if s.SnapshotToken != "" {
+		b = protowire.AppendTag(b, fieldSnapshotToken, protowire.BytesType)
+		b = protowire.AppendString(b, s.SnapshotToken)
+	}
+
+	for _, n := range s.Namespaces {
+		var nb []byte
+		nb = protowire.AppendTag(nb, fieldNamespaceID, protowire.VarintType)
+		nb = protowire.AppendVarint(nb, uint64(n.ID))
+		nb = protowire.AppendTag(nb, fieldNamespaceName, protowire.BytesType)
+		nb = protowire.AppendString(nb, n.Name)
+		if len(n.Config) > 0 {
+			nb = protowire.AppendTag(nb, fieldNamespaceConfig, protowire.BytesType)
+			nb = protowire.AppendBytes(nb, n.Config)
+		}
+		b = protowire.AppendTag(b, fieldNamespaces, protowire.BytesType)
+		b = protowire.AppendBytes(b, nb)
+	}
+
+	return appendRelationTuples(b, fieldRelationTuples, s.RelationTuples)
+}
+
+func unmarshalSnapshot(b []byte) (*Snapshot, error) {
+	s := &Snapshot{RelationTuples: []*RelationTuple{}}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) (err error) {
+		switch {
+		case num == fieldVersion && typ == protowire.VarintType:
+			s.Version = int(x)
+		case num == fieldCreatedAt && typ == protowire.BytesType:
+			s.CreatedAt, err = consumeTimestamp(v)
+		case num == fieldSnapshotToken && typ == protowire.BytesType:
+			s.SnapshotToken = string(v)
+		case num == fieldNamespaces && typ == protowire.BytesType:
+			n := &Namespace{}
+			if err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+				switch {
+				case num == fieldNamespaceID && typ == protowire.VarintType:
+					n.ID = int32(x)
+				case num == fieldNamespaceName && typ == protowire.BytesType:
+					n.Name = string(v)
+				case num == fieldNamespaceConfig && typ == protowire.BytesType:
+					n.Config = append([]byte(nil), v...)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			s.Namespaces = append(s.Namespaces, n)
+		case num == fieldRelationTuples && typ == protowire.BytesType:
+			s.RelationTuples, err = appendConsumedRelationTuple(s.RelationTuples, v)
+		}
+		return err
+	})
+	return s, err
+}
+
+func marshalDelta(d *Delta) ([]byte, error) {
+	var b []byte
+	b = protowire.AppendTag(b, fieldDeltaVersion, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(d.Version))
+	b = appendTimestamp(b, fieldDeltaCreatedAt, d.CreatedAt)
+	b = protowire.AppendTag(b, fieldDeltaSinceToken, protowire.BytesType)
+	b = protowire.AppendString(b, d.SinceToken)
+	b = protowire.AppendTag(b, fieldDeltaSnapshotToken, protowire.BytesType)
+	b = protowire.AppendString(b, d.SnapshotToken)
+
+	b, err := appendRelationTuples(b, fieldDeltaDeleted, d.Deleted)
+	if err != nil {
+		return nil, err
+	}
+	return appendRelationTuples(b, fieldDeltaWritten, d.Written)
+}
+
+func unmarshalDelta(b []byte) (*Delta, error) {
+	d := &Delta{Deleted: []*RelationTuple{}, Written: []*RelationTuple{}}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) (err error) {
+		switch {
+		case num == fieldDeltaVersion && typ == protowire.VarintType:
+			d.Version = int(x)
+		case num == fieldDeltaCreatedAt && typ == protowire.BytesType:
+			d.CreatedAt, err = consumeTimestamp(v)
+		case num == fieldDeltaSinceToken && typ == protowire.BytesType:
+			d.SinceToken = string(v)
+		case num == fieldDeltaSnapshotToken && typ == protowire.BytesType:
+			d.SnapshotToken = string(v)
+		case num == fieldDeltaDeleted && typ == protowire.BytesType:
+			d.Deleted, err = appendConsumedRelationTuple(d.Deleted, v)
+		case num == fieldDeltaWritten && typ == protowire.BytesType:
+			d.Written, err = appendConsumedRelationTuple(d.Written, v)
+		}
+		return err
+	})
+	return d, err
+}
+
+// appendTimestamp appends the time as a google.protobuf.Timestamp field.
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	var ts []byte
+	ts = protowire.AppendTag(ts, fieldTimestampSeconds, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, uint64(t.Unix()))
+	ts = protowire.AppendTag(ts, fieldTimestampNanos, protowire.VarintType)
+	ts = protowire.AppendVarint(ts, uint64(t.Nanosecond()))
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, ts)
+}
+
+func consumeTimestamp(v []byte) (time.Time, error) {
+	var sec, nsec uint64
+	if err := consumeFields(v, func(num protowire.Number, typ protowire.Type, _ []byte, x uint64) error {
+		if typ == protowire.VarintType && num == fieldTimestampSeconds {
+			sec = x
+		} else if typ == protowire.VarintType && num == fieldTimestampNanos {
+			nsec = x
+		}
+		return nil
+	}); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(sec), int64(nsec)).UTC(), nil
+}
+
+// appendRelationTuples appends the tuples as repeated
+// ory.keto.relation_tuples.v1alpha2.RelationTuple fields.
+func appendRelationTuples(b []byte, num protowire.Number, rr []*RelationTuple) ([]byte, error) {
+	for _, rt := range rr {
+		rb, err := proto.MarshalOptions{Deterministic: true}.Marshal(rt.ToProto())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, rb)
+	}
+	return b, nil
+}
+
+func appendConsumedRelationTuple(rr []*RelationTuple, v []byte) ([]*RelationTuple, error) {
+	var pt rts.RelationTuple
+	if err := proto.Unmarshal(v, &pt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rt, err := (&relationtuple.InternalRelationTuple{}).FromDataProvider(&pt)
+	if err != nil {
+		return nil, err
+	}
+	return append(rr, rt), nil
+}
+
+// consumeFields calls f for every field of the message, with the value of
+// length-delimited fields or of varints. Fields of other types are skipped.
+func consumeFields(b []byte, f func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errors.WithStack(protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var (
+			v []byte
+			x uint64
+		)
+		switch typ {
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errors.WithStack(protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if err := f(num, typ, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
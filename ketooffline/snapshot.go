@@ -0,0 +1,123 @@
+// Package ketooffline evaluates checks against an exported snapshot of the
+// relation tuples and namespaces, without a connection to Ory Keto, e.g. on
+// edge devices or in batch jobs.
+//
+// Snapshots are exported with `keto offline export`:
+//
+//	f, err := os.Open("snapshot.pb.gz")
+//	// handle err
+//	e, err := ketooffline.Load(ctx, f)
+//	// handle err
+//	allowed, err := e.Check(ctx, &ketooffline.RelationTuple{
+//		Namespace: "files",
+//		Object:    "report.pdf",
+//		Relation:  "view",
+//		Subject:   &ketooffline.SubjectID{ID: "alice"},
+//	}, 0)
+//
+// Checks are evaluated like on the server, including the options in the
+// namespace configs. The snapshot is not updated, so results get stale as
+// relation tuples are written to Keto. To catch up, export a delta since the
+// snapshot token with `keto offline export --since`, apply it with
+// Snapshot.Apply, and create a new Evaluator from the snapshot.
+package ketooffline
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+)
+
+// The types below are aliases of Keto's internal types, so that the package
+// can be used without importing internal packages.
+type (
+	Namespace     = namespace.Namespace
+	RelationTuple = relationtuple.InternalRelationTuple
+	Subject       = relationtuple.Subject
+	SubjectID     = relationtuple.SubjectID
+	SubjectSet    = relationtuple.SubjectSet
+)
+
+const (
+	// FormatVersion is the version of the snapshot format written by Write,
+	// the Snapshot message of snapshot.proto.
+	FormatVersion = 2
+	// jsonFormatVersion is the version of the JSON snapshots written before.
+	// Read still accepts them.
+	jsonFormatVersion = 1
+)
+
+// Snapshot holds all relation tuples and namespaces of a network at one point
+// in time. The configs of the namespaces are compiled, i.e. they include the
+// options inherited from the namespaces they extend.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// SnapshotToken is the token of the snapshot the relation tuples were
+	// read at, if the database supports reading snapshots.
+	SnapshotToken  string           `json:"snapshot_token,omitempty"`
+	Namespaces     []*Namespace     `json:"namespaces"`
+	RelationTuples []*RelationTuple `json:"relation_tuples"`
+}
+
+// Write writes the snapshot as gzip-compressed protobuf.
+func Write(w io.Writer, s *Snapshot) error {
+	b, err := marshalSnapshot(s)
+	if err != nil {
+		return err
+	}
+	return writeCompressed(w, b)
+}
+
+func writeCompressed(w io.Writer, b []byte) error {
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(zw.Close())
+}
+
+// Read reads a snapshot written by Write, or a JSON snapshot of format
+// version 1.
+func Read(r io.Reader) (*Snapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "not a gzip-compressed snapshot")
+	}
+	defer zr.Close()
+
+	// A protobuf snapshot starts with the tag of the version, which is
+	// never an opening brace.
+	br := bufio.NewReader(zr)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid snapshot")
+	}
+
+	var s *Snapshot
+	expected := FormatVersion
+	if first[0] == '{' {
+		expected = jsonFormatVersion
+		s = &Snapshot{}
+		err = errors.WithStack(json.NewDecoder(br).Decode(s))
+	} else {
+		var b []byte
+		if b, err = io.ReadAll(br); err == nil {
+			s, err = unmarshalSnapshot(b)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid snapshot")
+	}
+	if s.Version != expected {
+		return nil, errors.Errorf("unsupported snapshot format version %d, expected %d", s.Version, FormatVersion)
+	}
+	return s, nil
+}
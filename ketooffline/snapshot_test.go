@@ -0,0 +1,52 @@
+package ketooffline_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/ketooffline"
+)
+
+func TestReadWrite(t *testing.T) {
+	t.Run("case=round trip", func(t *testing.T) {
+		s := &ketooffline.Snapshot{
+			Version:       ketooffline.FormatVersion,
+			CreatedAt:     time.Date(2022, 10, 1, 12, 30, 0, 42, time.UTC),
+			SnapshotToken: "token",
+			Namespaces: []*ketooffline.Namespace{
+				{ID: 0, Name: "files", Config: json.RawMessage(`{"case_insensitive":true}`)},
+				{ID: 3, Name: "groups"},
+			},
+			RelationTuples: []*ketooffline.RelationTuple{
+				{Namespace: "files", Object: "report", Relation: "view", Subject: &ketooffline.SubjectSet{Namespace: "groups", Object: "staff", Relation: "member"}},
+				{Namespace: "groups", Object: "staff", Relation: "member", Subject: &ketooffline.SubjectID{ID: "alice"}, Labels: map[string]string{"source": "hr"}},
+			},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, ketooffline.Write(&buf, s))
+		actual, err := ketooffline.Read(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, s, actual)
+	})
+
+	t.Run("case=reads JSON snapshots of version 1", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err := zw.Write([]byte(`{"version": 1, "created_at": "2022-10-01T12:30:00Z", "namespaces": [{"id": 1, "name": "groups"}], "relation_tuples": [{"namespace": "groups", "object": "staff", "relation": "member", "subject_id": "alice"}]}`))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		s, err := ketooffline.Read(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, []*ketooffline.Namespace{{ID: 1, Name: "groups"}}, s.Namespaces)
+		require.Len(t, s.RelationTuples, 1)
+		assert.Equal(t, "groups:staff#member@alice", s.RelationTuples[0].String())
+	})
+}
@@ -0,0 +1,181 @@
+package ketooffline
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/internal/errorcode"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/relationtuple"
+	"github.com/ory/keto/internal/x"
+)
+
+// store serves the relation tuples of a snapshot from memory. It is
+// read-only.
+type store struct {
+	options map[string]*namespace.Options
+	// byObject indexes the relation tuples by namespace, object, and
+	// relation, the query checks make.
+	byObject map[[3]string][]*relationtuple.InternalRelationTuple
+	all      []*relationtuple.InternalRelationTuple
+}
+
+var errReadOnly = herodot.ErrBadRequest.WithID(errorcode.UnsupportedByStorage).WithError("offline snapshots are read-only")
+
+func newStore(options map[string]*namespace.Options, rts []*relationtuple.InternalRelationTuple) *store {
+	s := &store{
+		options:  options,
+		byObject: make(map[[3]string][]*relationtuple.InternalRelationTuple),
+		all:      rts,
+	}
+	for _, rt := range rts {
+		k := [3]string{rt.Namespace, rt.Object, rt.Relation}
+		s.byObject[k] = append(s.byObject[k], rt)
+	}
+	return s
+}
+
+func (s *store) GetRelationTuples(_ context.Context, query *relationtuple.RelationQuery, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	query = s.normalize(query)
+	candidates := s.all
+	if query.Namespace != "" && query.Object != "" && query.Relation != "" {
+		candidates = s.byObject[[3]string{query.Namespace, query.Object, query.Relation}]
+	}
+
+	var res []*relationtuple.InternalRelationTuple
+	for _, rt := range candidates {
+		if matches(query, rt) {
+			res = append(res, rt)
+		}
+	}
+	return paginate(res, x.GetPaginationOptions(options...))
+}
+
+func (s *store) GetRelationTuplesOfObjects(ctx context.Context, query *relationtuple.RelationQuery, objects []string, options ...x.PaginationOptionSetter) ([]*relationtuple.InternalRelationTuple, string, error) {
+	var res []*relationtuple.InternalRelationTuple
+	for _, o := range objects {
+		q := *query
+		q.Object = o
+		rts, _, err := s.GetRelationTuples(ctx, &q)
+		if err != nil {
+			return nil, "", err
+		}
+		res = append(res, rts...)
+	}
+	return paginate(res, x.GetPaginationOptions(options...))
+}
+
+// normalize converts the identifiers of the query like the SQL persister
+// does, as the snapshot holds them in the stored form.
+func (s *store) normalize(query *relationtuple.RelationQuery) *relationtuple.RelationQuery {
+	opts, ok := s.options[query.Namespace]
+	if !ok {
+		return query
+	}
+	q := *query
+	q.Object = opts.Normalize(q.Object)
+	if q.SubjectID != nil {
+		id := opts.Normalize(*q.SubjectID)
+		q.SubjectID = &id
+	}
+	if q.SubjectSet != nil {
+		set := *q.SubjectSet
+		if setOpts, ok := s.options[set.Namespace]; ok {
+			set.Object = setOpts.Normalize(set.Object)
+		}
+		q.SubjectSet = &set
+	}
+	return &q
+}
+
+func matches(query *relationtuple.RelationQuery, rt *relationtuple.InternalRelationTuple) bool {
+	switch {
+	case query.Namespace != "" && query.Namespace != rt.Namespace,
+		query.Object != "" && query.Object != rt.Object,
+		query.Relation != "" && query.Relation != rt.Relation,
+		query.SubjectID != nil && !rt.Subject.Equals(&relationtuple.SubjectID{ID: *query.SubjectID}),
+		query.SubjectSet != nil && !rt.Subject.Equals(query.SubjectSet):
+		return false
+	}
+	for k, v := range query.Labels {
+		if rt.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// paginate returns the page of the results. Page tokens are offsets.
+func paginate(res []*relationtuple.InternalRelationTuple, opts *x.PaginationOptions) ([]*relationtuple.InternalRelationTuple, string, error) {
+	offset := 0
+	if opts.Token != "" {
+		var err error
+		if offset, err = strconv.Atoi(opts.Token); err != nil || offset < 0 || offset > len(res) {
+			return nil, "", errors.WithStack(herodot.ErrBadRequest.WithError("invalid page token").WithDetail(errorcode.DetailField, "page_token"))
+		}
+	}
+	end := len(res)
+	if opts.Size > 0 && offset+opts.Size < end {
+		end = offset + opts.Size
+	}
+	var next string
+	if end < len(res) {
+		next = strconv.Itoa(end)
+	}
+	return res[offset:end], next, nil
+}
+
+func (s *store) WriteRelationTuples(context.Context, ...*relationtuple.InternalRelationTuple) error {
+	return errors.WithStack(errReadOnly)
+}
+
+func (s *store) DeleteRelationTuples(context.Context, ...*relationtuple.InternalRelationTuple) error {
+	return errors.WithStack(errReadOnly)
+}
+
+func (s *store) DeleteAllRelationTuples(context.Context, *relationtuple.RelationQuery) error {
+	return errors.WithStack(errReadOnly)
+}
+
+func (s *store) TransactRelationTuples(context.Context, []*relationtuple.InternalRelationTuple, []*relationtuple.InternalRelationTuple) error {
+	return errors.WithStack(errReadOnly)
+}
+
+// namespaces serves the namespaces of a snapshot, to resolve the options they
+// inherit.
+type namespaces []*namespace.Namespace
+
+func (nn namespaces) GetNamespaceByName(_ context.Context, name string) (*namespace.Namespace, error) {
+	for _, n := range nn {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithReasonf("Unknown namespace with name %q.", name))
+}
+
+func (nn namespaces) GetNamespaceByConfigID(_ context.Context, id int32) (*namespace.Namespace, error) {
+	for _, n := range nn {
+		if n.ID == id {
+			return n, nil
+		}
+	}
+	return nil, errors.WithStack(herodot.ErrNotFound.WithID(errorcode.NamespaceUnknown).WithReasonf("Unknown namespace with id %d.", id))
+}
+
+func (nn namespaces) Namespaces(context.Context) ([]*namespace.Namespace, error) {
+	return nn, nil
+}
+
+func (nn namespaces) ShouldReload(interface{}) bool {
+	return false
+}
+
+var (
+	_ relationtuple.Manager       = (*store)(nil)
+	_ relationtuple.ObjectsGetter = (*store)(nil)
+	_ namespace.Manager           = namespaces(nil)
+)
@@ -0,0 +1,105 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command wasm exposes the offline check evaluation of package ketooffline to
+// JavaScript, so that web apps can preview permission outcomes without a
+// server, e.g. while editing namespaces and relation tuples. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o keto.wasm ./ketooffline/wasm
+//
+// and run it with Go's wasm_exec.js:
+//
+//	const go = new Go()
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("keto.wasm"), go.importObject)
+//	go.run(instance)
+//
+//	const e = ketoOffline.fromJSON(JSON.stringify({
+//	  namespaces: [{ name: "files" }],
+//	  relation_tuples: [{ namespace: "files", object: "report.pdf", relation: "view", subject_id: "alice" }],
+//	}))
+//	e.check("files:report.pdf#view@alice") // { allowed: true }
+//
+// ketoOffline.load takes the bytes of a snapshot exported with
+// `keto offline export` instead. Failures are returned as { error: "..." }.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/keto/ketooffline"
+)
+
+func result(v map[string]interface{}, err error) interface{} {
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	return v
+}
+
+// evaluator wraps the Evaluator in a JavaScript object with a check method.
+func evaluator(e *ketooffline.Evaluator) interface{} {
+	return map[string]interface{}{
+		"check": js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+			if len(args) < 1 || args[0].Type() != js.TypeString {
+				return result(nil, errors.New("check expects a relation tuple like \"files:report.pdf#view@alice\""))
+			}
+			tuple, err := (&ketooffline.RelationTuple{}).FromString(args[0].String())
+			if err != nil {
+				return result(nil, err)
+			}
+			maxDepth := 0
+			if len(args) > 1 && args[1].Type() == js.TypeNumber {
+				maxDepth = args[1].Int()
+			}
+			allowed, err := e.Check(context.Background(), tuple, maxDepth)
+			return result(map[string]interface{}{"allowed": allowed}, err)
+		}),
+	}
+}
+
+// load reads a snapshot exported with `keto offline export` from a
+// Uint8Array.
+func load(_ js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return result(nil, errors.New("load expects the snapshot as Uint8Array"))
+	}
+	b := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(b, args[0])
+	e, err := ketooffline.Load(context.Background(), bytes.NewReader(b))
+	if err != nil {
+		return result(nil, err)
+	}
+	return evaluator(e)
+}
+
+// fromJSON reads the namespaces and relation tuples from uncompressed JSON in
+// the format of snapshots, where the version may be omitted.
+func fromJSON(_ js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return result(nil, errors.New("fromJSON expects a JSON string"))
+	}
+	var s ketooffline.Snapshot
+	if err := json.Unmarshal([]byte(args[0].String()), &s); err != nil {
+		return result(nil, errors.WithStack(err))
+	}
+	e, err := ketooffline.New(context.Background(), &s)
+	if err != nil {
+		return result(nil, err)
+	}
+	return evaluator(e)
+}
+
+func main() {
+	js.Global().Set("ketoOffline", map[string]interface{}{
+		"load":     js.FuncOf(load),
+		"fromJSON": js.FuncOf(fromJSON),
+	})
+	// The functions are called from JavaScript after main returned
+	// otherwise.
+	select {}
+}
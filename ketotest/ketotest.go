@@ -0,0 +1,99 @@
+// Package ketotest helps to write integration tests against Ory Keto backed by
+// any of the supported databases, without copying Keto's CI setup.
+//
+// Every call gets a fresh, migrated database. SQLite requires the `sqlite`
+// build tag. The other dialects are started as Docker containers, unless the
+// environment variables TEST_DATABASE_POSTGRESQL, TEST_DATABASE_MYSQL, or
+// TEST_DATABASE_COCKROACHDB point to a running server. They are skipped in
+// short mode.
+//
+//	func TestMyService(t *testing.T) {
+//		for _, d := range ketotest.Dialects {
+//			d := d
+//			t.Run("dialect="+string(d), func(t *testing.T) {
+//				t.Parallel()
+//				reg := ketotest.NewRegistry(t, d, &ketotest.Namespace{Name: "files"})
+//				// use reg.RelationTupleManager(), reg.PermissionEngine(), ...
+//			})
+//		}
+//	}
+package ketotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/internal/driver"
+	"github.com/ory/keto/internal/driver/config"
+	"github.com/ory/keto/internal/namespace"
+	"github.com/ory/keto/internal/persistence"
+	"github.com/ory/keto/internal/x/dbx"
+)
+
+type (
+	// Registry gives access to all services of a Keto instance.
+	Registry = driver.Registry
+	// Persister is the storage of a Keto instance.
+	Persister = persistence.Persister
+	Namespace = namespace.Namespace
+	Dialect   string
+)
+
+const (
+	SQLite      Dialect = "sqlite"
+	PostgreSQL  Dialect = "postgres"
+	MySQL       Dialect = "mysql"
+	CockroachDB Dialect = "cockroach"
+)
+
+// Dialects are all databases supported by Keto.
+var Dialects = []Dialect{SQLite, PostgreSQL, MySQL, CockroachDB}
+
+// DSN returns the DSN of a new, empty database of the dialect. The database is
+// dropped when the test finishes.
+func DSN(t testing.TB, d Dialect) string {
+	t.Helper()
+
+	switch d {
+	case SQLite:
+		return dbx.GetDSN(t, "memory").Conn
+	case PostgreSQL, MySQL, CockroachDB:
+	default:
+		t.Fatalf("Unknown dialect %q, expected one of %v.", d, Dialects)
+	}
+	if testing.Short() {
+		t.Skipf("Skipping %s in short mode.", d)
+	}
+
+	dsn := dbx.GetDSN(t, string(d))
+	if dsn == nil {
+		t.Skipf("Support for %s was disabled by a build tag.", d)
+	}
+	return dsn.Conn
+}
+
+// NewRegistry returns a registry on a new, migrated database of the dialect,
+// configured with the namespaces.
+func NewRegistry(t *testing.T, d Dialect, namespaces ...*Namespace) Registry {
+	t.Helper()
+
+	reg := driver.NewTestRegistry(t, &dbx.DsnT{
+		Name:        string(d),
+		Conn:        DSN(t, d),
+		MigrateUp:   true,
+		MigrateDown: d != SQLite,
+	})
+	if namespaces == nil {
+		namespaces = []*Namespace{}
+	}
+	require.NoError(t, reg.Config(context.Background()).Set(config.KeyNamespaces, namespaces))
+	return reg
+}
+
+// NewPersister is like NewRegistry, but only returns the persister.
+func NewPersister(t *testing.T, d Dialect, namespaces ...*Namespace) Persister {
+	t.Helper()
+	return NewRegistry(t, d, namespaces...).Persister()
+}
@@ -0,0 +1,42 @@
+package ketotest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/keto/ketodriver"
+	"github.com/ory/keto/ketotest"
+)
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range ketotest.Dialects {
+		d := d
+		t.Run("dialect="+string(d), func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			reg := ketotest.NewRegistry(t, d, &ketotest.Namespace{Name: "files"})
+			tuple := &ketodriver.RelationTuple{
+				Namespace: "files",
+				Object:    "readme",
+				Relation:  "viewer",
+				Subject:   &ketodriver.SubjectID{ID: "alice"},
+			}
+			require.NoError(t, reg.RelationTupleManager().WriteRelationTuples(ctx, tuple))
+
+			allowed, err := reg.PermissionEngine().SubjectIsAllowed(ctx, tuple, 0)
+			require.NoError(t, err)
+			assert.True(t, allowed)
+
+			// Every registry has its own database.
+			res, _, err := ketotest.NewPersister(t, d, &ketotest.Namespace{Name: "files"}).GetRelationTuples(ctx, &ketodriver.RelationQuery{Namespace: "files"})
+			require.NoError(t, err)
+			assert.Empty(t, res)
+		})
+	}
+}
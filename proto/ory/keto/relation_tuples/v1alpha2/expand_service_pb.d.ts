@@ -71,6 +71,10 @@ export class SubjectTree extends jspb.Message {
     getChildrenList(): Array<SubjectTree>;
     setChildrenList(value: Array<SubjectTree>): SubjectTree;
     addChildren(value?: SubjectTree, index?: number): SubjectTree;
+    getTruncated(): boolean;
+    setTruncated(value: boolean): SubjectTree;
+    getNextPageToken(): string;
+    setNextPageToken(value: string): SubjectTree;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): SubjectTree.AsObject;
@@ -87,6 +91,8 @@ export namespace SubjectTree {
         nodeType: NodeType,
         subject?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.AsObject,
         childrenList: Array<SubjectTree.AsObject>,
+        truncated: boolean,
+        nextPageToken: string,
     }
 }
 
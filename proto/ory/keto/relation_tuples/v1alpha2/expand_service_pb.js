@@ -494,7 +494,9 @@ proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.toObject = function(includeI
     nodeType: jspb.Message.getFieldWithDefault(msg, 1, 0),
     subject: (f = msg.getSubject()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.toObject(includeInstance, f),
     childrenList: jspb.Message.toObjectList(msg.getChildrenList(),
-    proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.toObject, includeInstance)
+    proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.toObject, includeInstance),
+    truncated: jspb.Message.getBooleanFieldWithDefault(msg, 4, false),
+    nextPageToken: jspb.Message.getFieldWithDefault(msg, 5, "")
   };
 
   if (includeInstance) {
@@ -545,6 +547,14 @@ proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.deserializeBinaryFromReader
       reader.readMessage(value,proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.deserializeBinaryFromReader);
       msg.addChildren(value);
       break;
+    case 4:
+      var value = /** @type {boolean} */ (reader.readBool());
+      msg.setTruncated(value);
+      break;
+    case 5:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setNextPageToken(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -597,6 +607,20 @@ proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.serializeBinaryToWriter = fu
       proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.serializeBinaryToWriter
     );
   }
+  f = message.getTruncated();
+  if (f) {
+    writer.writeBool(
+      4,
+      f
+    );
+  }
+  f = message.getNextPageToken();
+  if (f.length > 0) {
+    writer.writeString(
+      5,
+      f
+    );
+  }
 };
 
 
@@ -693,6 +717,42 @@ proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.prototype.clearChildrenList
 };
 
 
+/**
+ * optional bool truncated = 4;
+ * @return {boolean}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.prototype.getTruncated = function() {
+  return /** @type {boolean} */ (jspb.Message.getBooleanFieldWithDefault(this, 4, false));
+};
+
+
+/**
+ * @param {boolean} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.SubjectTree} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.prototype.setTruncated = function(value) {
+  return jspb.Message.setProto3BooleanField(this, 4, value);
+};
+
+
+/**
+ * optional string next_page_token = 5;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.prototype.getNextPageToken = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 5, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.SubjectTree} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.SubjectTree.prototype.setNextPageToken = function(value) {
+  return jspb.Message.setProto3StringField(this, 5, value);
+};
+
+
 /**
  * @enum {number}
  */
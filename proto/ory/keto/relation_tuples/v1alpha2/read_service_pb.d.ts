@@ -25,6 +25,12 @@ export class ListRelationTuplesRequest extends jspb.Message {
     setPageSize(value: number): ListRelationTuplesRequest;
     getPageToken(): string;
     setPageToken(value: string): ListRelationTuplesRequest;
+    getCount(): boolean;
+    setCount(value: boolean): ListRelationTuplesRequest;
+    getOrderBy(): string;
+    setOrderBy(value: string): ListRelationTuplesRequest;
+    getOrder(): string;
+    setOrder(value: string): ListRelationTuplesRequest;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): ListRelationTuplesRequest.AsObject;
@@ -43,6 +49,9 @@ export namespace ListRelationTuplesRequest {
         snaptoken: string,
         pageSize: number,
         pageToken: string,
+        count: boolean,
+        orderBy: string,
+        order: string,
     }
 
 
@@ -59,6 +68,9 @@ export namespace ListRelationTuplesRequest {
         getSubject(): ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject | undefined;
         setSubject(value?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject): Query;
 
+        getLabelsMap(): jspb.Map<string, string>;
+        clearLabelsMap(): void;
+
         serializeBinary(): Uint8Array;
         toObject(includeInstance?: boolean): Query.AsObject;
         static toObject(includeInstance: boolean, msg: Query): Query.AsObject;
@@ -75,6 +87,7 @@ export namespace ListRelationTuplesRequest {
             object: string,
             relation: string,
             subject?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.AsObject,
+            labelsMap: Array<[string, string]>,
         }
     }
 
@@ -87,6 +100,8 @@ export class ListRelationTuplesResponse extends jspb.Message {
     addRelationTuples(value?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple, index?: number): ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple;
     getNextPageToken(): string;
     setNextPageToken(value: string): ListRelationTuplesResponse;
+    getTotalCount(): number;
+    setTotalCount(value: number): ListRelationTuplesResponse;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): ListRelationTuplesResponse.AsObject;
@@ -102,5 +117,6 @@ export namespace ListRelationTuplesResponse {
     export type AsObject = {
         relationTuplesList: Array<ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.AsObject>,
         nextPageToken: string,
+        totalCount: number,
     }
 }
@@ -127,7 +127,10 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.toObject = fun
     expandMask: (f = msg.getExpandMask()) && google_protobuf_field_mask_pb.FieldMask.toObject(includeInstance, f),
     snaptoken: jspb.Message.getFieldWithDefault(msg, 3, ""),
     pageSize: jspb.Message.getFieldWithDefault(msg, 4, 0),
-    pageToken: jspb.Message.getFieldWithDefault(msg, 5, "")
+    pageToken: jspb.Message.getFieldWithDefault(msg, 5, ""),
+    count: jspb.Message.getBooleanFieldWithDefault(msg, 6, false),
+    orderBy: jspb.Message.getFieldWithDefault(msg, 7, ""),
+    order: jspb.Message.getFieldWithDefault(msg, 8, "")
   };
 
   if (includeInstance) {
@@ -186,6 +189,18 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.deserializeBin
       var value = /** @type {string} */ (reader.readString());
       msg.setPageToken(value);
       break;
+    case 6:
+      var value = /** @type {boolean} */ (reader.readBool());
+      msg.setCount(value);
+      break;
+    case 7:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setOrderBy(value);
+      break;
+    case 8:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setOrder(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -252,6 +267,27 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.serializeBinar
       f
     );
   }
+  f = message.getCount();
+  if (f) {
+    writer.writeBool(
+      6,
+      f
+    );
+  }
+  f = message.getOrderBy();
+  if (f.length > 0) {
+    writer.writeString(
+      7,
+      f
+    );
+  }
+  f = message.getOrder();
+  if (f.length > 0) {
+    writer.writeString(
+      8,
+      f
+    );
+  }
 };
 
 
@@ -290,7 +326,8 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.toObject
     namespace: jspb.Message.getFieldWithDefault(msg, 1, ""),
     object: jspb.Message.getFieldWithDefault(msg, 2, ""),
     relation: jspb.Message.getFieldWithDefault(msg, 3, ""),
-    subject: (f = msg.getSubject()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.toObject(includeInstance, f)
+    subject: (f = msg.getSubject()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.toObject(includeInstance, f),
+    labelsMap: (f = msg.getLabelsMap()) ? f.toObject(includeInstance, undefined) : []
   };
 
   if (includeInstance) {
@@ -344,6 +381,12 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.deserial
       reader.readMessage(value,ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.deserializeBinaryFromReader);
       msg.setSubject(value);
       break;
+    case 5:
+      var value = msg.getLabelsMap();
+      reader.readMessage(value, function(message, reader) {
+        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
+         });
+      break;
     default:
       reader.skipField();
       break;
@@ -402,6 +445,10 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.serializ
       ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.serializeBinaryToWriter
     );
   }
+  f = message.getLabelsMap(true);
+  if (f && f.getLength() > 0) {
+    f.serializeBinary(5, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
+  }
 };
 
 
@@ -496,6 +543,28 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.prototyp
 };
 
 
+/**
+ * map<string, string> labels = 5;
+ * @param {boolean=} opt_noLazyCreate Do not create the map if
+ * empty, instead returning `undefined`
+ * @return {!jspb.Map<string,string>}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.prototype.getLabelsMap = function(opt_noLazyCreate) {
+  return /** @type {!jspb.Map<string,string>} */ (
+      jspb.Message.getMapField(this, 5, opt_noLazyCreate,
+      null));
+};
+
+
+/**
+ * Clears values from the map. The map will be non-null.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query.prototype.clearLabelsMap = function() {
+  this.getLabelsMap().clear();
+  return this;};
+
+
 /**
  * optional Query query = 1;
  * @return {?proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.Query}
@@ -624,6 +693,60 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.setP
 };
 
 
+/**
+ * optional bool count = 6;
+ * @return {boolean}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.getCount = function() {
+  return /** @type {boolean} */ (jspb.Message.getBooleanFieldWithDefault(this, 6, false));
+};
+
+
+/**
+ * @param {boolean} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.setCount = function(value) {
+  return jspb.Message.setProto3BooleanField(this, 6, value);
+};
+
+
+/**
+ * optional string order_by = 7;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.getOrderBy = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 7, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.setOrderBy = function(value) {
+  return jspb.Message.setProto3StringField(this, 7, value);
+};
+
+
+/**
+ * optional string order = 8;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.getOrder = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 8, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesRequest.prototype.setOrder = function(value) {
+  return jspb.Message.setProto3StringField(this, 8, value);
+};
+
+
 
 /**
  * List of repeated fields within this message type.
@@ -665,7 +788,8 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.toObject = fu
   var f, obj = {
     relationTuplesList: jspb.Message.toObjectList(msg.getRelationTuplesList(),
     ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.toObject, includeInstance),
-    nextPageToken: jspb.Message.getFieldWithDefault(msg, 2, "")
+    nextPageToken: jspb.Message.getFieldWithDefault(msg, 2, ""),
+    totalCount: jspb.Message.getFieldWithDefault(msg, 3, 0)
   };
 
   if (includeInstance) {
@@ -711,6 +835,10 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.deserializeBi
       var value = /** @type {string} */ (reader.readString());
       msg.setNextPageToken(value);
       break;
+    case 3:
+      var value = /** @type {number} */ (reader.readInt64());
+      msg.setTotalCount(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -755,6 +883,13 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.serializeBina
       f
     );
   }
+  f = message.getTotalCount();
+  if (f !== 0) {
+    writer.writeInt64(
+      3,
+      f
+    );
+  }
 };
 
 
@@ -814,4 +949,22 @@ proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.prototype.set
 };
 
 
+/**
+ * optional int64 total_count = 3;
+ * @return {number}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.prototype.getTotalCount = function() {
+  return /** @type {number} */ (jspb.Message.getFieldWithDefault(this, 3, 0));
+};
+
+
+/**
+ * @param {number} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.ListRelationTuplesResponse.prototype.setTotalCount = function(value) {
+  return jspb.Message.setProto3IntField(this, 3, value);
+};
+
+
 goog.object.extend(exports, proto.ory.keto.relation_tuples.v1alpha2);
@@ -19,6 +19,9 @@ export class RelationTuple extends jspb.Message {
     getSubject(): Subject | undefined;
     setSubject(value?: Subject): RelationTuple;
 
+    getLabelsMap(): jspb.Map<string, string>;
+    clearLabelsMap(): void;
+
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): RelationTuple.AsObject;
     static toObject(includeInstance: boolean, msg: RelationTuple): RelationTuple.AsObject;
@@ -35,6 +38,7 @@ export namespace RelationTuple {
         object: string,
         relation: string,
         subject?: Subject.AsObject,
+        labelsMap: Array<[string, string]>,
     }
 }
 
@@ -123,7 +123,8 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.toObject = function(includ
     namespace: jspb.Message.getFieldWithDefault(msg, 1, ""),
     object: jspb.Message.getFieldWithDefault(msg, 2, ""),
     relation: jspb.Message.getFieldWithDefault(msg, 3, ""),
-    subject: (f = msg.getSubject()) && proto.ory.keto.relation_tuples.v1alpha2.Subject.toObject(includeInstance, f)
+    subject: (f = msg.getSubject()) && proto.ory.keto.relation_tuples.v1alpha2.Subject.toObject(includeInstance, f),
+    labelsMap: (f = msg.getLabelsMap()) ? f.toObject(includeInstance, undefined) : []
   };
 
   if (includeInstance) {
@@ -177,6 +178,12 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.deserializeBinaryFromReade
       reader.readMessage(value,proto.ory.keto.relation_tuples.v1alpha2.Subject.deserializeBinaryFromReader);
       msg.setSubject(value);
       break;
+    case 5:
+      var value = msg.getLabelsMap();
+      reader.readMessage(value, function(message, reader) {
+        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
+         });
+      break;
     default:
       reader.skipField();
       break;
@@ -235,6 +242,10 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.serializeBinaryToWriter =
       proto.ory.keto.relation_tuples.v1alpha2.Subject.serializeBinaryToWriter
     );
   }
+  f = message.getLabelsMap(true);
+  if (f && f.getLength() > 0) {
+    f.serializeBinary(5, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
+  }
 };
 
 
@@ -329,6 +340,28 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.prototype.hasSubject = fun
 };
 
 
+/**
+ * map<string, string> labels = 5;
+ * @param {boolean=} opt_noLazyCreate Do not create the map if
+ * empty, instead returning `undefined`
+ * @return {!jspb.Map<string,string>}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.prototype.getLabelsMap = function(opt_noLazyCreate) {
+  return /** @type {!jspb.Map<string,string>} */ (
+      jspb.Message.getMapField(this, 5, opt_noLazyCreate,
+      null));
+};
+
+
+/**
+ * Clears values from the map. The map will be non-null.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTuple} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTuple.prototype.clearLabelsMap = function() {
+  this.getLabelsMap().clear();
+  return this;};
+
+
 
 /**
  * Oneof group definitions for this message. Each group defines the field
@@ -20,6 +20,10 @@ const _ = grpc.SupportPackageIsVersion7
 type VersionServiceClient interface {
 	// Returns the version of the Ory Keto instance.
 	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
+	// Returns the API version, the supported features, and the schema
+	// migration level of the Ory Keto instance, so that clients can detect
+	// features instead of failing on older instances.
+	GetServerMetadata(ctx context.Context, in *GetServerMetadataRequest, opts ...grpc.CallOption) (*GetServerMetadataResponse, error)
 }
 
 type versionServiceClient struct {
@@ -39,12 +43,25 @@ func (c *versionServiceClient) GetVersion(ctx context.Context, in *GetVersionReq
 	return out, nil
 }
 
+func (c *versionServiceClient) GetServerMetadata(ctx context.Context, in *GetServerMetadataRequest, opts ...grpc.CallOption) (*GetServerMetadataResponse, error) {
+	out := new(GetServerMetadataResponse)
+	err := c.cc.Invoke(ctx, "/ory.keto.relation_tuples.v1alpha2.VersionService/GetServerMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VersionServiceServer is the server API for VersionService service.
 // All implementations should embed UnimplementedVersionServiceServer
 // for forward compatibility
 type VersionServiceServer interface {
 	// Returns the version of the Ory Keto instance.
 	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
+	// Returns the API version, the supported features, and the schema
+	// migration level of the Ory Keto instance, so that clients can detect
+	// features instead of failing on older instances.
+	GetServerMetadata(context.Context, *GetServerMetadataRequest) (*GetServerMetadataResponse, error)
 }
 
 // UnimplementedVersionServiceServer should be embedded to have forward compatible implementations.
@@ -54,6 +71,9 @@ type UnimplementedVersionServiceServer struct {
 func (UnimplementedVersionServiceServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
 }
+func (UnimplementedVersionServiceServer) GetServerMetadata(context.Context, *GetServerMetadataRequest) (*GetServerMetadataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerMetadata not implemented")
+}
 
 // UnsafeVersionServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to VersionServiceServer will
@@ -84,6 +104,24 @@ func _VersionService_GetVersion_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VersionService_GetServerMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VersionServiceServer).GetServerMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ory.keto.relation_tuples.v1alpha2.VersionService/GetServerMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VersionServiceServer).GetServerMetadata(ctx, req.(*GetServerMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VersionService_ServiceDesc is the grpc.ServiceDesc for VersionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,6 +133,10 @@ var VersionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetVersion",
 			Handler:    _VersionService_GetVersion_Handler,
 		},
+		{
+			MethodName: "GetServerMetadata",
+			Handler:    _VersionService_GetServerMetadata_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "ory/keto/relation_tuples/v1alpha2/version.proto",
@@ -9,6 +9,7 @@ import * as ory_keto_relation_tuples_v1alpha2_version_pb from "../../../../ory/k
 
 interface IVersionServiceService extends grpc.ServiceDefinition<grpc.UntypedServiceImplementation> {
     getVersion: IVersionServiceService_IGetVersion;
+    getServerMetadata: IVersionServiceService_IGetServerMetadata;
 }
 
 interface IVersionServiceService_IGetVersion extends grpc.MethodDefinition<ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse> {
@@ -21,16 +22,30 @@ interface IVersionServiceService_IGetVersion extends grpc.MethodDefinition<ory_k
     responseDeserialize: grpc.deserialize<ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse>;
 }
 
+interface IVersionServiceService_IGetServerMetadata extends grpc.MethodDefinition<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse> {
+    path: "/ory.keto.relation_tuples.v1alpha2.VersionService/GetServerMetadata";
+    requestStream: false;
+    responseStream: false;
+    requestSerialize: grpc.serialize<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest>;
+    requestDeserialize: grpc.deserialize<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest>;
+    responseSerialize: grpc.serialize<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse>;
+    responseDeserialize: grpc.deserialize<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse>;
+}
+
 export const VersionServiceService: IVersionServiceService;
 
 export interface IVersionServiceServer {
     getVersion: grpc.handleUnaryCall<ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse>;
+    getServerMetadata: grpc.handleUnaryCall<ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse>;
 }
 
 export interface IVersionServiceClient {
     getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
     getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
     getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
+    getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
+    getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
+    getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
 }
 
 export class VersionServiceClient extends grpc.Client implements IVersionServiceClient {
@@ -38,4 +53,7 @@ export class VersionServiceClient extends grpc.Client implements IVersionService
     public getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
     public getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
     public getVersion(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionResponse) => void): grpc.ClientUnaryCall;
+    public getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
+    public getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, metadata: grpc.Metadata, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
+    public getServerMetadata(request: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest, metadata: grpc.Metadata, options: Partial<grpc.CallOptions>, callback: (error: grpc.ServiceError | null, response: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse) => void): grpc.ClientUnaryCall;
 }
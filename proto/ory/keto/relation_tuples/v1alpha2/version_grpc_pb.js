@@ -4,6 +4,29 @@
 var grpc = require('@grpc/grpc-js');
 var ory_keto_relation_tuples_v1alpha2_version_pb = require('../../../../ory/keto/relation_tuples/v1alpha2/version_pb.js');
 
+function serialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataRequest(arg) {
+  if (!(arg instanceof ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest)) {
+    throw new Error('Expected argument of type ory.keto.relation_tuples.v1alpha2.GetServerMetadataRequest');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataRequest(buffer_arg) {
+  return ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+function serialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataResponse(arg) {
+  if (!(arg instanceof ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse)) {
+    throw new Error('Expected argument of type ory.keto.relation_tuples.v1alpha2.GetServerMetadataResponse');
+  }
+  return Buffer.from(arg.serializeBinary());
+}
+
+function deserialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataResponse(buffer_arg) {
+  return ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
+
 function serialize_ory_keto_relation_tuples_v1alpha2_GetVersionRequest(arg) {
   if (!(arg instanceof ory_keto_relation_tuples_v1alpha2_version_pb.GetVersionRequest)) {
     throw new Error('Expected argument of type ory.keto.relation_tuples.v1alpha2.GetVersionRequest');
@@ -43,6 +66,20 @@ getVersion: {
     responseSerialize: serialize_ory_keto_relation_tuples_v1alpha2_GetVersionResponse,
     responseDeserialize: deserialize_ory_keto_relation_tuples_v1alpha2_GetVersionResponse,
   },
+  // Returns the API version, the supported features, and the schema
+// migration level of the Ory Keto instance, so that clients can detect
+// features instead of failing on older instances.
+getServerMetadata: {
+    path: '/ory.keto.relation_tuples.v1alpha2.VersionService/GetServerMetadata',
+    requestStream: false,
+    responseStream: false,
+    requestType: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataRequest,
+    responseType: ory_keto_relation_tuples_v1alpha2_version_pb.GetServerMetadataResponse,
+    requestSerialize: serialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataRequest,
+    requestDeserialize: deserialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataRequest,
+    responseSerialize: serialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataResponse,
+    responseDeserialize: deserialize_ory_keto_relation_tuples_v1alpha2_GetServerMetadataResponse,
+  },
 };
 
 exports.VersionServiceClient = grpc.makeGenericClientConstructor(VersionServiceService);
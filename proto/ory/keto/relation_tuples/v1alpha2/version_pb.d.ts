@@ -42,3 +42,54 @@ export namespace GetVersionResponse {
         version: string,
     }
 }
+
+export class GetServerMetadataRequest extends jspb.Message { 
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): GetServerMetadataRequest.AsObject;
+    static toObject(includeInstance: boolean, msg: GetServerMetadataRequest): GetServerMetadataRequest.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: GetServerMetadataRequest, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): GetServerMetadataRequest;
+    static deserializeBinaryFromReader(message: GetServerMetadataRequest, reader: jspb.BinaryReader): GetServerMetadataRequest;
+}
+
+export namespace GetServerMetadataRequest {
+    export type AsObject = {
+    }
+}
+
+export class GetServerMetadataResponse extends jspb.Message { 
+    getVersion(): string;
+    setVersion(value: string): GetServerMetadataResponse;
+    getApiVersion(): string;
+    setApiVersion(value: string): GetServerMetadataResponse;
+
+    getFeaturesMap(): jspb.Map<string, boolean>;
+    clearFeaturesMap(): void;
+    getMigrationVersion(): string;
+    setMigrationVersion(value: string): GetServerMetadataResponse;
+    getPendingMigrations(): number;
+    setPendingMigrations(value: number): GetServerMetadataResponse;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): GetServerMetadataResponse.AsObject;
+    static toObject(includeInstance: boolean, msg: GetServerMetadataResponse): GetServerMetadataResponse.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: GetServerMetadataResponse, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): GetServerMetadataResponse;
+    static deserializeBinaryFromReader(message: GetServerMetadataResponse, reader: jspb.BinaryReader): GetServerMetadataResponse;
+}
+
+export namespace GetServerMetadataResponse {
+    export type AsObject = {
+        version: string,
+        apiVersion: string,
+
+        featuresMap: Array<[string, boolean]>,
+        migrationVersion: string,
+        pendingMigrations: number,
+    }
+}
@@ -5,6 +5,7 @@
 /* eslint-disable */
 
 import * as jspb from "google-protobuf";
+import * as google_protobuf_timestamp_pb from "google-protobuf/google/protobuf/timestamp_pb";
 import * as ory_keto_relation_tuples_v1alpha2_relation_tuples_pb from "../../../../ory/keto/relation_tuples/v1alpha2/relation_tuples_pb";
 
 export class TransactRelationTuplesRequest extends jspb.Message { 
@@ -38,6 +39,11 @@ export class RelationTupleDelta extends jspb.Message {
     getRelationTuple(): ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple | undefined;
     setRelationTuple(value?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple): RelationTupleDelta;
 
+    hasTemplate(): boolean;
+    clearTemplate(): void;
+    getTemplate(): RelationTupleTemplate | undefined;
+    setTemplate(value?: RelationTupleTemplate): RelationTupleDelta;
+
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): RelationTupleDelta.AsObject;
     static toObject(includeInstance: boolean, msg: RelationTupleDelta): RelationTupleDelta.AsObject;
@@ -52,6 +58,7 @@ export namespace RelationTupleDelta {
     export type AsObject = {
         action: RelationTupleDelta.Action,
         relationTuple?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.AsObject,
+        template?: RelationTupleTemplate.AsObject,
     }
 
     export enum Action {
@@ -62,11 +69,41 @@ export namespace RelationTupleDelta {
 
 }
 
+export class RelationTupleTemplate extends jspb.Message { 
+    getName(): string;
+    setName(value: string): RelationTupleTemplate;
+
+    getParametersMap(): jspb.Map<string, string>;
+    clearParametersMap(): void;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): RelationTupleTemplate.AsObject;
+    static toObject(includeInstance: boolean, msg: RelationTupleTemplate): RelationTupleTemplate.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: RelationTupleTemplate, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): RelationTupleTemplate;
+    static deserializeBinaryFromReader(message: RelationTupleTemplate, reader: jspb.BinaryReader): RelationTupleTemplate;
+}
+
+export namespace RelationTupleTemplate {
+    export type AsObject = {
+        name: string,
+        parametersMap: Array<[string, string]>,
+    }
+}
+
 export class TransactRelationTuplesResponse extends jspb.Message { 
     clearSnaptokensList(): void;
     getSnaptokensList(): Array<string>;
     setSnaptokensList(value: Array<string>): TransactRelationTuplesResponse;
     addSnaptokens(value: string, index?: number): string;
+    clearRelationTuplesList(): void;
+    getRelationTuplesList(): Array<StoredRelationTuple>;
+    setRelationTuplesList(value: Array<StoredRelationTuple>): TransactRelationTuplesResponse;
+    addRelationTuples(value?: StoredRelationTuple, index?: number): StoredRelationTuple;
+    getSnapshotToken(): string;
+    setSnapshotToken(value: string): TransactRelationTuplesResponse;
 
     serializeBinary(): Uint8Array;
     toObject(includeInstance?: boolean): TransactRelationTuplesResponse.AsObject;
@@ -81,6 +118,40 @@ export class TransactRelationTuplesResponse extends jspb.Message {
 export namespace TransactRelationTuplesResponse {
     export type AsObject = {
         snaptokensList: Array<string>,
+        relationTuplesList: Array<StoredRelationTuple.AsObject>,
+        snapshotToken: string,
+    }
+}
+
+export class StoredRelationTuple extends jspb.Message { 
+
+    hasRelationTuple(): boolean;
+    clearRelationTuple(): void;
+    getRelationTuple(): ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple | undefined;
+    setRelationTuple(value?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple): StoredRelationTuple;
+    getId(): string;
+    setId(value: string): StoredRelationTuple;
+
+    hasCommitTime(): boolean;
+    clearCommitTime(): void;
+    getCommitTime(): google_protobuf_timestamp_pb.Timestamp | undefined;
+    setCommitTime(value?: google_protobuf_timestamp_pb.Timestamp): StoredRelationTuple;
+
+    serializeBinary(): Uint8Array;
+    toObject(includeInstance?: boolean): StoredRelationTuple.AsObject;
+    static toObject(includeInstance: boolean, msg: StoredRelationTuple): StoredRelationTuple.AsObject;
+    static extensions: {[key: number]: jspb.ExtensionFieldInfo<jspb.Message>};
+    static extensionsBinary: {[key: number]: jspb.ExtensionFieldBinaryInfo<jspb.Message>};
+    static serializeBinaryToWriter(message: StoredRelationTuple, writer: jspb.BinaryWriter): void;
+    static deserializeBinary(bytes: Uint8Array): StoredRelationTuple;
+    static deserializeBinaryFromReader(message: StoredRelationTuple, reader: jspb.BinaryReader): StoredRelationTuple;
+}
+
+export namespace StoredRelationTuple {
+    export type AsObject = {
+        relationTuple?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.AsObject,
+        id: string,
+        commitTime?: google_protobuf_timestamp_pb.Timestamp.AsObject,
     }
 }
 
@@ -120,6 +191,9 @@ export namespace DeleteRelationTuplesRequest {
         getSubject(): ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject | undefined;
         setSubject(value?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject): Query;
 
+        getLabelsMap(): jspb.Map<string, string>;
+        clearLabelsMap(): void;
+
         serializeBinary(): Uint8Array;
         toObject(includeInstance?: boolean): Query.AsObject;
         static toObject(includeInstance: boolean, msg: Query): Query.AsObject;
@@ -136,6 +210,7 @@ export namespace DeleteRelationTuplesRequest {
             object: string,
             relation: string,
             subject?: ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.AsObject,
+            labelsMap: Array<[string, string]>,
         }
     }
 
@@ -21,6 +21,8 @@ var global = (function() {
   return Function('return this')();
 }.call(null));
 
+var google_protobuf_timestamp_pb = require('google-protobuf/google/protobuf/timestamp_pb.js');
+goog.object.extend(proto, google_protobuf_timestamp_pb);
 var ory_keto_relation_tuples_v1alpha2_relation_tuples_pb = require('../../../../ory/keto/relation_tuples/v1alpha2/relation_tuples_pb.js');
 goog.object.extend(proto, ory_keto_relation_tuples_v1alpha2_relation_tuples_pb);
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest', null, global);
@@ -28,6 +30,8 @@ goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesR
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesResponse', null, global);
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta', null, global);
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.Action', null, global);
+goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate', null, global);
+goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple', null, global);
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesRequest', null, global);
 goog.exportSymbol('proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse', null, global);
 /**
@@ -72,6 +76,27 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.displayName = 'proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.displayName = 'proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate';
+}
 /**
  * Generated by JsPbCodeGenerator.
  * @param {Array=} opt_data Optional initial data array, typically from a
@@ -93,6 +118,27 @@ if (goog.DEBUG && !COMPILED) {
    */
   proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.displayName = 'proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse';
 }
+/**
+ * Generated by JsPbCodeGenerator.
+ * @param {Array=} opt_data Optional initial data array, typically from a
+ * server response, or constructed directly in Javascript. The array is used
+ * in place and becomes part of the constructed object. It is not cloned.
+ * If no data is provided, the constructed object will be empty, but still
+ * valid.
+ * @extends {jspb.Message}
+ * @constructor
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple = function(opt_data) {
+  jspb.Message.initialize(this, opt_data, 0, -1, null, null);
+};
+goog.inherits(proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple, jspb.Message);
+if (goog.DEBUG && !COMPILED) {
+  /**
+   * @public
+   * @override
+   */
+  proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.displayName = 'proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple';
+}
 /**
  * Generated by JsPbCodeGenerator.
  * @param {Array=} opt_data Optional initial data array, typically from a
@@ -349,7 +395,8 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.toObject =
 proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.toObject = function(includeInstance, msg) {
   var f, obj = {
     action: jspb.Message.getFieldWithDefault(msg, 1, 0),
-    relationTuple: (f = msg.getRelationTuple()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.toObject(includeInstance, f)
+    relationTuple: (f = msg.getRelationTuple()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.toObject(includeInstance, f),
+    template: (f = msg.getTemplate()) && proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.toObject(includeInstance, f)
   };
 
   if (includeInstance) {
@@ -395,6 +442,11 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.deserializeBinaryFrom
       reader.readMessage(value,ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.deserializeBinaryFromReader);
       msg.setRelationTuple(value);
       break;
+    case 3:
+      var value = new proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate;
+      reader.readMessage(value,proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.deserializeBinaryFromReader);
+      msg.setTemplate(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -439,6 +491,14 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.serializeBinaryToWrit
       ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.serializeBinaryToWriter
     );
   }
+  f = message.getTemplate();
+  if (f != null) {
+    writer.writeMessage(
+      3,
+      f,
+      proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.serializeBinaryToWriter
+    );
+  }
 };
 
 
@@ -507,12 +567,213 @@ proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.hasRelation
 
 
 
+/**
+ * optional RelationTupleTemplate template = 3;
+ * @return {?proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.getTemplate = function() {
+  return /** @type{?proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} */ (
+    jspb.Message.getWrapperField(this, proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate, 3));
+};
+
+
+/**
+ * @param {?proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate|undefined} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta} returns this
+*/
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.setTemplate = function(value) {
+  return jspb.Message.setWrapperField(this, 3, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.clearTemplate = function() {
+  return this.setTemplate(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleDelta.prototype.hasTemplate = function() {
+  return jspb.Message.getField(this, 3) != null;
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.toObject = function(opt_includeInstance) {
+  return proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    name: jspb.Message.getFieldWithDefault(msg, 1, ""),
+    parametersMap: (f = msg.getParametersMap()) ? f.toObject(includeInstance, undefined) : []
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate;
+  return proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setName(value);
+      break;
+    case 2:
+      var value = msg.getParametersMap();
+      reader.readMessage(value, function(message, reader) {
+        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
+         });
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getName();
+  if (f.length > 0) {
+    writer.writeString(
+      1,
+      f
+    );
+  }
+  f = message.getParametersMap(true);
+  if (f && f.getLength() > 0) {
+    f.serializeBinary(2, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
+  }
+};
+
+
+/**
+ * optional string name = 1;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.getName = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 1, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.setName = function(value) {
+  return jspb.Message.setProto3StringField(this, 1, value);
+};
+
+
+/**
+ * map<string, string> parameters = 2;
+ * @param {boolean=} opt_noLazyCreate Do not create the map if
+ * empty, instead returning `undefined`
+ * @return {!jspb.Map<string,string>}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.getParametersMap = function(opt_noLazyCreate) {
+  return /** @type {!jspb.Map<string,string>} */ (
+      jspb.Message.getMapField(this, 2, opt_noLazyCreate,
+      null));
+};
+
+
+/**
+ * Clears values from the map. The map will be non-null.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.RelationTupleTemplate.prototype.clearParametersMap = function() {
+  this.getParametersMap().clear();
+  return this;};
+
+
+
 /**
  * List of repeated fields within this message type.
  * @private {!Array<number>}
  * @const
  */
-proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.repeatedFields_ = [1];
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.repeatedFields_ = [1,2];
 
 
 
@@ -545,7 +806,10 @@ proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype
  */
 proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.toObject = function(includeInstance, msg) {
   var f, obj = {
-    snaptokensList: (f = jspb.Message.getRepeatedField(msg, 1)) == null ? undefined : f
+    snaptokensList: (f = jspb.Message.getRepeatedField(msg, 1)) == null ? undefined : f,
+    relationTuplesList: jspb.Message.toObjectList(msg.getRelationTuplesList(),
+    proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.toObject, includeInstance),
+    snapshotToken: jspb.Message.getFieldWithDefault(msg, 3, "")
   };
 
   if (includeInstance) {
@@ -586,6 +850,15 @@ proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.deseriali
       var value = /** @type {string} */ (reader.readString());
       msg.addSnaptokens(value);
       break;
+    case 2:
+      var value = new proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple;
+      reader.readMessage(value,proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.deserializeBinaryFromReader);
+      msg.addRelationTuples(value);
+      break;
+    case 3:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setSnapshotToken(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -622,6 +895,21 @@ proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.serialize
       f
     );
   }
+  f = message.getRelationTuplesList();
+  if (f.length > 0) {
+    writer.writeRepeatedMessage(
+      2,
+      f,
+      proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.serializeBinaryToWriter
+    );
+  }
+  f = message.getSnapshotToken();
+  if (f.length > 0) {
+    writer.writeString(
+      3,
+      f
+    );
+  }
 };
 
 
@@ -662,6 +950,294 @@ proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype
 };
 
 
+/**
+ * repeated StoredRelationTuple relation_tuples = 2;
+ * @return {!Array<!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple>}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.getRelationTuplesList = function() {
+  return /** @type{!Array<!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple>} */ (
+    jspb.Message.getRepeatedWrapperField(this, proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple, 2));
+};
+
+
+/**
+ * @param {!Array<!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple>} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse} returns this
+*/
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.setRelationTuplesList = function(value) {
+  return jspb.Message.setRepeatedWrapperField(this, 2, value);
+};
+
+
+/**
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple=} opt_value
+ * @param {number=} opt_index
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.addRelationTuples = function(opt_value, opt_index) {
+  return jspb.Message.addToRepeatedWrapperField(this, 2, opt_value, proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.clearRelationTuplesList = function() {
+  return this.setRelationTuplesList([]);
+};
+
+
+/**
+ * optional string snapshot_token = 3;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.getSnapshotToken = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 3, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.TransactRelationTuplesResponse.prototype.setSnapshotToken = function(value) {
+  return jspb.Message.setProto3StringField(this, 3, value);
+};
+
+
+
+
+
+if (jspb.Message.GENERATE_TO_OBJECT) {
+/**
+ * Creates an object representation of this proto.
+ * Field names that are reserved in JavaScript and will be renamed to pb_name.
+ * Optional fields that are not set will be set to undefined.
+ * To access a reserved field use, foo.pb_<name>, eg, foo.pb_default.
+ * For the list of reserved names please see:
+ *     net/proto2/compiler/js/internal/generator.cc#kKeyword.
+ * @param {boolean=} opt_includeInstance Deprecated. whether to include the
+ *     JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @return {!Object}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.toObject = function(opt_includeInstance) {
+  return proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.toObject(opt_includeInstance, this);
+};
+
+
+/**
+ * Static version of the {@see toObject} method.
+ * @param {boolean|undefined} includeInstance Deprecated. Whether to include
+ *     the JSPB instance for transitional soy proto support:
+ *     http://goto/soy-param-migration
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} msg The msg instance to transform.
+ * @return {!Object}
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.toObject = function(includeInstance, msg) {
+  var f, obj = {
+    relationTuple: (f = msg.getRelationTuple()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.toObject(includeInstance, f),
+    id: jspb.Message.getFieldWithDefault(msg, 2, ""),
+    commitTime: (f = msg.getCommitTime()) && google_protobuf_timestamp_pb.Timestamp.toObject(includeInstance, f)
+  };
+
+  if (includeInstance) {
+    obj.$jspbMessageInstance = msg;
+  }
+  return obj;
+};
+}
+
+
+/**
+ * Deserializes binary data (in protobuf wire format).
+ * @param {jspb.ByteSource} bytes The bytes to deserialize.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.deserializeBinary = function(bytes) {
+  var reader = new jspb.BinaryReader(bytes);
+  var msg = new proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple;
+  return proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.deserializeBinaryFromReader(msg, reader);
+};
+
+
+/**
+ * Deserializes binary data (in protobuf wire format) from the
+ * given reader into the given message object.
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} msg The message object to deserialize into.
+ * @param {!jspb.BinaryReader} reader The BinaryReader to use.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.deserializeBinaryFromReader = function(msg, reader) {
+  while (reader.nextField()) {
+    if (reader.isEndGroup()) {
+      break;
+    }
+    var field = reader.getFieldNumber();
+    switch (field) {
+    case 1:
+      var value = new ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple;
+      reader.readMessage(value,ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.deserializeBinaryFromReader);
+      msg.setRelationTuple(value);
+      break;
+    case 2:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setId(value);
+      break;
+    case 3:
+      var value = new google_protobuf_timestamp_pb.Timestamp;
+      reader.readMessage(value,google_protobuf_timestamp_pb.Timestamp.deserializeBinaryFromReader);
+      msg.setCommitTime(value);
+      break;
+    default:
+      reader.skipField();
+      break;
+    }
+  }
+  return msg;
+};
+
+
+/**
+ * Serializes the message to binary data (in protobuf wire format).
+ * @return {!Uint8Array}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.serializeBinary = function() {
+  var writer = new jspb.BinaryWriter();
+  proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.serializeBinaryToWriter(this, writer);
+  return writer.getResultBuffer();
+};
+
+
+/**
+ * Serializes the given message to binary data (in protobuf wire
+ * format), writing to the given BinaryWriter.
+ * @param {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} message
+ * @param {!jspb.BinaryWriter} writer
+ * @suppress {unusedLocalVariables} f is only used for nested messages
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.serializeBinaryToWriter = function(message, writer) {
+  var f = undefined;
+  f = message.getRelationTuple();
+  if (f != null) {
+    writer.writeMessage(
+      1,
+      f,
+      ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple.serializeBinaryToWriter
+    );
+  }
+  f = message.getId();
+  if (f.length > 0) {
+    writer.writeString(
+      2,
+      f
+    );
+  }
+  f = message.getCommitTime();
+  if (f != null) {
+    writer.writeMessage(
+      3,
+      f,
+      google_protobuf_timestamp_pb.Timestamp.serializeBinaryToWriter
+    );
+  }
+};
+
+
+/**
+ * optional RelationTuple relation_tuple = 1;
+ * @return {?proto.ory.keto.relation_tuples.v1alpha2.RelationTuple}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.getRelationTuple = function() {
+  return /** @type{?proto.ory.keto.relation_tuples.v1alpha2.RelationTuple} */ (
+    jspb.Message.getWrapperField(this, ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.RelationTuple, 1));
+};
+
+
+/**
+ * @param {?proto.ory.keto.relation_tuples.v1alpha2.RelationTuple|undefined} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} returns this
+*/
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.setRelationTuple = function(value) {
+  return jspb.Message.setWrapperField(this, 1, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.clearRelationTuple = function() {
+  return this.setRelationTuple(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.hasRelationTuple = function() {
+  return jspb.Message.getField(this, 1) != null;
+};
+
+
+/**
+ * optional string id = 2;
+ * @return {string}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.getId = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 2, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.setId = function(value) {
+  return jspb.Message.setProto3StringField(this, 2, value);
+};
+
+
+/**
+ * optional google.protobuf.Timestamp commit_time = 3;
+ * @return {?proto.google.protobuf.Timestamp}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.getCommitTime = function() {
+  return /** @type{?proto.google.protobuf.Timestamp} */ (
+    jspb.Message.getWrapperField(this, google_protobuf_timestamp_pb.Timestamp, 3));
+};
+
+
+/**
+ * @param {?proto.google.protobuf.Timestamp|undefined} value
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} returns this
+*/
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.setCommitTime = function(value) {
+  return jspb.Message.setWrapperField(this, 3, value);
+};
+
+
+/**
+ * Clears the message field making it undefined.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.clearCommitTime = function() {
+  return this.setCommitTime(undefined);
+};
+
+
+/**
+ * Returns whether this field is set.
+ * @return {boolean}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.StoredRelationTuple.prototype.hasCommitTime = function() {
+  return jspb.Message.getField(this, 3) != null;
+};
+
+
 
 
 
@@ -811,7 +1387,8 @@ proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.toObje
     namespace: jspb.Message.getFieldWithDefault(msg, 1, ""),
     object: jspb.Message.getFieldWithDefault(msg, 2, ""),
     relation: jspb.Message.getFieldWithDefault(msg, 3, ""),
-    subject: (f = msg.getSubject()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.toObject(includeInstance, f)
+    subject: (f = msg.getSubject()) && ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.toObject(includeInstance, f),
+    labelsMap: (f = msg.getLabelsMap()) ? f.toObject(includeInstance, undefined) : []
   };
 
   if (includeInstance) {
@@ -865,6 +1442,12 @@ proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.deseri
       reader.readMessage(value,ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.deserializeBinaryFromReader);
       msg.setSubject(value);
       break;
+    case 5:
+      var value = msg.getLabelsMap();
+      reader.readMessage(value, function(message, reader) {
+        jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
+         });
+      break;
     default:
       reader.skipField();
       break;
@@ -923,6 +1506,10 @@ proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.serial
       ory_keto_relation_tuples_v1alpha2_relation_tuples_pb.Subject.serializeBinaryToWriter
     );
   }
+  f = message.getLabelsMap(true);
+  if (f && f.getLength() > 0) {
+    f.serializeBinary(5, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
+  }
 };
 
 
@@ -1017,6 +1604,28 @@ proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.protot
 };
 
 
+/**
+ * map<string, string> labels = 5;
+ * @param {boolean=} opt_noLazyCreate Do not create the map if
+ * empty, instead returning `undefined`
+ * @return {!jspb.Map<string,string>}
+ */
+proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.prototype.getLabelsMap = function(opt_noLazyCreate) {
+  return /** @type {!jspb.Map<string,string>} */ (
+      jspb.Message.getMapField(this, 5, opt_noLazyCreate,
+      null));
+};
+
+
+/**
+ * Clears values from the map. The map will be non-null.
+ * @return {!proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query} returns this
+ */
+proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query.prototype.clearLabelsMap = function() {
+  this.getLabelsMap().clear();
+  return this;};
+
+
 /**
  * optional Query query = 1;
  * @return {?proto.ory.keto.relation_tuples.v1alpha2.DeleteRelationTuplesRequest.Query}